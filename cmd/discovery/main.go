@@ -13,8 +13,8 @@ import (
 	_ "github.com/joho/godotenv/autoload"
 	"github.com/kelseyhightower/envconfig"
 	"github.com/noelukwa/indexer/internal/events"
+	"github.com/noelukwa/indexer/internal/pkg/broker"
 	"github.com/noelukwa/indexer/internal/pkg/config"
-	amqp "github.com/rabbitmq/amqp091-go"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -64,6 +64,9 @@ func updateIntent(ctx context.Context, redisClient *redis.Client, key string, up
 	}
 
 	existingIntent.From = updatedIntent.From
+	existingIntent.Until = updatedIntent.Until
+	existingIntent.IntentOptions = updatedIntent.IntentOptions
+	existingIntent.Credential = updatedIntent.Credential
 
 	return storeNewIntent(ctx, redisClient, key, &existingIntent)
 }
@@ -97,21 +100,12 @@ func getAllIntents(ctx context.Context, redisClient *redis.Client) ([]*events.In
 	return intents, nil
 }
 
-func publishEvent(ctx context.Context, ch *amqp.Channel, queueName string, event *events.IntentCommand) error {
+func publishEvent(ctx context.Context, b broker.Broker, queueName string, event *events.IntentCommand) error {
 	body, err := json.Marshal(event)
 	if err != nil {
 		return err
 	}
-
-	return ch.PublishWithContext(ctx,
-		"",
-		queueName,
-		false,
-		false,
-		amqp.Publishing{
-			ContentType: "application/json",
-			Body:        body,
-		})
+	return b.Publish(ctx, queueName, body)
 }
 
 func main() {
@@ -121,62 +115,40 @@ func main() {
 		log.Fatal(err)
 	}
 
-	redisClient := redis.NewClient(&redis.Options{
-		Addr: config.RedisURL,
-	})
-	defer redisClient.Close()
-
-	conn, err := amqp.Dial(config.RabbitMQURL)
+	brokerTLS, err := config.BrokerTLSConfig(config.RabbitMQURL)
 	if err != nil {
-		log.Fatalf("Failed to connect to RabbitMQ: %v", err)
+		log.Fatalf("Invalid broker config: %v", err)
 	}
-	defer conn.Close()
-
-	ch, err := conn.Channel()
+	compression, err := config.Compression()
 	if err != nil {
-		log.Fatalf("Failed to open a channel: %v", err)
-	}
-	defer ch.Close()
-
-	cq, err := ch.QueueDeclare(
-		config.RabbitMQConsumeQueue,
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
+		log.Fatalf("Invalid broker config: %v", err)
+	}
+	redisOpts, err := config.RedisOptions(config.RedisURL)
 	if err != nil {
-		log.Fatalf("Failed to declare consumer queue: %v", err)
+		log.Fatalf("Invalid redis config: %v", err)
 	}
 
-	_, err = ch.QueueDeclare(
-		config.RabbitMQPublishQueue,
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
-	if err != nil {
-		log.Fatalf("Failed to declare publish queue: %v", err)
-	}
-
-	msgs, err := ch.Consume(
-		cq.Name,
-		"",
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
+	redisClient := redis.NewClient(redisOpts)
+	defer redisClient.Close()
+
+	b, err := broker.Open(config.RabbitMQURL, brokerTLS, compression)
 	if err != nil {
-		log.Fatalf("Failed to register a consumer: %v", err)
+		log.Fatalf("Failed to connect to the broker: %v", err)
+	}
+	defer b.Close()
+
+	if err := b.Declare(config.RabbitMQConsumeQueue, config.RabbitMQPublishQueue); err != nil {
+		log.Fatalf("Failed to declare queues: %v", err)
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
+
+	msgs, err := b.Consume(ctx, config.RabbitMQConsumeQueue)
+	if err != nil {
+		log.Fatalf("Failed to register a consumer: %v", err)
+	}
+
 	go func() {
 		for d := range msgs {
 			processMessage(ctx, redisClient, d.Body)
@@ -186,7 +158,7 @@ func main() {
 	ticker := time.NewTicker(config.BroadcastInterval)
 	go func() {
 		for range ticker.C {
-			broadcastIntents(ctx, ch, redisClient, config.RabbitMQPublishQueue)
+			broadcastIntents(ctx, b, redisClient, config.RabbitMQPublishQueue)
 		}
 	}()
 
@@ -215,7 +187,7 @@ func processMessage(ctx context.Context, redisClient *redis.Client, body []byte)
 	}
 }
 
-func broadcastIntents(ctx context.Context, ch *amqp.Channel, redisClient *redis.Client, publishQueue string) {
+func broadcastIntents(ctx context.Context, b broker.Broker, redisClient *redis.Client, publishQueue string) {
 	intents, err := getAllIntents(ctx, redisClient)
 	if err != nil {
 		log.Printf("Failed to get all intents: %v", err)
@@ -228,7 +200,7 @@ func broadcastIntents(ctx context.Context, ch *amqp.Channel, redisClient *redis.
 			Intent: intent,
 		}
 
-		if err := publishEvent(ctx, ch, publishQueue, event); err != nil {
+		if err := publishEvent(ctx, b, publishQueue, event); err != nil {
 			log.Printf("Failed to publish intent: %v", err)
 			continue
 		}
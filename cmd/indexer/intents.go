@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/noelukwa/indexer/pkg/client"
+)
+
+const intentsUsage = `usage: indexer intents <export|import> [flags]
+
+  export  write the intent definitions as JSON
+  import  recreate the intents of an export
+`
+
+func runIntents(args []string) error {
+	if len(args) < 1 {
+		fmt.Fprint(os.Stderr, intentsUsage)
+		os.Exit(2)
+	}
+
+	fs := flag.NewFlagSet("intents "+args[0], flag.ExitOnError)
+	url := fs.String("url", envOr("INDEXER_URL", "http://localhost:8009"), "manager API base URL")
+	token := fs.String("token", os.Getenv("INDEXER_TOKEN"), "API key or session token")
+	file := fs.String("f", "-", "file to write the export to or read it from, - for stdout or stdin")
+
+	switch args[0] {
+	case "export":
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		return exportIntents(client.New(*url, *token), *file)
+	case "import":
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		return importIntents(client.New(*url, *token), *file)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown intents command %q\n\n%s", args[0], intentsUsage)
+		os.Exit(2)
+	}
+	return nil
+}
+
+func exportIntents(api *client.Client, file string) error {
+	export, err := api.ExportIntents(context.Background())
+	if err != nil {
+		return err
+	}
+
+	out := io.Writer(os.Stdout)
+	if file != "-" {
+		f, err := os.Create(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(export); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "exported %d intents\n", len(export.Intents))
+	return nil
+}
+
+func importIntents(api *client.Client, file string) error {
+	in := io.Reader(os.Stdin)
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	var export client.IntentExport
+	if err := json.NewDecoder(in).Decode(&export); err != nil {
+		return fmt.Errorf("failed to read export: %w", err)
+	}
+
+	result, err := api.ImportIntents(context.Background(), &export)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("created %d intents, skipped %d\n", result.Created, len(result.Skipped))
+	for _, skip := range result.Skipped {
+		fmt.Printf("  %s: %s\n", skip.Repository, skip.Reason)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+const usage = `usage: indexer <command> [flags]
+
+commands:
+  top      live view of intent statuses, ingestion rate and recent errors
+  loadgen  publish generated commits to the manager to test throughput
+  intents  export the intent definitions, or import an export
+
+Run "indexer <command> -h" for the flags of a command.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "top":
+		err = runTop(os.Args[2:])
+	case "loadgen":
+		err = runLoadgen(os.Args[2:])
+	case "intents":
+		err = runIntents(os.Args[2:])
+	case "-h", "--help", "help":
+		fmt.Print(usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "indexer %s: %v\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/noelukwa/indexer/internal/manager/models"
+	"github.com/noelukwa/indexer/pkg/client"
+)
+
+const (
+	clearScreen = "\033[H\033[2J"
+	hideCursor  = "\033[?25l"
+	showCursor  = "\033[?25h"
+
+	topIntentRows = 100
+)
+
+type topSnapshot struct {
+	at      time.Time
+	status  *client.IngestionStatus
+	intents *client.Page[client.Intent]
+	err     error
+}
+
+func runTop(args []string) error {
+	fs := flag.NewFlagSet("top", flag.ExitOnError)
+	url := fs.String("url", envOr("INDEXER_URL", "http://localhost:8009"), "manager API base URL")
+	token := fs.String("token", os.Getenv("INDEXER_TOKEN"), "API key or session token")
+	interval := fs.Duration("interval", 2*time.Second, "refresh interval")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	api := client.New(*url, *token)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Print(hideCursor)
+	defer fmt.Print(showCursor)
+
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+
+	var prev *topSnapshot
+	for {
+		snap := poll(ctx, api)
+		fmt.Print(clearScreen)
+		os.Stdout.Write(renderTop(*url, *interval, snap, prev))
+		if snap.err == nil {
+			prev = snap
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func poll(ctx context.Context, api *client.Client) *topSnapshot {
+	snap := &topSnapshot{at: time.Now()}
+	snap.status, snap.err = api.Status(ctx)
+	if snap.err != nil {
+		return snap
+	}
+	snap.intents, snap.err = api.ListIntents(ctx, client.IntentsQuery{PerPage: topIntentRows}, 1)
+	return snap
+}
+
+func renderTop(url string, interval time.Duration, snap, prev *topSnapshot) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "indexer top - %s - %s (every %s, ctrl+c to quit)\n\n", url, snap.at.Format(time.TimeOnly), interval)
+
+	if snap.err != nil {
+		fmt.Fprintf(&buf, "error: %v\n", snap.err)
+		return buf.Bytes()
+	}
+
+	statuses := make([]string, 0, len(snap.status.Intents))
+	for status := range snap.status.Intents {
+		statuses = append(statuses, string(status))
+	}
+	sort.Strings(statuses)
+
+	fmt.Fprint(&buf, "INTENTS ")
+	for _, status := range statuses {
+		fmt.Fprintf(&buf, "  %s: %d", status, snap.status.Intents[models.IntentStatus(status)])
+	}
+	fmt.Fprintf(&buf, "\nCOMMITS   total: %d  rate: %s\n\n", snap.status.TotalCommits, ingestionRate(snap, prev))
+
+	tw := tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "REPOSITORY\tSTATUS\tACTIVE\tSINCE")
+	for _, intent := range snap.intents.Data {
+		active := "no"
+		if intent.IsActive {
+			active = "yes"
+		}
+		since := "all"
+		if intent.StartDate != nil {
+			since = intent.StartDate.Format(time.DateOnly)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", intent.RepositoryName, intent.Status, active, since)
+	}
+	tw.Flush()
+	if snap.intents.TotalCount > int64(len(snap.intents.Data)) {
+		fmt.Fprintf(&buf, "... %d more\n", snap.intents.TotalCount-int64(len(snap.intents.Data)))
+	}
+
+	fmt.Fprint(&buf, "\nRECENT ERRORS\n")
+	if len(snap.status.RecentErrors) == 0 {
+		fmt.Fprint(&buf, "none\n")
+	}
+	tw = tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+	for _, e := range snap.status.RecentErrors {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", e.CreatedAt.Local().Format(time.DateTime), e.RepositoryName, e.Message)
+	}
+	tw.Flush()
+
+	return buf.Bytes()
+}
+
+func ingestionRate(snap, prev *topSnapshot) string {
+	if prev == nil {
+		return "-"
+	}
+	elapsed := snap.at.Sub(prev.at).Seconds()
+	if elapsed <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f commits/s", float64(snap.status.TotalCommits-prev.status.TotalCommits)/elapsed)
+}
@@ -2,9 +2,7 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"log"
-	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
@@ -15,18 +13,26 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/noelukwa/indexer/internal/manager"
 	"github.com/noelukwa/indexer/internal/manager/api"
+	"github.com/noelukwa/indexer/internal/manager/api/grpcapi"
+	"github.com/noelukwa/indexer/internal/manager/repository"
 	"github.com/noelukwa/indexer/internal/manager/repository/postgres"
+	"github.com/noelukwa/indexer/internal/pkg/apilimits"
+	"github.com/noelukwa/indexer/internal/pkg/broker"
 	"github.com/noelukwa/indexer/internal/pkg/config"
-	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/noelukwa/indexer/internal/pkg/mailer"
+	"github.com/noelukwa/indexer/internal/pkg/objectstore"
+	"github.com/noelukwa/indexer/internal/pkg/querycache"
+	"github.com/noelukwa/indexer/internal/pkg/ratelimits"
+	"github.com/noelukwa/indexer/internal/pkg/remotewrite"
+	"github.com/noelukwa/indexer/internal/pkg/repolocks"
+	"github.com/redis/go-redis/v9"
 )
 
-//	@title          Manager API
-//	@version        1.0
-//	@description    Manager Rest API server
-//	@license.name   MIT License
-//
-// @host       127.0.0.1:8009
-// @basePath   /v1
+// @title          Manager API
+// @version        1.0
+// @description    Manager Rest API server
+// @license.name   MIT License
+// @BasePath       /
 func main() {
 	var cfg config.ManagerConfig
 	err := envconfig.Process("manager_service", &cfg)
@@ -34,90 +40,142 @@ func main() {
 		log.Fatalf("Error loading configuration: %v", err)
 	}
 
-	conn, err := amqp.Dial(cfg.RabbitMQURL)
+	brokerTLS, err := cfg.BrokerTLSConfig(cfg.RabbitMQURL)
 	if err != nil {
-		log.Fatalf("Failed to connect to RabbitMQ: %v", err)
+		log.Fatalf("Invalid broker config: %v", err)
 	}
-	defer conn.Close()
-
-	ch, err := conn.Channel()
+	compression, err := cfg.Compression()
 	if err != nil {
-		log.Fatalf("Failed to open a channel: %v", err)
-	}
-	defer ch.Close()
-
-	_, err = ch.QueueDeclare(
-		cfg.IntentsQueueName,
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
+		log.Fatalf("Invalid broker config: %v", err)
+	}
+	redisOpts, err := cfg.RedisOptions(cfg.RedisAddr)
 	if err != nil {
-		log.Fatalf("Failed to declare consumer queue: %v", err)
+		log.Fatalf("Invalid redis config: %v", err)
 	}
 
-	cq, err := ch.QueueDeclare(
-		cfg.CommitsQueueName,
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
-	if err != nil {
-		log.Fatalf("Failed to declare publish queue: %v", err)
-	}
-
-	msgs, err := ch.Consume(
-		cq.Name,
-		"",
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
+	b, err := broker.Open(cfg.RabbitMQURL, brokerTLS, compression)
 	if err != nil {
-		log.Fatalf("Failed to register a consumer: %v", err)
+		log.Fatalf("Failed to connect to the broker: %v", err)
+	}
+	defer b.Close()
+
+	if err := b.Declare(cfg.IntentsQueueName, cfg.MonitorQueueName, cfg.CommitsQueueName); err != nil {
+		log.Fatalf("Failed to declare queues: %v", err)
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Batches are acked once saved, so while ingestion is throttled the
+	// backlog stays on the broker instead of piling up in memory.
+	msgs, err := b.ConsumeAcked(ctx, cfg.CommitsQueueName, max(cfg.IngestPrefetch, 1))
+	if err != nil {
+		log.Fatalf("Failed to register a consumer: %v", err)
+	}
+
 	dataStore, err := postgres.NewManagerStore(ctx, cfg.DatabaseURL)
 	if err != nil {
 		log.Fatalf("Failed to establish DB connection: %v", err)
 	}
 
-	service := manager.NewService(dataStore, &cfg)
+	var rateLimits manager.RateLimitSource
+	var locks manager.LockSource
+	var cache manager.ResultCache
+	var apiLimiter manager.APILimiter = apilimits.NewMemoryStore()
+	if cfg.RedisAddr != "" {
+		redisClient := redis.NewClient(redisOpts)
+		defer redisClient.Close()
+		rateLimits = ratelimits.NewStore(redisClient)
+		locks = repolocks.NewStore(redisClient)
+		if cfg.QueryCacheTTL > 0 {
+			cache = querycache.New(redisClient, cfg.QueryCacheTTL)
+		}
+		apiLimiter = apilimits.NewStore(redisClient)
+	} else if cfg.APIRateLimitEnabled() {
+		log.Printf("API rate limits are kept per replica without Redis")
+	}
 
-	e := echo.New()
-	handler := api.SetupRoutes(service, e)
+	service := manager.NewService(dataStore, rateLimits, locks, cache, &cfg)
+	hooks, err := manager.CommitHooks(cfg.CommitHooks)
+	if err != nil {
+		log.Fatalf("Invalid commit hooks: %v", err)
+	}
+	service.UseCommitHooks(hooks...)
+	service.UseAPILimiter(apiLimiter)
+	if cfg.DigestsEnabled() {
+		m, err := mailer.New(cfg.SMTPAddr, cfg.SMTPUsername, cfg.SMTPPassword, cfg.DigestFrom)
+		if err != nil {
+			log.Fatalf("Invalid SMTP config: %v", err)
+		}
+		service.UseMailer(m)
+	}
+	if cfg.RemoteWriteEnabled() {
+		service.UseMetricsWriter(remotewrite.New(cfg.RemoteWriteURL, cfg.RemoteWriteUsername, cfg.RemoteWritePassword))
+	}
+	// The archive store is opened even while archiving is off, so purges
+	// still delete the objects archived before.
+	if cfg.ArchiveURL != "" {
+		store, err := objectstore.Open(cfg.ArchiveURL, objectstore.S3Options{
+			Endpoint:        cfg.ArchiveS3Endpoint,
+			Region:          cfg.ArchiveS3Region,
+			AccessKeyID:     cfg.ArchiveS3AccessKeyID,
+			SecretAccessKey: cfg.ArchiveS3SecretAccessKey,
+		})
+		if err != nil {
+			log.Fatalf("Invalid archive config: %v", err)
+		}
+		service.UseArchiveStore(store)
+	}
 
-	httpServer := &http.Server{
-		Addr:    fmt.Sprintf(":%d", cfg.ServerPort),
-		Handler: handler,
+	if cfg.AuthEnabled() && !cfg.OAuthEnabled() && cfg.AdminAPIKey == "" {
+		log.Printf("Auth is enabled without GitHub login or an admin API key, so only stored API keys are accepted")
 	}
 
-	go func() {
-		log.Printf("server listening on %d", cfg.ServerPort)
-		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("HTTP server ListenAndServe: %v", err)
-		}
-	}()
+	e := echo.New()
+	handler := api.SetupRoutes(service, &cfg, e)
+
+	srv, err := newServers(&cfg, handler)
+	if err != nil {
+		log.Fatalf("Invalid server config: %v", err)
+	}
+	if cfg.GRPCEnabled() {
+		srv.grpc = grpcapi.NewServer(service, &cfg, srv.grpcOptions()...)
+	}
+	srv.serve()
 
 	go func() {
 		for d := range msgs {
-			if err := service.ProcessCommitCommands(ctx, d.Body); err != nil {
+			err := service.ProcessCommitCommands(ctx, d.Body)
+			if err != nil {
 				log.Printf("Error processing commit: %v", err)
 			}
+			settle(ctx, d, err)
 		}
 	}()
 
+	go service.RefreshLeaderboards(ctx)
+	go service.RunRollups(ctx)
+	go service.PurgeRepositories(ctx)
+	if cfg.OAuthEnabled() {
+		go service.PurgeSessions(ctx)
+	}
+	if cfg.DigestsEnabled() {
+		go service.SendDigests(ctx)
+	}
+	if cfg.RemoteWriteEnabled() {
+		go service.PushCommitMetrics(ctx)
+	}
+	if cfg.ArchiveEnabled() {
+		go service.ArchiveColdCommits(ctx)
+	}
+
+	if listener, ok := dataStore.(repository.CommitsListener); ok {
+		go service.FollowCommits(ctx, listener)
+	}
+	go service.FollowIntentEvents(ctx)
+
 	go func() {
-		if err := service.StartBroadCast(ctx, ch); err != nil {
+		if err := service.StartBroadCast(ctx, b); err != nil {
 			log.Printf("Error broadcasting: %v", err)
 		}
 	}()
@@ -128,19 +186,39 @@ func main() {
 
 	log.Println("Shutting down server...")
 
-	if err := ch.Close(); err != nil {
-		log.Printf("Error closing RabbitMQ channel: %v", err)
-	}
-	if err := conn.Close(); err != nil {
-		log.Printf("Error closing RabbitMQ connection: %v", err)
+	if err := b.Close(); err != nil {
+		log.Printf("Error closing the broker: %v", err)
 	}
 
 	ctxShutdown, cancelShutdown := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancelShutdown()
 
-	if err := httpServer.Shutdown(ctxShutdown); err != nil {
+	if srv.redirect != nil {
+		if err := srv.redirect.Shutdown(ctxShutdown); err != nil {
+			log.Printf("HTTP redirect server Shutdown: %v", err)
+		}
+	}
+	if srv.grpc != nil {
+		stopGRPC(ctxShutdown, srv.grpc)
+	}
+	if err := srv.api.Shutdown(ctxShutdown); err != nil {
 		log.Fatalf("HTTP server Shutdown: %v", err)
 	}
 
 	log.Println("Server exiting")
 }
+
+// settle acks a commits message once it has been handled, or requeues it
+// when shutdown interrupted it, such as while it waited for the ingestion
+// throttle.
+func settle(ctx context.Context, d broker.Delivery, err error) {
+	if err != nil && ctx.Err() != nil {
+		if err := d.Nack(true); err != nil {
+			log.Printf("Failed to requeue commits: %v", err)
+		}
+		return
+	}
+	if err := d.Ack(); err != nil {
+		log.Printf("Failed to ack commits: %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/noelukwa/indexer/internal/pkg/config"
+	"golang.org/x/crypto/acme/autocert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// servers are the API server, the gRPC server when it is enabled and,
+// when HTTPS redirects are on, the plain HTTP server redirecting to the
+// API.
+type servers struct {
+	api      *http.Server
+	redirect *http.Server
+	grpc     *grpc.Server
+	grpcAddr string
+	tls      bool
+}
+
+func newServers(cfg *config.ManagerConfig, handler http.Handler) (*servers, error) {
+	s := &servers{
+		api: &http.Server{
+			Addr:              fmt.Sprintf(":%d", cfg.ServerPort),
+			Handler:           handler,
+			ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+			ReadTimeout:       cfg.ReadTimeout,
+			WriteTimeout:      cfg.WriteTimeout,
+			IdleTimeout:       cfg.IdleTimeout,
+		},
+		grpcAddr: fmt.Sprintf(":%d", cfg.GRPCPort),
+		tls:      cfg.TLSEnabled(),
+	}
+
+	if !s.tls {
+		if cfg.HTTPRedirectPort != 0 {
+			return nil, errors.New("HTTPS redirect needs TLS: set a certificate or autocert domains")
+		}
+		return s, nil
+	}
+
+	redirect := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		if cfg.ServerPort != 443 {
+			host = net.JoinHostPort(host, strconv.Itoa(cfg.ServerPort))
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	}))
+
+	switch {
+	case len(cfg.AutocertDomains) > 0:
+		if cfg.TLSCertFile != "" || cfg.TLSKeyFile != "" {
+			return nil, errors.New("set either a TLS certificate or autocert domains, not both")
+		}
+		m := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(cfg.AutocertDomains...),
+			Cache:      autocert.DirCache(cfg.AutocertCacheDir),
+			Email:      cfg.AutocertEmail,
+		}
+		s.api.TLSConfig = m.TLSConfig()
+		redirect = m.HTTPHandler(redirect)
+	case cfg.TLSCertFile == "" || cfg.TLSKeyFile == "":
+		return nil, errors.New("TLS certificate and key must be set together")
+	default:
+		// Fail at startup rather than on the first handshake.
+		cert, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+		}
+		s.api.TLSConfig = &tls.Config{
+			MinVersion:   tls.VersionTLS12,
+			Certificates: []tls.Certificate{cert},
+		}
+	}
+
+	if cfg.HTTPRedirectPort != 0 {
+		s.redirect = &http.Server{
+			Addr:              fmt.Sprintf(":%d", cfg.HTTPRedirectPort),
+			Handler:           redirect,
+			ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+			ReadTimeout:       cfg.ReadTimeout,
+			WriteTimeout:      cfg.WriteTimeout,
+			IdleTimeout:       cfg.IdleTimeout,
+		}
+	}
+	return s, nil
+}
+
+// grpcOptions serves gRPC with the API's certificate when the API is
+// served over HTTPS.
+func (s *servers) grpcOptions() []grpc.ServerOption {
+	if !s.tls {
+		return nil
+	}
+	return []grpc.ServerOption{grpc.Creds(credentials.NewTLS(s.api.TLSConfig))}
+}
+
+// serve starts the servers, exiting the process if one fails.
+func (s *servers) serve() {
+	if s.grpc != nil {
+		lis, err := net.Listen("tcp", s.grpcAddr)
+		if err != nil {
+			log.Fatalf("gRPC server Listen: %v", err)
+		}
+		go func() {
+			log.Printf("gRPC server listening on %s", s.grpcAddr)
+			if err := s.grpc.Serve(lis); err != nil {
+				log.Fatalf("gRPC server Serve: %v", err)
+			}
+		}()
+	}
+
+	if s.redirect != nil {
+		go func() {
+			log.Printf("redirecting HTTP on %s to HTTPS", s.redirect.Addr)
+			if err := s.redirect.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Fatalf("HTTP redirect server ListenAndServe: %v", err)
+			}
+		}()
+	}
+
+	go func() {
+		var err error
+		if s.tls {
+			log.Printf("server listening on %s (HTTPS)", s.api.Addr)
+			err = s.api.ListenAndServeTLS("", "")
+		} else {
+			log.Printf("server listening on %s", s.api.Addr)
+			err = s.api.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			log.Fatalf("HTTP server ListenAndServe: %v", err)
+		}
+	}()
+}
+
+// stopGRPC lets the gRPC server finish its calls until ctx is done, then
+// closes the ones still open, such as long commit streams.
+func stopGRPC(ctx context.Context, s *grpc.Server) {
+	done := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		s.Stop()
+	}
+}
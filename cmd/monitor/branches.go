@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v63/github"
+	"github.com/noelukwa/indexer/internal/events"
+)
+
+// commitSet remembers the commits already emitted for a repository so that
+// history matched by more than one query is only published once. A positive
+// limit caps how many commits it accepts in total, in the order they are
+// added, so runs with several queries add their merged commits newest
+// first (see fetchNewestCommits).
+type commitSet struct {
+	mu    sync.Mutex
+	seen  map[string]struct{}
+	limit int
+	// indexed holds commits an earlier run already sent, which are
+	// skipped without counting toward the limit.
+	indexed map[string]struct{}
+	// pages counts the commit pages fetched, and oldest is the date of the
+	// oldest commit accepted. GitHub lists commits newest first, so it
+	// moves back toward the start of the run's date range.
+	pages  int64
+	oldest time.Time
+}
+
+func newCommitSet(limit int) *commitSet {
+	return &commitSet{seen: make(map[string]struct{}), limit: limit, indexed: make(map[string]struct{})}
+}
+
+// skip marks sha as sent by an earlier run.
+func (s *commitSet) skip(sha string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.indexed[sha] = struct{}{}
+}
+
+// add reports whether sha was not seen before and fits within the limit.
+func (s *commitSet) add(sha string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.seen[sha]; ok || s.fullLocked() {
+		return false
+	}
+	if _, ok := s.indexed[sha]; ok {
+		return false
+	}
+	s.seen[sha] = struct{}{}
+	return true
+}
+
+// full reports whether the limit has been reached.
+func (s *commitSet) full() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fullLocked()
+}
+
+// count returns how many commits have been accepted.
+func (s *commitSet) count() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return int64(len(s.seen))
+}
+
+// fetchedPage counts a page of commits fetched.
+func (s *commitSet) fetchedPage() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pages++
+}
+
+// reached records the date of an accepted commit.
+func (s *commitSet) reached(date time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !date.IsZero() && (s.oldest.IsZero() || date.Before(s.oldest)) {
+		s.oldest = date
+	}
+}
+
+// progress returns the pages fetched and the oldest commit date reached,
+// zero before any commit.
+func (s *commitSet) progress() (int64, time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pages, s.oldest
+}
+
+func (s *commitSet) fullLocked() bool {
+	return s.limit > 0 && len(s.seen) >= s.limit
+}
+
+func listBranches(ctx context.Context, client *github.Client, gate *fetchGate, ev *events.IntentPayload) ([]string, error) {
+	opts := &github.BranchListOptions{
+		ListOptions: github.ListOptions{
+			PerPage: 100,
+		},
+	}
+
+	var names []string
+	for {
+		var branches []*github.Branch
+		var resp *github.Response
+		err := gate.call(ctx, func() error {
+			var err error
+			branches, resp, err = client.Repositories.ListBranches(ctx, ev.RepoOwner, ev.RepoName, opts)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list branches: %w", err)
+		}
+
+		for _, branch := range branches {
+			names = append(names, branch.GetName())
+		}
+
+		if resp.NextPage == 0 {
+			return names, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
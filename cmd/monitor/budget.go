@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v63/github"
+)
+
+// secondaryPause is how long requests wait after a secondary rate limit
+// that didn't say for how long, as GitHub asks.
+const secondaryPause = time.Minute
+
+// rateBudget follows a token's core quota from the headers of the
+// responses to its requests, so fetches can pause before the quota runs
+// out instead of failing once it has. A secondary rate limit pauses them
+// for as long as GitHub asks.
+type rateBudget struct {
+	label   string
+	reserve int
+
+	mu        sync.Mutex
+	limit     int
+	remaining int
+	reset     time.Time
+	retryAt   time.Time
+	updatedAt time.Time
+	announced time.Time
+	revoked   bool
+}
+
+// quotaWaiter holds requests back while the quota they are made with is
+// paused.
+type quotaWaiter interface {
+	wait(ctx context.Context) error
+}
+
+// budgetStatus is a budget as the debug endpoint reports it.
+type budgetStatus struct {
+	Token       string     `json:"token"`
+	Limit       int        `json:"limit"`
+	Remaining   int        `json:"remaining"`
+	Reserve     int        `json:"reserve"`
+	Reset       time.Time  `json:"reset"`
+	PausedUntil *time.Time `json:"paused_until,omitempty"`
+	Revoked     bool       `json:"revoked,omitempty"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// observe records the quota reported by resp.
+func (b *rateBudget) observe(resp *http.Response) {
+	now := time.Now()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if resource := resp.Header.Get("X-RateLimit-Resource"); resource == "" || resource == "core" {
+		limit, errLimit := strconv.Atoi(resp.Header.Get("X-RateLimit-Limit"))
+		remaining, errRemaining := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+		reset, errReset := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+		if errLimit == nil && errRemaining == nil && errReset == nil {
+			b.limit, b.remaining, b.reset = limit, remaining, time.Unix(reset, 0)
+			b.updatedAt = now
+		}
+	}
+
+	// A permission error is also a 403, so only one saying when to retry
+	// is taken for a secondary rate limit.
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		b.retryAt = now.Add(time.Duration(seconds) * time.Second)
+	} else if resp.StatusCode == http.StatusTooManyRequests {
+		b.retryAt = now.Add(secondaryPause)
+	}
+}
+
+// pausedUntil returns when requests may resume, or zero when they needn't
+// wait.
+func (b *rateBudget) pausedUntil(now time.Time) time.Time {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.pausedUntilLocked(now)
+}
+
+func (b *rateBudget) pausedUntilLocked(now time.Time) time.Time {
+	if b.retryAt.After(now) {
+		return b.retryAt
+	}
+	if b.limit > 0 && b.remaining <= b.reserve && b.reset.After(now) {
+		return b.reset
+	}
+	return time.Time{}
+}
+
+// wait blocks while the budget is paused. A nil budget never pauses.
+func (b *rateBudget) wait(ctx context.Context) error {
+	if b == nil {
+		return nil
+	}
+	for {
+		until := b.pausedUntil(time.Now())
+		if until.IsZero() {
+			return nil
+		}
+		b.announce(until)
+
+		timer := time.NewTimer(time.Until(until))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// revoke records that GitHub no longer accepts the token.
+func (b *rateBudget) revoke() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.revoked = true
+}
+
+func (b *rateBudget) isRevoked() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.revoked
+}
+
+// announce logs a pause once, however many requests wait it out.
+func (b *rateBudget) announce(until time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.announced.Equal(until) {
+		return
+	}
+	b.announced = until
+	log.Printf("Pausing GitHub requests with token %s until %s (%d of %d left)", b.label, until.Format(time.RFC3339), b.remaining, b.limit)
+}
+
+func (b *rateBudget) status() budgetStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	status := budgetStatus{
+		Token:     b.label,
+		Limit:     b.limit,
+		Remaining: b.remaining,
+		Reserve:   b.reserve,
+		Reset:     b.reset,
+		Revoked:   b.revoked,
+		UpdatedAt: b.updatedAt,
+	}
+	if until := b.pausedUntilLocked(time.Now()); !until.IsZero() {
+		status.PausedUntil = &until
+	}
+	return status
+}
+
+// budgetTransport records the quota of every response in budget.
+type budgetTransport struct {
+	base   http.RoundTripper
+	budget *rateBudget
+}
+
+func (t *budgetTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err == nil {
+		t.budget.observe(resp)
+	}
+	return resp, err
+}
+
+// budgetOf returns what client's requests wait on: its token's budget, or
+// its pool of tokens. It is nil for a client without either.
+func budgetOf(client *github.Client) quotaWaiter {
+	return transportBudget(client.Client().Transport)
+}
+
+func transportBudget(transport http.RoundTripper) quotaWaiter {
+	switch transport := transport.(type) {
+	case *budgetTransport:
+		return transport.budget
+	case *tokenPool:
+		return transport
+	case *etagTransport:
+		return transportBudget(transport.base)
+	}
+	return nil
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/noelukwa/indexer/internal/events"
+	"github.com/redis/go-redis/v9"
+)
+
+// Full-history backfills record the next page to fetch so that a restarted
+// monitor resumes where it stopped. New commits only push older ones onto
+// later pages, so resuming from a saved page may refetch but never skips.
+// A reindex run keeps checkpoints of its own.
+func checkpointKey(ev *events.IntentPayload, query commitQuery) string {
+	id := ev.ID.String()
+	if ev.ReindexID != nil {
+		id += ":" + ev.ReindexID.String()
+	}
+	return fmt.Sprintf("checkpoint:%s:%s|%s|%s", id, query.branch, query.path, query.author)
+}
+
+// Once a run has listed every commit of a query, the newest of them is
+// kept as the query's cursor, and the intent's later runs list only the
+// commits since. The key carries the intent's start date, so an intent
+// whose range moves back is fetched again from its new start.
+func cursorKey(ev *events.IntentPayload, query commitQuery) string {
+	return fmt.Sprintf("cursor:%s:%s:%s|%s|%s", ev.ID, ev.From.UTC().Format(time.RFC3339), query.branch, query.path, query.author)
+}
+
+// syncCursor is the newest commit a query has listed, by commit date, and
+// Recent the others it listed that were committed within the overlap
+// before it. A commit pushed after a run can carry an older commit date,
+// as the commits of a merged branch do, so the next run lists the overlap
+// again and skips only the commits it already sent.
+type syncCursor struct {
+	SHA    string    `json:"sha"`
+	At     time.Time `json:"at"`
+	Recent []string  `json:"recent,omitempty"`
+}
+
+// newer returns whichever of c and other was committed later.
+func (c syncCursor) newer(other syncCursor) syncCursor {
+	if other.At.After(c.At) {
+		return other
+	}
+	return c
+}
+
+// cursorWalk builds a query's next cursor from the commits a run lists.
+// Pages may be listed concurrently. A nil walk ignores them.
+type cursorWalk struct {
+	overlap time.Duration
+
+	mu     sync.Mutex
+	newest syncCursor
+	recent map[string]time.Time
+}
+
+func newCursorWalk(overlap time.Duration) *cursorWalk {
+	return &cursorWalk{overlap: overlap, recent: make(map[string]time.Time)}
+}
+
+// list records a listed commit. Only those within the overlap of the
+// newest listed so far are kept.
+func (w *cursorWalk) list(sha string, at time.Time) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.newest = w.newest.newer(syncCursor{SHA: sha, At: at})
+	if !at.Before(w.newest.At.Add(-w.overlap)) {
+		w.recent[sha] = at
+	}
+}
+
+// cursor returns the newest commit listed with the others in its overlap,
+// or previous when the walk listed none.
+func (w *cursorWalk) cursor(previous syncCursor) syncCursor {
+	if w == nil {
+		return previous
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.newest.SHA == "" {
+		return previous
+	}
+	cursor := syncCursor{SHA: w.newest.SHA, At: w.newest.At}
+	from := cursor.At.Add(-w.overlap)
+	for sha, at := range w.recent {
+		if sha != cursor.SHA && !at.Before(from) {
+			cursor.Recent = append(cursor.Recent, sha)
+		}
+	}
+	sort.Strings(cursor.Recent)
+	return cursor
+}
+
+// checkpointStore keeps the checkpoints of backfills for checkpointTTL,
+// and the cursors of queries for cursorTTL. Saving and clearing only log
+// their failures, as a lost checkpoint or cursor costs a refetch at worst.
+type checkpointStore interface {
+	load(key string) (int, error)
+	save(key string, page int)
+	clear(key string)
+	loadCursor(key string) (syncCursor, error)
+	saveCursor(key string, cursor syncCursor)
+}
+
+// redisCheckpoints keeps checkpoints in Redis, where they survive
+// restarts and are shared with the other monitors.
+type redisCheckpoints struct {
+	client *redis.Client
+}
+
+func (c redisCheckpoints) load(key string) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	page, err := c.client.Get(ctx, key).Int()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to load checkpoint: %w", err)
+	}
+	return page, nil
+}
+
+func (c redisCheckpoints) save(key string, page int) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.client.Set(ctx, key, page, checkpointTTL).Err(); err != nil {
+		log.Printf("Failed to save checkpoint for %s: %v", key, err)
+	}
+}
+
+func (c redisCheckpoints) clear(key string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.client.Del(ctx, key).Err(); err != nil {
+		log.Printf("Failed to clear checkpoint for %s: %v", key, err)
+	}
+}
+
+func (c redisCheckpoints) loadCursor(key string) (syncCursor, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	raw, err := c.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return syncCursor{}, nil
+	}
+	if err != nil {
+		return syncCursor{}, fmt.Errorf("failed to load cursor: %w", err)
+	}
+	var cursor syncCursor
+	if err := json.Unmarshal(raw, &cursor); err != nil {
+		return syncCursor{}, fmt.Errorf("failed to decode cursor: %w", err)
+	}
+	return cursor, nil
+}
+
+func (c redisCheckpoints) saveCursor(key string, cursor syncCursor) {
+	raw, err := json.Marshal(cursor)
+	if err != nil {
+		log.Printf("Failed to encode cursor for %s: %v", key, err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.client.Set(ctx, key, raw, cursorTTL).Err(); err != nil {
+		log.Printf("Failed to save cursor for %s: %v", key, err)
+	}
+}
+
+// memoryCheckpoints keeps checkpoints in process, for a monitor running
+// without Redis. A redelivered intent resumes from them, but a restarted
+// monitor starts its backfills over.
+type memoryCheckpoints struct {
+	mu      sync.Mutex
+	pages   map[string]memoryCheckpoint
+	cursors map[string]memoryCursor
+}
+
+type memoryCheckpoint struct {
+	page      int
+	expiresAt time.Time
+}
+
+type memoryCursor struct {
+	cursor    syncCursor
+	expiresAt time.Time
+}
+
+func newMemoryCheckpoints() *memoryCheckpoints {
+	return &memoryCheckpoints{
+		pages:   make(map[string]memoryCheckpoint),
+		cursors: make(map[string]memoryCursor),
+	}
+}
+
+func (c *memoryCheckpoints) load(key string) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	checkpoint, ok := c.pages[key]
+	if !ok || time.Now().After(checkpoint.expiresAt) {
+		return 0, nil
+	}
+	return checkpoint.page, nil
+}
+
+func (c *memoryCheckpoints) save(key string, page int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	for k, checkpoint := range c.pages {
+		if now.After(checkpoint.expiresAt) {
+			delete(c.pages, k)
+		}
+	}
+	c.pages[key] = memoryCheckpoint{page: page, expiresAt: now.Add(checkpointTTL)}
+}
+
+func (c *memoryCheckpoints) clear(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.pages, key)
+}
+
+func (c *memoryCheckpoints) loadCursor(key string) (syncCursor, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	saved, ok := c.cursors[key]
+	if !ok || time.Now().After(saved.expiresAt) {
+		return syncCursor{}, nil
+	}
+	return saved.cursor, nil
+}
+
+func (c *memoryCheckpoints) saveCursor(key string, cursor syncCursor) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	for k, saved := range c.cursors {
+		if now.After(saved.expiresAt) {
+			delete(c.cursors, k)
+		}
+	}
+	c.cursors[key] = memoryCursor{cursor: cursor, expiresAt: now.Add(cursorTTL)}
+}
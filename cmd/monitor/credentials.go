@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/go-github/v63/github"
+	"github.com/noelukwa/indexer/internal/events"
+	"github.com/noelukwa/indexer/internal/pkg/config"
+	"github.com/noelukwa/indexer/internal/pkg/secrets"
+	"golang.org/x/oauth2"
+)
+
+// intentClient returns the GitHub client to index ev with: the monitor's own
+// unless the intent carries a sealed credential, which box opens. The
+// credential's requests are recorded in its budget from reporter, and share
+// the monitor's ETag cache.
+func intentClient(ctx context.Context, client *github.Client, box *secrets.Box, reporter *rateLimitReporter, ev *events.IntentPayload) (*github.Client, error) {
+	if len(ev.Credential) == 0 {
+		return client, nil
+	}
+	if box == nil {
+		return nil, fmt.Errorf("intent %s has a credential but no credentials key is configured", ev.ID)
+	}
+
+	token, err := box.Open(ev.Credential)
+	if err != nil {
+		return nil, fmt.Errorf("intent %s: %w", ev.ID, err)
+	}
+
+	intentClient, err := newGitHubClient(ctx, string(token), "", reporter.budget(tokenLabel(ev)))
+	if err != nil {
+		return nil, err
+	}
+	intentClient.BaseURL = client.BaseURL
+	if cache, ok := client.Client().Transport.(*etagTransport); ok {
+		intentClient = cache.wrap(intentClient)
+	}
+	return intentClient, nil
+}
+
+// monitorClient returns the client for the monitor's own tokens, which
+// takes turns with them when there are several, or for its GitHub App
+// installation when one is configured. Each token's quota is reported for
+// as long as the monitor runs, a pool's labelled by their position in it.
+func monitorClient(ctx context.Context, cfg *config.MonitorConfig, reporter *rateLimitReporter) (*github.Client, error) {
+	baseURL := cfg.GitHubBaseURL
+	tokens := cfg.GitHubTokens()
+	if cfg.GitHubAppID != 0 || len(tokens) == 1 {
+		newClient := func() (*github.Client, error) {
+			if cfg.GitHubAppID != 0 {
+				return newAppClient(ctx, cfg, reporter.budget(defaultTokenLabel))
+			}
+			return newGitHubClient(ctx, tokens[0], baseURL, reporter.budget(defaultTokenLabel))
+		}
+		client, err := newClient()
+		if err != nil {
+			return nil, err
+		}
+		reporter.track(defaultTokenLabel, client)
+		return client, nil
+	}
+	if len(tokens) == 0 {
+		return nil, errors.New("no GitHub token or GitHub App configured")
+	}
+
+	budgets := make([]*rateBudget, len(tokens))
+	for i, token := range tokens {
+		label := fmt.Sprintf("%s:%d", defaultTokenLabel, i+1)
+		budgets[i] = reporter.budget(label)
+		client, err := newGitHubClient(ctx, token, baseURL, budgets[i])
+		if err != nil {
+			return nil, err
+		}
+		reporter.track(label, client)
+	}
+	return newPooledClient(tokens, baseURL, budgets)
+}
+
+// newGitHubClient returns a client authenticating with token against the
+// GitHub API at baseURL, or github.com's when it is empty, that records the
+// quota of its responses in budget.
+func newGitHubClient(ctx context.Context, token, baseURL string, budget *rateBudget) (*github.Client, error) {
+	return newTokenClient(ctx, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}), baseURL, budget)
+}
+
+// newTokenClient is newGitHubClient for the tokens of ts.
+func newTokenClient(ctx context.Context, ts oauth2.TokenSource, baseURL string, budget *rateBudget) (*github.Client, error) {
+	httpClient := oauth2.NewClient(ctx, ts)
+	if budget != nil {
+		httpClient.Transport = &budgetTransport{base: httpClient.Transport, budget: budget}
+	}
+	return withBaseURL(github.NewClient(httpClient), baseURL)
+}
+
+// newPooledClient returns a client that takes turns with tokens against
+// the GitHub API at baseURL, recording the quota of each in its budget.
+func newPooledClient(tokens []string, baseURL string, budgets []*rateBudget) (*github.Client, error) {
+	pool := newTokenPool(http.DefaultTransport, tokens, budgets)
+	return withBaseURL(github.NewClient(&http.Client{Transport: pool}), baseURL)
+}
+
+// withBaseURL points client at the GitHub API at baseURL, or leaves it on
+// github.com's when it is empty.
+func withBaseURL(client *github.Client, baseURL string) (*github.Client, error) {
+	if baseURL == "" {
+		return client, nil
+	}
+
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(base.Path, "/") {
+		base.Path += "/"
+	}
+	client.BaseURL = base
+	return client, nil
+}
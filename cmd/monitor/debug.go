@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+)
+
+// serveDebug serves the monitor's debug endpoints on addr until ctx is
+// done. They are meant for operators, so are left unauthenticated and
+// should not be exposed beyond the cluster.
+func serveDebug(ctx context.Context, addr string, reporter *rateLimitReporter, pool *workerPool) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /debug/ratelimit", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(reporter.budgetStatuses()); err != nil {
+			log.Printf("Failed to write rate limit budgets: %v", err)
+		}
+	})
+	mux.HandleFunc("GET /debug/workers", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(pool.status()); err != nil {
+			log.Printf("Failed to write worker pool status: %v", err)
+		}
+	})
+	mux.HandleFunc("GET /metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if err := pool.writeMetrics(w); err != nil {
+			log.Printf("Failed to write worker pool metrics: %v", err)
+		}
+	})
+
+	server := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("Serving debug endpoints on %s", addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("Debug server stopped: %v", err)
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/go-github/v63/github"
+	"github.com/redis/go-redis/v9"
+)
+
+// etagTransport makes GitHub GET requests conditional. It keeps each
+// response that carries an ETag in Redis for ttl, keyed by its URL, and
+// sends the ETag with the next request for the URL. GitHub answers an
+// unchanged page with a 304 that doesn't count against the quota, and the
+// kept response is returned in its place, so callers never see the 304.
+type etagTransport struct {
+	base   http.RoundTripper
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// cachedResponse is a response kept with its ETag.
+type cachedResponse struct {
+	ETag   string      `json:"etag"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+}
+
+func etagKey(req *http.Request) string {
+	return "etag:" + req.URL.String()
+}
+
+// withETags returns a copy of client whose requests go through an
+// etagTransport.
+func withETags(client *github.Client, redisClient *redis.Client, ttl time.Duration) *github.Client {
+	return (&etagTransport{client: redisClient, ttl: ttl}).wrap(client)
+}
+
+// wrap returns a copy of client whose requests go through the same cache
+// as t's.
+func (t *etagTransport) wrap(client *github.Client) *github.Client {
+	base := client.Client().Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	wrapped := github.NewClient(&http.Client{Transport: &etagTransport{base: base, client: t.client, ttl: t.ttl}})
+	wrapped.BaseURL = client.BaseURL
+	wrapped.UploadURL = client.UploadURL
+	return wrapped
+}
+
+func (t *etagTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || req.Header.Get("If-None-Match") != "" {
+		return t.base.RoundTrip(req)
+	}
+
+	key := etagKey(req)
+	cached, err := t.load(req.Context(), key)
+	if err != nil {
+		log.Printf("Fetching %s unconditionally: %v", req.URL.Path, err)
+	}
+	if cached != nil {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		resp.Body.Close()
+		t.touch(key)
+		return cached.response(req, resp), nil
+	}
+
+	etag := resp.Header.Get("ETag")
+	if resp.StatusCode != http.StatusOK || etag == "" {
+		return resp, nil
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	t.save(key, cachedResponse{ETag: etag, Header: resp.Header.Clone(), Body: body})
+	return resp, nil
+}
+
+// response rebuilds the kept response for req. The headers of the 304,
+// which carry the current quota, replace the kept ones.
+func (c *cachedResponse) response(req *http.Request, notModified *http.Response) *http.Response {
+	header := c.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	for key, values := range notModified.Header {
+		header[key] = values
+	}
+	header.Set("Content-Length", strconv.Itoa(len(c.Body)))
+
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         notModified.Proto,
+		ProtoMajor:    notModified.ProtoMajor,
+		ProtoMinor:    notModified.ProtoMinor,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(c.Body)),
+		ContentLength: int64(len(c.Body)),
+		Request:       req,
+	}
+}
+
+func (t *etagTransport) load(ctx context.Context, key string) (*cachedResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	raw, err := t.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cached cachedResponse
+	if err := json.Unmarshal(raw, &cached); err != nil {
+		return nil, err
+	}
+	return &cached, nil
+}
+
+// save and touch only log their failures, as a lost response costs a
+// full request at worst.
+func (t *etagTransport) save(key string, cached cachedResponse) {
+	raw, err := json.Marshal(cached)
+	if err != nil {
+		log.Printf("Failed to encode response for %s: %v", key, err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := t.client.Set(ctx, key, raw, t.ttl).Err(); err != nil {
+		log.Printf("Failed to cache response for %s: %v", key, err)
+	}
+}
+
+func (t *etagTransport) touch(key string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := t.client.Expire(ctx, key, t.ttl).Err(); err != nil {
+		log.Printf("Failed to extend cached response for %s: %v", key, err)
+	}
+}
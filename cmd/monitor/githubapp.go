@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/google/go-github/v63/github"
+	"github.com/noelukwa/indexer/internal/pkg/config"
+	"golang.org/x/oauth2"
+)
+
+// appJWTTTL is how long the JWTs the monitor signs as its GitHub App are
+// valid. GitHub takes at most ten minutes.
+const appJWTTTL = 9 * time.Minute
+
+// newAppClient returns a client fetching as the GitHub App installation of
+// cfg. Its installation token is minted on the first request and again
+// shortly before each expires.
+func newAppClient(ctx context.Context, cfg *config.MonitorConfig, budget *rateBudget) (*github.Client, error) {
+	if cfg.GitHubAppInstallationID == 0 || cfg.GitHubAppPrivateKeyFile == "" {
+		return nil, errors.New("a GitHub App needs an installation ID and a private key file")
+	}
+	key, err := loadAppKey(cfg.GitHubAppPrivateKeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	app, err := withBaseURL(github.NewClient(&http.Client{
+		Transport: &appTransport{base: http.DefaultTransport, appID: cfg.GitHubAppID, key: key},
+	}), cfg.GitHubBaseURL)
+	if err != nil {
+		return nil, err
+	}
+	tokens := oauth2.ReuseTokenSource(nil, installationTokens{app: app, installationID: cfg.GitHubAppInstallationID})
+	return newTokenClient(ctx, tokens, cfg.GitHubBaseURL, budget)
+}
+
+// loadAppKey reads the App's RSA private key from a PEM file, in the
+// PKCS #1 form GitHub generates or in PKCS #8.
+func loadAppKey(path string) (*rsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read GitHub App private key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("GitHub App private key is not PEM encoded")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub App private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("GitHub App private key is not an RSA key")
+	}
+	return key, nil
+}
+
+// appJWT signs the JWT that authenticates as the App. It is backdated a
+// minute against clock drift, as GitHub recommends.
+func appJWT(appID int64, key *rsa.PrivateKey, now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]int64{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(appJWTTTL).Unix(),
+		"iss": appID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign GitHub App JWT: %w", err)
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// appTransport authenticates requests as the App itself, which is only
+// needed to mint installation tokens.
+type appTransport struct {
+	base  http.RoundTripper
+	appID int64
+	key   *rsa.PrivateKey
+}
+
+func (t *appTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	jwt, err := appJWT(t.appID, t.key, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	authed := req.Clone(req.Context())
+	authed.Header.Set("Authorization", "Bearer "+jwt)
+	return t.base.RoundTrip(authed)
+}
+
+// installationTokens mints access tokens for an installation of the App.
+type installationTokens struct {
+	app            *github.Client
+	installationID int64
+}
+
+func (s installationTokens) Token() (*oauth2.Token, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), githubAPITimeout)
+	defer cancel()
+	token, _, err := s.app.Apps.CreateInstallationToken(ctx, s.installationID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create installation token: %w", err)
+	}
+	return &oauth2.Token{AccessToken: token.GetToken(), Expiry: token.GetExpiresAt().Time}, nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/go-github/v63/github"
+	"github.com/noelukwa/indexer/internal/events"
+	"github.com/noelukwa/indexer/internal/manager/models"
+)
+
+// fetchGitHubStats sends the stats GitHub precomputes for the repository.
+// GitHub answers 202 while it computes them, which the gate retries; a
+// kind still being computed after that is left for the next sync.
+func fetchGitHubStats(ctx context.Context, client *github.Client, gate *fetchGate, lifecycleChan chan<- *events.CommitsCommand, ev *events.IntentPayload) error {
+	stats := &models.GitHubStats{
+		Repository: ev.RepoOwner + "/" + ev.RepoName,
+		FetchedAt:  time.Now().UTC(),
+	}
+
+	var weeks []*github.WeeklyStats
+	err := fetchStatsKind(ctx, gate, "code frequency", func() (err error) {
+		weeks, _, err = client.Repositories.ListCodeFrequency(ctx, ev.RepoOwner, ev.RepoName)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if weeks != nil {
+		stats.CodeFrequency = make([]models.WeeklyCodeChanges, 0, len(weeks))
+		for _, week := range weeks {
+			stats.CodeFrequency = append(stats.CodeFrequency, models.WeeklyCodeChanges{
+				Week:      week.GetWeek().Time,
+				Additions: int64(week.GetAdditions()),
+				Deletions: int64(week.GetDeletions()),
+			})
+		}
+	}
+
+	var participation *github.RepositoryParticipation
+	err = fetchStatsKind(ctx, gate, "participation", func() (err error) {
+		participation, _, err = client.Repositories.ListParticipation(ctx, ev.RepoOwner, ev.RepoName)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if participation != nil {
+		stats.Participation = &models.WeeklyParticipation{All: participation.All, Owner: participation.Owner}
+	}
+
+	var punchCard []*github.PunchCard
+	err = fetchStatsKind(ctx, gate, "punch card", func() (err error) {
+		punchCard, _, err = client.Repositories.ListPunchCard(ctx, ev.RepoOwner, ev.RepoName)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if punchCard != nil {
+		stats.PunchCard = make([]models.PunchCardHour, 0, len(punchCard))
+		for _, hour := range punchCard {
+			stats.PunchCard = append(stats.PunchCard, models.PunchCardHour{
+				Day:     hour.GetDay(),
+				Hour:    hour.GetHour(),
+				Commits: hour.GetCommits(),
+			})
+		}
+	}
+
+	if stats.CodeFrequency == nil && stats.Participation == nil && stats.PunchCard == nil {
+		return nil
+	}
+	command := &events.CommitsCommand{
+		Kind:    events.NewGitHubStatsKind,
+		Payload: &events.CommitPayload{GitHubStats: stats},
+	}
+	select {
+	case lifecycleChan <- command:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// fetchStatsKind makes the request for one kind of stats, leaving it empty
+// while GitHub is still computing it.
+func fetchStatsKind(ctx context.Context, gate *fetchGate, kind string, request func() error) error {
+	err := gate.call(ctx, request)
+	var accepted *github.AcceptedError
+	if errors.As(err, &accepted) {
+		log.Printf("GitHub is still computing the %s stats", kind)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s stats: %w", kind, err)
+	}
+	return nil
+}
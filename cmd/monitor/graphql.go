@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v63/github"
+	"github.com/noelukwa/indexer/internal/events"
+)
+
+// commitHistoryQuery lists a page of a ref's history with the line counts
+// that the REST listing leaves to a request per commit.
+const commitHistoryQuery = `query($owner: String!, $name: String!, $ref: String!, $first: Int!, $after: String, $since: GitTimestamp, $until: GitTimestamp, $path: String) {
+  repository(owner: $owner, name: $name) {
+    object(expression: $ref) {
+      ... on Commit {
+        history(first: $first, after: $after, since: $since, until: $until, path: $path) {
+          pageInfo { hasNextPage endCursor }
+          nodes {
+            oid
+            url
+            message
+            committedDate
+            additions
+            deletions
+            comments { totalCount }
+            author { name email date user { login databaseId } }
+          }
+        }
+      }
+    }
+  }
+}`
+
+type graphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+type graphQLError struct {
+	Message string `json:"message"`
+}
+
+type historyResponse struct {
+	Data struct {
+		Repository *struct {
+			Object *struct {
+				History *commitHistory `json:"history"`
+			} `json:"object"`
+		} `json:"repository"`
+	} `json:"data"`
+	Errors []graphQLError `json:"errors"`
+}
+
+type commitHistory struct {
+	PageInfo struct {
+		HasNextPage bool   `json:"hasNextPage"`
+		EndCursor   string `json:"endCursor"`
+	} `json:"pageInfo"`
+	Nodes []historyCommit `json:"nodes"`
+}
+
+type historyCommit struct {
+	OID           string    `json:"oid"`
+	URL           string    `json:"url"`
+	Message       string    `json:"message"`
+	CommittedDate time.Time `json:"committedDate"`
+	Additions     int       `json:"additions"`
+	Deletions     int       `json:"deletions"`
+	Comments      struct {
+		TotalCount int `json:"totalCount"`
+	} `json:"comments"`
+	Author struct {
+		Name  string    `json:"name"`
+		Email string    `json:"email"`
+		Date  time.Time `json:"date"`
+		User  *struct {
+			Login      string `json:"login"`
+			DatabaseID int64  `json:"databaseId"`
+		} `json:"user"`
+	} `json:"author"`
+}
+
+// repositoryCommit converts c to the REST API's form, stats included.
+func (c historyCommit) repositoryCommit() *github.RepositoryCommit {
+	commit := &github.RepositoryCommit{
+		SHA:     github.String(c.OID),
+		HTMLURL: github.String(c.URL),
+		Commit: &github.Commit{
+			Message: github.String(c.Message),
+			Author: &github.CommitAuthor{
+				Name:  github.String(c.Author.Name),
+				Email: github.String(c.Author.Email),
+				Date:  &github.Timestamp{Time: c.Author.Date},
+			},
+			Committer:    &github.CommitAuthor{Date: &github.Timestamp{Time: c.CommittedDate}},
+			CommentCount: github.Int(c.Comments.TotalCount),
+		},
+		Stats: &github.CommitStats{
+			Additions: github.Int(c.Additions),
+			Deletions: github.Int(c.Deletions),
+			Total:     github.Int(c.Additions + c.Deletions),
+		},
+	}
+	if c.Author.User != nil {
+		commit.Author = &github.User{Login: github.String(c.Author.User.Login), ID: github.Int64(c.Author.User.DatabaseID)}
+	}
+	return commit
+}
+
+// graphQLURL is the GraphQL endpoint of the API client talks to. GitHub
+// Enterprise serves it beside its REST API rather than under it.
+func graphQLURL(client *github.Client) string {
+	endpoint := *client.BaseURL
+	if strings.HasSuffix(endpoint.Path, "/api/v3/") {
+		endpoint.Path = strings.TrimSuffix(endpoint.Path, "v3/") + "graphql"
+		return endpoint.String()
+	}
+	return endpoint.ResolveReference(&url.URL{Path: "graphql"}).String()
+}
+
+// fetchGraphQLCommits sends the new commits of query's listing between
+// since and until, a zero time leaving that end open, and records every
+// commit listed in walk. The GraphQL API can't filter by login, so query
+// must not have an author.
+func fetchGraphQLCommits(ctx context.Context, client *github.Client, gate *fetchGate, seen *commitSet, commitsChan chan<- *CommitResult, walk *cursorWalk, ev *events.IntentPayload, query commitQuery, since, until time.Time, perPage int) error {
+	ref := query.branch
+	if ref == "" {
+		ref = "HEAD"
+	}
+	variables := map[string]interface{}{
+		"owner": ev.RepoOwner,
+		"name":  ev.RepoName,
+		"ref":   ref,
+		"first": perPage,
+	}
+	if !since.IsZero() {
+		variables["since"] = since.UTC().Format(time.RFC3339)
+	}
+	if !until.IsZero() {
+		variables["until"] = until.UTC().Format(time.RFC3339)
+	}
+	if query.path != "" {
+		variables["path"] = query.path
+	}
+
+	for {
+		history, err := fetchHistoryPage(ctx, client, gate, variables)
+		if err != nil {
+			return fmt.Errorf("ref %q: %w", ref, err)
+		}
+
+		seen.fetchedPage()
+		for _, node := range history.Nodes {
+			commit := node.repositoryCommit()
+			walk.list(node.OID, node.CommittedDate)
+			if excludedAuthor(commit, ev.ExcludedAuthors) {
+				continue
+			}
+			if !seen.add(node.OID) {
+				continue
+			}
+			seen.reached(node.CommittedDate)
+			select {
+			case commitsChan <- commitResult(ctx, client, gate, ev, commit):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		if !history.PageInfo.HasNextPage || seen.full() {
+			return nil
+		}
+		variables["after"] = history.PageInfo.EndCursor
+	}
+}
+
+// fetchHistoryPage makes one history query through the gate. GraphQL
+// reports most failures in a successful response, so those are errors too.
+func fetchHistoryPage(ctx context.Context, client *github.Client, gate *fetchGate, variables map[string]interface{}) (*commitHistory, error) {
+	var resp historyResponse
+	err := gate.call(ctx, func() error {
+		req, err := client.NewRequest(http.MethodPost, graphQLURL(client), graphQLRequest{Query: commitHistoryQuery, Variables: variables})
+		if err != nil {
+			return err
+		}
+		resp = historyResponse{}
+		_, err = client.Do(ctx, req, &resp)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query commit history: %w", err)
+	}
+
+	if len(resp.Errors) > 0 {
+		messages := make([]string, len(resp.Errors))
+		for i, e := range resp.Errors {
+			messages[i] = e.Message
+		}
+		return nil, fmt.Errorf("failed to query commit history: %s", strings.Join(messages, "; "))
+	}
+	repo := resp.Data.Repository
+	if repo == nil || repo.Object == nil || repo.Object.History == nil {
+		return nil, errors.New("no commit history found")
+	}
+	return repo.Object.History, nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/noelukwa/indexer/internal/events"
+)
+
+const progressInterval = 30 * time.Second
+
+// runReporter tells the manager how a run of an intent is going: when it
+// starts, how many commits it has fetched every progressInterval, and how
+// it ends.
+type runReporter struct {
+	lifecycleChan chan<- *events.CommitsCommand
+	commitsChan   chan<- *CommitResult
+	intent        *events.IntentPayload
+	seen          *commitSet
+	startedAt     time.Time
+}
+
+func (r *runReporter) command(kind events.CommitsEventKind, runErr error) *events.CommitsCommand {
+	pages, oldest := r.seen.progress()
+	progress := &events.IntentProgress{
+		IntentID:  r.intent.ID,
+		Commits:   r.seen.count(),
+		Pages:     pages,
+		StartedAt: r.startedAt,
+		At:        time.Now(),
+		ReindexID: r.intent.ReindexID,
+	}
+	if !oldest.IsZero() {
+		progress.LastCommitDate = &oldest
+	}
+	if runErr != nil {
+		progress.Error = runErr.Error()
+	}
+	return &events.CommitsCommand{
+		Kind:    kind,
+		Payload: &events.CommitPayload{Progress: progress},
+	}
+}
+
+func (r *runReporter) send(ctx context.Context, kind events.CommitsEventKind, runErr error) {
+	select {
+	case r.lifecycleChan <- r.command(kind, runErr):
+	case <-ctx.Done():
+	}
+}
+
+// start reports the run as started and keeps reporting progress until the
+// returned func is called.
+func (r *runReporter) start(ctx context.Context) func() {
+	r.startedAt = time.Now()
+	r.send(ctx, events.IntentStartedKind, nil)
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(progressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				r.send(ctx, events.IntentProgressKind, nil)
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// finish reports the run as completed, or failed when runErr is set. The
+// report follows the run's commits through the commit batches, so it
+// reaches the manager after the last of them.
+func (r *runReporter) finish(ctx context.Context, runErr error) {
+	kind := events.IntentCompletedKind
+	if runErr != nil {
+		kind = events.IntentFailedKind
+	}
+	select {
+	case r.commitsChan <- &CommitResult{ended: r.command(kind, runErr)}:
+	case <-ctx.Done():
+	}
+}
+
+func lifecycleResolver(ctx context.Context, pub *publisher, lifecycleChan <-chan *events.CommitsCommand) {
+	for {
+		select {
+		case event := <-lifecycleChan:
+			if err := pub.publish(ctx, event); err != nil {
+				log.Printf("Failed to publish %s after retries: %v", event.Kind, err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
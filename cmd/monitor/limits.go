@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/go-github/v63/github"
+	"github.com/noelukwa/indexer/internal/events"
+	"github.com/noelukwa/indexer/internal/pkg/config"
+	"github.com/noelukwa/indexer/internal/pkg/retry"
+	"golang.org/x/time/rate"
+)
+
+// fetchGate bounds GitHub requests made on behalf of one repository. Slots
+// are shared by every repository; the limiter belongs to this one only,
+// and the budget to the token it is fetched with. stats reports whether
+// each commit's details are worth the extra request, comments whether
+// commented commits' comments are, graphQL whether commits are listed
+// through the GraphQL API, overlap how far before its cursor a run lists
+// again, and retry bounds the attempts at each request.
+type fetchGate struct {
+	slots    chan struct{}
+	limiter  *rate.Limiter
+	budget   quotaWaiter
+	pages    int
+	stats    bool
+	comments bool
+	graphQL  bool
+	overlap  time.Duration
+	retry    retry.Policy
+}
+
+func newFetchGate(slots chan struct{}, client *github.Client, cfg *config.MonitorConfig, ev *events.IntentPayload) *fetchGate {
+	pages := cfg.RepoMaxConcurrentPages
+	if ev.MaxConcurrentPages != nil {
+		pages = int(*ev.MaxConcurrentPages)
+	}
+	if pages < 1 {
+		pages = 1
+	}
+
+	rpm := cfg.RepoRequestsPerMinute
+	if ev.RequestsPerMinute != nil {
+		rpm = int(*ev.RequestsPerMinute)
+	}
+
+	var limiter *rate.Limiter
+	if rpm > 0 {
+		limiter = rate.NewLimiter(rate.Every(time.Minute/time.Duration(rpm)), 1)
+	}
+
+	return &fetchGate{
+		slots:    slots,
+		limiter:  limiter,
+		budget:   budgetOf(client),
+		pages:    pages,
+		stats:    cfg.FetchCommitStats,
+		comments: cfg.FetchCommitComments,
+		graphQL:  cfg.GitHubGraphQL,
+		overlap:  cfg.CursorOverlap,
+		retry:    ev.Retry.Apply(cfg.RetryPolicy()),
+	}
+}
+
+// acquire blocks until the token's budget and the repository's rate allow
+// another request and a global slot is free. A paused budget holds no
+// slot, so other tokens' requests go on. The returned func must be called
+// once the request has completed.
+func (g *fetchGate) acquire(ctx context.Context) (func(), error) {
+	if g.budget != nil {
+		if err := g.budget.wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if g.limiter != nil {
+		if err := g.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	select {
+	case g.slots <- struct{}{}:
+		return func() { <-g.slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// call makes a GitHub request through the gate, retrying failures that may
+// be transient. Each attempt acquires the gate anew, so waiting out a
+// backoff doesn't hold a slot.
+func (g *fetchGate) call(ctx context.Context, request func() error) error {
+	return retry.Do(ctx, g.retry, func() error {
+		release, err := g.acquire(ctx)
+		if err != nil {
+			return retry.Stop(err)
+		}
+		err = request()
+		release()
+		if err != nil && !retryable(err) {
+			return retry.Stop(err)
+		}
+		return err
+	}, func(attempt int, err error) {
+		log.Printf("GitHub request failed (attempt %d/%d): %v", attempt, g.retry.MaxAttempts, err)
+	})
+}
+
+// retryable reports whether a failed GitHub request may succeed when
+// repeated. Server errors, secondary rate limits and network failures may,
+// and their retries wait out the pause the budget took from the response;
+// client errors and an exhausted quota, which lasts until its reset, won't.
+func retryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var rateErr *github.RateLimitError
+	if errors.As(err, &rateErr) {
+		return false
+	}
+	var abuseErr *github.AbuseRateLimitError
+	if errors.As(err, &abuseErr) {
+		return true
+	}
+	var respErr *github.ErrorResponse
+	if errors.As(err, &respErr) && respErr.Response != nil {
+		code := respErr.Response.StatusCode
+		return code >= http.StatusInternalServerError || code == http.StatusTooManyRequests
+	}
+	return true
+}
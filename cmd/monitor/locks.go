@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/noelukwa/indexer/internal/pkg/repolocks"
+)
+
+// lockOwner names this monitor in the locks it takes, unique across
+// restarts so a dead instance's locks don't look alive.
+func lockOwner() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "monitor"
+	}
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(suffix))
+}
+
+// runLockKeeper beats this monitor's heartbeat, so its locks stay alive,
+// and reaps stale locks every reapInterval until ctx is done.
+func runLockKeeper(ctx context.Context, locks repolocks.Locker, owner string, reapInterval time.Duration) {
+	heartbeat := time.NewTicker(repolocks.OwnerTTL / 3)
+	defer heartbeat.Stop()
+	if reapInterval <= 0 {
+		reapInterval = time.Minute
+	}
+	reap := time.NewTicker(reapInterval)
+	defer reap.Stop()
+
+	for {
+		if err := locks.Heartbeat(ctx, owner); err != nil {
+			log.Printf("Failed to beat lock heartbeat: %v", err)
+		}
+
+		select {
+		case <-heartbeat.C:
+		case <-reap.C:
+			reaped, err := locks.Reap(ctx)
+			if err != nil {
+				log.Printf("Failed to reap stale locks: %v", err)
+			}
+			for _, lock := range reaped {
+				log.Printf("Reaped stale lock of %s held by %q since %s: %s", lock.Repository, lock.Owner, lock.AcquiredAt.Format(time.RFC3339), lock.Stale)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// holdLock refreshes held until ctx is done, so a long fetch keeps its
+// lock while a crashed one lets it expire.
+func holdLock(ctx context.Context, held repolocks.Lease, repo string) {
+	ticker := time.NewTicker(repolocks.TTL / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			ok, err := held.Refresh(ctx)
+			if err != nil {
+				log.Printf("Failed to refresh lock of %s: %v", repo, err)
+			} else if !ok {
+				log.Printf("Lost lock of %s", repo)
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
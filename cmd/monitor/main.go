@@ -3,37 +3,60 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"os/signal"
+	"sort"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
 
 	"github.com/google/go-github/v63/github"
+	"github.com/google/uuid"
 	_ "github.com/joho/godotenv/autoload"
 	"github.com/kelseyhightower/envconfig"
 	"github.com/noelukwa/indexer/internal/events"
 	"github.com/noelukwa/indexer/internal/manager/models"
+	"github.com/noelukwa/indexer/internal/pkg/broker"
 	"github.com/noelukwa/indexer/internal/pkg/config"
-	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/noelukwa/indexer/internal/pkg/ratelimits"
+	"github.com/noelukwa/indexer/internal/pkg/repolocks"
+	"github.com/noelukwa/indexer/internal/pkg/retry"
+	"github.com/noelukwa/indexer/internal/pkg/secrets"
 	"github.com/redis/go-redis/v9"
-	"golang.org/x/oauth2"
+	"golang.org/x/sync/errgroup"
 )
 
 const (
 	batchSize        = 100
-	maxRetries       = 3
-	retryDelay       = 5 * time.Second
-	lockTTL          = 10 * time.Minute
+	checkpointTTL    = 7 * 24 * time.Hour
+	cursorTTL        = 30 * 24 * time.Hour
 	publishTimeout   = 5 * time.Second
 	githubAPITimeout = 30 * time.Second
 )
 
+// errInterrupted is returned for an intent whose fetch was cut short by
+// shutdown.
+var errInterrupted = errors.New("interrupted by shutdown")
+
+// CommitResult is a fetched commit on its way to the manager, or the end
+// of a run, which is published once the commits ahead of it are.
 type CommitResult struct {
 	Repository string `json:"repo"`
 	commit     *github.RepositoryCommit
+	comments   []*github.RepositoryComment
+	reindexID  *uuid.UUID
+	ended      *events.CommitsCommand
+	fetchedAt  time.Time
+}
+
+// RepoInfo is a repository fetched by a run of the intent with IntentID.
+type RepoInfo struct {
+	repo     *github.Repository
+	intentID uuid.UUID
 }
 
 func main() {
@@ -43,85 +66,130 @@ func main() {
 		log.Fatalf("Failed to process config: %v", err)
 	}
 
-	redisClient := redis.NewClient(&redis.Options{
-		Addr: config.RedisAddr,
-	})
-
-	conn, err := amqp.Dial(config.RabbitMQURL)
+	brokerTLS, err := config.BrokerTLSConfig(config.RabbitMQURL)
 	if err != nil {
-		log.Fatalf("Failed to connect to RabbitMQ: %v", err)
+		log.Fatalf("Invalid broker config: %v", err)
 	}
-	defer conn.Close()
-
-	ch, err := conn.Channel()
-	if err != nil {
-		log.Fatalf("Failed to open a channel: %v", err)
-	}
-	defer ch.Close()
-
-	cq, err := ch.QueueDeclare(
-		config.RabbitMQConsumeQueue,
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
+	compression, err := config.Compression()
 	if err != nil {
-		log.Fatalf("Failed to declare consumer queue: %v", err)
+		log.Fatalf("Invalid broker config: %v", err)
+	}
+	// Without Redis the monitor keeps its locks and checkpoints in process,
+	// which only suits a single monitor.
+	var redisClient *redis.Client
+	if config.RedisAddr != "" {
+		redisOpts, err := config.RedisOptions(config.RedisAddr)
+		if err != nil {
+			log.Fatalf("Invalid redis config: %v", err)
+		}
+		redisClient = redis.NewClient(redisOpts)
+	} else {
+		log.Println("No Redis configured, running as a single monitor")
 	}
 
-	_, err = ch.QueueDeclare(
-		config.RabbitMQPublishQueue,
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
-	if err != nil {
-		log.Fatalf("Failed to declare publish queue: %v", err)
-	}
-
-	msgs, err := ch.Consume(
-		cq.Name,
-		"",
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
+	b, err := broker.Open(config.RabbitMQURL, brokerTLS, compression)
 	if err != nil {
-		log.Fatalf("Failed to register a consumer: %v", err)
+		log.Fatalf("Failed to connect to the broker: %v", err)
+	}
+	defer b.Close()
+
+	if err := b.Declare(config.RabbitMQConsumeQueue, config.RabbitMQPublishQueue); err != nil {
+		log.Fatalf("Failed to declare queues: %v", err)
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: config.GitHubToken},
-	)
+	// The monitor takes more intents off the queue than it has workers, so
+	// that intents for other repositories queue up behind a busy one.
+	workers := max(config.MaxConcurrentRepos, 1)
+	msgs, err := b.ConsumeAcked(ctx, config.RabbitMQConsumeQueue, max(config.MaxConcurrentIntents, 2*workers))
+	if err != nil {
+		log.Fatalf("Failed to register a consumer: %v", err)
+	}
+
+	var box *secrets.Box
+	if config.CredentialsKey != "" {
+		box, err = secrets.NewBox(config.CredentialsKey)
+		if err != nil {
+			log.Fatalf("Failed to load credentials key: %v", err)
+		}
+	}
 
-	tc := oauth2.NewClient(ctx, ts)
+	var locks repolocks.Locker
+	var checkpoints checkpointStore
+	var rateLimits *ratelimits.Store
+	if redisClient != nil {
+		locks = repolocks.NewStore(redisClient)
+		checkpoints = redisCheckpoints{client: redisClient}
+		rateLimits = ratelimits.NewStore(redisClient)
+	} else {
+		locks = repolocks.NewMemoryStore()
+		checkpoints = newMemoryCheckpoints()
+	}
+	owner := lockOwner()
+	go runLockKeeper(ctx, locks, owner, config.LockReapInterval)
+
+	// Rate limits are only reported to the manager through Redis.
+	reporter := newRateLimitReporter(rateLimits, config.GitHubRateLimitReserve)
+	ghClient, err := monitorClient(ctx, &config, reporter)
+	if err != nil {
+		log.Fatalf("Failed to set up the GitHub client: %v", err)
+	}
+	if redisClient != nil && config.GitHubCacheTTL > 0 {
+		ghClient = withETags(ghClient, redisClient, config.GitHubCacheTTL)
+	}
+	if rateLimits != nil {
+		go reporter.run(ctx)
+	}
+	pool := newWorkerPool(workers)
+	if config.DebugAddr != "" {
+		go serveDebug(ctx, config.DebugAddr, reporter, pool)
+	}
 
-	ghClient := github.NewClient(tc)
+	githubSlots := make(chan struct{}, max(config.MaxConcurrentFetches, 1))
 
 	commitsChan := make(chan *CommitResult, batchSize)
-	repoChan := make(chan *github.Repository, 1)
 
-	go repoResolver(ctx, ch, config.RabbitMQPublishQueue, repoChan)
-	go commitsResolver(ctx, ch, config.RabbitMQPublishQueue, commitsChan)
+	var pushes *pushPoller
+	pushesDone := make(chan struct{})
+	if config.PushPollInterval > 0 {
+		pushes = newPushPoller(&config, githubSlots, locks, owner, commitsChan)
+		go func() {
+			defer close(pushesDone)
+			pushes.run(ctx)
+		}()
+	} else {
+		close(pushesDone)
+	}
+	repoChan := make(chan *RepoInfo, 1)
+	lifecycleChan := make(chan *events.CommitsCommand, batchSize)
 
-	var wg sync.WaitGroup
+	pub := &publisher{broker: b, queue: config.RabbitMQPublishQueue, retry: config.RetryPolicy(), maxSize: config.MaxMessageBytes}
+	go repoResolver(ctx, pub, repoChan)
+	go lifecycleResolver(ctx, pub, lifecycleChan)
+	go commitsResolver(ctx, pub, commitsChan)
+
+	// Intents taken off the queue wait for a worker, queued per repository.
+	consumed := make(chan struct{})
 
 	go func() {
+		defer close(consumed)
 		for d := range msgs {
-			wg.Add(1)
-			go func(d amqp.Delivery) {
-				defer wg.Done()
-				handleMessage(ctx, ghClient, redisClient, commitsChan, repoChan, d.Body)
-			}(d)
+			event, err := parseEvent(d.Body)
+			if err != nil {
+				log.Printf("Dropping intent: %v", err)
+				settle(d, err)
+				continue
+			}
+			// A broadcast repeated while its run still waits adds nothing.
+			queued := pool.submit(repoKey(event.Intent), runKey(event.Intent), func() {
+				err := handleMessage(ctx, ghClient, box, reporter, checkpoints, locks, owner, &config, githubSlots, pushes, commitsChan, repoChan, lifecycleChan, event)
+				settle(d, err)
+			})
+			if !queued {
+				settle(d, nil)
+			}
 		}
 	}()
 
@@ -133,49 +201,139 @@ func main() {
 	// Trigger shutdown
 	cancel()
 
-	// Close channels and wait for all goroutines to complete
+	// Wait for the in-flight intents to be settled, then close channels
+	<-consumed
+	pool.close()
+	<-pushesDone
 	close(repoChan)
 	close(commitsChan)
-	wg.Wait()
 
 	log.Println("Shutting down service...")
 }
 
-func handleMessage(ctx context.Context, client *github.Client, redisClient *redis.Client, commitsChan chan<- *CommitResult, repoChan chan<- *github.Repository, body []byte) error {
-	event, err := parseEvent(body)
+func handleMessage(ctx context.Context, client *github.Client, box *secrets.Box, reporter *rateLimitReporter, checkpoints checkpointStore, locks repolocks.Locker, owner string, cfg *config.MonitorConfig, githubSlots chan struct{}, pushes *pushPoller, commitsChan chan<- *CommitResult, repoChan chan<- *RepoInfo, lifecycleChan chan<- *events.CommitsCommand, event *events.IntentCommand) error {
+	client, err := intentClient(ctx, client, box, reporter, event.Intent)
 	if err != nil {
-		return fmt.Errorf("failed to parse event: %w", err)
+		log.Printf("Skipping intent: %v", err)
+		return err
 	}
+	// The monitor's own tokens are tracked for as long as it runs.
+	if label := tokenLabel(event.Intent); label != defaultTokenLabel {
+		defer reporter.track(label, client)()
+	}
+
+	if event.Intent.RepoName == orgRepoName {
+		return handleOrgIntent(ctx, client, cfg, githubSlots, commitsChan, lifecycleChan, event.Intent)
+	}
+
+	repo := event.Intent.RepoOwner + "/" + event.Intent.RepoName
+	held, err := acquireLock(locks, repolocks.Key(event.Intent.RepoOwner, event.Intent.RepoName), repolocks.Holder{
+		Repository: repo,
+		Owner:      owner,
+		IntentID:   event.Intent.ID.String(),
+		AcquiredAt: time.Now().UTC(),
+	})
+	if err != nil || held == nil {
+		return fmt.Errorf("failed to acquire lock for %s: %w", repo, err)
+	}
+	defer releaseLock(held, repo)
+	holdCtx, stopHolding := context.WithCancel(ctx)
+	defer stopHolding()
+	go holdLock(holdCtx, held, repo)
+
+	gate := newFetchGate(githubSlots, client, cfg, event.Intent)
 
-	lockKey := fmt.Sprintf("lock:%s.%s", event.Intent.RepoOwner, event.Intent.RepoName)
-	ok, err := acquireLock(redisClient, lockKey, lockTTL)
-	if err != nil || !ok {
-		return fmt.Errorf("failed to acquire lock for %s: %w", lockKey, err)
+	limit := 0
+	if event.Intent.MaxCommits != nil {
+		limit = int(*event.Intent.MaxCommits)
 	}
-	defer releaseLock(redisClient, lockKey)
+	run := &runReporter{
+		lifecycleChan: lifecycleChan,
+		commitsChan:   commitsChan,
+		intent:        event.Intent,
+		seen:          newCommitSet(limit),
+	}
+	startedAt := time.Now()
+	stopProgress := run.start(ctx)
 
+	var fetchErr error
+	var info *github.Repository
 	var wg sync.WaitGroup
 	wg.Add(2)
 
 	go func() {
 		defer wg.Done()
-		if err := fetchGithubInfo(ctx, client, repoChan, event.Intent); err != nil {
+		var err error
+		if info, err = fetchGithubInfo(ctx, client, gate, repoChan, event.Intent); err != nil {
 			log.Printf("Error fetching GitHub info: %v", err)
+			return
+		}
+		if cfg.FetchGitHubStats && event.Intent.ReindexID == nil {
+			if err := fetchGitHubStats(ctx, client, gate, lifecycleChan, event.Intent); err != nil {
+				log.Printf("Error fetching GitHub stats: %v", err)
+			}
+		}
+		if cfg.FetchSecurityAlerts && event.Intent.ReindexID == nil {
+			if err := fetchSecurityAlerts(ctx, client, gate, lifecycleChan, event.Intent); err != nil {
+				log.Printf("Error fetching security alerts: %v", err)
+			}
 		}
 	}()
 
 	go func() {
 		defer wg.Done()
-		if err := fetchCommits(ctx, client, commitsChan, event.Intent); err != nil {
-			log.Printf("Error fetching commits: %v", err)
+		if fetchErr = fetchCommits(ctx, client, checkpoints, gate, run.seen, commitsChan, event.Intent); fetchErr != nil {
+			log.Printf("Error fetching commits: %v", fetchErr)
+			return
+		}
+		// Reviews and CI runs aren't part of a reindexed history, and
+		// failing to fetch them leaves the commits indexed.
+		if event.Intent.ReindexID != nil {
+			return
+		}
+		if cfg.FetchPullRequestReviews {
+			if err := fetchReviews(ctx, client, gate, lifecycleChan, event.Intent); err != nil {
+				log.Printf("Error fetching reviews: %v", err)
+			}
+		}
+		if cfg.FetchWorkflowRuns {
+			if err := fetchWorkflowRuns(ctx, client, gate, lifecycleChan, event.Intent); err != nil {
+				log.Printf("Error fetching workflow runs: %v", err)
+			}
 		}
 	}()
 
 	wg.Wait()
-	return nil
+	stopProgress()
+	// A fetch cut short by shutdown goes back on the queue for another
+	// monitor rather than failing the run.
+	if ctx.Err() != nil {
+		return errInterrupted
+	}
+	run.finish(ctx, fetchErr)
+	if fetchErr == nil && info != nil && pushes != nil {
+		pushes.watch(event.Intent, client, info.GetDefaultBranch(), startedAt)
+	}
+	return fetchErr
+}
+
+// settle acks an intent message once it has been handled, or requeues it
+// when shutdown interrupted its fetch.
+func settle(d broker.Delivery, err error) {
+	if errors.Is(err, errInterrupted) {
+		if err := d.Nack(true); err != nil {
+			log.Printf("Failed to requeue intent: %v", err)
+			return
+		}
+		log.Println("Requeued interrupted intent")
+		return
+	}
+	if err := d.Ack(); err != nil {
+		log.Printf("Failed to ack intent: %v", err)
+	}
 }
 
-func commitsResolver(ctx context.Context, ch *amqp.Channel, publishQueue string, commitsChan <-chan *CommitResult) {
+func commitsResolver(ctx context.Context, pub *publisher, commitsChan <-chan *CommitResult) {
 	batch := make([]*CommitResult, 0, batchSize)
 
 	for {
@@ -183,34 +341,68 @@ func commitsResolver(ctx context.Context, ch *amqp.Channel, publishQueue string,
 		case commit, ok := <-commitsChan:
 			if !ok {
 				if len(batch) > 0 {
-					publishCommitsBatch(ctx, ch, publishQueue, batch)
+					publishCommitsBatch(ctx, pub, batch)
 				}
 				return
 			}
+			if commit.ended != nil {
+				// The run's commits are all ahead of its end, so the
+				// manager has them before it hears the run is over.
+				if len(batch) > 0 {
+					publishCommitsBatch(ctx, pub, batch)
+					batch = batch[:0]
+				}
+				if err := pub.publish(ctx, commit.ended); err != nil {
+					log.Printf("Failed to publish %s after retries: %v", commit.ended.Kind, err)
+				}
+				continue
+			}
 			batch = append(batch, commit)
 			if len(batch) == batchSize {
-				publishCommitsBatch(ctx, ch, publishQueue, batch)
+				publishCommitsBatch(ctx, pub, batch)
 				batch = batch[:0]
 			}
 		case <-time.After(5 * time.Second):
 			if len(batch) > 0 {
-				publishCommitsBatch(ctx, ch, publishQueue, batch)
+				publishCommitsBatch(ctx, pub, batch)
 				batch = batch[:0]
 			}
 		case <-ctx.Done():
 			if len(batch) > 0 {
-				publishCommitsBatch(ctx, ch, publishQueue, batch)
+				publishCommitsBatch(ctx, pub, batch)
 			}
 			return
 		}
 	}
 }
 
-func publishCommitsBatch(ctx context.Context, ch *amqp.Channel, publishQueue string, results []*CommitResult) {
+// publishCommitsBatch publishes the commits of reindex runs apart from the
+// rest, as they go to the reindex's shadow.
+func publishCommitsBatch(ctx context.Context, pub *publisher, results []*CommitResult) {
+	var live []*CommitResult
+	reindexes := make(map[uuid.UUID][]*CommitResult)
+	for _, result := range results {
+		if result.reindexID == nil {
+			live = append(live, result)
+			continue
+		}
+		reindexes[*result.reindexID] = append(reindexes[*result.reindexID], result)
+	}
+
+	if len(live) > 0 {
+		publishCommits(ctx, pub, nil, live)
+	}
+	for id, results := range reindexes {
+		publishCommits(ctx, pub, &id, results)
+	}
+}
+
+func publishCommits(ctx context.Context, pub *publisher, reindexID *uuid.UUID, results []*CommitResult) {
 	payload := &events.CommitsCommand{
 		Kind: events.NewCommitsKind,
 		Payload: &events.CommitPayload{
-			Commits: make([]*models.Commit, 0, len(results)),
+			Commits:   make([]*models.Commit, 0, len(results)),
+			ReindexID: reindexID,
 		},
 	}
 
@@ -219,47 +411,93 @@ func publishCommitsBatch(ctx context.Context, ch *amqp.Channel, publishQueue str
 		payload.Payload.Commits = append(payload.Payload.Commits, &models.Commit{
 			Hash:    *commit.SHA,
 			Message: *commit.Commit.Message,
+			Url:     commit.GetHTMLURL(),
+			// Author is the GitHub account the commit is linked to, if
+			// any; unlinked commits are left unattributed.
 			Author: models.Author{
-				Name:  *commit.Commit.Author.Name,
-				Email: *commit.Commit.Author.Email,
+				ID:       commit.GetAuthor().GetID(),
+				Name:     *commit.Commit.Author.Name,
+				Email:    *commit.Commit.Author.Email,
+				Username: commit.GetAuthor().GetLogin(),
 			},
 			CreatedAt: commit.Commit.Author.Date.Time,
+			Stats:     commitStats(commit.Stats),
+			Comments:  commitComments(result.comments),
+			FetchedAt: &result.fetchedAt,
 			Repository: models.Repository{
 				FullName: result.Repository,
 			},
 		})
 	}
 
-	err := publishWithRetry(ctx, ch, publishQueue, payload)
+	err := pub.publish(ctx, payload)
 	if err != nil {
 		log.Printf("Failed to publish commits batch after retries: %v", err)
 	}
 }
 
-func repoResolver(ctx context.Context, ch *amqp.Channel, publishQueue string, repoChan <-chan *github.Repository) {
+func commitStats(stats *github.CommitStats) *models.CommitStats {
+	if stats == nil {
+		return nil
+	}
+	return &models.CommitStats{
+		Additions: int32(stats.GetAdditions()),
+		Deletions: int32(stats.GetDeletions()),
+		Changes:   int32(stats.GetTotal()),
+	}
+}
+
+func commitComments(comments []*github.RepositoryComment) []models.CommitComment {
+	if len(comments) == 0 {
+		return nil
+	}
+	converted := make([]models.CommitComment, 0, len(comments))
+	for _, comment := range comments {
+		converted = append(converted, models.CommitComment{
+			ID:        comment.GetID(),
+			Author:    comment.GetUser().GetLogin(),
+			Body:      comment.GetBody(),
+			Url:       comment.GetHTMLURL(),
+			CreatedAt: comment.GetCreatedAt().Time,
+		})
+	}
+	return converted
+}
+
+func repoResolver(ctx context.Context, pub *publisher, repoChan <-chan *RepoInfo) {
 	for {
 		select {
-		case repo, ok := <-repoChan:
+		case info, ok := <-repoChan:
 			if !ok {
 				return
 			}
+			repo := info.repo
 			payload := &events.CommitsCommand{
 				Kind: events.NewRepoInfoKind,
 				Payload: &events.CommitPayload{
 					Repo: &models.Repository{
-						ID:        *repo.ID,
-						FullName:  *repo.FullName,
-						CreatedAt: repo.CreatedAt.Time,
-						UpdatedAt: repo.UpdatedAt.Time,
-						Stars:     int32(*repo.StargazersCount),
-						Watchers:  int32(*repo.WatchersCount),
-						Forks:     int32(*repo.ForksCount),
-						Language:  *repo.Language,
+						ID:            *repo.ID,
+						FullName:      *repo.FullName,
+						CreatedAt:     repo.CreatedAt.Time,
+						UpdatedAt:     repo.UpdatedAt.Time,
+						Stars:         int32(*repo.StargazersCount),
+						Watchers:      int32(*repo.WatchersCount),
+						Forks:         int32(*repo.ForksCount),
+						Language:      *repo.Language,
+						Topics:        repo.Topics,
+						License:       repo.GetLicense().GetSPDXID(),
+						DefaultBranch: repo.GetDefaultBranch(),
+						Description:   repo.GetDescription(),
+						Homepage:      repo.GetHomepage(),
+						OpenIssues:    int32(repo.GetOpenIssuesCount()),
+						Archived:      repo.GetArchived(),
+						NetworkID:     repo.GetSource().GetID(),
 					},
+					IntentID: &info.intentID,
 				},
 			}
 
-			err := publishWithRetry(ctx, ch, publishQueue, payload)
+			err := pub.publish(ctx, payload)
 			if err != nil {
 				log.Printf("Failed to publish repo info after retries: %v", err)
 			}
@@ -275,101 +513,453 @@ func parseEvent(data []byte) (*events.IntentCommand, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal event: %w", err)
 	}
+	if event.Intent == nil {
+		return nil, errors.New("event has no intent")
+	}
 	return &event, nil
 }
 
-func fetchGithubInfo(ctx context.Context, client *github.Client, repoChan chan<- *github.Repository, ev *events.IntentPayload) error {
-	repo, _, err := client.Repositories.Get(ctx, ev.RepoOwner, ev.RepoName)
+func fetchGithubInfo(ctx context.Context, client *github.Client, gate *fetchGate, repoChan chan<- *RepoInfo, ev *events.IntentPayload) (*github.Repository, error) {
+	var repo *github.Repository
+	err := gate.call(ctx, func() error {
+		var err error
+		repo, _, err = client.Repositories.Get(ctx, ev.RepoOwner, ev.RepoName)
+		return err
+	})
 	if err != nil {
-		return fmt.Errorf("failed to fetch repo info: %w", err)
+		return nil, fmt.Errorf("failed to fetch repo info: %w", err)
+	}
+	// GitHub redirects requests for a renamed or moved repository, so its
+	// info carries the new name; the manager records the old one as an alias.
+	if !strings.EqualFold(repo.GetFullName(), ev.RepoOwner+"/"+ev.RepoName) {
+		log.Printf("repository %s/%s has moved to %s", ev.RepoOwner, ev.RepoName, repo.GetFullName())
 	}
 	select {
-	case repoChan <- repo:
+	case repoChan <- &RepoInfo{repo: repo, intentID: ev.ID}:
 	case <-ctx.Done():
-		return ctx.Err()
+		return nil, ctx.Err()
+	}
+	return repo, nil
+}
+
+func fetchCommits(ctx context.Context, client *github.Client, checkpoints checkpointStore, gate *fetchGate, seen *commitSet, commitsChan chan<- *CommitResult, ev *events.IntentPayload) error {
+	branches := []string{""}
+	if ev.Branch != "" {
+		branches = []string{ev.Branch}
+	}
+	if ev.IndexAllBranches {
+		var err error
+		branches, err = listBranches(ctx, client, gate, ev)
+		if err != nil {
+			return err
+		}
+	}
+
+	queries := commitQueries(branches, ev.PathFilters, ev.AuthorFilters)
+	if seen.limit > 0 && len(queries) > 1 {
+		return fetchNewestCommits(ctx, client, gate, seen, commitsChan, ev, queries)
+	}
+
+	for _, query := range queries {
+		if seen.full() {
+			break
+		}
+		if err := fetchQueryCommits(ctx, client, checkpoints, gate, seen, commitsChan, ev, query); err != nil {
+			return fmt.Errorf("branch %q, path %q, author %q: %w", query.branch, query.path, query.author, err)
+		}
 	}
 	return nil
 }
 
-func fetchCommits(ctx context.Context, client *github.Client, commitsChan chan<- *CommitResult, ev *events.IntentPayload) error {
-	opts := &github.CommitsListOptions{
-		Since: ev.From,
+// fetchNewestCommits sends the seen.limit newest commits matched by any of
+// queries. Each query may hold the newest commits, so it lists the newest
+// seen.limit of every query and keeps the newest of them by commit date.
+// Commit details are only fetched for the commits it keeps.
+func fetchNewestCommits(ctx context.Context, client *github.Client, gate *fetchGate, seen *commitSet, commitsChan chan<- *CommitResult, ev *events.IntentPayload, queries []commitQuery) error {
+	listGate := *gate
+	listGate.stats = false
+
+	merged := make(map[string]*github.RepositoryCommit)
+	for _, query := range queries {
+		listed := make(chan *CommitResult, seen.limit)
+		if err := fetchQueryCommits(ctx, client, nil, &listGate, newCommitSet(seen.limit), listed, ev, query); err != nil {
+			return fmt.Errorf("branch %q, path %q, author %q: %w", query.branch, query.path, query.author, err)
+		}
+		close(listed)
+		for result := range listed {
+			merged[result.commit.GetSHA()] = result.commit
+		}
+	}
+
+	newest := make([]*github.RepositoryCommit, 0, len(merged))
+	for _, commit := range merged {
+		newest = append(newest, commit)
+	}
+	sort.Slice(newest, func(i, j int) bool {
+		return commitDate(newest[i]).After(commitDate(newest[j]))
+	})
+
+	for _, commit := range newest {
+		if !seen.add(commit.GetSHA()) {
+			continue
+		}
+		seen.reached(commitDate(commit))
+		select {
+		case commitsChan <- commitResult(ctx, client, gate, ev, commit):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// commitDate is when commit was committed, which orders GitHub's listings.
+func commitDate(commit *github.RepositoryCommit) time.Time {
+	return commit.GetCommit().GetCommitter().GetDate().Time
+}
+
+// commitQuery narrows a commit listing. Empty fields are left unfiltered,
+// so the zero value lists the default branch.
+type commitQuery struct {
+	branch string
+	path   string
+	author string
+}
+
+// commitQueries expands the intent's filters into one query per
+// combination, since GitHub accepts a single value for each.
+func commitQueries(branches, paths, authors []string) []commitQuery {
+	if len(paths) == 0 {
+		paths = []string{""}
+	}
+	if len(authors) == 0 {
+		authors = []string{""}
+	}
+
+	queries := make([]commitQuery, 0, len(branches)*len(paths)*len(authors))
+	for _, branch := range branches {
+		for _, path := range paths {
+			for _, author := range authors {
+				queries = append(queries, commitQuery{branch: branch, path: path, author: author})
+			}
+		}
+	}
+	return queries
+}
+
+// excludedAuthor reports whether commit was authored by one of excluded,
+// given as GitHub logins or email addresses in any case.
+func excludedAuthor(commit *github.RepositoryCommit, excluded []string) bool {
+	login := commit.GetAuthor().GetLogin()
+	email := commit.GetCommit().GetAuthor().GetEmail()
+	for _, author := range excluded {
+		if (login != "" && strings.EqualFold(author, login)) || (email != "" && strings.EqualFold(author, email)) {
+			return true
+		}
+	}
+	return false
+}
+
+func fetchQueryCommits(ctx context.Context, client *github.Client, checkpoints checkpointStore, gate *fetchGate, seen *commitSet, commitsChan chan<- *CommitResult, ev *events.IntentPayload, query commitQuery) error {
+	opts := github.CommitsListOptions{
+		SHA:    query.branch,
+		Path:   query.path,
+		Author: query.author,
+		Since:  ev.From,
 		ListOptions: github.ListOptions{
 			PerPage: 100,
 		},
 	}
+	// Until is a date and includes the whole of that day.
+	if !ev.Until.IsZero() {
+		opts.Until = ev.Until.AddDate(0, 0, 1).Add(-time.Second)
+	}
 
-	for {
-		commits, resp, err := client.Repositories.ListCommits(ctx, ev.RepoOwner, ev.RepoName, opts)
+	// A shallow index wants the newest commits whatever their age, and stops
+	// paging as soon as it has enough of them.
+	if seen.limit > 0 {
+		opts.Since = time.Time{}
+		opts.PerPage = min(opts.PerPage, seen.limit)
+	}
+
+	// A run picks up from the newest commit the intent's last complete run
+	// of the query listed, less the overlap, and skips the commits that run
+	// listed since then. Reindexes and shallow runs start over.
+	var cursor syncCursor
+	var cursorAt string
+	var walk *cursorWalk
+	if checkpoints != nil && ev.ReindexID == nil && seen.limit == 0 {
+		cursorAt = cursorKey(ev, query)
+		walk = newCursorWalk(gate.overlap)
+		var err error
+		cursor, err = checkpoints.loadCursor(cursorAt)
 		if err != nil {
-			return fmt.Errorf("error fetching commits: %w", err)
+			log.Printf("Starting %s from the start date: %v", cursorAt, err)
+		}
+		if from := cursor.At.Add(-gate.overlap); cursor.SHA != "" && from.After(opts.Since) {
+			opts.Since = from
 		}
+		if cursor.SHA != "" {
+			seen.skip(cursor.SHA)
+			for _, sha := range cursor.Recent {
+				seen.skip(sha)
+			}
+		}
+	}
+
+	// Through GraphQL each page of commits comes with their line counts.
+	// GraphQL can't list a login's commits, so those queries use REST, as
+	// does a query GraphQL fails, which skips the commits already sent.
+	if gate.graphQL && query.author == "" {
+		err := fetchGraphQLCommits(ctx, client, gate, seen, commitsChan, walk, ev, query, opts.Since, opts.Until, opts.PerPage)
+		if err == nil {
+			saveCursor(checkpoints, cursorAt, walk.cursor(cursor), seen)
+			return nil
+		}
+		if ctx.Err() != nil {
+			return err
+		}
+		log.Printf("Listing %s/%s commits through REST: %v", ev.RepoOwner, ev.RepoName, err)
+	}
+
+	// A full-history backfill walks its pages in order so it can resume
+	// from a checkpoint.
+	var checkpoint string
+	if ev.From.IsZero() && seen.limit == 0 {
+		checkpoint = checkpointKey(ev, query)
+		page, err := checkpoints.load(checkpoint)
+		if err != nil {
+			log.Printf("Starting %s from the first page: %v", checkpoint, err)
+		}
+		opts.Page = page
+	}
+	// GitHub lists the newest commits first, but a resumed backfill starts
+	// past them, so leaves the cursor as it was.
+	if opts.Page > 1 {
+		walk = nil
+	}
+
+	resp, err := fetchCommitsPage(ctx, client, gate, seen, commitsChan, walk, ev, opts)
+	if err != nil {
+		return err
+	}
 
-		for _, commit := range commits {
-			select {
-			case commitsChan <- &CommitResult{
-				Repository: fmt.Sprintf("%s/%s", ev.RepoOwner, ev.RepoName),
-				commit:     commit,
-			}:
-			case <-ctx.Done():
-				return ctx.Err()
+	// GitHub only reports the last page once it knows the result set, so
+	// the remaining pages can be fetched concurrently. Without it, walk them.
+	if gate.pages == 1 || resp.LastPage == 0 || seen.limit > 0 || checkpoint != "" {
+		for resp.NextPage != 0 && !seen.full() {
+			opts.Page = resp.NextPage
+			if checkpoint != "" {
+				checkpoints.save(checkpoint, opts.Page)
+			}
+			resp, err = fetchCommitsPage(ctx, client, gate, seen, commitsChan, walk, ev, opts)
+			if err != nil {
+				return err
 			}
 		}
+		if checkpoint != "" {
+			checkpoints.clear(checkpoint)
+		}
+		saveCursor(checkpoints, cursorAt, walk.cursor(cursor), seen)
+		return nil
+	}
 
-		if resp.NextPage == 0 {
-			break
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(gate.pages)
+	for page := resp.NextPage; page <= resp.LastPage; page++ {
+		pageOpts := opts
+		pageOpts.Page = page
+		g.Go(func() error {
+			_, err := fetchCommitsPage(gctx, client, gate, seen, commitsChan, walk, ev, pageOpts)
+			return err
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return err
+	}
+	saveCursor(checkpoints, cursorAt, walk.cursor(cursor), seen)
+	return nil
+}
+
+// saveCursor keeps cursor once its query has been listed in full. A run
+// stopped by its commit limit hasn't, and neither has one whose listing
+// returned no commits at all.
+func saveCursor(checkpoints checkpointStore, key string, cursor syncCursor, seen *commitSet) {
+	if key == "" || cursor.SHA == "" || seen.full() {
+		return
+	}
+	checkpoints.saveCursor(key, cursor)
+}
+
+// fetchCommitsPage sends the new commits of a page of the listing, and
+// records every commit on it in walk.
+func fetchCommitsPage(ctx context.Context, client *github.Client, gate *fetchGate, seen *commitSet, commitsChan chan<- *CommitResult, walk *cursorWalk, ev *events.IntentPayload, opts github.CommitsListOptions) (*github.Response, error) {
+	var commits []*github.RepositoryCommit
+	var resp *github.Response
+	err := gate.call(ctx, func() error {
+		var err error
+		commits, resp, err = client.Repositories.ListCommits(ctx, ev.RepoOwner, ev.RepoName, &opts)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error fetching commits page %d: %w", opts.Page, err)
+	}
+
+	seen.fetchedPage()
+	for _, commit := range commits {
+		walk.list(commit.GetSHA(), commitDate(commit))
+		if excludedAuthor(commit, ev.ExcludedAuthors) {
+			continue
+		}
+		if !seen.add(commit.GetSHA()) {
+			continue
+		}
+		seen.reached(commitDate(commit))
+		select {
+		case commitsChan <- commitResult(ctx, client, gate, ev, commit):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	return resp, nil
+}
+
+// commitResult fetches what the gate asks for of commit beyond its listing.
+// A commit whose stats or comments can't be fetched is indexed without.
+func commitResult(ctx context.Context, client *github.Client, gate *fetchGate, ev *events.IntentPayload, commit *github.RepositoryCommit) *CommitResult {
+	result := &CommitResult{
+		Repository: fmt.Sprintf("%s/%s", ev.RepoOwner, ev.RepoName),
+		commit:     commit,
+		reindexID:  ev.ReindexID,
+	}
+	if gate.stats && commit.Stats == nil {
+		if err := fetchCommitStats(ctx, client, gate, ev, commit); err != nil {
+			log.Printf("Indexing %s without stats: %v", commit.GetSHA(), err)
 		}
+	}
+	if gate.comments && commit.GetCommit().GetCommentCount() > 0 {
+		comments, err := fetchCommitComments(ctx, client, gate, ev, commit.GetSHA())
+		if err != nil {
+			log.Printf("Indexing %s without comments: %v", commit.GetSHA(), err)
+		}
+		result.comments = comments
+	}
+	result.fetchedAt = time.Now()
+	return result
+}
 
+// fetchCommitComments lists every comment on the commit.
+func fetchCommitComments(ctx context.Context, client *github.Client, gate *fetchGate, ev *events.IntentPayload, sha string) ([]*github.RepositoryComment, error) {
+	var comments []*github.RepositoryComment
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		var page []*github.RepositoryComment
+		var resp *github.Response
+		err := gate.call(ctx, func() error {
+			var err error
+			page, resp, err = client.Repositories.ListCommitComments(ctx, ev.RepoOwner, ev.RepoName, sha, opts)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list commit comments: %w", err)
+		}
+		comments = append(comments, page...)
+		if resp.NextPage == 0 {
+			return comments, nil
+		}
 		opts.Page = resp.NextPage
 	}
+}
+
+// fetchCommitStats fills in commit.Stats, which commit listings omit.
+func fetchCommitStats(ctx context.Context, client *github.Client, gate *fetchGate, ev *events.IntentPayload, commit *github.RepositoryCommit) error {
+	var detailed *github.RepositoryCommit
+	err := gate.call(ctx, func() error {
+		var err error
+		detailed, _, err = client.Repositories.GetCommit(ctx, ev.RepoOwner, ev.RepoName, commit.GetSHA(), nil)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to fetch commit details: %w", err)
+	}
+	commit.Stats = detailed.Stats
 	return nil
 }
 
-func acquireLock(client *redis.Client, key string, ttl time.Duration) (bool, error) {
+func acquireLock(locks repolocks.Locker, key string, holder repolocks.Holder) (repolocks.Lease, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	ok, err := client.SetNX(ctx, key, "locked", ttl).Result()
-	if err != nil {
-		return false, fmt.Errorf("failed to acquire lock: %w", err)
-	}
-	return ok, nil
+	return locks.Acquire(ctx, key, holder)
 }
 
-func releaseLock(client *redis.Client, key string) {
+func releaseLock(held repolocks.Lease, repo string) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	_, err := client.Del(ctx, key).Result()
+	if err := held.Release(ctx); err != nil {
+		log.Printf("Failed to release lock for %s: %v", repo, err)
+	}
+}
+
+// publisher sends events to the manager, retrying failed publishes under
+// the monitor's retry policy. Commit batches over maxSize bytes are split
+// across messages.
+type publisher struct {
+	broker  broker.Broker
+	queue   string
+	retry   retry.Policy
+	maxSize int
+}
+
+func (p *publisher) publish(ctx context.Context, ev *events.CommitsCommand) error {
+	bodies, err := splitCommand(ev, p.maxSize)
 	if err != nil {
-		log.Printf("Failed to release lock for %s: %v", key, err)
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+	if len(bodies) > 1 {
+		log.Printf("Split a batch of %d commits into %d messages", len(ev.Payload.Commits), len(bodies))
 	}
+
+	for _, body := range bodies {
+		err := retry.Do(ctx, p.retry, func() error {
+			return p.broker.Publish(ctx, p.queue, body)
+		}, func(attempt int, err error) {
+			log.Printf("Failed to publish (attempt %d/%d): %v", attempt, p.retry.MaxAttempts, err)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func publishWithRetry(ctx context.Context, ch *amqp.Channel, queueName string, ev *events.CommitsCommand) error {
+// splitCommand marshals ev, halving its commits across messages until
+// each body is at most maxSize bytes, or holds a single commit. The
+// manager saves each part on its own, and saving a commit twice is
+// harmless, so a part that fails is simply published again.
+func splitCommand(ev *events.CommitsCommand, maxSize int) ([][]byte, error) {
 	body, err := json.Marshal(ev)
 	if err != nil {
-		return fmt.Errorf("failed to marshal event: %w", err)
+		return nil, err
+	}
+	if maxSize <= 0 || len(body) <= maxSize || ev.Payload == nil || len(ev.Payload.Commits) < 2 {
+		if maxSize > 0 && len(body) > maxSize {
+			log.Printf("Publishing a %d byte message over the %d byte limit", len(body), maxSize)
+		}
+		return [][]byte{body}, nil
 	}
 
-	for i := 0; i < maxRetries; i++ {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-			err = ch.PublishWithContext(ctx,
-				"",
-				queueName,
-				false,
-				false,
-				amqp.Publishing{
-					ContentType: "application/json",
-					Body:        body,
-				})
-			if err == nil {
-				return nil
-			}
-			log.Printf("Failed to publish (attempt %d/%d): %v", i+1, maxRetries, err)
-			time.Sleep(retryDelay)
+	commits := ev.Payload.Commits
+	half := len(commits) / 2
+	var bodies [][]byte
+	for _, part := range [][]*models.Commit{commits[:half], commits[half:]} {
+		payload := *ev.Payload
+		payload.Commits = part
+		split, err := splitCommand(&events.CommitsCommand{Kind: ev.Kind, Payload: &payload}, maxSize)
+		if err != nil {
+			return nil, err
 		}
+		bodies = append(bodies, split...)
 	}
-	return fmt.Errorf("failed to publish after %d attempts", maxRetries)
+	return bodies, nil
 }
@@ -0,0 +1,873 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v63/github"
+	"github.com/google/uuid"
+	"github.com/noelukwa/indexer/internal/events"
+	"github.com/noelukwa/indexer/internal/manager/models"
+	"github.com/noelukwa/indexer/internal/pkg/githubtest"
+	"github.com/noelukwa/indexer/internal/pkg/testenv"
+	"github.com/redis/go-redis/v9"
+	"github.com/test-go/testify/assert"
+	"github.com/test-go/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	testenv.Terminate()
+	os.Exit(code)
+}
+
+// commitsPath is the listing fetchCommits requests for testIntent, before
+// any page is added.
+const commitsPath = "/repos/owner/repo/commits?per_page=100&since=2024-01-01T00%3A00%3A00Z"
+
+// testIntent starts from a date, which keeps the run off Redis checkpoints.
+func testIntent() *events.IntentPayload {
+	return &events.IntentPayload{
+		RepoOwner: "owner",
+		RepoName:  "repo",
+		From:      time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func testGate() *fetchGate {
+	return &fetchGate{slots: make(chan struct{}, 1), pages: 1}
+}
+
+func testCommits(shas ...string) []*github.RepositoryCommit {
+	commits := make([]*github.RepositoryCommit, len(shas))
+	for i, sha := range shas {
+		commits[i] = &github.RepositoryCommit{SHA: github.String(sha)}
+	}
+	return commits
+}
+
+// drain returns the SHAs of the commits sent so far.
+func drain(commitsChan chan *CommitResult) []string {
+	var shas []string
+	for {
+		select {
+		case result := <-commitsChan:
+			shas = append(shas, result.commit.GetSHA())
+		default:
+			return shas
+		}
+	}
+}
+
+func TestFetchCommits_Pages(t *testing.T) {
+	server := githubtest.NewServer(
+		githubtest.Page("GET", commitsPath, 2, testCommits("a", "b")),
+		githubtest.Page("GET", "/repos/owner/repo/commits?page=2&per_page=100&since=2024-01-01T00%3A00%3A00Z", 3, testCommits("c", "b")),
+		githubtest.Page("GET", "/repos/owner/repo/commits?page=3&per_page=100&since=2024-01-01T00%3A00%3A00Z", 0, testCommits("d")),
+	)
+	defer server.Close()
+
+	seen := newCommitSet(0)
+	commitsChan := make(chan *CommitResult, 10)
+	err := fetchCommits(context.Background(), server.Client(), nil, testGate(), seen, commitsChan, testIntent())
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"a", "b", "c", "d"}, drain(commitsChan))
+	assert.Equal(t, int64(4), seen.count())
+	pages, _ := seen.progress()
+	assert.Equal(t, int64(3), pages)
+	assert.Empty(t, server.Misses())
+}
+
+func datedCommit(sha string, date time.Time) *github.RepositoryCommit {
+	return &github.RepositoryCommit{
+		SHA:    github.String(sha),
+		Commit: &github.Commit{Committer: &github.CommitAuthor{Date: &github.Timestamp{Time: date}}},
+	}
+}
+
+func TestFetchCommits_LimitKeepsNewestAcrossQueries(t *testing.T) {
+	day := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	server := githubtest.NewServer(
+		githubtest.Page("GET", "/repos/owner/repo/commits?author=alice&per_page=2", 0, []*github.RepositoryCommit{
+			datedCommit("a2", day.AddDate(0, 0, -2)), datedCommit("a5", day.AddDate(0, 0, -5)),
+		}),
+		githubtest.Page("GET", "/repos/owner/repo/commits?author=bob&per_page=2", 0, []*github.RepositoryCommit{
+			datedCommit("b1", day.AddDate(0, 0, -1)), datedCommit("b3", day.AddDate(0, 0, -3)),
+		}),
+	)
+	defer server.Close()
+
+	ev := testIntent()
+	ev.AuthorFilters = []string{"alice", "bob"}
+	seen := newCommitSet(2)
+	commitsChan := make(chan *CommitResult, 10)
+	err := fetchCommits(context.Background(), server.Client(), nil, testGate(), seen, commitsChan, ev)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b1", "a2"}, drain(commitsChan))
+	_, oldest := seen.progress()
+	assert.Equal(t, day.AddDate(0, 0, -2), oldest)
+	assert.Empty(t, server.Misses())
+}
+
+func TestFetchCommits_UntilIncludesTheDay(t *testing.T) {
+	server := githubtest.NewServer(
+		githubtest.Page("GET", "/repos/owner/repo/commits?per_page=100&since=2024-01-01T00%3A00%3A00Z&until=2024-01-31T23%3A59%3A59Z", 0, testCommits("a")),
+	)
+	defer server.Close()
+
+	ev := testIntent()
+	ev.Until = time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+	commitsChan := make(chan *CommitResult, 10)
+	err := fetchCommits(context.Background(), server.Client(), nil, testGate(), newCommitSet(0), commitsChan, ev)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, drain(commitsChan))
+	assert.Empty(t, server.Misses())
+}
+
+func TestFetchCommits_BranchAndExcludedAuthors(t *testing.T) {
+	commits := testCommits("a", "b", "c")
+	commits[0].Author = &github.User{Login: github.String("Dependabot")}
+	commits[1].Commit = &github.Commit{Author: &github.CommitAuthor{Email: github.String("bot@example.com")}}
+	server := githubtest.NewServer(
+		githubtest.Page("GET", "/repos/owner/repo/commits?per_page=100&sha=release&since=2024-01-01T00%3A00%3A00Z", 0, commits),
+	)
+	defer server.Close()
+
+	ev := testIntent()
+	ev.Branch = "release"
+	ev.ExcludedAuthors = []string{"dependabot", "BOT@example.com"}
+	seen := newCommitSet(0)
+	commitsChan := make(chan *CommitResult, 10)
+	err := fetchCommits(context.Background(), server.Client(), nil, testGate(), seen, commitsChan, ev)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c"}, drain(commitsChan))
+	assert.Equal(t, int64(1), seen.count())
+	assert.Empty(t, server.Misses())
+}
+
+// historyPage is a GraphQL response listing commits with a line each.
+func historyPage(next string, shas ...string) map[string]interface{} {
+	nodes := make([]map[string]interface{}, len(shas))
+	for i, sha := range shas {
+		nodes[i] = map[string]interface{}{
+			"oid":           sha,
+			"message":       "commit " + sha,
+			"committedDate": "2024-06-01T00:00:00Z",
+			"additions":     1,
+			"deletions":     0,
+			"author":        map[string]interface{}{"name": "Alice", "email": "alice@example.com", "user": map[string]interface{}{"login": "alice", "databaseId": 7}},
+		}
+	}
+	return map[string]interface{}{"data": map[string]interface{}{"repository": map[string]interface{}{"object": map[string]interface{}{"history": map[string]interface{}{
+		"pageInfo": map[string]interface{}{"hasNextPage": next != "", "endCursor": next},
+		"nodes":    nodes,
+	}}}}}
+}
+
+func TestFetchCommits_GraphQL(t *testing.T) {
+	server := githubtest.NewServer(
+		githubtest.JSON("POST", "/graphql", historyPage("cursor", "a", "b")),
+		githubtest.JSON("POST", "/graphql", historyPage("", "c")),
+	)
+	defer server.Close()
+
+	gate := testGate()
+	gate.graphQL = true
+	gate.stats = true
+	commitsChan := make(chan *CommitResult, 10)
+	err := fetchCommits(context.Background(), server.Client(), nil, gate, newCommitSet(0), commitsChan, testIntent())
+	require.NoError(t, err)
+	close(commitsChan)
+
+	var shas []string
+	for result := range commitsChan {
+		shas = append(shas, result.commit.GetSHA())
+		assert.Equal(t, 1, result.commit.GetStats().GetTotal())
+		assert.Equal(t, "alice", result.commit.GetAuthor().GetLogin())
+	}
+	// The line counts come with the listing, so no commit is fetched.
+	assert.Equal(t, []string{"a", "b", "c"}, shas)
+	assert.Empty(t, server.Misses())
+}
+
+func TestFetchCommits_GraphQLFallsBackToREST(t *testing.T) {
+	server := githubtest.NewServer(
+		githubtest.JSON("POST", "/graphql", map[string]interface{}{"errors": []map[string]string{{"message": "Something went wrong"}}}),
+		githubtest.Page("GET", commitsPath, 0, testCommits("a", "b")),
+	)
+	defer server.Close()
+
+	gate := testGate()
+	gate.graphQL = true
+	commitsChan := make(chan *CommitResult, 10)
+	err := fetchCommits(context.Background(), server.Client(), nil, gate, newCommitSet(0), commitsChan, testIntent())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, drain(commitsChan))
+	assert.Empty(t, server.Misses())
+}
+
+func TestGraphQLURL(t *testing.T) {
+	client, err := withBaseURL(github.NewClient(nil), "https://github.example.com/api/v3/")
+	require.NoError(t, err)
+	assert.Equal(t, "https://github.example.com/api/graphql", graphQLURL(client))
+	assert.Equal(t, "https://api.github.com/graphql", graphQLURL(github.NewClient(nil)))
+}
+
+func TestFetchCommits_RateLimited(t *testing.T) {
+	server := githubtest.NewServer(
+		githubtest.Page("GET", commitsPath, 2, testCommits("a", "b")),
+		githubtest.RateLimited("GET", "/repos/owner/repo/commits?page=2&per_page=100&since=2024-01-01T00%3A00%3A00Z", time.Now().Add(time.Hour)),
+	)
+	defer server.Close()
+
+	gate := testGate()
+	gate.retry.MaxAttempts = 3
+	commitsChan := make(chan *CommitResult, 10)
+	err := fetchCommits(context.Background(), server.Client(), nil, gate, newCommitSet(0), commitsChan, testIntent())
+
+	// An exhausted quota lasts until its reset, so it is not retried.
+	var rateErr *github.RateLimitError
+	assert.True(t, errors.As(err, &rateErr), "got %v", err)
+	assert.Equal(t, []string{"a", "b"}, drain(commitsChan))
+	assert.Empty(t, server.Misses())
+}
+
+func TestRateBudget(t *testing.T) {
+	budget := &rateBudget{label: "default", reserve: 10}
+	now := time.Now()
+	reset := now.Add(time.Hour).Truncate(time.Second)
+	response := func(status int, headers map[string]string) *http.Response {
+		resp := &http.Response{StatusCode: status, Header: make(http.Header)}
+		for k, v := range headers {
+			resp.Header.Set(k, v)
+		}
+		return resp
+	}
+	quota := func(remaining int) map[string]string {
+		return map[string]string{
+			"X-RateLimit-Limit":     "5000",
+			"X-RateLimit-Remaining": fmt.Sprint(remaining),
+			"X-RateLimit-Reset":     fmt.Sprint(reset.Unix()),
+		}
+	}
+
+	budget.observe(response(http.StatusOK, quota(11)))
+	assert.True(t, budget.pausedUntil(now).IsZero())
+
+	// Search has a quota of its own.
+	search := quota(0)
+	search["X-RateLimit-Resource"] = "search"
+	budget.observe(response(http.StatusOK, search))
+	assert.True(t, budget.pausedUntil(now).IsZero())
+
+	budget.observe(response(http.StatusOK, quota(10)))
+	assert.Equal(t, reset, budget.pausedUntil(now))
+	assert.True(t, budget.pausedUntil(reset).IsZero())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.True(t, errors.Is(budget.wait(ctx), context.Canceled))
+
+	// A secondary rate limit pauses for as long as GitHub asks, and a 403
+	// that doesn't say is taken for a permission error.
+	budget = &rateBudget{label: "default"}
+	budget.observe(response(http.StatusForbidden, nil))
+	assert.True(t, budget.pausedUntil(now).IsZero())
+	budget.observe(response(http.StatusForbidden, map[string]string{"Retry-After": "30"}))
+	until := budget.pausedUntil(time.Now())
+	assert.WithinDuration(t, time.Now().Add(30*time.Second), until, time.Second)
+	status := budget.status()
+	require.NotNil(t, status.PausedUntil)
+	assert.Equal(t, until, *status.PausedUntil)
+}
+
+func TestETagTransport(t *testing.T) {
+	redisClient := redis.NewClient(testenv.Redis(t))
+	defer redisClient.Close()
+
+	var full, notModified atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", fmt.Sprint(4999-full.Load()))
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		full.Add(1)
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(testCommits("a", "b"))
+	}))
+	defer server.Close()
+
+	client, err := withBaseURL(github.NewClient(nil), server.URL)
+	require.NoError(t, err)
+	client = withETags(client, redisClient, time.Hour)
+
+	var resp *github.Response
+	for i := 0; i < 2; i++ {
+		var commits []*github.RepositoryCommit
+		commits, resp, err = client.Repositories.ListCommits(context.Background(), "owner", "repo", nil)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Len(t, commits, 2)
+		assert.Equal(t, "a", commits[0].GetSHA())
+	}
+	// The second listing was answered from the cache, with the quota the
+	// 304 reported.
+	assert.Equal(t, int32(1), full.Load())
+	assert.Equal(t, int32(1), notModified.Load())
+	assert.Equal(t, "4998", resp.Header.Get("X-RateLimit-Remaining"))
+}
+
+func TestWorkerPool(t *testing.T) {
+	pool := newWorkerPool(2)
+
+	var mu sync.Mutex
+	var order []string
+	running := make(map[string]int)
+	release := make(chan struct{})
+	started := make(chan string, 10)
+	job := func(repo, name string) func() {
+		return func() {
+			mu.Lock()
+			running[repo]++
+			assert.Equal(t, 1, running[repo], "%s ran alongside another of its intents", repo)
+			order = append(order, name)
+			mu.Unlock()
+			started <- name
+			<-release
+			mu.Lock()
+			running[repo]--
+			mu.Unlock()
+		}
+	}
+
+	assert.True(t, pool.submit("owner/a", "a1", job("owner/a", "a1")))
+	assert.True(t, pool.submit("owner/a", "a2", job("owner/a", "a2")))
+	assert.True(t, pool.submit("owner/a", "a3", job("owner/a", "a3")))
+	assert.True(t, pool.submit("owner/b", "b1", job("owner/b", "b1")))
+
+	// b gets the second worker while a's intents wait for the first.
+	first := []string{<-started, <-started}
+	sort.Strings(first)
+	assert.Equal(t, []string{"a1", "b1"}, first)
+	assert.Equal(t, poolStatus{Workers: 2, Busy: 2, Queued: 2, Repositories: 1}, pool.status())
+
+	// A run still waiting isn't queued again, but one already running is.
+	assert.False(t, pool.submit("owner/a", "a2", job("owner/a", "a2")))
+	assert.True(t, pool.submit("owner/b", "b1", job("owner/b", "b1 again")))
+
+	var metrics strings.Builder
+	require.NoError(t, pool.writeMetrics(&metrics))
+	assert.Contains(t, metrics.String(), "indexer_monitor_workers_busy 2\n")
+	assert.Contains(t, metrics.String(), "indexer_monitor_intents_queued 3\n")
+	assert.Contains(t, metrics.String(), "indexer_monitor_intents_duplicate_total 1\n")
+
+	close(release)
+	pool.close()
+	assert.Equal(t, []string{"a2", "a3"}, filterPrefix(order[2:], "a"))
+	assert.Equal(t, poolStatus{Workers: 2}, pool.status())
+
+	metrics.Reset()
+	require.NoError(t, pool.writeMetrics(&metrics))
+	assert.Contains(t, metrics.String(), "indexer_monitor_intents_total 5\n")
+}
+
+func filterPrefix(names []string, prefix string) []string {
+	var kept []string
+	for _, name := range names {
+		if strings.HasPrefix(name, prefix) {
+			kept = append(kept, name)
+		}
+	}
+	return kept
+}
+
+func TestTokenPool(t *testing.T) {
+	var used []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		used = append(used, strings.TrimPrefix(auth, "Bearer "))
+		if auth == "Bearer revoked" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	budgets := []*rateBudget{{label: "default:1"}, {label: "default:2"}, {label: "default:3"}}
+	pool := newTokenPool(http.DefaultTransport, []string{"a", "revoked", "c"}, budgets)
+	client := &http.Client{Transport: pool}
+	get := func() {
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+
+	// The rejected token is dropped and its request made with the next.
+	get()
+	get()
+	get()
+	assert.Equal(t, []string{"a", "revoked", "c", "a"}, used)
+	assert.True(t, budgets[1].isRevoked())
+
+	// A paused token is passed over until it resumes.
+	budgets[2].retryAt = time.Now().Add(time.Hour)
+	used = nil
+	get()
+	get()
+	assert.Equal(t, []string{"a", "a"}, used)
+
+	budgets[0].retryAt = time.Now().Add(time.Minute)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.True(t, errors.Is(pool.wait(ctx), context.Canceled))
+
+	budgets[0].revoke()
+	budgets[2].revoke()
+	_, err := client.Get(server.URL)
+	assert.True(t, errors.Is(err, errNoTokens))
+}
+
+func TestAppJWT(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	now := time.Unix(1700000000, 0)
+
+	jwt, err := appJWT(42, key, now)
+	require.NoError(t, err)
+	parts := strings.Split(jwt, ".")
+	require.Len(t, parts, 3)
+
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	require.NoError(t, err)
+	require.NoError(t, rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature))
+
+	raw, err := base64.RawURLEncoding.DecodeString(parts[1])
+	require.NoError(t, err)
+	var claims map[string]int64
+	require.NoError(t, json.Unmarshal(raw, &claims))
+	assert.Equal(t, map[string]int64{"iat": 1699999940, "exp": 1700000540, "iss": 42}, claims)
+}
+
+func TestInstallationTokens(t *testing.T) {
+	expires := time.Now().Add(time.Hour).Truncate(time.Second)
+	server := githubtest.NewServer(
+		githubtest.JSON("POST", "/app/installations/7/access_tokens", &github.InstallationToken{
+			Token:     github.String("ghs_installation"),
+			ExpiresAt: &github.Timestamp{Time: expires},
+		}),
+	)
+	defer server.Close()
+
+	token, err := installationTokens{app: server.Client(), installationID: 7}.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "ghs_installation", token.AccessToken)
+	assert.True(t, expires.Equal(token.Expiry))
+	assert.Empty(t, server.Misses())
+}
+
+func TestFetchCommits_ServerErrorRetried(t *testing.T) {
+	server := githubtest.NewServer(
+		githubtest.Error("GET", commitsPath, 502, "Bad Gateway"),
+		githubtest.Page("GET", commitsPath, 0, testCommits("a")),
+	)
+	defer server.Close()
+
+	gate := testGate()
+	gate.retry.MaxAttempts = 2
+	commitsChan := make(chan *CommitResult, 10)
+	err := fetchCommits(context.Background(), server.Client(), nil, gate, newCommitSet(0), commitsChan, testIntent())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, drain(commitsChan))
+}
+
+func TestFetchCommits_Comments(t *testing.T) {
+	commented := &github.RepositoryCommit{SHA: github.String("a"), Commit: &github.Commit{CommentCount: github.Int(2)}}
+	server := githubtest.NewServer(
+		githubtest.Page("GET", commitsPath, 0, []*github.RepositoryCommit{commented, {SHA: github.String("b")}}),
+		githubtest.Page("GET", "/repos/owner/repo/commits/a/comments?per_page=100", 2, []*github.RepositoryComment{
+			{ID: github.Int64(1), Body: github.String("Why?"), User: &github.User{Login: github.String("alice")}},
+		}),
+		githubtest.Page("GET", "/repos/owner/repo/commits/a/comments?page=2&per_page=100", 0, []*github.RepositoryComment{
+			{ID: github.Int64(2), Body: github.String("Because."), User: &github.User{Login: github.String("bob")}},
+		}),
+	)
+	defer server.Close()
+
+	gate := testGate()
+	gate.comments = true
+	commitsChan := make(chan *CommitResult, 10)
+	err := fetchCommits(context.Background(), server.Client(), nil, gate, newCommitSet(0), commitsChan, testIntent())
+	require.NoError(t, err)
+
+	// Only commits with comments cost a request.
+	a, b := <-commitsChan, <-commitsChan
+	comments := commitComments(a.comments)
+	require.Len(t, comments, 2)
+	assert.Equal(t, "alice", comments[0].Author)
+	assert.Equal(t, "Because.", comments[1].Body)
+	assert.Empty(t, b.comments)
+	assert.Empty(t, server.Misses())
+}
+
+func TestFetchReviews(t *testing.T) {
+	ev := testIntent()
+	at := func(days int) *github.Timestamp { return &github.Timestamp{Time: ev.From.AddDate(0, 0, days)} }
+	author := &github.User{Login: github.String("author")}
+	server := githubtest.NewServer(
+		githubtest.Page("GET", "/repos/owner/repo/pulls?direction=desc&per_page=100&sort=updated&state=all", 0, []*github.PullRequest{
+			{Number: github.Int(2), User: author, CreatedAt: at(3), UpdatedAt: at(4)},
+			{Number: github.Int(1), User: author, CreatedAt: at(-10), UpdatedAt: at(-1)},
+		}),
+		githubtest.Page("GET", "/repos/owner/repo/pulls/2/reviews?per_page=100", 0, []*github.PullRequestReview{
+			{ID: github.Int64(20), User: &github.User{Login: github.String("alice")}, State: github.String("APPROVED"), SubmittedAt: at(4)},
+			{ID: github.Int64(21), User: &github.User{Login: github.String("bob")}, State: github.String("PENDING")},
+		}),
+	)
+	defer server.Close()
+
+	lifecycleChan := make(chan *events.CommitsCommand, 10)
+	err := fetchReviews(context.Background(), server.Client(), testGate(), lifecycleChan, ev)
+	require.NoError(t, err)
+
+	// Pull requests last updated before the start date end the listing, and
+	// pending reviews are left out.
+	require.Len(t, lifecycleChan, 1)
+	command := <-lifecycleChan
+	assert.Equal(t, events.NewReviewsKind, command.Kind)
+	require.Len(t, command.Payload.Reviews, 1)
+	review := command.Payload.Reviews[0]
+	assert.Equal(t, "alice", review.Reviewer)
+	assert.Equal(t, "author", review.PullRequestAuthor)
+	assert.Equal(t, int32(2), review.PullRequest)
+	assert.Equal(t, "owner/repo", review.Repository)
+	assert.Empty(t, server.Misses())
+}
+
+func TestFetchWorkflowRuns(t *testing.T) {
+	started := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	server := githubtest.NewServer(
+		githubtest.Page("GET", "/repos/owner/repo/actions/runs?created=%3E%3D2024-01-01&per_page=100&status=completed", 0, &github.WorkflowRuns{
+			TotalCount: github.Int(1),
+			WorkflowRuns: []*github.WorkflowRun{{
+				ID:           github.Int64(9),
+				Name:         github.String("CI"),
+				HeadSHA:      github.String("abc"),
+				Conclusion:   github.String("success"),
+				RunStartedAt: &github.Timestamp{Time: started},
+				UpdatedAt:    &github.Timestamp{Time: started.Add(5 * time.Minute)},
+			}},
+		}),
+	)
+	defer server.Close()
+
+	lifecycleChan := make(chan *events.CommitsCommand, 10)
+	err := fetchWorkflowRuns(context.Background(), server.Client(), testGate(), lifecycleChan, testIntent())
+	require.NoError(t, err)
+
+	require.Len(t, lifecycleChan, 1)
+	command := <-lifecycleChan
+	assert.Equal(t, events.NewWorkflowRunsKind, command.Kind)
+	require.Len(t, command.Payload.WorkflowRuns, 1)
+	run := command.Payload.WorkflowRuns[0]
+	assert.Equal(t, "abc", run.HeadSHA)
+	assert.Equal(t, 5*time.Minute, run.CompletedAt.Sub(run.StartedAt))
+	assert.Empty(t, server.Misses())
+}
+
+func TestFetchSecurityAlerts(t *testing.T) {
+	created := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	alert := func(number int, state, severity string) *github.DependabotAlert {
+		return &github.DependabotAlert{
+			Number: github.Int(number),
+			State:  github.String(state),
+			Dependency: &github.Dependency{
+				Package:      &github.VulnerabilityPackage{Ecosystem: github.String("npm"), Name: github.String("lodash")},
+				ManifestPath: github.String("package-lock.json"),
+			},
+			SecurityAdvisory: &github.DependabotSecurityAdvisory{
+				GHSAID:   github.String("GHSA-xxxx"),
+				Severity: github.String(severity),
+			},
+			CreatedAt: &github.Timestamp{Time: created},
+			UpdatedAt: &github.Timestamp{Time: created},
+		}
+	}
+	first := githubtest.JSON("GET", "/repos/owner/repo/dependabot/alerts?per_page=100", []*github.DependabotAlert{alert(2, "open", "high")})
+	first.Header = http.Header{"Link": {`<https://api.github.com/repos/owner/repo/dependabot/alerts?after=Y3Vyc29y&per_page=100>; rel="next"`}}
+	fixed := alert(1, "fixed", "critical")
+	fixed.FixedAt = &github.Timestamp{Time: created.Add(time.Hour)}
+	server := githubtest.NewServer(
+		first,
+		githubtest.JSON("GET", "/repos/owner/repo/dependabot/alerts?after=Y3Vyc29y&per_page=100", []*github.DependabotAlert{fixed}),
+	)
+	defer server.Close()
+
+	lifecycleChan := make(chan *events.CommitsCommand, 10)
+	err := fetchSecurityAlerts(context.Background(), server.Client(), testGate(), lifecycleChan, testIntent())
+	require.NoError(t, err)
+
+	require.Len(t, lifecycleChan, 2)
+	command := <-lifecycleChan
+	assert.Equal(t, events.NewSecurityAlertsKind, command.Kind)
+	require.Len(t, command.Payload.SecurityAlerts, 1)
+	open := command.Payload.SecurityAlerts[0]
+	assert.Equal(t, "owner/repo", open.Repository)
+	assert.Equal(t, "high", open.Severity)
+	assert.Equal(t, "lodash", open.Package)
+	assert.Nil(t, open.FixedAt)
+	command = <-lifecycleChan
+	assert.Equal(t, "fixed", command.Payload.SecurityAlerts[0].State)
+	assert.NotNil(t, command.Payload.SecurityAlerts[0].FixedAt)
+	assert.Empty(t, server.Misses())
+}
+
+func TestFetchSecurityAlerts_NoAccess(t *testing.T) {
+	server := githubtest.NewServer(
+		githubtest.Error("GET", "/repos/owner/repo/dependabot/alerts?per_page=100", http.StatusForbidden, "Resource not accessible by integration"),
+	)
+	defer server.Close()
+
+	lifecycleChan := make(chan *events.CommitsCommand, 10)
+	err := fetchSecurityAlerts(context.Background(), server.Client(), testGate(), lifecycleChan, testIntent())
+	require.NoError(t, err)
+	assert.Empty(t, lifecycleChan)
+}
+
+func TestCreatedRange(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, "", createdRange(time.Time{}, time.Time{}))
+	assert.Equal(t, ">=2024-01-01", createdRange(from, time.Time{}))
+	assert.Equal(t, "<=2024-06-30", createdRange(time.Time{}, until))
+	assert.Equal(t, "2024-01-01..2024-06-30", createdRange(from, until))
+}
+
+func TestFetchGitHubStats(t *testing.T) {
+	week := time.Date(2024, 6, 2, 0, 0, 0, 0, time.UTC)
+	server := githubtest.NewServer(
+		githubtest.JSON("GET", "/repos/owner/repo/stats/code_frequency", [][]int64{{week.Unix(), 120, -40}}),
+		githubtest.JSON("GET", "/repos/owner/repo/stats/participation", map[string][]int{"all": {3, 5}, "owner": {1, 0}}),
+		githubtest.Interaction{Method: "GET", Path: "/repos/owner/repo/stats/punch_card", Status: 202},
+	)
+	defer server.Close()
+
+	lifecycleChan := make(chan *events.CommitsCommand, 10)
+	err := fetchGitHubStats(context.Background(), server.Client(), testGate(), lifecycleChan, testIntent())
+	require.NoError(t, err)
+
+	require.Len(t, lifecycleChan, 1)
+	command := <-lifecycleChan
+	assert.Equal(t, events.NewGitHubStatsKind, command.Kind)
+	stats := command.Payload.GitHubStats
+	assert.Equal(t, "owner/repo", stats.Repository)
+	require.Len(t, stats.CodeFrequency, 1)
+	assert.True(t, week.Equal(stats.CodeFrequency[0].Week))
+	assert.Equal(t, int64(-40), stats.CodeFrequency[0].Deletions)
+	assert.Equal(t, []int{3, 5}, stats.Participation.All)
+	// GitHub was still computing the punch card.
+	assert.Nil(t, stats.PunchCard)
+	assert.Empty(t, server.Misses())
+}
+
+func TestSplitCommand(t *testing.T) {
+	reindexID := uuid.New()
+	command := &events.CommitsCommand{
+		Kind:    events.NewCommitsKind,
+		Payload: &events.CommitPayload{ReindexID: &reindexID},
+	}
+	for i := 0; i < 10; i++ {
+		command.Payload.Commits = append(command.Payload.Commits, &models.Commit{
+			Hash:    fmt.Sprintf("%040d", i),
+			Message: strings.Repeat("x", 1000),
+		})
+	}
+
+	bodies, err := splitCommand(command, 3500)
+	require.NoError(t, err)
+	require.True(t, len(bodies) > 2, "%d messages", len(bodies))
+
+	var hashes []string
+	for _, body := range bodies {
+		assert.True(t, len(body) <= 3500)
+		var part events.CommitsCommand
+		require.NoError(t, json.Unmarshal(body, &part))
+		assert.Equal(t, events.NewCommitsKind, part.Kind)
+		assert.Equal(t, reindexID, *part.Payload.ReindexID)
+		for _, commit := range part.Payload.Commits {
+			hashes = append(hashes, commit.Hash)
+		}
+	}
+	require.Len(t, hashes, 10)
+	assert.Equal(t, fmt.Sprintf("%040d", 0), hashes[0])
+	assert.Equal(t, fmt.Sprintf("%040d", 9), hashes[9])
+
+	// A batch under the limit, or a single commit over it, goes as it is.
+	bodies, err = splitCommand(command, 0)
+	require.NoError(t, err)
+	assert.Len(t, bodies, 1)
+	command.Payload.Commits = command.Payload.Commits[:1]
+	bodies, err = splitCommand(command, 100)
+	require.NoError(t, err)
+	assert.Len(t, bodies, 1)
+}
+
+func TestMemoryCheckpoints(t *testing.T) {
+	checkpoints := newMemoryCheckpoints()
+
+	page, err := checkpoints.load("checkpoint:a")
+	require.NoError(t, err)
+	assert.Equal(t, 0, page)
+
+	checkpoints.save("checkpoint:a", 3)
+	page, err = checkpoints.load("checkpoint:a")
+	require.NoError(t, err)
+	assert.Equal(t, 3, page)
+
+	checkpoints.clear("checkpoint:a")
+	page, err = checkpoints.load("checkpoint:a")
+	require.NoError(t, err)
+	assert.Equal(t, 0, page)
+}
+
+func TestFetchCommits_ResumesFromCursor(t *testing.T) {
+	day := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	checkpoints := newMemoryCheckpoints()
+	ev := testIntent()
+	ev.ID = uuid.New()
+
+	first := githubtest.NewServer(
+		githubtest.Page("GET", commitsPath, 0, []*github.RepositoryCommit{
+			datedCommit("b", day), datedCommit("a", day.AddDate(0, 0, -1)),
+		}),
+	)
+	defer first.Close()
+	commitsChan := make(chan *CommitResult, 10)
+	err := fetchCommits(context.Background(), first.Client(), checkpoints, testGate(), newCommitSet(0), commitsChan, ev)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b", "a"}, drain(commitsChan))
+
+	// The next run lists from the newest commit's date, skipping that commit.
+	second := githubtest.NewServer(
+		githubtest.Page("GET", "/repos/owner/repo/commits?per_page=100&since=2024-06-01T12%3A00%3A00Z", 0, []*github.RepositoryCommit{
+			datedCommit("c", day.Add(time.Hour)), datedCommit("b", day),
+		}),
+	)
+	defer second.Close()
+	seen := newCommitSet(0)
+	err = fetchCommits(context.Background(), second.Client(), checkpoints, testGate(), seen, commitsChan, ev)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c"}, drain(commitsChan))
+	assert.Equal(t, int64(1), seen.count())
+	assert.Empty(t, second.Misses())
+
+	cursor, err := checkpoints.loadCursor(cursorKey(ev, commitQuery{}))
+	require.NoError(t, err)
+	assert.Equal(t, syncCursor{SHA: "c", At: day.Add(time.Hour)}, cursor)
+}
+
+func TestFetchCommits_CursorOverlap(t *testing.T) {
+	day := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	checkpoints := newMemoryCheckpoints()
+	ev := testIntent()
+	ev.ID = uuid.New()
+	gate := testGate()
+	gate.overlap = 48 * time.Hour
+
+	first := githubtest.NewServer(
+		githubtest.Page("GET", commitsPath, 0, []*github.RepositoryCommit{
+			datedCommit("b", day), datedCommit("a", day.AddDate(0, 0, -1)), datedCommit("old", day.AddDate(0, 0, -5)),
+		}),
+	)
+	defer first.Close()
+	commitsChan := make(chan *CommitResult, 10)
+	err := fetchCommits(context.Background(), first.Client(), checkpoints, gate, newCommitSet(0), commitsChan, ev)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b", "a", "old"}, drain(commitsChan))
+
+	// A branch merged since brings in m, committed before the cursor. The
+	// next run lists the overlap again and only sends what is new.
+	second := githubtest.NewServer(
+		githubtest.Page("GET", "/repos/owner/repo/commits?per_page=100&since=2024-05-30T12%3A00%3A00Z", 0, []*github.RepositoryCommit{
+			datedCommit("c", day.Add(time.Hour)), datedCommit("b", day), datedCommit("m", day.Add(-12*time.Hour)), datedCommit("a", day.AddDate(0, 0, -1)),
+		}),
+	)
+	defer second.Close()
+	err = fetchCommits(context.Background(), second.Client(), checkpoints, gate, newCommitSet(0), commitsChan, ev)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c", "m"}, drain(commitsChan))
+	assert.Empty(t, second.Misses())
+
+	cursor, err := checkpoints.loadCursor(cursorKey(ev, commitQuery{}))
+	require.NoError(t, err)
+	assert.Equal(t, syncCursor{SHA: "c", At: day.Add(time.Hour), Recent: []string{"a", "b", "m"}}, cursor)
+}
+
+func testRepos(names ...string) []*github.Repository {
+	repos := make([]*github.Repository, len(names))
+	for i, name := range names {
+		repos[i] = &github.Repository{FullName: github.String(name)}
+	}
+	return repos
+}
+
+func TestFetchOrgRepos(t *testing.T) {
+	server := githubtest.NewServer(
+		githubtest.Page("GET", "/orgs/owner/repos?per_page=100&type=all", 2, testRepos("owner/a", "owner/b")),
+		githubtest.Page("GET", "/orgs/owner/repos?page=2&per_page=100&type=all", 0, testRepos("owner/c")),
+	)
+	defer server.Close()
+
+	ev := testIntent()
+	ev.ID = uuid.New()
+	ev.RepoName = orgRepoName
+	lifecycleChan := make(chan *events.CommitsCommand, 10)
+	err := fetchOrgRepos(context.Background(), server.Client(), testGate(), lifecycleChan, ev)
+	require.NoError(t, err)
+
+	require.Len(t, lifecycleChan, 1)
+	command := <-lifecycleChan
+	assert.Equal(t, events.NewOrgReposKind, command.Kind)
+	assert.Equal(t, ev.ID, command.Payload.OrgRepos.IntentID)
+	assert.Equal(t, []string{"owner/a", "owner/b", "owner/c"}, command.Payload.OrgRepos.Repos)
+	assert.Empty(t, server.Misses())
+}
+
+func TestFetchOrgRepos_User(t *testing.T) {
+	server := githubtest.NewServer(
+		githubtest.Error("GET", "/orgs/owner/repos?per_page=100&type=all", http.StatusNotFound, "Not Found"),
+		githubtest.Page("GET", "/users/owner/repos?per_page=100&type=owner", 0, testRepos("owner/a")),
+	)
+	defer server.Close()
+
+	ev := testIntent()
+	ev.RepoName = orgRepoName
+	lifecycleChan := make(chan *events.CommitsCommand, 10)
+	err := fetchOrgRepos(context.Background(), server.Client(), testGate(), lifecycleChan, ev)
+	require.NoError(t, err)
+
+	require.Len(t, lifecycleChan, 1)
+	command := <-lifecycleChan
+	assert.Equal(t, []string{"owner/a"}, command.Payload.OrgRepos.Repos)
+	assert.Empty(t, server.Misses())
+}
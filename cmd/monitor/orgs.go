@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-github/v63/github"
+	"github.com/noelukwa/indexer/internal/events"
+	"github.com/noelukwa/indexer/internal/pkg/config"
+)
+
+// orgRepoName is the repository name of an org intent, which covers every
+// repository of its owner.
+const orgRepoName = "*"
+
+// handleOrgIntent lists the repositories of an org intent's owner and
+// sends them to the manager, which creates an intent for each new one.
+// Every broadcast of the intent lists them again, picking up repositories
+// created since.
+func handleOrgIntent(ctx context.Context, client *github.Client, cfg *config.MonitorConfig, githubSlots chan struct{}, commitsChan chan<- *CommitResult, lifecycleChan chan<- *events.CommitsCommand, ev *events.IntentPayload) error {
+	run := &runReporter{
+		lifecycleChan: lifecycleChan,
+		commitsChan:   commitsChan,
+		intent:        ev,
+		seen:          newCommitSet(0),
+	}
+	stopProgress := run.start(ctx)
+	err := fetchOrgRepos(ctx, client, newFetchGate(githubSlots, client, cfg, ev), lifecycleChan, ev)
+	stopProgress()
+	if ctx.Err() != nil {
+		return errInterrupted
+	}
+	run.finish(ctx, err)
+	return err
+}
+
+// fetchOrgRepos sends every repository of the intent's owner, listing the
+// owner as an organization and, when it isn't one, as a user.
+func fetchOrgRepos(ctx context.Context, client *github.Client, gate *fetchGate, lifecycleChan chan<- *events.CommitsCommand, ev *events.IntentPayload) error {
+	repos, err := listOrgRepos(ctx, client, gate, ev.RepoOwner)
+	var respErr *github.ErrorResponse
+	if errors.As(err, &respErr) && respErr.Response.StatusCode == http.StatusNotFound {
+		repos, err = listUserRepos(ctx, client, gate, ev.RepoOwner)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to list the repositories of %s: %w", ev.RepoOwner, err)
+	}
+
+	command := &events.CommitsCommand{
+		Kind: events.NewOrgReposKind,
+		Payload: &events.CommitPayload{
+			OrgRepos: &events.OrgRepos{IntentID: ev.ID, Repos: repos},
+		},
+	}
+	select {
+	case lifecycleChan <- command:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func listOrgRepos(ctx context.Context, client *github.Client, gate *fetchGate, org string) ([]string, error) {
+	opts := &github.RepositoryListByOrgOptions{
+		Type:        "all",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	var names []string
+	for {
+		var page []*github.Repository
+		var resp *github.Response
+		err := gate.call(ctx, func() error {
+			var err error
+			page, resp, err = client.Repositories.ListByOrg(ctx, org, opts)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		names = appendRepoNames(names, page)
+		if resp.NextPage == 0 {
+			return names, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// listUserRepos lists the repositories the user owns, leaving out the ones
+// they only collaborate on.
+func listUserRepos(ctx context.Context, client *github.Client, gate *fetchGate, user string) ([]string, error) {
+	opts := &github.RepositoryListByUserOptions{
+		Type:        "owner",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	var names []string
+	for {
+		var page []*github.Repository
+		var resp *github.Response
+		err := gate.call(ctx, func() error {
+			var err error
+			page, resp, err = client.Repositories.ListByUser(ctx, user, opts)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		names = appendRepoNames(names, page)
+		if resp.NextPage == 0 {
+			return names, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+func appendRepoNames(names []string, repos []*github.Repository) []string {
+	for _, repo := range repos {
+		if repo.GetFullName() != "" {
+			names = append(names, repo.GetFullName())
+		}
+	}
+	return names
+}
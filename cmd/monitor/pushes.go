@@ -0,0 +1,287 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v63/github"
+	"github.com/noelukwa/indexer/internal/events"
+	"github.com/noelukwa/indexer/internal/pkg/config"
+	"github.com/noelukwa/indexer/internal/pkg/repolocks"
+)
+
+// pushPoller follows the events feed of the repositories this monitor has
+// synced, so commits pushed between full syncs reach the manager within
+// seconds instead of at the next broadcast. A repository is dropped once
+// no sync has refreshed it for its TTL, which is how paused and deleted
+// intents fall off.
+type pushPoller struct {
+	mu    sync.Mutex
+	repos map[string]*polledRepo
+
+	interval    time.Duration
+	ttl         time.Duration
+	cfg         *config.MonitorConfig
+	slots       chan struct{}
+	locks       repolocks.Locker
+	owner       string
+	commitsChan chan<- *CommitResult
+}
+
+// polledRepo is a repository followed by the poller. branch is the branch
+// whose pushes count, empty for every branch. Before lastEventID is known,
+// pushes made since the sync started count. watch sets the fields up to
+// syncedAt under the poller's lock; the rest belong to the polling loop.
+type polledRepo struct {
+	intent   *events.IntentPayload
+	client   *github.Client
+	branch   string
+	since    time.Time
+	syncedAt time.Time
+
+	etag        string
+	lastEventID int64
+	nextPoll    time.Time
+}
+
+// pushTarget is what a poll needs of a polledRepo, read under the lock.
+type pushTarget struct {
+	intent *events.IntentPayload
+	client *github.Client
+	branch string
+	since  time.Time
+}
+
+func newPushPoller(cfg *config.MonitorConfig, slots chan struct{}, locks repolocks.Locker, owner string, commitsChan chan<- *CommitResult) *pushPoller {
+	ttl := cfg.PushPollTTL
+	if ttl <= 0 {
+		ttl = time.Hour
+	}
+	return &pushPoller{
+		repos:       make(map[string]*polledRepo),
+		interval:    cfg.PushPollInterval,
+		ttl:         ttl,
+		cfg:         cfg,
+		slots:       slots,
+		locks:       locks,
+		owner:       owner,
+		commitsChan: commitsChan,
+	}
+}
+
+// pollable reports whether pushes can stand in for a full sync of ev
+// until the next one. Bounded, shallow and filtered intents can't be told
+// apart from a push alone, and reindexes are one-off runs.
+func pollable(ev *events.IntentPayload) bool {
+	return ev.Until.IsZero() && ev.ReindexID == nil && ev.MaxCommits == nil &&
+		len(ev.PathFilters) == 0 && len(ev.AuthorFilters) == 0 && len(ev.ExcludedAuthors) == 0
+}
+
+// watch follows the repository of ev after a sync of it that started at
+// since, on the intent's branch or else defaultBranch, unless the intent
+// indexes all branches.
+func (p *pushPoller) watch(ev *events.IntentPayload, client *github.Client, defaultBranch string, since time.Time) {
+	if !pollable(ev) {
+		return
+	}
+	branch := defaultBranch
+	if ev.Branch != "" {
+		branch = ev.Branch
+	}
+	if ev.IndexAllBranches {
+		branch = ""
+	}
+
+	key := strings.ToLower(ev.RepoOwner + "/" + ev.RepoName)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	repo, ok := p.repos[key]
+	if !ok {
+		repo = &polledRepo{since: since}
+		p.repos[key] = repo
+	}
+	repo.intent = ev
+	repo.client = client
+	repo.branch = branch
+	repo.syncedAt = time.Now()
+}
+
+// run polls the followed repositories every interval until ctx is done.
+func (p *pushPoller) run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			for _, repo := range p.due() {
+				if err := p.poll(ctx, repo); err != nil {
+					log.Printf("Failed to poll pushes: %v", err)
+				}
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// due returns the repositories to poll now and drops the expired ones.
+func (p *pushPoller) due() []*polledRepo {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	var due []*polledRepo
+	for key, repo := range p.repos {
+		if now.Sub(repo.syncedAt) > p.ttl {
+			delete(p.repos, key)
+			continue
+		}
+		if now.Before(repo.nextPoll) {
+			continue
+		}
+		due = append(due, repo)
+	}
+	return due
+}
+
+// poll sends the commits pushed to repo since its last poll. A repository
+// locked by a sync is skipped, as that sync fetches them anyway.
+func (p *pushPoller) poll(ctx context.Context, repo *polledRepo) (err error) {
+	p.mu.Lock()
+	target := pushTarget{intent: repo.intent, client: repo.client, branch: repo.branch, since: repo.since}
+	p.mu.Unlock()
+
+	ev := target.intent
+	name := ev.RepoOwner + "/" + ev.RepoName
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("%s: %w", name, err)
+		}
+	}()
+
+	held, err := acquireLock(p.locks, repolocks.Key(ev.RepoOwner, ev.RepoName), repolocks.Holder{
+		Repository: name,
+		Owner:      p.owner,
+		IntentID:   ev.ID.String(),
+		AcquiredAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to acquire lock: %w", err)
+	}
+	if held == nil {
+		return nil
+	}
+	defer releaseLock(held, name)
+
+	gate := newFetchGate(p.slots, target.client, p.cfg, ev)
+	shas, err := p.pushedCommits(ctx, gate, repo, target)
+	if err != nil {
+		return err
+	}
+
+	for _, sha := range shas {
+		var commit *github.RepositoryCommit
+		err := gate.call(ctx, func() error {
+			var err error
+			commit, _, err = target.client.Repositories.GetCommit(ctx, ev.RepoOwner, ev.RepoName, sha, nil)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to fetch pushed commit %s: %w", sha, err)
+		}
+		select {
+		case p.commitsChan <- commitResult(ctx, target.client, gate, ev, commit):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if len(shas) > 0 {
+		log.Printf("Sent %d pushed commits of %s", len(shas), name)
+	}
+	return nil
+}
+
+// pushedCommits lists the distinct commits of the pushes to repo's branch
+// that it has not seen, oldest first. Unchanged feeds are answered from
+// the ETag and don't count against the rate limit.
+func (p *pushPoller) pushedCommits(ctx context.Context, gate *fetchGate, repo *polledRepo, target pushTarget) ([]string, error) {
+	ev := target.intent
+	req, err := target.client.NewRequest(http.MethodGet, fmt.Sprintf("repos/%s/%s/events?per_page=100", ev.RepoOwner, ev.RepoName), nil)
+	if err != nil {
+		return nil, err
+	}
+	if repo.etag != "" {
+		req.Header.Set("If-None-Match", repo.etag)
+	}
+
+	var feed []*github.Event
+	var resp *github.Response
+	err = gate.call(ctx, func() error {
+		var err error
+		resp, err = target.client.Do(ctx, req, &feed)
+		return err
+	})
+	var respErr *github.ErrorResponse
+	if errors.As(err, &respErr) && respErr.Response.StatusCode == http.StatusNotModified {
+		p.schedule(repo, respErr.Response)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+	p.schedule(repo, resp.Response)
+	repo.etag = resp.Header.Get("ETag")
+
+	// The feed is newest first.
+	var shas []string
+	newest := repo.lastEventID
+	for _, event := range feed {
+		id, _ := strconv.ParseInt(event.GetID(), 10, 64)
+		if repo.lastEventID != 0 && id <= repo.lastEventID {
+			break
+		}
+		newest = max(newest, id)
+		if repo.lastEventID == 0 && event.GetCreatedAt().Before(target.since) {
+			break
+		}
+		if event.GetType() != "PushEvent" {
+			continue
+		}
+		payload, err := event.ParsePayload()
+		if err != nil {
+			continue
+		}
+		push := payload.(*github.PushEvent)
+		if target.branch != "" && push.GetRef() != "refs/heads/"+target.branch {
+			continue
+		}
+		for i := len(push.Commits) - 1; i >= 0; i-- {
+			if push.Commits[i].GetDistinct() {
+				shas = append(shas, push.Commits[i].GetSHA())
+			}
+		}
+	}
+	repo.lastEventID = newest
+
+	slices.Reverse(shas)
+	return slices.Compact(shas), nil
+}
+
+// schedule holds the next poll of repo back for the X-Poll-Interval GitHub
+// asks for, which overrides a shorter interval.
+func (p *pushPoller) schedule(repo *polledRepo, resp *http.Response) {
+	seconds, err := strconv.Atoi(resp.Header.Get("X-Poll-Interval"))
+	if err != nil {
+		return
+	}
+	repo.nextPoll = time.Now().Add(time.Duration(seconds) * time.Second)
+}
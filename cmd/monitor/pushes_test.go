@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/noelukwa/indexer/internal/pkg/githubtest"
+	"github.com/test-go/testify/assert"
+	"github.com/test-go/testify/require"
+)
+
+const eventsPath = "/repos/owner/repo/events?per_page=100"
+
+func pushEvent(id string, at time.Time, ref string, commits ...map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"id":         id,
+		"type":       "PushEvent",
+		"created_at": at,
+		"payload":    map[string]interface{}{"ref": ref, "commits": commits},
+	}
+}
+
+func pushed(sha string, distinct bool) map[string]interface{} {
+	return map[string]interface{}{"sha": sha, "distinct": distinct}
+}
+
+func TestPushedCommits(t *testing.T) {
+	synced := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	feed := githubtest.JSON("GET", eventsPath, []interface{}{
+		pushEvent("5", synced.Add(2*time.Minute), "refs/heads/main", pushed("c3", true), pushed("c4", true)),
+		pushEvent("4", synced.Add(time.Minute), "refs/heads/feature", pushed("f1", true)),
+		map[string]interface{}{"id": "3", "type": "WatchEvent", "created_at": synced.Add(time.Minute), "payload": map[string]interface{}{}},
+		pushEvent("2", synced.Add(time.Second), "refs/heads/main", pushed("c1", false), pushed("c2", true)),
+		pushEvent("1", synced.Add(-time.Minute), "refs/heads/main", pushed("c0", true)),
+	})
+	feed.Header = http.Header{"Etag": {`"feed-1"`}, "X-Poll-Interval": {"60"}}
+	server := githubtest.NewServer(feed)
+	defer server.Close()
+
+	repo := &polledRepo{}
+	target := pushTarget{intent: testIntent(), client: server.Client(), branch: "main", since: synced}
+	shas, err := (&pushPoller{}).pushedCommits(context.Background(), testGate(), repo, target)
+	require.NoError(t, err)
+
+	// Pushes from before the sync started, to other branches and of
+	// commits that aren't new to the repository are left out.
+	assert.Equal(t, []string{"c2", "c3", "c4"}, shas)
+	assert.Equal(t, int64(5), repo.lastEventID)
+	assert.Equal(t, `"feed-1"`, repo.etag)
+	assert.True(t, repo.nextPoll.After(time.Now().Add(50*time.Second)))
+}
+
+func TestPushedCommits_OnlyNewEvents(t *testing.T) {
+	synced := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	server := githubtest.NewServer(githubtest.JSON("GET", eventsPath, []interface{}{
+		pushEvent("7", synced.Add(time.Hour), "refs/heads/dev", pushed("d1", true)),
+		pushEvent("5", synced.Add(time.Minute), "refs/heads/main", pushed("c3", true)),
+	}))
+	defer server.Close()
+
+	repo := &polledRepo{lastEventID: 5}
+	target := pushTarget{intent: testIntent(), client: server.Client(), since: synced}
+	shas, err := (&pushPoller{}).pushedCommits(context.Background(), testGate(), repo, target)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"d1"}, shas)
+	assert.Equal(t, int64(7), repo.lastEventID)
+}
+
+func TestPushedCommits_NotModified(t *testing.T) {
+	server := githubtest.NewServer(githubtest.Interaction{Method: "GET", Path: eventsPath, Status: http.StatusNotModified})
+	defer server.Close()
+
+	repo := &polledRepo{etag: `"feed-1"`, lastEventID: 5}
+	target := pushTarget{intent: testIntent(), client: server.Client()}
+	shas, err := (&pushPoller{}).pushedCommits(context.Background(), testGate(), repo, target)
+	require.NoError(t, err)
+	assert.Empty(t, shas)
+	assert.Equal(t, int64(5), repo.lastEventID)
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v63/github"
+	"github.com/noelukwa/indexer/internal/events"
+	"github.com/noelukwa/indexer/internal/pkg/ratelimits"
+)
+
+const (
+	defaultTokenLabel = "default"
+	rateLimitInterval = time.Minute
+)
+
+// rateLimitReporter samples the quota of every token the monitor fetches
+// with and reports it to Redis for the manager. It also keeps each token's
+// budget, which its fetches pause on when fewer than reserve requests are
+// left.
+type rateLimitReporter struct {
+	store   *ratelimits.Store
+	reserve int
+
+	mu      sync.Mutex
+	clients map[string]*trackedClient
+	last    map[string]ratelimits.Status
+	budgets map[string]*rateBudget
+}
+
+// trackedClient is a client the reporter samples while runs is above 0.
+type trackedClient struct {
+	client *github.Client
+	runs   int
+}
+
+func newRateLimitReporter(store *ratelimits.Store, reserve int) *rateLimitReporter {
+	return &rateLimitReporter{
+		store:   store,
+		reserve: reserve,
+		clients: make(map[string]*trackedClient),
+		last:    make(map[string]ratelimits.Status),
+		budgets: make(map[string]*rateBudget),
+	}
+}
+
+// budget returns the budget of the token with label. A token's budget
+// outlives its clients, which a stored credential gets one of per run.
+func (r *rateLimitReporter) budget(label string) *rateBudget {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	budget, ok := r.budgets[label]
+	if !ok {
+		budget = &rateBudget{label: label, reserve: r.reserve}
+		r.budgets[label] = budget
+	}
+	return budget
+}
+
+// budgetStatuses returns every token's budget, by label.
+func (r *rateLimitReporter) budgetStatuses() []budgetStatus {
+	r.mu.Lock()
+	budgets := make([]*rateBudget, 0, len(r.budgets))
+	for _, budget := range r.budgets {
+		budgets = append(budgets, budget)
+	}
+	r.mu.Unlock()
+
+	statuses := make([]budgetStatus, len(budgets))
+	for i, budget := range budgets {
+		statuses[i] = budget.status()
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Token < statuses[j].Token })
+	return statuses
+}
+
+// tokenLabel names the token an intent is fetched with without revealing it.
+func tokenLabel(ev *events.IntentPayload) string {
+	if len(ev.Credential) == 0 || ev.CredentialID == nil {
+		return defaultTokenLabel
+	}
+	return "credential:" + ev.CredentialID.String()
+}
+
+// track samples the client's quota until the returned untrack is called
+// by every run that tracked it. Once no run uses a stored credential its
+// decrypted client is dropped, and its status expires from Redis.
+func (r *rateLimitReporter) track(label string, client *github.Client) (untrack func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tracked, ok := r.clients[label]
+	if !ok {
+		tracked = &trackedClient{}
+		r.clients[label] = tracked
+	}
+	tracked.client = client
+	tracked.runs++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			if tracked.runs--; tracked.runs == 0 {
+				delete(r.clients, label)
+				delete(r.last, label)
+			}
+		})
+	}
+}
+
+func (r *rateLimitReporter) run(ctx context.Context) {
+	ticker := time.NewTicker(rateLimitInterval)
+	defer ticker.Stop()
+
+	for {
+		r.report(ctx)
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (r *rateLimitReporter) report(ctx context.Context) {
+	r.mu.Lock()
+	clients := make(map[string]*github.Client, len(r.clients))
+	for label, tracked := range r.clients {
+		clients[label] = tracked.client
+	}
+	r.mu.Unlock()
+
+	for label, client := range clients {
+		// Checking the rate limit does not count against it.
+		reqCtx, cancel := context.WithTimeout(ctx, githubAPITimeout)
+		limits, _, err := client.RateLimit.Get(reqCtx)
+		cancel()
+		if err != nil {
+			log.Printf("Failed to fetch rate limits for %s: %v", label, err)
+			continue
+		}
+
+		status := ratelimits.Status{
+			Token:     label,
+			Core:      toQuota(limits.GetCore()),
+			Search:    toQuota(limits.GetSearch()),
+			UpdatedAt: time.Now(),
+		}
+
+		r.mu.Lock()
+		if prev, ok := r.last[label]; ok {
+			status.Core = ratelimits.Project(prev.Core, prev.UpdatedAt, status.Core, status.UpdatedAt)
+			status.Search = ratelimits.Project(prev.Search, prev.UpdatedAt, status.Search, status.UpdatedAt)
+		}
+		r.last[label] = status
+		r.mu.Unlock()
+
+		if err := r.store.Save(ctx, status); err != nil {
+			log.Printf("Failed to report rate limits for %s: %v", label, err)
+		}
+	}
+}
+
+func toQuota(rate *github.Rate) ratelimits.Quota {
+	if rate == nil {
+		return ratelimits.Quota{}
+	}
+	return ratelimits.Quota{
+		Limit:     rate.Limit,
+		Remaining: rate.Remaining,
+		Reset:     rate.Reset.Time,
+	}
+}
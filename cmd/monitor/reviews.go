@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v63/github"
+	"github.com/noelukwa/indexer/internal/events"
+	"github.com/noelukwa/indexer/internal/manager/models"
+)
+
+// fetchReviews sends the reviews of the repository's pull requests updated
+// since the intent's start date, a page of pull requests at a time. Pull
+// requests opened after its until date are left out.
+func fetchReviews(ctx context.Context, client *github.Client, gate *fetchGate, lifecycleChan chan<- *events.CommitsCommand, ev *events.IntentPayload) error {
+	opts := &github.PullRequestListOptions{
+		State:       "all",
+		Sort:        "updated",
+		Direction:   "desc",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	var until time.Time
+	if !ev.Until.IsZero() {
+		until = ev.Until.AddDate(0, 0, 1)
+	}
+
+	for {
+		var pulls []*github.PullRequest
+		var resp *github.Response
+		err := gate.call(ctx, func() error {
+			var err error
+			pulls, resp, err = client.PullRequests.List(ctx, ev.RepoOwner, ev.RepoName, opts)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list pull requests: %w", err)
+		}
+
+		var reviews []*models.PullRequestReview
+		older := false
+		for _, pull := range pulls {
+			// Pull requests are listed by their last update, so the rest
+			// haven't changed since the start date either.
+			if pull.GetUpdatedAt().Before(ev.From) {
+				older = true
+				break
+			}
+			if !until.IsZero() && !pull.GetCreatedAt().Before(until) {
+				continue
+			}
+			pullReviews, err := fetchPullReviews(ctx, client, gate, ev, pull.GetNumber())
+			if err != nil {
+				return err
+			}
+			reviews = append(reviews, pullRequestReviews(ev, pull, pullReviews)...)
+		}
+
+		if len(reviews) > 0 {
+			command := &events.CommitsCommand{
+				Kind:    events.NewReviewsKind,
+				Payload: &events.CommitPayload{Reviews: reviews},
+			}
+			select {
+			case lifecycleChan <- command:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		if older || resp.NextPage == 0 {
+			return nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// fetchPullReviews lists every review of the pull request.
+func fetchPullReviews(ctx context.Context, client *github.Client, gate *fetchGate, ev *events.IntentPayload, number int) ([]*github.PullRequestReview, error) {
+	var reviews []*github.PullRequestReview
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		var page []*github.PullRequestReview
+		var resp *github.Response
+		err := gate.call(ctx, func() error {
+			var err error
+			page, resp, err = client.PullRequests.ListReviews(ctx, ev.RepoOwner, ev.RepoName, number, opts)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list reviews of pull request %d: %w", number, err)
+		}
+		reviews = append(reviews, page...)
+		if resp.NextPage == 0 {
+			return reviews, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// pullRequestReviews converts the submitted reviews of pull, leaving out
+// pending ones.
+func pullRequestReviews(ev *events.IntentPayload, pull *github.PullRequest, reviews []*github.PullRequestReview) []*models.PullRequestReview {
+	converted := make([]*models.PullRequestReview, 0, len(reviews))
+	for _, review := range reviews {
+		if review.SubmittedAt == nil || strings.EqualFold(review.GetState(), "PENDING") {
+			continue
+		}
+		converted = append(converted, &models.PullRequestReview{
+			ID:                   review.GetID(),
+			Repository:           ev.RepoOwner + "/" + ev.RepoName,
+			PullRequest:          int32(pull.GetNumber()),
+			PullRequestAuthor:    pull.GetUser().GetLogin(),
+			PullRequestCreatedAt: pull.GetCreatedAt().Time,
+			Reviewer:             review.GetUser().GetLogin(),
+			State:                review.GetState(),
+			SubmittedAt:          review.GetSubmittedAt().Time,
+		})
+	}
+	return converted
+}
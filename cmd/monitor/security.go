@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/go-github/v63/github"
+	"github.com/noelukwa/indexer/internal/events"
+	"github.com/noelukwa/indexer/internal/manager/models"
+)
+
+// fetchSecurityAlerts sends the repository's Dependabot alerts in every
+// state, a page at a time, so alerts fixed or dismissed since the last
+// sync are closed. Tokens that can't read them, and repositories with
+// Dependabot alerts turned off, are skipped.
+func fetchSecurityAlerts(ctx context.Context, client *github.Client, gate *fetchGate, lifecycleChan chan<- *events.CommitsCommand, ev *events.IntentPayload) error {
+	opts := &github.ListAlertsOptions{
+		ListCursorOptions: github.ListCursorOptions{PerPage: 100},
+	}
+	name := ev.RepoOwner + "/" + ev.RepoName
+
+	for {
+		var page []*github.DependabotAlert
+		var resp *github.Response
+		err := gate.call(ctx, func() error {
+			var err error
+			page, resp, err = client.Dependabot.ListRepoAlerts(ctx, ev.RepoOwner, ev.RepoName, opts)
+			return err
+		})
+		var respErr *github.ErrorResponse
+		if errors.As(err, &respErr) && (respErr.Response.StatusCode == http.StatusForbidden || respErr.Response.StatusCode == http.StatusNotFound) {
+			log.Printf("Skipping security alerts of %s: %v", name, err)
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to list security alerts: %w", err)
+		}
+
+		alerts := make([]*models.SecurityAlert, 0, len(page))
+		for _, alert := range page {
+			alerts = append(alerts, securityAlert(name, alert))
+		}
+		if len(alerts) > 0 {
+			command := &events.CommitsCommand{
+				Kind:    events.NewSecurityAlertsKind,
+				Payload: &events.CommitPayload{SecurityAlerts: alerts},
+			}
+			select {
+			case lifecycleChan <- command:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		if resp.After == "" {
+			return nil
+		}
+		opts.ListCursorOptions.After = resp.After
+	}
+}
+
+// securityAlert converts a Dependabot alert on the repository name.
+func securityAlert(name string, alert *github.DependabotAlert) *models.SecurityAlert {
+	dependency := alert.GetDependency()
+	advisory := alert.GetSecurityAdvisory()
+	dismissedAt := alert.DismissedAt
+	if dismissedAt == nil {
+		dismissedAt = alert.AutoDismissedAt
+	}
+	return &models.SecurityAlert{
+		Number:       int32(alert.GetNumber()),
+		Repository:   name,
+		State:        alert.GetState(),
+		Severity:     advisory.GetSeverity(),
+		Package:      dependency.GetPackage().GetName(),
+		Ecosystem:    dependency.GetPackage().GetEcosystem(),
+		ManifestPath: dependency.GetManifestPath(),
+		AdvisoryID:   advisory.GetGHSAID(),
+		Summary:      advisory.GetSummary(),
+		Url:          alert.GetHTMLURL(),
+		CreatedAt:    alert.GetCreatedAt().Time,
+		UpdatedAt:    alert.GetUpdatedAt().Time,
+		FixedAt:      timestampTime(alert.FixedAt),
+		DismissedAt:  timestampTime(dismissedAt),
+	}
+}
+
+// timestampTime is the time of t, nil when t is.
+func timestampTime(t *github.Timestamp) *time.Time {
+	if t == nil {
+		return nil
+	}
+	return &t.Time
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// errNoTokens fails the requests of a pool whose every token was revoked.
+var errNoTokens = errors.New("every GitHub token in the pool has been revoked")
+
+// tokenPool takes turns with several tokens, one request each, so a large
+// backfill draws on all of their quotas. Tokens whose budget is paused are
+// passed over until it resumes, and tokens GitHub rejects are dropped for
+// good. Requests wait while every token is paused.
+type tokenPool struct {
+	base   http.RoundTripper
+	tokens []pooledToken
+
+	mu   sync.Mutex
+	next int
+}
+
+type pooledToken struct {
+	token  string
+	budget *rateBudget
+}
+
+func newTokenPool(base http.RoundTripper, tokens []string, budgets []*rateBudget) *tokenPool {
+	pool := &tokenPool{base: base, tokens: make([]pooledToken, len(tokens))}
+	for i, token := range tokens {
+		pool.tokens[i] = pooledToken{token: token, budget: budgets[i]}
+	}
+	return pool
+}
+
+// take returns the next token in turn that isn't paused, or when every
+// token is paused, when the first of them resumes. Only a claimed token
+// uses up its turn.
+func (p *tokenPool) take(now time.Time, claim bool) (pooledToken, time.Time, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var resume time.Time
+	usable := false
+	for i := range p.tokens {
+		n := (p.next + i) % len(p.tokens)
+		token := p.tokens[n]
+		if token.budget.isRevoked() {
+			continue
+		}
+		usable = true
+		until := token.budget.pausedUntil(now)
+		if until.IsZero() {
+			if claim {
+				p.next = n + 1
+			}
+			return token, time.Time{}, nil
+		}
+		if resume.IsZero() || until.Before(resume) {
+			resume = until
+		}
+	}
+	if !usable {
+		return pooledToken{}, time.Time{}, errNoTokens
+	}
+	return pooledToken{}, resume, nil
+}
+
+// wait blocks while every token is paused.
+func (p *tokenPool) wait(ctx context.Context) error {
+	_, err := p.await(ctx, false)
+	return err
+}
+
+func (p *tokenPool) await(ctx context.Context, claim bool) (pooledToken, error) {
+	for {
+		token, resume, err := p.take(time.Now(), claim)
+		if err != nil || resume.IsZero() {
+			return token, err
+		}
+
+		timer := time.NewTimer(time.Until(resume))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return pooledToken{}, ctx.Err()
+		}
+	}
+}
+
+func (p *tokenPool) RoundTrip(req *http.Request) (*http.Response, error) {
+	for {
+		token, err := p.await(req.Context(), true)
+		if err != nil {
+			return nil, err
+		}
+
+		authed := req.Clone(req.Context())
+		authed.Header.Set("Authorization", "Bearer "+token.token)
+		resp, err := p.base.RoundTrip(authed)
+		if err != nil {
+			return nil, err
+		}
+		token.budget.observe(resp)
+
+		// A request without a body can be made again with the next token.
+		if resp.StatusCode != http.StatusUnauthorized || req.Body != nil {
+			return resp, nil
+		}
+		token.budget.revoke()
+		log.Printf("GitHub rejected token %s, dropping it from the pool", token.budget.label)
+		resp.Body.Close()
+	}
+}
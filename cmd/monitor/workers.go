@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/noelukwa/indexer/internal/events"
+)
+
+// workerPool runs intents on a fixed number of workers. Intents waiting
+// for a worker are queued per repository and the workers take the
+// repositories in turn, so a repository with many queued intents doesn't
+// hold every worker, and one repository's intents run one after another
+// instead of racing for its lock. A run already queued isn't queued twice.
+type workerPool struct {
+	workers int
+
+	mu      sync.Mutex
+	wake    *sync.Cond
+	queues  map[string][]poolJob
+	turns   []string
+	running map[string]bool
+	queued  int
+	busy    int
+	closed  bool
+	done    sync.WaitGroup
+
+	// Counters since the monitor started, for its metrics.
+	completed  int64
+	duplicates int64
+	busyFor    time.Duration
+}
+
+type poolJob struct {
+	run string
+	fn  func()
+}
+
+// poolStatus is the pool as the debug endpoint reports it.
+type poolStatus struct {
+	Workers      int `json:"workers"`
+	Busy         int `json:"busy"`
+	Queued       int `json:"queued"`
+	Repositories int `json:"queued_repositories"`
+}
+
+// newWorkerPool starts workers workers, at least one.
+func newWorkerPool(workers int) *workerPool {
+	p := &workerPool{
+		workers: max(workers, 1),
+		queues:  make(map[string][]poolJob),
+		running: make(map[string]bool),
+	}
+	p.wake = sync.NewCond(&p.mu)
+	p.done.Add(p.workers)
+	for i := 0; i < p.workers; i++ {
+		go p.work()
+	}
+	return p
+}
+
+// repoKey is the repository the pool queues an intent under.
+func repoKey(ev *events.IntentPayload) string {
+	return strings.ToLower(ev.RepoOwner + "/" + ev.RepoName)
+}
+
+// runKey tells the runs of intents apart: an intent's reindex is a run of
+// its own.
+func runKey(ev *events.IntentPayload) string {
+	if ev.ReindexID != nil {
+		return ev.ID.String() + ":" + ev.ReindexID.String()
+	}
+	return ev.ID.String()
+}
+
+// submit queues fn behind the other jobs for repo. It reports false,
+// leaving fn out, when the same run is already waiting in repo's queue.
+func (p *workerPool) submit(repo, run string, fn func()) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, queued := range p.queues[repo] {
+		if queued.run == run {
+			p.duplicates++
+			return false
+		}
+	}
+
+	p.queues[repo] = append(p.queues[repo], poolJob{run: run, fn: fn})
+	p.queued++
+	if !p.running[repo] && len(p.queues[repo]) == 1 {
+		p.turns = append(p.turns, repo)
+		p.wake.Signal()
+	}
+	return true
+}
+
+// close stops the workers once they have run every queued job, and waits
+// for them.
+func (p *workerPool) close() {
+	p.mu.Lock()
+	p.closed = true
+	p.wake.Broadcast()
+	p.mu.Unlock()
+	p.done.Wait()
+}
+
+func (p *workerPool) work() {
+	defer p.done.Done()
+	for {
+		repo, job, ok := p.next()
+		if !ok {
+			return
+		}
+		started := time.Now()
+		job.fn()
+		p.finish(repo, time.Since(started))
+	}
+}
+
+// next takes the first job of the repository whose turn it is, waiting
+// for one, or reports false once the pool is closed and drained.
+func (p *workerPool) next() (string, poolJob, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for len(p.turns) == 0 {
+		if p.closed {
+			return "", poolJob{}, false
+		}
+		p.wake.Wait()
+	}
+
+	repo := p.turns[0]
+	p.turns = p.turns[1:]
+	job := p.queues[repo][0]
+	if p.queues[repo] = p.queues[repo][1:]; len(p.queues[repo]) == 0 {
+		delete(p.queues, repo)
+	}
+	p.queued--
+	p.running[repo] = true
+	p.busy++
+	return repo, job, true
+}
+
+// finish puts repo at the back of the turns if it has more jobs queued.
+func (p *workerPool) finish(repo string, took time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.running, repo)
+	p.busy--
+	p.completed++
+	p.busyFor += took
+	if len(p.queues[repo]) > 0 {
+		p.turns = append(p.turns, repo)
+		p.wake.Signal()
+	}
+}
+
+func (p *workerPool) status() poolStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return poolStatus{
+		Workers:      p.workers,
+		Busy:         p.busy,
+		Queued:       p.queued,
+		Repositories: len(p.queues),
+	}
+}
+
+// writeMetrics writes the pool's metrics in the Prometheus text format.
+func (p *workerPool) writeMetrics(w io.Writer) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var err error
+	printf := func(format string, args ...interface{}) {
+		if err == nil {
+			_, err = fmt.Fprintf(w, format, args...)
+		}
+	}
+
+	printf("# HELP indexer_monitor_workers Workers running intents.\n")
+	printf("# TYPE indexer_monitor_workers gauge\n")
+	printf("indexer_monitor_workers %d\n", p.workers)
+	printf("# HELP indexer_monitor_workers_busy Workers running an intent now.\n")
+	printf("# TYPE indexer_monitor_workers_busy gauge\n")
+	printf("indexer_monitor_workers_busy %d\n", p.busy)
+	printf("# HELP indexer_monitor_intents_queued Intents waiting for a worker.\n")
+	printf("# TYPE indexer_monitor_intents_queued gauge\n")
+	printf("indexer_monitor_intents_queued %d\n", p.queued)
+	printf("# HELP indexer_monitor_queued_repositories Repositories with intents waiting for a worker.\n")
+	printf("# TYPE indexer_monitor_queued_repositories gauge\n")
+	printf("indexer_monitor_queued_repositories %d\n", len(p.queues))
+	printf("# HELP indexer_monitor_intents_total Intents the workers have run.\n")
+	printf("# TYPE indexer_monitor_intents_total counter\n")
+	printf("indexer_monitor_intents_total %d\n", p.completed)
+	printf("# HELP indexer_monitor_intents_duplicate_total Intents dropped as already queued.\n")
+	printf("# TYPE indexer_monitor_intents_duplicate_total counter\n")
+	printf("indexer_monitor_intents_duplicate_total %d\n", p.duplicates)
+	printf("# HELP indexer_monitor_worker_busy_seconds_total Time the workers have spent running intents.\n")
+	printf("# TYPE indexer_monitor_worker_busy_seconds_total counter\n")
+	printf("indexer_monitor_worker_busy_seconds_total %s\n", strconv.FormatFloat(p.busyFor.Seconds(), 'g', -1, 64))
+	return err
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/go-github/v63/github"
+	"github.com/noelukwa/indexer/internal/events"
+	"github.com/noelukwa/indexer/internal/manager/models"
+)
+
+// fetchWorkflowRuns sends the repository's completed GitHub Actions runs
+// created within the intent's dates, a page at a time.
+func fetchWorkflowRuns(ctx context.Context, client *github.Client, gate *fetchGate, lifecycleChan chan<- *events.CommitsCommand, ev *events.IntentPayload) error {
+	opts := &github.ListWorkflowRunsOptions{
+		Status:      "completed",
+		Created:     createdRange(ev.From, ev.Until),
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	for {
+		var page *github.WorkflowRuns
+		var resp *github.Response
+		err := gate.call(ctx, func() error {
+			var err error
+			page, resp, err = client.Actions.ListRepositoryWorkflowRuns(ctx, ev.RepoOwner, ev.RepoName, opts)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list workflow runs: %w", err)
+		}
+
+		runs := make([]*models.WorkflowRun, 0, len(page.WorkflowRuns))
+		for _, run := range page.WorkflowRuns {
+			runs = append(runs, &models.WorkflowRun{
+				ID:          run.GetID(),
+				Repository:  ev.RepoOwner + "/" + ev.RepoName,
+				Workflow:    run.GetName(),
+				HeadSHA:     run.GetHeadSHA(),
+				Branch:      run.GetHeadBranch(),
+				Event:       run.GetEvent(),
+				Conclusion:  run.GetConclusion(),
+				StartedAt:   run.GetRunStartedAt().Time,
+				CompletedAt: run.GetUpdatedAt().Time,
+			})
+		}
+		if len(runs) > 0 {
+			command := &events.CommitsCommand{
+				Kind:    events.NewWorkflowRunsKind,
+				Payload: &events.CommitPayload{WorkflowRuns: runs},
+			}
+			select {
+			case lifecycleChan <- command:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		if resp.NextPage == 0 {
+			return nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// createdRange is GitHub's date range qualifier for from and until, either
+// of which may be zero. It is empty when both are.
+func createdRange(from, until time.Time) string {
+	switch {
+	case from.IsZero() && until.IsZero():
+		return ""
+	case until.IsZero():
+		return ">=" + from.Format(time.DateOnly)
+	case from.IsZero():
+		return "<=" + until.Format(time.DateOnly)
+	default:
+		return from.Format(time.DateOnly) + ".." + until.Format(time.DateOnly)
+	}
+}
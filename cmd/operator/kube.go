@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	crdGroup    = "indexer.noelukwa.github.io"
+	crdVersion  = "v1alpha1"
+	crdResource = "repositoryintents"
+
+	// finalizer keeps a deleted RepositoryIntent around until its intent
+	// is paused.
+	finalizer = crdGroup + "/pause-intent"
+
+	serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+)
+
+// repositoryIntent is a RepositoryIntent custom resource.
+type repositoryIntent struct {
+	Metadata objectMeta   `json:"metadata"`
+	Spec     intentSpec   `json:"spec"`
+	Status   intentStatus `json:"status,omitempty"`
+}
+
+type objectMeta struct {
+	Name              string     `json:"name"`
+	Namespace         string     `json:"namespace"`
+	Generation        int64      `json:"generation"`
+	ResourceVersion   string     `json:"resourceVersion"`
+	DeletionTimestamp *time.Time `json:"deletionTimestamp,omitempty"`
+	Finalizers        []string   `json:"finalizers,omitempty"`
+}
+
+// intentSpec mirrors the manager's intent options. Only Paused and Since
+// can change once the intent exists; the other fields apply when it is
+// created, and edits to them are reported in the status message.
+type intentSpec struct {
+	Repository         string   `json:"repository"`
+	Since              string   `json:"since,omitempty"`
+	Until              string   `json:"until,omitempty"`
+	Paused             bool     `json:"paused,omitempty"`
+	IndexAllBranches   bool     `json:"indexAllBranches,omitempty"`
+	PathFilters        []string `json:"pathFilters,omitempty"`
+	AuthorFilters      []string `json:"authorFilters,omitempty"`
+	Branch             string   `json:"branch,omitempty"`
+	ExcludedAuthors    []string `json:"excludedAuthors,omitempty"`
+	MaxCommits         *int32   `json:"maxCommits,omitempty"`
+	MaxConcurrentPages *int32   `json:"maxConcurrentPages,omitempty"`
+	RequestsPerMinute  *int32   `json:"requestsPerMinute,omitempty"`
+}
+
+// intentStatus is written with a merge patch, so fields that can go back
+// to empty are always sent to clear them.
+type intentStatus struct {
+	IntentID           string     `json:"intentID,omitempty"`
+	Phase              string     `json:"phase,omitempty"`
+	Active             bool       `json:"active"`
+	SyncedCommits      int64      `json:"syncedCommits"`
+	LastSyncedAt       *time.Time `json:"lastSyncedAt"`
+	Error              string     `json:"error"`
+	Message            string     `json:"message"`
+	ObservedGeneration int64      `json:"observedGeneration,omitempty"`
+}
+
+func (s intentStatus) equal(other intentStatus) bool {
+	a, b := s.LastSyncedAt, other.LastSyncedAt
+	s.LastSyncedAt, other.LastSyncedAt = nil, nil
+	return s == other && (a == b || a != nil && b != nil && a.Equal(*b))
+}
+
+func (ri *repositoryIntent) String() string {
+	return ri.Metadata.Namespace + "/" + ri.Metadata.Name
+}
+
+func (ri *repositoryIntent) hasFinalizer() bool {
+	for _, f := range ri.Metadata.Finalizers {
+		if f == finalizer {
+			return true
+		}
+	}
+	return false
+}
+
+// kubeClient is the little of the Kubernetes API the operator needs:
+// listing RepositoryIntents and patching them.
+type kubeClient struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+// newKubeClient returns a client of the API at apiURL, or of the cluster
+// the operator runs in when apiURL is empty.
+func newKubeClient(apiURL string) (*kubeClient, error) {
+	if apiURL != "" {
+		return &kubeClient{
+			baseURL: strings.TrimRight(apiURL, "/"),
+			http:    &http.Client{Timeout: 30 * time.Second},
+		}, nil
+	}
+
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, fmt.Errorf("not running in a cluster, set OPERATOR_SERVICE_KUBE_APIURL")
+	}
+	token, err := os.ReadFile(serviceAccountDir + "/token")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account token: %w", err)
+	}
+	ca, err := os.ReadFile(serviceAccountDir + "/ca.crt")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cluster CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("no certificates in %s/ca.crt", serviceAccountDir)
+	}
+
+	return &kubeClient{
+		baseURL: "https://" + net.JoinHostPort(host, port),
+		token:   strings.TrimSpace(string(token)),
+		http: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}},
+		},
+	}, nil
+}
+
+func resourcePath(namespace string) string {
+	path := "/apis/" + crdGroup + "/" + crdVersion
+	if namespace != "" {
+		path += "/namespaces/" + namespace
+	}
+	return path + "/" + crdResource
+}
+
+// list returns the RepositoryIntents in namespace, or in all namespaces
+// when it is empty.
+func (k *kubeClient) list(ctx context.Context, namespace string) ([]repositoryIntent, error) {
+	var list struct {
+		Items []repositoryIntent `json:"items"`
+	}
+	if err := k.do(ctx, http.MethodGet, resourcePath(namespace), nil, &list); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// setFinalizers replaces the resource's finalizers, failing if it changed
+// since it was read.
+func (k *kubeClient) setFinalizers(ctx context.Context, ri *repositoryIntent, finalizers []string) error {
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"finalizers":      finalizers,
+			"resourceVersion": ri.Metadata.ResourceVersion,
+		},
+	}
+	return k.do(ctx, http.MethodPatch, resourcePath(ri.Metadata.Namespace)+"/"+ri.Metadata.Name, patch, ri)
+}
+
+func (k *kubeClient) updateStatus(ctx context.Context, ri *repositoryIntent, status intentStatus) error {
+	patch := map[string]interface{}{"status": status}
+	return k.do(ctx, http.MethodPatch, resourcePath(ri.Metadata.Namespace)+"/"+ri.Metadata.Name+"/status", patch, ri)
+}
+
+func (k *kubeClient) do(ctx context.Context, method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, k.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+	}
+	if k.token != "" {
+		req.Header.Set("Authorization", "Bearer "+k.token)
+	}
+
+	resp, err := k.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var status struct {
+			Message string `json:"message"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&status)
+		if status.Message == "" {
+			status.Message = resp.Status
+		}
+		return fmt.Errorf("%s %s: %s", method, path, status.Message)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
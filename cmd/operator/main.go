@@ -0,0 +1,52 @@
+// Command operator reconciles RepositoryIntent custom resources into
+// manager intents, so the repositories to index can be declared in YAML.
+package main
+
+import (
+	"context"
+	"log"
+	"os/signal"
+	"syscall"
+	"time"
+
+	_ "github.com/joho/godotenv/autoload"
+	"github.com/kelseyhightower/envconfig"
+	"github.com/noelukwa/indexer/internal/pkg/config"
+	"github.com/noelukwa/indexer/pkg/client"
+)
+
+func main() {
+	var cfg config.OperatorConfig
+	if err := envconfig.Process("operator_service", &cfg); err != nil {
+		log.Fatalf("Failed to process config: %v", err)
+	}
+
+	kube, err := newKubeClient(cfg.KubeAPIURL)
+	if err != nil {
+		log.Fatalf("Failed to connect to Kubernetes: %v", err)
+	}
+
+	r := &reconciler{
+		kube:      kube,
+		api:       client.New(cfg.ManagerURL, cfg.ManagerToken),
+		namespace: cfg.Namespace,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	ticker := time.NewTicker(cfg.ResyncInterval)
+	defer ticker.Stop()
+
+	log.Printf("reconciling RepositoryIntents every %s", cfg.ResyncInterval)
+	for {
+		r.resync(ctx)
+
+		select {
+		case <-ctx.Done():
+			log.Println("Shutting down operator...")
+			return
+		case <-ticker.C:
+		}
+	}
+}
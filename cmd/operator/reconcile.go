@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/noelukwa/indexer/pkg/client"
+)
+
+// reconciler makes the manager's intents match the RepositoryIntents.
+type reconciler struct {
+	kube      *kubeClient
+	api       *client.Client
+	namespace string
+}
+
+// resync reconciles every RepositoryIntent once.
+func (r *reconciler) resync(ctx context.Context) {
+	items, err := r.kube.list(ctx, r.namespace)
+	if err != nil {
+		log.Printf("Failed to list RepositoryIntents: %v", err)
+		return
+	}
+
+	for i := range items {
+		ri := &items[i]
+		if err := r.reconcile(ctx, ri); err != nil {
+			log.Printf("Failed to reconcile %s: %v", ri, err)
+			status := ri.Status
+			status.Message = err.Error()
+			if err := r.kube.updateStatus(ctx, ri, status); err != nil {
+				log.Printf("Failed to update status of %s: %v", ri, err)
+			}
+		}
+	}
+}
+
+func (r *reconciler) reconcile(ctx context.Context, ri *repositoryIntent) error {
+	if ri.Metadata.DeletionTimestamp != nil {
+		return r.finalize(ctx, ri)
+	}
+
+	if !ri.hasFinalizer() {
+		if err := r.kube.setFinalizers(ctx, ri, append(ri.Metadata.Finalizers, finalizer)); err != nil {
+			return fmt.Errorf("failed to add finalizer: %w", err)
+		}
+	}
+
+	intent, err := r.find(ctx, ri)
+	if err != nil {
+		return err
+	}
+	if intent == nil {
+		intent, err = r.create(ctx, ri)
+		if err != nil {
+			return err
+		}
+		log.Printf("Created intent %s for %s", intent.ID, ri)
+	} else if update, ok := pendingUpdate(ri, intent); ok {
+		intent, err = r.api.UpdateIntent(ctx, intent.ID, update)
+		if err != nil {
+			return fmt.Errorf("failed to update intent: %w", err)
+		}
+		log.Printf("Updated intent %s for %s", intent.ID, ri)
+	}
+
+	status := intentStatus{
+		IntentID:           intent.ID.String(),
+		Phase:              string(intent.Status),
+		Active:             intent.IsActive,
+		SyncedCommits:      intent.SyncedCommits,
+		LastSyncedAt:       intent.LastSyncedAt,
+		ObservedGeneration: ri.Metadata.Generation,
+	}
+	if intent.Error != nil {
+		status.Error = intent.Error.Message
+	}
+	if changed := frozenChanges(ri, intent); len(changed) > 0 {
+		status.Message = fmt.Sprintf("%s cannot change once the intent exists; recreate the RepositoryIntent to apply them", strings.Join(changed, ", "))
+	}
+	if status.equal(ri.Status) {
+		return nil
+	}
+	return r.kube.updateStatus(ctx, ri, status)
+}
+
+// find returns the intent of the resource's repository, or nil if there is
+// none yet.
+func (r *reconciler) find(ctx context.Context, ri *repositoryIntent) (*client.Intent, error) {
+	page, err := r.api.ListIntents(ctx, client.IntentsQuery{Repository: ri.Spec.Repository}, 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up intent: %w", err)
+	}
+	for i := range page.Data {
+		if page.Data[i].ID.String() == ri.Status.IntentID {
+			return &page.Data[i], nil
+		}
+	}
+	// Adopt an intent created before the resource, or by an earlier
+	// operator that failed to record it.
+	if len(page.Data) > 0 {
+		return &page.Data[0], nil
+	}
+	return nil, nil
+}
+
+func (r *reconciler) create(ctx context.Context, ri *repositoryIntent) (*client.Intent, error) {
+	since, err := parseDate(ri.Spec.Since)
+	if err != nil {
+		return nil, fmt.Errorf("invalid since: %w", err)
+	}
+	until, err := parseDate(ri.Spec.Until)
+	if err != nil {
+		return nil, fmt.Errorf("invalid until: %w", err)
+	}
+
+	intent, err := r.api.CreateIntent(ctx, client.CreateIntentRequest{
+		Repository: ri.Spec.Repository,
+		Since:      since,
+		Until:      until,
+		IntentOptions: client.IntentOptions{
+			IndexAllBranches:   ri.Spec.IndexAllBranches,
+			PathFilters:        ri.Spec.PathFilters,
+			AuthorFilters:      ri.Spec.AuthorFilters,
+			Branch:             ri.Spec.Branch,
+			ExcludedAuthors:    ri.Spec.ExcludedAuthors,
+			MaxCommits:         ri.Spec.MaxCommits,
+			MaxConcurrentPages: ri.Spec.MaxConcurrentPages,
+			RequestsPerMinute:  ri.Spec.RequestsPerMinute,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create intent: %w", err)
+	}
+	if ri.Spec.Paused {
+		return r.api.UpdateIntent(ctx, intent.ID, client.UpdateIntentRequest{IsActive: false})
+	}
+	return intent, nil
+}
+
+// pendingUpdate returns the update that brings intent in line with the
+// resource's Paused and Since, if it differs.
+func pendingUpdate(ri *repositoryIntent, intent *client.Intent) (client.UpdateIntentRequest, bool) {
+	update := client.UpdateIntentRequest{IsActive: !ri.Spec.Paused}
+	changed := update.IsActive != intent.IsActive
+
+	since, err := parseDate(ri.Spec.Since)
+	if err == nil && !since.IsZero() && (intent.StartDate == nil || intent.StartDate.UTC().Format(time.DateOnly) != ri.Spec.Since) {
+		update.Since = since
+		changed = true
+	}
+	return update, changed
+}
+
+// frozenChanges lists the spec fields that differ from the intent but can
+// only be set when it is created. They are reported rather than applied.
+func frozenChanges(ri *repositoryIntent, intent *client.Intent) []string {
+	var changed []string
+	if until := dateOf(intent.Until); until != ri.Spec.Until {
+		changed = append(changed, "until")
+	}
+	if ri.Spec.IndexAllBranches != intent.IndexAllBranches {
+		changed = append(changed, "indexAllBranches")
+	}
+	if !slices.Equal(pathPrefixes(ri.Spec.PathFilters), intent.PathFilters) {
+		changed = append(changed, "pathFilters")
+	}
+	if !slices.Equal(trimmed(ri.Spec.AuthorFilters), intent.AuthorFilters) {
+		changed = append(changed, "authorFilters")
+	}
+	if strings.TrimSpace(ri.Spec.Branch) != intent.Branch {
+		changed = append(changed, "branch")
+	}
+	if !slices.Equal(trimmed(ri.Spec.ExcludedAuthors), intent.ExcludedAuthors) {
+		changed = append(changed, "excludedAuthors")
+	}
+	if !sameLimit(ri.Spec.MaxCommits, intent.MaxCommits) {
+		changed = append(changed, "maxCommits")
+	}
+	if !sameLimit(ri.Spec.MaxConcurrentPages, intent.MaxConcurrentPages) {
+		changed = append(changed, "maxConcurrentPages")
+	}
+	if !sameLimit(ri.Spec.RequestsPerMinute, intent.RequestsPerMinute) {
+		changed = append(changed, "requestsPerMinute")
+	}
+	return changed
+}
+
+func dateOf(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.DateOnly)
+}
+
+// pathPrefixes normalizes path filters the way the manager stores them.
+func pathPrefixes(filters []string) []string {
+	paths := make([]string, 0, len(filters))
+	for _, filter := range filters {
+		path := strings.Trim(strings.TrimSpace(filter), "/")
+		path = strings.TrimSuffix(path, "/**")
+		paths = append(paths, strings.TrimSuffix(path, "/*"))
+	}
+	return paths
+}
+
+func trimmed(filters []string) []string {
+	out := make([]string, 0, len(filters))
+	for _, filter := range filters {
+		if filter = strings.TrimSpace(filter); filter != "" {
+			out = append(out, filter)
+		}
+	}
+	return out
+}
+
+func sameLimit(a, b *int32) bool {
+	return a == b || a != nil && b != nil && *a == *b
+}
+
+// finalize pauses the intent of a deleted resource and lets it go. The
+// indexed commits stay.
+func (r *reconciler) finalize(ctx context.Context, ri *repositoryIntent) error {
+	if !ri.hasFinalizer() {
+		return nil
+	}
+
+	intent, err := r.find(ctx, ri)
+	if err != nil {
+		return err
+	}
+	if intent != nil && intent.IsActive {
+		if _, err := r.api.UpdateIntent(ctx, intent.ID, client.UpdateIntentRequest{IsActive: false}); err != nil && !client.IsNotFound(err) {
+			return fmt.Errorf("failed to pause intent: %w", err)
+		}
+		log.Printf("Paused intent %s of deleted %s", intent.ID, ri)
+	}
+
+	finalizers := make([]string, 0, len(ri.Metadata.Finalizers))
+	for _, f := range ri.Metadata.Finalizers {
+		if f != finalizer {
+			finalizers = append(finalizers, f)
+		}
+	}
+	return r.kube.setFinalizers(ctx, ri, finalizers)
+}
+
+func parseDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.DateOnly, s)
+}
@@ -0,0 +1,10 @@
+// Package swagger embeds the manager's OpenAPI spec, generated from the
+// handlers' annotations by swag.
+package swagger
+
+import _ "embed"
+
+// YAML is the Swagger 2.0 spec of the manager's REST API.
+//
+//go:embed swagger.yaml
+var YAML []byte
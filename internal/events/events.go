@@ -8,15 +8,66 @@ import (
 )
 
 type CommitPayload struct {
-	Commits []*models.Commit   `json:"commits"`
-	Repo    *models.Repository `json:"repo"`
+	Commits  []*models.Commit   `json:"commits"`
+	Repo     *models.Repository `json:"repo"`
+	Progress *IntentProgress    `json:"progress,omitempty"`
+	// IntentID is the intent whose run fetched Repo. Its tenant tracks the
+	// repository from then on.
+	IntentID *uuid.UUID `json:"intent_id,omitempty"`
+	// Reviews are the reviews of a repository's pull requests, sent apart
+	// from its commits.
+	Reviews []*models.PullRequestReview `json:"reviews,omitempty"`
+	// WorkflowRuns are a repository's completed CI runs.
+	WorkflowRuns []*models.WorkflowRun `json:"workflow_runs,omitempty"`
+	// GitHubStats are the aggregates GitHub precomputes for a repository.
+	GitHubStats *models.GitHubStats `json:"github_stats,omitempty"`
+	// SecurityAlerts are a repository's Dependabot alerts.
+	SecurityAlerts []*models.SecurityAlert `json:"security_alerts,omitempty"`
+	// OrgRepos are the repositories an owner/* intent expands to.
+	OrgRepos *OrgRepos `json:"org_repos,omitempty"`
+	// ReindexID is set on commits fetched by a reindex run, which go to
+	// its shadow instead of the live commits.
+	ReindexID *uuid.UUID `json:"reindex_id,omitempty"`
+}
+
+// IntentProgress reports on a monitor run of an intent. Commits counts the
+// commits fetched so far, Pages the pages of commits listed and
+// LastCommitDate is the date of the oldest commit fetched, which moves
+// back toward the start of the intent's range as the run goes. Error is
+// set when the run failed. ReindexID is set when the run is a reindex.
+type IntentProgress struct {
+	IntentID       uuid.UUID  `json:"intent_id"`
+	Commits        int64      `json:"commits"`
+	Pages          int64      `json:"pages,omitempty"`
+	LastCommitDate *time.Time `json:"last_commit_date,omitempty"`
+	StartedAt      time.Time  `json:"started_at"`
+	At             time.Time  `json:"at"`
+	Error          string     `json:"error,omitempty"`
+	ReindexID      *uuid.UUID `json:"reindex_id,omitempty"`
+}
+
+// OrgRepos lists every repository of the owner of the org intent with
+// IntentID, as owner/name.
+type OrgRepos struct {
+	IntentID uuid.UUID `json:"intent_id"`
+	Repos    []string  `json:"repos"`
 }
 
 type CommitsEventKind string
 
 const (
-	NewCommitsKind  CommitsEventKind = "new_commits"
-	NewRepoInfoKind CommitsEventKind = "new_repo_info"
+	NewCommitsKind        CommitsEventKind = "new_commits"
+	NewRepoInfoKind       CommitsEventKind = "new_repo_info"
+	NewReviewsKind        CommitsEventKind = "new_reviews"
+	NewWorkflowRunsKind   CommitsEventKind = "new_workflow_runs"
+	NewGitHubStatsKind    CommitsEventKind = "new_github_stats"
+	NewSecurityAlertsKind CommitsEventKind = "new_security_alerts"
+	NewOrgReposKind       CommitsEventKind = "new_org_repos"
+
+	IntentStartedKind   CommitsEventKind = "intent_started"
+	IntentProgressKind  CommitsEventKind = "intent_progress"
+	IntentCompletedKind CommitsEventKind = "intent_completed"
+	IntentFailedKind    CommitsEventKind = "intent_failed"
 )
 
 type CommitsCommand struct {
@@ -28,7 +79,16 @@ type IntentPayload struct {
 	RepoOwner string    `json:"repo_owner"`
 	RepoName  string    `json:"repo_name"`
 	From      time.Time `json:"from"`
+	Until     time.Time `json:"until"`
 	ID        uuid.UUID `json:"id"`
+	// Credential is the sealed token of the intent's credential, which only
+	// holders of the credentials key can open. Empty uses the monitor's own.
+	Credential []byte `json:"credential,omitempty"`
+	// ReindexID asks for a reindex run, which refetches the whole history
+	// into the reindex's shadow. Only sent straight to the monitor, so
+	// discovery never re-broadcasts it.
+	ReindexID *uuid.UUID `json:"reindex_id,omitempty"`
+	models.IntentOptions
 }
 
 type IntentKind string
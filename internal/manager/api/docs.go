@@ -0,0 +1,63 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/noelukwa/indexer/docs/swagger"
+	"gopkg.in/yaml.v3"
+)
+
+//go:generate swag init -d ../../.. -g cmd/manager/main.go -o ../../../docs/swagger -ot yaml --parseInternal
+
+// swaggerPage is Swagger UI, loaded from a CDN and pointed at the spec.
+const swaggerPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Manager API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: "/openapi.json", dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>
+`
+
+// openAPISpec converts the embedded spec to JSON, which Swagger UI and
+// most client generators expect.
+func openAPISpec() []byte {
+	var spec map[string]interface{}
+	if err := yaml.Unmarshal(swagger.YAML, &spec); err != nil {
+		panic(err)
+	}
+	out, err := json.Marshal(spec)
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
+
+// serveDocs serves the spec at /openapi.json and Swagger UI at
+// /swagger/index.html. Neither needs auth, as the spec only describes the
+// API; trying its endpoints from the UI takes an API key.
+func serveDocs(e *echo.Echo) {
+	spec := openAPISpec()
+	e.GET("/openapi.json", func(c echo.Context) error {
+		return c.JSONBlob(http.StatusOK, spec)
+	})
+	e.GET("/swagger", func(c echo.Context) error {
+		return c.Redirect(http.StatusMovedPermanently, "/swagger/index.html")
+	})
+	e.GET("/swagger/", func(c echo.Context) error {
+		return c.Redirect(http.StatusMovedPermanently, "/swagger/index.html")
+	})
+	e.GET("/swagger/index.html", func(c echo.Context) error {
+		return c.HTML(http.StatusOK, swaggerPage)
+	})
+}
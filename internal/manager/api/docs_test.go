@@ -0,0 +1,49 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/noelukwa/indexer/internal/pkg/config"
+	"github.com/test-go/testify/assert"
+	"github.com/test-go/testify/require"
+)
+
+// TestSpecDocumentsRoutes checks the annotations, which the spec is
+// generated from, against the routes actually served.
+func TestSpecDocumentsRoutes(t *testing.T) {
+	cfg := &config.ManagerConfig{GitHubClientID: "client", GitHubWebhookSecret: "secret", SMTPAddr: "smtp:25"}
+	e := SetupRoutes(nil, cfg, echo.New())
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var spec struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &spec))
+
+	param := regexp.MustCompile(`:(\w+)`)
+	undocumented := map[string]bool{"/openapi.json": true}
+	for _, r := range e.Routes() {
+		if undocumented[r.Path] || r.Path == "/ui" || strings.HasPrefix(r.Path, "/ui/") || strings.HasPrefix(r.Path, "/swagger") {
+			continue
+		}
+		path := param.ReplaceAllString(r.Path, "{$1}")
+		_, ok := spec.Paths[path][strings.ToLower(r.Method)]
+		assert.True(t, ok, "%s %s is not documented", r.Method, path)
+	}
+}
+
+func TestDocsDisabled(t *testing.T) {
+	e := SetupRoutes(nil, &config.ManagerConfig{DocsDisabled: true}, echo.New())
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/swagger/index.html", nil))
+	assert.NotEqual(t, http.StatusOK, rec.Code)
+}
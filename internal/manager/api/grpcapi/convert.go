@@ -0,0 +1,207 @@
+package grpcapi
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/noelukwa/indexer/internal/manager"
+	"github.com/noelukwa/indexer/internal/manager/models"
+	"github.com/noelukwa/indexer/internal/pkg/config"
+	"github.com/noelukwa/indexer/pkg/indexerpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// serviceError is the status answering a failed service call: the code of
+// the error's kind and its message. Errors of no kind may carry internals,
+// so they are logged and answered with message instead.
+func serviceError(err error, message string) error {
+	var code codes.Code
+	switch {
+	case errors.Is(err, manager.ErrNotFound):
+		code = codes.NotFound
+	case errors.Is(err, manager.ErrConflict):
+		code = codes.AlreadyExists
+	case errors.Is(err, manager.ErrInvalid):
+		code = codes.InvalidArgument
+	case errors.Is(err, manager.ErrUnavailable):
+		code = codes.Unavailable
+	default:
+		log.Printf("%s: %v", message, err)
+		return status.Error(codes.Internal, message)
+	}
+	return status.Error(code, err.Error())
+}
+
+// parseDate parses a YYYY-MM-DD date, leaving an empty one zero.
+func parseDate(field, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, status.Errorf(codes.InvalidArgument, "Invalid %s, want YYYY-MM-DD", field)
+	}
+	return t, nil
+}
+
+func parseDateRange(since, until string) (time.Time, time.Time, error) {
+	start, err := parseDate("since", since)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	end, err := parseDate("until", until)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return start, end, nil
+}
+
+// paging fills in and caps the page sizes of listings like the REST API.
+type paging struct {
+	defaultPerPage int
+	maxPerPage     int
+}
+
+func newPaging(cfg *config.ManagerConfig) paging {
+	p := paging{defaultPerPage: cfg.DefaultPerPage, maxPerPage: cfg.MaxPerPage}
+	if p.maxPerPage < 1 {
+		p.maxPerPage = 100
+	}
+	if p.defaultPerPage < 1 || p.defaultPerPage > p.maxPerPage {
+		p.defaultPerPage = min(20, p.maxPerPage)
+	}
+	return p
+}
+
+// resolve returns the page and page size to serve a listing with, the
+// first page and the default size when they are left out.
+func (p paging) resolve(page, perPage int32) (int, int, error) {
+	if page < 0 || perPage < 0 {
+		return 0, 0, status.Error(codes.InvalidArgument, "page and per_page must not be negative")
+	}
+	size := int(perPage)
+	if size == 0 {
+		size = p.defaultPerPage
+	}
+	return max(int(page), 1), min(size, p.maxPerPage), nil
+}
+
+func timestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+func toIntent(intent *models.Intent) *indexerpb.Intent {
+	out := &indexerpb.Intent{
+		Id:             intent.ID.String(),
+		RepositoryName: intent.RepositoryName,
+		StartDate:      timestamp(intent.StartDate),
+		EndDate:        timestamp(intent.Until),
+		Status:         string(intent.Status),
+		IsActive:       intent.IsActive,
+		SyncedCommits:  intent.SyncedCommits,
+		SyncStartedAt:  timestamp(intent.SyncStartedAt),
+		LastSyncedAt:   timestamp(intent.LastSyncedAt),
+	}
+	if intent.ParentID != nil {
+		out.ParentId = intent.ParentID.String()
+	}
+	if intent.Error != nil {
+		out.Error = intent.Error.Message
+	}
+	return out
+}
+
+func toRepository(repo *models.Repository) *indexerpb.Repository {
+	return &indexerpb.Repository{
+		Id:            repo.ID,
+		FullName:      repo.FullName,
+		Description:   repo.Description,
+		Language:      repo.Language,
+		Topics:        repo.Topics,
+		License:       repo.License,
+		DefaultBranch: repo.DefaultBranch,
+		Homepage:      repo.Homepage,
+		Stars:         repo.Stars,
+		Watchers:      repo.Watchers,
+		Forks:         repo.Forks,
+		OpenIssues:    repo.OpenIssues,
+		Archived:      repo.Archived,
+		CreatedAt:     timestamp(&repo.CreatedAt),
+		UpdatedAt:     timestamp(&repo.UpdatedAt),
+		CommitCount:   repo.CommitCount,
+		LastCommitAt:  timestamp(repo.LastCommitAt),
+	}
+}
+
+func toAuthor(author models.Author) *indexerpb.Author {
+	return &indexerpb.Author{
+		Id:       author.ID,
+		Name:     author.Name,
+		Email:    author.Email,
+		Username: author.Username,
+	}
+}
+
+func toCommit(commit *models.Commit) *indexerpb.Commit {
+	out := &indexerpb.Commit{
+		Hash:       commit.Hash,
+		Author:     toAuthor(commit.Author),
+		Message:    commit.Message,
+		Url:        commit.Url,
+		CreatedAt:  timestamp(&commit.CreatedAt),
+		Tags:       commit.Tags,
+		Repository: commit.Repository.FullName,
+	}
+	if commit.Stats != nil {
+		out.Stats = &indexerpb.CommitStats{
+			Additions: commit.Stats.Additions,
+			Deletions: commit.Stats.Deletions,
+			Changes:   commit.Stats.Changes,
+		}
+	}
+	return out
+}
+
+// optionalInt32 leaves an option unset when it is zero, so the monitor's
+// configuration applies.
+func optionalInt32(v int32) *int32 {
+	if v == 0 {
+		return nil
+	}
+	return &v
+}
+
+// intentOptions checks a creation request's options against the REST
+// API's limits.
+func intentOptions(req *indexerpb.CreateIntentRequest) (models.IntentOptions, error) {
+	switch {
+	case req.MaxConcurrentPages < 0 || req.MaxConcurrentPages > 20:
+		return models.IntentOptions{}, status.Error(codes.InvalidArgument, "max_concurrent_pages must be between 1 and 20")
+	case req.RequestsPerMinute < 0:
+		return models.IntentOptions{}, status.Error(codes.InvalidArgument, "requests_per_minute must be positive")
+	case req.MaxCommits < 0:
+		return models.IntentOptions{}, status.Error(codes.InvalidArgument, "max_commits must be positive")
+	case len(req.PathFilters) > 20 || len(req.AuthorFilters) > 20:
+		return models.IntentOptions{}, status.Error(codes.InvalidArgument, "at most 20 path and author filters are allowed")
+	}
+	for i, author := range req.AuthorFilters {
+		if author == "" {
+			return models.IntentOptions{}, status.Error(codes.InvalidArgument, fmt.Sprintf("author_filters[%d] is empty", i))
+		}
+	}
+	return models.IntentOptions{
+		MaxConcurrentPages: optionalInt32(req.MaxConcurrentPages),
+		RequestsPerMinute:  optionalInt32(req.RequestsPerMinute),
+		IndexAllBranches:   req.IndexAllBranches,
+		PathFilters:        req.PathFilters,
+		AuthorFilters:      req.AuthorFilters,
+		MaxCommits:         optionalInt32(req.MaxCommits),
+	}, nil
+}
@@ -0,0 +1,125 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/noelukwa/indexer/internal/manager"
+	"github.com/noelukwa/indexer/internal/manager/models"
+	"github.com/noelukwa/indexer/pkg/indexerpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var intentStatuses = map[models.IntentStatus]bool{
+	models.Created:          true,
+	models.PendingBroadcast: true,
+	models.Broadcast:        true,
+	models.Fetching:         true,
+	models.Ingesting:        true,
+	models.Completed:        true,
+	models.Failed:           true,
+	models.Paused:           true,
+}
+
+type intentServer struct {
+	indexerpb.UnimplementedIntentServiceServer
+	service *manager.Service
+	paging  paging
+}
+
+func (s *intentServer) CreateIntent(ctx context.Context, req *indexerpb.CreateIntentRequest) (*indexerpb.Intent, error) {
+	if req.Repository == "" {
+		return nil, status.Error(codes.InvalidArgument, "repository is required")
+	}
+	since, until, err := parseDateRange(req.Since, req.Until)
+	if err != nil {
+		return nil, err
+	}
+	opts, err := intentOptions(req)
+	if err != nil {
+		return nil, err
+	}
+
+	intent, err := s.service.CreateIntent(ctx, req.Repository, since, until, opts)
+	if err != nil {
+		// The credential is part of the request, so a missing one makes it
+		// invalid rather than the intent not found.
+		if errors.Is(err, manager.ErrCredentialNotFound) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, serviceError(err, "Failed to add intent")
+	}
+	return toIntent(intent), nil
+}
+
+func (s *intentServer) GetIntent(ctx context.Context, req *indexerpb.GetIntentRequest) (*indexerpb.Intent, error) {
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Invalid intent ID")
+	}
+
+	intent, err := s.service.GetIntent(ctx, id)
+	if err != nil {
+		return nil, serviceError(err, "Failed to fetch intent")
+	}
+	return toIntent(intent), nil
+}
+
+func (s *intentServer) ListIntents(ctx context.Context, req *indexerpb.ListIntentsRequest) (*indexerpb.ListIntentsResponse, error) {
+	filter := models.IntentFilter{IsActive: req.IsActive}
+	if req.Status != "" {
+		intentStatus := models.IntentStatus(req.Status)
+		if !intentStatuses[intentStatus] {
+			return nil, status.Error(codes.InvalidArgument, "Invalid status")
+		}
+		filter.Status = &intentStatus
+	}
+	if req.RepositoryName != "" {
+		filter.RepositoryName = &req.RepositoryName
+	}
+	if req.ParentId != "" {
+		parentID, err := uuid.Parse(req.ParentId)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "Invalid parent_id")
+		}
+		filter.ParentID = &parentID
+	}
+
+	page, perPage, err := s.paging.resolve(req.Page, req.PerPage)
+	if err != nil {
+		return nil, err
+	}
+	intents, err := s.service.GetIntents(ctx, filter, perPage, page)
+	if err != nil {
+		return nil, serviceError(err, "Failed to fetch intents")
+	}
+
+	resp := &indexerpb.ListIntentsResponse{
+		Intents:    make([]*indexerpb.Intent, len(intents.Data)),
+		TotalCount: intents.TotalCount,
+		Page:       int32(intents.Page),
+		PerPage:    int32(intents.PerPage),
+	}
+	for i := range intents.Data {
+		resp.Intents[i] = toIntent(&intents.Data[i])
+	}
+	return resp, nil
+}
+
+func (s *intentServer) DeleteIntent(ctx context.Context, req *indexerpb.DeleteIntentRequest) (*indexerpb.DeleteIntentResponse, error) {
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Invalid intent ID")
+	}
+
+	deletion, err := s.service.DeleteIntent(ctx, id, req.Purge)
+	if err != nil {
+		return nil, serviceError(err, "Failed to delete intent")
+	}
+	return &indexerpb.DeleteIntentResponse{
+		Intent:        toIntent(deletion.Intent),
+		PurgedCommits: deletion.PurgedCommits,
+	}, nil
+}
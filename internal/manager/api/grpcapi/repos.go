@@ -0,0 +1,179 @@
+package grpcapi
+
+import (
+	"context"
+
+	"github.com/noelukwa/indexer/internal/manager"
+	"github.com/noelukwa/indexer/internal/manager/models"
+	"github.com/noelukwa/indexer/pkg/indexerpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var repositorySorts = map[string]bool{
+	"":                                true,
+	string(models.SortByName):         true,
+	string(models.SortByStars):        true,
+	string(models.SortByCommitCount):  true,
+	string(models.SortByLastCommitAt): true,
+}
+
+type repoServer struct {
+	indexerpb.UnimplementedRepoServiceServer
+	service *manager.Service
+	paging  paging
+}
+
+func (s *repoServer) GetRepo(ctx context.Context, req *indexerpb.GetRepoRequest) (*indexerpb.Repository, error) {
+	repo, err := s.service.FindRepository(ctx, req.Repository)
+	if err != nil {
+		return nil, serviceError(err, "Failed to fetch repository information")
+	}
+	return toRepository(repo), nil
+}
+
+func (s *repoServer) ListRepos(ctx context.Context, req *indexerpb.ListReposRequest) (*indexerpb.ListReposResponse, error) {
+	if !repositorySorts[req.Sort] {
+		return nil, status.Error(codes.InvalidArgument, "Invalid sort")
+	}
+	filter := models.RepositoryFilter{
+		Sort:       models.RepositorySort(req.Sort),
+		Descending: req.Descending,
+	}
+	if req.Language != "" {
+		filter.Language = &req.Language
+	}
+
+	page, perPage, err := s.paging.resolve(req.Page, req.PerPage)
+	if err != nil {
+		return nil, err
+	}
+	repos, err := s.service.GetRepositories(ctx, filter, page, perPage)
+	if err != nil {
+		return nil, serviceError(err, "Failed to fetch repositories")
+	}
+
+	resp := &indexerpb.ListReposResponse{
+		Repositories: make([]*indexerpb.Repository, len(repos.Data)),
+		TotalCount:   repos.TotalCount,
+		Page:         int32(repos.Page),
+		PerPage:      int32(repos.PerPage),
+	}
+	for i := range repos.Data {
+		resp.Repositories[i] = toRepository(&repos.Data[i])
+	}
+	return resp, nil
+}
+
+func (s *repoServer) GetRepoStats(ctx context.Context, req *indexerpb.GetRepoStatsRequest) (*indexerpb.RepoStats, error) {
+	since, until, err := parseDateRange(req.Since, req.Until)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := s.service.GetRepoStats(ctx, req.Repository, since, until, req.Dedupe)
+	if err != nil {
+		return nil, serviceError(err, "Failed to fetch stats")
+	}
+	return &indexerpb.RepoStats{
+		Commits:    stats.Commits,
+		Additions:  stats.Additions,
+		Deletions:  stats.Deletions,
+		Authors:    stats.Authors,
+		ActiveDays: stats.ActiveDays,
+	}, nil
+}
+
+func (s *repoServer) ListTopCommitters(ctx context.Context, req *indexerpb.ListTopCommittersRequest) (*indexerpb.ListTopCommittersResponse, error) {
+	since, until, err := parseDateRange(req.Since, req.Until)
+	if err != nil {
+		return nil, err
+	}
+	page, perPage, err := s.paging.resolve(req.Page, req.PerPage)
+	if err != nil {
+		return nil, err
+	}
+
+	committers, err := s.service.GetTopCommitters(ctx, req.Repository, since, until, page, perPage)
+	if err != nil {
+		return nil, serviceError(err, "Failed to get top committers")
+	}
+
+	resp := &indexerpb.ListTopCommittersResponse{
+		Committers: make([]*indexerpb.AuthorStats, len(committers.Data)),
+		TotalCount: committers.TotalCount,
+		Page:       int32(committers.Page),
+		PerPage:    int32(committers.PerPage),
+	}
+	for i, stats := range committers.Data {
+		resp.Committers[i] = &indexerpb.AuthorStats{Author: toAuthor(stats.Author), Commits: stats.Commits}
+	}
+	return resp, nil
+}
+
+func (s *repoServer) ListCommits(ctx context.Context, req *indexerpb.ListCommitsRequest) (*indexerpb.ListCommitsResponse, error) {
+	filter, err := commitsFilter(req.Repository, req.Author, req.Since, req.Until, req.Message)
+	if err != nil {
+		return nil, err
+	}
+	page, perPage, err := s.paging.resolve(req.Page, req.PerPage)
+	if err != nil {
+		return nil, err
+	}
+
+	commits, err := s.service.GetCommits(ctx, filter, page, perPage)
+	if err != nil {
+		return nil, serviceError(err, "Failed to fetch commits")
+	}
+
+	resp := &indexerpb.ListCommitsResponse{
+		Commits:    make([]*indexerpb.Commit, len(commits.Commits)),
+		TotalCount: commits.TotalCount,
+		Page:       commits.Page,
+		PerPage:    commits.PerPage,
+	}
+	for i := range commits.Commits {
+		resp.Commits[i] = toCommit(&commits.Commits[i])
+	}
+	return resp, nil
+}
+
+// GetCommitsStream sends the matching commits a page of the largest size
+// at a time. Commits indexed while it runs may shift the pages, so a
+// commit can be sent twice.
+func (s *repoServer) GetCommitsStream(req *indexerpb.GetCommitsStreamRequest, stream indexerpb.RepoService_GetCommitsStreamServer) error {
+	filter, err := commitsFilter(req.Repository, req.Author, req.Since, req.Until, req.Message)
+	if err != nil {
+		return err
+	}
+
+	ctx := stream.Context()
+	for page := 1; ; page++ {
+		commits, err := s.service.GetCommits(ctx, filter, page, s.paging.maxPerPage)
+		if err != nil {
+			return serviceError(err, "Failed to fetch commits")
+		}
+		for i := range commits.Commits {
+			if err := stream.Send(toCommit(&commits.Commits[i])); err != nil {
+				return err
+			}
+		}
+		if len(commits.Commits) < s.paging.maxPerPage {
+			return nil
+		}
+	}
+}
+
+func commitsFilter(repo, author, since, until, message string) (models.CommitsFilter, error) {
+	start, end, err := parseDateRange(since, until)
+	if err != nil {
+		return models.CommitsFilter{}, err
+	}
+	return models.CommitsFilter{
+		RepositoryName: repo,
+		StartDate:      &start,
+		EndDate:        &end,
+		AuthorUsername: &author,
+		Message:        &message,
+	}, nil
+}
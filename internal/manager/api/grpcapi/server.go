@@ -0,0 +1,149 @@
+// Package grpcapi serves the index over gRPC, for internal services that
+// would rather not go through the REST API's JSON. Its services are
+// defined in proto/indexer/v1/indexer.proto.
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/noelukwa/indexer/internal/manager"
+	"github.com/noelukwa/indexer/internal/manager/models"
+	"github.com/noelukwa/indexer/internal/manager/repository"
+	"github.com/noelukwa/indexer/internal/pkg/config"
+	"github.com/noelukwa/indexer/pkg/indexerpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// apiKeyMetadata is the metadata key of an API key, the X-API-Key header
+// of the REST API.
+const apiKeyMetadata = "x-api-key"
+
+// writeMethods change the index, so need an admin like the REST API's
+// writes.
+var writeMethods = map[string]bool{
+	indexerpb.IntentService_CreateIntent_FullMethodName: true,
+	indexerpb.IntentService_DeleteIntent_FullMethodName: true,
+}
+
+// NewServer returns a gRPC server for the index, authenticating and rate
+// limiting its calls as the REST API does its requests.
+func NewServer(service *manager.Service, cfg *config.ManagerConfig, opts ...grpc.ServerOption) *grpc.Server {
+	g := &gate{service: service, cfg: cfg}
+	opts = append(opts,
+		grpc.ChainUnaryInterceptor(g.unary),
+		grpc.ChainStreamInterceptor(g.stream),
+	)
+	s := grpc.NewServer(opts...)
+
+	paging := newPaging(cfg)
+	indexerpb.RegisterIntentServiceServer(s, &intentServer{service: service, paging: paging})
+	indexerpb.RegisterRepoServiceServer(s, &repoServer{service: service, paging: paging})
+	return s
+}
+
+// gate admits the calls the REST API's middleware would admit.
+type gate struct {
+	service *manager.Service
+	cfg     *config.ManagerConfig
+}
+
+func (g *gate) unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := g.admit(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (g *gate) stream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := g.admit(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &scopedStream{ServerStream: ss, ctx: ctx})
+}
+
+// scopedStream is a stream with the context admit scoped it to.
+type scopedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *scopedStream) Context() context.Context {
+	return s.ctx
+}
+
+// admit refuses calls over their client's rate limit and, when auth is
+// enabled, calls without a valid API key or session token, or writes
+// without an admin's. Calls are let through when the limiter fails, so an
+// outage of Redis doesn't take the API down. It returns the context to
+// serve the call with, scoped to the tenant of its key if it has one.
+func (g *gate) admit(ctx context.Context, method string) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	key := firstValue(md, apiKeyMetadata)
+
+	if g.cfg.APIRateLimitEnabled() {
+		var ip string
+		if p, ok := peer.FromContext(ctx); ok {
+			ip = hostOf(p.Addr.String())
+		}
+		result, err := g.service.AllowRequest(ctx, key, ip)
+		if err != nil {
+			log.Printf("Error rate limiting call: %v", err)
+		} else if !result.Allowed {
+			seconds := int(math.Ceil(result.RetryAfter.Seconds()))
+			grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(max(seconds, 1))))
+			return nil, status.Error(codes.ResourceExhausted, "Rate limit exceeded")
+		}
+	}
+
+	if !g.cfg.AuthEnabled() {
+		return ctx, nil
+	}
+	var session *models.Session
+	var err error
+	if key != "" || !g.cfg.OAuthEnabled() {
+		session, err = g.service.ValidateAPIKey(ctx, key)
+	} else {
+		token, _ := strings.CutPrefix(firstValue(md, "authorization"), "Bearer ")
+		session, err = g.service.ValidateSession(ctx, token)
+	}
+	if err != nil {
+		if errors.Is(err, manager.ErrInvalidAPIKey) || errors.Is(err, manager.ErrInvalidSession) {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		log.Printf("Error authenticating call: %v", err)
+		return nil, status.Error(codes.Internal, "Failed to authenticate call")
+	}
+	if writeMethods[method] && !session.Role.Allows(models.AdminRole) {
+		return nil, status.Error(codes.PermissionDenied, "Insufficient permissions")
+	}
+	if session.Tenant != "" {
+		ctx = repository.WithTenant(ctx, session.Tenant)
+	}
+	return ctx, nil
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// hostOf strips the port from a peer address.
+func hostOf(addr string) string {
+	if i := strings.LastIndexByte(addr, ':'); i >= 0 {
+		return strings.Trim(addr[:i], "[]")
+	}
+	return addr
+}
@@ -0,0 +1,102 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/noelukwa/indexer/internal/manager"
+	"github.com/noelukwa/indexer/internal/pkg/config"
+	"github.com/noelukwa/indexer/pkg/indexerpb"
+	"github.com/test-go/testify/assert"
+	"github.com/test-go/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// dial serves a server without a store, which is enough for the calls
+// refused before reaching one.
+func dial(t *testing.T, cfg *config.ManagerConfig) *grpc.ClientConn {
+	lis := bufconn.Listen(1 << 20)
+	s := NewServer(manager.NewService(nil, nil, nil, nil, cfg), cfg)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestAuthentication(t *testing.T) {
+	conn := dial(t, &config.ManagerConfig{AdminAPIKey: "admin-key"})
+	intents := indexerpb.NewIntentServiceClient(conn)
+	ctx := context.Background()
+
+	_, err := intents.GetIntent(ctx, &indexerpb.GetIntentRequest{Id: "not-a-uuid"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// Authenticated calls reach the service, which refuses the ID.
+	admin := metadata.AppendToOutgoingContext(ctx, apiKeyMetadata, "admin-key")
+	_, err = intents.GetIntent(admin, &indexerpb.GetIntentRequest{Id: "not-a-uuid"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	stream, err := indexerpb.NewRepoServiceClient(conn).GetCommitsStream(ctx, &indexerpb.GetCommitsStreamRequest{Repository: "acme/widgets"})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestInvalidRequests(t *testing.T) {
+	conn := dial(t, &config.ManagerConfig{AuthDisabled: true})
+	intents := indexerpb.NewIntentServiceClient(conn)
+	repos := indexerpb.NewRepoServiceClient(conn)
+	ctx := context.Background()
+
+	_, err := intents.CreateIntent(ctx, &indexerpb.CreateIntentRequest{Repository: "acme/widgets", Since: "01/02/2024"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = intents.CreateIntent(ctx, &indexerpb.CreateIntentRequest{Repository: "acme/widgets", MaxConcurrentPages: 21})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = intents.ListIntents(ctx, &indexerpb.ListIntentsRequest{Status: "sleeping"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = repos.ListRepos(ctx, &indexerpb.ListReposRequest{Sort: "forks"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = repos.ListCommits(ctx, &indexerpb.ListCommitsRequest{Repository: "acme/widgets", PerPage: -1})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestServiceError(t *testing.T) {
+	assert.Equal(t, codes.NotFound, status.Code(serviceError(manager.ErrIntentNotFound, "Failed")))
+	assert.Equal(t, codes.AlreadyExists, status.Code(serviceError(manager.ErrExistingIntent, "Failed")))
+	assert.Equal(t, codes.InvalidArgument, status.Code(serviceError(manager.ErrInvalidStartDate, "Failed")))
+
+	err := serviceError(errors.New("connection refused"), "Failed to fetch intents")
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Equal(t, "Failed to fetch intents", status.Convert(err).Message())
+}
+
+func TestPaging(t *testing.T) {
+	p := newPaging(&config.ManagerConfig{DefaultPerPage: 20, MaxPerPage: 50})
+
+	page, perPage, err := p.resolve(0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, page)
+	assert.Equal(t, 20, perPage)
+
+	page, perPage, err = p.resolve(3, 500)
+	require.NoError(t, err)
+	assert.Equal(t, 3, page)
+	assert.Equal(t, 50, perPage)
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/noelukwa/indexer/internal/manager"
+)
+
+// AdminHandler handles operator-facing HTTP requests
+type AdminHandler struct {
+	service *manager.Service
+}
+
+// NewAdminHandler creates a new AdminHandler instance
+func NewAdminHandler(service *manager.Service) *AdminHandler {
+	return &AdminHandler{
+		service: service,
+	}
+}
+
+// FetchStatus godoc
+// @Summary Fetch the ingestion status
+// @Description Get intent counts by status, the total number of indexed commits and the most recent intent errors
+// @Tags admin
+// @Produce json
+// @Success 200 {object} models.IngestionStatus
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/status [get]
+func (h *AdminHandler) FetchStatus(c echo.Context) error {
+	status, err := h.service.GetIngestionStatus(c.Request().Context())
+	if err != nil {
+		return serviceError(c, err, "Failed to fetch ingestion status")
+	}
+
+	return c.JSON(http.StatusOK, status)
+}
+
+// FetchRateLimits godoc
+// @Summary Fetch GitHub rate limits
+// @Description Get the remaining core and search quota of each GitHub token the monitor uses, with the projected exhaustion time at the current pace
+// @Tags admin
+// @Produce json
+// @Success 200 {array} ratelimits.Status
+// @Failure 500 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Router /admin/github/rate-limit [get]
+func (h *AdminHandler) FetchRateLimits(c echo.Context) error {
+	statuses, err := h.service.GetRateLimits(c.Request().Context())
+	if err != nil {
+		return serviceError(c, err, "Failed to fetch rate limits")
+	}
+
+	return c.JSON(http.StatusOK, statuses)
+}
+
+// FetchLocks godoc
+// @Summary Fetch repository locks
+// @Description List the repository locks monitors hold, with their owner, intent, expiry and whether the reaper considers them stale, and count the stale locks reaped so far by reason
+// @Tags admin
+// @Produce json
+// @Success 200 {object} repolocks.Report
+// @Failure 500 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Router /admin/locks [get]
+func (h *AdminHandler) FetchLocks(c echo.Context) error {
+	report, err := h.service.GetLocks(c.Request().Context())
+	if err != nil {
+		return serviceError(c, err, "Failed to fetch locks")
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
+
+// FetchPipeline godoc
+// @Summary Fetch ingestion pipeline metrics
+// @Description Get the commits fetched by monitors, received and persisted by this manager since it started, with the rate of each over the last minute, and the latency between the stages from the commit's authoring on. Percentiles are approximated by histogram buckets.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} models.PipelineStats
+// @Router /admin/pipeline [get]
+func (h *AdminHandler) FetchPipeline(c echo.Context) error {
+	return c.JSON(http.StatusOK, h.service.GetPipelineStats())
+}
+
+// FetchMetrics godoc
+// @Summary Fetch Prometheus metrics
+// @Description Get this manager's ingestion pipeline counters and latency histograms in the Prometheus text format
+// @Tags admin
+// @Produce plain
+// @Success 200 {string} string "Prometheus text format"
+// @Router /metrics [get]
+func (h *AdminHandler) FetchMetrics(c echo.Context) error {
+	c.Response().Header().Set(echo.HeaderContentType, "text/plain; version=0.0.4; charset=utf-8")
+	c.Response().WriteHeader(http.StatusOK)
+	return h.service.WriteMetrics(c.Response())
+}
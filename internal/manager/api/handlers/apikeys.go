@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/noelukwa/indexer/internal/manager"
+	"github.com/noelukwa/indexer/internal/manager/models"
+)
+
+// APIKeyHeader carries an API key on a request.
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyHandler handles HTTP requests for managing API keys
+type APIKeyHandler struct {
+	service   *manager.Service
+	validator *validator.Validate
+}
+
+// NewAPIKeyHandler creates a new APIKeyHandler instance
+func NewAPIKeyHandler(service *manager.Service) *APIKeyHandler {
+	return &APIKeyHandler{
+		service:   service,
+		validator: validator.New(),
+	}
+}
+
+// CreateAPIKeyRequest represents the request body for creating an API key
+type CreateAPIKeyRequest struct {
+	Name string      `json:"name" validate:"required,max=100"`
+	Role models.Role `json:"role" validate:"required,oneof=viewer admin"`
+	// Tenant binds the key to a tenant, whose records are all it sees.
+	Tenant string `json:"tenant" validate:"omitempty,max=63"`
+}
+
+// CreateAPIKey godoc
+// @Summary Create an API key
+// @Description Generate a key that authenticates requests with the X-API-Key header under the given role. The key is only returned in this response. A key given a tenant only sees and creates the intents, repositories and commits of that tenant, and can't manage keys, credentials, digest subscriptions or the admin endpoints.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param request body CreateAPIKeyRequest true "API key creation request"
+// @Success 201 {object} models.APIKey
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api-keys [post]
+func (h *APIKeyHandler) CreateAPIKey(c echo.Context) error {
+	var request CreateAPIKeyRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request body"})
+	}
+
+	if err := h.validator.Struct(request); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	key, err := h.service.CreateAPIKey(c.Request().Context(), request.Name, request.Role, request.Tenant)
+	if err != nil {
+		return serviceError(c, err, "Failed to create API key")
+	}
+
+	return c.JSON(http.StatusCreated, key)
+}
+
+// FetchAPIKeys godoc
+// @Summary List API keys
+// @Description Get every API key, revoked ones included, without the keys themselves
+// @Tags api-keys
+// @Produce json
+// @Success 200 {array} models.APIKey
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api-keys [get]
+func (h *APIKeyHandler) FetchAPIKeys(c echo.Context) error {
+	keys, err := h.service.GetAPIKeys(c.Request().Context())
+	if err != nil {
+		return serviceError(c, err, "Failed to fetch API keys")
+	}
+
+	return c.JSON(http.StatusOK, keys)
+}
+
+// RevokeAPIKey godoc
+// @Summary Revoke an API key
+// @Description Refuse an API key from now on
+// @Tags api-keys
+// @Param id path string true "API key ID"
+// @Success 204 "Revoked"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api-keys/{id} [delete]
+func (h *APIKeyHandler) RevokeAPIKey(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid API key ID"})
+	}
+
+	if err := h.service.RevokeAPIKey(c.Request().Context(), id); err != nil {
+		return serviceError(c, err, "Failed to revoke API key")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v63/github"
+	"github.com/labstack/echo/v4"
+	"github.com/noelukwa/indexer/internal/manager"
+	"github.com/noelukwa/indexer/internal/pkg/config"
+	"golang.org/x/oauth2"
+	githuboauth "golang.org/x/oauth2/github"
+)
+
+const (
+	SessionCookieName = "indexer_session"
+	oauthStateCookie  = "indexer_oauth_state"
+)
+
+// AuthHandler handles the GitHub OAuth login flow
+type AuthHandler struct {
+	service *manager.Service
+	oauth   *oauth2.Config
+}
+
+// NewAuthHandler creates a new AuthHandler instance
+func NewAuthHandler(service *manager.Service, cfg *config.ManagerConfig) *AuthHandler {
+	return &AuthHandler{
+		service: service,
+		oauth: &oauth2.Config{
+			ClientID:     cfg.GitHubClientID,
+			ClientSecret: cfg.GitHubClientSecret,
+			RedirectURL:  cfg.GitHubRedirectURL,
+			Scopes:       []string{"read:user", "read:org"},
+			Endpoint:     githuboauth.Endpoint,
+		},
+	}
+}
+
+// Login godoc
+// @Summary Start GitHub login
+// @Description Redirect the user to GitHub to authorize the indexer
+// @Tags auth
+// @Success 307
+// @Router /auth/github/login [get]
+func (h *AuthHandler) Login(c echo.Context) error {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to start login"})
+	}
+	state := hex.EncodeToString(b)
+
+	c.SetCookie(&http.Cookie{
+		Name:     oauthStateCookie,
+		Value:    state,
+		Path:     "/",
+		MaxAge:   int((10 * time.Minute).Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return c.Redirect(http.StatusTemporaryRedirect, h.oauth.AuthCodeURL(state))
+}
+
+// SessionResponse represents a newly opened API session
+type SessionResponse struct {
+	Token     string    `json:"token"`
+	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Callback godoc
+// @Summary Complete GitHub login
+// @Description Exchange the GitHub authorization code for an API session
+// @Tags auth
+// @Produce json
+// @Param code query string true "Authorization code"
+// @Param state query string true "OAuth state"
+// @Success 200 {object} SessionResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /auth/github/callback [get]
+func (h *AuthHandler) Callback(c echo.Context) error {
+	stateCookie, err := c.Cookie(oauthStateCookie)
+	if err != nil || stateCookie.Value == "" || stateCookie.Value != c.QueryParam("state") {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid OAuth state"})
+	}
+
+	ctx := c.Request().Context()
+	token, err := h.oauth.Exchange(ctx, c.QueryParam("code"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Failed to exchange authorization code"})
+	}
+
+	client := github.NewClient(h.oauth.Client(ctx, token))
+
+	user, _, err := client.Users.Get(ctx, "")
+	if err != nil {
+		log.Printf("Error fetching GitHub user: %v", err)
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch GitHub user"})
+	}
+
+	var orgs []string
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := client.Organizations.List(ctx, "", opts)
+		if err != nil {
+			log.Printf("Error fetching GitHub organisations: %v", err)
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch GitHub organisations"})
+		}
+		for _, org := range page {
+			orgs = append(orgs, org.GetLogin())
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	session, err := h.service.CreateSession(ctx, user.GetLogin(), orgs)
+	if err != nil {
+		if errors.Is(err, manager.ErrNoRole) {
+			return c.JSON(http.StatusForbidden, ErrorResponse{Error: err.Error()})
+		}
+		return serviceError(c, err, "Failed to create session")
+	}
+
+	c.SetCookie(&http.Cookie{
+		Name:     oauthStateCookie,
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+	})
+	c.SetCookie(&http.Cookie{
+		Name:     SessionCookieName,
+		Value:    session.Token,
+		Path:     "/",
+		Expires:  session.ExpiresAt,
+		HttpOnly: true,
+		Secure:   c.IsTLS(),
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	// Browsers arriving from the dashboard go back to it with the cookie set.
+	if strings.Contains(c.Request().Header.Get(echo.HeaderAccept), echo.MIMETextHTML) {
+		return c.Redirect(http.StatusFound, "/ui/")
+	}
+
+	return c.JSON(http.StatusOK, SessionResponse{
+		Token:     session.Token,
+		Username:  session.Username,
+		Role:      string(session.Role),
+		ExpiresAt: session.ExpiresAt,
+	})
+}
+
+// Logout godoc
+// @Summary End the current session
+// @Description Revoke the session used to make this request
+// @Tags auth
+// @Success 204
+// @Failure 500 {object} ErrorResponse
+// @Router /auth/logout [post]
+func (h *AuthHandler) Logout(c echo.Context) error {
+	if token := SessionToken(c); token != "" {
+		if err := h.service.RevokeSession(c.Request().Context(), token); err != nil {
+			return serviceError(c, err, "Failed to revoke session")
+		}
+	}
+
+	c.SetCookie(&http.Cookie{
+		Name:     SessionCookieName,
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+	})
+	return c.NoContent(http.StatusNoContent)
+}
+
+// SessionToken extracts the session token from the Authorization header,
+// falling back to the session cookie set by the login callback.
+func SessionToken(c echo.Context) string {
+	if auth := c.Request().Header.Get(echo.HeaderAuthorization); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimPrefix(auth, "Bearer ")
+	}
+	if cookie, err := c.Cookie(SessionCookieName); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
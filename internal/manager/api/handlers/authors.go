@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/noelukwa/indexer/internal/manager"
+)
+
+// AuthorHandler handles HTTP requests related to commit authors
+type AuthorHandler struct {
+	service *manager.Service
+}
+
+// NewAuthorHandler creates a new AuthorHandler instance
+func NewAuthorHandler(service *manager.Service) *AuthorHandler {
+	return &AuthorHandler{service: service}
+}
+
+// FetchAuthorProfile godoc
+// @Summary Fetch an author's profile
+// @Description Get the author with a GitHub login, in any case, and their indexed commits across repositories: the total, the number of repositories, their first and last commit dates and a breakdown per repository, most commits first. Archived commits are not counted.
+// @Tags authors
+// @Produce json
+// @Param username path string true "GitHub login of the author"
+// @Param If-None-Match header string false "ETag of a previous response"
+// @Success 200 {object} models.AuthorProfile
+// @Header 200 {string} ETag "Weak ETag of the response body"
+// @Success 304 "Unchanged since the If-None-Match ETag"
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /authors/{username} [get]
+func (h *AuthorHandler) FetchAuthorProfile(c echo.Context) error {
+	profile, err := h.service.GetAuthorProfile(c.Request().Context(), c.Param("username"))
+	if err != nil {
+		return serviceError(c, err, "Failed to fetch author profile")
+	}
+
+	return cachedJSON(c, profile)
+}
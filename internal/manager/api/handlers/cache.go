@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// cachedJSON writes v as JSON with an ETag of its body. A request whose
+// If-None-Match still matches gets 304 Not Modified instead, so polling
+// clients don't transfer identical payloads again. There is no
+// Last-Modified: commits backfilled into a repository change its results
+// without moving any timestamp it keeps.
+func cachedJSON(c echo.Context, v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(body)
+	etag := `W/"` + hex.EncodeToString(sum[:16]) + `"`
+
+	header := c.Response().Header()
+	header.Set("ETag", etag)
+	// Clients may keep the response but must revalidate before using it.
+	header.Set(echo.HeaderCacheControl, "private, no-cache")
+
+	if notModified(c.Request(), etag) {
+		return c.NoContent(http.StatusNotModified)
+	}
+	return c.JSONBlob(http.StatusOK, body)
+}
+
+func notModified(req *http.Request, etag string) bool {
+	for _, tag := range strings.Split(req.Header.Get("If-None-Match"), ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || (tag != "" && strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/")) {
+			return true
+		}
+	}
+	return false
+}
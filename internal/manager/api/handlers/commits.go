@@ -3,32 +3,37 @@ package handlers
 import (
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
 	"github.com/noelukwa/indexer/internal/manager"
 	"github.com/noelukwa/indexer/internal/manager/models"
+	"github.com/noelukwa/indexer/internal/pkg/config"
 )
 
 // RemoteHandler handles HTTP requests related to remote repositories
 type RemoteHandler struct {
 	service   *manager.Service
 	validator *validator.Validate
+	paging    paging
 }
 
 // NewRemoteRepositoryHandler creates a new RemoteHandler instance
-func NewRemoteRepositoryHandler(service *manager.Service) *RemoteHandler {
+func NewRemoteRepositoryHandler(service *manager.Service, cfg *config.ManagerConfig) *RemoteHandler {
 	return &RemoteHandler{
 		service:   service,
 		validator: validator.New(),
+		paging:    newPaging(cfg),
 	}
 }
 
 // TopCommittersRequest represents the request parameters for fetching top committers
 type TopCommittersRequest struct {
-	Repo    string `query:"repo" validate:"required"`
-	Page    int    `query:"page" validate:"required,min=1"`
-	PerPage int    `query:"per_page" validate:"required,min=1,max=100"`
+	Repo  string `query:"repo" validate:"required"`
+	Since string `query:"since" validate:"omitempty,datetime=2006-01-02"`
+	Until string `query:"until" validate:"omitempty,datetime=2006-01-02"`
+	PageQuery
 }
 
 // TopCommittersResponse represents the response for top committers
@@ -37,21 +42,33 @@ type TopCommittersResponse struct {
 	TotalCount int64                `json:"total_count"`
 	Page       int                  `json:"page"`
 	PerPage    int                  `json:"per_page"`
+	// PerPageCapped is set when the requested per_page was over the
+	// maximum.
+	PerPageCapped bool `json:"per_page_capped,omitempty"`
+	// Archived is set when some of the repository's commits have been
+	// archived, and so are left out of the counts.
+	Archived *models.ArchiveNotice `json:"archived,omitempty"`
 }
 
 // FetchTopCommitters godoc
 // @Summary Fetch the top committers in a repository
-// @Description Get a paginated list of top committers for a specified repository
+// @Description Get a paginated list of top committers for a specified repository, optionally counting only the commits in a date range. Until includes the commits made on that day. Commits that have been archived are not counted, which the archived field reports.
 // @Tags repos
 // @Accept json
 // @Produce json
+// @Param name path string true "Repository owner, kept for older clients; the repo parameter names the repository"
 // @Param repo query string true "Repository name in the format 'owner/repo'"
-// @Param page query int true "Page number for pagination" minimum(1)
-// @Param per_page query int true "Number of items per page" minimum(1) maximum(100)
+// @Param since query string false "Start date (YYYY-MM-DD)"
+// @Param until query string false "End date (YYYY-MM-DD)"
+// @Param page query int false "Page number, 1 by default" minimum(1)
+// @Param per_page query int false "Items per page, 20 by default and capped at 100 unless configured otherwise" minimum(1)
+// @Param If-None-Match header string false "ETag of a previous response"
 // @Success 200 {object} TopCommittersResponse
+// @Header 200 {string} ETag "Weak ETag of the response body"
+// @Success 304 "Unchanged since the If-None-Match ETag"
 // @Failure 400 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
-// @Router /repos/top-committers [get]
+// @Router /repos/{name}/committers [get]
 func (h *RemoteHandler) FetchTopCommitters(c echo.Context) error {
 	var req TopCommittersRequest
 	if err := c.Bind(&req); err != nil {
@@ -62,19 +79,88 @@ func (h *RemoteHandler) FetchTopCommitters(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 	}
 
-	paginatedResult, err := h.service.GetTopCommitters(c.Request().Context(), req.Repo, req.Page, req.PerPage)
+	var since, until time.Time
+	if req.Since != "" {
+		since, _ = time.Parse(time.DateOnly, req.Since)
+	}
+	if req.Until != "" {
+		until, _ = time.Parse(time.DateOnly, req.Until)
+	}
+
+	page, perPage, capped := h.paging.resolve(req.PageQuery)
+	paginatedResult, err := h.service.GetTopCommitters(c.Request().Context(), req.Repo, since, until, page, perPage)
+	if err != nil {
+		return serviceError(c, err, "Failed to get top committers")
+	}
+	archived, err := h.service.GetArchiveNotice(c.Request().Context(), req.Repo, &since)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("Failed to get top committers: %v", err)})
+		return serviceError(c, err, "Failed to get top committers")
 	}
 
 	response := TopCommittersResponse{
-		Data:       paginatedResult.Data,
-		TotalCount: paginatedResult.TotalCount,
-		Page:       paginatedResult.Page,
-		PerPage:    paginatedResult.PerPage,
+		Data:          paginatedResult.Data,
+		TotalCount:    paginatedResult.TotalCount,
+		Page:          paginatedResult.Page,
+		PerPage:       paginatedResult.PerPage,
+		PerPageCapped: capped,
+		Archived:      archived,
+	}
+
+	return cachedJSON(c, response)
+}
+
+// FetchReposRequest represents the query parameters for listing repositories
+type FetchReposRequest struct {
+	Language *string `query:"language" validate:"omitempty"`
+	Sort     string  `query:"sort" validate:"omitempty,oneof=name stars commit_count last_commit_at"`
+	Order    string  `query:"order" validate:"omitempty,oneof=asc desc"`
+	PageQuery
+}
+
+// FetchRepos godoc
+// @Summary List indexed repositories
+// @Description Get a paginated list of the indexed repositories, sorted by name, stars, indexed commit count or latest indexed commit
+// @Tags repos
+// @Accept json
+// @Produce json
+// @Param language query string false "Filter by language"
+// @Param sort query string false "Sort key, name by default" Enums(name, stars, commit_count, last_commit_at)
+// @Param order query string false "Sort order, asc by default" Enums(asc, desc)
+// @Param page query int false "Page number, 1 by default" minimum(1)
+// @Param per_page query int false "Items per page, 20 by default and capped at 100 unless configured otherwise" minimum(1)
+// @Success 200 {object} PaginatedResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /repos [get]
+func (h *RemoteHandler) FetchRepos(c echo.Context) error {
+	var req FetchReposRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid query parameters"})
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	filter := models.RepositoryFilter{
+		Language:   req.Language,
+		Sort:       models.RepositorySort(req.Sort),
+		Descending: req.Order == "desc",
+	}
+
+	page, perPage, capped := h.paging.resolve(req.PageQuery)
+	repos, err := h.service.GetRepositories(c.Request().Context(), filter, page, perPage)
+	if err != nil {
+		return serviceError(c, err, "Failed to fetch repositories")
 	}
 
-	return c.JSON(http.StatusOK, response)
+	return c.JSON(http.StatusOK, PaginatedResponse{
+		Data:          repos.Data,
+		TotalCount:    repos.TotalCount,
+		Page:          repos.Page,
+		PerPage:       repos.PerPage,
+		PerPageCapped: capped,
+	})
 }
 
 // FetchRepoInfo godoc
@@ -85,7 +171,10 @@ func (h *RemoteHandler) FetchTopCommitters(c echo.Context) error {
 // @Produce json
 // @Param owner path string true "Repository owner"
 // @Param name path string true "Repository name"
+// @Param If-None-Match header string false "ETag of a previous response"
 // @Success 200 {object} models.Repository
+// @Header 200 {string} ETag "Weak ETag of the response body"
+// @Success 304 "Unchanged since the If-None-Match ETag"
 // @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /repos/{owner}/{name} [get]
@@ -94,13 +183,481 @@ func (h *RemoteHandler) FetchRepoInfo(c echo.Context) error {
 	name := c.Param("name")
 	repoInfo, err := h.service.FindRepository(c.Request().Context(), fmt.Sprintf("%s/%s", owner, name))
 	if err != nil {
-		if err == manager.ErrRepositoryNotFound {
-			return c.JSON(http.StatusNotFound, ErrorResponse{Error: "Repository not found"})
-		}
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch repository information"})
+		return serviceError(c, err, "Failed to fetch repository information")
+	}
+
+	return cachedJSON(c, repoInfo)
+}
+
+// DeleteRepo godoc
+// @Summary Delete a repository
+// @Description Soft-delete a repository, leaving it and its indexed commits out of every lookup, listing, search and statistic. It is purged for good, with its commits and archives, once the configured retention has passed, and cannot be indexed again until then. Deleting is refused while intents index the repository.
+// @Tags repos
+// @Produce json
+// @Param owner path string true "Repository owner"
+// @Param name path string true "Repository name"
+// @Success 200 {object} models.Repository
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /repos/{owner}/{name} [delete]
+func (h *RemoteHandler) DeleteRepo(c echo.Context) error {
+	owner := c.Param("owner")
+	name := c.Param("name")
+	repo, err := h.service.DeleteRepository(c.Request().Context(), fmt.Sprintf("%s/%s", owner, name))
+	if err != nil {
+		return serviceError(c, err, "Failed to delete repository")
+	}
+
+	return c.JSON(http.StatusOK, repo)
+}
+
+// ChurnRequest represents the query parameters for fetching churn
+type ChurnRequest struct {
+	Since string `query:"since" validate:"omitempty,datetime=2006-01-02"`
+	Until string `query:"until" validate:"omitempty,datetime=2006-01-02"`
+}
+
+// FetchChurn godoc
+// @Summary Fetch repository churn
+// @Description Get the lines added, deleted and changed across a repository's indexed commits
+// @Tags repos
+// @Accept json
+// @Produce json
+// @Param owner path string true "Repository owner"
+// @Param name path string true "Repository name"
+// @Param If-None-Match header string false "ETag of a previous response"
+// @Param since query string false "Start date (YYYY-MM-DD)"
+// @Param until query string false "End date (YYYY-MM-DD)"
+// @Success 200 {object} models.Churn
+// @Header 200 {string} ETag "Weak ETag of the response body"
+// @Success 304 "Unchanged since the If-None-Match ETag"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /repos/{owner}/{name}/churn [get]
+func (h *RemoteHandler) FetchChurn(c echo.Context) error {
+	var req ChurnRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request parameters"})
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	var since, until time.Time
+	if req.Since != "" {
+		since, _ = time.Parse(time.DateOnly, req.Since)
+	}
+	if req.Until != "" {
+		until, _ = time.Parse(time.DateOnly, req.Until)
+	}
+
+	repo := fmt.Sprintf("%s/%s", c.Param("owner"), c.Param("name"))
+	churn, err := h.service.GetChurn(c.Request().Context(), repo, since, until)
+	if err != nil {
+		return serviceError(c, err, "Failed to fetch churn")
+	}
+
+	return cachedJSON(c, churn)
+}
+
+// StatsRequest represents the query parameters for fetching repository
+// stats. Dedupe counts commits shared across a fork network once.
+type StatsRequest struct {
+	Since  string `query:"since" validate:"omitempty,datetime=2006-01-02"`
+	Until  string `query:"until" validate:"omitempty,datetime=2006-01-02"`
+	Dedupe bool   `query:"dedupe"`
+}
+
+// FetchStats godoc
+// @Summary Fetch repository stats
+// @Description Get the commits, line changes, authors and active days of a repository from the daily rollup
+// @Tags repos
+// @Accept json
+// @Produce json
+// @Param owner path string true "Repository owner"
+// @Param name path string true "Repository name"
+// @Param If-None-Match header string false "ETag of a previous response"
+// @Param since query string false "Start date (YYYY-MM-DD)"
+// @Param until query string false "End date (YYYY-MM-DD)"
+// @Param dedupe query bool false "Leave out commits shared with the repository's upstream"
+// @Success 200 {object} models.RepoStats
+// @Header 200 {string} ETag "Weak ETag of the response body"
+// @Success 304 "Unchanged since the If-None-Match ETag"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /repos/{owner}/{name}/stats [get]
+func (h *RemoteHandler) FetchStats(c echo.Context) error {
+	var req StatsRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request parameters"})
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	var since, until time.Time
+	if req.Since != "" {
+		since, _ = time.Parse(time.DateOnly, req.Since)
+	}
+	if req.Until != "" {
+		until, _ = time.Parse(time.DateOnly, req.Until)
+	}
+
+	repo := fmt.Sprintf("%s/%s", c.Param("owner"), c.Param("name"))
+	stats, err := h.service.GetRepoStats(c.Request().Context(), repo, since, until, req.Dedupe)
+	if err != nil {
+		return serviceError(c, err, "Failed to fetch stats")
+	}
+
+	return cachedJSON(c, stats)
+}
+
+// FetchDailyStats godoc
+// @Summary Fetch a repository's daily commit counts
+// @Description Get the commits, additions and deletions per UTC day from the daily rollup, with zeros for days without commits. The range may span at most 5 years and end by tomorrow.
+// @Tags repos
+// @Accept json
+// @Produce json
+// @Param owner path string true "Repository owner"
+// @Param name path string true "Repository name"
+// @Param If-None-Match header string false "ETag of a previous response"
+// @Param since query string false "Start date (YYYY-MM-DD)"
+// @Param until query string false "End date (YYYY-MM-DD)"
+// @Param dedupe query bool false "Leave out commits shared with the repository's upstream"
+// @Success 200 {array} models.DailyCommits
+// @Header 200 {string} ETag "Weak ETag of the response body"
+// @Success 304 "Unchanged since the If-None-Match ETag"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /repos/{owner}/{name}/stats/daily [get]
+func (h *RemoteHandler) FetchDailyStats(c echo.Context) error {
+	var req StatsRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request parameters"})
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	var since, until time.Time
+	if req.Since != "" {
+		since, _ = time.Parse(time.DateOnly, req.Since)
+	}
+	if req.Until != "" {
+		until, _ = time.Parse(time.DateOnly, req.Until)
+	}
+
+	repo := fmt.Sprintf("%s/%s", c.Param("owner"), c.Param("name"))
+	days, err := h.service.GetDailyCommits(c.Request().Context(), repo, since, until, req.Dedupe)
+	if err != nil {
+		return serviceError(c, err, "Failed to fetch daily commits")
+	}
+
+	return cachedJSON(c, days)
+}
+
+// ContributionsRequest is the date range of a contribution matrix.
+type ContributionsRequest struct {
+	Since string `query:"since" validate:"omitempty,datetime=2006-01-02"`
+	Until string `query:"until" validate:"omitempty,datetime=2006-01-02"`
+}
+
+// FetchContributions godoc
+// @Summary Fetch a repository's contribution matrix
+// @Description Get each author's commits per UTC month from the daily rollup, with zeros for months without commits. Authors with the most commits come first. The range may span at most 5 years and end by tomorrow.
+// @Tags repos
+// @Accept json
+// @Produce json
+// @Param owner path string true "Repository owner"
+// @Param name path string true "Repository name"
+// @Param If-None-Match header string false "ETag of a previous response"
+// @Param since query string false "Start date (YYYY-MM-DD)"
+// @Param until query string false "End date (YYYY-MM-DD)"
+// @Success 200 {object} models.ContributionMatrix
+// @Header 200 {string} ETag "Weak ETag of the response body"
+// @Success 304 "Unchanged since the If-None-Match ETag"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /repos/{owner}/{name}/stats/contributions [get]
+func (h *RemoteHandler) FetchContributions(c echo.Context) error {
+	var req ContributionsRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request parameters"})
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	var since, until time.Time
+	if req.Since != "" {
+		since, _ = time.Parse(time.DateOnly, req.Since)
+	}
+	if req.Until != "" {
+		until, _ = time.Parse(time.DateOnly, req.Until)
+	}
+
+	repo := fmt.Sprintf("%s/%s", c.Param("owner"), c.Param("name"))
+	matrix, err := h.service.GetContributions(c.Request().Context(), repo, since, until)
+	if err != nil {
+		return serviceError(c, err, "Failed to fetch contributions")
+	}
+
+	return cachedJSON(c, matrix)
+}
+
+// ActivityRequest is the date range and bucket width of a commit activity
+// histogram.
+type ActivityRequest struct {
+	Since    string `query:"since" validate:"omitempty,datetime=2006-01-02"`
+	Until    string `query:"until" validate:"omitempty,datetime=2006-01-02"`
+	Interval string `query:"interval" validate:"omitempty,oneof=day week month"`
+}
+
+// FetchActivity godoc
+// @Summary Fetch a repository's commit activity
+// @Description Get the commits per UTC day, week or month from the daily rollup, with zeros for buckets without commits. Weeks start on Monday and buckets are dated by their first day. The range may span at most 5 years and end by tomorrow.
+// @Tags repos
+// @Accept json
+// @Produce json
+// @Param owner path string true "Repository owner"
+// @Param name path string true "Repository name"
+// @Param If-None-Match header string false "ETag of a previous response"
+// @Param since query string false "Start date (YYYY-MM-DD)"
+// @Param until query string false "End date (YYYY-MM-DD)"
+// @Param interval query string false "Bucket width, day by default" Enums(day, week, month)
+// @Success 200 {array} models.ActivityBucket
+// @Header 200 {string} ETag "Weak ETag of the response body"
+// @Success 304 "Unchanged since the If-None-Match ETag"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /repos/{owner}/{name}/stats/activity [get]
+func (h *RemoteHandler) FetchActivity(c echo.Context) error {
+	var req ActivityRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request parameters"})
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	var since, until time.Time
+	if req.Since != "" {
+		since, _ = time.Parse(time.DateOnly, req.Since)
+	}
+	if req.Until != "" {
+		until, _ = time.Parse(time.DateOnly, req.Until)
+	}
+
+	repo := fmt.Sprintf("%s/%s", c.Param("owner"), c.Param("name"))
+	buckets, err := h.service.GetCommitActivity(c.Request().Context(), repo, models.ActivityInterval(req.Interval), since, until)
+	if err != nil {
+		return serviceError(c, err, "Failed to fetch commit activity")
+	}
+
+	return cachedJSON(c, buckets)
+}
+
+// FetchGitHubStats godoc
+// @Summary Fetch GitHub's stats of a repository
+// @Description Get the weekly code frequency, the weekly participation of the last year and the punch card GitHub precomputes for a repository, as last fetched by the monitor. They are available before a backfill of its commits ends.
+// @Tags repos
+// @Accept json
+// @Produce json
+// @Param owner path string true "Repository owner"
+// @Param name path string true "Repository name"
+// @Param If-None-Match header string false "ETag of a previous response"
+// @Success 200 {object} models.GitHubStats
+// @Header 200 {string} ETag "Weak ETag of the response body"
+// @Success 304 "Unchanged since the If-None-Match ETag"
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /repos/{owner}/{name}/stats/github [get]
+func (h *RemoteHandler) FetchGitHubStats(c echo.Context) error {
+	repo := fmt.Sprintf("%s/%s", c.Param("owner"), c.Param("name"))
+	stats, err := h.service.GetGitHubStats(c.Request().Context(), repo)
+	if err != nil {
+		return serviceError(c, err, "Failed to fetch GitHub stats")
+	}
+
+	return cachedJSON(c, stats)
+}
+
+// FetchCommitsRequest represents the query parameters for listing a
+// repository's commits
+type FetchCommitsRequest struct {
+	Author  string `query:"author"`
+	Since   string `query:"since" validate:"omitempty,datetime=2006-01-02"`
+	Until   string `query:"until" validate:"omitempty,datetime=2006-01-02"`
+	Message string `query:"message"`
+	PageQuery
+}
+
+// FetchCommits godoc
+// @Summary List a repository's commits
+// @Description Get a paginated list of a repository's indexed commits, the newest first, optionally narrowed to an author's GitHub login, a date range and a case-insensitive message substring. Commits that have been archived are left out, which the archived field reports.
+// @Tags repos
+// @Accept json
+// @Produce json
+// @Param owner path string true "Repository owner"
+// @Param name path string true "Repository name"
+// @Param If-None-Match header string false "ETag of a previous response"
+// @Param author query string false "Author GitHub username"
+// @Param since query string false "Start date (YYYY-MM-DD)"
+// @Param until query string false "End date, inclusive (YYYY-MM-DD)"
+// @Param message query string false "Substring of the commit message"
+// @Param page query int false "Page number, 1 by default" minimum(1)
+// @Param per_page query int false "Items per page, 20 by default and capped at 100 unless configured otherwise" minimum(1)
+// @Success 200 {object} PaginatedResponse
+// @Header 200 {string} ETag "Weak ETag of the response body"
+// @Success 304 "Unchanged since the If-None-Match ETag"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /repos/{owner}/{name}/commits [get]
+func (h *RemoteHandler) FetchCommits(c echo.Context) error {
+	var req FetchCommitsRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request parameters"})
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	since, until := parseDateRange(req.Since, req.Until)
+	filter := models.CommitsFilter{
+		RepositoryName: fmt.Sprintf("%s/%s", c.Param("owner"), c.Param("name")),
+		StartDate:      &since,
+		EndDate:        &until,
+		AuthorUsername: &req.Author,
+		Message:        &req.Message,
+	}
+
+	page, perPage, capped := h.paging.resolve(req.PageQuery)
+	commits, err := h.service.GetCommits(c.Request().Context(), filter, page, perPage)
+	if err != nil {
+		return serviceError(c, err, "Failed to fetch commits")
+	}
+
+	return cachedJSON(c, PaginatedResponse{
+		Data:          commits.Commits,
+		TotalCount:    commits.TotalCount,
+		Page:          int(commits.Page),
+		PerPage:       int(commits.PerPage),
+		PerPageCapped: capped,
+		Archived:      commits.Archived,
+	})
+}
+
+// CommitDeltaRequest represents the query parameters for tailing a
+// repository's commits
+type CommitDeltaRequest struct {
+	Cursor   string `query:"cursor"`
+	SinceSHA string `query:"since_sha" validate:"omitempty,hexadecimal"`
+	Since    string `query:"since" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	PerPage  int    `query:"per_page" validate:"omitempty,min=1"`
+}
+
+// FetchCommitDelta godoc
+// @Summary Tail a repository's commits
+// @Description Get a repository's commits in the order they were made, the oldest first, starting after an opaque cursor from a previous response, after the commit since_sha, or at the timestamp since, for consumers keeping a copy of the index in sync. At most one of them may be given; without any the commits start at the first. next_cursor continues after the last commit returned, or from the same position when there are none, and has_more is set when more commits are already indexed. Older history indexed after a cursor has passed it, such as by a backfill, lands behind the cursor, and archived commits are left out.
+// @Tags repos
+// @Produce json
+// @Param owner path string true "Repository owner"
+// @Param name path string true "Repository name"
+// @Param cursor query string false "next_cursor of a previous response"
+// @Param since_sha query string false "SHA of an indexed commit to start after"
+// @Param since query string false "Timestamp to start at (RFC 3339)"
+// @Param per_page query int false "Commits per response, 20 by default and capped at 100 unless configured otherwise" minimum(1)
+// @Param If-None-Match header string false "ETag of a previous response"
+// @Success 200 {object} models.CommitDelta
+// @Header 200 {string} ETag "Weak ETag of the response body"
+// @Success 304 "Unchanged since the If-None-Match ETag"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /repos/{owner}/{name}/commits/delta [get]
+func (h *RemoteHandler) FetchCommitDelta(c echo.Context) error {
+	var req CommitDeltaRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request parameters"})
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	_, perPage, _ := h.paging.resolve(PageQuery{PerPage: req.PerPage})
+	q := models.CommitDeltaQuery{Cursor: req.Cursor, SinceSHA: req.SinceSHA, Limit: perPage}
+	if req.Since != "" {
+		q.Since, _ = time.Parse(time.RFC3339, req.Since)
+	}
+
+	repo := fmt.Sprintf("%s/%s", c.Param("owner"), c.Param("name"))
+	delta, err := h.service.GetCommitDelta(c.Request().Context(), repo, q)
+	if err != nil {
+		return serviceError(c, err, "Failed to fetch commits")
+	}
+
+	return cachedJSON(c, delta)
+}
+
+// FetchArchive godoc
+// @Summary List a repository's archived commits
+// @Description Get the Parquet objects in the archive store holding a repository's archived commits, the oldest first, with the date before which its commits may have been archived
+// @Tags repos
+// @Produce json
+// @Param owner path string true "Repository owner"
+// @Param name path string true "Repository name"
+// @Success 200 {object} models.RepoArchive
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /repos/{owner}/{name}/archives [get]
+func (h *RemoteHandler) FetchArchive(c echo.Context) error {
+	repo := fmt.Sprintf("%s/%s", c.Param("owner"), c.Param("name"))
+	archive, err := h.service.GetRepoArchive(c.Request().Context(), repo)
+	if err != nil {
+		return serviceError(c, err, "Failed to fetch archives")
+	}
+
+	return c.JSON(http.StatusOK, archive)
+}
+
+// FetchCommitComments godoc
+// @Summary Fetch the comments on a commit
+// @Description Get the comments left on a commit of a repository, the oldest first. Comments are only indexed when the monitor fetches them.
+// @Tags repos
+// @Accept json
+// @Produce json
+// @Param owner path string true "Repository owner"
+// @Param name path string true "Repository name"
+// @Param sha path string true "Commit SHA"
+// @Param If-None-Match header string false "ETag of a previous response"
+// @Success 200 {array} models.CommitComment
+// @Header 200 {string} ETag "Weak ETag of the response body"
+// @Success 304 "Unchanged since the If-None-Match ETag"
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /repos/{owner}/{name}/commits/{sha}/comments [get]
+func (h *RemoteHandler) FetchCommitComments(c echo.Context) error {
+	repo := fmt.Sprintf("%s/%s", c.Param("owner"), c.Param("name"))
+	comments, err := h.service.GetCommitComments(c.Request().Context(), repo, c.Param("sha"))
+	if err != nil {
+		return serviceError(c, err, "Failed to fetch commit comments")
 	}
 
-	return c.JSON(http.StatusOK, repoInfo)
+	return cachedJSON(c, comments)
 }
 
 // ErrorResponse represents an error response
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/noelukwa/indexer/internal/manager"
+)
+
+// CredentialHandler handles HTTP requests for stored GitHub credentials
+type CredentialHandler struct {
+	service   *manager.Service
+	validator *validator.Validate
+}
+
+// NewCredentialHandler creates a new CredentialHandler instance
+func NewCredentialHandler(service *manager.Service) *CredentialHandler {
+	return &CredentialHandler{
+		service:   service,
+		validator: validator.New(),
+	}
+}
+
+// CreateCredentialRequest represents the request body for storing a credential
+type CreateCredentialRequest struct {
+	Name  string `json:"name" validate:"required,max=100"`
+	Token string `json:"token" validate:"required"`
+}
+
+// CreateCredential godoc
+// @Summary Store a GitHub credential
+// @Description Seal a GitHub token so intents can index under it by referencing its ID
+// @Tags credentials
+// @Accept json
+// @Produce json
+// @Param request body CreateCredentialRequest true "Credential creation request"
+// @Success 201 {object} models.Credential
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /credentials [post]
+func (h *CredentialHandler) CreateCredential(c echo.Context) error {
+	var request CreateCredentialRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request body"})
+	}
+
+	if err := h.validator.Struct(request); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	credential, err := h.service.CreateCredential(c.Request().Context(), request.Name, request.Token)
+	if err != nil {
+		return serviceError(c, err, "Failed to store credential")
+	}
+
+	return c.JSON(http.StatusCreated, credential)
+}
+
+// FetchCredentials godoc
+// @Summary List stored GitHub credentials
+// @Description Get the names and IDs of stored credentials; tokens are never returned
+// @Tags credentials
+// @Produce json
+// @Success 200 {array} models.Credential
+// @Failure 500 {object} ErrorResponse
+// @Router /credentials [get]
+func (h *CredentialHandler) FetchCredentials(c echo.Context) error {
+	credentials, err := h.service.GetCredentials(c.Request().Context())
+	if err != nil {
+		return serviceError(c, err, "Failed to fetch credentials")
+	}
+
+	return c.JSON(http.StatusOK, credentials)
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/noelukwa/indexer/internal/manager"
+)
+
+// DigestHandler handles HTTP requests for weekly email digests
+type DigestHandler struct {
+	service   *manager.Service
+	validator *validator.Validate
+}
+
+// NewDigestHandler creates a new DigestHandler instance
+func NewDigestHandler(service *manager.Service) *DigestHandler {
+	return &DigestHandler{
+		service:   service,
+		validator: validator.New(),
+	}
+}
+
+// CreateDigestSubscriptionRequest represents the request body for
+// subscribing to a weekly digest
+type CreateDigestSubscriptionRequest struct {
+	Email      string `json:"email" validate:"required,email"`
+	Repository string `json:"repository"`
+	Label      string `json:"label" validate:"max=50"`
+}
+
+// CreateDigestSubscription godoc
+// @Summary Subscribe to a weekly digest
+// @Description Email the weekly digest of a repository, or of every indexed repository with a GitHub topic given as label, to an address. Digests summarize the last full week, Monday to Sunday in UTC, and are only sent for weeks with commits.
+// @Tags digests
+// @Accept json
+// @Produce json
+// @Param request body CreateDigestSubscriptionRequest true "Digest subscription request"
+// @Success 201 {object} models.DigestSubscription
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /digests/subscriptions [post]
+func (h *DigestHandler) CreateDigestSubscription(c echo.Context) error {
+	var request CreateDigestSubscriptionRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request body"})
+	}
+
+	if err := h.validator.Struct(request); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	sub, err := h.service.CreateDigestSubscription(c.Request().Context(), request.Email, request.Repository, request.Label)
+	if err != nil {
+		return serviceError(c, err, "Failed to create digest subscription")
+	}
+
+	return c.JSON(http.StatusCreated, sub)
+}
+
+// FetchDigestSubscriptions godoc
+// @Summary List weekly digest subscriptions
+// @Description Get every digest subscription with the Monday of the last week it was sent
+// @Tags digests
+// @Produce json
+// @Success 200 {array} models.DigestSubscription
+// @Failure 500 {object} ErrorResponse
+// @Router /digests/subscriptions [get]
+func (h *DigestHandler) FetchDigestSubscriptions(c echo.Context) error {
+	subs, err := h.service.GetDigestSubscriptions(c.Request().Context())
+	if err != nil {
+		return serviceError(c, err, "Failed to fetch digest subscriptions")
+	}
+
+	return c.JSON(http.StatusOK, subs)
+}
+
+// DeleteDigestSubscription godoc
+// @Summary Unsubscribe from a weekly digest
+// @Description Delete a digest subscription
+// @Tags digests
+// @Param id path string true "Subscription ID"
+// @Success 204 "Deleted"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /digests/subscriptions/{id} [delete]
+func (h *DigestHandler) DeleteDigestSubscription(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid subscription ID"})
+	}
+
+	if err := h.service.DeleteDigestSubscription(c.Request().Context(), id); err != nil {
+		return serviceError(c, err, "Failed to delete digest subscription")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// DigestRequest represents the query parameters for previewing a
+// repository's weekly digest
+type DigestRequest struct {
+	Week string `query:"week" validate:"omitempty,datetime=2006-01-02"`
+}
+
+// FetchDigest godoc
+// @Summary Preview a repository's weekly digest
+// @Description Get the commit count, top committers and largest changes of a repository over a week, Monday to Sunday in UTC, as emailed to its digest subscribers
+// @Tags digests
+// @Produce json
+// @Param owner path string true "Repository owner"
+// @Param name path string true "Repository name"
+// @Param If-None-Match header string false "ETag of a previous response"
+// @Param week query string false "Any day of the week (YYYY-MM-DD), the last full week by default"
+// @Success 200 {object} models.RepoDigest
+// @Header 200 {string} ETag "Weak ETag of the response body"
+// @Success 304 "Unchanged since the If-None-Match ETag"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /repos/{owner}/{name}/digest [get]
+func (h *DigestHandler) FetchDigest(c echo.Context) error {
+	var req DigestRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request parameters"})
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	var week time.Time
+	if req.Week != "" {
+		week, _ = time.Parse(time.DateOnly, req.Week)
+	}
+	repo := fmt.Sprintf("%s/%s", c.Param("owner"), c.Param("name"))
+	digest, err := h.service.GetWeeklyDigest(c.Request().Context(), repo, week)
+	if err != nil {
+		return serviceError(c, err, "Failed to fetch digest")
+	}
+
+	return cachedJSON(c, digest)
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/noelukwa/indexer/internal/manager"
+)
+
+// errorStatus returns the status answering a failed service call: the
+// one of the error's kind, or 500 for errors of no kind.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, manager.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, manager.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, manager.ErrInvalid):
+		return http.StatusBadRequest
+	case errors.Is(err, manager.ErrUnavailable):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// serviceError answers a failed service call with the status of the
+// error's kind and its message. Errors of no kind may carry internals, so
+// they are logged and answered with message instead.
+func serviceError(c echo.Context, err error, message string) error {
+	status := errorStatus(err)
+	if status == http.StatusInternalServerError {
+		log.Printf("%s: %v", message, err)
+		return c.JSON(status, ErrorResponse{Error: message})
+	}
+	return c.JSON(status, ErrorResponse{Error: err.Error()})
+}
@@ -1,23 +1,34 @@
 package handlers
 
 import (
+	"encoding/json"
 	"errors"
-	"log"
+	"fmt"
+	"io"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/noelukwa/indexer/internal/manager"
 	"github.com/noelukwa/indexer/internal/manager/models"
+	"github.com/noelukwa/indexer/internal/pkg/config"
+	"golang.org/x/net/websocket"
 )
 
-// Since is a custom type for handling date parsing
+// Since is a custom type for handling date parsing. An empty or null value
+// leaves it zero, which means the full history.
 type Since time.Time
 
 func (ct *Since) UnmarshalJSON(b []byte) error {
 	s := strings.Trim(string(b), "\"")
+	if s == "" || s == "null" {
+		*ct = Since{}
+		return nil
+	}
 	t, err := time.Parse("2006-01-02", s)
 	if err != nil {
 		return err
@@ -29,30 +40,54 @@ func (ct *Since) UnmarshalJSON(b []byte) error {
 type IntentHandler struct {
 	service   *manager.Service
 	validator *validator.Validate
+	paging    paging
 }
 
-func NewIntentHandler(service *manager.Service) *IntentHandler {
+func NewIntentHandler(service *manager.Service, cfg *config.ManagerConfig) *IntentHandler {
 	return &IntentHandler{
 		service:   service,
 		validator: validator.New(),
+		paging:    newPaging(cfg),
 	}
 }
 
-// AddIntentRequest represents the request body for creating an intent
+// AddIntentRequest represents the request body for creating an intent.
+// Until is inclusive: commits made on that day are indexed.
 type AddIntentRequest struct {
-	Repository string `json:"repository" validate:"required"`
-	Since      Since  `json:"since" validate:"required"`
+	Repository         string              `json:"repository" validate:"required"`
+	Since              Since               `json:"since"`
+	Until              Since               `json:"until"`
+	MaxConcurrentPages *int32              `json:"max_concurrent_pages" validate:"omitempty,min=1,max=20"`
+	RequestsPerMinute  *int32              `json:"requests_per_minute" validate:"omitempty,min=1"`
+	IndexAllBranches   bool                `json:"index_all_branches"`
+	PathFilters        []string            `json:"path_filters" validate:"omitempty,max=20"`
+	AuthorFilters      []string            `json:"author_filters" validate:"omitempty,max=20,dive,required"`
+	Branch             string              `json:"branch"`
+	ExcludedAuthors    []string            `json:"excluded_authors" validate:"omitempty,max=20,dive,required"`
+	MaxCommits         *int32              `json:"max_commits" validate:"omitempty,min=1"`
+	CredentialID       *uuid.UUID          `json:"credential_id"`
+	Retry              *models.RetryPolicy `json:"retry"`
+}
+
+// ExistingIntentResponse answers the creation of an intent for a
+// repository that already has an active one.
+type ExistingIntentResponse struct {
+	Error    string    `json:"error"`
+	IntentID uuid.UUID `json:"intent_id"`
 }
 
 // CreateIntent godoc
 // @Summary Create a new intent
-// @Description Create a new intent for a repository
+// @Description Create a new intent for a repository, or for every repository of an owner with owner/*. A repository that already has an active intent gets 409 Conflict with that intent's ID, unless upsert is set, which extends the existing intent's date range to cover since to until instead
 // @Tags intents
 // @Accept json
 // @Produce json
 // @Param request body AddIntentRequest true "Intent creation request"
+// @Param upsert query bool false "Extend the repository's active intent instead of failing"
+// @Success 200 {object} models.Intent "The extended existing intent"
 // @Success 201 {object} models.Intent
 // @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ExistingIntentResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /intents [post]
 func (h *IntentHandler) CreateIntent(c echo.Context) error {
@@ -61,35 +96,109 @@ func (h *IntentHandler) CreateIntent(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request body"})
 	}
 
+	var upsert bool
+	if err := echo.QueryParamsBinder(c).Bool("upsert", &upsert).BindError(); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid upsert parameter"})
+	}
+
 	if err := h.validator.Struct(request); err != nil {
 		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 	}
 
-	intent, err := h.service.CreateIntent(
-		c.Request().Context(),
-		request.Repository,
-		time.Time(request.Since),
-	)
+	ctx := c.Request().Context()
+	since, until := time.Time(request.Since), time.Time(request.Until)
+	opts := models.IntentOptions{
+		MaxConcurrentPages: request.MaxConcurrentPages,
+		RequestsPerMinute:  request.RequestsPerMinute,
+		IndexAllBranches:   request.IndexAllBranches,
+		PathFilters:        request.PathFilters,
+		AuthorFilters:      request.AuthorFilters,
+		Branch:             request.Branch,
+		ExcludedAuthors:    request.ExcludedAuthors,
+		MaxCommits:         request.MaxCommits,
+		CredentialID:       request.CredentialID,
+		Retry:              request.Retry,
+	}
+	var intent *models.Intent
+	var err error
+	created := true
+	if upsert {
+		intent, created, err = h.service.UpsertIntent(ctx, request.Repository, since, until, opts)
+	} else {
+		intent, err = h.service.CreateIntent(ctx, request.Repository, since, until, opts)
+	}
 	if err != nil {
-		if errors.Is(err, manager.ErrInvalidRepository) || errors.Is(err, manager.ErrExistingIntent) {
+		// The credential is part of the request, so a missing one makes it
+		// invalid rather than the intent not found.
+		if errors.Is(err, manager.ErrCredentialNotFound) {
 			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 		}
-		log.Printf("Error creating intent: %s", err.Error())
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to add intent"})
+		var existing *manager.ExistingIntentError
+		if errors.As(err, &existing) {
+			return c.JSON(http.StatusConflict, ExistingIntentResponse{Error: err.Error(), IntentID: existing.IntentID})
+		}
+		return serviceError(c, err, "Failed to add intent")
 	}
 
+	if !created {
+		return c.JSON(http.StatusOK, intent)
+	}
 	return c.JSON(http.StatusCreated, intent)
 }
 
+// ExportIntents godoc
+// @Summary Export intents
+// @Description Dump the definition of each repository's intent, its active one or else its latest, to back up the tracking configuration apart from the commit data. Credentials are left out.
+// @Tags intents
+// @Produce json
+// @Success 200 {object} models.IntentExport
+// @Failure 500 {object} ErrorResponse
+// @Router /intents/export [get]
+func (h *IntentHandler) ExportIntents(c echo.Context) error {
+	export, err := h.service.ExportIntents(c.Request().Context())
+	if err != nil {
+		return serviceError(c, err, "Failed to export intents")
+	}
+
+	return c.JSON(http.StatusOK, export)
+}
+
+// ImportIntents godoc
+// @Summary Import intents
+// @Description Recreate exported intents. Active ones are sent to the monitor and the rest created paused. Repositories that already have an intent, and invalid definitions, are skipped and reported.
+// @Tags intents
+// @Accept json
+// @Produce json
+// @Param request body models.IntentExport true "Intent export"
+// @Success 200 {object} models.IntentImport
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /intents/import [post]
+func (h *IntentHandler) ImportIntents(c echo.Context) error {
+	var export models.IntentExport
+	if err := c.Bind(&export); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request body"})
+	}
+
+	result, err := h.service.ImportIntents(c.Request().Context(), &export)
+	if err != nil {
+		return serviceError(c, err, "Failed to import intents")
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
 // UpdateIntentRequest represents the request body for updating an intent
 type UpdateIntentRequest struct {
-	IsActive bool  `json:"is_active"`
+	// IsActive pauses the intent when false and resumes it when true. Left
+	// out, the intent stays as it is.
+	IsActive *bool `json:"is_active,omitempty"`
 	Since    Since `json:"since"`
 }
 
 // UpdateIntent godoc
 // @Summary Update an existing intent
-// @Description Update the details of an existing intent
+// @Description Pause or resume an intent when is_active is set, and move its start date when since is set
 // @Tags intents
 // @Accept json
 // @Produce json
@@ -97,9 +206,14 @@ type UpdateIntentRequest struct {
 // @Param request body UpdateIntentRequest true "Intent update request"
 // @Success 200 {object} models.Intent
 // @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /intents/{id} [put]
 func (h *IntentHandler) UpdateIntent(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid intent ID"})
+	}
 
 	var request UpdateIntentRequest
 	if err := c.Bind(&request); err != nil {
@@ -110,12 +224,129 @@ func (h *IntentHandler) UpdateIntent(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 	}
 
-	return c.JSON(http.StatusOK, "Intent updated successfully")
+	ctx := c.Request().Context()
+	if _, err := h.service.GetIntent(ctx, id); err != nil {
+		return serviceError(c, err, "Failed to update intent")
+	}
+
+	if since := time.Time(request.Since); !since.IsZero() {
+		if err := h.service.ResetIntentStartDate(ctx, id, since); err != nil {
+			return serviceError(c, err, "Failed to update intent")
+		}
+	}
+
+	// Pausing a paused intent and resuming an active one leave it as it
+	// is, so requests racing each other settle on what they asked for.
+	if request.IsActive != nil {
+		if *request.IsActive {
+			_, err = h.service.ResumeIntent(ctx, id)
+		} else {
+			_, err = h.service.PauseIntent(ctx, id)
+		}
+		if err != nil {
+			return serviceError(c, err, "Failed to update intent")
+		}
+	}
+
+	intent, err := h.service.GetIntent(ctx, id)
+	if err != nil {
+		return serviceError(c, err, "Failed to update intent")
+	}
+	return c.JSON(http.StatusOK, intent)
+}
+
+// PauseIntent godoc
+// @Summary Pause an intent
+// @Description Stop indexing an intent and have discovery stop scheduling it. The intent records when it was paused. Pausing a paused intent leaves it unchanged
+// @Tags intents
+// @Produce json
+// @Param id path string true "Intent ID"
+// @Success 200 {object} models.Intent
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /intents/{id}/pause [post]
+func (h *IntentHandler) PauseIntent(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid intent ID"})
+	}
+
+	intent, err := h.service.PauseIntent(c.Request().Context(), id)
+	if err != nil {
+		return serviceError(c, err, "Failed to pause intent")
+	}
+
+	return c.JSON(http.StatusOK, intent)
+}
+
+// ResumeIntent godoc
+// @Summary Resume a paused intent
+// @Description Start indexing a paused intent again. Once a run of the intent has completed, indexing picks up from the newest commit indexed for its repository rather than the start date. Resuming an active intent leaves it unchanged
+// @Tags intents
+// @Produce json
+// @Param id path string true "Intent ID"
+// @Success 200 {object} models.Intent
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /intents/{id}/resume [post]
+func (h *IntentHandler) ResumeIntent(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid intent ID"})
+	}
+
+	intent, err := h.service.ResumeIntent(c.Request().Context(), id)
+	if err != nil {
+		return serviceError(c, err, "Failed to resume intent")
+	}
+
+	return c.JSON(http.StatusOK, intent)
+}
+
+// DeleteIntentRequest holds the query parameters of an intent deletion.
+type DeleteIntentRequest struct {
+	Purge bool `query:"purge"`
+}
+
+// DeleteIntent godoc
+// @Summary Delete an intent
+// @Description Soft-delete an intent and broadcast its cancellation. With purge, also delete its repository's indexed commits, which is refused while other intents index the repository.
+// @Tags intents
+// @Produce json
+// @Param id path string true "Intent ID"
+// @Param purge query bool false "Delete the repository's indexed commits"
+// @Success 200 {object} models.IntentDeletion
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /intents/{id} [delete]
+func (h *IntentHandler) DeleteIntent(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid intent ID"})
+	}
+
+	var req DeleteIntentRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request parameters"})
+	}
+
+	deletion, err := h.service.DeleteIntent(c.Request().Context(), id, req.Purge)
+	if err != nil {
+		return serviceError(c, err, "Failed to delete intent")
+	}
+
+	return c.JSON(http.StatusOK, deletion)
 }
 
 // FetchIntent godoc
 // @Summary Fetch a single intent
-// @Description Get details of a specific intent by ID
+// @Description Get details of a specific intent by ID, with how far its latest run has got
 // @Tags intents
 // @Accept json
 // @Produce json
@@ -126,17 +357,291 @@ func (h *IntentHandler) UpdateIntent(c echo.Context) error {
 // @Failure 500 {object} ErrorResponse
 // @Router /intents/{id} [get]
 func (h *IntentHandler) FetchIntent(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid intent ID"})
+	}
+
+	intent, err := h.service.GetIntent(c.Request().Context(), id)
+	if err != nil {
+		return serviceError(c, err, "Failed to fetch intent")
+	}
+
+	return c.JSON(http.StatusOK, intent)
+}
+
+// FetchIntentHistory godoc
+// @Summary Fetch an intent's status history
+// @Description Get the most recent status transitions of an intent, newest first
+// @Tags intents
+// @Produce json
+// @Param id path string true "Intent ID"
+// @Success 200 {array} models.IntentTransition
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /intents/{id}/history [get]
+func (h *IntentHandler) FetchIntentHistory(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid intent ID"})
+	}
+
+	history, err := h.service.GetIntentHistory(c.Request().Context(), id)
+	if err != nil {
+		return serviceError(c, err, "Failed to fetch intent history")
+	}
+
+	return c.JSON(http.StatusOK, history)
+}
+
+// BroadcastIntent godoc
+// @Summary Broadcast an intent now
+// @Description Publish an active intent straight to the monitor instead of waiting for discovery's next tick, and mark it broadcast until the monitor picks it up. An intent can be broadcast this way once per cooldown
+// @Tags intents
+// @Produce json
+// @Param id path string true "Intent ID"
+// @Success 202 {object} models.Intent
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /intents/{id}/broadcast [post]
+func (h *IntentHandler) BroadcastIntent(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid intent ID"})
+	}
 
-	return c.JSON(http.StatusOK, "Intent details")
+	intent, err := h.service.ForceBroadcast(c.Request().Context(), id)
+	if err != nil {
+		if errors.Is(err, manager.ErrBroadcastTooSoon) {
+			return c.JSON(http.StatusTooManyRequests, ErrorResponse{Error: err.Error()})
+		}
+		return serviceError(c, err, "Failed to broadcast intent")
+	}
+
+	return c.JSON(http.StatusAccepted, intent)
+}
+
+// RebroadcastIntent godoc
+// @Summary Rebroadcast an intent
+// @Description Publish an active intent to discovery again right away, for when its broadcast was lost, and mark it pending_broadcast until it is published. An intent can be forced out once per cooldown, by this endpoint or the broadcast one
+// @Tags intents
+// @Produce json
+// @Param id path string true "Intent ID"
+// @Success 202 {object} models.Intent
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /intents/{id}/rebroadcast [post]
+func (h *IntentHandler) RebroadcastIntent(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid intent ID"})
+	}
+
+	intent, err := h.service.RebroadcastIntent(c.Request().Context(), id)
+	if err != nil {
+		if errors.Is(err, manager.ErrBroadcastTooSoon) {
+			return c.JSON(http.StatusTooManyRequests, ErrorResponse{Error: err.Error()})
+		}
+		return serviceError(c, err, "Failed to rebroadcast intent")
+	}
+
+	return c.JSON(http.StatusAccepted, intent)
+}
+
+// ReindexIntent godoc
+// @Summary Reindex an intent's repository
+// @Description Refetch the whole history of an active intent's repository into a shadow table, and swap it in for the live commits once it passes verification. A reindex still building for the repository is aborted
+// @Tags intents
+// @Produce json
+// @Param id path string true "Intent ID"
+// @Success 202 {object} models.Reindex
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /intents/{id}/reindex [post]
+func (h *IntentHandler) ReindexIntent(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid intent ID"})
+	}
+
+	reindex, err := h.service.StartReindex(c.Request().Context(), id)
+	if err != nil {
+		return serviceError(c, err, "Failed to start reindex")
+	}
+
+	return c.JSON(http.StatusAccepted, reindex)
+}
+
+// FetchReindex godoc
+// @Summary Fetch an intent's latest reindex
+// @Description Get the most recent reindex of an intent's repository, with its status
+// @Tags intents
+// @Produce json
+// @Param id path string true "Intent ID"
+// @Success 200 {object} models.Reindex
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /intents/{id}/reindex [get]
+func (h *IntentHandler) FetchReindex(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid intent ID"})
+	}
+
+	reindex, err := h.service.GetLatestReindex(c.Request().Context(), id)
+	if err != nil {
+		return serviceError(c, err, "Failed to fetch reindex")
+	}
+
+	return c.JSON(http.StatusOK, reindex)
+}
+
+// keepAliveInterval is how often an idle event stream sends a comment so
+// proxies don't close it.
+const keepAliveInterval = 15 * time.Second
+
+// StreamIntentEvents godoc
+// @Summary Stream an intent's events
+// @Description Stream status changes, progress updates and errors of an intent as server-sent events, starting with its current status
+// @Tags intents
+// @Produce text/event-stream
+// @Param id path string true "Intent ID"
+// @Success 200 {object} models.IntentEvent
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /intents/{id}/events [get]
+func (h *IntentHandler) StreamIntentEvents(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid intent ID"})
+	}
+
+	ctx := c.Request().Context()
+	events, stop, err := h.service.WatchIntent(ctx, id)
+	if err != nil {
+		return serviceError(c, err, "Failed to watch intent")
+	}
+	defer stop()
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	// The stream outlives the server's write timeout.
+	_ = http.NewResponseController(res).SetWriteDeadline(time.Time{})
+	res.WriteHeader(http.StatusOK)
+	res.Flush()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(res, ": keep-alive\n\n"); err != nil {
+				return nil
+			}
+			res.Flush()
+		case event := <-events:
+			data, err := json.Marshal(event)
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(res, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+				return nil
+			}
+			res.Flush()
+		}
+	}
+}
+
+// StreamIntentStatuses godoc
+// @Summary Stream every intent's status changes
+// @Description Upgrade to a WebSocket that receives each intent status change from then on as a JSON models.IntentEvent, for dashboards following all intents. A key bound to a tenant only receives the changes of its tenant's intents. Browsers on other sites are refused.
+// @Tags intents
+// @Success 101 {object} models.IntentEvent
+// @Failure 403 "Cross-origin handshake"
+// @Router /ws/intents [get]
+func (h *IntentHandler) StreamIntentStatuses(c echo.Context) error {
+	ctx := c.Request().Context()
+	events, stop := h.service.WatchIntents(ctx)
+	defer stop()
+
+	server := websocket.Server{
+		Handshake: sameOrigin,
+		Handler: func(ws *websocket.Conn) {
+			defer ws.Close()
+			// The hijacked connection keeps the server's timeouts.
+			_ = ws.SetDeadline(time.Time{})
+
+			// Clients only send control frames and the close, which ends
+			// the read.
+			closed := make(chan struct{})
+			go func() {
+				_, _ = io.Copy(io.Discard, ws)
+				close(closed)
+			}()
+
+			keepAlive := time.NewTicker(keepAliveInterval)
+			defer keepAlive.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-closed:
+					return
+				case <-keepAlive.C:
+					ws.PayloadType = websocket.PingFrame
+					if _, err := ws.Write(nil); err != nil {
+						return
+					}
+				case event := <-events:
+					if err := websocket.JSON.Send(ws, event); err != nil {
+						return
+					}
+				}
+			}
+		},
+	}
+	server.ServeHTTP(c.Response(), c.Request())
+	return nil
+}
+
+// sameOrigin refuses WebSocket handshakes from other sites' pages, which
+// would otherwise ride on the browser's session cookie. Clients sending no
+// Origin, which browsers always send, are let through.
+func sameOrigin(config *websocket.Config, req *http.Request) error {
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		return nil
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Host != req.Host {
+		return fmt.Errorf("cross-origin WebSocket handshake from %s", origin)
+	}
+	return nil
 }
 
 // FetchIntentsRequest represents the query parameters for fetching intents
 type FetchIntentsRequest struct {
 	IsActive       *bool                `query:"is_active" validate:"omitempty"`
-	Status         *models.IntentStatus `query:"status" validate:"omitempty,oneof=pending active completed failed"`
+	Status         *models.IntentStatus `query:"status" validate:"omitempty,oneof=created pending_broadcast broadcast fetching ingesting completed failed paused"`
 	RepositoryName *string              `query:"repository_name" validate:"omitempty"`
-	Page           int                  `query:"page" validate:"required,min=1"`
-	PerPage        int                  `query:"per_page" validate:"required,min=1,max=100"`
+	ParentID       *string              `query:"parent_id" validate:"omitempty,uuid"`
+	PageQuery
 }
 
 // FetchIntents godoc
@@ -146,10 +651,11 @@ type FetchIntentsRequest struct {
 // @Accept json
 // @Produce json
 // @Param is_active query bool false "Filter by active status"
-// @Param status query string false "Filter by intent status" Enums(pending, active, completed, failed)
+// @Param status query string false "Filter by intent status" Enums(created, pending_broadcast, broadcast, fetching, ingesting, completed, failed, paused)
 // @Param repository_name query string false "Filter by repository name"
-// @Param page query int true "Page number" minimum(1)
-// @Param per_page query int true "Items per page" minimum(1) maximum(100)
+// @Param parent_id query string false "List the intents created by this org intent"
+// @Param page query int false "Page number, 1 by default" minimum(1)
+// @Param per_page query int false "Items per page, 20 by default and capped at 100 unless configured otherwise" minimum(1)
 // @Success 200 {object} PaginatedResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
@@ -169,18 +675,26 @@ func (h *IntentHandler) FetchIntents(c echo.Context) error {
 		Status:         request.Status,
 		RepositoryName: request.RepositoryName,
 	}
+	if request.ParentID != nil {
+		parentID, err := uuid.Parse(*request.ParentID)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid parent_id"})
+		}
+		filter.ParentID = &parentID
+	}
 
-	paginatedIntents, err := h.service.GetIntents(c.Request().Context(), filter, request.PerPage, request.Page)
+	page, perPage, capped := h.paging.resolve(request.PageQuery)
+	paginatedIntents, err := h.service.GetIntents(c.Request().Context(), filter, perPage, page)
 	if err != nil {
-		log.Printf("Error fetching intents: %v", err)
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch intents"})
+		return serviceError(c, err, "Failed to fetch intents")
 	}
 
 	response := PaginatedResponse{
-		Data:       paginatedIntents.Data,
-		TotalCount: paginatedIntents.TotalCount,
-		Page:       paginatedIntents.Page,
-		PerPage:    paginatedIntents.PerPage,
+		Data:          paginatedIntents.Data,
+		TotalCount:    paginatedIntents.TotalCount,
+		Page:          paginatedIntents.Page,
+		PerPage:       paginatedIntents.PerPage,
+		PerPageCapped: capped,
 	}
 
 	return c.JSON(http.StatusOK, response)
@@ -192,4 +706,10 @@ type PaginatedResponse struct {
 	TotalCount int64       `json:"total_count"`
 	Page       int         `json:"page"`
 	PerPage    int         `json:"per_page"`
+	// PerPageCapped is set when the requested per_page was over the
+	// maximum.
+	PerPageCapped bool `json:"per_page_capped,omitempty"`
+	// Archived is set on listings of commits when some in their range
+	// have been archived, and so are left out.
+	Archived *models.ArchiveNotice `json:"archived,omitempty"`
 }
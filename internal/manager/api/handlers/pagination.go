@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"github.com/noelukwa/indexer/internal/pkg/config"
+)
+
+// PageQuery represents the optional pagination parameters of a listing
+type PageQuery struct {
+	Page    int `query:"page" validate:"omitempty,min=1"`
+	PerPage int `query:"per_page" validate:"omitempty,min=1"`
+}
+
+// paging fills in and caps the page sizes of listings.
+type paging struct {
+	defaultPerPage int
+	maxPerPage     int
+}
+
+func newPaging(cfg *config.ManagerConfig) paging {
+	p := paging{defaultPerPage: cfg.DefaultPerPage, maxPerPage: cfg.MaxPerPage}
+	if p.maxPerPage < 1 {
+		p.maxPerPage = 100
+	}
+	if p.defaultPerPage < 1 || p.defaultPerPage > p.maxPerPage {
+		p.defaultPerPage = min(20, p.maxPerPage)
+	}
+	return p
+}
+
+// resolve returns the page and page size to serve q with, the first page
+// and the default size when they are left out. capped is set when the
+// requested size was over the maximum.
+func (p paging) resolve(q PageQuery) (page, perPage int, capped bool) {
+	page = max(q.Page, 1)
+	switch {
+	case q.PerPage == 0:
+		perPage = p.defaultPerPage
+	case q.PerPage > p.maxPerPage:
+		perPage, capped = p.maxPerPage, true
+	default:
+		perPage = q.PerPage
+	}
+	return page, perPage, capped
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// QualityRequest represents the query parameters for fetching a
+// repository's data quality report
+type QualityRequest struct {
+	MinGapDays int `query:"min_gap_days" validate:"omitempty,min=1,max=3650"`
+}
+
+// FetchQuality godoc
+// @Summary Fetch a repository's data quality report
+// @Description Report the indexed commits that have no GitHub account behind their author, the share made by bots, those whose hash is also indexed under another repository, the runs of days without commits in the date range the repository's intents ask for, and when a reindex last replaced the commits.
+// @Tags repos
+// @Accept json
+// @Produce json
+// @Param owner path string true "Repository owner"
+// @Param name path string true "Repository name"
+// @Param If-None-Match header string false "ETag of a previous response"
+// @Param min_gap_days query int false "Days without commits that make a gap (default 30)"
+// @Success 200 {object} models.QualityReport
+// @Header 200 {string} ETag "Weak ETag of the response body"
+// @Success 304 "Unchanged since the If-None-Match ETag"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /repos/{owner}/{name}/quality [get]
+func (h *RemoteHandler) FetchQuality(c echo.Context) error {
+	var req QualityRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request parameters"})
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	repo := fmt.Sprintf("%s/%s", c.Param("owner"), c.Param("name"))
+	report, err := h.service.GetQualityReport(c.Request().Context(), repo, req.MinGapDays)
+	if err != nil {
+		return serviceError(c, err, "Failed to fetch quality report")
+	}
+
+	return cachedJSON(c, report)
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// TopReviewersRequest represents the query parameters for ranking a
+// repository's reviewers
+type TopReviewersRequest struct {
+	Since string `query:"since" validate:"omitempty,datetime=2006-01-02"`
+	Until string `query:"until" validate:"omitempty,datetime=2006-01-02"`
+	PageQuery
+}
+
+// FetchReviewTurnaround godoc
+// @Summary Fetch a repository's review turnaround
+// @Description Get the median, 90th percentile and mean wait, in seconds, of the pull requests opened in the range for their first review by someone other than their author. Reviews are only indexed when the monitor fetches them.
+// @Tags repos
+// @Accept json
+// @Produce json
+// @Param owner path string true "Repository owner"
+// @Param name path string true "Repository name"
+// @Param If-None-Match header string false "ETag of a previous response"
+// @Param since query string false "Start date (YYYY-MM-DD)"
+// @Param until query string false "End date (YYYY-MM-DD)"
+// @Success 200 {object} models.ReviewTurnaround
+// @Header 200 {string} ETag "Weak ETag of the response body"
+// @Success 304 "Unchanged since the If-None-Match ETag"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /repos/{owner}/{name}/reviews/turnaround [get]
+func (h *RemoteHandler) FetchReviewTurnaround(c echo.Context) error {
+	var req StatsRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request parameters"})
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	since, until := parseDateRange(req.Since, req.Until)
+	repo := fmt.Sprintf("%s/%s", c.Param("owner"), c.Param("name"))
+	turnaround, err := h.service.GetReviewTurnaround(c.Request().Context(), repo, since, until)
+	if err != nil {
+		return serviceError(c, err, "Failed to fetch review turnaround")
+	}
+
+	return cachedJSON(c, turnaround)
+}
+
+// FetchTopReviewers godoc
+// @Summary Fetch the top reviewers of a repository
+// @Description Get a paginated ranking of a repository's reviewers by the reviews they submitted in the range, with their approvals, requested changes and reviewed pull requests. Reviews of their own pull requests don't count.
+// @Tags repos
+// @Accept json
+// @Produce json
+// @Param owner path string true "Repository owner"
+// @Param name path string true "Repository name"
+// @Param If-None-Match header string false "ETag of a previous response"
+// @Param since query string false "Start date (YYYY-MM-DD)"
+// @Param until query string false "End date (YYYY-MM-DD)"
+// @Param page query int false "Page number, 1 by default" minimum(1)
+// @Param per_page query int false "Items per page, 20 by default and capped at 100 unless configured otherwise" minimum(1)
+// @Success 200 {object} PaginatedResponse
+// @Header 200 {string} ETag "Weak ETag of the response body"
+// @Success 304 "Unchanged since the If-None-Match ETag"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /repos/{owner}/{name}/reviews/reviewers [get]
+func (h *RemoteHandler) FetchTopReviewers(c echo.Context) error {
+	var req TopReviewersRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request parameters"})
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	since, until := parseDateRange(req.Since, req.Until)
+	repo := fmt.Sprintf("%s/%s", c.Param("owner"), c.Param("name"))
+	page, perPage, capped := h.paging.resolve(req.PageQuery)
+	reviewers, err := h.service.GetTopReviewers(c.Request().Context(), repo, since, until, page, perPage)
+	if err != nil {
+		return serviceError(c, err, "Failed to fetch top reviewers")
+	}
+
+	return cachedJSON(c, PaginatedResponse{
+		Data:          reviewers.Data,
+		TotalCount:    reviewers.TotalCount,
+		Page:          reviewers.Page,
+		PerPage:       reviewers.PerPage,
+		PerPageCapped: capped,
+	})
+}
+
+// parseDateRange parses validated YYYY-MM-DD dates, leaving empty ones zero.
+func parseDateRange(since, until string) (time.Time, time.Time) {
+	var start, end time.Time
+	if since != "" {
+		start, _ = time.Parse(time.DateOnly, since)
+	}
+	if until != "" {
+		end, _ = time.Parse(time.DateOnly, until)
+	}
+	return start, end
+}
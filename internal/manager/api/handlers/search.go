@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/noelukwa/indexer/internal/manager"
+	"github.com/noelukwa/indexer/internal/manager/models"
+	"github.com/noelukwa/indexer/internal/pkg/config"
+)
+
+// SearchHandler handles HTTP requests for searching the index
+type SearchHandler struct {
+	service   *manager.Service
+	validator *validator.Validate
+	paging    paging
+}
+
+// NewSearchHandler creates a new SearchHandler instance
+func NewSearchHandler(service *manager.Service, cfg *config.ManagerConfig) *SearchHandler {
+	return &SearchHandler{
+		service:   service,
+		validator: validator.New(),
+		paging:    newPaging(cfg),
+	}
+}
+
+// SearchRequest represents the query parameters for a search
+type SearchRequest struct {
+	Query string `query:"q" validate:"required"`
+	PageQuery
+}
+
+// Search godoc
+// @Summary Search the index
+// @Description Search repository names, author names and usernames, and commit messages for a substring. Matches are grouped by type and each group is paginated on its own
+// @Tags search
+// @Produce json
+// @Param q query string true "Text to search for, at least 2 characters"
+// @Param page query int false "Page number, 1 by default" minimum(1)
+// @Param per_page query int false "Items per page and group, 20 by default and capped at 100 unless configured otherwise" minimum(1)
+// @Success 200 {object} models.SearchResults
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /search [get]
+func (h *SearchHandler) Search(c echo.Context) error {
+	var req SearchRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request parameters"})
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	page, perPage, capped := h.paging.resolve(req.PageQuery)
+	results, err := h.service.Search(c.Request().Context(), req.Query, page, perPage)
+	if err != nil {
+		return serviceError(c, err, "Failed to search")
+	}
+	results.PerPageCapped = capped
+
+	return c.JSON(http.StatusOK, results)
+}
+
+// SearchCommitsRequest represents the query parameters for a full-text
+// search of commit messages
+type SearchCommitsRequest struct {
+	Query string `query:"q" validate:"required"`
+	Repo  string `query:"repo"`
+	Since string `query:"since" validate:"omitempty,datetime=2006-01-02"`
+	Until string `query:"until" validate:"omitempty,datetime=2006-01-02"`
+	PageQuery
+}
+
+// SearchCommitsResponse represents a page of ranked commit matches
+type SearchCommitsResponse struct {
+	Query      string               `json:"query"`
+	Data       []models.CommitMatch `json:"data"`
+	TotalCount int64                `json:"total_count"`
+	Page       int                  `json:"page"`
+	PerPage    int                  `json:"per_page"`
+	// PerPageCapped is set when the requested per_page was over the
+	// maximum.
+	PerPageCapped bool `json:"per_page_capped,omitempty"`
+}
+
+// SearchCommits godoc
+// @Summary Search commit messages
+// @Description Full-text search of commit messages, optionally in one repository and date range. Words are matched by their stem, so "fixing" finds "fixed", and the query accepts "quoted phrases", or and -excluded words. Matches are ranked best first. Until includes the commits made on that day
+// @Tags search
+// @Produce json
+// @Param q query string true "Words to search for, at least 2 characters"
+// @Param repo query string false "Repository name in the format 'owner/repo'"
+// @Param since query string false "Start date (YYYY-MM-DD)"
+// @Param until query string false "End date (YYYY-MM-DD)"
+// @Param page query int false "Page number, 1 by default" minimum(1)
+// @Param per_page query int false "Items per page, 20 by default and capped at 100 unless configured otherwise" minimum(1)
+// @Success 200 {object} SearchCommitsResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /search/commits [get]
+func (h *SearchHandler) SearchCommits(c echo.Context) error {
+	var req SearchCommitsRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request parameters"})
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	var since, until time.Time
+	if req.Since != "" {
+		since, _ = time.Parse(time.DateOnly, req.Since)
+	}
+	if req.Until != "" {
+		until, _ = time.Parse(time.DateOnly, req.Until)
+	}
+
+	page, perPage, capped := h.paging.resolve(req.PageQuery)
+	matches, err := h.service.SearchCommits(c.Request().Context(), req.Query, req.Repo, since, until, page, perPage)
+	if err != nil {
+		return serviceError(c, err, "Failed to search commits")
+	}
+
+	return c.JSON(http.StatusOK, SearchCommitsResponse{
+		Query:         req.Query,
+		Data:          matches.Data,
+		TotalCount:    matches.TotalCount,
+		Page:          matches.Page,
+		PerPage:       matches.PerPage,
+		PerPageCapped: capped,
+	})
+}
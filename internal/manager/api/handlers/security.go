@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"github.com/labstack/echo/v4"
+)
+
+// FetchSecurityAlerts godoc
+// @Summary Summarize open security alerts
+// @Description Count the open Dependabot alerts of the indexed repositories by severity, overall and for each repository that has any, the most severe first. Alerts are only indexed when the monitor fetches them, for repositories whose token can read them.
+// @Tags repos
+// @Accept json
+// @Produce json
+// @Param If-None-Match header string false "ETag of a previous response"
+// @Success 200 {object} models.SecurityAlertSummary
+// @Header 200 {string} ETag "Weak ETag of the response body"
+// @Success 304 "Unchanged since the If-None-Match ETag"
+// @Failure 500 {object} ErrorResponse
+// @Router /security/alerts [get]
+func (h *RemoteHandler) FetchSecurityAlerts(c echo.Context) error {
+	summary, err := h.service.GetOpenAlertsSummary(c.Request().Context())
+	if err != nil {
+		return serviceError(c, err, "Failed to fetch security alerts")
+	}
+
+	return cachedJSON(c, summary)
+}
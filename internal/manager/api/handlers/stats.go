@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/noelukwa/indexer/internal/manager"
+	"github.com/noelukwa/indexer/internal/manager/models"
+	"github.com/noelukwa/indexer/internal/pkg/config"
+)
+
+// StatsHandler handles HTTP requests for statistics across repositories
+type StatsHandler struct {
+	service   *manager.Service
+	validator *validator.Validate
+	paging    paging
+}
+
+// NewStatsHandler creates a new StatsHandler instance
+func NewStatsHandler(service *manager.Service, cfg *config.ManagerConfig) *StatsHandler {
+	return &StatsHandler{
+		service:   service,
+		validator: validator.New(),
+		paging:    newPaging(cfg),
+	}
+}
+
+// FetchLanguageStats godoc
+// @Summary Fetch statistics per language
+// @Description Get the number of indexed repositories and their indexed commits per repository language, most commits first. Repositories GitHub detected no language for are grouped under an empty language. Commits that have since been archived are still counted. Results may be cached for up to the configured stats cache TTL, a minute by default.
+// @Tags stats
+// @Produce json
+// @Param If-None-Match header string false "ETag of a previous response"
+// @Success 200 {array} models.LanguageStats
+// @Header 200 {string} ETag "Weak ETag of the response body"
+// @Success 304 "Unchanged since the If-None-Match ETag"
+// @Failure 500 {object} ErrorResponse
+// @Router /stats/languages [get]
+func (h *StatsHandler) FetchLanguageStats(c echo.Context) error {
+	stats, err := h.service.GetLanguageStats(c.Request().Context())
+	if err != nil {
+		return serviceError(c, err, "Failed to get language stats")
+	}
+
+	return cachedJSON(c, stats)
+}
+
+// ActiveReposRequest represents the query parameters for ranking the most
+// active repositories
+type ActiveReposRequest struct {
+	Since    string `query:"since" validate:"omitempty,datetime=2006-01-02"`
+	Until    string `query:"until" validate:"omitempty,datetime=2006-01-02"`
+	Language string `query:"language"`
+	PageQuery
+}
+
+// ActiveReposResponse represents the response for the most active
+// repositories
+type ActiveReposResponse struct {
+	Data       []models.RepoActivity `json:"data"`
+	TotalCount int64                 `json:"total_count"`
+	Page       int                   `json:"page"`
+	PerPage    int                   `json:"per_page"`
+	// PerPageCapped is set when the requested per_page was over the
+	// maximum.
+	PerPageCapped bool `json:"per_page_capped,omitempty"`
+}
+
+// FetchActiveRepos godoc
+// @Summary Fetch the most active repositories
+// @Description Get a paginated ranking of the repositories by their commits in a window of days, with the lines those commits changed and how many authors made them. The window is the last 30 days by default and both its ends are included. Counts come from the daily rollup, so may trail the newest commits by one rollup run, and may be cached for up to the configured stats cache TTL, a minute by default. Repositories without commits in the window are left out.
+// @Tags stats
+// @Produce json
+// @Param since query string false "First day of the window (YYYY-MM-DD), 29 days before until by default"
+// @Param until query string false "Last day of the window (YYYY-MM-DD), today by default"
+// @Param language query string false "Only rank repositories in this language, in any case"
+// @Param page query int false "Page number, 1 by default" minimum(1)
+// @Param per_page query int false "Items per page, 20 by default and capped at 100 unless configured otherwise" minimum(1)
+// @Param If-None-Match header string false "ETag of a previous response"
+// @Success 200 {object} ActiveReposResponse
+// @Header 200 {string} ETag "Weak ETag of the response body"
+// @Success 304 "Unchanged since the If-None-Match ETag"
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /stats/repos [get]
+func (h *StatsHandler) FetchActiveRepos(c echo.Context) error {
+	var req ActiveReposRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request parameters"})
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	var since, until time.Time
+	if req.Since != "" {
+		since, _ = time.Parse(time.DateOnly, req.Since)
+	}
+	if req.Until != "" {
+		until, _ = time.Parse(time.DateOnly, req.Until)
+	}
+
+	page, perPage, capped := h.paging.resolve(req.PageQuery)
+	repos, err := h.service.GetActiveRepos(c.Request().Context(), since, until, req.Language, page, perPage)
+	if err != nil {
+		return serviceError(c, err, "Failed to get active repositories")
+	}
+
+	response := ActiveReposResponse{
+		Data:          repos.Data,
+		TotalCount:    repos.TotalCount,
+		Page:          repos.Page,
+		PerPage:       repos.PerPage,
+		PerPageCapped: capped,
+	}
+
+	return cachedJSON(c, response)
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/google/go-github/v63/github"
+	"github.com/labstack/echo/v4"
+	"github.com/noelukwa/indexer/internal/manager"
+	"github.com/noelukwa/indexer/internal/manager/models"
+	"github.com/noelukwa/indexer/internal/pkg/config"
+)
+
+// WebhookHandler handles the webhook deliveries of the indexer's GitHub App
+type WebhookHandler struct {
+	service *manager.Service
+	secret  []byte
+}
+
+// NewWebhookHandler creates a new WebhookHandler instance
+func NewWebhookHandler(service *manager.Service, cfg *config.ManagerConfig) *WebhookHandler {
+	return &WebhookHandler{
+		service: service,
+		secret:  []byte(cfg.GitHubWebhookSecret),
+	}
+}
+
+// ReceiveGitHubEvent godoc
+// @Summary Receive a GitHub App webhook delivery
+// @Description Create intents for the repositories the indexer's GitHub App is installed on, and pause the intents of those it is removed from. Deliveries must be signed with the webhook secret. Events other than installation and installation_repositories are acknowledged and ignored
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param X-GitHub-Event header string true "Event type"
+// @Param X-Hub-Signature-256 header string true "HMAC SHA-256 signature of the body"
+// @Success 200 {object} models.InstallationChange
+// @Success 204 "Event ignored"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /webhooks/github [post]
+func (h *WebhookHandler) ReceiveGitHubEvent(c echo.Context) error {
+	payload, err := github.ValidatePayload(c.Request(), h.secret)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Invalid webhook signature"})
+	}
+
+	eventType := github.WebHookType(c.Request())
+	if eventType != "installation" && eventType != "installation_repositories" {
+		return c.NoContent(http.StatusNoContent)
+	}
+	event, err := github.ParseWebHook(eventType, payload)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid webhook payload"})
+	}
+
+	ctx := c.Request().Context()
+	var change *models.InstallationChange
+	switch event := event.(type) {
+	case *github.InstallationEvent:
+		switch event.GetAction() {
+		case "created":
+			change, err = h.service.InstallRepositories(ctx, repositoryNames(event.Repositories))
+		case "deleted":
+			change, err = h.service.UninstallRepositories(ctx, repositoryNames(event.Repositories))
+		}
+	case *github.InstallationRepositoriesEvent:
+		switch event.GetAction() {
+		case "added":
+			change, err = h.service.InstallRepositories(ctx, repositoryNames(event.RepositoriesAdded))
+		case "removed":
+			change, err = h.service.UninstallRepositories(ctx, repositoryNames(event.RepositoriesRemoved))
+		}
+	}
+	if err != nil {
+		return serviceError(c, err, "Failed to handle installation event")
+	}
+	if change == nil {
+		return c.NoContent(http.StatusNoContent)
+	}
+
+	return c.JSON(http.StatusOK, change)
+}
+
+func repositoryNames(repos []*github.Repository) []string {
+	names := make([]string, 0, len(repos))
+	for _, repo := range repos {
+		names = append(names, repo.GetFullName())
+	}
+	return names
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// CIStatsRequest represents the query parameters for fetching a
+// repository's CI stats
+type CIStatsRequest struct {
+	Since    string `query:"since" validate:"omitempty,datetime=2006-01-02"`
+	Until    string `query:"until" validate:"omitempty,datetime=2006-01-02"`
+	Workflow string `query:"workflow"`
+}
+
+// FetchCIStats godoc
+// @Summary Fetch a repository's CI stats
+// @Description Get the pass rate and median and 90th percentile duration, in seconds, of the GitHub Actions runs started in the range. Cancelled and skipped runs are left out. Runs are only indexed when the monitor fetches them.
+// @Tags repos
+// @Accept json
+// @Produce json
+// @Param owner path string true "Repository owner"
+// @Param name path string true "Repository name"
+// @Param If-None-Match header string false "ETag of a previous response"
+// @Param since query string false "Start date (YYYY-MM-DD)"
+// @Param until query string false "End date (YYYY-MM-DD)"
+// @Param workflow query string false "Workflow name"
+// @Success 200 {object} models.CIStats
+// @Header 200 {string} ETag "Weak ETag of the response body"
+// @Success 304 "Unchanged since the If-None-Match ETag"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /repos/{owner}/{name}/ci/stats [get]
+func (h *RemoteHandler) FetchCIStats(c echo.Context) error {
+	var req CIStatsRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request parameters"})
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	since, until := parseDateRange(req.Since, req.Until)
+	repo := fmt.Sprintf("%s/%s", c.Param("owner"), c.Param("name"))
+	stats, err := h.service.GetCIStats(c.Request().Context(), repo, since, until, req.Workflow)
+	if err != nil {
+		return serviceError(c, err, "Failed to fetch CI stats")
+	}
+
+	return cachedJSON(c, stats)
+}
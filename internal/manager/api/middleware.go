@@ -0,0 +1,104 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/noelukwa/indexer/internal/manager"
+	"github.com/noelukwa/indexer/internal/manager/api/handlers"
+	"github.com/noelukwa/indexer/internal/manager/models"
+	"github.com/noelukwa/indexer/internal/manager/repository"
+	"github.com/noelukwa/indexer/internal/pkg/config"
+)
+
+const sessionContextKey = "session"
+
+// rateLimit refuses requests over their client's rate limit with 429 Too
+// Many Requests and a Retry-After header. Requests are let through when
+// the limiter fails, so an outage of Redis doesn't take the API down.
+func rateLimit(managerService *manager.Service) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			// Scrapers and GitHub's deliveries are not clients to limit.
+			if path := c.Path(); path == "/metrics" || path == "/webhooks/github" {
+				return next(c)
+			}
+
+			result, err := managerService.AllowRequest(c.Request().Context(), c.Request().Header.Get(handlers.APIKeyHeader), c.RealIP())
+			if err != nil {
+				log.Printf("Error rate limiting request: %v", err)
+				return next(c)
+			}
+			c.Response().Header().Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			if !result.Allowed {
+				seconds := int(math.Ceil(result.RetryAfter.Seconds()))
+				c.Response().Header().Set("Retry-After", strconv.Itoa(max(seconds, 1)))
+				return c.JSON(http.StatusTooManyRequests, handlers.ErrorResponse{Error: "Rate limit exceeded"})
+			}
+			return next(c)
+		}
+	}
+}
+
+// authenticate rejects requests that carry neither a valid API key in the
+// X-API-Key header nor, when GitHub login is configured, a valid session
+// token. Requests with a key bound to a tenant are scoped to it.
+func authenticate(managerService *manager.Service, cfg *config.ManagerConfig) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ctx := c.Request().Context()
+			var session *models.Session
+			var err error
+			if key := c.Request().Header.Get(handlers.APIKeyHeader); key != "" || !cfg.OAuthEnabled() {
+				session, err = managerService.ValidateAPIKey(ctx, key)
+			} else {
+				session, err = managerService.ValidateSession(ctx, handlers.SessionToken(c))
+			}
+			if err != nil {
+				if errors.Is(err, manager.ErrInvalidAPIKey) || errors.Is(err, manager.ErrInvalidSession) {
+					return c.JSON(http.StatusUnauthorized, handlers.ErrorResponse{Error: err.Error()})
+				}
+				log.Printf("Error authenticating request: %v", err)
+				return c.JSON(http.StatusInternalServerError, handlers.ErrorResponse{Error: "Failed to authenticate request"})
+			}
+
+			c.Set(sessionContextKey, session)
+			if session.Tenant != "" {
+				c.SetRequest(c.Request().WithContext(repository.WithTenant(ctx, session.Tenant)))
+			}
+			return next(c)
+		}
+	}
+}
+
+// requireRole rejects requests whose session does not grant the given role.
+// It must run after authenticate.
+func requireRole(role models.Role) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			session, ok := c.Get(sessionContextKey).(*models.Session)
+			if !ok || !session.Role.Allows(role) {
+				return c.JSON(http.StatusForbidden, handlers.ErrorResponse{Error: "Insufficient permissions"})
+			}
+			return next(c)
+		}
+	}
+}
+
+// requireOperator rejects requests whose session is bound to a tenant, for
+// the endpoints that span every tenant. Requests without a session, when
+// auth is disabled, are let through.
+func requireOperator() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if session, ok := c.Get(sessionContextKey).(*models.Session); ok && session.Tenant != "" {
+				return c.JSON(http.StatusForbidden, handlers.ErrorResponse{Error: "Not available to tenant API keys"})
+			}
+			return next(c)
+		}
+	}
+}
@@ -1,13 +1,19 @@
 package api
 
 import (
+	"net/http"
+	"slices"
+
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/noelukwa/indexer/internal/manager"
 	"github.com/noelukwa/indexer/internal/manager/api/handlers"
+	"github.com/noelukwa/indexer/internal/manager/api/ui"
+	"github.com/noelukwa/indexer/internal/manager/models"
+	"github.com/noelukwa/indexer/internal/pkg/config"
 )
 
-func SetupRoutes(managerService *manager.Service, e *echo.Echo) *echo.Echo {
+func SetupRoutes(managerService *manager.Service, cfg *config.ManagerConfig, e *echo.Echo) *echo.Echo {
 
 	e.Use(middleware.Logger())
 	e.Use(middleware.Recover())
@@ -19,16 +25,128 @@ func SetupRoutes(managerService *manager.Service, e *echo.Echo) *echo.Echo {
 		HSTSMaxAge:            31536000,
 		HSTSExcludeSubdomains: true,
 	}))
+	if cfg.APIRateLimitEnabled() {
+		e.Use(rateLimit(managerService))
+	}
+
+	if cfg.OAuthEnabled() {
+		authHandler := handlers.NewAuthHandler(managerService, cfg)
+		e.GET("/auth/github/login", authHandler.Login)
+		e.GET("/auth/github/callback", authHandler.Callback)
+		e.POST("/auth/logout", authHandler.Logout)
+	}
+
+	var readers, writers []echo.MiddlewareFunc
+	if cfg.AuthEnabled() {
+		readers = []echo.MiddlewareFunc{authenticate(managerService, cfg)}
+		writers = []echo.MiddlewareFunc{authenticate(managerService, cfg), requireRole(models.AdminRole)}
+	}
+	// Keys bound to a tenant only reach that tenant's records, so the
+	// endpoints spanning every tenant are closed to them.
+	operatorReaders := append(slices.Clip(readers), requireOperator())
+	operatorWriters := append(slices.Clip(writers), requireOperator())
+
+	if cfg.AuthEnabled() {
+		apiKeyHandler := handlers.NewAPIKeyHandler(managerService)
+		e.POST("/api-keys", apiKeyHandler.CreateAPIKey, operatorWriters...)
+		e.GET("/api-keys", apiKeyHandler.FetchAPIKeys, operatorWriters...)
+		e.DELETE("/api-keys/:id", apiKeyHandler.RevokeAPIKey, operatorWriters...)
+	}
+
+	intentHandler := handlers.NewIntentHandler(managerService, cfg)
+
+	e.POST("/intents", intentHandler.CreateIntent, writers...)
+	e.GET("/intents/export", intentHandler.ExportIntents, writers...)
+	e.POST("/intents/import", intentHandler.ImportIntents, writers...)
+	e.PUT("/intents/:id", intentHandler.UpdateIntent, writers...)
+	e.DELETE("/intents/:id", intentHandler.DeleteIntent, writers...)
+	e.GET("/intents/:id", intentHandler.FetchIntent, readers...)
+	e.GET("/intents/:id/history", intentHandler.FetchIntentHistory, readers...)
+	e.GET("/intents/:id/events", intentHandler.StreamIntentEvents, readers...)
+	e.POST("/intents/:id/pause", intentHandler.PauseIntent, writers...)
+	e.POST("/intents/:id/resume", intentHandler.ResumeIntent, writers...)
+	e.POST("/intents/:id/broadcast", intentHandler.BroadcastIntent, writers...)
+	e.POST("/intents/:id/rebroadcast", intentHandler.RebroadcastIntent, writers...)
+	e.POST("/intents/:id/reindex", intentHandler.ReindexIntent, writers...)
+	e.GET("/intents/:id/reindex", intentHandler.FetchReindex, readers...)
+	e.GET("/intents", intentHandler.FetchIntents, readers...)
+	e.GET("/ws/intents", intentHandler.StreamIntentStatuses, readers...)
+
+	remoteRepoHandler := handlers.NewRemoteRepositoryHandler(managerService, cfg)
+	digestHandler := handlers.NewDigestHandler(managerService)
+	e.GET("/repos", remoteRepoHandler.FetchRepos, readers...)
+	e.GET("/repos/:owner/:name", remoteRepoHandler.FetchRepoInfo, readers...)
+	e.DELETE("/repos/:owner/:name", remoteRepoHandler.DeleteRepo, writers...)
+	e.GET("/repos/:name/committers", remoteRepoHandler.FetchTopCommitters, readers...)
+	e.GET("/repos/:owner/:name/churn", remoteRepoHandler.FetchChurn, readers...)
+	e.GET("/repos/:owner/:name/stats", remoteRepoHandler.FetchStats, readers...)
+	e.GET("/repos/:owner/:name/stats/daily", remoteRepoHandler.FetchDailyStats, readers...)
+	e.GET("/repos/:owner/:name/stats/contributions", remoteRepoHandler.FetchContributions, readers...)
+	e.GET("/repos/:owner/:name/stats/activity", remoteRepoHandler.FetchActivity, readers...)
+	e.GET("/repos/:owner/:name/commits", remoteRepoHandler.FetchCommits, readers...)
+	e.GET("/repos/:owner/:name/commits/delta", remoteRepoHandler.FetchCommitDelta, readers...)
+	e.GET("/repos/:owner/:name/commits/:sha/comments", remoteRepoHandler.FetchCommitComments, readers...)
+	e.GET("/repos/:owner/:name/archives", remoteRepoHandler.FetchArchive, readers...)
+	e.GET("/repos/:owner/:name/reviews/turnaround", remoteRepoHandler.FetchReviewTurnaround, readers...)
+	e.GET("/repos/:owner/:name/reviews/reviewers", remoteRepoHandler.FetchTopReviewers, readers...)
+	e.GET("/repos/:owner/:name/ci/stats", remoteRepoHandler.FetchCIStats, readers...)
+	e.GET("/repos/:owner/:name/stats/github", remoteRepoHandler.FetchGitHubStats, readers...)
+	e.GET("/repos/:owner/:name/quality", remoteRepoHandler.FetchQuality, readers...)
+	e.GET("/repos/:owner/:name/digest", digestHandler.FetchDigest, readers...)
+	e.GET("/security/alerts", remoteRepoHandler.FetchSecurityAlerts, readers...)
 
-	intentHandler := handlers.NewIntentHandler(managerService)
+	authorHandler := handlers.NewAuthorHandler(managerService)
+	e.GET("/authors/:username", authorHandler.FetchAuthorProfile, readers...)
 
-	e.POST("/intents", intentHandler.CreateIntent)
-	e.PUT("/intents/:id", intentHandler.UpdateIntent)
-	e.GET("/intents/:id", intentHandler.FetchIntent)
-	e.GET("/intents", intentHandler.FetchIntents)
+	statsHandler := handlers.NewStatsHandler(managerService, cfg)
+	e.GET("/stats/languages", statsHandler.FetchLanguageStats, readers...)
+	e.GET("/stats/repos", statsHandler.FetchActiveRepos, readers...)
 
-	remoteRepoHandler := handlers.NewRemoteRepositoryHandler(managerService)
-	e.GET("/repos/:owner/:name", remoteRepoHandler.FetchRepoInfo)
-	e.GET("/repos/:name/committers", remoteRepoHandler.FetchTopCommitters)
+	searchHandler := handlers.NewSearchHandler(managerService, cfg)
+	e.GET("/search", searchHandler.Search, readers...)
+	e.GET("/search/commits", searchHandler.SearchCommits, readers...)
+
+	// Without auth anyone could store tokens, so credentials need it.
+	if cfg.AuthEnabled() {
+		credentialHandler := handlers.NewCredentialHandler(managerService)
+		e.POST("/credentials", credentialHandler.CreateCredential, operatorWriters...)
+		e.GET("/credentials", credentialHandler.FetchCredentials, operatorWriters...)
+	}
+
+	// Subscriptions are only sent with an SMTP server to send them through.
+	if cfg.DigestsEnabled() {
+		e.POST("/digests/subscriptions", digestHandler.CreateDigestSubscription, operatorWriters...)
+		e.GET("/digests/subscriptions", digestHandler.FetchDigestSubscriptions, operatorWriters...)
+		e.DELETE("/digests/subscriptions/:id", digestHandler.DeleteDigestSubscription, operatorWriters...)
+	}
+
+	// GitHub signs its deliveries with the webhook secret instead of
+	// logging in.
+	if cfg.GitHubWebhookSecret != "" {
+		webhookHandler := handlers.NewWebhookHandler(managerService, cfg)
+		e.POST("/webhooks/github", webhookHandler.ReceiveGitHubEvent)
+	}
+
+	adminHandler := handlers.NewAdminHandler(managerService)
+	e.GET("/admin/status", adminHandler.FetchStatus, operatorReaders...)
+	e.GET("/admin/github/rate-limit", adminHandler.FetchRateLimits, operatorWriters...)
+	e.GET("/admin/locks", adminHandler.FetchLocks, operatorWriters...)
+	e.GET("/admin/pipeline", adminHandler.FetchPipeline, operatorReaders...)
+	// Scrapers can't log in, and the metrics are counts only.
+	e.GET("/metrics", adminHandler.FetchMetrics)
+
+	if cfg.DocsEnabled() {
+		serveDocs(e)
+	}
+
+	e.GET("/ui", func(c echo.Context) error {
+		return c.Redirect(http.StatusMovedPermanently, "/ui/")
+	})
+	dashboard := e.Group("/ui")
+	dashboard.Use(middleware.StaticWithConfig(middleware.StaticConfig{
+		Filesystem: http.FS(ui.Assets()),
+		HTML5:      true,
+		IgnoreBase: true,
+	}))
 	return e
 }
@@ -0,0 +1,261 @@
+"use strict";
+
+const app = document.getElementById("app");
+const perPage = 20;
+
+async function api(path, options = {}) {
+    const resp = await fetch(path, {
+        credentials: "same-origin",
+        headers: { "Content-Type": "application/json" },
+        ...options,
+    });
+    if (resp.status === 401) {
+        throw new Error("You need to log in to view this page.");
+    }
+    const body = await resp.json();
+    if (!resp.ok) {
+        throw new Error(body.error || resp.statusText);
+    }
+    return body;
+}
+
+function el(tag, attrs = {}, ...children) {
+    const node = document.createElement(tag);
+    for (const [key, value] of Object.entries(attrs)) {
+        if (key.startsWith("on")) {
+            node.addEventListener(key.slice(2), value);
+        } else {
+            node.setAttribute(key, value);
+        }
+    }
+    for (const child of children) {
+        node.append(child instanceof Node ? child : document.createTextNode(child ?? ""));
+    }
+    return node;
+}
+
+function showError(err) {
+    app.replaceChildren(el("section", {}, el("p", { class: "error" }, err.message)));
+}
+
+function formatDate(value) {
+    return value ? new Date(value).toLocaleDateString() : "-";
+}
+
+async function renderIntents(page = 1) {
+    const result = await api(`/intents?page=${page}&per_page=${perPage}`);
+    const rows = result.data.map((intent) => el("tr", {},
+        el("td", {}, el("a", { href: `#/repos/${intent.repository_name}` }, intent.repository_name)),
+        el("td", {}, intent.start_date ? formatDate(intent.start_date) : "full history"),
+        el("td", {}, intent.end_date ? formatDate(intent.end_date) : "ongoing"),
+        el("td", {}, el("span", { class: "status" }, intent.status)),
+        el("td", {}, formatDate(intent.last_synced_at)),
+        el("td", {}, intent.synced_commits),
+        el("td", {}, intent.is_active ? "yes" : "no"),
+    ));
+
+    const pages = Math.max(1, Math.ceil(result.total_count / perPage));
+    const pager = el("div", { class: "pager" },
+        el("button", { onclick: () => route(`#/intents/${page - 1}`), ...(page <= 1 && { disabled: "" }) }, "Previous"),
+        `Page ${page} of ${pages}`,
+        el("button", { onclick: () => route(`#/intents/${page + 1}`), ...(page >= pages && { disabled: "" }) }, "Next"),
+    );
+
+    const repository = el("input", { name: "repository", placeholder: "owner/repo", required: "" });
+    const since = el("input", { name: "since", type: "date", title: "Leave empty to index the full history" });
+    const until = el("input", { name: "until", type: "date", title: "Leave empty to keep indexing new commits" });
+    const form = el("form", {
+        onsubmit: async (e) => {
+            e.preventDefault();
+            try {
+                await api("/intents", {
+                    method: "POST",
+                    body: JSON.stringify({ repository: repository.value, since: since.value, until: until.value }),
+                });
+                await renderIntents(page);
+            } catch (err) {
+                showError(err);
+            }
+        },
+    }, repository, since, until, el("button", { type: "submit" }, "Track repository"));
+
+    app.replaceChildren(el("section", {},
+        el("h2", {}, "Intents"),
+        form,
+        el("table", {},
+            el("thead", {}, el("tr", {}, el("th", {}, "Repository"), el("th", {}, "Since"), el("th", {}, "Until"), el("th", {}, "Status"), el("th", {}, "Last synced"), el("th", {}, "Commits"), el("th", {}, "Active"))),
+            el("tbody", {}, ...rows),
+        ),
+        pager,
+    ));
+}
+
+function renderRepoSearch() {
+    const input = el("input", { placeholder: "owner/repo", required: "" });
+    app.replaceChildren(el("section", {},
+        el("h2", {}, "Repositories"),
+        el("form", {
+            onsubmit: (e) => {
+                e.preventDefault();
+                route(`#/repos/${input.value.trim()}`);
+            },
+        }, input, el("button", { type: "submit" }, "Open")),
+    ));
+}
+
+function committersChart(committers) {
+    const ns = "http://www.w3.org/2000/svg";
+    const barHeight = 22;
+    const width = 600;
+    const labelWidth = 140;
+    const max = Math.max(1, ...committers.map((c) => c.Commits));
+
+    const svg = document.createElementNS(ns, "svg");
+    svg.setAttribute("width", width);
+    svg.setAttribute("height", committers.length * barHeight);
+
+    committers.forEach((c, i) => {
+        const y = i * barHeight;
+        const label = document.createElementNS(ns, "text");
+        label.setAttribute("x", 0);
+        label.setAttribute("y", y + 15);
+        label.textContent = c.Author.Username || c.Author.Name;
+
+        const bar = document.createElementNS(ns, "rect");
+        const barWidth = ((width - labelWidth - 40) * c.Commits) / max;
+        bar.setAttribute("x", labelWidth);
+        bar.setAttribute("y", y + 4);
+        bar.setAttribute("width", barWidth);
+        bar.setAttribute("height", barHeight - 8);
+        bar.setAttribute("fill", "#2da44e");
+
+        const count = document.createElementNS(ns, "text");
+        count.setAttribute("x", labelWidth + barWidth + 6);
+        count.setAttribute("y", y + 15);
+        count.textContent = c.Commits;
+
+        svg.append(label, bar, count);
+    });
+    return svg;
+}
+
+// weekly sums a daily series into weeks, starting at its first day.
+function weekly(days) {
+    const weeks = [];
+    days.forEach((d, i) => {
+        if (i % 7 === 0) {
+            weeks.push({ day: d.day, commits: 0 });
+        }
+        weeks[weeks.length - 1].commits += d.commits;
+    });
+    return weeks;
+}
+
+function activityChart(days) {
+    const ns = "http://www.w3.org/2000/svg";
+    const width = 600;
+    const height = 120;
+    const axis = 16;
+    const buckets = days.length > 120 ? weekly(days) : days;
+    const max = Math.max(1, ...buckets.map((d) => d.commits));
+    const step = width / buckets.length;
+
+    const svg = document.createElementNS(ns, "svg");
+    svg.setAttribute("width", width);
+    svg.setAttribute("height", height + axis);
+
+    buckets.forEach((d, i) => {
+        const barHeight = (height * d.commits) / max;
+        const bar = document.createElementNS(ns, "rect");
+        bar.setAttribute("x", i * step);
+        bar.setAttribute("y", height - barHeight);
+        bar.setAttribute("width", Math.max(1, step - 1));
+        bar.setAttribute("height", barHeight);
+        bar.setAttribute("fill", "#2da44e");
+
+        const title = document.createElementNS(ns, "title");
+        title.textContent = `${d.day}: ${d.commits} commits`;
+        bar.append(title);
+        svg.append(bar);
+    });
+
+    const first = document.createElementNS(ns, "text");
+    first.setAttribute("x", 0);
+    first.setAttribute("y", height + axis - 2);
+    first.textContent = buckets[0].day;
+
+    const last = document.createElementNS(ns, "text");
+    last.setAttribute("x", width);
+    last.setAttribute("y", height + axis - 2);
+    last.setAttribute("text-anchor", "end");
+    last.textContent = buckets[buckets.length - 1].day;
+
+    const peak = document.createElementNS(ns, "text");
+    peak.setAttribute("x", 0);
+    peak.setAttribute("y", 10);
+    peak.textContent = `${max} commits ${buckets === days ? "a day" : "a week"}`;
+
+    svg.append(first, last, peak);
+    return svg;
+}
+
+async function renderRepo(fullName) {
+    const [owner, name] = fullName.split("/");
+    const since = new Date();
+    since.setFullYear(since.getFullYear() - 1);
+    const [repo, committers, days] = await Promise.all([
+        api(`/repos/${owner}/${name}`),
+        api(`/repos/${name}/committers?repo=${encodeURIComponent(fullName)}&page=1&per_page=10`),
+        api(`/repos/${owner}/${name}/stats/daily?since=${since.toISOString().slice(0, 10)}`),
+    ]);
+
+    app.replaceChildren(
+        el("section", {},
+            el("h2", {}, repo.full_name),
+            repo.archived ? el("p", {}, el("span", { class: "status" }, "archived"), " No longer indexed.") : "",
+            repo.description ? el("p", {}, repo.description) : "",
+            repo.homepage ? el("p", {}, el("a", { href: repo.homepage }, repo.homepage)) : "",
+            el("div", { class: "stats" },
+                el("div", {}, el("strong", {}, repo.stargazers_count), "stars"),
+                el("div", {}, el("strong", {}, repo.watchers_count), "watchers"),
+                el("div", {}, el("strong", {}, repo.forks), "forks"),
+                el("div", {}, el("strong", {}, repo.open_issues_count), "open issues"),
+                el("div", {}, el("strong", {}, repo.language || "-"), "language"),
+                el("div", {}, el("strong", {}, formatDate(repo.updated_at)), "last updated"),
+                el("div", {}, el("strong", {}, repo.default_branch || "-"), "default branch"),
+                el("div", {}, el("strong", {}, repo.license || "-"), "license"),
+            ),
+            repo.topics && repo.topics.length ? el("p", { class: "topics" }, ...repo.topics.map((t) => el("span", { class: "status" }, t))) : "",
+        ),
+        el("section", {},
+            el("h3", {}, "Activity over the last year"),
+            days.length ? activityChart(days) : el("p", {}, "No commits indexed yet."),
+        ),
+        el("section", {},
+            el("h3", {}, "Commits by top committers"),
+            committers.data.length ? committersChart(committers.data) : el("p", {}, "No commits indexed yet."),
+        ),
+    );
+}
+
+async function route(hash = location.hash) {
+    if (hash !== location.hash) {
+        location.hash = hash;
+        return;
+    }
+    const parts = hash.replace(/^#\/?/, "").split("/");
+    try {
+        if (parts[0] === "repos" && parts.length >= 3) {
+            await renderRepo(`${parts[1]}/${parts[2]}`);
+        } else if (parts[0] === "repos") {
+            renderRepoSearch();
+        } else {
+            await renderIntents(Number(parts[1]) || 1);
+        }
+    } catch (err) {
+        showError(err);
+    }
+}
+
+window.addEventListener("hashchange", () => route());
+route();
@@ -0,0 +1,19 @@
+// Package ui embeds the manager's web dashboard.
+package ui
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed dist
+var dist embed.FS
+
+// Assets returns the dashboard's static files rooted at the dist directory.
+func Assets() fs.FS {
+	assets, err := fs.Sub(dist, "dist")
+	if err != nil {
+		panic(err)
+	}
+	return assets
+}
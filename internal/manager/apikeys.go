@@ -0,0 +1,118 @@
+package manager
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/noelukwa/indexer/internal/manager/models"
+	"github.com/noelukwa/indexer/internal/manager/repository"
+)
+
+var (
+	ErrInvalidAPIKey  error = fmt.Errorf("API key is invalid or has been revoked")
+	ErrAPIKeyNotFound error = newError(ErrNotFound, "API key not found")
+	ErrInvalidKeyName error = newError(ErrInvalid, "API key name is required and role must be viewer or admin")
+	ErrInvalidTenant  error = newError(ErrInvalid, "invalid tenant: must be up to 63 lowercase letters, digits, '-' and '_', starting with a letter or digit")
+)
+
+// tenantPattern is what tenant names look like.
+var tenantPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+const (
+	// apiKeyPrefix starts every key, so leaked keys are easy to scan for.
+	apiKeyPrefix = "idx_"
+	// apiKeyTouchInterval is how stale a key's last use may get before a
+	// request records it again.
+	apiKeyTouchInterval = time.Minute
+)
+
+// CreateAPIKey generates a key granting role. The key is only returned
+// here; the store keeps its hash. A key bound to a tenant only sees and
+// creates that tenant's intents, repositories and commits, while one
+// without sees every tenant's.
+func (svc *Service) CreateAPIKey(ctx context.Context, name string, role models.Role, tenant string) (*models.APIKey, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || !role.Valid() {
+		return nil, ErrInvalidKeyName
+	}
+	tenant = strings.TrimSpace(tenant)
+	if tenant != "" && !tenantPattern.MatchString(tenant) {
+		return nil, ErrInvalidTenant
+	}
+
+	token, err := newSessionToken()
+	if err != nil {
+		return nil, err
+	}
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return nil, err
+	}
+
+	key := apiKeyPrefix + token
+	saved, err := svc.store.SaveAPIKey(ctx, hashSessionToken(key), models.APIKey{
+		ID:     id,
+		Name:   name,
+		Prefix: key[:len(apiKeyPrefix)+8],
+		Role:   role,
+		Tenant: tenant,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save API key: %w", err)
+	}
+
+	saved.Key = key
+	return saved, nil
+}
+
+// ValidateAPIKey returns the session a request with the key acts as: the
+// configured admin key's, or a stored key's under its name, role and
+// tenant.
+func (svc *Service) ValidateAPIKey(ctx context.Context, key string) (*models.Session, error) {
+	if key == "" {
+		return nil, ErrInvalidAPIKey
+	}
+	if admin := svc.cfg.AdminAPIKey; admin != "" && subtle.ConstantTimeCompare([]byte(key), []byte(admin)) == 1 {
+		return &models.Session{Username: "api-key:admin", Role: models.AdminRole}, nil
+	}
+
+	found, err := svc.store.FindAPIKey(ctx, hashSessionToken(key))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find API key: %w", err)
+	}
+
+	if found.LastUsedAt == nil || time.Since(*found.LastUsedAt) > apiKeyTouchInterval {
+		if err := svc.store.TouchAPIKey(ctx, found.ID); err != nil {
+			log.Printf("failed to record use of API key %s: %v", found.ID, err)
+		}
+	}
+	return &models.Session{
+		Username:  "api-key:" + found.Name,
+		Role:      found.Role,
+		CreatedAt: found.CreatedAt,
+		Tenant:    found.Tenant,
+	}, nil
+}
+
+func (svc *Service) GetAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	return svc.store.FindAPIKeys(ctx)
+}
+
+// RevokeAPIKey refuses the key from now on. Revoked keys stay listed.
+func (svc *Service) RevokeAPIKey(ctx context.Context, id uuid.UUID) error {
+	err := svc.store.RevokeAPIKey(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrAPIKeyNotFound
+	}
+	return err
+}
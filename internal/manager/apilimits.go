@@ -0,0 +1,36 @@
+package manager
+
+import (
+	"context"
+
+	"github.com/noelukwa/indexer/internal/pkg/apilimits"
+)
+
+// APILimiter rate limits API clients. See apilimits.Store.
+type APILimiter interface {
+	Allow(ctx context.Context, key string, limit apilimits.Limit) (apilimits.Result, error)
+}
+
+// UseAPILimiter rate limits API clients with l, at APIRateLimit.
+func (svc *Service) UseAPILimiter(l APILimiter) {
+	svc.apiLimiter = l
+}
+
+// AllowRequest takes a request of the client with apiKey, or from the
+// address ip when it sent none, from its rate limit. Every request is
+// allowed when there is no limit.
+func (svc *Service) AllowRequest(ctx context.Context, apiKey, ip string) (apilimits.Result, error) {
+	if svc.apiLimiter == nil || !svc.cfg.APIRateLimitEnabled() {
+		return apilimits.Result{Allowed: true}, nil
+	}
+
+	// Keys are hashed so they don't sit in Redis in the clear.
+	client := "ip:" + ip
+	if apiKey != "" {
+		client = "key:" + hashSessionToken(apiKey)
+	}
+	return svc.apiLimiter.Allow(ctx, client, apilimits.Limit{
+		Rate:  svc.cfg.APIRateLimit,
+		Burst: max(svc.cfg.APIRateBurst, 1),
+	})
+}
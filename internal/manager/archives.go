@@ -0,0 +1,271 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/noelukwa/indexer/internal/manager/models"
+	"github.com/noelukwa/indexer/internal/pkg/objectstore"
+	"github.com/noelukwa/indexer/internal/pkg/parquet"
+)
+
+const (
+	// archiveLease is how long a replica holds a repository it archives.
+	archiveLease = 30 * time.Minute
+	// parquetContentType is the media type of archive objects.
+	parquetContentType = "application/vnd.apache.parquet"
+)
+
+// UseArchiveStore archives cold commits to store. Call it before
+// ArchiveColdCommits.
+func (svc *Service) UseArchiveStore(store objectstore.Store) {
+	svc.archives = store
+}
+
+// ArchiveColdCommits moves the commits authored more than ArchiveAfter ago
+// out to the archive store every ArchiveInterval, until ctx is done.
+func (svc *Service) ArchiveColdCommits(ctx context.Context) {
+	interval := svc.cfg.ArchiveInterval
+	if interval <= 0 {
+		interval = 24 * time.Hour
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		archived, err := svc.archiveColdCommits(ctx, time.Now())
+		if err != nil {
+			log.Printf("failed to archive commits: %v", err)
+		} else if archived > 0 {
+			log.Printf("archived %d commits", archived)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// archiveColdCommits archives the rolled up commits authored before now
+// less ArchiveAfter of every repository not being archived by another
+// replica, and returns how many it archived.
+func (svc *Service) archiveColdCommits(ctx context.Context, now time.Time) (int, error) {
+	if svc.archives == nil || svc.cfg.ArchiveAfter <= 0 {
+		return 0, nil
+	}
+
+	cutoff := now.Add(-svc.cfg.ArchiveAfter).UTC()
+	repos, err := svc.store.FindArchivableRepos(ctx, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find repositories to archive: %w", err)
+	}
+
+	archived := 0
+	for _, repo := range repos {
+		claimed, err := svc.store.ClaimRepoArchive(ctx, repo.ID, cutoff, now.Add(archiveLease))
+		if err != nil {
+			return archived, fmt.Errorf("failed to claim %s: %w", repo.FullName, err)
+		}
+		if !claimed {
+			continue
+		}
+
+		n, archiveErr := svc.archiveRepo(ctx, repo, cutoff)
+		archived += n
+		if n > 0 {
+			svc.invalidateRepo(ctx, repo.FullName)
+		}
+		if err := svc.store.ReleaseRepoArchive(ctx, repo.ID); err != nil {
+			log.Printf("failed to release the archive of %s: %v", repo.FullName, err)
+		}
+		if archiveErr != nil {
+			return archived, fmt.Errorf("failed to archive %s: %w", repo.FullName, archiveErr)
+		}
+	}
+	return archived, nil
+}
+
+// archiveRepo moves the repository's rolled up commits authored before
+// cutoff to the archive store, ArchiveBatchSize to an object.
+func (svc *Service) archiveRepo(ctx context.Context, repo models.Repository, cutoff time.Time) (int, error) {
+	batchSize := svc.cfg.ArchiveBatchSize
+	if batchSize <= 0 {
+		batchSize = 50000
+	}
+
+	archived := 0
+	for {
+		commits, err := svc.store.FindArchivableCommits(ctx, repo.ID, cutoff, batchSize)
+		if err != nil {
+			return archived, fmt.Errorf("failed to find commits: %w", err)
+		}
+		if len(commits) == 0 {
+			return archived, nil
+		}
+
+		data, err := encodeCommits(repo.FullName, commits)
+		if err != nil {
+			return archived, err
+		}
+		id, err := uuid.NewRandom()
+		if err != nil {
+			return archived, err
+		}
+		first, last := commits[0].CreatedAt.UTC(), commits[len(commits)-1].CreatedAt.UTC()
+		key := fmt.Sprintf("commits/%s/%s-%s-%s.parquet", repo.FullName, first.Format("20060102"), last.Format("20060102"), id)
+		if err := svc.archives.Put(ctx, key, data, parquetContentType); err != nil {
+			return archived, fmt.Errorf("failed to put %s: %w", key, err)
+		}
+
+		hashes := make([]string, len(commits))
+		for i, commit := range commits {
+			hashes[i] = commit.Hash
+		}
+		err = svc.store.ArchiveCommits(ctx, models.CommitArchive{
+			ID:            id,
+			RepositoryID:  repo.ID,
+			ObjectKey:     key,
+			Commits:       int32(len(commits)),
+			FirstCommitAt: first,
+			LastCommitAt:  last,
+			SizeBytes:     int64(len(data)),
+		}, hashes)
+		if err != nil {
+			// The object is left over, but no catalog entry refers to it.
+			return archived, fmt.Errorf("failed to record %s: %w", key, err)
+		}
+		archived += len(commits)
+		if len(commits) < batchSize {
+			return archived, nil
+		}
+	}
+}
+
+// encodeCommits is the Parquet file of a repository's commits. Stats are
+// null for commits indexed without them, and tags are comma separated.
+func encodeCommits(repo string, commits []models.Commit) ([]byte, error) {
+	columns := []parquet.Column{
+		{Name: "hash", Kind: parquet.String},
+		{Name: "repository", Kind: parquet.String},
+		{Name: "author_id", Kind: parquet.Int64},
+		{Name: "author_name", Kind: parquet.String},
+		{Name: "author_email", Kind: parquet.String},
+		{Name: "author_username", Kind: parquet.String},
+		{Name: "message", Kind: parquet.String},
+		{Name: "url", Kind: parquet.String, Optional: true},
+		{Name: "created_at", Kind: parquet.Timestamp},
+		{Name: "additions", Kind: parquet.Int32, Optional: true},
+		{Name: "deletions", Kind: parquet.Int32, Optional: true},
+		{Name: "changes", Kind: parquet.Int32, Optional: true},
+		{Name: "tags", Kind: parquet.String, Optional: true},
+	}
+	for i := range columns {
+		columns[i].Values = make([]interface{}, 0, len(commits))
+	}
+
+	for _, commit := range commits {
+		var url, tags interface{}
+		if commit.Url != "" {
+			url = commit.Url
+		}
+		if len(commit.Tags) > 0 {
+			tags = strings.Join(commit.Tags, ",")
+		}
+		var additions, deletions, changes interface{}
+		if commit.Stats != nil {
+			additions, deletions, changes = commit.Stats.Additions, commit.Stats.Deletions, commit.Stats.Changes
+		}
+
+		row := []interface{}{
+			commit.Hash,
+			repo,
+			commit.Author.ID,
+			commit.Author.Name,
+			commit.Author.Email,
+			commit.Author.Username,
+			commit.Message,
+			url,
+			commit.CreatedAt,
+			additions,
+			deletions,
+			changes,
+			tags,
+		}
+		for i, value := range row {
+			columns[i].Values = append(columns[i].Values, value)
+		}
+	}
+	return parquet.Encode(columns)
+}
+
+// GetRepoArchive lists the repository's archive objects.
+func (svc *Service) GetRepoArchive(ctx context.Context, repo string) (*models.RepoArchive, error) {
+	found, err := svc.findRepo(ctx, normalizeRepositoryName(repo))
+	if err != nil {
+		return nil, err
+	}
+
+	objects, err := svc.store.FindCommitArchives(ctx, found.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find archives: %w", err)
+	}
+	archive := &models.RepoArchive{Repository: found.FullName, Objects: objects}
+	for _, object := range objects {
+		archive.Commits += int64(object.Commits)
+	}
+
+	notice, err := svc.store.GetArchiveSummary(ctx, found.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get archive summary: %w", err)
+	}
+	if notice != nil {
+		archive.ArchivedBefore = &notice.ArchivedBefore
+	}
+	return archive, nil
+}
+
+// archiveNotice is GetArchiveNotice for the repository with the id. A
+// zero start is left open too.
+func (svc *Service) archiveNotice(ctx context.Context, repoID int64, start *time.Time) (*models.ArchiveNotice, error) {
+	notice, err := svc.store.GetArchiveSummary(ctx, repoID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get archive summary: %w", err)
+	}
+	if notice == nil {
+		return nil, nil
+	}
+	if start != nil && !start.IsZero() && !start.Before(notice.ArchivedBefore) {
+		return nil, nil
+	}
+	return notice, nil
+}
+
+// GetArchiveNotice tells whether results read from the repository's
+// commits since start, or all of them when start is nil, miss archived
+// commits. It is nil when they don't.
+func (svc *Service) GetArchiveNotice(ctx context.Context, repo string, start *time.Time) (*models.ArchiveNotice, error) {
+	found, err := svc.findRepo(ctx, normalizeRepositoryName(repo))
+	if err != nil {
+		return nil, err
+	}
+	return svc.archiveNotice(ctx, found.ID, start)
+}
+
+// deleteArchiveObjects deletes the archive objects of a purged
+// repository. Failures are logged, leaving the objects over.
+func (svc *Service) deleteArchiveObjects(ctx context.Context, objects []models.CommitArchive) {
+	if svc.archives == nil {
+		return
+	}
+	for _, object := range objects {
+		if err := svc.archives.Delete(ctx, object.ObjectKey); err != nil {
+			log.Printf("failed to delete archive object %s: %v", object.ObjectKey, err)
+		}
+	}
+}
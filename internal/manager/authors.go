@@ -0,0 +1,32 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/noelukwa/indexer/internal/manager/models"
+	"github.com/noelukwa/indexer/internal/manager/repository"
+)
+
+var ErrAuthorNotFound error = newError(ErrNotFound, "author not found")
+
+// GetAuthorProfile returns the author with the GitHub login username and
+// their commits across the indexed repositories. Authors without a GitHub
+// account have no login, so have no profile.
+func (svc *Service) GetAuthorProfile(ctx context.Context, username string) (*models.AuthorProfile, error) {
+	username = strings.TrimSpace(username)
+	if username == "" {
+		return nil, ErrAuthorNotFound
+	}
+
+	profile, err := svc.store.GetAuthorProfile(ctx, username)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrAuthorNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get author profile: %w", err)
+	}
+	return profile, nil
+}
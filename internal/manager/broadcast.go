@@ -0,0 +1,114 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/noelukwa/indexer/internal/events"
+	"github.com/noelukwa/indexer/internal/manager/models"
+)
+
+var (
+	ErrIntentInactive   = newError(ErrConflict, "intent is paused")
+	ErrBroadcastTooSoon = errors.New("intent was broadcast too recently")
+)
+
+// ForceBroadcast queues an active intent straight for the monitor rather
+// than waiting for discovery's next tick, and marks it broadcast until the
+// monitor reports the run. Each intent can be forced once every
+// BroadcastCooldown, across replicas.
+func (svc *Service) ForceBroadcast(ctx context.Context, id uuid.UUID) (*models.Intent, error) {
+	intent, err := svc.findIntent(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !intent.IsActive {
+		return nil, ErrIntentInactive
+	}
+
+	payload, err := svc.intentPayload(ctx, intent)
+	if err != nil {
+		return nil, err
+	}
+
+	forcedAt, err := svc.claimForced(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := svc.queueIntentTo(ctx, svc.cfg.MonitorQueueName, events.NewIntentKind, payload); err != nil {
+		svc.releaseForced(ctx, id, forcedAt)
+		return nil, err
+	}
+
+	// The broadcast is queued either way, so failing to mark it only logs.
+	updated, err := svc.transitionIntent(ctx, intent, models.Broadcast, models.IntentUpdate{})
+	if err != nil {
+		log.Printf("failed to mark intent %s as broadcast: %v", id, err)
+		return intent, nil
+	}
+	return updated, nil
+}
+
+// RebroadcastIntent publishes an active intent to discovery again now, for
+// when its broadcast was lost. The intent is pending_broadcast until the
+// broadcaster has published it, which marks it broadcast. It shares
+// ForceBroadcast's cooldown.
+func (svc *Service) RebroadcastIntent(ctx context.Context, id uuid.UUID) (*models.Intent, error) {
+	intent, err := svc.findIntent(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !intent.IsActive {
+		return nil, ErrIntentInactive
+	}
+
+	payload, err := svc.intentPayload(ctx, intent)
+	if err != nil {
+		return nil, err
+	}
+
+	forcedAt, err := svc.claimForced(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Marked before it is queued, so the broadcaster's mark comes after.
+	updated, err := svc.transitionIntent(ctx, intent, models.PendingBroadcast, models.IntentUpdate{})
+	if err != nil {
+		svc.releaseForced(ctx, id, forcedAt)
+		return nil, fmt.Errorf("failed to mark intent pending broadcast: %w", err)
+	}
+
+	if err := svc.queueIntent(ctx, events.NewIntentKind, payload); err != nil {
+		svc.releaseForced(ctx, id, forcedAt)
+		return nil, err
+	}
+	return updated, nil
+}
+
+// claimForced records a forced broadcast of the intent, or returns
+// ErrBroadcastTooSoon if the last one is still within the cooldown. The
+// store keeps the record, so the cooldown holds across replicas.
+func (svc *Service) claimForced(ctx context.Context, id uuid.UUID) (time.Time, error) {
+	forcedAt, ok, err := svc.store.ClaimForcedBroadcast(ctx, id, svc.cfg.BroadcastCooldown)
+	if err != nil {
+		return time.Time{}, storeError(err)
+	}
+	if !ok {
+		return time.Time{}, ErrBroadcastTooSoon
+	}
+	return forcedAt, nil
+}
+
+// releaseForced gives back the cooldown of a broadcast that was never
+// queued. It only logs its failure, which costs a cooldown at worst.
+func (svc *Service) releaseForced(ctx context.Context, id uuid.UUID, forcedAt time.Time) {
+	if err := svc.store.ReleaseForcedBroadcast(ctx, id, forcedAt); err != nil {
+		log.Printf("failed to release broadcast cooldown of intent %s: %v", id, err)
+	}
+}
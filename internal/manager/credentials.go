@@ -0,0 +1,92 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/noelukwa/indexer/internal/events"
+	"github.com/noelukwa/indexer/internal/manager/models"
+	"github.com/noelukwa/indexer/internal/manager/repository"
+	"github.com/noelukwa/indexer/internal/pkg/secrets"
+)
+
+var (
+	ErrCredentialsDisabled error = newError(ErrInvalid, "stored credentials are disabled: no credentials key configured")
+	ErrCredentialNotFound  error = newError(ErrNotFound, "credential not found")
+	ErrExistingCredential  error = newError(ErrConflict, "credential name already exists")
+	ErrInvalidCredential   error = newError(ErrInvalid, "credential name and token are required")
+)
+
+// CreateCredential seals a GitHub token with the configured credentials key
+// and stores it under name for intents to reference.
+func (svc *Service) CreateCredential(ctx context.Context, name, token string) (*models.Credential, error) {
+	name = strings.TrimSpace(name)
+	token = strings.TrimSpace(token)
+	if name == "" || token == "" {
+		return nil, ErrInvalidCredential
+	}
+
+	box, err := secrets.NewBox(svc.cfg.CredentialsKey)
+	if errors.Is(err, secrets.ErrNoKey) {
+		return nil, ErrCredentialsDisabled
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := box.Seal([]byte(token))
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return nil, err
+	}
+	credential, err := svc.store.SaveCredential(ctx, models.Credential{
+		ID:         id,
+		Name:       name,
+		Ciphertext: ciphertext,
+	})
+	if errors.Is(err, repository.ErrConflict) {
+		return nil, ErrExistingCredential
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to save credential: %w", err)
+	}
+	return credential, nil
+}
+
+func (svc *Service) GetCredentials(ctx context.Context) ([]models.Credential, error) {
+	return svc.store.FindCredentials(ctx)
+}
+
+func (svc *Service) findCredential(ctx context.Context, id uuid.UUID) (*models.Credential, error) {
+	credential, err := svc.store.FindCredential(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrCredentialNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find credential: %w", err)
+	}
+	return credential, nil
+}
+
+// intentPayload is newIntentPayload with the intent's sealed credential
+// attached, for events the monitor fetches with.
+func (svc *Service) intentPayload(ctx context.Context, intent *models.Intent) (*events.IntentPayload, error) {
+	payload := newIntentPayload(intent)
+	if intent.CredentialID == nil {
+		return payload, nil
+	}
+
+	credential, err := svc.findCredential(ctx, *intent.CredentialID)
+	if err != nil {
+		return nil, err
+	}
+	payload.Credential = credential.Ciphertext
+	return payload, nil
+}
@@ -0,0 +1,174 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/noelukwa/indexer/internal/events"
+	"github.com/noelukwa/indexer/internal/manager/models"
+	"github.com/noelukwa/indexer/internal/manager/repository"
+)
+
+var (
+	// ErrRepositoryShared is returned for a purge of a repository other
+	// intents still index.
+	ErrRepositoryShared error = newError(ErrConflict, "repository is indexed by other intents, delete them first to purge its commits")
+	// ErrRepositoryIndexed is returned for a deletion of a repository
+	// intents still index, as they would index it again.
+	ErrRepositoryIndexed error = newError(ErrConflict, "repository is indexed by intents, delete them first")
+)
+
+// DeleteIntent soft-deletes an intent and broadcasts its cancellation, so
+// discovery stops scheduling it and monitors drop it. The intent stays in
+// the database with its history but is gone from the API. purge also
+// deletes the repository's indexed commits, which is refused while other
+// intents index it.
+func (svc *Service) DeleteIntent(ctx context.Context, id uuid.UUID, purge bool) (*models.IntentDeletion, error) {
+	intent, err := svc.findIntent(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if purge {
+		// Intents of other tenants share the repository's commits too.
+		others, err := svc.store.FindIntents(repository.WithoutTenant(ctx), models.IntentFilter{
+			RepositoryName: &intent.RepositoryName,
+		}, repository.Pagination{Page: 1, PerPage: 2})
+		if err != nil {
+			return nil, fmt.Errorf("failed to find intents: %w", err)
+		}
+		for _, other := range others.Data {
+			if other.ID != intent.ID {
+				return nil, ErrRepositoryShared
+			}
+		}
+	}
+
+	deleted, err := svc.store.DeleteIntent(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrIntentNotFound
+	}
+	if err != nil {
+		return nil, storeError(err)
+	}
+	log.Printf("deleted intent %s of %s", deleted.ID, deleted.RepositoryName)
+
+	if err := svc.queueIntent(ctx, events.CancelIntentKind, newIntentPayload(deleted)); err != nil {
+		return nil, err
+	}
+
+	deletion := &models.IntentDeletion{Intent: deleted}
+	if !purge {
+		return deletion, nil
+	}
+	// The purge forgets the repository's archive objects, so list them
+	// first to delete them after.
+	var archived []models.CommitArchive
+	if repo, err := svc.store.GetRepo(ctx, deleted.RepositoryName); err == nil {
+		archived, err = svc.store.FindCommitArchives(ctx, repo.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find archives: %w", err)
+		}
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("failed to find repository: %w", err)
+	}
+	deletion.PurgedCommits, err = svc.store.PurgeRepoCommits(ctx, deleted.RepositoryName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to purge commits: %w", err)
+	}
+	svc.deleteArchiveObjects(ctx, archived)
+	svc.invalidateRepo(ctx, deleted.RepositoryName)
+	log.Printf("purged %d commits of %s", deletion.PurgedCommits, deleted.RepositoryName)
+	return deletion, nil
+}
+
+// DeleteRepository soft-deletes a repository, leaving it and its commits
+// out of every lookup and listing until PurgeRepositories deletes them for
+// good once RepoRetention has passed. It is refused while intents index
+// the repository, of any tenant.
+func (svc *Service) DeleteRepository(ctx context.Context, repoName string) (*models.Repository, error) {
+	repo, err := svc.findRepo(ctx, normalizeRepositoryName(repoName))
+	if err != nil {
+		return nil, err
+	}
+
+	intents, err := svc.store.FindIntents(repository.WithoutTenant(ctx), models.IntentFilter{
+		RepositoryName: &repo.FullName,
+	}, repository.Pagination{Page: 1, PerPage: 1})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find intents: %w", err)
+	}
+	if intents.TotalCount > 0 {
+		return nil, ErrRepositoryIndexed
+	}
+
+	deleted, err := svc.store.DeleteRepo(ctx, repo.ID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrRepositoryNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete repository: %w", err)
+	}
+	svc.invalidateRepo(ctx, deleted.FullName)
+	svc.invalidateRepo(ctx, statsScope)
+	log.Printf("deleted repository %s", deleted.FullName)
+	return deleted, nil
+}
+
+// PurgeRepositories deletes the repositories deleted for RepoRetention for
+// good, with everything indexed of them, every RepoPurgeInterval until ctx
+// is done.
+func (svc *Service) PurgeRepositories(ctx context.Context) {
+	interval := svc.cfg.RepoPurgeInterval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := svc.purgeRepositories(ctx, time.Now())
+		if err != nil {
+			log.Printf("failed to purge deleted repositories: %v", err)
+		} else if purged > 0 {
+			log.Printf("purged %d deleted repositories", purged)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// purgeRepositories purges the repositories deleted before now less
+// RepoRetention, and returns how many it purged.
+func (svc *Service) purgeRepositories(ctx context.Context, now time.Time) (int, error) {
+	repos, err := svc.store.FindDeletedRepos(ctx, now.Add(-svc.cfg.RepoRetention))
+	if err != nil {
+		return 0, fmt.Errorf("failed to find deleted repositories: %w", err)
+	}
+
+	purged := 0
+	for _, repo := range repos {
+		// The purge forgets the repository's archive objects, so list
+		// them first to delete them after.
+		archived, err := svc.store.FindCommitArchives(ctx, repo.ID)
+		if err != nil {
+			return purged, fmt.Errorf("failed to find archives of %s: %w", repo.FullName, err)
+		}
+		commits, err := svc.store.PurgeRepo(ctx, repo.ID)
+		if err != nil {
+			return purged, fmt.Errorf("failed to purge %s: %w", repo.FullName, err)
+		}
+		svc.deleteArchiveObjects(ctx, archived)
+		log.Printf("purged %s with %d commits", repo.FullName, commits)
+		purged++
+	}
+	return purged, nil
+}
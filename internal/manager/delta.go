@@ -0,0 +1,104 @@
+package manager
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/noelukwa/indexer/internal/manager/models"
+	"github.com/noelukwa/indexer/internal/manager/repository"
+)
+
+var (
+	ErrInvalidCursor      error = newError(ErrInvalid, "invalid cursor")
+	ErrAmbiguousDelta     error = newError(ErrInvalid, "only one of cursor, since_sha and since may be given")
+	ErrDeltaSHANotIndexed error = newError(ErrNotFound, "since_sha is not an indexed commit of the repository")
+)
+
+// GetCommitDelta returns the repository's next commits from where q
+// starts, the oldest first, for consumers tailing the index. Commits are
+// ordered by when they were made, so older history indexed after a cursor
+// has passed it, such as by a backfill, lands behind that cursor.
+func (svc *Service) GetCommitDelta(ctx context.Context, repo string, q models.CommitDeltaQuery) (models.CommitDelta, error) {
+	set := 0
+	for _, given := range []bool{q.Cursor != "", q.SinceSHA != "", !q.Since.IsZero()} {
+		if given {
+			set++
+		}
+	}
+	if set > 1 {
+		return models.CommitDelta{}, ErrAmbiguousDelta
+	}
+
+	found, err := svc.findRepo(ctx, normalizeRepositoryName(repo))
+	if err != nil {
+		return models.CommitDelta{}, err
+	}
+
+	var after models.CommitCursor
+	switch {
+	case q.Cursor != "":
+		after, err = decodeCommitCursor(q.Cursor)
+		if err != nil {
+			return models.CommitDelta{}, err
+		}
+	case q.SinceSHA != "":
+		cursor, err := svc.store.FindCommitCursor(ctx, found.ID, strings.ToLower(strings.TrimSpace(q.SinceSHA)))
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.CommitDelta{}, ErrDeltaSHANotIndexed
+		}
+		if err != nil {
+			return models.CommitDelta{}, fmt.Errorf("failed to find commit: %w", err)
+		}
+		after = *cursor
+	case !q.Since.IsZero():
+		// No hash sorts before the empty one, so the commits made at
+		// Since are included.
+		after.CreatedAt = q.Since
+	}
+
+	q.Limit = max(q.Limit, 1)
+	// One more than asked for tells whether there are more.
+	commits, err := svc.store.FindCommitsAfter(ctx, found.ID, after, q.Limit+1)
+	if err != nil {
+		return models.CommitDelta{}, fmt.Errorf("failed to find commits: %w", err)
+	}
+
+	delta := models.CommitDelta{Data: commits, HasMore: len(commits) > q.Limit}
+	if delta.HasMore {
+		delta.Data = commits[:q.Limit]
+	}
+	if delta.Data == nil {
+		delta.Data = []models.Commit{}
+	}
+	if n := len(delta.Data); n > 0 {
+		after = models.CommitCursor{CreatedAt: delta.Data[n-1].CreatedAt, Hash: delta.Data[n-1].Hash}
+	}
+	delta.NextCursor = encodeCommitCursor(after)
+	return delta, nil
+}
+
+// encodeCommitCursor makes an opaque cursor of a position in the commits.
+func encodeCommitCursor(cursor models.CommitCursor) string {
+	raw := cursor.CreatedAt.UTC().Format(time.RFC3339Nano) + " " + cursor.Hash
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCommitCursor(s string) (models.CommitCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return models.CommitCursor{}, ErrInvalidCursor
+	}
+	createdAt, hash, ok := strings.Cut(string(raw), " ")
+	if !ok {
+		return models.CommitCursor{}, ErrInvalidCursor
+	}
+	t, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return models.CommitCursor{}, ErrInvalidCursor
+	}
+	return models.CommitCursor{CreatedAt: t, Hash: hash}, nil
+}
@@ -0,0 +1,307 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/noelukwa/indexer/internal/manager/models"
+	"github.com/noelukwa/indexer/internal/manager/repository"
+)
+
+var (
+	ErrInvalidSubscription  error = newError(ErrInvalid, "a digest subscription needs an email address and either a repository or a label")
+	ErrSubscriptionNotFound error = newError(ErrNotFound, "digest subscription not found")
+	ErrExistingSubscription error = newError(ErrConflict, "digest subscription already exists")
+)
+
+const (
+	// digestTop is how many committers and commits a digest lists.
+	digestTop = 5
+	// digestClaimSize is how many subscriptions are claimed at a time.
+	digestClaimSize = 20
+	// maxLabelRepos bounds the repositories in the digest of a label.
+	maxLabelRepos = 100
+)
+
+// Mailer sends plain text email. See mailer.SMTP.
+type Mailer interface {
+	Send(ctx context.Context, to []string, subject, body string) error
+}
+
+// UseMailer emails the weekly digests with m. Call it before SendDigests.
+func (svc *Service) UseMailer(m Mailer) {
+	svc.mailer = m
+}
+
+// CreateDigestSubscription subscribes email to the weekly digest of repo,
+// or of every repository with the GitHub topic label. Exactly one of repo
+// and label must be set.
+func (svc *Service) CreateDigestSubscription(ctx context.Context, email, repo, label string) (*models.DigestSubscription, error) {
+	email = strings.TrimSpace(email)
+	label = strings.ToLower(strings.TrimSpace(label))
+	if email == "" || (repo == "") == (label == "") {
+		return nil, ErrInvalidSubscription
+	}
+	if repo != "" {
+		repo = normalizeRepositoryName(repo)
+		if err := validateRepositoryName(repo); err != nil {
+			return nil, err
+		}
+	}
+
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return nil, err
+	}
+	sub, err := svc.store.SaveDigestSubscription(ctx, models.DigestSubscription{
+		ID:         id,
+		Email:      email,
+		Repository: repo,
+		Label:      label,
+	})
+	if errors.Is(err, repository.ErrConflict) {
+		return nil, ErrExistingSubscription
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to save digest subscription: %w", err)
+	}
+	return sub, nil
+}
+
+func (svc *Service) GetDigestSubscriptions(ctx context.Context) ([]models.DigestSubscription, error) {
+	return svc.store.FindDigestSubscriptions(ctx)
+}
+
+func (svc *Service) DeleteDigestSubscription(ctx context.Context, id uuid.UUID) error {
+	err := svc.store.DeleteDigestSubscription(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrSubscriptionNotFound
+	}
+	return err
+}
+
+// GetWeeklyDigest compiles the digest of the repository for the week, from
+// Monday to Sunday in UTC, containing week, or for the last full week when
+// week is zero.
+func (svc *Service) GetWeeklyDigest(ctx context.Context, repo string, week time.Time) (*models.RepoDigest, error) {
+	found, err := svc.findRepo(ctx, normalizeRepositoryName(repo))
+	if err != nil {
+		return nil, err
+	}
+
+	start := weekOf(time.Now()).AddDate(0, 0, -7)
+	if !week.IsZero() {
+		start = weekOf(week)
+	}
+	return cachedQuery(ctx, svc, found.FullName, "digest", start.Format(time.DateOnly), func() (*models.RepoDigest, error) {
+		return svc.compileDigest(ctx, found.FullName, start)
+	})
+}
+
+// compileDigest summarizes the commits of repo in the week starting on
+// the Monday start.
+func (svc *Service) compileDigest(ctx context.Context, repo string, start time.Time) (*models.RepoDigest, error) {
+	lastDay := start.AddDate(0, 0, 6)
+	end := start.AddDate(0, 0, 7).Add(-time.Nanosecond)
+
+	stats, err := svc.store.GetRepoStats(ctx, models.CommitsFilter{
+		RepositoryName: repo,
+		StartDate:      &start,
+		EndDate:        &lastDay,
+	})
+	if err != nil {
+		return nil, err
+	}
+	top, err := svc.store.GetTopCommitters(ctx, repo, &start, &end, repository.Pagination{Page: 1, PerPage: digestTop})
+	if err != nil {
+		return nil, err
+	}
+	largest, err := svc.store.GetLargestCommits(ctx, models.CommitsFilter{
+		RepositoryName: repo,
+		StartDate:      &start,
+		EndDate:        &end,
+	}, digestTop)
+	if err != nil {
+		return nil, err
+	}
+
+	digest := &models.RepoDigest{
+		Repository:     repo,
+		WeekStart:      start.Format(time.DateOnly),
+		WeekEnd:        lastDay.Format(time.DateOnly),
+		Commits:        stats.Commits,
+		Additions:      stats.Additions,
+		Deletions:      stats.Deletions,
+		Authors:        stats.Authors,
+		TopCommitters:  top.Data,
+		LargestCommits: largest,
+	}
+	if digest.TopCommitters == nil {
+		digest.TopCommitters = []models.AuthorStats{}
+	}
+	return digest, nil
+}
+
+// SendDigests emails the digests of the last full week to the
+// subscriptions that haven't had them, every DigestCheckInterval, until
+// ctx is done.
+func (svc *Service) SendDigests(ctx context.Context) {
+	interval := svc.cfg.DigestCheckInterval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		sent, err := svc.sendDigests(ctx, time.Now())
+		if err != nil {
+			log.Printf("failed to send digests: %v", err)
+		} else if sent > 0 {
+			log.Printf("sent %d weekly digests", sent)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// sendDigests emails the digest of the week before now's to every
+// subscription due it and returns how many it sent. Subscriptions are
+// claimed before they are sent, so a digest that fails is not retried.
+func (svc *Service) sendDigests(ctx context.Context, now time.Time) (int, error) {
+	if svc.mailer == nil {
+		return 0, nil
+	}
+
+	week := weekOf(now).AddDate(0, 0, -7)
+	sent := 0
+	for {
+		subs, err := svc.store.ClaimDigestSubscriptions(ctx, week, digestClaimSize)
+		if err != nil {
+			return sent, fmt.Errorf("failed to claim digest subscriptions: %w", err)
+		}
+		if len(subs) == 0 {
+			return sent, nil
+		}
+
+		for _, sub := range subs {
+			ok, err := svc.sendDigest(ctx, sub, week)
+			if err != nil {
+				log.Printf("failed to send digest %s to %s: %v", sub.ID, sub.Email, err)
+				continue
+			}
+			if ok {
+				sent++
+			}
+		}
+	}
+}
+
+// sendDigest emails the subscription's digest of the week starting on
+// week, unless none of its repositories had commits that week.
+func (svc *Service) sendDigest(ctx context.Context, sub models.DigestSubscription, week time.Time) (bool, error) {
+	repos, err := svc.digestRepos(ctx, sub)
+	if err != nil {
+		return false, err
+	}
+
+	var digests []*models.RepoDigest
+	for _, repo := range repos {
+		digest, err := svc.compileDigest(ctx, repo, week)
+		if err != nil {
+			return false, err
+		}
+		if digest.Commits > 0 {
+			digests = append(digests, digest)
+		}
+	}
+	if len(digests) == 0 {
+		return false, nil
+	}
+
+	subject, body := renderDigest(sub, digests)
+	if err := svc.mailer.Send(ctx, []string{sub.Email}, subject, body); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// digestRepos names the indexed repositories a subscription covers.
+func (svc *Service) digestRepos(ctx context.Context, sub models.DigestSubscription) ([]string, error) {
+	if sub.Repository != "" {
+		repo, err := svc.findRepo(ctx, sub.Repository)
+		if errors.Is(err, ErrRepositoryNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return []string{repo.FullName}, nil
+	}
+
+	repos, err := svc.store.FindRepos(ctx, models.RepositoryFilter{Topic: &sub.Label}, repository.Pagination{Page: 1, PerPage: maxLabelRepos})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find repositories: %w", err)
+	}
+	names := make([]string, 0, len(repos.Data))
+	for _, repo := range repos.Data {
+		names = append(names, repo.FullName)
+	}
+	return names, nil
+}
+
+// renderDigest is the subject and plain text body of the email of a
+// subscription's digests, which cover the same week.
+func renderDigest(sub models.DigestSubscription, digests []*models.RepoDigest) (subject, body string) {
+	scope := sub.Repository
+	if sub.Label != "" {
+		scope = "label " + sub.Label
+	}
+	subject = fmt.Sprintf("Weekly digest for %s, %s to %s", scope, digests[0].WeekStart, digests[0].WeekEnd)
+
+	var b strings.Builder
+	for i, digest := range digests {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "%s\n", digest.Repository)
+		fmt.Fprintf(&b, "%d commits by %d authors, +%d -%d lines\n", digest.Commits, digest.Authors, digest.Additions, digest.Deletions)
+		if len(digest.TopCommitters) > 0 {
+			b.WriteString("\nTop committers:\n")
+			for _, committer := range digest.TopCommitters {
+				fmt.Fprintf(&b, "  %s: %d\n", authorName(committer.Author), committer.Commits)
+			}
+		}
+		if len(digest.LargestCommits) > 0 {
+			b.WriteString("\nLargest changes:\n")
+			for _, commit := range digest.LargestCommits {
+				fmt.Fprintf(&b, "  %.7s +%d -%d %s (%s)\n", commit.Hash, commit.Additions, commit.Deletions, commit.Title, authorName(commit.Author))
+			}
+		}
+	}
+	return subject, b.String()
+}
+
+// authorName is the GitHub login of author, or its name for commits not
+// linked to an account.
+func authorName(author models.Author) string {
+	if author.Username != "" {
+		return author.Username
+	}
+	return author.Name
+}
+
+// weekOf is the Monday, at midnight UTC, of the week containing t.
+func weekOf(t time.Time) time.Time {
+	day := t.UTC().Truncate(24 * time.Hour)
+	return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+}
@@ -0,0 +1,55 @@
+package manager
+
+import (
+	"errors"
+
+	"github.com/noelukwa/indexer/internal/manager/repository"
+)
+
+// The kinds of the service's errors. Each error the service defines wraps
+// one of them, so callers such as the API can tell what went wrong with
+// errors.Is without knowing every error.
+var (
+	ErrNotFound    = errors.New("not found")
+	ErrConflict    = errors.New("conflict")
+	ErrInvalid     = errors.New("invalid request")
+	ErrUnavailable = errors.New("unavailable")
+)
+
+// kindError is an error of one of the kinds above. err, if set, is the
+// error it was mapped from.
+type kindError struct {
+	kind error
+	msg  string
+	err  error
+}
+
+func newError(kind error, msg string) error {
+	return &kindError{kind: kind, msg: msg}
+}
+
+func (e *kindError) Error() string {
+	return e.msg
+}
+
+func (e *kindError) Unwrap() []error {
+	if e.err == nil {
+		return []error{e.kind}
+	}
+	return []error{e.kind, e.err}
+}
+
+// storeError maps the store's errors to the service's kinds: a missing
+// record to ErrNotFound, a duplicate to ErrConflict and a record breaking
+// another constraint to ErrInvalid. Other errors are returned as they are.
+func storeError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return &kindError{kind: ErrNotFound, msg: "record not found", err: err}
+	case errors.Is(err, repository.ErrConflict):
+		return &kindError{kind: ErrConflict, msg: "record already exists", err: err}
+	case errors.Is(err, repository.ErrConstraint):
+		return &kindError{kind: ErrInvalid, msg: "request refers to records that don't exist or breaks a constraint", err: err}
+	}
+	return err
+}
@@ -0,0 +1,44 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/noelukwa/indexer/internal/manager/models"
+	"github.com/noelukwa/indexer/internal/manager/repository"
+)
+
+var ErrGitHubStatsNotFound error = newError(ErrNotFound, "no GitHub stats fetched for the repository yet")
+
+// saveGitHubStats saves the GitHub stats the monitor fetched for a
+// repository.
+func (svc *Service) saveGitHubStats(ctx context.Context, stats *models.GitHubStats) error {
+	repo, err := svc.findRepo(ctx, normalizeRepositoryName(stats.Repository))
+	if err != nil {
+		return err
+	}
+	return svc.persist(ctx, repo.FullName, func() error {
+		return svc.store.SaveGitHubStats(ctx, repo.ID, stats)
+	})
+}
+
+// GetGitHubStats returns the latest GitHub stats of the repository. They
+// are available as soon as its first sync starts, well before a backfill
+// of its commits ends.
+func (svc *Service) GetGitHubStats(ctx context.Context, repo string) (*models.GitHubStats, error) {
+	found, err := svc.findRepo(ctx, normalizeRepositoryName(repo))
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := svc.store.GetGitHubStats(ctx, found.ID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrGitHubStatsNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get GitHub stats: %w", err)
+	}
+	stats.Repository = found.FullName
+	return stats, nil
+}
@@ -0,0 +1,114 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/noelukwa/indexer/internal/manager/models"
+)
+
+// CommitHook sees each batch of commits on its way to the database. It may
+// change the commits, such as tagging them, and returns the ones to keep,
+// so it can also drop commits. An error fails the whole batch, as a failed
+// save would.
+type CommitHook interface {
+	ProcessCommits(ctx context.Context, commits []*models.Commit) ([]*models.Commit, error)
+}
+
+// CommitHookFunc is a CommitHook written as a function.
+type CommitHookFunc func(ctx context.Context, commits []*models.Commit) ([]*models.Commit, error)
+
+func (f CommitHookFunc) ProcessCommits(ctx context.Context, commits []*models.Commit) ([]*models.Commit, error) {
+	return f(ctx, commits)
+}
+
+var (
+	commitHooksMu sync.RWMutex
+	commitHooks   = map[string]CommitHook{
+		"ticket_ids": CommitHookFunc(tagTicketIDs),
+	}
+)
+
+// RegisterCommitHook makes hook available under name, for the manager's
+// configuration to enable. Call it from an init function of the package
+// that defines the hook, and import that package into the manager's
+// build. It panics if name is taken, like registering a database driver.
+func RegisterCommitHook(name string, hook CommitHook) {
+	commitHooksMu.Lock()
+	defer commitHooksMu.Unlock()
+	if hook == nil {
+		panic("manager: RegisterCommitHook hook is nil")
+	}
+	if _, dup := commitHooks[name]; dup {
+		panic("manager: RegisterCommitHook called twice for " + name)
+	}
+	commitHooks[name] = hook
+}
+
+// CommitHooks returns the registered hooks of names in the same order.
+func CommitHooks(names []string) ([]NamedCommitHook, error) {
+	commitHooksMu.RLock()
+	defer commitHooksMu.RUnlock()
+
+	hooks := make([]NamedCommitHook, 0, len(names))
+	for _, name := range names {
+		hook, ok := commitHooks[name]
+		if !ok {
+			registered := make([]string, 0, len(commitHooks))
+			for name := range commitHooks {
+				registered = append(registered, name)
+			}
+			sort.Strings(registered)
+			return nil, fmt.Errorf("unknown commit hook %q, registered hooks are %v", name, registered)
+		}
+		hooks = append(hooks, NamedCommitHook{Name: name, Hook: hook})
+	}
+	return hooks, nil
+}
+
+// NamedCommitHook is a hook with the name it was registered under.
+type NamedCommitHook struct {
+	Name string
+	Hook CommitHook
+}
+
+// UseCommitHooks runs hooks, in order, on every batch of commits before
+// it is persisted. Call it before the service starts consuming commits.
+func (svc *Service) UseCommitHooks(hooks ...NamedCommitHook) {
+	svc.hooks = append(svc.hooks, hooks...)
+}
+
+// runCommitHooks passes commits through the hooks and returns the commits
+// left to persist.
+func (svc *Service) runCommitHooks(ctx context.Context, commits []*models.Commit) ([]*models.Commit, error) {
+	for _, hook := range svc.hooks {
+		var err error
+		commits, err = hook.Hook.ProcessCommits(ctx, commits)
+		if err != nil {
+			return nil, fmt.Errorf("commit hook %s failed: %w", hook.Name, err)
+		}
+		if len(commits) == 0 {
+			return nil, nil
+		}
+	}
+	return commits, nil
+}
+
+// ticketIDPattern matches issue keys such as PROJ-123.
+var ticketIDPattern = regexp.MustCompile(`\b[A-Z][A-Z0-9]+-[1-9][0-9]*\b`)
+
+// tagTicketIDs tags each commit with the ticket IDs its message mentions.
+func tagTicketIDs(ctx context.Context, commits []*models.Commit) ([]*models.Commit, error) {
+	for _, commit := range commits {
+		for _, id := range ticketIDPattern.FindAllString(commit.Message, -1) {
+			if !slices.Contains(commit.Tags, id) {
+				commit.Tags = append(commit.Tags, id)
+			}
+		}
+	}
+	return commits, nil
+}
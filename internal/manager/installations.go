@@ -0,0 +1,60 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/noelukwa/indexer/internal/manager/models"
+	"github.com/noelukwa/indexer/internal/manager/repository"
+)
+
+// InstallRepositories gives each repository the indexer's GitHub App was
+// granted an active intent for its full history. Repositories that
+// already have an intent keep it, paused or not.
+func (svc *Service) InstallRepositories(ctx context.Context, names []string) (*models.InstallationChange, error) {
+	change := newInstallationChange()
+	for _, name := range names {
+		repoName := normalizeRepositoryName(name)
+		existing, err := svc.store.FindIntents(ctx, models.IntentFilter{RepositoryName: &repoName}, repository.Pagination{Page: 1, PerPage: 1})
+		if err != nil {
+			return nil, fmt.Errorf("failed to check for existing intent: %w", err)
+		}
+		if existing.TotalCount > 0 {
+			change.Skipped = append(change.Skipped, models.IntentImportSkip{Repository: repoName, Reason: ErrExistingIntent.Error()})
+			continue
+		}
+
+		if _, err := svc.createIntent(ctx, repoName, time.Time{}, time.Time{}, models.IntentOptions{}, true); err != nil {
+			change.Skipped = append(change.Skipped, models.IntentImportSkip{Repository: repoName, Reason: err.Error()})
+			continue
+		}
+		change.Created = append(change.Created, repoName)
+	}
+	return change, nil
+}
+
+// UninstallRepositories pauses the active intents of the repositories the
+// indexer's GitHub App lost access to. Their indexed commits are kept.
+func (svc *Service) UninstallRepositories(ctx context.Context, names []string) (*models.InstallationChange, error) {
+	change := newInstallationChange()
+	for _, name := range names {
+		repoName := normalizeRepositoryName(name)
+		paused, err := svc.pauseIntents(ctx, repoName, "the GitHub App was removed from "+repoName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to pause intents of %s: %w", repoName, err)
+		}
+		if paused > 0 {
+			change.Paused = append(change.Paused, repoName)
+		}
+	}
+	return change, nil
+}
+
+func newInstallationChange() *models.InstallationChange {
+	return &models.InstallationChange{
+		Created: []string{},
+		Paused:  []string{},
+		Skipped: []models.IntentImportSkip{},
+	}
+}
@@ -0,0 +1,99 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/noelukwa/indexer/internal/manager/models"
+	"github.com/noelukwa/indexer/internal/manager/repository"
+)
+
+var ErrUnsupportedExport error = newError(ErrInvalid, "unsupported intent export version")
+
+// exportPageSize is the page size intents are read in for an export.
+const exportPageSize = 500
+
+// ExportIntents exports the definition of each repository's intent: its
+// active one, or else its latest.
+func (svc *Service) ExportIntents(ctx context.Context) (*models.IntentExport, error) {
+	export := &models.IntentExport{
+		Version:    models.IntentExportVersion,
+		ExportedAt: time.Now().UTC(),
+		Intents:    []models.IntentDefinition{},
+	}
+	seen := make(map[string]int)
+
+	for page := 1; ; page++ {
+		intents, err := svc.store.FindIntents(ctx, models.IntentFilter{}, repository.Pagination{Page: page, PerPage: exportPageSize})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list intents: %w", err)
+		}
+		// Intents come newest first.
+		for _, intent := range intents.Data {
+			def := intentDefinition(intent)
+			i, ok := seen[intent.RepositoryName]
+			if !ok {
+				seen[intent.RepositoryName] = len(export.Intents)
+				export.Intents = append(export.Intents, def)
+				continue
+			}
+			if intent.IsActive && !export.Intents[i].IsActive {
+				export.Intents[i] = def
+			}
+		}
+		if int64(page*exportPageSize) >= intents.TotalCount {
+			return export, nil
+		}
+	}
+}
+
+func intentDefinition(intent models.Intent) models.IntentDefinition {
+	def := models.IntentDefinition{
+		Repository:    intent.RepositoryName,
+		StartDate:     intent.StartDate,
+		Until:         intent.Until,
+		IsActive:      intent.IsActive,
+		IntentOptions: intent.IntentOptions,
+	}
+	def.CredentialID = nil
+	return def
+}
+
+// ImportIntents recreates exported intents, active ones sent to the
+// monitor and the rest paused. Repositories that already have an intent
+// are skipped, so an import can be run again after a partial one.
+func (svc *Service) ImportIntents(ctx context.Context, export *models.IntentExport) (*models.IntentImport, error) {
+	if export.Version != models.IntentExportVersion {
+		return nil, ErrUnsupportedExport
+	}
+
+	result := &models.IntentImport{Skipped: []models.IntentImportSkip{}}
+	for _, def := range export.Intents {
+		repoName := normalizeRepositoryName(def.Repository)
+		existing, err := svc.store.FindIntents(ctx, models.IntentFilter{RepositoryName: &repoName}, repository.Pagination{Page: 1, PerPage: 1})
+		if err != nil {
+			return nil, fmt.Errorf("failed to check for existing intent: %w", err)
+		}
+		if existing.TotalCount > 0 {
+			result.Skipped = append(result.Skipped, models.IntentImportSkip{Repository: repoName, Reason: ErrExistingIntent.Error()})
+			continue
+		}
+
+		var startDate, until time.Time
+		if def.StartDate != nil {
+			startDate = *def.StartDate
+		}
+		if def.Until != nil {
+			until = *def.Until
+		}
+		opts := def.IntentOptions
+		opts.CredentialID = nil
+		if _, err := svc.createIntent(ctx, repoName, startDate, until, opts, def.IsActive); err != nil {
+			result.Skipped = append(result.Skipped, models.IntentImportSkip{Repository: repoName, Reason: err.Error()})
+			continue
+		}
+		result.Created++
+	}
+	return result, nil
+}
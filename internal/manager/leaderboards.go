@@ -0,0 +1,54 @@
+package manager
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// noteIngested counts persisted commits towards the next leaderboard
+// refresh and asks for one once a large ingest has gone by.
+func (svc *Service) noteIngested(commits int) {
+	select {
+	case svc.rollup <- struct{}{}:
+	default:
+	}
+
+	threshold := svc.cfg.LeaderboardRefreshCommits
+	if threshold <= 0 || svc.ingested.Add(int64(commits)) < threshold {
+		return
+	}
+	svc.ingested.Store(0)
+	select {
+	case svc.refresh <- struct{}{}:
+	default:
+	}
+}
+
+// RefreshLeaderboards refreshes the leaderboard views on the configured
+// interval and after large ingests until ctx is done.
+func (svc *Service) RefreshLeaderboards(ctx context.Context) {
+	interval := svc.cfg.LeaderboardRefreshInterval
+	if interval <= 0 {
+		interval = 10 * time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-svc.refresh:
+		case <-ctx.Done():
+			return
+		}
+
+		started := time.Now()
+		if err := svc.store.RefreshLeaderboards(ctx); err != nil {
+			log.Printf("failed to refresh leaderboards: %v", err)
+			continue
+		}
+		log.Printf("refreshed leaderboards in %s", time.Since(started))
+		svc.invalidateAll(ctx)
+	}
+}
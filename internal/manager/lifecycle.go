@@ -0,0 +1,75 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/noelukwa/indexer/internal/manager/models"
+	"github.com/noelukwa/indexer/internal/manager/repository"
+)
+
+// statusHistoryLimit caps the transitions kept per intent, since every
+// monitor run adds a few.
+const statusHistoryLimit = 100
+
+var ErrInvalidTransition = newError(ErrConflict, "invalid intent status transition")
+
+// transitionIntent moves intent to status, applying the rest of update in
+// the same write, and records the change in the intent's history.
+func (svc *Service) transitionIntent(ctx context.Context, intent *models.Intent, status models.IntentStatus, update models.IntentUpdate) (*models.Intent, error) {
+	if !intent.Status.CanTransitionTo(status) {
+		return nil, fmt.Errorf("%w: %s to %s", ErrInvalidTransition, intent.Status, status)
+	}
+
+	update.ID = intent.ID
+	update.Status = &status
+	if status == models.Paused && intent.Status != models.Paused && update.PausedAt == nil {
+		now := time.Now()
+		update.PausedAt = &now
+	}
+	updated, err := svc.store.UpdateIntent(ctx, update)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrIntentNotFound
+	}
+	if err != nil {
+		return nil, storeError(err)
+	}
+
+	if intent.Status != status {
+		svc.recordTransition(ctx, intent.ID, intent.Status, status)
+		svc.emit(ctx, models.IntentEvent{
+			Type:          models.StatusEvent,
+			IntentID:      intent.ID,
+			Status:        status,
+			SyncedCommits: updated.SyncedCommits,
+			Repository:    intent.RepositoryName,
+			Tenant:        intent.Tenant,
+			At:            time.Now(),
+		})
+	}
+	return updated, nil
+}
+
+// recordTransition saves a status change. The history only aids debugging,
+// so failing to save it does not fail the transition.
+func (svc *Service) recordTransition(ctx context.Context, id uuid.UUID, from, to models.IntentStatus) {
+	err := svc.store.SaveIntentTransition(ctx, models.IntentTransition{
+		IntentID:  id,
+		From:      from,
+		To:        to,
+		CreatedAt: time.Now(),
+	}, statusHistoryLimit)
+	if err != nil {
+		log.Printf("failed to record transition of intent %s from %q to %s: %v", id, from, to, err)
+	}
+}
+
+// GetIntentHistory returns the intent's most recent status transitions,
+// newest first.
+func (svc *Service) GetIntentHistory(ctx context.Context, id uuid.UUID) ([]models.IntentTransition, error) {
+	return svc.store.FindIntentTransitions(ctx, id)
+}
@@ -0,0 +1,21 @@
+package manager
+
+import (
+	"context"
+
+	"github.com/noelukwa/indexer/internal/pkg/repolocks"
+)
+
+var ErrLocksUnavailable error = newError(ErrUnavailable, "repository locks are unavailable: no redis configured")
+
+// LockSource reports the repository locks the monitors hold.
+type LockSource interface {
+	Report(ctx context.Context) (*repolocks.Report, error)
+}
+
+func (svc *Service) GetLocks(ctx context.Context) (*repolocks.Report, error) {
+	if svc.locks == nil {
+		return nil, ErrLocksUnavailable
+	}
+	return svc.locks.Report(ctx)
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CommitArchive is a Parquet object in the archive store holding Commits
+// of a repository authored between FirstCommitAt and LastCommitAt.
+type CommitArchive struct {
+	ID            uuid.UUID `json:"id"`
+	RepositoryID  int64     `json:"-"`
+	ObjectKey     string    `json:"object_key"`
+	Commits       int32     `json:"commits"`
+	FirstCommitAt time.Time `json:"first_commit_at"`
+	LastCommitAt  time.Time `json:"last_commit_at"`
+	SizeBytes     int64     `json:"size_bytes"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// ArchiveNotice is set on results read from the commits when some of the
+// repository's commits in their range have been archived, and so are
+// missing from them. Every commit authored before ArchivedBefore may
+// have been, Commits of them in all.
+type ArchiveNotice struct {
+	ArchivedBefore time.Time `json:"archived_before"`
+	Commits        int64     `json:"commits"`
+}
+
+// RepoArchive lists the archive objects of a repository.
+type RepoArchive struct {
+	Repository     string          `json:"repository"`
+	ArchivedBefore *time.Time      `json:"archived_before,omitempty"`
+	Commits        int64           `json:"commits"`
+	Objects        []CommitArchive `json:"objects"`
+}
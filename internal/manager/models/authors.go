@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// AuthorProfile is an author's activity across the indexed repositories.
+// The dates are nil when none of the author's commits are indexed anymore.
+type AuthorProfile struct {
+	Author        Author               `json:"author"`
+	TotalCommits  int64                `json:"total_commits"`
+	Repositories  int                  `json:"repositories"`
+	FirstCommitAt *time.Time           `json:"first_commit_at"`
+	LastCommitAt  *time.Time           `json:"last_commit_at"`
+	ByRepository  []AuthorRepoActivity `json:"by_repository"`
+}
+
+// AuthorRepoActivity is an author's share of one repository's commits.
+type AuthorRepoActivity struct {
+	Repository    string    `json:"repository"`
+	Commits       int64     `json:"commits"`
+	FirstCommitAt time.Time `json:"first_commit_at"`
+	LastCommitAt  time.Time `json:"last_commit_at"`
+}
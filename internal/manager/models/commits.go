@@ -1,30 +1,169 @@
 package models
 
 import (
-	"net/url"
 	"time"
 )
 
+// Repository is a GitHub repository's metadata. License is its SPDX
+// identifier, empty when GitHub detects none. Archived repositories are
+// read-only, so their intents are paused once they have been synced.
+// NetworkID is the root of a fork's network, zero for repositories that
+// are not forks.
 type Repository struct {
-	Watchers  int32     `json:"watchers_count"`
-	Stars     int32     `json:"stargazers_count"`
-	FullName  string    `json:"full_name"`
-	ID        int64     `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Language  string    `json:"language"`
-	Forks     int32     `json:"forks"`
+	Watchers      int32     `json:"watchers_count"`
+	Stars         int32     `json:"stargazers_count"`
+	FullName      string    `json:"full_name"`
+	ID            int64     `json:"id"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	Language      string    `json:"language"`
+	Forks         int32     `json:"forks"`
+	Topics        []string  `json:"topics"`
+	License       string    `json:"license,omitempty"`
+	DefaultBranch string    `json:"default_branch"`
+	Description   string    `json:"description,omitempty"`
+	Homepage      string    `json:"homepage,omitempty"`
+	OpenIssues    int32     `json:"open_issues_count"`
+	Archived      bool      `json:"archived"`
+	NetworkID     int64     `json:"network_id,omitempty"`
+	// CommitCount and LastCommitAt describe the indexed commits, not the
+	// repository on GitHub.
+	CommitCount  int64      `json:"commit_count"`
+	LastCommitAt *time.Time `json:"last_commit_at"`
+	// DeletedAt is set once the repository is deleted, until it is purged.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// RepositorySort is what a listing of repositories is ordered by.
+type RepositorySort string
+
+const (
+	SortByName         RepositorySort = "name"
+	SortByStars        RepositorySort = "stars"
+	SortByCommitCount  RepositorySort = "commit_count"
+	SortByLastCommitAt RepositorySort = "last_commit_at"
+)
+
+// RepositoryFilter selects and orders a listing of repositories. Topic
+// keeps those with it among their GitHub topics. Sort is SortByName when
+// empty.
+type RepositoryFilter struct {
+	Language   *string        `json:"language"`
+	Topic      *string        `json:"topic"`
+	Sort       RepositorySort `json:"sort"`
+	Descending bool           `json:"descending"`
 }
 
 type Commit struct {
-	Hash       string    `json:"hash"`
-	Author     Author    `json:"author"`
-	Message    string    `json:"message"`
-	Url        *url.URL  `json:"url"`
-	CreatedAt  time.Time `json:"created_at"`
+	Hash      string       `json:"hash"`
+	Author    Author       `json:"author"`
+	Message   string       `json:"message"`
+	Url       string       `json:"url,omitempty"`
+	CreatedAt time.Time    `json:"created_at"`
+	Stats     *CommitStats `json:"stats,omitempty"`
+	// Comments is only set on commits fetched with their comments.
+	Comments []CommitComment `json:"comments,omitempty"`
+	// Tags are set by the manager's commit hooks, such as the ticket IDs
+	// the message mentions.
+	Tags []string `json:"tags,omitempty"`
+	// FetchedAt is when the monitor fetched the commit. It is only set
+	// on commits on their way to the manager.
+	FetchedAt  *time.Time `json:"fetched_at,omitempty"`
 	Repository Repository
 }
 
+// CommitComment is a comment left on a commit on GitHub. Author is the
+// commenter's username.
+type CommitComment struct {
+	ID        int64     `json:"id"`
+	Author    string    `json:"author"`
+	Body      string    `json:"body"`
+	Url       string    `json:"url,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CommitStats holds the line counts of a commit. It is nil for commits
+// indexed without fetching their details.
+type CommitStats struct {
+	Additions int32 `json:"additions"`
+	Deletions int32 `json:"deletions"`
+	Changes   int32 `json:"changes"`
+}
+
+// Churn sums the line changes of the commits matching a CommitsFilter.
+type Churn struct {
+	Commits   int64 `json:"commits"`
+	Additions int64 `json:"additions"`
+	Deletions int64 `json:"deletions"`
+	Changes   int64 `json:"changes"`
+}
+
+// CommitsNotice announces a batch of commits persisted for a repository to
+// every manager replica. Commits counts the batch, including commits that
+// were already indexed.
+type CommitsNotice struct {
+	Repository string    `json:"repository"`
+	Commits    int       `json:"commits"`
+	At         time.Time `json:"at"`
+}
+
+// DailyCommits sums a repository's commits on a UTC day, formatted as
+// YYYY-MM-DD.
+type DailyCommits struct {
+	Day       string `json:"day"`
+	Commits   int64  `json:"commits"`
+	Additions int64  `json:"additions"`
+	Deletions int64  `json:"deletions"`
+}
+
+// ActivityInterval is the width of the buckets of a commit activity
+// histogram. Weeks start on Monday.
+type ActivityInterval string
+
+const (
+	ActivityDay   ActivityInterval = "day"
+	ActivityWeek  ActivityInterval = "week"
+	ActivityMonth ActivityInterval = "month"
+)
+
+// ActivityBucket counts a repository's commits in the UTC day, week or
+// month starting on Start, formatted as YYYY-MM-DD.
+type ActivityBucket struct {
+	Start   string `json:"start"`
+	Commits int64  `json:"commits"`
+}
+
+// MonthlyContribution counts an author's commits in the month starting
+// on Month.
+type MonthlyContribution struct {
+	Author  Author
+	Month   time.Time
+	Commits int64
+}
+
+// ContributionMatrix counts each author's commits per month. Months are
+// formatted as YYYY-MM, and each author's Commits line up with them.
+type ContributionMatrix struct {
+	Months  []string              `json:"months"`
+	Authors []AuthorContributions `json:"authors"`
+}
+
+// AuthorContributions is an author's row of a ContributionMatrix.
+type AuthorContributions struct {
+	Author  Author  `json:"author"`
+	Commits []int64 `json:"commits"`
+	Total   int64   `json:"total"`
+}
+
+// RepoStats summarizes a repository's commits from the daily rollup.
+type RepoStats struct {
+	Commits    int64 `json:"commits"`
+	Additions  int64 `json:"additions"`
+	Deletions  int64 `json:"deletions"`
+	Authors    int64 `json:"authors"`
+	ActiveDays int64 `json:"active_days"`
+}
+
 type Author struct {
 	Name     string `json:"name"`
 	Email    string `json:"email"`
@@ -42,11 +181,48 @@ type CommitPage struct {
 	TotalCount int64
 	Page       int32
 	PerPage    int32
+	// Archived is set when commits in the range have been archived.
+	Archived *ArchiveNotice
+}
+
+// CommitCursor is a position in a repository's commits ordered by
+// creation time, then hash.
+type CommitCursor struct {
+	CreatedAt time.Time
+	Hash      string
+}
+
+// CommitDeltaQuery picks where a delta of a repository's commits starts:
+// after the position Cursor encodes, after the commit SinceSHA, or at
+// Since. At most one is set, and without any the delta starts at the
+// repository's first commit.
+type CommitDeltaQuery struct {
+	Cursor   string
+	SinceSHA string
+	Since    time.Time
+	Limit    int
+}
+
+// CommitDelta is the next commits of a repository, the oldest first.
+// NextCursor continues after the last of them, or from the same position
+// when there are none yet, and HasMore is set when more are already
+// indexed.
+type CommitDelta struct {
+	Data       []Commit `json:"data"`
+	NextCursor string   `json:"next_cursor"`
+	HasMore    bool     `json:"has_more"`
 }
 
+// CommitsFilter selects a repository's commits. Dedupe leaves out the
+// commits the repository shares with an older repository in its fork
+// network, so each SHA in the network counts once.
 type CommitsFilter struct {
 	RepositoryName string
 	StartDate      *time.Time
 	EndDate        *time.Time
 	AuthorUsername *string
+	// Message, when set, keeps the commits whose message contains it,
+	// ignoring case. Only FindCommits supports it.
+	Message *string
+	Dedupe  bool
 }
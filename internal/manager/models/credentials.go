@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Credential is a GitHub token intents can index under instead of the
+// monitor's own. It is stored sealed and the token is never returned.
+type Credential struct {
+	ID         uuid.UUID `json:"id"`
+	Name       string    `json:"name"`
+	Ciphertext []byte    `json:"-"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DigestSubscription emails the weekly digest of a repository, or of every
+// repository with Label among its GitHub topics, to Email. Exactly one of
+// Repository and Label is set. LastWeek is the Monday of the last week
+// sent.
+type DigestSubscription struct {
+	ID         uuid.UUID  `json:"id"`
+	Email      string     `json:"email"`
+	Repository string     `json:"repository,omitempty"`
+	Label      string     `json:"label,omitempty"`
+	LastWeek   *time.Time `json:"last_week,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// RepoDigest summarizes a repository's commits over the week from the
+// Monday WeekStart to the Sunday WeekEnd.
+type RepoDigest struct {
+	Repository     string         `json:"repository"`
+	WeekStart      string         `json:"week_start"`
+	WeekEnd        string         `json:"week_end"`
+	Commits        int64          `json:"commits"`
+	Additions      int64          `json:"additions"`
+	Deletions      int64          `json:"deletions"`
+	Authors        int64          `json:"authors"`
+	TopCommitters  []AuthorStats  `json:"top_committers"`
+	LargestCommits []DigestCommit `json:"largest_commits"`
+}
+
+// DigestCommit is one of a digest's largest changes. Title is the first
+// line of its message.
+type DigestCommit struct {
+	Hash      string    `json:"hash"`
+	Title     string    `json:"title"`
+	Author    Author    `json:"author"`
+	Url       string    `json:"url,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	Additions int32     `json:"additions"`
+	Deletions int32     `json:"deletions"`
+}
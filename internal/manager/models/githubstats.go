@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// GitHubStats are the aggregates GitHub precomputes for a repository, as
+// last fetched. A kind GitHub was still computing when last asked is kept
+// from the fetch before, or left empty.
+type GitHubStats struct {
+	Repository    string               `json:"repository"`
+	CodeFrequency []WeeklyCodeChanges  `json:"code_frequency"`
+	Participation *WeeklyParticipation `json:"participation"`
+	PunchCard     []PunchCardHour      `json:"punch_card"`
+	FetchedAt     time.Time            `json:"fetched_at"`
+}
+
+// WeeklyCodeChanges sums the lines added and deleted in a week starting
+// on Sunday. Deletions are negative, as GitHub reports them.
+type WeeklyCodeChanges struct {
+	Week      time.Time `json:"week"`
+	Additions int64     `json:"additions"`
+	Deletions int64     `json:"deletions"`
+}
+
+// WeeklyParticipation counts the commits of the last 52 weeks, the oldest
+// first, by everyone and by the repository's owner.
+type WeeklyParticipation struct {
+	All   []int `json:"all"`
+	Owner []int `json:"owner"`
+}
+
+// PunchCardHour counts the commits made in an hour of a day of the week,
+// with Day 0 as Sunday.
+type PunchCardHour struct {
+	Day     int `json:"day"`
+	Hour    int `json:"hour"`
+	Commits int `json:"commits"`
+}
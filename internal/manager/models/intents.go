@@ -4,41 +4,283 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/noelukwa/indexer/internal/pkg/retry"
 )
 
+// IntentStatus is where an intent is in its lifecycle. A new intent is
+// Created, then Broadcast to discovery. Each monitor run moves it through
+// Fetching and Ingesting to Completed or Failed, and the next run starts
+// over from there. A rebroadcast intent is PendingBroadcast until the
+// manager has published it again. Deactivating an intent Pauses it.
 type IntentStatus string
 
 const (
-	PendingBroadCast IntentStatus = "pending_broadcast"
-	SuccessBroadCast IntentStatus = "success_broadcast"
+	Created          IntentStatus = "created"
+	PendingBroadcast IntentStatus = "pending_broadcast"
+	Broadcast        IntentStatus = "broadcast"
+	Fetching         IntentStatus = "fetching"
+	Ingesting        IntentStatus = "ingesting"
+	Completed        IntentStatus = "completed"
+	Failed           IntentStatus = "failed"
+	Paused           IntentStatus = "paused"
 )
 
+var intentTransitions = map[IntentStatus][]IntentStatus{
+	Created:          {PendingBroadcast, Broadcast, Fetching, Paused},
+	PendingBroadcast: {Broadcast, Fetching, Paused},
+	Broadcast:        {PendingBroadcast, Fetching, Paused},
+	Fetching:         {Ingesting, Completed, Failed, PendingBroadcast, Broadcast, Paused},
+	Ingesting:        {Completed, Failed, PendingBroadcast, Broadcast, Paused},
+	Completed:        {Fetching, PendingBroadcast, Broadcast, Paused},
+	Failed:           {Fetching, PendingBroadcast, Broadcast, Paused},
+	Paused:           {Created},
+}
+
+// CanTransitionTo reports whether an intent in status s may move to next.
+// Staying in the same status is always allowed.
+func (s IntentStatus) CanTransitionTo(next IntentStatus) bool {
+	if s == next {
+		return true
+	}
+	for _, allowed := range intentTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+type IntentEventType string
+
+const (
+	StatusEvent   IntentEventType = "status"
+	ProgressEvent IntentEventType = "progress"
+	ErrorEvent    IntentEventType = "error"
+	CommitsEvent  IntentEventType = "commits"
+	RenameEvent   IntentEventType = "rename"
+)
+
+// IntentEvent is a change to an intent streamed to the clients watching it.
+// Error is only set on error events and Commits, the size of a persisted
+// batch, only on commits events. Repository is the intent's repository on
+// status events and its new name on rename events, where RenamedFrom is
+// the old one.
+type IntentEvent struct {
+	Type          IntentEventType `json:"type"`
+	IntentID      uuid.UUID       `json:"intent_id"`
+	Status        IntentStatus    `json:"status"`
+	SyncedCommits int64           `json:"synced_commits"`
+	Error         string          `json:"error,omitempty"`
+	Commits       int             `json:"commits,omitempty"`
+	Repository    string          `json:"repository,omitempty"`
+	RenamedFrom   string          `json:"renamed_from,omitempty"`
+	// Tenant is set on status events, which go to the watchers of every
+	// intent in the tenant.
+	Tenant string    `json:"tenant,omitempty"`
+	At     time.Time `json:"at"`
+}
+
+// IntentTransition records a status change for debugging. From is empty
+// for the intent's initial status.
+type IntentTransition struct {
+	IntentID  uuid.UUID    `json:"intent_id"`
+	From      IntentStatus `json:"from,omitempty"`
+	To        IntentStatus `json:"to"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+// IntentDeletion is a deleted intent and how many of its repository's
+// commits were purged with it.
+type IntentDeletion struct {
+	Intent        *Intent `json:"intent"`
+	PurgedCommits int64   `json:"purged_commits"`
+}
+
+// Intent asks for a repository to be indexed. A nil StartDate indexes the
+// full history and a nil Until keeps indexing new commits. SyncedCommits
+// counts the commits fetched by the latest monitor run, which started at
+// SyncStartedAt; LastSyncedAt is when a run last completed. DeletedAt is
+// only set on the intent returned by its deletion, and PausedAt while the
+// intent is paused. An intent for owner/* is an org intent, which indexes
+// each of the owner's repositories through a child intent whose ParentID
+// is its ID.
 type Intent struct {
 	RepositoryName string       `json:"repository_name"`
-	StartDate      time.Time    `json:"start_date"`
-	Until          time.Time    `json:"end_date"`
+	StartDate      *time.Time   `json:"start_date"`
+	Until          *time.Time   `json:"end_date"`
 	Status         IntentStatus `json:"status"`
 	IsActive       bool         `json:"is_active"`
 	Error          *IntentError `json:"error,omitempty"`
 	ID             uuid.UUID    `json:"id"`
+	SyncStartedAt  *time.Time   `json:"sync_started_at"`
+	LastSyncedAt   *time.Time   `json:"last_synced_at"`
+	SyncedCommits  int64        `json:"synced_commits"`
+	DeletedAt      *time.Time   `json:"deleted_at,omitempty"`
+	ParentID       *uuid.UUID   `json:"parent_id,omitempty"`
+	PausedAt       *time.Time   `json:"paused_at,omitempty"`
+	Tenant         string       `json:"tenant"`
+	// Progress is how far the latest run has got. It is only set on a
+	// single intent, and not before its first run.
+	Progress *IntentProgress `json:"progress,omitempty"`
+	IntentOptions
+}
+
+// IntentProgress is how far the latest monitor run of an intent has got.
+// Runs fetch the newest commits first, so LastCommitDate, the date of the
+// oldest commit fetched, moves back toward the intent's start date.
+// PercentComplete estimates how much of the intent's date range, or of its
+// MaxCommits, the run has covered; it is nil when it can't be estimated.
+type IntentProgress struct {
+	Commits         int64      `json:"commits"`
+	Pages           int64      `json:"pages"`
+	LastCommitDate  *time.Time `json:"last_commit_date"`
+	StartedAt       time.Time  `json:"started_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	PercentComplete *float64   `json:"percent_complete"`
+}
+
+// IntentOptions tunes how the monitor indexes a repository. Unset values
+// fall back to the monitor's configuration.
+type IntentOptions struct {
+	MaxConcurrentPages *int32 `json:"max_concurrent_pages,omitempty"`
+	RequestsPerMinute  *int32 `json:"requests_per_minute,omitempty"`
+	// IndexAllBranches makes the monitor walk every branch instead of only
+	// the default one.
+	IndexAllBranches bool `json:"index_all_branches,omitempty"`
+	// PathFilters limits indexing to commits touching one of these path
+	// prefixes.
+	PathFilters []string `json:"path_filters,omitempty"`
+	// AuthorFilters limits indexing to commits by one of these GitHub logins
+	// or email addresses.
+	AuthorFilters []string `json:"author_filters,omitempty"`
+	// Branch makes the monitor walk only this branch instead of the default
+	// one.
+	Branch string `json:"branch,omitempty"`
+	// ExcludedAuthors leaves out the commits by any of these GitHub logins
+	// or email addresses.
+	ExcludedAuthors []string `json:"excluded_authors,omitempty"`
+	// MaxCommits, when set, indexes only the most recent commits up to this
+	// count regardless of the start date.
+	MaxCommits *int32 `json:"max_commits,omitempty"`
+	// CredentialID names a stored Credential to fetch with in place of the
+	// monitor's GitHub token.
+	CredentialID *uuid.UUID `json:"credential_id,omitempty"`
+	// Retry overrides how the intent's failed GitHub requests and writes
+	// are retried.
+	Retry *RetryPolicy `json:"retry,omitempty"`
+}
+
+// RetryPolicy is an intent's override of the retry configuration. Unset
+// fields keep the service's default. Jitter is a fraction between 0 and 1.
+type RetryPolicy struct {
+	MaxAttempts   *int32   `json:"max_attempts,omitempty"`
+	BackoffBaseMs *int32   `json:"backoff_base_ms,omitempty"`
+	Jitter        *float64 `json:"jitter,omitempty"`
+}
+
+// Apply overrides def with the fields set on p, which may be nil.
+func (p *RetryPolicy) Apply(def retry.Policy) retry.Policy {
+	if p == nil {
+		return def
+	}
+	if p.MaxAttempts != nil {
+		def.MaxAttempts = int(*p.MaxAttempts)
+	}
+	if p.BackoffBaseMs != nil {
+		def.BackoffBase = time.Duration(*p.BackoffBaseMs) * time.Millisecond
+	}
+	if p.Jitter != nil {
+		def.Jitter = *p.Jitter
+	}
+	return def
+}
+
+// IntentExportVersion is the version of the intent export format.
+const IntentExportVersion = 1
+
+// IntentExport is a backup of the intents' definitions, one per
+// repository, without their sync state or commit data.
+type IntentExport struct {
+	Version    int                `json:"version"`
+	ExportedAt time.Time          `json:"exported_at"`
+	Intents    []IntentDefinition `json:"intents"`
+}
+
+// IntentDefinition is what recreates an intent. Credentials are not
+// exported, as their key belongs to the deployment.
+type IntentDefinition struct {
+	Repository string     `json:"repository"`
+	StartDate  *time.Time `json:"start_date,omitempty"`
+	Until      *time.Time `json:"end_date,omitempty"`
+	IsActive   bool       `json:"is_active"`
+	IntentOptions
+}
+
+// IntentImport reports on an import: the number of intents created, and
+// the repositories skipped with the reason.
+type IntentImport struct {
+	Created int                `json:"created"`
+	Skipped []IntentImportSkip `json:"skipped"`
+}
+
+type IntentImportSkip struct {
+	Repository string `json:"repository"`
+	Reason     string `json:"reason"`
+}
+
+// InstallationChange reports on the intents a GitHub App installation
+// event changed: the repositories given an intent, those whose intents
+// were paused, and those skipped with the reason.
+type InstallationChange struct {
+	Created []string           `json:"created"`
+	Paused  []string           `json:"paused"`
+	Skipped []IntentImportSkip `json:"skipped"`
 }
 
 type IntentUpdate struct {
-	ID        uuid.UUID
-	Status    *IntentStatus `json:"status"`
-	IsActive  *bool         `json:"is_active"`
-	StartDate *time.Time    `json:"start_date"`
+	ID            uuid.UUID
+	Status        *IntentStatus `json:"status"`
+	IsActive      *bool         `json:"is_active"`
+	StartDate     *time.Time    `json:"start_date"`
+	Until         *time.Time    `json:"end_date"`
+	SyncStartedAt *time.Time    `json:"sync_started_at"`
+	LastSyncedAt  *time.Time    `json:"last_synced_at"`
+	SyncedCommits *int64        `json:"synced_commits"`
+	// FullHistory clears the start date and OpenEnded the end date.
+	FullHistory bool `json:"full_history"`
+	OpenEnded   bool `json:"open_ended"`
+	// PausedAt is saved when the intent is paused. Activating the intent
+	// clears it.
+	PausedAt *time.Time `json:"paused_at"`
 }
 
 type IntentError struct {
-	ID        uuid.UUID `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	Message   string    `json:"message"`
-	IntentID  uuid.UUID
+	ID             uuid.UUID `json:"id"`
+	CreatedAt      time.Time `json:"created_at"`
+	Message        string    `json:"message"`
+	IntentID       uuid.UUID `json:"intent_id"`
+	RepositoryName string    `json:"repository_name,omitempty"`
 }
 
 type IntentFilter struct {
 	Status         *IntentStatus `json:"status"`
 	IsActive       *bool         `json:"is_active"`
 	RepositoryName *string       `json:"repository_name"`
+	ParentID       *uuid.UUID    `json:"parent_id"`
+}
+
+// IngestionStatus is a point-in-time summary of the pipeline used by
+// operator tooling.
+type IngestionStatus struct {
+	Intents      map[IntentStatus]int64 `json:"intents"`
+	TotalCommits int64                  `json:"total_commits"`
+	RecentErrors []IntentError          `json:"recent_errors"`
+	// QueryCache counts query cache hits and misses by query since the
+	// manager started, when the cache is on.
+	QueryCache map[string]CacheStats `json:"query_cache,omitempty"`
+}
+
+type CacheStats struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
 }
@@ -0,0 +1,31 @@
+package models
+
+// PipelineStats reports how commits have moved through the ingestion
+// pipeline since the manager started: fetched by a monitor, received by
+// the manager and persisted. ThrottledSeconds is how long received batches
+// waited for the ingestion throttle.
+type PipelineStats struct {
+	Stages           []PipelineStage   `json:"stages"`
+	Latency          []PipelineLatency `json:"latency"`
+	ThrottledSeconds float64           `json:"throttled_seconds"`
+}
+
+// PipelineStage counts the commits through a stage. PerSecond is the rate
+// over the last minute.
+type PipelineStage struct {
+	Stage     string  `json:"stage"`
+	Commits   int64   `json:"commits"`
+	PerSecond float64 `json:"per_second"`
+}
+
+// PipelineLatency summarizes the time commits took between two stages,
+// in seconds. Percentiles are the upper bounds of the histogram buckets
+// they fall in.
+type PipelineLatency struct {
+	Span          string  `json:"span"`
+	Commits       int64   `json:"commits"`
+	MeanSeconds   float64 `json:"mean_seconds"`
+	MedianSeconds float64 `json:"median_seconds"`
+	P90Seconds    float64 `json:"p90_seconds"`
+	MaxSeconds    float64 `json:"max_seconds"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// QualityReport describes how far a repository's indexed commits can be
+// trusted. Unattributed commits have no GitHub account behind their
+// author, and bot commits are those of an author named like
+// dependabot[bot]. DuplicateHashes counts the commits also indexed under
+// another repository, such as a fork and its upstream. LastReconciledAt
+// is when a reindex last replaced the commits.
+type QualityReport struct {
+	Commits             int64      `json:"commits"`
+	UnattributedCommits int64      `json:"unattributed_commits"`
+	BotCommits          int64      `json:"bot_commits"`
+	BotPercentage       float64    `json:"bot_percentage"`
+	DuplicateHashes     int64      `json:"duplicate_hashes"`
+	FirstCommitAt       *time.Time `json:"first_commit_at"`
+	LastCommitAt        *time.Time `json:"last_commit_at"`
+	LastReconciledAt    *time.Time `json:"last_reconciled_at"`
+	// Coverage is nil when no intent indexes the repository.
+	Coverage *Coverage `json:"coverage"`
+}
+
+// Coverage compares the indexed commits with the date range the
+// repository's intents ask for. Gaps are the runs of at least MinGapDays
+// days in that range without a single commit.
+type Coverage struct {
+	Since      string        `json:"since"`
+	Until      string        `json:"until"`
+	MinGapDays int           `json:"min_gap_days"`
+	Gaps       []CoverageGap `json:"gaps"`
+}
+
+// CoverageGap is a run of days, both inclusive, without indexed commits.
+type CoverageGap struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+	Days  int    `json:"days"`
+}
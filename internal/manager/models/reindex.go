@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReindexStatus is where a reindex is. A reindex is Building while its run
+// fetches the repository's commits into the shadow table, then either
+// Swapped in for the live commits or Failed, when the run failed or the
+// shadow didn't check out. Starting another reindex of the repository
+// Aborts one still building.
+type ReindexStatus string
+
+const (
+	ReindexBuilding ReindexStatus = "building"
+	ReindexSwapped  ReindexStatus = "swapped"
+	ReindexFailed   ReindexStatus = "failed"
+	ReindexAborted  ReindexStatus = "aborted"
+)
+
+// Reindex is a rebuild of a repository's commits by one of its intents.
+// ExpectedCommits is set once the run has fetched them all, and the
+// reindex swaps in as soon as they have all reached the shadow table.
+type Reindex struct {
+	ID              uuid.UUID     `json:"id"`
+	RepositoryID    int64         `json:"repository_id"`
+	IntentID        uuid.UUID     `json:"intent_id"`
+	Status          ReindexStatus `json:"status"`
+	ExpectedCommits *int64        `json:"expected_commits,omitempty"`
+	Error           string        `json:"error,omitempty"`
+	CreatedAt       time.Time     `json:"created_at"`
+	FinishedAt      *time.Time    `json:"finished_at,omitempty"`
+}
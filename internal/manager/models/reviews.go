@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// PullRequestReview is a review submitted on a pull request. Reviewer and
+// PullRequestAuthor are usernames. State is GitHub's, such as APPROVED,
+// CHANGES_REQUESTED or COMMENTED.
+type PullRequestReview struct {
+	ID                   int64     `json:"id"`
+	Repository           string    `json:"repository"`
+	PullRequest          int32     `json:"pull_request"`
+	PullRequestAuthor    string    `json:"pull_request_author"`
+	PullRequestCreatedAt time.Time `json:"pull_request_created_at"`
+	Reviewer             string    `json:"reviewer"`
+	State                string    `json:"state"`
+	SubmittedAt          time.Time `json:"submitted_at"`
+}
+
+// ReviewTurnaround describes how long the pull requests opened in a range
+// waited for their first review by someone other than their author, in
+// seconds. Pull requests still waiting are left out.
+type ReviewTurnaround struct {
+	PullRequests  int64   `json:"pull_requests"`
+	MedianSeconds float64 `json:"median_seconds"`
+	P90Seconds    float64 `json:"p90_seconds"`
+	MeanSeconds   float64 `json:"mean_seconds"`
+}
+
+// ReviewerStats counts a reviewer's reviews in a repository. Approvals and
+// ChangesRequested count the reviews in those states, and PullRequests the
+// distinct pull requests reviewed.
+type ReviewerStats struct {
+	Reviewer         string `json:"reviewer"`
+	Reviews          int64  `json:"reviews"`
+	Approvals        int64  `json:"approvals"`
+	ChangesRequested int64  `json:"changes_requested"`
+	PullRequests     int64  `json:"pull_requests"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// SearchResults groups the matches of a search by type. Every group is
+// paginated on its own, with the same page and page size.
+type SearchResults struct {
+	Query        string                   `json:"query"`
+	Repositories SearchGroup[Repository]  `json:"repositories"`
+	Authors      SearchGroup[Author]      `json:"authors"`
+	Commits      SearchGroup[CommitMatch] `json:"commits"`
+	Page         int                      `json:"page"`
+	PerPage      int                      `json:"per_page"`
+	// PerPageCapped is set when the requested per_page was over the
+	// maximum.
+	PerPageCapped bool `json:"per_page_capped,omitempty"`
+}
+
+// SearchGroup is a page of one type of search match and the number of
+// matches of that type.
+type SearchGroup[T any] struct {
+	Data       []T   `json:"data"`
+	TotalCount int64 `json:"total_count"`
+}
+
+// CommitMatch is a commit whose message matches a search.
+type CommitMatch struct {
+	Hash       string    `json:"hash"`
+	Message    string    `json:"message"`
+	Url        string    `json:"url,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	Repository string    `json:"repository"`
+	Author     Author    `json:"author"`
+	// Rank is how well the message matches a full-text search, the best
+	// matches first. Substring matches are not ranked.
+	Rank float32 `json:"rank,omitempty"`
+}
+
+// CommitSearchFilter narrows a full-text search of commit messages to a
+// repository and a date range.
+type CommitSearchFilter struct {
+	Query          string
+	RepositoryName *string
+	StartDate      *time.Time
+	EndDate        *time.Time
+}
@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// SecurityAlert is a Dependabot alert on a repository's dependency. State
+// is GitHub's (open, dismissed, fixed or auto_dismissed), and Severity its
+// advisory's (critical, high, medium or low).
+type SecurityAlert struct {
+	Number       int32      `json:"number"`
+	Repository   string     `json:"repository"`
+	State        string     `json:"state"`
+	Severity     string     `json:"severity"`
+	Package      string     `json:"package"`
+	Ecosystem    string     `json:"ecosystem"`
+	ManifestPath string     `json:"manifest_path"`
+	AdvisoryID   string     `json:"advisory_id"`
+	Summary      string     `json:"summary"`
+	Url          string     `json:"url,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	FixedAt      *time.Time `json:"fixed_at,omitempty"`
+	DismissedAt  *time.Time `json:"dismissed_at,omitempty"`
+}
+
+// SecurityAlertCounts counts open alerts by severity.
+type SecurityAlertCounts struct {
+	Critical int64 `json:"critical"`
+	High     int64 `json:"high"`
+	Medium   int64 `json:"medium"`
+	Low      int64 `json:"low"`
+	Total    int64 `json:"total"`
+}
+
+// RepositorySecurityAlerts counts a repository's open alerts.
+type RepositorySecurityAlerts struct {
+	Repository string `json:"repository"`
+	SecurityAlertCounts
+}
+
+// SecurityAlertSummary counts the open alerts across the indexed
+// repositories, listing the repositories that have any, the most severe
+// first.
+type SecurityAlertSummary struct {
+	Open         SecurityAlertCounts        `json:"open"`
+	Repositories []RepositorySecurityAlerts `json:"repositories"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+type Role string
+
+const (
+	ViewerRole Role = "viewer"
+	AdminRole  Role = "admin"
+)
+
+// Allows reports whether r grants at least the access of required.
+func (r Role) Allows(required Role) bool {
+	return roleRank[r] >= roleRank[required]
+}
+
+func (r Role) Valid() bool {
+	_, ok := roleRank[r]
+	return ok
+}
+
+var roleRank = map[Role]int{
+	ViewerRole: 1,
+	AdminRole:  2,
+}
+
+type Session struct {
+	Token     string    `json:"token,omitempty"`
+	Username  string    `json:"username"`
+	Role      Role      `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	// Tenant is the tenant of the API key the session is for, if any.
+	Tenant string `json:"tenant,omitempty"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// LanguageStats totals the indexed repositories of a language and their
+// commits. Repositories GitHub detected no language for are grouped under
+// an empty Language.
+type LanguageStats struct {
+	Language     string `json:"language"`
+	Repositories int64  `json:"repositories"`
+	Commits      int64  `json:"commits"`
+}
+
+// RepoActivity is a repository's commits over a window of days, with the
+// lines they changed and how many authors made them.
+type RepoActivity struct {
+	Repository string `json:"repository"`
+	Language   string `json:"language"`
+	Commits    int64  `json:"commits"`
+	Additions  int64  `json:"additions"`
+	Deletions  int64  `json:"deletions"`
+	Authors    int64  `json:"authors"`
+}
+
+// RepoActivityFilter selects the days, both included, to rank repositories
+// by their commits over, and optionally the language of the repositories.
+type RepoActivityFilter struct {
+	StartDate time.Time
+	EndDate   time.Time
+	Language  *string
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// WorkflowRun is a completed GitHub Actions run. Conclusion is GitHub's,
+// such as success, failure or cancelled, and HeadSHA the commit it ran on.
+type WorkflowRun struct {
+	ID          int64     `json:"id"`
+	Repository  string    `json:"repository"`
+	Workflow    string    `json:"workflow"`
+	HeadSHA     string    `json:"head_sha"`
+	Branch      string    `json:"branch"`
+	Event       string    `json:"event"`
+	Conclusion  string    `json:"conclusion"`
+	StartedAt   time.Time `json:"started_at"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+// CIStats summarizes a repository's workflow runs. Runs leaves out those
+// cancelled or skipped, which didn't pass or fail, and PassRate is the
+// share of Runs that passed. Durations are in seconds.
+type CIStats struct {
+	Runs          int64   `json:"runs"`
+	Passed        int64   `json:"passed"`
+	PassRate      float64 `json:"pass_rate"`
+	MedianSeconds float64 `json:"median_seconds"`
+	P90Seconds    float64 `json:"p90_seconds"`
+}
+
+// CIFilter selects the workflow runs of a repository started between two
+// inclusive dates, either of which may be zero. Workflow narrows them to
+// one workflow by name.
+type CIFilter struct {
+	RepositoryID int64
+	StartDate    time.Time
+	EndDate      time.Time
+	Workflow     string
+}
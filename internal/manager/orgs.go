@@ -0,0 +1,70 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/noelukwa/indexer/internal/events"
+	"github.com/noelukwa/indexer/internal/manager/repository"
+)
+
+// expandOrgIntent creates an intent for each of the org intent's
+// repositories it hasn't created one for yet, with the org intent's dates
+// and options. Repositories that already have an active intent of their
+// own are left to it, and paused or deleted org intents create none.
+func (svc *Service) expandOrgIntent(ctx context.Context, orgRepos *events.OrgRepos) error {
+	parent, err := svc.findIntent(ctx, orgRepos.IntentID)
+	if errors.Is(err, ErrIntentNotFound) {
+		log.Printf("org intent %s is gone, not expanding it", orgRepos.IntentID)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !parent.IsActive {
+		return nil
+	}
+	// The org intent's repositories are indexed for its tenant.
+	if parent.Tenant != "" {
+		ctx = repository.WithTenant(ctx, parent.Tenant)
+	}
+
+	known, err := svc.store.FindChildIntentRepos(ctx, parent.ID)
+	if err != nil {
+		return fmt.Errorf("failed to find child intents: %w", err)
+	}
+	created := make(map[string]bool, len(known))
+	for _, name := range known {
+		created[name] = true
+	}
+
+	var startDate, until time.Time
+	if parent.StartDate != nil {
+		startDate = *parent.StartDate
+	}
+	if parent.Until != nil {
+		until = *parent.Until
+	}
+	owner := strings.TrimSuffix(parent.RepositoryName, "*")
+
+	for _, repo := range orgRepos.Repos {
+		name := normalizeRepositoryName(repo)
+		if created[name] || !strings.HasPrefix(name, owner) {
+			continue
+		}
+		_, err := svc.createChildIntent(ctx, &parent.ID, name, startDate, until, parent.IntentOptions, true)
+		if errors.Is(err, ErrExistingIntent) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create an intent for %s: %w", name, err)
+		}
+		created[name] = true
+		log.Printf("org intent %s created an intent for %s", parent.ID, name)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/noelukwa/indexer/internal/events"
+	"github.com/noelukwa/indexer/internal/manager/models"
+	"github.com/noelukwa/indexer/internal/manager/repository"
+)
+
+// PauseIntent stops indexing the intent wherever its run is, recording
+// when, and has discovery stop scheduling it. Pausing a paused intent
+// leaves it as it is.
+func (svc *Service) PauseIntent(ctx context.Context, id uuid.UUID) (*models.Intent, error) {
+	intent, err := svc.findIntent(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !intent.IsActive {
+		return intent, nil
+	}
+	return svc.pauseIntent(ctx, intent)
+}
+
+func (svc *Service) pauseIntent(ctx context.Context, intent *models.Intent) (*models.Intent, error) {
+	active := false
+	update, err := svc.transitionIntent(ctx, intent, models.Paused, models.IntentUpdate{
+		IsActive: &active,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to pause intent: %w", err)
+	}
+	if err := svc.queueIntent(ctx, events.CancelIntentKind, newIntentPayload(update)); err != nil {
+		return nil, err
+	}
+	return update, nil
+}
+
+// ResumeIntent starts indexing a paused intent again from the last commit
+// indexed for its repository. Resuming an active intent leaves it as it
+// is.
+func (svc *Service) ResumeIntent(ctx context.Context, id uuid.UUID) (*models.Intent, error) {
+	intent, err := svc.findIntent(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if intent.IsActive {
+		return intent, nil
+	}
+	return svc.resumeIntent(ctx, intent)
+}
+
+func (svc *Service) resumeIntent(ctx context.Context, intent *models.Intent) (*models.Intent, error) {
+	from, err := svc.resumeFrom(ctx, intent)
+	if err != nil {
+		return nil, err
+	}
+
+	active := true
+	update, err := svc.transitionIntent(ctx, intent, models.Created, models.IntentUpdate{
+		IsActive: &active,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to resume intent: %w", err)
+	}
+
+	payload, err := svc.intentPayload(ctx, update)
+	if err != nil {
+		return nil, err
+	}
+	if !from.IsZero() {
+		payload.From = from
+	}
+	if err := svc.queueIntent(ctx, events.NewIntentKind, payload); err != nil {
+		return nil, err
+	}
+	return update, nil
+}
+
+// resumeFrom returns the date of the newest commit indexed for the
+// intent's repository, where a resumed intent picks up instead of its
+// start date, or zero to keep the start date. Only intents with a
+// completed run have their history up to that commit indexed: runs walk
+// back from the newest commit, so a backfill paused halfway resumes from
+// its start date, where the monitor's checkpoint takes it on from the page
+// it had reached.
+func (svc *Service) resumeFrom(ctx context.Context, intent *models.Intent) (time.Time, error) {
+	if intent.LastSyncedAt == nil {
+		return time.Time{}, nil
+	}
+	newest, err := svc.store.FindCommits(ctx, models.CommitsFilter{
+		RepositoryName: intent.RepositoryName,
+	}, repository.Pagination{Page: 1, PerPage: 1})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to find the newest commit: %w", err)
+	}
+	if len(newest.Data) == 0 {
+		return time.Time{}, nil
+	}
+	from := newest.Data[0].CreatedAt
+	if intent.StartDate != nil && !from.After(*intent.StartDate) {
+		return time.Time{}, nil
+	}
+	if intent.Until != nil && from.After(*intent.Until) {
+		return time.Time{}, nil
+	}
+	return from, nil
+}
@@ -0,0 +1,234 @@
+package manager
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/noelukwa/indexer/internal/manager/models"
+)
+
+// Pipeline stages, in the order commits pass them.
+const (
+	stageFetched = iota
+	stageReceived
+	stagePersisted
+	stageCount
+)
+
+var stageNames = [stageCount]string{"fetched", "received", "persisted"}
+
+// Latency spans between stages. Authored to fetched is how far behind
+// GitHub indexing runs, and is large for backfilled commits.
+const (
+	spanAuthoredFetched = iota
+	spanFetchedReceived
+	spanReceivedPersisted
+	spanAuthoredPersisted
+	spanCount
+)
+
+var spanNames = [spanCount]string{"authored_to_fetched", "fetched_to_received", "received_to_persisted", "authored_to_persisted"}
+
+// latencyBuckets are the upper bounds, in seconds, of the latency
+// histograms: sub-second store writes up to month-old backfills.
+var latencyBuckets = [...]float64{0.1, 0.5, 1, 5, 15, 60, 300, 900, 3600, 21600, 86400, 604800, 2592000}
+
+// rateWindow is the seconds of the rates, in one-second slots.
+const rateWindow = 60
+
+type latencyHistogram struct {
+	// buckets counts observations per bucket, the last one past the
+	// largest bound.
+	buckets [len(latencyBuckets) + 1]int64
+	count   int64
+	sum     float64
+	max     float64
+}
+
+func (h *latencyHistogram) observe(seconds float64) {
+	seconds = max(seconds, 0)
+	i := 0
+	for i < len(latencyBuckets) && seconds > latencyBuckets[i] {
+		i++
+	}
+	h.buckets[i]++
+	h.count++
+	h.sum += seconds
+	h.max = max(h.max, seconds)
+}
+
+// quantile is the upper bound of the bucket holding the q quantile, or
+// the largest observation when that is past the last bound.
+func (h *latencyHistogram) quantile(q float64) float64 {
+	if h.count == 0 {
+		return 0
+	}
+	rank := int64(math.Ceil(q * float64(h.count)))
+	var seen int64
+	for i, n := range h.buckets {
+		seen += n
+		if seen >= rank && i < len(latencyBuckets) {
+			return min(latencyBuckets[i], h.max)
+		}
+	}
+	return h.max
+}
+
+type rateCounter struct {
+	total   int64
+	seconds [rateWindow]int64
+	counts  [rateWindow]int64
+}
+
+func (r *rateCounter) add(at time.Time, n int64) {
+	r.total += n
+	sec := at.Unix()
+	slot := sec % rateWindow
+	if r.seconds[slot] != sec {
+		r.seconds[slot] = sec
+		r.counts[slot] = 0
+	}
+	r.counts[slot] += n
+}
+
+// rate is the commits per second over the window ending at now. Commits
+// that reach the manager late still count towards their fetch second.
+func (r *rateCounter) rate(now time.Time) float64 {
+	var n int64
+	for slot, sec := range r.seconds {
+		if age := now.Unix() - sec; age >= 0 && age < rateWindow {
+			n += r.counts[slot]
+		}
+	}
+	return float64(n) / rateWindow
+}
+
+// pipelineMetrics tracks commits through the pipeline since the manager
+// started. Commits from monitors that don't say when they fetched them
+// only count from the received stage.
+type pipelineMetrics struct {
+	mu        sync.Mutex
+	stages    [stageCount]rateCounter
+	latencies [spanCount]latencyHistogram
+	// throttledFor is how long batches waited for the ingestion throttle.
+	throttledFor time.Duration
+}
+
+// throttled accounts for a batch that waited d for the ingestion
+// throttle.
+func (m *pipelineMetrics) throttled(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.throttledFor += d
+}
+
+// record accounts for commits received at receivedAt and persisted at
+// persistedAt.
+func (m *pipelineMetrics) record(commits []*models.Commit, receivedAt, persistedAt time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, commit := range commits {
+		if commit.FetchedAt != nil {
+			fetchedAt := *commit.FetchedAt
+			m.stages[stageFetched].add(fetchedAt, 1)
+			m.latencies[spanAuthoredFetched].observe(fetchedAt.Sub(commit.CreatedAt).Seconds())
+			m.latencies[spanFetchedReceived].observe(receivedAt.Sub(fetchedAt).Seconds())
+		}
+		m.latencies[spanAuthoredPersisted].observe(persistedAt.Sub(commit.CreatedAt).Seconds())
+	}
+	n := int64(len(commits))
+	m.stages[stageReceived].add(receivedAt, n)
+	m.stages[stagePersisted].add(persistedAt, n)
+	// The batch is written at once, so its commits share the write time.
+	for range commits {
+		m.latencies[spanReceivedPersisted].observe(persistedAt.Sub(receivedAt).Seconds())
+	}
+}
+
+func (m *pipelineMetrics) snapshot(now time.Time) *models.PipelineStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := &models.PipelineStats{
+		Stages:           make([]models.PipelineStage, 0, stageCount),
+		Latency:          make([]models.PipelineLatency, 0, spanCount),
+		ThrottledSeconds: m.throttledFor.Seconds(),
+	}
+	for i := range m.stages {
+		stats.Stages = append(stats.Stages, models.PipelineStage{
+			Stage:     stageNames[i],
+			Commits:   m.stages[i].total,
+			PerSecond: m.stages[i].rate(now),
+		})
+	}
+	for i := range m.latencies {
+		h := &m.latencies[i]
+		latency := models.PipelineLatency{
+			Span:          spanNames[i],
+			Commits:       h.count,
+			MedianSeconds: h.quantile(0.5),
+			P90Seconds:    h.quantile(0.9),
+			MaxSeconds:    h.max,
+		}
+		if h.count > 0 {
+			latency.MeanSeconds = h.sum / float64(h.count)
+		}
+		stats.Latency = append(stats.Latency, latency)
+	}
+	return stats
+}
+
+// writeMetrics writes the metrics in the Prometheus text format.
+func (m *pipelineMetrics) writeMetrics(w io.Writer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var err error
+	printf := func(format string, args ...interface{}) {
+		if err == nil {
+			_, err = fmt.Fprintf(w, format, args...)
+		}
+	}
+
+	printf("# HELP indexer_pipeline_commits_total Commits through each stage of the ingestion pipeline.\n")
+	printf("# TYPE indexer_pipeline_commits_total counter\n")
+	for i := range m.stages {
+		printf("indexer_pipeline_commits_total{stage=%q} %d\n", stageNames[i], m.stages[i].total)
+	}
+
+	printf("# HELP indexer_pipeline_latency_seconds Time commits took between two stages of the ingestion pipeline.\n")
+	printf("# TYPE indexer_pipeline_latency_seconds histogram\n")
+	for i := range m.latencies {
+		h := &m.latencies[i]
+		var cumulative int64
+		for b, bound := range latencyBuckets {
+			cumulative += h.buckets[b]
+			printf("indexer_pipeline_latency_seconds_bucket{span=%q,le=%q} %d\n", spanNames[i], strconv.FormatFloat(bound, 'g', -1, 64), cumulative)
+		}
+		printf("indexer_pipeline_latency_seconds_bucket{span=%q,le=\"+Inf\"} %d\n", spanNames[i], h.count)
+		printf("indexer_pipeline_latency_seconds_sum{span=%q} %s\n", spanNames[i], strconv.FormatFloat(h.sum, 'g', -1, 64))
+		printf("indexer_pipeline_latency_seconds_count{span=%q} %d\n", spanNames[i], h.count)
+	}
+
+	printf("# HELP indexer_pipeline_throttled_seconds_total Time commit batches waited for the ingestion throttle.\n")
+	printf("# TYPE indexer_pipeline_throttled_seconds_total counter\n")
+	printf("indexer_pipeline_throttled_seconds_total %s\n", strconv.FormatFloat(m.throttledFor.Seconds(), 'g', -1, 64))
+	return err
+}
+
+// GetPipelineStats reports the commits through each pipeline stage and
+// the latency between them since this manager started.
+func (svc *Service) GetPipelineStats() *models.PipelineStats {
+	return svc.pipeline.snapshot(time.Now())
+}
+
+// WriteMetrics writes this manager's pipeline metrics to w in the
+// Prometheus text format.
+func (svc *Service) WriteMetrics(w io.Writer) error {
+	return svc.pipeline.writeMetrics(w)
+}
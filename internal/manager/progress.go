@@ -0,0 +1,94 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/noelukwa/indexer/internal/events"
+	"github.com/noelukwa/indexer/internal/manager/models"
+	"github.com/noelukwa/indexer/internal/manager/repository"
+)
+
+// saveProgress keeps the monitor's report of how far a run has got.
+// Monitors from before runs reported their start fall back to when the
+// intent last started syncing.
+func (svc *Service) saveProgress(ctx context.Context, intent *models.Intent, kind events.CommitsEventKind, progress *events.IntentProgress) error {
+	startedAt := progress.StartedAt
+	if startedAt.IsZero() {
+		startedAt = progress.At
+		if kind != events.IntentStartedKind && intent.SyncStartedAt != nil {
+			startedAt = *intent.SyncStartedAt
+		}
+	}
+	return svc.store.SaveIntentProgress(ctx, intent.ID, models.IntentProgress{
+		Commits:        progress.Commits,
+		Pages:          progress.Pages,
+		LastCommitDate: progress.LastCommitDate,
+		StartedAt:      startedAt,
+		UpdatedAt:      progress.At,
+	})
+}
+
+// intentProgress returns how far the intent's latest run has got, or nil
+// before its first run.
+func (svc *Service) intentProgress(ctx context.Context, intent *models.Intent) (*models.IntentProgress, error) {
+	progress, err := svc.store.GetIntentProgress(ctx, intent.ID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get intent progress: %w", err)
+	}
+	progress.PercentComplete = svc.estimateProgress(ctx, intent, progress)
+	return progress, nil
+}
+
+// estimateProgress estimates the percentage of the intent's work its run
+// has done. A shallow index counts its commits against MaxCommits. Other
+// runs walk back from the newest commit, so the share of the date range
+// between the oldest commit fetched and the end of the range is done. The
+// range starts at the intent's start date, or the repository's creation,
+// and ends at its end date, or when the run started. Runs fetching pages
+// concurrently reach old commits early, which overestimates them.
+func (svc *Service) estimateProgress(ctx context.Context, intent *models.Intent, progress *models.IntentProgress) *float64 {
+	if intent.Status == models.Completed {
+		return percentage(1)
+	}
+	if intent.MaxCommits != nil && *intent.MaxCommits > 0 {
+		return percentage(float64(progress.Commits) / float64(*intent.MaxCommits))
+	}
+
+	end := progress.StartedAt
+	if intent.Until != nil {
+		if endOfDay := intent.Until.AddDate(0, 0, 1); endOfDay.Before(end) {
+			end = endOfDay
+		}
+	}
+	var start time.Time
+	if intent.StartDate != nil {
+		start = *intent.StartDate
+	} else {
+		repo, err := svc.store.GetRepo(ctx, intent.RepositoryName)
+		if err != nil {
+			return nil
+		}
+		start = repo.CreatedAt
+	}
+	if !end.After(start) {
+		return nil
+	}
+	if progress.LastCommitDate == nil {
+		return percentage(0)
+	}
+	return percentage(float64(end.Sub(*progress.LastCommitDate)) / float64(end.Sub(start)))
+}
+
+// percentage turns a share into a percentage between 0 and 100, rounded
+// to one decimal.
+func percentage(share float64) *float64 {
+	p := math.Round(math.Max(0, math.Min(share, 1))*1000) / 10
+	return &p
+}
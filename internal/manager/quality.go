@@ -0,0 +1,94 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/noelukwa/indexer/internal/manager/models"
+	"github.com/noelukwa/indexer/internal/manager/repository"
+)
+
+// DefaultMinGapDays is how many days without commits make a coverage gap
+// when the caller doesn't say.
+const DefaultMinGapDays = 30
+
+// GetQualityReport reports how trustworthy the repository's indexed
+// commits are, with the gaps of at least minGapDays days in the range its
+// intents ask for.
+func (svc *Service) GetQualityReport(ctx context.Context, repo string, minGapDays int) (*models.QualityReport, error) {
+	if minGapDays <= 0 {
+		minGapDays = DefaultMinGapDays
+	}
+
+	found, err := svc.findRepo(ctx, normalizeRepositoryName(repo))
+	if err != nil {
+		return nil, err
+	}
+
+	since, until, err := svc.intentRange(ctx, found)
+	if err != nil {
+		return nil, err
+	}
+
+	params := dateParams(since, until, false) + fmt.Sprintf(":%d", minGapDays)
+	return cachedQuery(ctx, svc, found.FullName, "quality", params, func() (*models.QualityReport, error) {
+		report, err := svc.store.GetQuality(ctx, found.ID)
+		if err != nil {
+			return nil, err
+		}
+		if report.Commits > 0 {
+			report.BotPercentage = 100 * float64(report.BotCommits) / float64(report.Commits)
+		}
+		if since.IsZero() {
+			return report, nil
+		}
+
+		gaps, err := svc.store.GetCoverageGaps(ctx, found.ID, since, until, minGapDays)
+		if err != nil {
+			return nil, err
+		}
+		report.Coverage = &models.Coverage{
+			Since:      since.Format(time.DateOnly),
+			Until:      until.Format(time.DateOnly),
+			MinGapDays: minGapDays,
+			Gaps:       gaps,
+		}
+		return report, nil
+	})
+}
+
+// intentRange is the span of days the intents of repo ask to index, from
+// the earliest start date to the latest end date. An intent without a
+// start date starts at the repository's creation and one without an end
+// date runs until today. Both are zero when no intent indexes repo.
+func (svc *Service) intentRange(ctx context.Context, repo *models.Repository) (since, until time.Time, err error) {
+	intents, err := svc.store.FindIntents(ctx, models.IntentFilter{
+		RepositoryName: &repo.FullName,
+	}, repository.Pagination{Page: 1, PerPage: 100})
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("failed to find intents: %w", err)
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	for _, intent := range intents.Data {
+		start := repo.CreatedAt
+		if intent.StartDate != nil {
+			start = *intent.StartDate
+		}
+		end := today
+		if intent.Until != nil && intent.Until.Before(today) {
+			end = *intent.Until
+		}
+		if since.IsZero() || start.Before(since) {
+			since = start
+		}
+		if end.After(until) {
+			until = end
+		}
+	}
+	if since.IsZero() {
+		return time.Time{}, time.Time{}, nil
+	}
+	return since.UTC().Truncate(24 * time.Hour), until.UTC().Truncate(24 * time.Hour), nil
+}
@@ -0,0 +1,144 @@
+package manager
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/noelukwa/indexer/internal/manager/models"
+	"github.com/noelukwa/indexer/internal/manager/repository"
+)
+
+// statsScope is the scope the cross-repository statistics are cached
+// under. No repository's new commits invalidate them.
+const statsScope = "*"
+
+// ResultCache stores the results of expensive queries per repository. See
+// querycache.Cache.
+type ResultCache interface {
+	Key(ctx context.Context, repo, query string) (string, error)
+	Get(ctx context.Context, key string, dst interface{}) (bool, error)
+	Set(ctx context.Context, key string, v interface{}) error
+	SetFor(ctx context.Context, key string, v interface{}, ttl time.Duration) error
+	Invalidate(ctx context.Context, repo string) error
+	InvalidateAll(ctx context.Context) error
+}
+
+// cacheMetrics counts cache hits and misses per query kind.
+type cacheMetrics struct {
+	mu     sync.Mutex
+	counts map[string]*cacheCounts
+}
+
+type cacheCounts struct {
+	hits, misses atomic.Int64
+}
+
+func (m *cacheMetrics) record(kind string, hit bool) {
+	m.mu.Lock()
+	if m.counts == nil {
+		m.counts = make(map[string]*cacheCounts)
+	}
+	c, ok := m.counts[kind]
+	if !ok {
+		c = new(cacheCounts)
+		m.counts[kind] = c
+	}
+	m.mu.Unlock()
+
+	if hit {
+		c.hits.Add(1)
+	} else {
+		c.misses.Add(1)
+	}
+}
+
+func (m *cacheMetrics) snapshot() map[string]models.CacheStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := make(map[string]models.CacheStats, len(m.counts))
+	for kind, c := range m.counts {
+		stats[kind] = models.CacheStats{Hits: c.hits.Load(), Misses: c.misses.Load()}
+	}
+	return stats
+}
+
+// cachedQuery returns the cached result of the kind query on repo with
+// params, running load on a miss. Cache errors fall back to load; they
+// never fail the request.
+func cachedQuery[T any](ctx context.Context, svc *Service, repo, kind, params string, load func() (T, error)) (T, error) {
+	return cacheQuery(ctx, svc, repo, kind, params, 0, load)
+}
+
+// cachedStatsQuery is cachedQuery for the statistics across repositories,
+// kept per tenant. As new commits leave them be, they are only cached for
+// the shorter QueryCacheStatsTTL, and not at all when it is zero.
+func cachedStatsQuery[T any](ctx context.Context, svc *Service, kind, params string, load func() (T, error)) (T, error) {
+	if svc.cfg.QueryCacheStatsTTL <= 0 {
+		return load()
+	}
+	tenant, _ := repository.TenantFrom(ctx)
+	return cacheQuery(ctx, svc, statsScope, kind, tenant+":"+params, svc.cfg.QueryCacheStatsTTL, load)
+}
+
+// cacheQuery runs a cached query, storing its result for ttl, or the
+// cache's own TTL when zero.
+func cacheQuery[T any](ctx context.Context, svc *Service, repo, kind, params string, ttl time.Duration, load func() (T, error)) (T, error) {
+	if svc.cache == nil {
+		return load()
+	}
+
+	key, err := svc.cache.Key(ctx, repo, kind+":"+params)
+	if err != nil {
+		log.Printf("failed to read query cache generation: %v", err)
+		return load()
+	}
+
+	var result T
+	hit, err := svc.cache.Get(ctx, key, &result)
+	if err != nil {
+		log.Printf("failed to read query cache: %v", err)
+	}
+	svc.cacheMetrics.record(kind, hit)
+	if hit {
+		return result, nil
+	}
+
+	result, err = load()
+	if err != nil {
+		return result, err
+	}
+	if ttl > 0 {
+		err = svc.cache.SetFor(ctx, key, result, ttl)
+	} else {
+		err = svc.cache.Set(ctx, key, result)
+	}
+	if err != nil {
+		log.Printf("failed to write query cache: %v", err)
+	}
+	return result, nil
+}
+
+// invalidateRepo drops the cached results of repo after new commits.
+func (svc *Service) invalidateRepo(ctx context.Context, repo string) {
+	if svc.cache == nil {
+		return
+	}
+	if err := svc.cache.Invalidate(ctx, repo); err != nil {
+		log.Printf("failed to invalidate query cache of %s: %v", repo, err)
+	}
+}
+
+// invalidateAll drops every cached result, once the leaderboards the
+// queries read from have moved.
+func (svc *Service) invalidateAll(ctx context.Context) {
+	if svc.cache == nil {
+		return
+	}
+	if err := svc.cache.InvalidateAll(ctx); err != nil {
+		log.Printf("failed to invalidate query cache: %v", err)
+	}
+}
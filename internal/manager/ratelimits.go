@@ -0,0 +1,21 @@
+package manager
+
+import (
+	"context"
+
+	"github.com/noelukwa/indexer/internal/pkg/ratelimits"
+)
+
+var ErrRateLimitsUnavailable error = newError(ErrUnavailable, "rate limits are unavailable: no redis configured")
+
+// RateLimitSource lists the GitHub quotas the monitor has reported.
+type RateLimitSource interface {
+	List(ctx context.Context) ([]ratelimits.Status, error)
+}
+
+func (svc *Service) GetRateLimits(ctx context.Context) ([]ratelimits.Status, error) {
+	if svc.rateLimits == nil {
+		return nil, ErrRateLimitsUnavailable
+	}
+	return svc.rateLimits.List(ctx)
+}
@@ -0,0 +1,130 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/noelukwa/indexer/internal/events"
+	"github.com/noelukwa/indexer/internal/manager/models"
+	"github.com/noelukwa/indexer/internal/manager/repository"
+)
+
+var (
+	ErrNotIndexed      = newError(ErrConflict, "repository has not been indexed yet")
+	ErrReindexNotFound = newError(ErrNotFound, "intent has never been reindexed")
+)
+
+// StartReindex rebuilds the repository of an active intent without
+// touching its live commits. The intent is published straight to the
+// monitor as a reindex run, whose commits go to a shadow table; once they
+// have all arrived and pass verification they replace the live commits in
+// one transaction. A reindex already building for the repository is
+// aborted.
+func (svc *Service) StartReindex(ctx context.Context, id uuid.UUID) (*models.Reindex, error) {
+	intent, err := svc.findIntent(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !intent.IsActive {
+		return nil, ErrIntentInactive
+	}
+
+	payload, err := svc.intentPayload(ctx, intent)
+	if err != nil {
+		return nil, err
+	}
+
+	reindex, err := svc.store.CreateReindex(ctx, intent.ID, intent.RepositoryName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create reindex: %w", err)
+	}
+	if reindex == nil {
+		return nil, ErrNotIndexed
+	}
+	payload.ReindexID = &reindex.ID
+
+	if err := svc.queueIntentTo(ctx, svc.cfg.MonitorQueueName, events.NewIntentKind, payload); err != nil {
+		if err := svc.store.FailReindex(context.WithoutCancel(ctx), reindex.ID, "reindex run was never queued"); err != nil {
+			log.Printf("failed to fail reindex %s: %v", reindex.ID, err)
+		}
+		return nil, err
+	}
+	return reindex, nil
+}
+
+// GetLatestReindex returns the intent's most recent reindex.
+func (svc *Service) GetLatestReindex(ctx context.Context, id uuid.UUID) (*models.Reindex, error) {
+	if _, err := svc.findIntent(ctx, id); err != nil {
+		return nil, err
+	}
+	reindex, err := svc.store.GetLatestReindex(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrReindexNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find reindex: %w", err)
+	}
+	return reindex, nil
+}
+
+// saveShadowCommits adds a batch of a reindex run to its shadow, swapping
+// the shadow in if it was the last one.
+func (svc *Service) saveShadowCommits(ctx context.Context, id uuid.UUID, commits []*models.Commit) error {
+	repoName := normalizeRepositoryName(commits[0].Repository.FullName)
+	err := svc.persist(ctx, repoName, func() error {
+		return svc.store.SaveShadowCommits(ctx, id, commits)
+	})
+	if err != nil {
+		return err
+	}
+	return svc.swapReindex(ctx, id, repoName)
+}
+
+// recordReindexProgress finishes the reindex of a run once it ends: a
+// failed run fails it, and a completed one records how many commits the
+// shadow should end up with.
+func (svc *Service) recordReindexProgress(ctx context.Context, intent *models.Intent, kind events.CommitsEventKind, progress *events.IntentProgress) error {
+	id := *progress.ReindexID
+	switch kind {
+	case events.IntentFailedKind:
+		return svc.store.FailReindex(ctx, id, progress.Error)
+	case events.IntentCompletedKind:
+		if err := svc.store.SetReindexExpected(ctx, id, progress.Commits); err != nil {
+			return err
+		}
+		// The run's last batches may well have arrived first.
+		return svc.swapReindex(ctx, id, intent.RepositoryName)
+	}
+	return nil
+}
+
+func (svc *Service) swapReindex(ctx context.Context, id uuid.UUID, repoName string) error {
+	reindex, err := svc.store.SwapReindex(ctx, id, svc.cfg.ReindexMinRatio)
+	if err != nil {
+		return fmt.Errorf("failed to swap reindex %s: %w", id, err)
+	}
+	if reindex == nil {
+		return nil
+	}
+
+	switch reindex.Status {
+	case models.ReindexSwapped:
+		log.Printf("reindex %s of %s swapped in", reindex.ID, repoName)
+		svc.invalidateRepo(ctx, repoName)
+		if reindex.ExpectedCommits != nil {
+			svc.noteIngested(int(*reindex.ExpectedCommits))
+		}
+	case models.ReindexFailed:
+		log.Printf("reindex %s of %s failed verification: %s", reindex.ID, repoName, reindex.Error)
+		return svc.store.SaveIntentError(ctx, models.IntentError{
+			IntentID:  reindex.IntentID,
+			CreatedAt: time.Now(),
+			Message:   "reindex failed verification: " + reindex.Error,
+		})
+	}
+	return nil
+}
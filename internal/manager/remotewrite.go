@@ -0,0 +1,101 @@
+package manager
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/noelukwa/indexer/internal/pkg/remotewrite"
+)
+
+// repoCommitsMetric counts the commits ingested for each repository.
+const repoCommitsMetric = "indexer_repository_commits_ingested_total"
+
+// MetricsWriter pushes series to a Prometheus remote-write endpoint. See
+// remotewrite.Client.
+type MetricsWriter interface {
+	Write(ctx context.Context, series []remotewrite.Series) error
+}
+
+// UseMetricsWriter pushes the per-repository commit counters with w. Call
+// it before PushCommitMetrics.
+func (svc *Service) UseMetricsWriter(w MetricsWriter) {
+	svc.metricsWriter = w
+}
+
+// repoCommitCounters counts the commits ingested per repository since the
+// manager started.
+type repoCommitCounters struct {
+	mu     sync.Mutex
+	counts map[string]int64
+}
+
+func (c *repoCommitCounters) add(repo string, n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.counts == nil {
+		c.counts = make(map[string]int64)
+	}
+	c.counts[repo] += int64(n)
+}
+
+// series is a sample of every counter at now, with the extra labels.
+func (c *repoCommitCounters) series(now time.Time, extra map[string]string) []remotewrite.Series {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	series := make([]remotewrite.Series, 0, len(c.counts))
+	for repo, count := range c.counts {
+		labels := []remotewrite.Label{
+			{Name: "__name__", Value: repoCommitsMetric},
+			{Name: "repository", Value: repo},
+		}
+		for name, value := range extra {
+			labels = append(labels, remotewrite.Label{Name: name, Value: value})
+		}
+		series = append(series, remotewrite.Series{
+			Labels:  labels,
+			Samples: []remotewrite.Sample{{Value: float64(count), Timestamp: now}},
+		})
+	}
+	sort.Slice(series, func(i, j int) bool {
+		return series[i].Labels[1].Value < series[j].Labels[1].Value
+	})
+	return series
+}
+
+// PushCommitMetrics pushes the per-repository commit counters every
+// RemoteWriteInterval until ctx is done. A push that fails is dropped; the
+// next one carries the counts on.
+func (svc *Service) PushCommitMetrics(ctx context.Context) {
+	interval := svc.cfg.RemoteWriteInterval
+	if interval <= 0 {
+		interval = 15 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+		if err := svc.pushCommitMetrics(ctx, time.Now()); err != nil {
+			log.Printf("failed to push commit metrics: %v", err)
+		}
+	}
+}
+
+func (svc *Service) pushCommitMetrics(ctx context.Context, now time.Time) error {
+	if svc.metricsWriter == nil {
+		return nil
+	}
+	series := svc.repoCommits.series(now, svc.cfg.RemoteWriteLabels)
+	if len(series) == 0 {
+		return nil
+	}
+	return svc.metricsWriter.Write(ctx, series)
+}
@@ -0,0 +1,79 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/noelukwa/indexer/internal/manager/models"
+	"github.com/noelukwa/indexer/internal/manager/repository"
+	"github.com/noelukwa/indexer/internal/manager/repository/postgres/sqlc"
+)
+
+func (p *pgStore) SaveAPIKey(ctx context.Context, keyHash string, key models.APIKey) (*models.APIKey, error) {
+	saved, err := p.q.SaveAPIKey(ctx, sqlc.SaveAPIKeyParams{
+		ID:       key.ID,
+		Name:     key.Name,
+		KeyHash:  keyHash,
+		Prefix:   key.Prefix,
+		Role:     string(key.Role),
+		TenantID: pgtype.Text{String: key.Tenant, Valid: key.Tenant != ""},
+	})
+	if err != nil {
+		return nil, storeError(err)
+	}
+	return toAPIKey(saved), nil
+}
+
+// FindAPIKey finds the unrevoked key with the hash.
+func (p *pgStore) FindAPIKey(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	key, err := p.q.FindAPIKey(ctx, keyHash)
+	if err != nil {
+		return nil, storeError(err)
+	}
+	return toAPIKey(key), nil
+}
+
+// FindAPIKeys lists every key, revoked ones included, oldest first.
+func (p *pgStore) FindAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	rows, err := p.q.FindAPIKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]models.APIKey, 0, len(rows))
+	for _, row := range rows {
+		keys = append(keys, *toAPIKey(row))
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey returns repository.ErrNotFound when there is no key with
+// the id, or it is already revoked.
+func (p *pgStore) RevokeAPIKey(ctx context.Context, id uuid.UUID) error {
+	revoked, err := p.q.RevokeAPIKey(ctx, id)
+	if err != nil {
+		return err
+	}
+	if revoked == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// TouchAPIKey records that the key has just been used.
+func (p *pgStore) TouchAPIKey(ctx context.Context, id uuid.UUID) error {
+	return p.q.TouchAPIKey(ctx, id)
+}
+
+func toAPIKey(key sqlc.ApiKey) *models.APIKey {
+	return &models.APIKey{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Role:       models.Role(key.Role),
+		CreatedAt:  key.CreatedAt.Time,
+		LastUsedAt: fromTimestamptz(key.LastUsedAt),
+		RevokedAt:  fromTimestamptz(key.RevokedAt),
+		Tenant:     key.TenantID.String,
+	}
+}
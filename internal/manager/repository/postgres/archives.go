@@ -0,0 +1,165 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/noelukwa/indexer/internal/manager/models"
+	"github.com/noelukwa/indexer/internal/manager/repository/postgres/sqlc"
+)
+
+// FindArchivableRepos lists the repositories with rolled up commits
+// authored before the cutoff.
+func (p *pgStore) FindArchivableRepos(ctx context.Context, before time.Time) ([]models.Repository, error) {
+	rows, err := p.q.FindArchivableRepos(ctx, pgtype.Timestamptz{Time: before, Valid: true})
+	if err != nil {
+		return nil, err
+	}
+	repos := make([]models.Repository, 0, len(rows))
+	for _, row := range rows {
+		repos = append(repos, models.Repository{ID: row.ID, FullName: row.FullName})
+	}
+	return repos, nil
+}
+
+// ClaimRepoArchive leases the repository's archive until leasedUntil and
+// moves its archived_before up to before, so commits that old are no
+// longer saved. It returns false while another replica holds the lease.
+func (p *pgStore) ClaimRepoArchive(ctx context.Context, repoID int64, before, leasedUntil time.Time) (bool, error) {
+	_, err := p.q.ClaimRepoArchive(ctx, sqlc.ClaimRepoArchiveParams{
+		RepositoryID:   repoID,
+		ArchivedBefore: pgtype.Timestamptz{Time: before, Valid: true},
+		LeasedUntil:    pgtype.Timestamptz{Time: leasedUntil, Valid: true},
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (p *pgStore) ReleaseRepoArchive(ctx context.Context, repoID int64) error {
+	return p.q.ReleaseRepoArchive(ctx, repoID)
+}
+
+// FindArchivableCommits returns up to limit of the repository's rolled up
+// commits authored before the cutoff, the oldest first.
+func (p *pgStore) FindArchivableCommits(ctx context.Context, repoID int64, before time.Time, limit int) ([]models.Commit, error) {
+	rows, err := p.q.FindArchivableCommits(ctx, sqlc.FindArchivableCommitsParams{
+		RepositoryID: repoID,
+		CreatedAt:    pgtype.Timestamptz{Time: before, Valid: true},
+		Limit:        int32(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	commits := make([]models.Commit, 0, len(rows))
+	for _, row := range rows {
+		commit := models.Commit{
+			Hash: row.Hash,
+			Author: models.Author{
+				ID:       row.ID,
+				Name:     row.Name,
+				Email:    row.Email,
+				Username: row.Username,
+			},
+			Message:   row.Message,
+			Url:       row.Url.String,
+			CreatedAt: row.CreatedAt.Time,
+			Tags:      row.Tags,
+		}
+		if row.Additions.Valid || row.Deletions.Valid || row.Changes.Valid {
+			commit.Stats = &models.CommitStats{
+				Additions: row.Additions.Int32,
+				Deletions: row.Deletions.Int32,
+				Changes:   row.Changes.Int32,
+			}
+		}
+		commits = append(commits, commit)
+	}
+	return commits, nil
+}
+
+// ArchiveCommits records the archive object and deletes the commits it
+// holds, the hashes, together.
+func (p *pgStore) ArchiveCommits(ctx context.Context, archive models.CommitArchive, hashes []string) error {
+	tx, err := p.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := p.q.WithTx(tx)
+	err = qtx.SaveCommitArchive(ctx, sqlc.SaveCommitArchiveParams{
+		ID:            archive.ID,
+		RepositoryID:  archive.RepositoryID,
+		ObjectKey:     archive.ObjectKey,
+		Commits:       archive.Commits,
+		FirstCommitAt: pgtype.Timestamptz{Time: archive.FirstCommitAt, Valid: true},
+		LastCommitAt:  pgtype.Timestamptz{Time: archive.LastCommitAt, Valid: true},
+		SizeBytes:     archive.SizeBytes,
+	})
+	if err != nil {
+		return storeError(err)
+	}
+	if _, err := qtx.DeleteArchivedCommits(ctx, sqlc.DeleteArchivedCommitsParams{
+		RepositoryID: archive.RepositoryID,
+		Column2:      hashes,
+	}); err != nil {
+		return fmt.Errorf("failed to delete archived commits: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// FindCommitArchives lists the repository's archive objects, the oldest
+// commits first.
+func (p *pgStore) FindCommitArchives(ctx context.Context, repoID int64) ([]models.CommitArchive, error) {
+	rows, err := p.q.FindCommitArchives(ctx, repoID)
+	if err != nil {
+		return nil, err
+	}
+	archives := make([]models.CommitArchive, 0, len(rows))
+	for _, row := range rows {
+		archives = append(archives, models.CommitArchive{
+			ID:            row.ID,
+			RepositoryID:  row.RepositoryID,
+			ObjectKey:     row.ObjectKey,
+			Commits:       row.Commits,
+			FirstCommitAt: row.FirstCommitAt.Time,
+			LastCommitAt:  row.LastCommitAt.Time,
+			SizeBytes:     row.SizeBytes,
+			CreatedAt:     row.CreatedAt.Time,
+		})
+	}
+	return archives, nil
+}
+
+// GetArchiveSummary returns nil when none of the repository's commits
+// have been archived.
+func (p *pgStore) GetArchiveSummary(ctx context.Context, repoID int64) (*models.ArchiveNotice, error) {
+	row, err := p.q.GetArchiveSummary(ctx, repoID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !row.ArchivedBefore.Valid || row.Commits == 0 {
+		return nil, nil
+	}
+	return &models.ArchiveNotice{
+		ArchivedBefore: row.ArchivedBefore.Time,
+		Commits:        row.Commits,
+	}, nil
+}
@@ -0,0 +1,58 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/noelukwa/indexer/internal/manager/models"
+	"github.com/noelukwa/indexer/internal/manager/repository"
+	"github.com/noelukwa/indexer/internal/manager/repository/postgres/sqlc"
+)
+
+// GetAuthorProfile finds the author with the GitHub login username, in any
+// case, and totals their commits per repository, most commits first. With
+// ctx scoped to a tenant, only the tenant's commits count, and authors
+// without any are repository.ErrNotFound.
+func (p *pgStore) GetAuthorProfile(ctx context.Context, username string) (*models.AuthorProfile, error) {
+	author, err := p.q.GetAuthorByUsername(ctx, username)
+	if err != nil {
+		return nil, storeError(err)
+	}
+
+	tenant := tenantArg(ctx)
+	rows, err := p.q.GetAuthorRepoActivity(ctx, sqlc.GetAuthorRepoActivityParams{AuthorID: author.ID, TenantID: tenant})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get author activity: %w", err)
+	}
+	if tenant.Valid && len(rows) == 0 {
+		return nil, repository.ErrNotFound
+	}
+
+	profile := &models.AuthorProfile{
+		Author: models.Author{
+			ID:       author.ID,
+			Name:     author.Name,
+			Email:    author.Email,
+			Username: author.Username,
+		},
+		Repositories: len(rows),
+		ByRepository: make([]models.AuthorRepoActivity, 0, len(rows)),
+	}
+	for _, row := range rows {
+		activity := models.AuthorRepoActivity{
+			Repository:    row.Repository,
+			Commits:       row.Commits,
+			FirstCommitAt: row.FirstCommitAt.Time,
+			LastCommitAt:  row.LastCommitAt.Time,
+		}
+		profile.ByRepository = append(profile.ByRepository, activity)
+		profile.TotalCommits += activity.Commits
+		if profile.FirstCommitAt == nil || activity.FirstCommitAt.Before(*profile.FirstCommitAt) {
+			profile.FirstCommitAt = &activity.FirstCommitAt
+		}
+		if profile.LastCommitAt == nil || activity.LastCommitAt.After(*profile.LastCommitAt) {
+			profile.LastCommitAt = &activity.LastCommitAt
+		}
+	}
+	return profile, nil
+}
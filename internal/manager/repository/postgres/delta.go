@@ -0,0 +1,34 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/noelukwa/indexer/internal/manager/models"
+)
+
+// FindCommitCursor returns the position of the repository's commit with
+// the hash.
+func (p *pgStore) FindCommitCursor(ctx context.Context, repoID int64, hash string) (*models.CommitCursor, error) {
+	cursor := models.CommitCursor{Hash: hash}
+	err := p.conn.QueryRow(ctx,
+		"SELECT created_at FROM commits WHERE repository_id = $1 AND hash = $2",
+		repoID, hash,
+	).Scan(&cursor.CreatedAt)
+	if err != nil {
+		return nil, storeError(err)
+	}
+	return &cursor, nil
+}
+
+// FindCommitsAfter returns up to limit of the repository's commits after
+// the cursor, ordered by creation time then hash.
+func (p *pgStore) FindCommitsAfter(ctx context.Context, repoID int64, after models.CommitCursor, limit int) ([]models.Commit, error) {
+	query := commitsSelect().
+		Where(squirrel.Eq{"c.repository_id": repoID}).
+		Where(squirrel.Expr("(c.created_at, c.hash) > (?, ?)", after.CreatedAt, after.Hash)).
+		OrderBy("c.created_at", "c.hash").
+		Limit(uint64(limit))
+
+	return p.queryCommits(ctx, query)
+}
@@ -0,0 +1,116 @@
+package postgres
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/noelukwa/indexer/internal/manager/models"
+	"github.com/noelukwa/indexer/internal/manager/repository"
+	"github.com/noelukwa/indexer/internal/manager/repository/postgres/sqlc"
+)
+
+func (p *pgStore) SaveDigestSubscription(ctx context.Context, sub models.DigestSubscription) (*models.DigestSubscription, error) {
+	saved, err := p.q.SaveDigestSubscription(ctx, sqlc.SaveDigestSubscriptionParams{
+		ID:         sub.ID,
+		Email:      sub.Email,
+		Repository: sub.Repository,
+		Label:      sub.Label,
+	})
+	if err != nil {
+		return nil, storeError(err)
+	}
+	return toDigestSubscription(saved), nil
+}
+
+func (p *pgStore) FindDigestSubscriptions(ctx context.Context) ([]models.DigestSubscription, error) {
+	rows, err := p.q.FindDigestSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return toDigestSubscriptions(rows), nil
+}
+
+func (p *pgStore) DeleteDigestSubscription(ctx context.Context, id uuid.UUID) error {
+	deleted, err := p.q.DeleteDigestSubscription(ctx, id)
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// ClaimDigestSubscriptions marks up to limit subscriptions not yet sent
+// the digest of the week starting on week as sent, and returns them.
+// Replicas claiming at once get different subscriptions.
+func (p *pgStore) ClaimDigestSubscriptions(ctx context.Context, week time.Time, limit int) ([]models.DigestSubscription, error) {
+	rows, err := p.q.ClaimDigestSubscriptions(ctx, sqlc.ClaimDigestSubscriptionsParams{
+		LastWeek: pgtype.Date{Time: week, Valid: true},
+		Limit:    int32(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toDigestSubscriptions(rows), nil
+}
+
+// GetLargestCommits returns up to limit of the repository's commits
+// between the filter's dates with the most changed lines, the largest
+// first. Commits saved without stats are left out.
+func (p *pgStore) GetLargestCommits(ctx context.Context, filter models.CommitsFilter, limit int) ([]models.DigestCommit, error) {
+	rows, err := p.q.GetLargestCommits(ctx, sqlc.GetLargestCommitsParams{
+		FullName:    filter.RepositoryName,
+		CreatedAt:   toTimestamptz(filter.StartDate),
+		CreatedAt_2: toTimestamptz(filter.EndDate),
+		Limit:       int32(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	commits := make([]models.DigestCommit, 0, len(rows))
+	for _, row := range rows {
+		title, _, _ := strings.Cut(row.Message, "\n")
+		commits = append(commits, models.DigestCommit{
+			Hash:  row.Hash,
+			Title: title,
+			Author: models.Author{
+				ID:       row.ID,
+				Name:     row.Name,
+				Email:    row.Email,
+				Username: row.Username,
+			},
+			Url:       row.Url.String,
+			CreatedAt: row.CreatedAt.Time,
+			Additions: row.Additions.Int32,
+			Deletions: row.Deletions.Int32,
+		})
+	}
+	return commits, nil
+}
+
+func toDigestSubscriptions(rows []sqlc.DigestSubscription) []models.DigestSubscription {
+	subs := make([]models.DigestSubscription, 0, len(rows))
+	for _, row := range rows {
+		subs = append(subs, *toDigestSubscription(row))
+	}
+	return subs
+}
+
+func toDigestSubscription(sub sqlc.DigestSubscription) *models.DigestSubscription {
+	saved := &models.DigestSubscription{
+		ID:         sub.ID,
+		Email:      sub.Email,
+		Repository: sub.Repository,
+		Label:      sub.Label,
+		CreatedAt:  sub.CreatedAt.Time,
+	}
+	if sub.LastWeek.Valid {
+		saved.LastWeek = &sub.LastWeek.Time
+	}
+	return saved
+}
@@ -0,0 +1,41 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/noelukwa/indexer/internal/manager/repository"
+)
+
+// SQLSTATE codes of the integrity violations storeError maps.
+const (
+	notNullViolation    = "23502"
+	foreignKeyViolation = "23503"
+	uniqueViolation     = "23505"
+	checkViolation      = "23514"
+	exclusionViolation  = "23P01"
+)
+
+// storeError maps an error of pgx to the repository's: no rows to
+// ErrNotFound, a unique or exclusion violation to ErrConflict and the other
+// integrity violations to ErrConstraint, naming the constraint. Other
+// errors are returned as they are.
+func storeError(err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repository.ErrNotFound
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+	switch pgErr.Code {
+	case uniqueViolation, exclusionViolation:
+		return fmt.Errorf("%w: %s", repository.ErrConflict, pgErr.ConstraintName)
+	case notNullViolation, foreignKeyViolation, checkViolation:
+		return fmt.Errorf("%w: %s", repository.ErrConstraint, pgErr.ConstraintName)
+	}
+	return err
+}
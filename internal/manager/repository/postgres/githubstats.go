@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/noelukwa/indexer/internal/manager/models"
+	"github.com/noelukwa/indexer/internal/manager/repository/postgres/sqlc"
+)
+
+// SaveGitHubStats replaces the repository's GitHub stats, keeping the kinds
+// stats leaves empty from the snapshot before.
+func (p *pgStore) SaveGitHubStats(ctx context.Context, repoID int64, stats *models.GitHubStats) error {
+	params := sqlc.SaveGitHubStatsParams{
+		RepositoryID: repoID,
+		FetchedAt:    pgtype.Timestamptz{Time: stats.FetchedAt, Valid: true},
+	}
+	var err error
+	if params.CodeFrequency, err = marshalStats(stats.CodeFrequency, stats.CodeFrequency == nil); err != nil {
+		return err
+	}
+	if params.Participation, err = marshalStats(stats.Participation, stats.Participation == nil); err != nil {
+		return err
+	}
+	if params.PunchCard, err = marshalStats(stats.PunchCard, stats.PunchCard == nil); err != nil {
+		return err
+	}
+	return p.q.SaveGitHubStats(ctx, params)
+}
+
+// marshalStats encodes one kind of stats, or NULL when it is missing.
+func marshalStats(v interface{}, missing bool) ([]byte, error) {
+	if missing {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal stats: %w", err)
+	}
+	return data, nil
+}
+
+// GetGitHubStats returns the repository's GitHub stats, or
+// repository.ErrNotFound if none have been fetched.
+func (p *pgStore) GetGitHubStats(ctx context.Context, repoID int64) (*models.GitHubStats, error) {
+	row, err := p.q.GetGitHubStats(ctx, repoID)
+	if err != nil {
+		return nil, storeError(err)
+	}
+
+	stats := &models.GitHubStats{FetchedAt: row.FetchedAt.Time}
+	for _, kind := range []struct {
+		data []byte
+		dst  interface{}
+	}{
+		{row.CodeFrequency, &stats.CodeFrequency},
+		{row.Participation, &stats.Participation},
+		{row.PunchCard, &stats.PunchCard},
+	} {
+		if kind.data == nil {
+			continue
+		}
+		if err := json.Unmarshal(kind.data, kind.dst); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal stats: %w", err)
+		}
+	}
+	return stats, nil
+}
@@ -0,0 +1,83 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/noelukwa/indexer/internal/manager/models"
+)
+
+const (
+	// commitsChannel is notified by SaveManyCommit.
+	commitsChannel = "commits"
+	// intentEventsChannel is notified by NotifyIntentEvent.
+	intentEventsChannel = "intent_events"
+
+	// maxEventError keeps an event's error well within the 8000 bytes a
+	// notification may carry.
+	maxEventError = 2000
+)
+
+// ListenCommits holds a connection of its own for as long as it listens.
+func (p *pgStore) ListenCommits(ctx context.Context, notify func(models.CommitsNotice)) error {
+	return p.listen(ctx, commitsChannel, func(payload []byte) {
+		var notice models.CommitsNotice
+		if err := json.Unmarshal(payload, &notice); err != nil {
+			log.Printf("ignoring malformed commits notice: %v", err)
+			return
+		}
+		notify(notice)
+	})
+}
+
+// NotifyIntentEvent announces event to the listeners of every replica.
+// Overlong errors are cut short.
+func (p *pgStore) NotifyIntentEvent(ctx context.Context, event models.IntentEvent) error {
+	if len(event.Error) > maxEventError {
+		event.Error = event.Error[:maxEventError]
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return p.q.NotifyIntentEvent(ctx, string(payload))
+}
+
+// ListenIntentEvents holds a connection of its own for as long as it
+// listens.
+func (p *pgStore) ListenIntentEvents(ctx context.Context, notify func(models.IntentEvent)) error {
+	return p.listen(ctx, intentEventsChannel, func(payload []byte) {
+		var event models.IntentEvent
+		if err := json.Unmarshal(payload, &event); err != nil {
+			log.Printf("ignoring malformed intent event: %v", err)
+			return
+		}
+		notify(event)
+	})
+}
+
+func (p *pgStore) listen(ctx context.Context, channel string, handle func(payload []byte)) error {
+	conn, err := p.conn.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer func() {
+		// Don't hand a listening connection back to the pool.
+		conn.Exec(context.Background(), "UNLISTEN "+channel)
+		conn.Release()
+	}()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+channel); err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", channel, err)
+	}
+
+	for {
+		n, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		handle([]byte(n.Payload))
+	}
+}
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE intents
+    ADD COLUMN max_concurrent_pages INT,
+    ADD COLUMN requests_per_minute INT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE intents
+    DROP COLUMN IF EXISTS max_concurrent_pages,
+    DROP COLUMN IF EXISTS requests_per_minute;
+-- +goose StatementEnd
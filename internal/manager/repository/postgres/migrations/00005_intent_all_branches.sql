@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE intents
+    ADD COLUMN index_all_branches BOOLEAN NOT NULL DEFAULT FALSE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE intents
+    DROP COLUMN IF EXISTS index_all_branches;
+-- +goose StatementEnd
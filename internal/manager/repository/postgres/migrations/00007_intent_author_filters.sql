@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE intents
+    ADD COLUMN author_filters TEXT[] NOT NULL DEFAULT '{}';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE intents
+    DROP COLUMN IF EXISTS author_filters;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE intents
+    ADD COLUMN max_commits INT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE intents
+    DROP COLUMN IF EXISTS max_commits;
+-- +goose StatementEnd
@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE intents
+    ALTER COLUMN start_date DROP NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+UPDATE intents SET start_date = to_timestamp(0) WHERE start_date IS NULL;
+ALTER TABLE intents
+    ALTER COLUMN start_date SET NOT NULL;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+UPDATE intents SET is_active = FALSE
+WHERE is_active AND id NOT IN (
+    SELECT DISTINCT ON (repository_name) id
+    FROM intents
+    WHERE is_active
+    ORDER BY repository_name, created_at DESC
+);
+
+CREATE UNIQUE INDEX idx_intents_active_repository_name ON intents(repository_name) WHERE is_active;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_intents_active_repository_name;
+-- +goose StatementEnd
@@ -0,0 +1,42 @@
+-- +goose Up
+-- +goose StatementBegin
+UPDATE intents SET is_active = FALSE
+WHERE is_active AND id NOT IN (
+    SELECT DISTINCT ON (lower(repository_name)) id
+    FROM intents
+    WHERE is_active
+    ORDER BY lower(repository_name), created_at DESC
+);
+
+UPDATE intents SET repository_name = lower(repository_name);
+
+DROP INDEX IF EXISTS idx_intents_active_repository_name;
+CREATE UNIQUE INDEX idx_intents_active_repository_name ON intents(lower(repository_name)) WHERE is_active;
+
+-- Fold repositories that differ only by case into the most recently
+-- updated one before lowercasing their names.
+CREATE TEMPORARY TABLE repository_merges AS
+SELECT id, first_value(id) OVER (PARTITION BY lower(full_name) ORDER BY updated_at DESC, id) AS keep_id
+FROM repositories;
+
+UPDATE commits c SET repository_id = m.keep_id
+FROM repository_merges m
+WHERE c.repository_id = m.id AND m.id <> m.keep_id;
+
+DELETE FROM repositories
+WHERE id IN (SELECT id FROM repository_merges WHERE id <> keep_id);
+
+DROP TABLE repository_merges;
+
+UPDATE repositories SET full_name = lower(full_name);
+
+CREATE UNIQUE INDEX idx_repositories_lower_full_name ON repositories(lower(full_name));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_repositories_lower_full_name;
+
+DROP INDEX IF EXISTS idx_intents_active_repository_name;
+CREATE UNIQUE INDEX idx_intents_active_repository_name ON intents(repository_name) WHERE is_active;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE commits
+    ADD COLUMN additions INT,
+    ADD COLUMN deletions INT,
+    ADD COLUMN changes INT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE commits
+    DROP COLUMN IF EXISTS additions,
+    DROP COLUMN IF EXISTS deletions,
+    DROP COLUMN IF EXISTS changes;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE repositories
+    ADD COLUMN topics TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN license TEXT,
+    ADD COLUMN default_branch TEXT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE repositories
+    DROP COLUMN IF EXISTS topics,
+    DROP COLUMN IF EXISTS license,
+    DROP COLUMN IF EXISTS default_branch;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE repositories
+    ADD COLUMN description TEXT,
+    ADD COLUMN homepage TEXT,
+    ADD COLUMN open_issues INT NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE repositories
+    DROP COLUMN IF EXISTS description,
+    DROP COLUMN IF EXISTS homepage,
+    DROP COLUMN IF EXISTS open_issues;
+-- +goose StatementEnd
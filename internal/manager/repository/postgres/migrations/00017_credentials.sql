@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE credentials (
+    id UUID PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    ciphertext BYTEA NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE intents ADD COLUMN credential_id UUID REFERENCES credentials(id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE intents DROP COLUMN IF EXISTS credential_id;
+DROP TABLE IF EXISTS credentials;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose NO TRANSACTION
+-- +goose Up
+ALTER TYPE intent_status ADD VALUE IF NOT EXISTS 'syncing';
+ALTER TYPE intent_status ADD VALUE IF NOT EXISTS 'synced';
+ALTER TYPE intent_status ADD VALUE IF NOT EXISTS 'failed';
+
+ALTER TABLE intents
+    ADD COLUMN sync_started_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN last_synced_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN synced_commits BIGINT NOT NULL DEFAULT 0;
+
+-- +goose Down
+-- Enum values cannot be dropped, so the new statuses are left unused.
+UPDATE intents SET status = 'success_broadcast' WHERE status IN ('syncing', 'synced', 'failed');
+
+ALTER TABLE intents
+    DROP COLUMN IF EXISTS sync_started_at,
+    DROP COLUMN IF EXISTS last_synced_at,
+    DROP COLUMN IF EXISTS synced_commits;
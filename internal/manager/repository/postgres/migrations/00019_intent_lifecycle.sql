@@ -0,0 +1,33 @@
+-- +goose NO TRANSACTION
+-- +goose Up
+ALTER TYPE intent_status RENAME VALUE 'pending_broadcast' TO 'created';
+ALTER TYPE intent_status RENAME VALUE 'success_broadcast' TO 'broadcast';
+ALTER TYPE intent_status RENAME VALUE 'syncing' TO 'fetching';
+ALTER TYPE intent_status RENAME VALUE 'synced' TO 'completed';
+ALTER TYPE intent_status ADD VALUE IF NOT EXISTS 'ingesting' AFTER 'fetching';
+ALTER TYPE intent_status ADD VALUE IF NOT EXISTS 'paused';
+
+UPDATE intents SET status = 'paused' WHERE NOT is_active;
+
+CREATE TABLE intent_status_history (
+    id BIGSERIAL PRIMARY KEY,
+    intent_id UUID NOT NULL REFERENCES intents(id) ON DELETE CASCADE,
+    from_status intent_status,
+    to_status intent_status NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_intent_status_history_intent_id ON intent_status_history(intent_id, id DESC);
+
+-- +goose Down
+DROP TABLE IF EXISTS intent_status_history;
+
+-- Enum values cannot be dropped, so ingesting and paused are folded back
+-- into the statuses they replaced.
+UPDATE intents SET status = 'fetching' WHERE status = 'ingesting';
+UPDATE intents SET status = 'broadcast' WHERE status = 'paused';
+
+ALTER TYPE intent_status RENAME VALUE 'completed' TO 'synced';
+ALTER TYPE intent_status RENAME VALUE 'fetching' TO 'syncing';
+ALTER TYPE intent_status RENAME VALUE 'broadcast' TO 'success_broadcast';
+ALTER TYPE intent_status RENAME VALUE 'created' TO 'pending_broadcast';
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE intents
+    ADD COLUMN retry_max_attempts INT,
+    ADD COLUMN retry_backoff_base_ms INT,
+    ADD COLUMN retry_jitter DOUBLE PRECISION;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE intents
+    DROP COLUMN IF EXISTS retry_max_attempts,
+    DROP COLUMN IF EXISTS retry_backoff_base_ms,
+    DROP COLUMN IF EXISTS retry_jitter;
+-- +goose StatementEnd
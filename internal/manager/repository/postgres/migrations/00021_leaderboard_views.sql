@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE MATERIALIZED VIEW repository_top_committers AS
+SELECT repository_id, author_id, COUNT(*) AS commit_count
+FROM commits
+GROUP BY repository_id, author_id;
+
+-- REFRESH ... CONCURRENTLY needs a unique index.
+CREATE UNIQUE INDEX repository_top_committers_key ON repository_top_committers (repository_id, author_id);
+CREATE INDEX repository_top_committers_rank ON repository_top_committers (repository_id, commit_count DESC);
+
+CREATE MATERIALIZED VIEW repository_daily_commits AS
+SELECT repository_id, (created_at AT TIME ZONE 'UTC')::date AS day, COUNT(*) AS commits
+FROM commits
+GROUP BY repository_id, (created_at AT TIME ZONE 'UTC')::date;
+
+CREATE UNIQUE INDEX repository_daily_commits_key ON repository_daily_commits (repository_id, day);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP MATERIALIZED VIEW IF EXISTS repository_daily_commits;
+DROP MATERIALIZED VIEW IF EXISTS repository_top_committers;
+-- +goose StatementEnd
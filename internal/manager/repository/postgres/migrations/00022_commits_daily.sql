@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE commits_daily (
+    repository_id BIGINT NOT NULL REFERENCES repositories(id),
+    author_id BIGINT NOT NULL REFERENCES authors(id),
+    day DATE NOT NULL,
+    commits INT NOT NULL DEFAULT 0,
+    additions BIGINT NOT NULL DEFAULT 0,
+    deletions BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (repository_id, day, author_id)
+);
+
+-- Existing commits start out pending, so the aggregator backfills them.
+ALTER TABLE commits ADD COLUMN rolled_up BOOLEAN NOT NULL DEFAULT FALSE;
+CREATE INDEX commits_pending_rollup ON commits (hash) WHERE NOT rolled_up;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS commits_pending_rollup;
+ALTER TABLE commits DROP COLUMN IF EXISTS rolled_up;
+DROP TABLE IF EXISTS commits_daily;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Daily counts are read from the commits_daily rollup now.
+DROP MATERIALIZED VIEW IF EXISTS repository_daily_commits;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE MATERIALIZED VIEW repository_daily_commits AS
+SELECT repository_id, (created_at AT TIME ZONE 'UTC')::date AS day, COUNT(*) AS commits
+FROM commits
+GROUP BY repository_id, (created_at AT TIME ZONE 'UTC')::date;
+
+CREATE UNIQUE INDEX repository_daily_commits_key ON repository_daily_commits (repository_id, day);
+-- +goose StatementEnd
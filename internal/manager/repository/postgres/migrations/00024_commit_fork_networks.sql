@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+-- A fork shares its upstream's history, so the same SHA may belong to
+-- several repositories. Each keeps its own row, linked by the hash.
+ALTER TABLE commits DROP CONSTRAINT commits_pkey;
+ALTER TABLE commits ADD PRIMARY KEY (repository_id, hash);
+CREATE INDEX commits_hash ON commits (hash);
+
+DROP INDEX IF EXISTS commits_pending_rollup;
+CREATE INDEX commits_pending_rollup ON commits (repository_id, hash) WHERE NOT rolled_up;
+
+-- The root repository of a fork network, NULL for repositories that are
+-- not forks.
+ALTER TABLE repositories ADD COLUMN network_id BIGINT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE repositories DROP COLUMN IF EXISTS network_id;
+
+DROP INDEX IF EXISTS commits_pending_rollup;
+CREATE INDEX commits_pending_rollup ON commits (hash) WHERE NOT rolled_up;
+
+DROP INDEX IF EXISTS commits_hash;
+DELETE FROM commits c
+USING commits d
+WHERE c.hash = d.hash AND c.repository_id > d.repository_id;
+ALTER TABLE commits DROP CONSTRAINT commits_pkey;
+ALTER TABLE commits ADD PRIMARY KEY (hash);
+-- +goose StatementEnd
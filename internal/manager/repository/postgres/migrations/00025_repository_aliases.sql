@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Names a repository was known by before GitHub renamed or moved it, so
+-- lookups by an old name still find it.
+CREATE TABLE repository_aliases (
+    name TEXT PRIMARY KEY,
+    repository_id BIGINT NOT NULL REFERENCES repositories(id) ON DELETE CASCADE
+);
+
+CREATE INDEX repository_aliases_repository_id ON repository_aliases (repository_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS repository_aliases;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Trigram indexes let the substring matches of GET /search use an index.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX repositories_full_name_trgm ON repositories USING GIN (full_name gin_trgm_ops);
+CREATE INDEX authors_name_trgm ON authors USING GIN (name gin_trgm_ops);
+CREATE INDEX authors_username_trgm ON authors USING GIN (username gin_trgm_ops);
+CREATE INDEX commits_message_trgm ON commits USING GIN (message gin_trgm_ops);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS commits_message_trgm;
+DROP INDEX IF EXISTS authors_username_trgm;
+DROP INDEX IF EXISTS authors_name_trgm;
+DROP INDEX IF EXISTS repositories_full_name_trgm;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Intent commands wait here until the broadcaster has published them, so
+-- API requests never wait on the broker and a restart loses nothing.
+CREATE TABLE intent_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    intent_id UUID NOT NULL REFERENCES intents(id) ON DELETE CASCADE,
+    command JSONB NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS intent_outbox;
+-- +goose StatementEnd
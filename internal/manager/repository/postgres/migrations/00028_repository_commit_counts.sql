@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Kept up to date by SaveManyCommit, so sizing a repository's index
+-- doesn't need a COUNT over its commits.
+ALTER TABLE repositories
+    ADD COLUMN commit_count BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN last_commit_at TIMESTAMPTZ;
+
+UPDATE repositories r
+SET commit_count = c.commits, last_commit_at = c.last_commit_at
+FROM (
+    SELECT repository_id, COUNT(*) AS commits, MAX(created_at) AS last_commit_at
+    FROM commits
+    GROUP BY repository_id
+) c
+WHERE c.repository_id = r.id;
+
+CREATE INDEX repositories_commit_count ON repositories (commit_count);
+CREATE INDEX repositories_last_commit_at ON repositories (last_commit_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS repositories_last_commit_at;
+DROP INDEX IF EXISTS repositories_commit_count;
+ALTER TABLE repositories
+    DROP COLUMN IF EXISTS last_commit_at,
+    DROP COLUMN IF EXISTS commit_count;
+-- +goose StatementEnd
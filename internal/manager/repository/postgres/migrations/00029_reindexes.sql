@@ -0,0 +1,40 @@
+-- +goose Up
+-- +goose StatementBegin
+-- A reindex rebuilds a repository's commits into commits_shadow, then swaps
+-- them for the live commits in one transaction once they check out.
+CREATE TABLE reindexes (
+    id UUID PRIMARY KEY,
+    repository_id BIGINT NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+    intent_id UUID NOT NULL REFERENCES intents(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'building'
+        CHECK (status IN ('building', 'swapped', 'failed', 'aborted')),
+    expected_commits BIGINT,
+    error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMPTZ
+);
+
+-- A repository rebuilds into one shadow at a time.
+CREATE UNIQUE INDEX reindexes_building ON reindexes (repository_id) WHERE status = 'building';
+CREATE INDEX reindexes_intent_id ON reindexes (intent_id, created_at DESC);
+
+CREATE TABLE commits_shadow (
+    reindex_id UUID NOT NULL REFERENCES reindexes(id) ON DELETE CASCADE,
+    hash TEXT NOT NULL,
+    author_id BIGINT NOT NULL REFERENCES authors(id),
+    message TEXT NOT NULL,
+    url TEXT DEFAULT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    repository_id BIGINT NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+    additions INT,
+    deletions INT,
+    changes INT,
+    PRIMARY KEY (reindex_id, hash)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS commits_shadow;
+DROP TABLE IF EXISTS reindexes;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Forced broadcasts and reindex runs skip discovery and go straight to the
+-- monitor's queue. NULL is discovery's intents queue.
+ALTER TABLE intent_outbox ADD COLUMN queue TEXT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE intent_outbox DROP COLUMN IF EXISTS queue;
+-- +goose StatementEnd
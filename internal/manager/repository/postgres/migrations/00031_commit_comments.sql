@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Comments are kept per repository like the commits they belong to, as a
+-- fork's commits carry its upstream's comments too.
+CREATE TABLE commit_comments (
+    id BIGINT NOT NULL,
+    repository_id BIGINT NOT NULL REFERENCES repositories(id),
+    commit_hash TEXT NOT NULL,
+    author TEXT NOT NULL,
+    body TEXT NOT NULL,
+    url TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (repository_id, id)
+);
+
+CREATE INDEX commit_comments_commit ON commit_comments (repository_id, commit_hash, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS commit_comments;
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Each review carries what turnaround needs of its pull request, so pull
+-- requests themselves aren't stored.
+CREATE TABLE pull_request_reviews (
+    id BIGINT NOT NULL,
+    repository_id BIGINT NOT NULL REFERENCES repositories(id),
+    pull_number INT NOT NULL,
+    pull_author TEXT NOT NULL,
+    pull_created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    reviewer TEXT NOT NULL,
+    state TEXT NOT NULL,
+    submitted_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (repository_id, id)
+);
+
+CREATE INDEX pull_request_reviews_pull ON pull_request_reviews (repository_id, pull_created_at, pull_number);
+CREATE INDEX pull_request_reviews_submitted ON pull_request_reviews (repository_id, submitted_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS pull_request_reviews;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE workflow_runs (
+    id BIGINT NOT NULL,
+    repository_id BIGINT NOT NULL REFERENCES repositories(id),
+    workflow TEXT NOT NULL,
+    head_sha TEXT NOT NULL,
+    branch TEXT NOT NULL,
+    event TEXT NOT NULL,
+    conclusion TEXT NOT NULL,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    completed_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (repository_id, id)
+);
+
+CREATE INDEX workflow_runs_started ON workflow_runs (repository_id, started_at);
+CREATE INDEX workflow_runs_head_sha ON workflow_runs (repository_id, head_sha);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS workflow_runs;
+-- +goose StatementEnd
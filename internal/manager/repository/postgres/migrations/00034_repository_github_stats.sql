@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The latest snapshot of GitHub's precomputed stats per repository, one
+-- JSON document per kind.
+CREATE TABLE repository_github_stats (
+    repository_id BIGINT PRIMARY KEY REFERENCES repositories(id),
+    code_frequency JSONB,
+    participation JSONB,
+    punch_card JSONB,
+    fetched_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS repository_github_stats;
+-- +goose StatementEnd
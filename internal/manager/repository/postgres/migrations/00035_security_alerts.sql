@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE security_alerts (
+    repository_id BIGINT NOT NULL REFERENCES repositories(id),
+    number INT NOT NULL,
+    state TEXT NOT NULL,
+    severity TEXT NOT NULL,
+    package TEXT NOT NULL,
+    ecosystem TEXT NOT NULL,
+    manifest_path TEXT NOT NULL,
+    advisory_id TEXT NOT NULL,
+    summary TEXT NOT NULL,
+    url TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    fixed_at TIMESTAMP WITH TIME ZONE,
+    dismissed_at TIMESTAMP WITH TIME ZONE,
+    PRIMARY KEY (repository_id, number)
+);
+
+CREATE INDEX security_alerts_open ON security_alerts (repository_id) WHERE state = 'open';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS security_alerts;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Deleted intents are kept, with their history and errors, but left out
+-- of every listing.
+ALTER TABLE intents ADD COLUMN deleted_at TIMESTAMPTZ;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE intents DROP COLUMN IF EXISTS deleted_at;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Tags are set by the manager's commit hooks, such as the ticket IDs a
+-- commit message mentions.
+ALTER TABLE commits ADD COLUMN tags TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE commits_shadow ADD COLUMN tags TEXT[] NOT NULL DEFAULT '{}';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE commits_shadow DROP COLUMN IF EXISTS tags;
+ALTER TABLE commits DROP COLUMN IF EXISTS tags;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+-- A subscription emails the weekly digest of one repository, or of every
+-- repository with a GitHub topic (its label), to one address. last_week is
+-- the Monday of the last week sent.
+CREATE TABLE digest_subscriptions (
+    id UUID PRIMARY KEY,
+    email TEXT NOT NULL,
+    repository TEXT NOT NULL DEFAULT '',
+    label TEXT NOT NULL DEFAULT '',
+    last_week DATE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((repository = '') <> (label = '')),
+    UNIQUE (email, repository, label)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS digest_subscriptions;
+-- +goose StatementEnd
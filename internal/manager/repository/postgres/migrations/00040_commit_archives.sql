@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Commits older than the archive age are moved out to Parquet objects,
+-- one row per object. Their rollups stay in commits_daily.
+CREATE TABLE commit_archives (
+    id UUID PRIMARY KEY,
+    repository_id BIGINT NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+    object_key TEXT NOT NULL,
+    commits INT NOT NULL,
+    first_commit_at TIMESTAMPTZ NOT NULL,
+    last_commit_at TIMESTAMPTZ NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX commit_archives_repository ON commit_archives (repository_id, first_commit_at);
+
+-- Every commit of a repository authored before archived_before has been
+-- archived, so commits that old are not saved again. A replica archiving
+-- a repository holds it until leased_until.
+CREATE TABLE repository_archives (
+    repository_id BIGINT PRIMARY KEY REFERENCES repositories(id) ON DELETE CASCADE,
+    archived_before TIMESTAMPTZ,
+    leased_until TIMESTAMPTZ
+);
+
+CREATE INDEX commits_repository_created_at ON commits (repository_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS commits_repository_created_at;
+DROP TABLE IF EXISTS repository_archives;
+DROP TABLE IF EXISTS commit_archives;
+-- +goose StatementEnd
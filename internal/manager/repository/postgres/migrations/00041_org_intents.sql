@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- An intent for owner/* indexes every repository of the owner through a
+-- child intent per repository, which points back to it.
+ALTER TABLE intents ADD COLUMN parent_id UUID REFERENCES intents(id);
+CREATE UNIQUE INDEX intents_parent_id_idx ON intents (parent_id, repository_name) WHERE parent_id IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS intents_parent_id_idx;
+ALTER TABLE intents DROP COLUMN IF EXISTS parent_id;
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- GET /search/commits ranks commit messages by full-text search over this
+-- column, which Postgres keeps up to date as commits are saved.
+ALTER TABLE commits ADD COLUMN message_tsv TSVECTOR
+    GENERATED ALWAYS AS (to_tsvector('english', message)) STORED;
+CREATE INDEX commits_message_tsv_idx ON commits USING GIN (message_tsv);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS commits_message_tsv_idx;
+ALTER TABLE commits DROP COLUMN IF EXISTS message_tsv;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- GET /authors/:username looks authors up by their GitHub login, which
+-- GitHub matches case-insensitively, and aggregates their commits.
+CREATE INDEX authors_username_lower ON authors (lower(username));
+CREATE INDEX commits_author_id ON commits (author_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS commits_author_id;
+DROP INDEX IF EXISTS authors_username_lower;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- How far the latest monitor run of each intent has got, replaced by every
+-- progress report of the run.
+CREATE TABLE intent_progress (
+    intent_id UUID PRIMARY KEY REFERENCES intents(id) ON DELETE CASCADE,
+    commits BIGINT NOT NULL DEFAULT 0,
+    pages BIGINT NOT NULL DEFAULT 0,
+    last_commit_date TIMESTAMPTZ,
+    started_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS intent_progress;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- When an intent was paused, cleared when it is resumed.
+ALTER TABLE intents ADD COLUMN paused_at TIMESTAMPTZ;
+UPDATE intents SET paused_at = updated_at WHERE status = 'paused';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE intents DROP COLUMN IF EXISTS paused_at;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The daily rollup is keyed by repository first, so ranking repositories
+-- over a window of days needs an index on the day.
+CREATE INDEX commits_daily_day ON commits_daily (day, repository_id);
+CREATE INDEX repositories_language ON repositories (lower(language));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS repositories_language;
+DROP INDEX IF EXISTS commits_daily_day;
+-- +goose StatementEnd
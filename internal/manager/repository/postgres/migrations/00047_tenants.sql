@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Every intent, repository and commit belongs to a tenant. A repository
+-- belongs to the tenant of its latest intent, and its commits with it.
+-- Keys without a tenant see every tenant's records.
+ALTER TABLE intents ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE repositories ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE commits ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE api_keys ADD COLUMN tenant_id TEXT;
+
+CREATE INDEX intents_tenant_id ON intents (tenant_id, created_at DESC);
+CREATE INDEX repositories_tenant_id ON repositories (tenant_id);
+CREATE INDEX commits_tenant_id ON commits (tenant_id, created_at DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS commits_tenant_id;
+DROP INDEX IF EXISTS repositories_tenant_id;
+DROP INDEX IF EXISTS intents_tenant_id;
+ALTER TABLE api_keys DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE commits DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE repositories DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE intents DROP COLUMN IF EXISTS tenant_id;
+-- +goose StatementEnd
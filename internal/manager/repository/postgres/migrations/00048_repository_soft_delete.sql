@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Deleted repositories are left out of every lookup and listing, and
+-- their commits with them, until the purge deletes them for good. Commits
+-- are hidden through their repository rather than marked one by one, which
+-- would rewrite every row of a large repository.
+ALTER TABLE repositories ADD COLUMN deleted_at TIMESTAMPTZ;
+CREATE INDEX repositories_deleted_at ON repositories (deleted_at) WHERE deleted_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS repositories_deleted_at;
+ALTER TABLE repositories DROP COLUMN IF EXISTS deleted_at;
+-- +goose StatementEnd
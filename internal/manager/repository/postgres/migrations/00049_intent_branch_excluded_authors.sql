@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- An intent may index a single named branch, and leave out the commits of
+-- some authors.
+ALTER TABLE intents
+    ADD COLUMN branch TEXT,
+    ADD COLUMN excluded_authors TEXT[] NOT NULL DEFAULT '{}';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE intents
+    DROP COLUMN IF EXISTS excluded_authors,
+    DROP COLUMN IF EXISTS branch;
+-- +goose StatementEnd
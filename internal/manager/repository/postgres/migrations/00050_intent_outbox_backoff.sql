@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- A command that fails to publish waits out a backoff of its own instead of
+-- holding back the commands queued after it.
+ALTER TABLE intent_outbox ADD COLUMN next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP;
+CREATE INDEX idx_intent_outbox_next_attempt_at ON intent_outbox (next_attempt_at, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_intent_outbox_next_attempt_at;
+ALTER TABLE intent_outbox DROP COLUMN IF EXISTS next_attempt_at;
+-- +goose StatementEnd
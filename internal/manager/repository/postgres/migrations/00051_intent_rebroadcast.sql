@@ -0,0 +1,18 @@
+-- +goose NO TRANSACTION
+-- +goose Up
+-- A rebroadcast intent waits in pending_broadcast until the manager has
+-- published it to discovery.
+ALTER TYPE intent_status ADD VALUE IF NOT EXISTS 'pending_broadcast' BEFORE 'broadcast';
+
+-- When each intent was last forced out, so every replica keeps to the same
+-- cooldown.
+CREATE TABLE IF NOT EXISTS intent_forced_broadcasts (
+    intent_id UUID PRIMARY KEY REFERENCES intents(id) ON DELETE CASCADE,
+    forced_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS intent_forced_broadcasts;
+
+-- Enum values cannot be dropped, so pending intents go back to created.
+UPDATE intents SET status = 'created' WHERE status = 'pending_broadcast';
@@ -0,0 +1,64 @@
+-- +goose Up
+-- +goose StatementBegin
+-- A repository and its commits belonged to the tenant of its latest
+-- intent, so creating an intent took the repository away from the tenant
+-- tracking it. Tenants now track a repository side by side: a tenant sees
+-- it, and its commits, once a run of one of the tenant's intents has
+-- fetched it, which the intent's credential can only do if it can read the
+-- repository.
+CREATE TABLE repository_tenants (
+    tenant_id TEXT NOT NULL,
+    repository_id BIGINT NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+    PRIMARY KEY (tenant_id, repository_id)
+);
+CREATE INDEX repository_tenants_repository_id ON repository_tenants (repository_id);
+
+INSERT INTO repository_tenants (tenant_id, repository_id)
+SELECT tenant_id, id FROM repositories;
+
+DROP INDEX IF EXISTS commits_tenant_id;
+DROP INDEX IF EXISTS repositories_tenant_id;
+ALTER TABLE commits DROP COLUMN tenant_id;
+ALTER TABLE repositories DROP COLUMN tenant_id;
+
+-- Each tenant has its own active intent per repository, so creating one
+-- says nothing about other tenants' intents.
+DROP INDEX IF EXISTS idx_intents_active_repository_name;
+CREATE UNIQUE INDEX idx_intents_active_repository_name ON intents(tenant_id, lower(repository_name)) WHERE is_active;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+UPDATE intents SET is_active = FALSE
+WHERE is_active AND id NOT IN (
+    SELECT DISTINCT ON (lower(repository_name)) id
+    FROM intents
+    WHERE is_active
+    ORDER BY lower(repository_name), created_at DESC
+);
+
+DROP INDEX IF EXISTS idx_intents_active_repository_name;
+CREATE UNIQUE INDEX idx_intents_active_repository_name ON intents(lower(repository_name)) WHERE is_active;
+
+ALTER TABLE repositories ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE commits ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default';
+
+-- A repository goes back to a single tenant, the one of its latest intent.
+UPDATE repositories r SET tenant_id = i.tenant_id
+FROM (
+    SELECT DISTINCT ON (repository_name) repository_name, tenant_id
+    FROM intents
+    WHERE deleted_at IS NULL
+    ORDER BY repository_name, created_at DESC
+) i
+WHERE i.repository_name = r.full_name;
+
+UPDATE commits c SET tenant_id = r.tenant_id
+FROM repositories r
+WHERE c.repository_id = r.id AND r.tenant_id <> 'default';
+
+CREATE INDEX repositories_tenant_id ON repositories (tenant_id);
+CREATE INDEX commits_tenant_id ON commits (tenant_id, created_at DESC);
+
+DROP TABLE IF EXISTS repository_tenants;
+-- +goose StatementEnd
@@ -0,0 +1,54 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/noelukwa/indexer/internal/manager/models"
+	"github.com/noelukwa/indexer/internal/manager/repository/postgres/sqlc"
+)
+
+// GetQuality counts the repository's commits that are unattributed, by
+// bots or indexed under other repositories too. BotPercentage and
+// Coverage are left for the caller.
+func (p *pgStore) GetQuality(ctx context.Context, repoID int64) (*models.QualityReport, error) {
+	row, err := p.q.GetRepoQuality(ctx, repoID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.QualityReport{
+		Commits:             row.Commits,
+		UnattributedCommits: row.Unattributed,
+		BotCommits:          row.Bots,
+		DuplicateHashes:     row.Duplicates,
+		FirstCommitAt:       fromTimestamptz(row.FirstCommitAt),
+		LastCommitAt:        fromTimestamptz(row.LastCommitAt),
+		LastReconciledAt:    fromTimestamptz(row.LastReconciledAt),
+	}, nil
+}
+
+// GetCoverageGaps finds the runs of at least minDays days between
+// startDate and endDate, both inclusive, in which the repository has no
+// commits, oldest first.
+func (p *pgStore) GetCoverageGaps(ctx context.Context, repoID int64, startDate, endDate time.Time, minDays int) ([]models.CoverageGap, error) {
+	rows, err := p.q.GetCoverageGaps(ctx, sqlc.GetCoverageGapsParams{
+		RepositoryID: repoID,
+		Column2:      pgtype.Date{Time: startDate, Valid: true},
+		Column3:      pgtype.Date{Time: endDate, Valid: true},
+		Column4:      int32(minDays),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	gaps := make([]models.CoverageGap, 0, len(rows))
+	for _, row := range rows {
+		gaps = append(gaps, models.CoverageGap{
+			Start: row.GapStart.Time.Format(time.DateOnly),
+			End:   row.GapEnd.Time.Format(time.DateOnly),
+			Days:  int(row.GapEnd.Time.Sub(row.GapStart.Time).Hours()/24) + 1,
+		})
+	}
+	return gaps, nil
+}
@@ -0,0 +1,20 @@
+-- name: FindAPIKey :one
+SELECT * FROM api_keys
+WHERE key_hash = $1 AND revoked_at IS NULL;
+
+-- name: FindAPIKeys :many
+SELECT * FROM api_keys
+ORDER BY created_at;
+
+-- name: RevokeAPIKey :execrows
+UPDATE api_keys SET revoked_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND revoked_at IS NULL;
+
+-- name: SaveAPIKey :one
+INSERT INTO api_keys (id, name, key_hash, prefix, role, tenant_id)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: TouchAPIKey :exec
+UPDATE api_keys SET last_used_at = CURRENT_TIMESTAMP
+WHERE id = $1;
@@ -0,0 +1,54 @@
+-- name: ClaimRepoArchive :one
+-- A replica archiving a repository holds it until its lease runs out.
+INSERT INTO repository_archives (repository_id, archived_before, leased_until)
+VALUES ($1, $2, $3)
+ON CONFLICT (repository_id) DO UPDATE SET
+    archived_before = GREATEST(repository_archives.archived_before, EXCLUDED.archived_before),
+    leased_until = EXCLUDED.leased_until
+WHERE repository_archives.leased_until IS NULL OR repository_archives.leased_until < now()
+RETURNING repository_id;
+
+-- name: DeleteArchivedCommits :execrows
+DELETE FROM commits
+WHERE repository_id = $1 AND hash = ANY($2::text[]);
+
+-- name: FindArchivableCommits :many
+-- Only rolled up commits are archived, so the rollups keep counting them.
+SELECT c.hash, c.message, c.url, c.created_at, c.additions, c.deletions, c.changes, c.tags,
+    a.id, a.name, a.email, a.username
+FROM commits c
+JOIN authors a ON c.author_id = a.id
+WHERE c.repository_id = $1
+    AND c.rolled_up
+    AND c.created_at < $2
+ORDER BY c.created_at, c.hash
+LIMIT $3;
+
+-- name: FindArchivableRepos :many
+SELECT r.id, r.full_name
+FROM repositories r
+WHERE r.deleted_at IS NULL AND EXISTS (
+    SELECT 1 FROM commits c
+    WHERE c.repository_id = r.id AND c.rolled_up AND c.created_at < $1
+)
+ORDER BY r.id;
+
+-- name: FindCommitArchives :many
+SELECT * FROM commit_archives
+WHERE repository_id = $1
+ORDER BY first_commit_at, created_at;
+
+-- name: GetArchiveSummary :one
+SELECT a.archived_before, COALESCE(SUM(c.commits), 0)::bigint AS commits
+FROM repository_archives a
+LEFT JOIN commit_archives c ON c.repository_id = a.repository_id
+WHERE a.repository_id = $1
+GROUP BY a.archived_before;
+
+-- name: ReleaseRepoArchive :exec
+UPDATE repository_archives SET leased_until = NULL
+WHERE repository_id = $1;
+
+-- name: SaveCommitArchive :exec
+INSERT INTO commit_archives (id, repository_id, object_key, commits, first_commit_at, last_commit_at, size_bytes)
+VALUES ($1, $2, $3, $4, $5, $6, $7);
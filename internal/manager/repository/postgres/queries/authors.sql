@@ -0,0 +1,19 @@
+-- name: GetAuthorByUsername :one
+SELECT * FROM authors
+WHERE lower(username) = lower($1)
+ORDER BY id
+LIMIT 1;
+
+-- name: GetAuthorRepoActivity :many
+SELECT
+    r.full_name AS repository,
+    COUNT(*)::bigint AS commits,
+    MIN(c.created_at)::timestamptz AS first_commit_at,
+    MAX(c.created_at)::timestamptz AS last_commit_at
+FROM commits c
+JOIN repositories r ON c.repository_id = r.id
+WHERE c.author_id = sqlc.arg('author_id')
+    AND r.deleted_at IS NULL
+    AND (sqlc.narg('tenant_id')::text IS NULL OR EXISTS (SELECT 1 FROM repository_tenants t WHERE t.repository_id = c.repository_id AND t.tenant_id = sqlc.narg('tenant_id')))
+GROUP BY r.full_name
+ORDER BY commits DESC, r.full_name;
@@ -1,16 +1,93 @@
 -- name: SaveRepo :exec
-INSERT INTO repositories (id, watchers, stargazers, full_name, created_at, updated_at, language, forks)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+-- A deleted repository is left as it was until it is purged.
+INSERT INTO repositories (
+    id, watchers, stargazers, full_name, created_at, updated_at, language, forks, topics, license, default_branch,
+    description, homepage, open_issues, archived, network_id
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
 ON CONFLICT (full_name) DO UPDATE SET
     watchers = EXCLUDED.watchers,
     stargazers = EXCLUDED.stargazers,
     updated_at = EXCLUDED.updated_at,
     language = EXCLUDED.language,
-    forks = EXCLUDED.forks;
+    forks = EXCLUDED.forks,
+    topics = EXCLUDED.topics,
+    license = EXCLUDED.license,
+    default_branch = EXCLUDED.default_branch,
+    description = EXCLUDED.description,
+    homepage = EXCLUDED.homepage,
+    open_issues = EXCLUDED.open_issues,
+    archived = EXCLUDED.archived,
+    network_id = EXCLUDED.network_id
+WHERE repositories.deleted_at IS NULL;
 
 -- name: GetRepo :one
 SELECT * FROM repositories
-WHERE full_name = $1;
+WHERE (full_name = $1
+    OR id = (SELECT repository_id FROM repository_aliases WHERE name = $1))
+    AND deleted_at IS NULL
+ORDER BY full_name = $1 DESC
+LIMIT 1;
+
+-- name: DeleteRepo :one
+UPDATE repositories SET deleted_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING *;
+
+-- name: FindDeletedRepos :many
+SELECT * FROM repositories
+WHERE deleted_at < $1
+ORDER BY deleted_at;
+
+-- name: PurgeRepo :one
+-- Deletes a deleted repository for good, with everything indexed of it,
+-- and counts its commits. Its aliases, reindexes and archives cascade.
+WITH repo AS (
+    DELETE FROM repositories WHERE id = $1 AND deleted_at IS NOT NULL
+    RETURNING id
+), comments AS (
+    DELETE FROM commit_comments WHERE repository_id IN (SELECT id FROM repo)
+), daily AS (
+    DELETE FROM commits_daily WHERE repository_id IN (SELECT id FROM repo)
+), reviews AS (
+    DELETE FROM pull_request_reviews WHERE repository_id IN (SELECT id FROM repo)
+), runs AS (
+    DELETE FROM workflow_runs WHERE repository_id IN (SELECT id FROM repo)
+), github AS (
+    DELETE FROM repository_github_stats WHERE repository_id IN (SELECT id FROM repo)
+), alerts AS (
+    DELETE FROM security_alerts WHERE repository_id IN (SELECT id FROM repo)
+), purged AS (
+    DELETE FROM commits WHERE repository_id IN (SELECT id FROM repo)
+    RETURNING 1
+)
+SELECT COUNT(*) FROM purged;
+
+-- name: RenameRepo :one
+WITH previous AS (
+    SELECT id, full_name FROM repositories
+    WHERE id = $1 AND full_name <> sqlc.arg('full_name')::text
+    FOR UPDATE
+), renamed AS (
+    UPDATE repositories r SET full_name = sqlc.arg('full_name')::text
+    FROM previous
+    WHERE r.id = previous.id
+), aliased AS (
+    INSERT INTO repository_aliases (name, repository_id)
+    SELECT full_name, id FROM previous
+    ON CONFLICT (name) DO UPDATE SET repository_id = EXCLUDED.repository_id
+), unaliased AS (
+    DELETE FROM repository_aliases
+    WHERE name = sqlc.arg('full_name')::text
+), moved AS (
+    UPDATE intents SET repository_name = sqlc.arg('full_name')::text
+    WHERE repository_name IN (SELECT full_name FROM previous)
+        AND NOT (is_active AND EXISTS (
+            SELECT 1 FROM intents i
+            WHERE i.repository_name = sqlc.arg('full_name')::text AND i.is_active
+        ))
+)
+SELECT full_name FROM previous;
 
 -- name: GetAuthor :one
 SELECT * FROM authors
@@ -25,10 +102,46 @@ ON CONFLICT (id) DO UPDATE SET
     username = EXCLUDED.username
 RETURNING *;
 
--- name: SaveCommit :exec
-INSERT INTO commits (hash, author_id, message, url, created_at, repository_id)
-VALUES ($1, $2, $3, $4, $5, $6)
-ON CONFLICT (hash) DO NOTHING;
+-- name: SaveAuthors :exec
+INSERT INTO authors (id, name, email, username)
+SELECT * FROM unnest(
+    sqlc.arg('ids')::bigint[],
+    sqlc.arg('names')::text[],
+    sqlc.arg('emails')::text[],
+    sqlc.arg('usernames')::text[]
+) AS a (id, name, email, username)
+ON CONFLICT (id) DO NOTHING;
+
+-- name: SaveCommit :execrows
+-- Commits older than the repository's archive are in it already.
+INSERT INTO commits (hash, author_id, message, url, created_at, repository_id, additions, deletions, changes, tags)
+SELECT $1, $2, $3, $4, $5::timestamptz, $6::bigint, $7, $8, $9, $10
+FROM repositories r
+WHERE r.id = $6
+    AND r.deleted_at IS NULL
+    AND NOT EXISTS (
+        SELECT 1 FROM repository_archives a
+        WHERE a.repository_id = $6 AND $5 < a.archived_before
+    )
+ON CONFLICT (repository_id, hash) DO NOTHING;
+
+-- name: SaveCommitComments :exec
+INSERT INTO commit_comments (id, repository_id, commit_hash, author, body, url, created_at)
+SELECT c.id, sqlc.arg('repository_id')::bigint, c.commit_hash, c.author, c.body, NULLIF(c.url, ''), c.created_at
+FROM unnest(
+    sqlc.arg('ids')::bigint[],
+    sqlc.arg('commit_hashes')::text[],
+    sqlc.arg('authors')::text[],
+    sqlc.arg('bodies')::text[],
+    sqlc.arg('urls')::text[],
+    sqlc.arg('created_ats')::timestamptz[]
+) AS c (id, commit_hash, author, body, url, created_at)
+ON CONFLICT (repository_id, id) DO UPDATE SET body = EXCLUDED.body;
+
+-- name: FindCommitComments :many
+SELECT id, author, body, url, created_at FROM commit_comments
+WHERE repository_id = $1 AND commit_hash = $2
+ORDER BY created_at, id;
 
 
 -- name: FindCommits :many
@@ -67,12 +180,237 @@ WHERE r.full_name = $1
     AND ($2::timestamptz IS NULL OR c.created_at >= $2)
     AND ($3::timestamptz IS NULL OR c.created_at <= $3)
 GROUP BY a.id, a.name, a.email, a.username
-ORDER BY commit_count DESC
+ORDER BY commit_count DESC, a.id
 LIMIT $4 OFFSET $5;
 
+-- name: CountTopCommitters :one
+SELECT COUNT(DISTINCT c.author_id)
+FROM commits c
+JOIN repositories r ON c.repository_id = r.id
+WHERE r.full_name = $1
+    AND ($2::timestamptz IS NULL OR c.created_at >= $2)
+    AND ($3::timestamptz IS NULL OR c.created_at <= $3);
+
+-- name: GetLeaderboard :many
+SELECT a.id, a.name, a.email, a.username, t.commit_count
+FROM repository_top_committers t
+JOIN repositories r ON t.repository_id = r.id
+JOIN authors a ON t.author_id = a.id
+WHERE r.full_name = $1
+ORDER BY t.commit_count DESC, a.id
+LIMIT $2 OFFSET $3;
+
+-- name: CountLeaderboard :one
+SELECT COUNT(*)
+FROM repository_top_committers t
+JOIN repositories r ON t.repository_id = r.id
+WHERE r.full_name = $1;
+
+-- name: GetContributions :many
+SELECT a.id, a.name, a.email, a.username,
+    date_trunc('month', d.day)::date AS month,
+    SUM(d.commits)::bigint AS commits
+FROM commits_daily d
+JOIN repositories r ON d.repository_id = r.id
+JOIN authors a ON d.author_id = a.id
+WHERE r.full_name = $1
+    AND ($2::date IS NULL OR d.day >= $2)
+    AND ($3::date IS NULL OR d.day <= $3)
+GROUP BY a.id, a.name, a.email, a.username, month
+ORDER BY a.id, month;
+
+-- name: GetCommitActivity :many
+WITH buckets AS (
+    SELECT date_trunc(sqlc.arg('bucket')::text, d.day)::date AS bucket, SUM(d.commits)::bigint AS commits
+    FROM commits_daily d
+    JOIN repositories r ON d.repository_id = r.id
+    WHERE r.full_name = sqlc.arg('full_name')
+        AND (sqlc.narg('start_date')::date IS NULL OR d.day >= sqlc.narg('start_date')::date)
+        AND (sqlc.narg('end_date')::date IS NULL OR d.day <= sqlc.narg('end_date')::date)
+    GROUP BY 1
+), bounds AS (
+    SELECT date_trunc(sqlc.arg('bucket')::text, COALESCE(sqlc.narg('start_date')::date, MIN(bucket)))::date AS first_bucket,
+        date_trunc(sqlc.arg('bucket')::text, COALESCE(sqlc.narg('end_date')::date, MAX(bucket)))::date AS last_bucket
+    FROM buckets
+)
+SELECT s.bucket::date AS bucket, COALESCE(buckets.commits, 0)::bigint AS commits
+FROM bounds
+CROSS JOIN generate_series(bounds.first_bucket, bounds.last_bucket, ('1 ' || sqlc.arg('bucket')::text)::interval) AS s(bucket)
+LEFT JOIN buckets ON buckets.bucket = s.bucket::date
+ORDER BY s.bucket;
+
+-- name: GetDailyCommits :many
+WITH days AS (
+    SELECT d.day, SUM(d.commits)::bigint AS commits,
+        SUM(d.additions)::bigint AS additions, SUM(d.deletions)::bigint AS deletions
+    FROM commits_daily d
+    JOIN repositories r ON d.repository_id = r.id
+    WHERE r.full_name = $1
+    GROUP BY d.day
+), bounds AS (
+    SELECT COALESCE($2::date, MIN(day)) AS first_day, COALESCE($3::date, MAX(day)) AS last_day
+    FROM days
+)
+SELECT s.day::date AS day,
+    COALESCE(days.commits, 0)::bigint AS commits,
+    COALESCE(days.additions, 0)::bigint AS additions,
+    COALESCE(days.deletions, 0)::bigint AS deletions
+FROM bounds
+CROSS JOIN generate_series(bounds.first_day, bounds.last_day, interval '1 day') AS s(day)
+LEFT JOIN days ON days.day = s.day::date
+ORDER BY s.day;
+
+-- name: GetDedupedDailyCommits :many
+WITH days AS (
+    SELECT (c.created_at AT TIME ZONE 'UTC')::date AS day, COUNT(*) AS commits,
+        COALESCE(SUM(c.additions), 0)::bigint AS additions, COALESCE(SUM(c.deletions), 0)::bigint AS deletions
+    FROM commits c
+    JOIN repositories r ON c.repository_id = r.id
+    WHERE r.full_name = $1
+    AND NOT EXISTS (
+        SELECT 1 FROM commits s
+        JOIN repositories u ON s.repository_id = u.id
+        WHERE s.hash = c.hash
+            AND COALESCE(u.network_id, u.id) = COALESCE(r.network_id, r.id)
+            AND (u.created_at, u.id) < (r.created_at, r.id)
+    )
+    GROUP BY 1
+), bounds AS (
+    SELECT COALESCE($2::date, MIN(day)) AS first_day, COALESCE($3::date, MAX(day)) AS last_day
+    FROM days
+)
+SELECT s.day::date AS day,
+    COALESCE(days.commits, 0)::bigint AS commits,
+    COALESCE(days.additions, 0)::bigint AS additions,
+    COALESCE(days.deletions, 0)::bigint AS deletions
+FROM bounds
+CROSS JOIN generate_series(bounds.first_day, bounds.last_day, interval '1 day') AS s(day)
+LEFT JOIN days ON days.day = s.day::date
+ORDER BY s.day;
+
+-- name: PurgeRepoCommits :execrows
+WITH repo AS (
+    SELECT id FROM repositories WHERE full_name = $1
+), comments AS (
+    DELETE FROM commit_comments WHERE repository_id IN (SELECT id FROM repo)
+), daily AS (
+    DELETE FROM commits_daily WHERE repository_id IN (SELECT id FROM repo)
+), archives AS (
+    DELETE FROM commit_archives WHERE repository_id IN (SELECT id FROM repo)
+), archived AS (
+    DELETE FROM repository_archives WHERE repository_id IN (SELECT id FROM repo)
+), counts AS (
+    UPDATE repositories SET commit_count = 0, last_commit_at = NULL
+    WHERE id IN (SELECT id FROM repo)
+)
+DELETE FROM commits WHERE repository_id IN (SELECT id FROM repo);
+
+-- name: RefreshTopCommitters :exec
+REFRESH MATERIALIZED VIEW CONCURRENTLY repository_top_committers;
+
+-- name: RollupCommits :many
+WITH batch AS (
+    UPDATE commits SET rolled_up = TRUE
+    WHERE (repository_id, hash) IN (
+        SELECT repository_id, hash FROM commits
+        WHERE NOT rolled_up
+        LIMIT $1
+        FOR UPDATE SKIP LOCKED
+    )
+    RETURNING repository_id, author_id, created_at, additions, deletions
+), rolled AS (
+    INSERT INTO commits_daily (repository_id, author_id, day, commits, additions, deletions)
+    SELECT repository_id, author_id, (created_at AT TIME ZONE 'UTC')::date,
+        COUNT(*), COALESCE(SUM(additions), 0), COALESCE(SUM(deletions), 0)
+    FROM batch
+    GROUP BY repository_id, author_id, (created_at AT TIME ZONE 'UTC')::date
+    ON CONFLICT (repository_id, day, author_id) DO UPDATE SET
+        commits = commits_daily.commits + EXCLUDED.commits,
+        additions = commits_daily.additions + EXCLUDED.additions,
+        deletions = commits_daily.deletions + EXCLUDED.deletions
+)
+SELECT r.full_name, COUNT(*)::bigint AS commits
+FROM batch b
+JOIN repositories r ON r.id = b.repository_id
+GROUP BY r.full_name;
+
+-- name: GetRepoStats :one
+SELECT
+    COALESCE(SUM(d.commits), 0)::bigint AS commits,
+    COALESCE(SUM(d.additions), 0)::bigint AS additions,
+    COALESCE(SUM(d.deletions), 0)::bigint AS deletions,
+    COUNT(DISTINCT d.author_id) AS authors,
+    COUNT(DISTINCT d.day) AS active_days
+FROM commits_daily d
+JOIN repositories r ON d.repository_id = r.id
+WHERE r.full_name = $1
+    AND ($2::date IS NULL OR d.day >= $2)
+    AND ($3::date IS NULL OR d.day <= $3);
+
+-- name: GetDedupedRepoStats :one
+SELECT
+    COUNT(*) AS commits,
+    COALESCE(SUM(c.additions), 0)::bigint AS additions,
+    COALESCE(SUM(c.deletions), 0)::bigint AS deletions,
+    COUNT(DISTINCT c.author_id) AS authors,
+    COUNT(DISTINCT (c.created_at AT TIME ZONE 'UTC')::date) AS active_days
+FROM commits c
+JOIN repositories r ON c.repository_id = r.id
+WHERE r.full_name = $1
+    AND ($2::date IS NULL OR (c.created_at AT TIME ZONE 'UTC')::date >= $2)
+    AND ($3::date IS NULL OR (c.created_at AT TIME ZONE 'UTC')::date <= $3)
+    AND NOT EXISTS (
+        SELECT 1 FROM commits s
+        JOIN repositories u ON s.repository_id = u.id
+        WHERE s.hash = c.hash
+            AND COALESCE(u.network_id, u.id) = COALESCE(r.network_id, r.id)
+            AND (u.created_at, u.id) < (r.created_at, r.id)
+    );
+
 -- name: SaveManyCommits :many
 INSERT INTO commits (hash, author_id, message, url, created_at, repository_id)
-VALUES ($1, $2, $3, $4, $5, $6)
-ON CONFLICT (hash) DO NOTHING
+SELECT $1, $2, $3, $4, $5, $6::bigint
+FROM repositories r
+WHERE r.id = $6 AND r.deleted_at IS NULL
+ON CONFLICT (repository_id, hash) DO NOTHING
 RETURNING *;
 
+
+-- name: CountAllCommits :one
+SELECT COUNT(*) FROM commits;
+
+-- name: NotifyCommits :exec
+SELECT pg_notify('commits', json_build_object(
+    'repository', r.full_name,
+    'commits', sqlc.arg('commits')::int,
+    'at', CURRENT_TIMESTAMP
+)::text)
+FROM repositories r
+WHERE r.id = sqlc.arg('repository_id');
+
+-- name: AddRepoCommits :exec
+UPDATE repositories
+SET commit_count = commit_count + sqlc.arg('commits')::bigint,
+    last_commit_at = GREATEST(last_commit_at, sqlc.arg('last_commit_at')::timestamptz)
+WHERE id = sqlc.arg('id');
+
+-- name: AddRepoTenant :exec
+-- The tenant of the intent whose run fetched the repository sees it from
+-- then on.
+INSERT INTO repository_tenants (tenant_id, repository_id)
+SELECT tenant_id, sqlc.arg('repository_id')::bigint FROM intents
+WHERE id = sqlc.arg('intent_id')
+ON CONFLICT DO NOTHING;
+
+-- name: RepoInTenant :one
+SELECT EXISTS (
+    SELECT 1 FROM repository_tenants
+    WHERE repository_id = $1 AND tenant_id = $2
+);
+
+-- name: LockRepo :one
+-- Saving commits and swapping in a reindex lock the repository first, so
+-- they take turns.
+SELECT commit_count FROM repositories
+WHERE id = $1
+FOR NO KEY UPDATE;
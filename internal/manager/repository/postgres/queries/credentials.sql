@@ -0,0 +1,12 @@
+-- name: SaveCredential :one
+INSERT INTO credentials (id, name, ciphertext)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: FindCredential :one
+SELECT * FROM credentials
+WHERE id = $1;
+
+-- name: FindCredentials :many
+SELECT * FROM credentials
+ORDER BY name;
@@ -0,0 +1,36 @@
+-- name: ClaimDigestSubscriptions :many
+UPDATE digest_subscriptions SET last_week = $1
+WHERE id IN (
+    SELECT id FROM digest_subscriptions
+    WHERE last_week IS NULL OR last_week < $1
+    ORDER BY created_at
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: DeleteDigestSubscription :execrows
+DELETE FROM digest_subscriptions
+WHERE id = $1;
+
+-- name: FindDigestSubscriptions :many
+SELECT * FROM digest_subscriptions
+ORDER BY email, repository, label;
+
+-- name: GetLargestCommits :many
+SELECT c.hash, c.message, c.url, c.created_at, c.additions, c.deletions, c.changes,
+    a.id, a.name, a.email, a.username
+FROM commits c
+JOIN repositories r ON c.repository_id = r.id
+JOIN authors a ON c.author_id = a.id
+WHERE r.full_name = $1
+    AND c.created_at >= $2
+    AND c.created_at <= $3
+    AND c.changes IS NOT NULL
+ORDER BY c.changes DESC, c.created_at DESC
+LIMIT $4;
+
+-- name: SaveDigestSubscription :one
+INSERT INTO digest_subscriptions (id, email, repository, label)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
@@ -0,0 +1,13 @@
+-- name: SaveGitHubStats :exec
+INSERT INTO repository_github_stats (repository_id, code_frequency, participation, punch_card, fetched_at)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (repository_id) DO UPDATE SET
+    code_frequency = COALESCE(EXCLUDED.code_frequency, repository_github_stats.code_frequency),
+    participation = COALESCE(EXCLUDED.participation, repository_github_stats.participation),
+    punch_card = COALESCE(EXCLUDED.punch_card, repository_github_stats.punch_card),
+    fetched_at = EXCLUDED.fetched_at;
+
+-- name: GetGitHubStats :one
+SELECT repository_id, code_frequency, participation, punch_card, fetched_at
+FROM repository_github_stats
+WHERE repository_id = $1;
@@ -1,21 +1,39 @@
 -- SaveIntent.sql
 -- name: SaveIntent :one
 INSERT INTO intents (
-    id, repository_name, start_date, status, is_active
+    id, repository_name, start_date, status, is_active, max_concurrent_pages, requests_per_minute, index_all_branches,
+    path_filters, author_filters, max_commits, end_date, credential_id,
+    retry_max_attempts, retry_backoff_base_ms, retry_jitter, parent_id, tenant_id,
+    branch, excluded_authors
 ) VALUES (
-    $1, $2, $3, $4, $5
-) RETURNING id, repository_name, start_date, status, is_active, created_at, updated_at;
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20
+) RETURNING id, repository_name, start_date, status, is_active, created_at, updated_at,
+    max_concurrent_pages, requests_per_minute, index_all_branches, path_filters,
+    author_filters, max_commits, end_date, credential_id,
+    sync_started_at, last_synced_at, synced_commits,
+    retry_max_attempts, retry_backoff_base_ms, retry_jitter, deleted_at, parent_id, paused_at, tenant_id,
+    branch, excluded_authors;
 
 -- UpdateIntent.sql
 -- name: UpdateIntent :one
 UPDATE intents
-SET 
-    status = COALESCE($2, status),
-    is_active = COALESCE($3, is_active),
-    start_date = COALESCE($4, start_date),
+SET
+    status = COALESCE(sqlc.narg('status'), status),
+    is_active = COALESCE(sqlc.narg('is_active'), is_active),
+    start_date = CASE WHEN sqlc.arg('full_history')::boolean THEN NULL ELSE COALESCE(sqlc.narg('start_date'), start_date) END,
+    end_date = CASE WHEN sqlc.arg('open_ended')::boolean THEN NULL ELSE COALESCE(sqlc.narg('end_date'), end_date) END,
+    sync_started_at = COALESCE(sqlc.narg('sync_started_at'), sync_started_at),
+    last_synced_at = COALESCE(sqlc.narg('last_synced_at'), last_synced_at),
+    synced_commits = COALESCE(sqlc.narg('synced_commits'), synced_commits),
+    paused_at = CASE WHEN sqlc.narg('is_active')::boolean THEN NULL ELSE COALESCE(sqlc.narg('paused_at'), paused_at) END,
     updated_at = CURRENT_TIMESTAMP
-WHERE id = $1
-RETURNING id, repository_name, start_date, status, is_active, created_at, updated_at;
+WHERE id = sqlc.arg('id') AND deleted_at IS NULL
+RETURNING id, repository_name, start_date, status, is_active, created_at, updated_at,
+    max_concurrent_pages, requests_per_minute, index_all_branches, path_filters,
+    author_filters, max_commits, end_date, credential_id,
+    sync_started_at, last_synced_at, synced_commits,
+    retry_max_attempts, retry_backoff_base_ms, retry_jitter, deleted_at, parent_id, paused_at, tenant_id,
+    branch, excluded_authors;
 
 -- SaveIntentError.sql
 -- name: SaveIntentError :exec
@@ -26,15 +44,32 @@ INSERT INTO intent_errors (
 );
 
 
+-- name: DeleteIntent :one
+UPDATE intents
+SET deleted_at = CURRENT_TIMESTAMP, is_active = FALSE, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING id, repository_name, start_date, status, is_active, created_at, updated_at,
+    max_concurrent_pages, requests_per_minute, index_all_branches, path_filters,
+    author_filters, max_commits, end_date, credential_id,
+    sync_started_at, last_synced_at, synced_commits,
+    retry_max_attempts, retry_backoff_base_ms, retry_jitter, deleted_at, parent_id, paused_at, tenant_id,
+    branch, excluded_authors;
+
 -- name: FindIntents :many
 SELECT 
-    id, repository_name, start_date, status, is_active, created_at, updated_at
+    id, repository_name, start_date, status, is_active, created_at, updated_at,
+    max_concurrent_pages, requests_per_minute, index_all_branches, path_filters,
+    author_filters, max_commits, end_date, credential_id,
+    sync_started_at, last_synced_at, synced_commits,
+    retry_max_attempts, retry_backoff_base_ms, retry_jitter, deleted_at, parent_id, paused_at, tenant_id,
+    branch, excluded_authors
 FROM 
     intents
 WHERE 
     (NOT @status_provided OR status = @status::intent_status)
     AND (NOT @is_active_provided OR is_active = @is_active)
     AND (NOT @repository_name_provided OR repository_name = @repository_name)
+    AND deleted_at IS NULL
 ORDER BY created_at DESC
 LIMIT $1 OFFSET $2;
 
@@ -44,13 +79,111 @@ FROM intents
 WHERE 
     (NOT @status_provided OR status = @status::intent_status)
     AND (NOT @is_active_provided OR is_active = @is_active)
-    AND (NOT @repository_name_provided OR repository_name = @repository_name);
+    AND (NOT @repository_name_provided OR repository_name = @repository_name)
+    AND deleted_at IS NULL;
     
 -- FindIntent.sql
 -- name: FindIntent :one
 SELECT 
-    id, repository_name, start_date, status, is_active, created_at, updated_at
+    id, repository_name, start_date, status, is_active, created_at, updated_at,
+    max_concurrent_pages, requests_per_minute, index_all_branches, path_filters,
+    author_filters, max_commits, end_date, credential_id,
+    sync_started_at, last_synced_at, synced_commits,
+    retry_max_attempts, retry_backoff_base_ms, retry_jitter, deleted_at, parent_id, paused_at, tenant_id,
+    branch, excluded_authors
 FROM 
     intents
 WHERE 
-    id = $1;
+    id = $1 AND deleted_at IS NULL;
+
+-- name: FindChildIntentRepos :many
+SELECT repository_name
+FROM intents
+WHERE parent_id = $1;
+
+-- name: CountIntentsByStatus :many
+SELECT status, COUNT(*) AS count
+FROM intents
+WHERE deleted_at IS NULL
+GROUP BY status;
+
+-- name: FindRecentIntentErrors :many
+SELECT
+    e.id, e.intent_id, e.created_at, e.message, i.repository_name
+FROM
+    intent_errors e
+JOIN intents i ON i.id = e.intent_id
+ORDER BY e.created_at DESC
+LIMIT $1;
+
+-- name: SaveIntentTransition :exec
+INSERT INTO intent_status_history (intent_id, from_status, to_status, created_at)
+VALUES ($1, $2, $3, $4);
+
+-- name: TrimIntentTransitions :exec
+DELETE FROM intent_status_history
+WHERE intent_id = $1 AND id NOT IN (
+    SELECT id FROM intent_status_history
+    WHERE intent_id = $1
+    ORDER BY id DESC
+    LIMIT $2
+);
+
+-- name: FindIntentTransitions :many
+SELECT id, intent_id, from_status, to_status, created_at
+FROM intent_status_history
+WHERE intent_id = $1
+ORDER BY id DESC;
+
+-- name: EnqueueIntentCommand :exec
+INSERT INTO intent_outbox (intent_id, queue, command)
+VALUES ($1, $2, $3);
+
+-- name: ClaimIntentCommands :many
+SELECT id, queue, command
+FROM intent_outbox
+WHERE next_attempt_at <= CURRENT_TIMESTAMP
+ORDER BY id
+LIMIT $1
+FOR UPDATE SKIP LOCKED;
+
+-- name: DeleteIntentCommand :exec
+DELETE FROM intent_outbox WHERE id = $1;
+
+-- name: FailIntentCommand :exec
+UPDATE intent_outbox
+SET attempts = attempts + 1,
+    last_error = $2,
+    next_attempt_at = CURRENT_TIMESTAMP + LEAST(INTERVAL '1 second' * POWER(2, attempts), INTERVAL '5 minutes')
+WHERE id = $1;
+
+-- name: NotifyIntentEvent :exec
+SELECT pg_notify('intent_events', sqlc.arg('event')::text);
+
+-- name: SaveIntentProgress :exec
+INSERT INTO intent_progress (
+    intent_id, commits, pages, last_commit_date, started_at, updated_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+)
+ON CONFLICT (intent_id) DO UPDATE SET
+    commits = EXCLUDED.commits,
+    pages = EXCLUDED.pages,
+    last_commit_date = EXCLUDED.last_commit_date,
+    started_at = EXCLUDED.started_at,
+    updated_at = EXCLUDED.updated_at
+WHERE intent_progress.updated_at <= EXCLUDED.updated_at;
+
+-- name: GetIntentProgress :one
+SELECT * FROM intent_progress
+WHERE intent_id = $1;
+
+-- name: ClaimForcedBroadcast :one
+INSERT INTO intent_forced_broadcasts (intent_id, forced_at)
+VALUES ($1, CURRENT_TIMESTAMP)
+ON CONFLICT (intent_id) DO UPDATE SET forced_at = EXCLUDED.forced_at
+WHERE intent_forced_broadcasts.forced_at <= CURRENT_TIMESTAMP - sqlc.arg('cooldown_ms')::bigint * INTERVAL '1 millisecond'
+RETURNING forced_at;
+
+-- name: ReleaseForcedBroadcast :exec
+DELETE FROM intent_forced_broadcasts WHERE intent_id = $1 AND forced_at = $2;
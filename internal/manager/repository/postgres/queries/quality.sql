@@ -0,0 +1,36 @@
+-- name: GetCoverageGaps :many
+WITH days AS (
+    SELECT DISTINCT (created_at AT TIME ZONE 'UTC')::date AS day
+    FROM commits
+    WHERE repository_id = $1
+), bounds AS (
+    SELECT day FROM days WHERE day BETWEEN $2::date AND $3::date
+    UNION ALL SELECT $2::date - 1
+    UNION ALL SELECT $3::date + 1
+), spans AS (
+    SELECT day + 1 AS gap_start, LEAD(day) OVER (ORDER BY day) - 1 AS gap_end
+    FROM bounds
+)
+SELECT gap_start::date AS gap_start, gap_end::date AS gap_end
+FROM spans
+WHERE gap_end - gap_start + 1 >= $4::int
+ORDER BY gap_start;
+
+-- name: GetRepoQuality :one
+SELECT
+    COUNT(*)::bigint AS commits,
+    COUNT(*) FILTER (WHERE a.username = '')::bigint AS unattributed,
+    COUNT(*) FILTER (WHERE a.username LIKE '%[bot]' OR a.name LIKE '%[bot]')::bigint AS bots,
+    COUNT(*) FILTER (WHERE EXISTS (
+        SELECT 1 FROM commits d
+        WHERE d.hash = c.hash AND d.repository_id <> c.repository_id
+    ))::bigint AS duplicates,
+    MIN(c.created_at)::timestamptz AS first_commit_at,
+    MAX(c.created_at)::timestamptz AS last_commit_at,
+    (
+        SELECT MAX(x.finished_at) FROM reindexes x
+        WHERE x.repository_id = $1 AND x.status = 'swapped'
+    )::timestamptz AS last_reconciled_at
+FROM commits c
+JOIN authors a ON c.author_id = a.id
+WHERE c.repository_id = $1;
@@ -0,0 +1,85 @@
+-- name: AbortReindexes :exec
+UPDATE reindexes
+SET status = 'aborted', finished_at = CURRENT_TIMESTAMP
+WHERE repository_id = $1 AND status = 'building';
+
+-- name: DeleteRepoShadowCommits :exec
+DELETE FROM commits_shadow
+WHERE repository_id = $1;
+
+-- name: CreateReindex :one
+INSERT INTO reindexes (id, repository_id, intent_id)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetReindex :one
+SELECT * FROM reindexes
+WHERE id = $1;
+
+-- name: LockReindex :one
+SELECT * FROM reindexes
+WHERE id = $1
+FOR UPDATE;
+
+-- name: GetLatestReindex :one
+SELECT * FROM reindexes
+WHERE intent_id = $1
+ORDER BY created_at DESC
+LIMIT 1;
+
+-- name: SetReindexExpected :exec
+UPDATE reindexes
+SET expected_commits = $2
+WHERE id = $1 AND status = 'building';
+
+-- name: FinishReindex :exec
+UPDATE reindexes
+SET status = $2, error = $3, finished_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: SaveShadowCommit :exec
+INSERT INTO commits_shadow (reindex_id, hash, author_id, message, url, created_at, repository_id, additions, deletions, changes, tags)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+ON CONFLICT (reindex_id, hash) DO NOTHING;
+
+-- name: CountShadowCommits :one
+SELECT COUNT(*) FROM commits_shadow
+WHERE reindex_id = $1;
+
+-- name: DeleteShadowCommits :exec
+DELETE FROM commits_shadow
+WHERE reindex_id = $1;
+
+-- name: DeleteRepoCommits :exec
+DELETE FROM commits
+WHERE repository_id = $1;
+
+-- name: DeleteRepoRollups :exec
+DELETE FROM commits_daily
+WHERE repository_id = $1;
+
+-- name: SwapInShadowCommits :execrows
+-- The shadow's rollups are written alongside, so its commits go in rolled
+-- up already. Those older than the repository's archive stay archived.
+INSERT INTO commits (hash, author_id, message, url, created_at, repository_id, additions, deletions, changes, rolled_up, tags)
+SELECT hash, author_id, message, url, created_at, repository_id, additions, deletions, changes, TRUE, tags
+FROM commits_shadow s
+WHERE reindex_id = $1
+    AND NOT EXISTS (
+        SELECT 1 FROM repository_archives a
+        WHERE a.repository_id = s.repository_id AND s.created_at < a.archived_before
+    );
+
+-- name: RollupShadowCommits :exec
+INSERT INTO commits_daily (repository_id, author_id, day, commits, additions, deletions)
+SELECT repository_id, author_id, (created_at AT TIME ZONE 'UTC')::date,
+    COUNT(*), COALESCE(SUM(additions), 0), COALESCE(SUM(deletions), 0)
+FROM commits_shadow
+WHERE reindex_id = $1
+GROUP BY repository_id, author_id, (created_at AT TIME ZONE 'UTC')::date;
+
+-- name: SetRepoCommits :exec
+UPDATE repositories
+SET commit_count = sqlc.arg('commits')::bigint,
+    last_commit_at = (SELECT MAX(created_at) FROM commits WHERE repository_id = sqlc.arg('id'))
+WHERE id = sqlc.arg('id');
@@ -0,0 +1,53 @@
+-- name: SaveReviews :exec
+INSERT INTO pull_request_reviews (id, repository_id, pull_number, pull_author, pull_created_at, reviewer, state, submitted_at)
+SELECT r.id, sqlc.arg('repository_id')::bigint, r.pull_number, r.pull_author, r.pull_created_at, r.reviewer, r.state, r.submitted_at
+FROM unnest(
+    sqlc.arg('ids')::bigint[],
+    sqlc.arg('pull_numbers')::int[],
+    sqlc.arg('pull_authors')::text[],
+    sqlc.arg('pull_created_ats')::timestamptz[],
+    sqlc.arg('reviewers')::text[],
+    sqlc.arg('states')::text[],
+    sqlc.arg('submitted_ats')::timestamptz[]
+) AS r (id, pull_number, pull_author, pull_created_at, reviewer, state, submitted_at)
+ON CONFLICT (repository_id, id) DO UPDATE SET state = EXCLUDED.state;
+
+-- name: GetReviewTurnaround :one
+WITH first_reviews AS (
+    SELECT EXTRACT(EPOCH FROM MIN(submitted_at) - MIN(pull_created_at))::float8 AS wait
+    FROM pull_request_reviews
+    WHERE repository_id = $1
+        AND reviewer <> pull_author
+        AND ($2::date IS NULL OR pull_created_at >= $2)
+        AND ($3::date IS NULL OR pull_created_at < $3::date + 1)
+    GROUP BY pull_number
+)
+SELECT
+    COUNT(*)::bigint AS pull_requests,
+    COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY wait), 0)::float8 AS median,
+    COALESCE(percentile_cont(0.9) WITHIN GROUP (ORDER BY wait), 0)::float8 AS p90,
+    COALESCE(AVG(wait), 0)::float8 AS mean
+FROM first_reviews;
+
+-- name: GetTopReviewers :many
+SELECT
+    reviewer,
+    COUNT(*)::bigint AS reviews,
+    COUNT(*) FILTER (WHERE state = 'APPROVED')::bigint AS approvals,
+    COUNT(*) FILTER (WHERE state = 'CHANGES_REQUESTED')::bigint AS changes_requested,
+    COUNT(DISTINCT pull_number)::bigint AS pull_requests
+FROM pull_request_reviews
+WHERE repository_id = $1
+    AND reviewer <> pull_author
+    AND ($2::date IS NULL OR submitted_at >= $2)
+    AND ($3::date IS NULL OR submitted_at < $3::date + 1)
+GROUP BY reviewer
+ORDER BY reviews DESC, reviewer
+LIMIT $4 OFFSET $5;
+
+-- name: CountReviewers :one
+SELECT COUNT(DISTINCT reviewer)::bigint FROM pull_request_reviews
+WHERE repository_id = $1
+    AND reviewer <> pull_author
+    AND ($2::date IS NULL OR submitted_at >= $2)
+    AND ($3::date IS NULL OR submitted_at < $3::date + 1);
@@ -0,0 +1,83 @@
+-- name: SearchRepositories :many
+SELECT * FROM repositories
+WHERE full_name ILIKE sqlc.arg('full_name')
+    AND deleted_at IS NULL
+    AND (sqlc.narg('tenant_id')::text IS NULL OR EXISTS (SELECT 1 FROM repository_tenants t WHERE t.repository_id = repositories.id AND t.tenant_id = sqlc.narg('tenant_id')))
+ORDER BY stargazers DESC, full_name
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: CountSearchRepositories :one
+SELECT COUNT(*) FROM repositories
+WHERE full_name ILIKE sqlc.arg('full_name')
+    AND deleted_at IS NULL
+    AND (sqlc.narg('tenant_id')::text IS NULL OR EXISTS (SELECT 1 FROM repository_tenants t WHERE t.repository_id = repositories.id AND t.tenant_id = sqlc.narg('tenant_id')));
+
+-- name: SearchAuthors :many
+-- Within a tenant, only the authors of its commits are found.
+SELECT * FROM authors
+WHERE (name ILIKE sqlc.arg('name') OR username ILIKE sqlc.arg('name'))
+    AND (sqlc.narg('tenant_id')::text IS NULL OR EXISTS (
+        SELECT 1 FROM commits c
+        JOIN repository_tenants t ON t.repository_id = c.repository_id
+        WHERE c.author_id = authors.id AND t.tenant_id = sqlc.narg('tenant_id')
+    ))
+ORDER BY username, id
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: CountSearchAuthors :one
+SELECT COUNT(*) FROM authors
+WHERE (name ILIKE sqlc.arg('name') OR username ILIKE sqlc.arg('name'))
+    AND (sqlc.narg('tenant_id')::text IS NULL OR EXISTS (
+        SELECT 1 FROM commits c
+        JOIN repository_tenants t ON t.repository_id = c.repository_id
+        WHERE c.author_id = authors.id AND t.tenant_id = sqlc.narg('tenant_id')
+    ));
+
+-- name: SearchCommits :many
+SELECT
+    c.hash, c.message, c.url, c.created_at, r.full_name AS repository,
+    a.id AS author_id, a.name AS author_name, a.email AS author_email, a.username AS author_username
+FROM commits c
+JOIN repositories r ON c.repository_id = r.id
+JOIN authors a ON c.author_id = a.id
+WHERE c.message ILIKE sqlc.arg('message')
+    AND r.deleted_at IS NULL
+    AND (sqlc.narg('tenant_id')::text IS NULL OR EXISTS (SELECT 1 FROM repository_tenants t WHERE t.repository_id = c.repository_id AND t.tenant_id = sqlc.narg('tenant_id')))
+ORDER BY c.created_at DESC, c.hash
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: CountSearchCommits :one
+SELECT COUNT(*)
+FROM commits c
+JOIN repositories r ON c.repository_id = r.id
+WHERE c.message ILIKE sqlc.arg('message')
+    AND r.deleted_at IS NULL
+    AND (sqlc.narg('tenant_id')::text IS NULL OR EXISTS (SELECT 1 FROM repository_tenants t WHERE t.repository_id = c.repository_id AND t.tenant_id = sqlc.narg('tenant_id')));
+
+-- name: SearchCommitMessages :many
+SELECT
+    c.hash, c.message, c.url, c.created_at, r.full_name AS repository,
+    a.id AS author_id, a.name AS author_name, a.email AS author_email, a.username AS author_username,
+    ts_rank(c.message_tsv, websearch_to_tsquery('english', sqlc.arg('query')::text)) AS rank
+FROM commits c
+JOIN repositories r ON c.repository_id = r.id
+JOIN authors a ON c.author_id = a.id
+WHERE c.message_tsv @@ websearch_to_tsquery('english', sqlc.arg('query')::text)
+    AND r.deleted_at IS NULL
+    AND (sqlc.narg('repository')::text IS NULL OR r.full_name = sqlc.narg('repository'))
+    AND (sqlc.narg('since')::timestamptz IS NULL OR c.created_at >= sqlc.narg('since'))
+    AND (sqlc.narg('until')::timestamptz IS NULL OR c.created_at <= sqlc.narg('until'))
+    AND (sqlc.narg('tenant_id')::text IS NULL OR EXISTS (SELECT 1 FROM repository_tenants t WHERE t.repository_id = c.repository_id AND t.tenant_id = sqlc.narg('tenant_id')))
+ORDER BY rank DESC, c.created_at DESC, c.hash
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: CountSearchCommitMessages :one
+SELECT COUNT(*)
+FROM commits c
+JOIN repositories r ON c.repository_id = r.id
+WHERE c.message_tsv @@ websearch_to_tsquery('english', sqlc.arg('query')::text)
+    AND r.deleted_at IS NULL
+    AND (sqlc.narg('repository')::text IS NULL OR r.full_name = sqlc.narg('repository'))
+    AND (sqlc.narg('since')::timestamptz IS NULL OR c.created_at >= sqlc.narg('since'))
+    AND (sqlc.narg('until')::timestamptz IS NULL OR c.created_at <= sqlc.narg('until'))
+    AND (sqlc.narg('tenant_id')::text IS NULL OR EXISTS (SELECT 1 FROM repository_tenants t WHERE t.repository_id = c.repository_id AND t.tenant_id = sqlc.narg('tenant_id')));
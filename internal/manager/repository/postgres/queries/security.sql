@@ -0,0 +1,45 @@
+-- name: SaveSecurityAlerts :exec
+INSERT INTO security_alerts (
+    repository_id, number, state, severity, package, ecosystem, manifest_path,
+    advisory_id, summary, url, created_at, updated_at, fixed_at, dismissed_at
+)
+SELECT sqlc.arg('repository_id')::bigint, a.number, a.state, a.severity, a.package, a.ecosystem, a.manifest_path,
+    a.advisory_id, a.summary, NULLIF(a.url, ''), a.created_at, a.updated_at, a.fixed_at, a.dismissed_at
+FROM unnest(
+    sqlc.arg('numbers')::int[],
+    sqlc.arg('states')::text[],
+    sqlc.arg('severities')::text[],
+    sqlc.arg('packages')::text[],
+    sqlc.arg('ecosystems')::text[],
+    sqlc.arg('manifest_paths')::text[],
+    sqlc.arg('advisory_ids')::text[],
+    sqlc.arg('summaries')::text[],
+    sqlc.arg('urls')::text[],
+    sqlc.arg('created_ats')::timestamptz[],
+    sqlc.arg('updated_ats')::timestamptz[],
+    sqlc.arg('fixed_ats')::timestamptz[],
+    sqlc.arg('dismissed_ats')::timestamptz[]
+) AS a (number, state, severity, package, ecosystem, manifest_path, advisory_id, summary, url, created_at, updated_at, fixed_at, dismissed_at)
+ON CONFLICT (repository_id, number) DO UPDATE SET
+    state = EXCLUDED.state,
+    severity = EXCLUDED.severity,
+    summary = EXCLUDED.summary,
+    updated_at = EXCLUDED.updated_at,
+    fixed_at = EXCLUDED.fixed_at,
+    dismissed_at = EXCLUDED.dismissed_at;
+
+-- name: GetOpenSecurityAlerts :many
+SELECT
+    r.full_name,
+    COUNT(*) FILTER (WHERE a.severity = 'critical')::bigint AS critical,
+    COUNT(*) FILTER (WHERE a.severity = 'high')::bigint AS high,
+    COUNT(*) FILTER (WHERE a.severity = 'medium')::bigint AS medium,
+    COUNT(*) FILTER (WHERE a.severity = 'low')::bigint AS low,
+    COUNT(*)::bigint AS total
+FROM security_alerts a
+JOIN repositories r ON r.id = a.repository_id
+WHERE a.state = 'open'
+    AND r.deleted_at IS NULL
+    AND (sqlc.narg('tenant_id')::text IS NULL OR EXISTS (SELECT 1 FROM repository_tenants t WHERE t.repository_id = r.id AND t.tenant_id = sqlc.narg('tenant_id')))
+GROUP BY r.full_name
+ORDER BY critical DESC, high DESC, medium DESC, total DESC, r.full_name;
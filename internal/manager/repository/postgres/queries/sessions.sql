@@ -0,0 +1,16 @@
+-- name: SaveSession :one
+INSERT INTO sessions (token_hash, username, role, expires_at)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: FindSession :one
+SELECT * FROM sessions
+WHERE token_hash = $1 AND expires_at > CURRENT_TIMESTAMP;
+
+-- name: DeleteSession :exec
+DELETE FROM sessions
+WHERE token_hash = $1;
+
+-- name: DeleteExpiredSessions :execrows
+DELETE FROM sessions
+WHERE expires_at <= CURRENT_TIMESTAMP;
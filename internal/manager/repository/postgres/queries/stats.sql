@@ -0,0 +1,39 @@
+-- name: GetLanguageStats :many
+SELECT
+    COALESCE(language, '')::text AS language,
+    COUNT(*) AS repositories,
+    SUM(commit_count)::bigint AS commits
+FROM repositories
+WHERE deleted_at IS NULL
+    AND (sqlc.narg('tenant_id')::text IS NULL OR EXISTS (SELECT 1 FROM repository_tenants t WHERE t.repository_id = repositories.id AND t.tenant_id = sqlc.narg('tenant_id')))
+GROUP BY 1
+ORDER BY commits DESC, language;
+
+-- name: GetActiveRepos :many
+SELECT
+    r.full_name,
+    COALESCE(r.language, '')::text AS language,
+    SUM(d.commits)::bigint AS commits,
+    SUM(d.additions)::bigint AS additions,
+    SUM(d.deletions)::bigint AS deletions,
+    COUNT(DISTINCT d.author_id) AS authors
+FROM commits_daily d
+JOIN repositories r ON d.repository_id = r.id
+WHERE d.day >= sqlc.arg('start_date')::date
+    AND d.day <= sqlc.arg('end_date')::date
+    AND r.deleted_at IS NULL
+    AND (sqlc.narg('language')::text IS NULL OR lower(r.language) = lower(sqlc.narg('language')))
+    AND (sqlc.narg('tenant_id')::text IS NULL OR EXISTS (SELECT 1 FROM repository_tenants t WHERE t.repository_id = r.id AND t.tenant_id = sqlc.narg('tenant_id')))
+GROUP BY r.id, r.full_name, r.language
+ORDER BY commits DESC, r.full_name
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: CountActiveRepos :one
+SELECT COUNT(DISTINCT d.repository_id)
+FROM commits_daily d
+JOIN repositories r ON d.repository_id = r.id
+WHERE d.day >= sqlc.arg('start_date')::date
+    AND d.day <= sqlc.arg('end_date')::date
+    AND r.deleted_at IS NULL
+    AND (sqlc.narg('language')::text IS NULL OR lower(r.language) = lower(sqlc.narg('language')))
+    AND (sqlc.narg('tenant_id')::text IS NULL OR EXISTS (SELECT 1 FROM repository_tenants t WHERE t.repository_id = r.id AND t.tenant_id = sqlc.narg('tenant_id')));
@@ -0,0 +1,30 @@
+-- name: SaveWorkflowRuns :exec
+INSERT INTO workflow_runs (id, repository_id, workflow, head_sha, branch, event, conclusion, started_at, completed_at)
+SELECT w.id, sqlc.arg('repository_id')::bigint, w.workflow, w.head_sha, w.branch, w.event, w.conclusion, w.started_at, w.completed_at
+FROM unnest(
+    sqlc.arg('ids')::bigint[],
+    sqlc.arg('workflows')::text[],
+    sqlc.arg('head_shas')::text[],
+    sqlc.arg('branches')::text[],
+    sqlc.arg('events')::text[],
+    sqlc.arg('conclusions')::text[],
+    sqlc.arg('started_ats')::timestamptz[],
+    sqlc.arg('completed_ats')::timestamptz[]
+) AS w (id, workflow, head_sha, branch, event, conclusion, started_at, completed_at)
+ON CONFLICT (repository_id, id) DO UPDATE SET
+    conclusion = EXCLUDED.conclusion,
+    started_at = EXCLUDED.started_at,
+    completed_at = EXCLUDED.completed_at;
+
+-- name: GetCIStats :one
+SELECT
+    COUNT(*)::bigint AS runs,
+    COUNT(*) FILTER (WHERE conclusion = 'success')::bigint AS passed,
+    COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM completed_at - started_at)), 0)::float8 AS median,
+    COALESCE(percentile_cont(0.9) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM completed_at - started_at)), 0)::float8 AS p90
+FROM workflow_runs
+WHERE repository_id = $1
+    AND conclusion NOT IN ('cancelled', 'skipped')
+    AND ($2::date IS NULL OR started_at >= $2)
+    AND ($3::date IS NULL OR started_at < $3::date + 1)
+    AND ($4::text = '' OR workflow = $4);
@@ -0,0 +1,268 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/noelukwa/indexer/internal/manager/models"
+	"github.com/noelukwa/indexer/internal/manager/repository/postgres/sqlc"
+)
+
+// CreateReindex starts a reindex of the repository named repoName by the
+// intent, aborting the one already building, if any. It returns nil if
+// the repository hasn't been indexed yet.
+func (p *pgStore) CreateReindex(ctx context.Context, intentID uuid.UUID, repoName string) (*models.Reindex, error) {
+	tx, err := p.conn.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := p.q.WithTx(tx)
+	repo, err := qtx.GetRepo(ctx, repoName)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if err := qtx.AbortReindexes(ctx, repo.ID); err != nil {
+		return nil, fmt.Errorf("failed to abort reindexes: %w", err)
+	}
+	if err := qtx.DeleteRepoShadowCommits(ctx, repo.ID); err != nil {
+		return nil, fmt.Errorf("failed to clear shadow commits: %w", err)
+	}
+
+	reindex, err := qtx.CreateReindex(ctx, sqlc.CreateReindexParams{
+		ID:           uuid.New(),
+		RepositoryID: repo.ID,
+		IntentID:     intentID,
+	})
+	if err != nil {
+		return nil, storeError(err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return toReindex(reindex), nil
+}
+
+// GetLatestReindex returns the intent's most recent reindex, or
+// repository.ErrNotFound if it has never reindexed.
+func (p *pgStore) GetLatestReindex(ctx context.Context, intentID uuid.UUID) (*models.Reindex, error) {
+	reindex, err := p.q.GetLatestReindex(ctx, intentID)
+	if err != nil {
+		return nil, storeError(err)
+	}
+	return toReindex(reindex), nil
+}
+
+// SaveShadowCommits adds commits to the shadow of a building reindex, and
+// drops them for any other.
+func (p *pgStore) SaveShadowCommits(ctx context.Context, id uuid.UUID, commits []*models.Commit) error {
+	tx, err := p.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := p.q.WithTx(tx)
+	reindex, err := qtx.LockReindex(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if models.ReindexStatus(reindex.Status) != models.ReindexBuilding {
+		return nil
+	}
+
+	if err := qtx.SaveAuthors(ctx, batchAuthors(commits)); err != nil {
+		return fmt.Errorf("failed to save authors: %w", err)
+	}
+
+	for _, commit := range commits {
+		var stats models.CommitStats
+		if commit.Stats != nil {
+			stats = *commit.Stats
+		}
+
+		err = qtx.SaveShadowCommit(ctx, sqlc.SaveShadowCommitParams{
+			ReindexID:    id,
+			Hash:         commit.Hash,
+			AuthorID:     commit.Author.ID,
+			Message:      commit.Message,
+			Url:          pgtype.Text{String: commit.Url, Valid: commit.Url != ""},
+			CreatedAt:    pgtype.Timestamptz{Time: commit.CreatedAt, Valid: true},
+			RepositoryID: reindex.RepositoryID,
+			Additions:    pgtype.Int4{Int32: stats.Additions, Valid: commit.Stats != nil},
+			Deletions:    pgtype.Int4{Int32: stats.Deletions, Valid: commit.Stats != nil},
+			Changes:      pgtype.Int4{Int32: stats.Changes, Valid: commit.Stats != nil},
+			Tags:         commitTags(commit),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to save shadow commit %s: %w", commit.Hash, err)
+		}
+	}
+	// Comments aren't part of the history a reindex rebuilds, so they go
+	// straight to the repository.
+	if err := saveComments(ctx, qtx, reindex.RepositoryID, commits); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// SetReindexExpected records how many commits the reindex run fetched.
+func (p *pgStore) SetReindexExpected(ctx context.Context, id uuid.UUID, commits int64) error {
+	return p.q.SetReindexExpected(ctx, sqlc.SetReindexExpectedParams{
+		ID:              id,
+		ExpectedCommits: pgtype.Int8{Int64: commits, Valid: true},
+	})
+}
+
+// FailReindex gives up on a building reindex and drops its shadow.
+func (p *pgStore) FailReindex(ctx context.Context, id uuid.UUID, reason string) error {
+	tx, err := p.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := p.q.WithTx(tx)
+	reindex, err := qtx.LockReindex(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if models.ReindexStatus(reindex.Status) != models.ReindexBuilding {
+		return nil
+	}
+
+	if err := finishReindex(ctx, qtx, id, models.ReindexFailed, reason); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// SwapReindex replaces the repository's live commits and daily rollups with
+// the reindex's shadow once all the commits its run fetched are in it. A
+// shadow holding fewer than minRatio of the live commits fails
+// verification instead. It returns the reindex if this call finished it,
+// and nil while its commits are yet to arrive or once it has finished.
+func (p *pgStore) SwapReindex(ctx context.Context, id uuid.UUID, minRatio float64) (*models.Reindex, error) {
+	tx, err := p.conn.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := p.q.WithTx(tx)
+	reindex, err := qtx.LockReindex(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if models.ReindexStatus(reindex.Status) != models.ReindexBuilding || !reindex.ExpectedCommits.Valid {
+		return nil, nil
+	}
+
+	shadow, err := qtx.CountShadowCommits(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if shadow < reindex.ExpectedCommits.Int64 {
+		return nil, nil
+	}
+
+	live, err := qtx.LockRepo(ctx, reindex.RepositoryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock repository %d: %w", reindex.RepositoryID, err)
+	}
+
+	status, reason := models.ReindexSwapped, ""
+	if float64(shadow) < minRatio*float64(live) {
+		status = models.ReindexFailed
+		reason = fmt.Sprintf("shadow has %d commits, fewer than %.0f%% of the %d live ones", shadow, minRatio*100, live)
+	} else {
+		if err := swapShadow(ctx, qtx, reindex, shadow); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := finishReindex(ctx, qtx, id, status, reason); err != nil {
+		return nil, err
+	}
+	finished, err := qtx.GetReindex(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return toReindex(finished), nil
+}
+
+// swapShadow replaces the repository's commits and rollups with the
+// shadow's, of which there are commits. Shadow commits older than the
+// repository's archive stay archived, but are rolled up and counted.
+func swapShadow(ctx context.Context, qtx *sqlc.Queries, reindex sqlc.Reindex, commits int64) error {
+	if err := qtx.DeleteRepoCommits(ctx, reindex.RepositoryID); err != nil {
+		return fmt.Errorf("failed to delete live commits: %w", err)
+	}
+	if err := qtx.DeleteRepoRollups(ctx, reindex.RepositoryID); err != nil {
+		return fmt.Errorf("failed to delete live rollups: %w", err)
+	}
+	if _, err := qtx.SwapInShadowCommits(ctx, reindex.ID); err != nil {
+		return fmt.Errorf("failed to swap in shadow commits: %w", err)
+	}
+	if err := qtx.RollupShadowCommits(ctx, reindex.ID); err != nil {
+		return fmt.Errorf("failed to roll up shadow commits: %w", err)
+	}
+	return qtx.SetRepoCommits(ctx, sqlc.SetRepoCommitsParams{
+		Commits: commits,
+		ID:      reindex.RepositoryID,
+	})
+}
+
+func finishReindex(ctx context.Context, qtx *sqlc.Queries, id uuid.UUID, status models.ReindexStatus, reason string) error {
+	if err := qtx.DeleteShadowCommits(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete shadow commits: %w", err)
+	}
+	return qtx.FinishReindex(ctx, sqlc.FinishReindexParams{
+		ID:     id,
+		Status: string(status),
+		Error:  pgtype.Text{String: reason, Valid: reason != ""},
+	})
+}
+
+func toReindex(reindex sqlc.Reindex) *models.Reindex {
+	found := &models.Reindex{
+		ID:           reindex.ID,
+		RepositoryID: reindex.RepositoryID,
+		IntentID:     reindex.IntentID,
+		Status:       models.ReindexStatus(reindex.Status),
+		Error:        reindex.Error.String,
+		CreatedAt:    reindex.CreatedAt.Time,
+		FinishedAt:   fromTimestamptz(reindex.FinishedAt),
+	}
+	if reindex.ExpectedCommits.Valid {
+		found.ExpectedCommits = &reindex.ExpectedCommits.Int64
+	}
+	return found
+}
@@ -6,7 +6,6 @@ import (
 	"errors"
 	"fmt"
 	"log"
-	"net/url"
 	"time"
 
 	"github.com/Masterminds/squirrel"
@@ -82,76 +81,158 @@ func (p *pgStore) runMigrate(conn *pgxpool.Pool) error {
 	return nil
 }
 
+// SaveIntent saves the intent in its tenant, or the tenant ctx is scoped
+// to if it names none. Other tenants' intents for the repository are left
+// alone; the tenant sees the repository once a run of the intent fetches
+// it.
 func (p *pgStore) SaveIntent(ctx context.Context, freshIntent models.Intent) (*models.Intent, error) {
-	intent, err := p.q.SaveIntent(ctx, sqlc.SaveIntentParams{
-		ID:             freshIntent.ID,
-		RepositoryName: freshIntent.RepositoryName,
-		StartDate: pgtype.Timestamptz{
-			Time:  freshIntent.StartDate,
-			Valid: true,
-		},
-		Status:   sqlc.IntentStatus(freshIntent.Status),
-		IsActive: freshIntent.IsActive,
-	})
+	return p.saveIntent(ctx, freshIntent, nil)
+}
+
+// SaveQueuedIntent is SaveIntent that also adds command, the intent's
+// encoded command, to discovery's outbox, so the intent is never saved
+// without it.
+func (p *pgStore) SaveQueuedIntent(ctx context.Context, freshIntent models.Intent, command []byte) (*models.Intent, error) {
+	return p.saveIntent(ctx, freshIntent, command)
+}
+
+func (p *pgStore) saveIntent(ctx context.Context, freshIntent models.Intent, command []byte) (*models.Intent, error) {
+	var retry models.RetryPolicy
+	if freshIntent.Retry != nil {
+		retry = *freshIntent.Retry
+	}
+	tenant := freshIntent.Tenant
+	if tenant == "" {
+		tenant = newTenant(ctx)
+	}
+
+	tx, err := p.conn.Begin(ctx)
 	if err != nil {
 		return nil, err
 	}
+	defer tx.Rollback(ctx)
 
-	return &models.Intent{
-		ID:             intent.ID,
-		RepositoryName: intent.RepositoryName,
-		StartDate:      intent.StartDate.Time,
-		Status:         models.IntentStatus(intent.Status),
-		IsActive:       intent.IsActive,
-	}, nil
+	qtx := p.q.WithTx(tx)
+	intent, err := qtx.SaveIntent(ctx, sqlc.SaveIntentParams{
+		ID:                 freshIntent.ID,
+		RepositoryName:     freshIntent.RepositoryName,
+		StartDate:          toTimestamptz(freshIntent.StartDate),
+		Status:             sqlc.IntentStatus(freshIntent.Status),
+		IsActive:           freshIntent.IsActive,
+		MaxConcurrentPages: toInt4(freshIntent.MaxConcurrentPages),
+		RequestsPerMinute:  toInt4(freshIntent.RequestsPerMinute),
+		IndexAllBranches:   freshIntent.IndexAllBranches,
+		PathFilters:        freshIntent.PathFilters,
+		AuthorFilters:      freshIntent.AuthorFilters,
+		MaxCommits:         toInt4(freshIntent.MaxCommits),
+		EndDate:            toTimestamptz(freshIntent.Until),
+		CredentialID:       toUUID(freshIntent.CredentialID),
+		RetryMaxAttempts:   toInt4(retry.MaxAttempts),
+		RetryBackoffBaseMs: toInt4(retry.BackoffBaseMs),
+		RetryJitter:        toFloat8(retry.Jitter),
+		ParentID:           toUUID(freshIntent.ParentID),
+		TenantID:           tenant,
+		Branch:             pgtype.Text{String: freshIntent.Branch, Valid: freshIntent.Branch != ""},
+		ExcludedAuthors:    freshIntent.ExcludedAuthors,
+	})
+	if err != nil {
+		return nil, storeError(err)
+	}
+
+	if command != nil {
+		err = qtx.EnqueueIntentCommand(ctx, sqlc.EnqueueIntentCommandParams{
+			IntentID: intent.ID,
+			Command:  command,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to queue intent: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return toIntent(intent), nil
 }
 
 func (p *pgStore) UpdateIntent(ctx context.Context, update models.IntentUpdate) (*models.Intent, error) {
-	params := sqlc.UpdateIntentParams{}
-	if update.Status != nil {
-		params.Status = sqlc.IntentStatus(*update.Status)
+	if err := p.checkIntentTenant(ctx, update.ID); err != nil {
+		return nil, err
 	}
 
+	// Fields left nil in the update are passed as NULL, which keeps the
+	// stored value.
+	params := sqlc.UpdateIntentParams{
+		ID:            update.ID,
+		FullHistory:   update.FullHistory,
+		StartDate:     toTimestamptz(update.StartDate),
+		OpenEnded:     update.OpenEnded,
+		EndDate:       toTimestamptz(update.Until),
+		SyncStartedAt: toTimestamptz(update.SyncStartedAt),
+		LastSyncedAt:  toTimestamptz(update.LastSyncedAt),
+		PausedAt:      toTimestamptz(update.PausedAt),
+	}
+	if update.Status != nil {
+		params.Status = sqlc.NullIntentStatus{IntentStatus: sqlc.IntentStatus(*update.Status), Valid: true}
+	}
 	if update.IsActive != nil {
-		params.IsActive = *update.IsActive
+		params.IsActive = pgtype.Bool{Bool: *update.IsActive, Valid: true}
 	}
-	if update.StartDate != nil {
-		params.StartDate = pgtype.Timestamptz{
-			Time:  *update.StartDate,
-			Valid: true,
-		}
+	if update.SyncedCommits != nil {
+		params.SyncedCommits = pgtype.Int8{Int64: *update.SyncedCommits, Valid: true}
 	}
 
-	intent, err := p.q.UpdateIntent(ctx, sqlc.UpdateIntentParams{
-		ID:        update.ID,
-		Status:    params.Status,
-		IsActive:  params.IsActive,
-		StartDate: params.StartDate,
-	})
+	intent, err := p.q.UpdateIntent(ctx, params)
 	if err != nil {
-		return nil, err
+		return nil, storeError(err)
 	}
 
-	return &models.Intent{
-		ID:             intent.ID,
-		RepositoryName: intent.RepositoryName,
-		StartDate:      intent.StartDate.Time,
-		Status:         models.IntentStatus(intent.Status),
-		IsActive:       intent.IsActive,
-	}, nil
+	return toIntent(intent), nil
 }
 
-func (p *pgStore) SaveIntentError(ctx context.Context, err models.IntentError) error {
-
-	return p.q.SaveIntentError(ctx, sqlc.SaveIntentErrorParams{
+func (p *pgStore) SaveIntentError(ctx context.Context, intentErr models.IntentError) error {
+	err := p.q.SaveIntentError(ctx, sqlc.SaveIntentErrorParams{
 		ID:       uuid.New(),
-		IntentID: err.IntentID,
+		IntentID: intentErr.IntentID,
 		CreatedAt: pgtype.Timestamptz{
-			Time:  err.CreatedAt,
+			Time:  intentErr.CreatedAt,
 			Valid: true,
 		},
-		Message: err.Message,
+		Message: intentErr.Message,
+	})
+	return storeError(err)
+}
+
+// SaveIntentProgress replaces the intent's progress, unless a later report
+// has already replaced it.
+func (p *pgStore) SaveIntentProgress(ctx context.Context, intentID uuid.UUID, progress models.IntentProgress) error {
+	var lastCommitDate pgtype.Timestamptz
+	if progress.LastCommitDate != nil {
+		lastCommitDate = pgtype.Timestamptz{Time: *progress.LastCommitDate, Valid: true}
+	}
+	err := p.q.SaveIntentProgress(ctx, sqlc.SaveIntentProgressParams{
+		IntentID:       intentID,
+		Commits:        progress.Commits,
+		Pages:          progress.Pages,
+		LastCommitDate: lastCommitDate,
+		StartedAt:      pgtype.Timestamptz{Time: progress.StartedAt, Valid: true},
+		UpdatedAt:      pgtype.Timestamptz{Time: progress.UpdatedAt, Valid: true},
 	})
+	return storeError(err)
+}
+
+func (p *pgStore) GetIntentProgress(ctx context.Context, intentID uuid.UUID) (*models.IntentProgress, error) {
+	row, err := p.q.GetIntentProgress(ctx, intentID)
+	if err != nil {
+		return nil, storeError(err)
+	}
+	return &models.IntentProgress{
+		Commits:        row.Commits,
+		Pages:          row.Pages,
+		LastCommitDate: fromTimestamptz(row.LastCommitDate),
+		StartedAt:      row.StartedAt.Time,
+		UpdatedAt:      row.UpdatedAt.Time,
+	}, nil
 }
 
 func (p *pgStore) FindIntents(ctx context.Context, filter models.IntentFilter, pag repository.Pagination) (repository.Paginated[models.Intent], error) {
@@ -162,6 +243,25 @@ func (p *pgStore) FindIntents(ctx context.Context, filter models.IntentFilter, p
 		"i.start_date",
 		"i.status",
 		"i.is_active",
+		"i.max_concurrent_pages",
+		"i.requests_per_minute",
+		"i.index_all_branches",
+		"i.path_filters",
+		"i.author_filters",
+		"i.max_commits",
+		"i.end_date",
+		"i.credential_id",
+		"i.sync_started_at",
+		"i.last_synced_at",
+		"i.synced_commits",
+		"i.retry_max_attempts",
+		"i.retry_backoff_base_ms",
+		"i.retry_jitter",
+		"i.parent_id",
+		"i.paused_at",
+		"i.tenant_id",
+		"i.branch",
+		"i.excluded_authors",
 	).From("intents i")
 
 	if filter.Status != nil {
@@ -173,6 +273,13 @@ func (p *pgStore) FindIntents(ctx context.Context, filter models.IntentFilter, p
 	if filter.RepositoryName != nil {
 		sb = sb.Where(squirrel.Eq{"i.repository_name": *filter.RepositoryName})
 	}
+	if filter.ParentID != nil {
+		sb = sb.Where(squirrel.Eq{"i.parent_id": *filter.ParentID})
+	}
+	if tenant, ok := repository.TenantFrom(ctx); ok {
+		sb = sb.Where(squirrel.Eq{"i.tenant_id": tenant})
+	}
+	sb = sb.Where("i.deleted_at IS NULL")
 
 	countBuilder := sb.PlaceholderFormat(squirrel.Dollar).Prefix("SELECT COUNT(*) FROM (").Suffix(") AS subquery")
 	totalCountSQL, args, err := countBuilder.ToSql()
@@ -186,7 +293,7 @@ func (p *pgStore) FindIntents(ctx context.Context, filter models.IntentFilter, p
 		return repository.Paginated[models.Intent]{}, fmt.Errorf("failed to get total count: %w", err)
 	}
 
-	sb = sb.Offset(uint64((pag.Page - 1) * pag.PerPage)).Limit(uint64(pag.PerPage)).PlaceholderFormat(squirrel.Dollar)
+	sb = sb.OrderBy("i.created_at DESC", "i.id").Offset(uint64((pag.Page - 1) * pag.PerPage)).Limit(uint64(pag.PerPage)).PlaceholderFormat(squirrel.Dollar)
 	sql, args, err := sb.ToSql()
 	if err != nil {
 		return repository.Paginated[models.Intent]{}, fmt.Errorf("failed to build SQL: %w", err)
@@ -201,17 +308,55 @@ func (p *pgStore) FindIntents(ctx context.Context, filter models.IntentFilter, p
 	intents := []models.Intent{}
 	for rows.Next() {
 		var intent models.Intent
+		var startDate, endDate pgtype.Timestamptz
+		var maxConcurrentPages, requestsPerMinute, maxCommits pgtype.Int4
+		var credentialID, parentID pgtype.UUID
+		var syncStartedAt, lastSyncedAt, pausedAt pgtype.Timestamptz
+		var retryMaxAttempts, retryBackoffBaseMs pgtype.Int4
+		var retryJitter pgtype.Float8
+		var branch pgtype.Text
 
 		err = rows.Scan(
 			&intent.ID,
 			&intent.RepositoryName,
-			&intent.StartDate,
+			&startDate,
 			&intent.Status,
 			&intent.IsActive,
+			&maxConcurrentPages,
+			&requestsPerMinute,
+			&intent.IndexAllBranches,
+			&intent.PathFilters,
+			&intent.AuthorFilters,
+			&maxCommits,
+			&endDate,
+			&credentialID,
+			&syncStartedAt,
+			&lastSyncedAt,
+			&intent.SyncedCommits,
+			&retryMaxAttempts,
+			&retryBackoffBaseMs,
+			&retryJitter,
+			&parentID,
+			&pausedAt,
+			&intent.Tenant,
+			&branch,
+			&intent.ExcludedAuthors,
 		)
 		if err != nil {
 			return repository.Paginated[models.Intent]{}, fmt.Errorf("failed to scan row: %w", err)
 		}
+		intent.MaxConcurrentPages = fromInt4(maxConcurrentPages)
+		intent.StartDate = fromTimestamptz(startDate)
+		intent.Until = fromTimestamptz(endDate)
+		intent.RequestsPerMinute = fromInt4(requestsPerMinute)
+		intent.MaxCommits = fromInt4(maxCommits)
+		intent.CredentialID = fromUUID(credentialID)
+		intent.SyncStartedAt = fromTimestamptz(syncStartedAt)
+		intent.LastSyncedAt = fromTimestamptz(lastSyncedAt)
+		intent.Retry = fromRetryColumns(retryMaxAttempts, retryBackoffBaseMs, retryJitter)
+		intent.ParentID = fromUUID(parentID)
+		intent.PausedAt = fromTimestamptz(pausedAt)
+		intent.Branch = branch.String
 
 		intents = append(intents, intent)
 	}
@@ -224,22 +369,49 @@ func (p *pgStore) FindIntents(ctx context.Context, filter models.IntentFilter, p
 	}, nil
 }
 
+// DeleteIntent marks the intent deleted and inactive. Deleted intents are
+// left out of every lookup, so deleting one twice is ErrNotFound.
+func (p *pgStore) DeleteIntent(ctx context.Context, id uuid.UUID) (*models.Intent, error) {
+	if err := p.checkIntentTenant(ctx, id); err != nil {
+		return nil, err
+	}
+
+	intent, err := p.q.DeleteIntent(ctx, id)
+	if err != nil {
+		return nil, storeError(err)
+	}
+
+	return toIntent(intent), nil
+}
+
+// FindIntent returns the intent, or repository.ErrNotFound if there is
+// none or it is in another tenant than ctx is scoped to.
 func (p *pgStore) FindIntent(ctx context.Context, id uuid.UUID) (*models.Intent, error) {
 	intent, err := p.q.FindIntent(ctx, id)
 	if err != nil {
-		return nil, err
+		return nil, storeError(err)
+	}
+	if !inTenant(ctx, intent.TenantID) {
+		return nil, repository.ErrNotFound
 	}
 
-	return &models.Intent{
-		ID:             intent.ID,
-		RepositoryName: intent.RepositoryName,
-		StartDate:      intent.StartDate.Time,
-		Status:         models.IntentStatus(intent.Status),
-		IsActive:       intent.IsActive,
-	}, nil
+	return toIntent(intent), nil
 }
 
-func (p *pgStore) SaveManyCommit(ctx context.Context, repoID int64, commits []*models.Commit) error {
+// checkIntentTenant returns repository.ErrNotFound if ctx is scoped to a
+// tenant and the intent is not in it, so that writes by id stay in the
+// tenant too.
+func (p *pgStore) checkIntentTenant(ctx context.Context, id uuid.UUID) error {
+	if _, ok := repository.TenantFrom(ctx); !ok {
+		return nil
+	}
+	_, err := p.FindIntent(ctx, id)
+	return err
+}
+
+// SaveIntentTransition records transition and drops all but the keep most
+// recent transitions of its intent.
+func (p *pgStore) SaveIntentTransition(ctx context.Context, transition models.IntentTransition, keep int) error {
 	tx, err := p.conn.Begin(ctx)
 	if err != nil {
 		return err
@@ -247,157 +419,188 @@ func (p *pgStore) SaveManyCommit(ctx context.Context, repoID int64, commits []*m
 	defer tx.Rollback(ctx)
 
 	qtx := p.q.WithTx(tx)
+	err = qtx.SaveIntentTransition(ctx, sqlc.SaveIntentTransitionParams{
+		IntentID: transition.IntentID,
+		FromStatus: sqlc.NullIntentStatus{
+			IntentStatus: sqlc.IntentStatus(transition.From),
+			Valid:        transition.From != "",
+		},
+		ToStatus:  sqlc.IntentStatus(transition.To),
+		CreatedAt: pgtype.Timestamptz{Time: transition.CreatedAt, Valid: true},
+	})
+	if err != nil {
+		return storeError(err)
+	}
 
-	for _, commit := range commits {
-		author, err := qtx.GetAuthor(ctx, commit.Author.ID)
-		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
-			return fmt.Errorf("failed to get author %d: %w", commit.Author.ID, err)
-		}
+	err = qtx.TrimIntentTransitions(ctx, sqlc.TrimIntentTransitionsParams{
+		IntentID: transition.IntentID,
+		Limit:    int32(keep),
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// EnqueueIntentCommand adds command, an encoded intent command, to the
+// outbox the broadcaster publishes from. An empty queue is discovery's.
+func (p *pgStore) EnqueueIntentCommand(ctx context.Context, intentID uuid.UUID, queue string, command []byte) error {
+	err := p.q.EnqueueIntentCommand(ctx, sqlc.EnqueueIntentCommandParams{
+		IntentID: intentID,
+		Queue:    pgtype.Text{String: queue, Valid: queue != ""},
+		Command:  command,
+	})
+	return storeError(err)
+}
+
+// DispatchIntentCommands passes up to limit due outbox commands to publish
+// in the order they were queued and deletes the published ones. A failure
+// is recorded against its command, which isn't due again until a backoff
+// doubling with each attempt, up to five minutes, has passed; the commands
+// after it are still published. It returns how many commands it passed.
+// Replicas dispatching at the same time skip each other's commands.
+func (p *pgStore) DispatchIntentCommands(ctx context.Context, limit int, publish func(queue string, command []byte) error) (int, error) {
+	tx, err := p.conn.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := p.q.WithTx(tx)
+	rows, err := qtx.ClaimIntentCommands(ctx, int32(limit))
+	if err != nil {
+		return 0, err
+	}
 
-		if errors.Is(err, pgx.ErrNoRows) {
-			author, err = qtx.SaveAuthor(ctx, sqlc.SaveAuthorParams{
-				ID:       commit.Author.ID,
-				Name:     commit.Author.Name,
-				Email:    commit.Author.Email,
-				Username: commit.Author.Username,
+	for _, row := range rows {
+		if err := publish(row.Queue.String, row.Command); err != nil {
+			failErr := qtx.FailIntentCommand(ctx, sqlc.FailIntentCommandParams{
+				ID:        row.ID,
+				LastError: pgtype.Text{String: err.Error(), Valid: true},
 			})
-			if err != nil {
-				return fmt.Errorf("failed to save author %s: %w", commit.Author.Username, err)
+			if failErr != nil {
+				return 0, failErr
 			}
+			continue
 		}
-
-		err = qtx.SaveCommit(ctx, sqlc.SaveCommitParams{
-			Hash:         commit.Hash,
-			AuthorID:     author.ID,
-			CreatedAt:    pgtype.Timestamptz{Time: commit.CreatedAt, Valid: true},
-			Message:      commit.Message,
-			RepositoryID: repoID,
-		})
-		if err != nil {
-			return fmt.Errorf("failed to save commit %s: %w", commit.Hash, err)
+		if err := qtx.DeleteIntentCommand(ctx, row.ID); err != nil {
+			return 0, err
 		}
 	}
 
-	if err := tx.Commit(ctx); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
-	}
-
-	return nil
+	return len(rows), tx.Commit(ctx)
 }
 
-func (p *pgStore) SaveRepo(ctx context.Context, repo *models.Repository) error {
-	var createdAt, updatedAt pgtype.Timestamptz
-	createdAt.Time = repo.CreatedAt
-	createdAt.Valid = true
-	updatedAt.Time = repo.UpdatedAt
-	updatedAt.Valid = true
-
-	return p.q.SaveRepo(ctx, sqlc.SaveRepoParams{
-		ID:         repo.ID,
-		Watchers:   int32(repo.Watchers),
-		Stargazers: int32(repo.Stars),
-		FullName:   repo.FullName,
-		CreatedAt:  createdAt,
-		UpdatedAt:  updatedAt,
-		Language:   pgtype.Text{String: repo.Language, Valid: true},
-		Forks:      int32(repo.Forks),
+// ClaimForcedBroadcast records that the intent is forced out now, unless
+// it already was within cooldown, and reports whether it was recorded.
+// The claim is one statement, so replicas racing for it get one winner.
+func (p *pgStore) ClaimForcedBroadcast(ctx context.Context, intentID uuid.UUID, cooldown time.Duration) (time.Time, bool, error) {
+	forcedAt, err := p.q.ClaimForcedBroadcast(ctx, sqlc.ClaimForcedBroadcastParams{
+		IntentID:   intentID,
+		CooldownMs: cooldown.Milliseconds(),
 	})
-}
-
-func (p *pgStore) GetRepo(ctx context.Context, name string) (*models.Repository, error) {
-	repo, err := p.q.GetRepo(ctx, name)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return time.Time{}, false, nil
+	}
 	if err != nil {
-		return nil, err
+		return time.Time{}, false, storeError(err)
 	}
+	return forcedAt.Time, true, nil
+}
 
-	return &models.Repository{
-		ID:        repo.ID,
-		Watchers:  repo.Watchers,
-		Stars:     repo.Stargazers,
-		FullName:  repo.FullName,
-		CreatedAt: repo.CreatedAt.Time,
-		UpdatedAt: repo.UpdatedAt.Time,
-		Language:  repo.Language.String,
-		Forks:     repo.Forks,
-	}, nil
+// ReleaseForcedBroadcast gives back the claim made at forcedAt, leaving a
+// later one in place.
+func (p *pgStore) ReleaseForcedBroadcast(ctx context.Context, intentID uuid.UUID, forcedAt time.Time) error {
+	err := p.q.ReleaseForcedBroadcast(ctx, sqlc.ReleaseForcedBroadcastParams{
+		IntentID: intentID,
+		ForcedAt: pgtype.Timestamptz{Time: forcedAt, Valid: true},
+	})
+	return storeError(err)
 }
 
-func (p *pgStore) GetTopCommitters(ctx context.Context, repo string, startDate, endDate *time.Time, pagination repository.Pagination) (repository.Paginated[models.AuthorStats], error) {
-	var start, end pgtype.Timestamptz
-	if startDate != nil {
-		start.Time = *startDate
-		start.Valid = true
+// FindChildIntentRepos lists the repositories the org intent has created
+// intents for, including deleted ones, so they aren't created again.
+func (p *pgStore) FindChildIntentRepos(ctx context.Context, parentID uuid.UUID) ([]string, error) {
+	return p.q.FindChildIntentRepos(ctx, pgtype.UUID{Bytes: parentID, Valid: true})
+}
+
+func (p *pgStore) FindIntentTransitions(ctx context.Context, intentID uuid.UUID) ([]models.IntentTransition, error) {
+	err := p.checkIntentTenant(ctx, intentID)
+	if errors.Is(err, repository.ErrNotFound) {
+		// Other tenants' intents have no history, like unknown ones.
+		return []models.IntentTransition{}, nil
 	}
-	if endDate != nil {
-		end.Time = *endDate
-		end.Valid = true
+	if err != nil {
+		return nil, err
 	}
 
-	rows, err := p.q.GetTopCommitters(ctx, sqlc.GetTopCommittersParams{
-		FullName: repo,
-		Column2:  start,
-		Column3:  end,
-		Limit:    int32(pagination.PerPage),
-		Offset:   int32((pagination.Page - 1) * pagination.PerPage),
-	})
+	rows, err := p.q.FindIntentTransitions(ctx, intentID)
 	if err != nil {
-		return repository.Paginated[models.AuthorStats]{}, err
+		return nil, err
 	}
 
-	var stats []models.AuthorStats
+	transitions := make([]models.IntentTransition, 0, len(rows))
 	for _, row := range rows {
-		stats = append(stats, models.AuthorStats{
-			Author: models.Author{
-				ID:       row.ID,
-				Name:     row.Name,
-				Email:    row.Email,
-				Username: row.Username,
-			},
-			Commits: row.CommitCount,
+		transitions = append(transitions, models.IntentTransition{
+			IntentID:  row.IntentID,
+			From:      models.IntentStatus(row.FromStatus.IntentStatus),
+			To:        models.IntentStatus(row.ToStatus),
+			CreatedAt: row.CreatedAt.Time,
 		})
 	}
-
-	return repository.Paginated[models.AuthorStats]{
-		Data:       stats,
-		TotalCount: int64(len(stats)),
-		Page:       pagination.Page,
-		PerPage:    pagination.PerPage,
-	}, nil
+	return transitions, nil
 }
 
-func (p *pgStore) SaveAuthor(ctx context.Context, author *models.Author) error {
-	_, err := p.q.SaveAuthor(ctx, sqlc.SaveAuthorParams{
-		ID:       author.ID,
-		Name:     author.Name,
-		Email:    author.Email,
-		Username: author.Username,
-	})
-	return err
+func toIntent(intent sqlc.Intent) *models.Intent {
+	return &models.Intent{
+		ID:             intent.ID,
+		RepositoryName: intent.RepositoryName,
+		StartDate:      fromTimestamptz(intent.StartDate),
+		Until:          fromTimestamptz(intent.EndDate),
+		Status:         models.IntentStatus(intent.Status),
+		IsActive:       intent.IsActive,
+		SyncStartedAt:  fromTimestamptz(intent.SyncStartedAt),
+		LastSyncedAt:   fromTimestamptz(intent.LastSyncedAt),
+		SyncedCommits:  intent.SyncedCommits,
+		DeletedAt:      fromTimestamptz(intent.DeletedAt),
+		ParentID:       fromUUID(intent.ParentID),
+		PausedAt:       fromTimestamptz(intent.PausedAt),
+		Tenant:         intent.TenantID,
+		IntentOptions: models.IntentOptions{
+			MaxConcurrentPages: fromInt4(intent.MaxConcurrentPages),
+			RequestsPerMinute:  fromInt4(intent.RequestsPerMinute),
+			IndexAllBranches:   intent.IndexAllBranches,
+			PathFilters:        intent.PathFilters,
+			AuthorFilters:      intent.AuthorFilters,
+			Branch:             intent.Branch.String,
+			ExcludedAuthors:    intent.ExcludedAuthors,
+			MaxCommits:         fromInt4(intent.MaxCommits),
+			CredentialID:       fromUUID(intent.CredentialID),
+			Retry:              fromRetryColumns(intent.RetryMaxAttempts, intent.RetryBackoffBaseMs, intent.RetryJitter),
+		},
+	}
 }
 
-func (p *pgStore) FindCommits(ctx context.Context, filter models.CommitsFilter, pagination repository.Pagination) (repository.Paginated[models.Commit], error) {
-	var commits []models.Commit
-
+func (p *pgStore) GetChurn(ctx context.Context, filter models.CommitsFilter) (*models.Churn, error) {
 	query := squirrel.Select(
-		"c.hash", "c.message", "c.url", "c.created_at",
-		"a.id AS author_id", "a.name AS author_name", "a.email AS author_email", "a.username AS author_username",
-		"r.id AS repo_id", "r.watchers", "r.stargazers", "r.full_name AS repository",
-		"r.created_at AS repo_created_at", "r.updated_at AS repo_updated_at", "r.language", "r.forks",
+		"COUNT(*)",
+		"COALESCE(SUM(c.additions), 0)",
+		"COALESCE(SUM(c.deletions), 0)",
+		"COALESCE(SUM(c.changes), 0)",
 	).
 		From("commits c").
 		Join("repositories r ON c.repository_id = r.id").
 		Join("authors a ON c.author_id = a.id").
-		Where(squirrel.Eq{"r.full_name": filter.RepositoryName}).
-		OrderBy("c.created_at DESC").
-		Limit(uint64(pagination.PerPage)).
-		Offset(uint64((pagination.Page - 1) * pagination.PerPage))
+		Where(squirrel.Eq{"r.full_name": filter.RepositoryName})
 
 	if filter.StartDate != nil && !filter.StartDate.IsZero() {
 		query = query.Where(squirrel.GtOrEq{"c.created_at": *filter.StartDate})
 	}
 
+	// The end date is a day, and includes every commit made on it.
 	if filter.EndDate != nil && !filter.EndDate.IsZero() {
-		query = query.Where(squirrel.LtOrEq{"c.created_at": *filter.EndDate})
+		query = query.Where(squirrel.Lt{"c.created_at": filter.EndDate.AddDate(0, 0, 1)})
 	}
 
 	if filter.AuthorUsername != nil && *filter.AuthorUsername != "" {
@@ -406,79 +609,1023 @@ func (p *pgStore) FindCommits(ctx context.Context, filter models.CommitsFilter,
 
 	sql, args, err := query.PlaceholderFormat(squirrel.Dollar).ToSql()
 	if err != nil {
-		return repository.Paginated[models.Commit]{}, err
+		return nil, err
 	}
 
-	rows, err := p.conn.Query(ctx, sql, args...)
+	var churn models.Churn
+	err = p.conn.QueryRow(ctx, sql, args...).Scan(&churn.Commits, &churn.Additions, &churn.Deletions, &churn.Changes)
 	if err != nil {
-		return repository.Paginated[models.Commit]{}, err
+		return nil, fmt.Errorf("failed to get churn: %w", err)
 	}
-	defer rows.Close()
 
-	for rows.Next() {
-		var commit models.Commit
-		var urlStr pgtype.Text
-		var repoCreatedAt, repoUpdatedAt, commitCreatedAt pgtype.Timestamptz
-		var language pgtype.Text
+	return &churn, nil
+}
 
-		err := rows.Scan(
-			&commit.Hash, &commit.Message, &urlStr, &commitCreatedAt,
-			&commit.Author.ID, &commit.Author.Name, &commit.Author.Email, &commit.Author.Username,
-			&commit.Repository.ID, &commit.Repository.Watchers, &commit.Repository.Stars, &commit.Repository.FullName,
-			&repoCreatedAt, &repoUpdatedAt, &language, &commit.Repository.Forks,
-		)
-		if err != nil {
-			return repository.Paginated[models.Commit]{}, err
-		}
+func toTimestamptz(t *time.Time) pgtype.Timestamptz {
+	if t == nil {
+		return pgtype.Timestamptz{}
+	}
+	return pgtype.Timestamptz{Time: *t, Valid: true}
+}
 
-		if urlStr.Valid {
-			parsedURL, err := url.Parse(urlStr.String)
-			if err != nil {
-				return repository.Paginated[models.Commit]{}, err
-			}
-			commit.Url = parsedURL
-		}
+func fromTimestamptz(t pgtype.Timestamptz) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
 
-		commit.CreatedAt = commitCreatedAt.Time
-		commit.Repository.CreatedAt = repoCreatedAt.Time
-		commit.Repository.UpdatedAt = repoUpdatedAt.Time
-		commit.Repository.Language = language.String
+func toInt4(v *int32) pgtype.Int4 {
+	if v == nil {
+		return pgtype.Int4{}
+	}
+	return pgtype.Int4{Int32: *v, Valid: true}
+}
 
-		commits = append(commits, commit)
+func fromInt4(v pgtype.Int4) *int32 {
+	if !v.Valid {
+		return nil
 	}
+	return &v.Int32
+}
 
-	if rows.Err() != nil {
-		return repository.Paginated[models.Commit]{}, rows.Err()
+func toFloat8(v *float64) pgtype.Float8 {
+	if v == nil {
+		return pgtype.Float8{}
 	}
+	return pgtype.Float8{Float64: *v, Valid: true}
+}
 
-	countQuery := squirrel.Select("COUNT(*)").
-		From("commits c").
-		Join("repositories r ON c.repository_id = r.id").
-		Where(squirrel.Eq{"r.full_name": filter.RepositoryName})
+func fromFloat8(v pgtype.Float8) *float64 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Float64
+}
 
-	if filter.StartDate != nil && !filter.StartDate.IsZero() {
-		countQuery = countQuery.Where(squirrel.GtOrEq{"c.created_at": *filter.StartDate})
+// fromRetryColumns returns nil when the intent has no retry policy of its
+// own.
+func fromRetryColumns(maxAttempts, backoffBaseMs pgtype.Int4, jitter pgtype.Float8) *models.RetryPolicy {
+	if !maxAttempts.Valid && !backoffBaseMs.Valid && !jitter.Valid {
+		return nil
 	}
+	return &models.RetryPolicy{
+		MaxAttempts:   fromInt4(maxAttempts),
+		BackoffBaseMs: fromInt4(backoffBaseMs),
+		Jitter:        fromFloat8(jitter),
+	}
+}
 
-	if filter.EndDate != nil && !filter.EndDate.IsZero() {
-		countQuery = countQuery.Where(squirrel.LtOrEq{"c.created_at": *filter.EndDate})
+func toUUID(v *uuid.UUID) pgtype.UUID {
+	if v == nil {
+		return pgtype.UUID{}
 	}
+	return pgtype.UUID{Bytes: *v, Valid: true}
+}
 
-	sqlCount, argsCount, err := countQuery.PlaceholderFormat(squirrel.Dollar).ToSql()
-	if err != nil {
-		return repository.Paginated[models.Commit]{}, err
+func fromUUID(v pgtype.UUID) *uuid.UUID {
+	if !v.Valid {
+		return nil
 	}
+	id := uuid.UUID(v.Bytes)
+	return &id
+}
 
-	var totalCount int64
-	err = p.conn.QueryRow(ctx, sqlCount, argsCount...).Scan(&totalCount)
+func (p *pgStore) SaveManyCommit(ctx context.Context, repoID int64, commits []*models.Commit) error {
+	tx, err := p.conn.Begin(ctx)
 	if err != nil {
-		return repository.Paginated[models.Commit]{}, err
+		return err
 	}
+	defer tx.Rollback(ctx)
 
-	return repository.Paginated[models.Commit]{
-		Data:       commits,
-		TotalCount: totalCount,
+	qtx := p.q.WithTx(tx)
+
+	if _, err := qtx.LockRepo(ctx, repoID); err != nil {
+		return fmt.Errorf("failed to lock repository %d: %w", repoID, err)
+	}
+
+	if err := qtx.SaveAuthors(ctx, batchAuthors(commits)); err != nil {
+		return fmt.Errorf("failed to save authors: %w", err)
+	}
+
+	var saved int64
+	var lastCommitAt time.Time
+	for _, commit := range commits {
+		var stats models.CommitStats
+		if commit.Stats != nil {
+			stats = *commit.Stats
+		}
+
+		n, err := qtx.SaveCommit(ctx, sqlc.SaveCommitParams{
+			Hash:         commit.Hash,
+			AuthorID:     commit.Author.ID,
+			CreatedAt:    pgtype.Timestamptz{Time: commit.CreatedAt, Valid: true},
+			Message:      commit.Message,
+			Url:          pgtype.Text{String: commit.Url, Valid: commit.Url != ""},
+			RepositoryID: repoID,
+			Additions:    pgtype.Int4{Int32: stats.Additions, Valid: commit.Stats != nil},
+			Deletions:    pgtype.Int4{Int32: stats.Deletions, Valid: commit.Stats != nil},
+			Changes:      pgtype.Int4{Int32: stats.Changes, Valid: commit.Stats != nil},
+			Tags:         commitTags(commit),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to save commit %s: %w", commit.Hash, err)
+		}
+		// Commits already indexed don't count again.
+		if n == 0 {
+			continue
+		}
+		saved += n
+		if commit.CreatedAt.After(lastCommitAt) {
+			lastCommitAt = commit.CreatedAt
+		}
+	}
+
+	if err := saveComments(ctx, qtx, repoID, commits); err != nil {
+		return err
+	}
+
+	if saved > 0 {
+		err = qtx.AddRepoCommits(ctx, sqlc.AddRepoCommitsParams{
+			Commits:      saved,
+			LastCommitAt: pgtype.Timestamptz{Time: lastCommitAt, Valid: true},
+			ID:           repoID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to count commits: %w", err)
+		}
+	}
+
+	// Listeners only hear of the batch once the transaction commits.
+	err = qtx.NotifyCommits(ctx, sqlc.NotifyCommitsParams{
+		Commits:      int32(len(commits)),
+		RepositoryID: repoID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to notify commits: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// commitTags is the tags column of commit, which is never NULL.
+func commitTags(commit *models.Commit) []string {
+	if commit.Tags == nil {
+		return []string{}
+	}
+	return commit.Tags
+}
+
+// batchAuthors collects the distinct authors of commits so a batch saves
+// them in one statement. As authors already saved are kept, the first
+// commit of an author wins.
+func batchAuthors(commits []*models.Commit) sqlc.SaveAuthorsParams {
+	var params sqlc.SaveAuthorsParams
+	seen := make(map[int64]bool, len(commits))
+	for _, commit := range commits {
+		author := commit.Author
+		if seen[author.ID] {
+			continue
+		}
+		seen[author.ID] = true
+		params.Ids = append(params.Ids, author.ID)
+		params.Names = append(params.Names, author.Name)
+		params.Emails = append(params.Emails, author.Email)
+		params.Usernames = append(params.Usernames, author.Username)
+	}
+	return params
+}
+
+// saveComments saves the comments fetched with commits. A comment saved
+// before takes its latest body.
+func saveComments(ctx context.Context, qtx *sqlc.Queries, repoID int64, commits []*models.Commit) error {
+	params := sqlc.SaveCommitCommentsParams{RepositoryID: repoID}
+	for _, commit := range commits {
+		for _, comment := range commit.Comments {
+			params.Ids = append(params.Ids, comment.ID)
+			params.CommitHashes = append(params.CommitHashes, commit.Hash)
+			params.Authors = append(params.Authors, comment.Author)
+			params.Bodies = append(params.Bodies, comment.Body)
+			params.Urls = append(params.Urls, comment.Url)
+			params.CreatedAts = append(params.CreatedAts, pgtype.Timestamptz{Time: comment.CreatedAt, Valid: true})
+		}
+	}
+	if len(params.Ids) == 0 {
+		return nil
+	}
+	if err := qtx.SaveCommitComments(ctx, params); err != nil {
+		return fmt.Errorf("failed to save commit comments: %w", err)
+	}
+	return nil
+}
+
+// FindCommitComments returns the comments on the repository's commit, the
+// oldest first.
+func (p *pgStore) FindCommitComments(ctx context.Context, repoID int64, hash string) ([]models.CommitComment, error) {
+	rows, err := p.q.FindCommitComments(ctx, sqlc.FindCommitCommentsParams{
+		RepositoryID: repoID,
+		CommitHash:   hash,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	comments := make([]models.CommitComment, 0, len(rows))
+	for _, row := range rows {
+		comments = append(comments, models.CommitComment{
+			ID:        row.ID,
+			Author:    row.Author,
+			Body:      row.Body,
+			Url:       row.Url.String,
+			CreatedAt: row.CreatedAt.Time,
+		})
+	}
+	return comments, nil
+}
+
+func (p *pgStore) SaveRepo(ctx context.Context, repo *models.Repository) error {
+	var createdAt, updatedAt pgtype.Timestamptz
+	createdAt.Time = repo.CreatedAt
+	createdAt.Valid = true
+	updatedAt.Time = repo.UpdatedAt
+	updatedAt.Valid = true
+
+	return p.q.SaveRepo(ctx, sqlc.SaveRepoParams{
+		ID:            repo.ID,
+		Watchers:      int32(repo.Watchers),
+		Stargazers:    int32(repo.Stars),
+		FullName:      repo.FullName,
+		CreatedAt:     createdAt,
+		UpdatedAt:     updatedAt,
+		Language:      pgtype.Text{String: repo.Language, Valid: true},
+		Forks:         int32(repo.Forks),
+		Topics:        repo.Topics,
+		License:       pgtype.Text{String: repo.License, Valid: repo.License != ""},
+		DefaultBranch: pgtype.Text{String: repo.DefaultBranch, Valid: repo.DefaultBranch != ""},
+		Description:   pgtype.Text{String: repo.Description, Valid: repo.Description != ""},
+		Homepage:      pgtype.Text{String: repo.Homepage, Valid: repo.Homepage != ""},
+		OpenIssues:    repo.OpenIssues,
+		Archived:      repo.Archived,
+		NetworkID:     pgtype.Int8{Int64: repo.NetworkID, Valid: repo.NetworkID != 0},
+	})
+}
+
+// AddRepoTenant has the tenant of the intent with intentID track the
+// repository with repoID, so the tenant sees it and its commits. Nothing
+// happens if there is no such intent.
+func (p *pgStore) AddRepoTenant(ctx context.Context, repoID int64, intentID uuid.UUID) error {
+	return p.q.AddRepoTenant(ctx, sqlc.AddRepoTenantParams{RepositoryID: repoID, IntentID: intentID})
+}
+
+// GetRepo returns the repository by its current or an old name, or
+// repository.ErrNotFound if there is none or the tenant ctx is scoped to
+// does not track it.
+func (p *pgStore) GetRepo(ctx context.Context, name string) (*models.Repository, error) {
+	repo, err := p.q.GetRepo(ctx, name)
+	if err != nil {
+		return nil, storeError(err)
+	}
+	if tenant, ok := repository.TenantFrom(ctx); ok {
+		tracked, err := p.q.RepoInTenant(ctx, sqlc.RepoInTenantParams{RepositoryID: repo.ID, TenantID: tenant})
+		if err != nil {
+			return nil, err
+		}
+		if !tracked {
+			return nil, repository.ErrNotFound
+		}
+	}
+
+	found := toRepository(repo)
+	return &found, nil
+}
+
+// repoSortColumns maps each repository sort to its column.
+var repoSortColumns = map[models.RepositorySort]string{
+	models.SortByName:         "r.full_name",
+	models.SortByStars:        "r.stargazers",
+	models.SortByCommitCount:  "r.commit_count",
+	models.SortByLastCommitAt: "r.last_commit_at",
+}
+
+func (p *pgStore) FindRepos(ctx context.Context, filter models.RepositoryFilter, pag repository.Pagination) (repository.Paginated[models.Repository], error) {
+	sb := squirrel.Select(
+		"r.id",
+		"r.watchers",
+		"r.stargazers",
+		"r.full_name",
+		"r.created_at",
+		"r.updated_at",
+		"r.language",
+		"r.forks",
+		"r.topics",
+		"r.license",
+		"r.default_branch",
+		"r.description",
+		"r.homepage",
+		"r.open_issues",
+		"r.archived",
+		"r.network_id",
+		"r.commit_count",
+		"r.last_commit_at",
+	).From("repositories r")
+
+	if filter.Language != nil {
+		sb = sb.Where(squirrel.Eq{"r.language": *filter.Language})
+	}
+	if filter.Topic != nil {
+		sb = sb.Where(squirrel.Expr("? = ANY(r.topics)", *filter.Topic))
+	}
+	if tenant, ok := repository.TenantFrom(ctx); ok {
+		sb = sb.Where(repoInTenant("r.id", tenant))
+	}
+	sb = sb.Where("r.deleted_at IS NULL")
+
+	countBuilder := sb.PlaceholderFormat(squirrel.Dollar).Prefix("SELECT COUNT(*) FROM (").Suffix(") AS subquery")
+	totalCountSQL, args, err := countBuilder.ToSql()
+	if err != nil {
+		return repository.Paginated[models.Repository]{}, fmt.Errorf("failed to build count SQL: %w", err)
+	}
+
+	var totalCount int64
+	err = p.conn.QueryRow(ctx, totalCountSQL, args...).Scan(&totalCount)
+	if err != nil {
+		return repository.Paginated[models.Repository]{}, fmt.Errorf("failed to get total count: %w", err)
+	}
+
+	column, ok := repoSortColumns[filter.Sort]
+	if !ok {
+		column = repoSortColumns[models.SortByName]
+	}
+	order := " ASC"
+	if filter.Descending {
+		order = " DESC"
+	}
+	// Repositories without indexed commits go last either way, and names
+	// break ties so pages don't overlap.
+	sb = sb.OrderBy(column+order+" NULLS LAST", "r.full_name")
+
+	sb = sb.Offset(uint64((pag.Page - 1) * pag.PerPage)).Limit(uint64(pag.PerPage)).PlaceholderFormat(squirrel.Dollar)
+	sql, args, err := sb.ToSql()
+	if err != nil {
+		return repository.Paginated[models.Repository]{}, fmt.Errorf("failed to build SQL: %w", err)
+	}
+
+	rows, err := p.conn.Query(ctx, sql, args...)
+	if err != nil {
+		return repository.Paginated[models.Repository]{}, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	repos := []models.Repository{}
+	for rows.Next() {
+		var repo sqlc.Repository
+		err = rows.Scan(
+			&repo.ID,
+			&repo.Watchers,
+			&repo.Stargazers,
+			&repo.FullName,
+			&repo.CreatedAt,
+			&repo.UpdatedAt,
+			&repo.Language,
+			&repo.Forks,
+			&repo.Topics,
+			&repo.License,
+			&repo.DefaultBranch,
+			&repo.Description,
+			&repo.Homepage,
+			&repo.OpenIssues,
+			&repo.Archived,
+			&repo.NetworkID,
+			&repo.CommitCount,
+			&repo.LastCommitAt,
+		)
+		if err != nil {
+			return repository.Paginated[models.Repository]{}, fmt.Errorf("failed to scan row: %w", err)
+		}
+		repos = append(repos, toRepository(repo))
+	}
+	if err := rows.Err(); err != nil {
+		return repository.Paginated[models.Repository]{}, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return repository.Paginated[models.Repository]{
+		Data:       repos,
+		TotalCount: totalCount,
+		Page:       pag.Page,
+		PerPage:    pag.PerPage,
+	}, nil
+}
+
+func toRepository(repo sqlc.Repository) models.Repository {
+	return models.Repository{
+		ID:            repo.ID,
+		Watchers:      repo.Watchers,
+		Stars:         repo.Stargazers,
+		FullName:      repo.FullName,
+		CreatedAt:     repo.CreatedAt.Time,
+		UpdatedAt:     repo.UpdatedAt.Time,
+		Language:      repo.Language.String,
+		Forks:         repo.Forks,
+		Topics:        repo.Topics,
+		License:       repo.License.String,
+		DefaultBranch: repo.DefaultBranch.String,
+		Description:   repo.Description.String,
+		Homepage:      repo.Homepage.String,
+		OpenIssues:    repo.OpenIssues,
+		Archived:      repo.Archived,
+		NetworkID:     repo.NetworkID.Int64,
+		CommitCount:   repo.CommitCount,
+		LastCommitAt:  fromTimestamptz(repo.LastCommitAt),
+		DeletedAt:     fromTimestamptz(repo.DeletedAt),
+	}
+}
+
+// DeleteRepo soft-deletes the repository with the given id, leaving it
+// and its commits out of every lookup and listing until it is purged. It
+// returns repository.ErrNotFound if there is none or it is already
+// deleted.
+func (p *pgStore) DeleteRepo(ctx context.Context, id int64) (*models.Repository, error) {
+	repo, err := p.q.DeleteRepo(ctx, id)
+	if err != nil {
+		return nil, storeError(err)
+	}
+	deleted := toRepository(repo)
+	return &deleted, nil
+}
+
+// FindDeletedRepos returns the repositories deleted before before, the
+// longest deleted first.
+func (p *pgStore) FindDeletedRepos(ctx context.Context, before time.Time) ([]models.Repository, error) {
+	rows, err := p.q.FindDeletedRepos(ctx, pgtype.Timestamptz{Time: before, Valid: true})
+	if err != nil {
+		return nil, err
+	}
+	repos := make([]models.Repository, 0, len(rows))
+	for _, row := range rows {
+		repos = append(repos, toRepository(row))
+	}
+	return repos, nil
+}
+
+// PurgeRepo deletes a deleted repository for good, with its commits and
+// everything else indexed of it, and returns how many commits it had.
+func (p *pgStore) PurgeRepo(ctx context.Context, id int64) (int64, error) {
+	return p.q.PurgeRepo(ctx, id)
+}
+
+// RenameRepo gives the repository with the given id a new name, keeping
+// its old one as an alias, and moves its intents along. It returns the old
+// name, or an empty string if the repository is unknown or already has
+// that name.
+func (p *pgStore) RenameRepo(ctx context.Context, id int64, name string) (string, error) {
+	previous, err := p.q.RenameRepo(ctx, sqlc.RenameRepoParams{ID: id, FullName: name})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	return previous, err
+}
+
+// GetTopCommitters reads the all-time leaderboard from its materialized
+// view, which may trail the latest commits until RefreshLeaderboards runs.
+// Bounded date ranges are counted from the commits.
+func (p *pgStore) GetTopCommitters(ctx context.Context, repo string, startDate, endDate *time.Time, pagination repository.Pagination) (repository.Paginated[models.AuthorStats], error) {
+	if startDate == nil && endDate == nil {
+		return p.getLeaderboard(ctx, repo, pagination)
+	}
+
+	var start, end pgtype.Timestamptz
+	if startDate != nil {
+		start.Time = *startDate
+		start.Valid = true
+	}
+	if endDate != nil {
+		end.Time = *endDate
+		end.Valid = true
+	}
+
+	rows, err := p.q.GetTopCommitters(ctx, sqlc.GetTopCommittersParams{
+		FullName: repo,
+		Column2:  start,
+		Column3:  end,
+		Limit:    int32(pagination.PerPage),
+		Offset:   int32((pagination.Page - 1) * pagination.PerPage),
+	})
+	if err != nil {
+		return repository.Paginated[models.AuthorStats]{}, err
+	}
+
+	var stats []models.AuthorStats
+	for _, row := range rows {
+		stats = append(stats, models.AuthorStats{
+			Author: models.Author{
+				ID:       row.ID,
+				Name:     row.Name,
+				Email:    row.Email,
+				Username: row.Username,
+			},
+			Commits: row.CommitCount,
+		})
+	}
+
+	total, err := p.q.CountTopCommitters(ctx, sqlc.CountTopCommittersParams{
+		FullName: repo,
+		Column2:  start,
+		Column3:  end,
+	})
+	if err != nil {
+		return repository.Paginated[models.AuthorStats]{}, fmt.Errorf("failed to count top committers: %w", err)
+	}
+
+	return repository.Paginated[models.AuthorStats]{
+		Data:       stats,
+		TotalCount: total,
+		Page:       pagination.Page,
+		PerPage:    pagination.PerPage,
+	}, nil
+}
+
+func (p *pgStore) getLeaderboard(ctx context.Context, repo string, pagination repository.Pagination) (repository.Paginated[models.AuthorStats], error) {
+	rows, err := p.q.GetLeaderboard(ctx, sqlc.GetLeaderboardParams{
+		FullName: repo,
+		Limit:    int32(pagination.PerPage),
+		Offset:   int32((pagination.Page - 1) * pagination.PerPage),
+	})
+	if err != nil {
+		return repository.Paginated[models.AuthorStats]{}, err
+	}
+
+	var stats []models.AuthorStats
+	for _, row := range rows {
+		stats = append(stats, models.AuthorStats{
+			Author: models.Author{
+				ID:       row.ID,
+				Name:     row.Name,
+				Email:    row.Email,
+				Username: row.Username,
+			},
+			Commits: row.CommitCount,
+		})
+	}
+
+	total, err := p.q.CountLeaderboard(ctx, repo)
+	if err != nil {
+		return repository.Paginated[models.AuthorStats]{}, fmt.Errorf("failed to count top committers: %w", err)
+	}
+
+	return repository.Paginated[models.AuthorStats]{
+		Data:       stats,
+		TotalCount: total,
+		Page:       pagination.Page,
+		PerPage:    pagination.PerPage,
+	}, nil
+}
+
+// GetDailyCommits reads the per-day counts from the daily rollup. Days
+// without commits are filled in with zeros, between the filter's dates or,
+// when unset, the repository's first and last rolled up days.
+func (p *pgStore) GetDailyCommits(ctx context.Context, filter models.CommitsFilter) ([]models.DailyCommits, error) {
+	params := sqlc.GetDailyCommitsParams{FullName: filter.RepositoryName}
+	if filter.StartDate != nil && !filter.StartDate.IsZero() {
+		params.Column2 = pgtype.Date{Time: *filter.StartDate, Valid: true}
+	}
+	if filter.EndDate != nil && !filter.EndDate.IsZero() {
+		params.Column3 = pgtype.Date{Time: *filter.EndDate, Valid: true}
+	}
+
+	if filter.Dedupe {
+		return p.getDedupedDailyCommits(ctx, params)
+	}
+
+	rows, err := p.q.GetDailyCommits(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	days := make([]models.DailyCommits, 0, len(rows))
+	for _, row := range rows {
+		days = append(days, models.DailyCommits{
+			Day:       row.Day.Time.Format(time.DateOnly),
+			Commits:   row.Commits,
+			Additions: row.Additions,
+			Deletions: row.Deletions,
+		})
+	}
+	return days, nil
+}
+
+// GetCommitActivity sums the daily rollup into a dense series of buckets of
+// the interval, with zeros for the buckets without commits. The first and
+// last buckets hold only the commits within the filter's dates.
+func (p *pgStore) GetCommitActivity(ctx context.Context, filter models.CommitsFilter, interval models.ActivityInterval) ([]models.ActivityBucket, error) {
+	params := sqlc.GetCommitActivityParams{
+		Bucket:   string(interval),
+		FullName: filter.RepositoryName,
+	}
+	if filter.StartDate != nil && !filter.StartDate.IsZero() {
+		params.StartDate = pgtype.Date{Time: *filter.StartDate, Valid: true}
+	}
+	if filter.EndDate != nil && !filter.EndDate.IsZero() {
+		params.EndDate = pgtype.Date{Time: *filter.EndDate, Valid: true}
+	}
+
+	rows, err := p.q.GetCommitActivity(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	buckets := make([]models.ActivityBucket, 0, len(rows))
+	for _, row := range rows {
+		buckets = append(buckets, models.ActivityBucket{
+			Start:   row.Bucket.Time.Format(time.DateOnly),
+			Commits: row.Commits,
+		})
+	}
+	return buckets, nil
+}
+
+// getDedupedDailyCommits counts from the commits rather than the rollup,
+// which doesn't know which of them are shared across a fork network.
+func (p *pgStore) getDedupedDailyCommits(ctx context.Context, params sqlc.GetDailyCommitsParams) ([]models.DailyCommits, error) {
+	rows, err := p.q.GetDedupedDailyCommits(ctx, sqlc.GetDedupedDailyCommitsParams(params))
+	if err != nil {
+		return nil, err
+	}
+
+	days := make([]models.DailyCommits, 0, len(rows))
+	for _, row := range rows {
+		days = append(days, models.DailyCommits{
+			Day:       row.Day.Time.Format(time.DateOnly),
+			Commits:   row.Commits,
+			Additions: row.Additions,
+			Deletions: row.Deletions,
+		})
+	}
+	return days, nil
+}
+
+// GetContributions reads each author's commits per month from the daily
+// rollup, for the months they committed in. Months start on the 1st.
+func (p *pgStore) GetContributions(ctx context.Context, filter models.CommitsFilter) ([]models.MonthlyContribution, error) {
+	params := sqlc.GetContributionsParams{FullName: filter.RepositoryName}
+	if filter.StartDate != nil && !filter.StartDate.IsZero() {
+		params.Column2 = pgtype.Date{Time: *filter.StartDate, Valid: true}
+	}
+	if filter.EndDate != nil && !filter.EndDate.IsZero() {
+		params.Column3 = pgtype.Date{Time: *filter.EndDate, Valid: true}
+	}
+
+	rows, err := p.q.GetContributions(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	contributions := make([]models.MonthlyContribution, 0, len(rows))
+	for _, row := range rows {
+		contributions = append(contributions, models.MonthlyContribution{
+			Author: models.Author{
+				ID:       row.ID,
+				Name:     row.Name,
+				Email:    row.Email,
+				Username: row.Username,
+			},
+			Month:   row.Month.Time,
+			Commits: row.Commits,
+		})
+	}
+	return contributions, nil
+}
+
+// PurgeRepoCommits deletes the commits of repo with their comments and
+// daily rollup, and returns how many commits it deleted. The repository
+// itself and its other data are kept.
+func (p *pgStore) PurgeRepoCommits(ctx context.Context, repo string) (int64, error) {
+	purged, err := p.q.PurgeRepoCommits(ctx, repo)
+	if err != nil {
+		return 0, storeError(err)
+	}
+	return purged, nil
+}
+
+// RefreshLeaderboards recomputes the top committers view. A concurrent
+// refresh keeps it readable meanwhile.
+func (p *pgStore) RefreshLeaderboards(ctx context.Context) error {
+	if err := p.q.RefreshTopCommitters(ctx); err != nil {
+		return fmt.Errorf("failed to refresh top committers: %w", err)
+	}
+	return nil
+}
+
+// RollupCommits adds up to limit commits that are not yet in the daily
+// rollup to it and returns how many it added per repository. Replicas
+// rolling up at the same time skip each other's commits.
+func (p *pgStore) RollupCommits(ctx context.Context, limit int) (map[string]int64, error) {
+	rows, err := p.q.RollupCommits(ctx, int32(limit))
+	if err != nil {
+		return nil, err
+	}
+
+	rolled := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		rolled[row.FullName] = row.Commits
+	}
+	return rolled, nil
+}
+
+// GetRepoStats sums the daily rollup, so commits that have not been rolled
+// up yet are left out. Deduped stats are counted from the commits instead.
+func (p *pgStore) GetRepoStats(ctx context.Context, filter models.CommitsFilter) (*models.RepoStats, error) {
+	params := sqlc.GetRepoStatsParams{FullName: filter.RepositoryName}
+	if filter.StartDate != nil && !filter.StartDate.IsZero() {
+		params.Column2 = pgtype.Date{Time: *filter.StartDate, Valid: true}
+	}
+	if filter.EndDate != nil && !filter.EndDate.IsZero() {
+		params.Column3 = pgtype.Date{Time: *filter.EndDate, Valid: true}
+	}
+
+	if filter.Dedupe {
+		row, err := p.q.GetDedupedRepoStats(ctx, sqlc.GetDedupedRepoStatsParams(params))
+		if err != nil {
+			return nil, err
+		}
+		return &models.RepoStats{
+			Commits:    row.Commits,
+			Additions:  row.Additions,
+			Deletions:  row.Deletions,
+			Authors:    row.Authors,
+			ActiveDays: row.ActiveDays,
+		}, nil
+	}
+
+	row, err := p.q.GetRepoStats(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return &models.RepoStats{
+		Commits:    row.Commits,
+		Additions:  row.Additions,
+		Deletions:  row.Deletions,
+		Authors:    row.Authors,
+		ActiveDays: row.ActiveDays,
+	}, nil
+}
+
+func (p *pgStore) SaveAuthor(ctx context.Context, author *models.Author) error {
+	_, err := p.q.SaveAuthor(ctx, sqlc.SaveAuthorParams{
+		ID:       author.ID,
+		Name:     author.Name,
+		Email:    author.Email,
+		Username: author.Username,
+	})
+	return err
+}
+
+func (p *pgStore) FindCommits(ctx context.Context, filter models.CommitsFilter, pagination repository.Pagination) (repository.Paginated[models.Commit], error) {
+	query := commitsSelect().
+		Where(commitsWhere(ctx, filter)).
+		OrderBy("c.created_at DESC").
+		Limit(uint64(pagination.PerPage)).
+		Offset(uint64((pagination.Page - 1) * pagination.PerPage))
+
+	commits, err := p.queryCommits(ctx, query)
+	if err != nil {
+		return repository.Paginated[models.Commit]{}, err
+	}
+
+	countQuery := squirrel.Select("COUNT(*)").
+		From("commits c").
+		Join("repositories r ON c.repository_id = r.id").
+		Join("authors a ON c.author_id = a.id").
+		Where(commitsWhere(ctx, filter))
+
+	sqlCount, argsCount, err := countQuery.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return repository.Paginated[models.Commit]{}, err
+	}
+
+	var totalCount int64
+	err = p.conn.QueryRow(ctx, sqlCount, argsCount...).Scan(&totalCount)
+	if err != nil {
+		return repository.Paginated[models.Commit]{}, err
+	}
+
+	return repository.Paginated[models.Commit]{
+		Data:       commits,
+		TotalCount: totalCount,
 		Page:       pagination.Page,
 		PerPage:    pagination.PerPage,
 	}, nil
 }
+
+// commitsSelect selects commits with their author and repository, as
+// scanned by queryCommits.
+func commitsSelect() squirrel.SelectBuilder {
+	return squirrel.Select(
+		"c.hash", "c.message", "c.url", "c.created_at",
+		"a.id AS author_id", "a.name AS author_name", "a.email AS author_email", "a.username AS author_username",
+		"r.id AS repo_id", "r.watchers", "r.stargazers", "r.full_name AS repository",
+		"r.created_at AS repo_created_at", "r.updated_at AS repo_updated_at", "r.language", "r.forks",
+		"c.additions", "c.deletions", "c.changes", "c.tags",
+	).
+		From("commits c").
+		Join("repositories r ON c.repository_id = r.id").
+		Join("authors a ON c.author_id = a.id")
+}
+
+// queryCommits runs a query built on commitsSelect.
+func (p *pgStore) queryCommits(ctx context.Context, query squirrel.SelectBuilder) ([]models.Commit, error) {
+	sql, args, err := query.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := p.conn.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var commits []models.Commit
+	for rows.Next() {
+		var commit models.Commit
+		var urlStr pgtype.Text
+		var repoCreatedAt, repoUpdatedAt, commitCreatedAt pgtype.Timestamptz
+		var language pgtype.Text
+		var additions, deletions, changes pgtype.Int4
+
+		err := rows.Scan(
+			&commit.Hash, &commit.Message, &urlStr, &commitCreatedAt,
+			&commit.Author.ID, &commit.Author.Name, &commit.Author.Email, &commit.Author.Username,
+			&commit.Repository.ID, &commit.Repository.Watchers, &commit.Repository.Stars, &commit.Repository.FullName,
+			&repoCreatedAt, &repoUpdatedAt, &language, &commit.Repository.Forks,
+			&additions, &deletions, &changes, &commit.Tags,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		commit.Url = urlStr.String
+		commit.CreatedAt = commitCreatedAt.Time
+		commit.Repository.CreatedAt = repoCreatedAt.Time
+		commit.Repository.UpdatedAt = repoUpdatedAt.Time
+		commit.Repository.Language = language.String
+		if additions.Valid {
+			commit.Stats = &models.CommitStats{
+				Additions: additions.Int32,
+				Deletions: deletions.Int32,
+				Changes:   changes.Int32,
+			}
+		}
+
+		commits = append(commits, commit)
+	}
+	return commits, rows.Err()
+}
+
+// commitsWhere is the condition selecting the commits matching filter in
+// the tenant ctx is scoped to, on commits c joined with repositories r and
+// authors a. Commits of deleted repositories are left out.
+func commitsWhere(ctx context.Context, filter models.CommitsFilter) squirrel.And {
+	where := squirrel.And{squirrel.Eq{"r.full_name": filter.RepositoryName}, squirrel.Expr("r.deleted_at IS NULL")}
+	if tenant, ok := repository.TenantFrom(ctx); ok {
+		where = append(where, repoInTenant("c.repository_id", tenant))
+	}
+	if filter.StartDate != nil && !filter.StartDate.IsZero() {
+		where = append(where, squirrel.GtOrEq{"c.created_at": *filter.StartDate})
+	}
+	if filter.EndDate != nil && !filter.EndDate.IsZero() {
+		where = append(where, squirrel.LtOrEq{"c.created_at": *filter.EndDate})
+	}
+	if filter.AuthorUsername != nil && *filter.AuthorUsername != "" {
+		where = append(where, squirrel.Eq{"a.username": *filter.AuthorUsername})
+	}
+	if filter.Message != nil && *filter.Message != "" {
+		where = append(where, squirrel.ILike{"c.message": "%" + likeEscaper.Replace(*filter.Message) + "%"})
+	}
+	return where
+}
+
+func (p *pgStore) GetIngestionStatus(ctx context.Context, errorLimit int) (*models.IngestionStatus, error) {
+	status := &models.IngestionStatus{
+		Intents:      map[models.IntentStatus]int64{},
+		RecentErrors: []models.IntentError{},
+	}
+
+	counts, err := p.q.CountIntentsByStatus(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count intents: %w", err)
+	}
+	for _, row := range counts {
+		status.Intents[models.IntentStatus(row.Status)] = row.Count
+	}
+
+	status.TotalCommits, err = p.q.CountAllCommits(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count commits: %w", err)
+	}
+
+	intentErrors, err := p.q.FindRecentIntentErrors(ctx, int32(errorLimit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find intent errors: %w", err)
+	}
+	for _, row := range intentErrors {
+		status.RecentErrors = append(status.RecentErrors, models.IntentError{
+			ID:             row.ID,
+			IntentID:       row.IntentID,
+			CreatedAt:      row.CreatedAt.Time,
+			Message:        row.Message,
+			RepositoryName: row.RepositoryName,
+		})
+	}
+
+	return status, nil
+}
+
+func (p *pgStore) SaveSession(ctx context.Context, tokenHash string, session models.Session) (*models.Session, error) {
+	saved, err := p.q.SaveSession(ctx, sqlc.SaveSessionParams{
+		TokenHash: tokenHash,
+		Username:  session.Username,
+		Role:      string(session.Role),
+		ExpiresAt: pgtype.Timestamptz{Time: session.ExpiresAt, Valid: true},
+	})
+	if err != nil {
+		return nil, storeError(err)
+	}
+
+	return &models.Session{
+		Username:  saved.Username,
+		Role:      models.Role(saved.Role),
+		CreatedAt: saved.CreatedAt.Time,
+		ExpiresAt: saved.ExpiresAt.Time,
+	}, nil
+}
+
+func (p *pgStore) FindSession(ctx context.Context, tokenHash string) (*models.Session, error) {
+	session, err := p.q.FindSession(ctx, tokenHash)
+	if err != nil {
+		return nil, storeError(err)
+	}
+
+	return &models.Session{
+		Username:  session.Username,
+		Role:      models.Role(session.Role),
+		CreatedAt: session.CreatedAt.Time,
+		ExpiresAt: session.ExpiresAt.Time,
+	}, nil
+}
+
+func (p *pgStore) DeleteSession(ctx context.Context, tokenHash string) error {
+	return p.q.DeleteSession(ctx, tokenHash)
+}
+
+// DeleteExpiredSessions returns how many sessions it deleted.
+func (p *pgStore) DeleteExpiredSessions(ctx context.Context) (int64, error) {
+	return p.q.DeleteExpiredSessions(ctx)
+}
+
+func (p *pgStore) SaveCredential(ctx context.Context, credential models.Credential) (*models.Credential, error) {
+	saved, err := p.q.SaveCredential(ctx, sqlc.SaveCredentialParams{
+		ID:         credential.ID,
+		Name:       credential.Name,
+		Ciphertext: credential.Ciphertext,
+	})
+	if err != nil {
+		return nil, storeError(err)
+	}
+
+	return toCredential(saved), nil
+}
+
+func (p *pgStore) FindCredential(ctx context.Context, id uuid.UUID) (*models.Credential, error) {
+	credential, err := p.q.FindCredential(ctx, id)
+	if err != nil {
+		return nil, storeError(err)
+	}
+
+	return toCredential(credential), nil
+}
+
+func (p *pgStore) FindCredentials(ctx context.Context) ([]models.Credential, error) {
+	rows, err := p.q.FindCredentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials := make([]models.Credential, 0, len(rows))
+	for _, row := range rows {
+		credentials = append(credentials, *toCredential(row))
+	}
+	return credentials, nil
+}
+
+func toCredential(credential sqlc.Credential) *models.Credential {
+	return &models.Credential{
+		ID:         credential.ID,
+		Name:       credential.Name,
+		Ciphertext: credential.Ciphertext,
+		CreatedAt:  credential.CreatedAt.Time,
+	}
+}
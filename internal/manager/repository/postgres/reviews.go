@@ -0,0 +1,99 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/noelukwa/indexer/internal/manager/models"
+	"github.com/noelukwa/indexer/internal/manager/repository"
+	"github.com/noelukwa/indexer/internal/manager/repository/postgres/sqlc"
+)
+
+// SaveReviews saves the repository's pull request reviews. A review saved
+// before takes its latest state, as dismissing a review changes it.
+func (p *pgStore) SaveReviews(ctx context.Context, repoID int64, reviews []*models.PullRequestReview) error {
+	params := sqlc.SaveReviewsParams{RepositoryID: repoID}
+	for _, review := range reviews {
+		params.Ids = append(params.Ids, review.ID)
+		params.PullNumbers = append(params.PullNumbers, review.PullRequest)
+		params.PullAuthors = append(params.PullAuthors, review.PullRequestAuthor)
+		params.PullCreatedAts = append(params.PullCreatedAts, pgtype.Timestamptz{Time: review.PullRequestCreatedAt, Valid: true})
+		params.Reviewers = append(params.Reviewers, review.Reviewer)
+		params.States = append(params.States, review.State)
+		params.SubmittedAts = append(params.SubmittedAts, pgtype.Timestamptz{Time: review.SubmittedAt, Valid: true})
+	}
+	return p.q.SaveReviews(ctx, params)
+}
+
+// GetReviewTurnaround measures the wait for a first review of the pull
+// requests opened between startDate and endDate, inclusive dates that are
+// open when nil or zero.
+func (p *pgStore) GetReviewTurnaround(ctx context.Context, repoID int64, startDate, endDate *time.Time) (*models.ReviewTurnaround, error) {
+	row, err := p.q.GetReviewTurnaround(ctx, sqlc.GetReviewTurnaroundParams{
+		RepositoryID: repoID,
+		Column2:      dateParam(startDate),
+		Column3:      dateParam(endDate),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &models.ReviewTurnaround{
+		PullRequests:  row.PullRequests,
+		MedianSeconds: row.Median,
+		P90Seconds:    row.P90,
+		MeanSeconds:   row.Mean,
+	}, nil
+}
+
+// GetTopReviewers ranks the reviewers of the repository by the reviews
+// they submitted between startDate and endDate. Reviews of their own pull
+// requests don't count.
+func (p *pgStore) GetTopReviewers(ctx context.Context, repoID int64, startDate, endDate *time.Time, pagination repository.Pagination) (repository.Paginated[models.ReviewerStats], error) {
+	start, end := dateParam(startDate), dateParam(endDate)
+	rows, err := p.q.GetTopReviewers(ctx, sqlc.GetTopReviewersParams{
+		RepositoryID: repoID,
+		Column2:      start,
+		Column3:      end,
+		Limit:        int32(pagination.PerPage),
+		Offset:       int32((pagination.Page - 1) * pagination.PerPage),
+	})
+	if err != nil {
+		return repository.Paginated[models.ReviewerStats]{}, err
+	}
+
+	total, err := p.q.CountReviewers(ctx, sqlc.CountReviewersParams{
+		RepositoryID: repoID,
+		Column2:      start,
+		Column3:      end,
+	})
+	if err != nil {
+		return repository.Paginated[models.ReviewerStats]{}, err
+	}
+
+	stats := make([]models.ReviewerStats, 0, len(rows))
+	for _, row := range rows {
+		stats = append(stats, models.ReviewerStats{
+			Reviewer:         row.Reviewer,
+			Reviews:          row.Reviews,
+			Approvals:        row.Approvals,
+			ChangesRequested: row.ChangesRequested,
+			PullRequests:     row.PullRequests,
+		})
+	}
+
+	return repository.Paginated[models.ReviewerStats]{
+		Data:       stats,
+		TotalCount: total,
+		Page:       pagination.Page,
+		PerPage:    pagination.PerPage,
+	}, nil
+}
+
+// dateParam is a date filter, NULL when it is nil or zero.
+func dateParam(date *time.Time) pgtype.Date {
+	if date == nil || date.IsZero() {
+		return pgtype.Date{}
+	}
+	return pgtype.Date{Time: *date, Valid: true}
+}
@@ -0,0 +1,151 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/noelukwa/indexer/internal/manager/models"
+	"github.com/noelukwa/indexer/internal/manager/repository"
+	"github.com/noelukwa/indexer/internal/manager/repository/postgres/sqlc"
+)
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// Search matches query as a case-insensitive substring of repository
+// names, author names and usernames, and commit messages.
+func (p *pgStore) Search(ctx context.Context, query string, pagination repository.Pagination) (*models.SearchResults, error) {
+	pattern := "%" + likeEscaper.Replace(query) + "%"
+	limit := int32(pagination.PerPage)
+	offset := int32((pagination.Page - 1) * pagination.PerPage)
+	tenant := tenantArg(ctx)
+
+	results := &models.SearchResults{
+		Query:   query,
+		Page:    pagination.Page,
+		PerPage: pagination.PerPage,
+	}
+
+	repos, err := p.q.SearchRepositories(ctx, sqlc.SearchRepositoriesParams{FullName: pattern, TenantID: tenant, Limit: limit, Offset: offset})
+	if err != nil {
+		return nil, err
+	}
+	results.Repositories.Data = make([]models.Repository, 0, len(repos))
+	for _, repo := range repos {
+		results.Repositories.Data = append(results.Repositories.Data, toRepository(repo))
+	}
+	results.Repositories.TotalCount, err = p.q.CountSearchRepositories(ctx, sqlc.CountSearchRepositoriesParams{FullName: pattern, TenantID: tenant})
+	if err != nil {
+		return nil, err
+	}
+
+	authors, err := p.q.SearchAuthors(ctx, sqlc.SearchAuthorsParams{Name: pattern, TenantID: tenant, Limit: limit, Offset: offset})
+	if err != nil {
+		return nil, err
+	}
+	results.Authors.Data = make([]models.Author, 0, len(authors))
+	for _, author := range authors {
+		results.Authors.Data = append(results.Authors.Data, models.Author{
+			ID:       author.ID,
+			Name:     author.Name,
+			Email:    author.Email,
+			Username: author.Username,
+		})
+	}
+	results.Authors.TotalCount, err = p.q.CountSearchAuthors(ctx, sqlc.CountSearchAuthorsParams{Name: pattern, TenantID: tenant})
+	if err != nil {
+		return nil, err
+	}
+
+	commits, err := p.q.SearchCommits(ctx, sqlc.SearchCommitsParams{Message: pattern, TenantID: tenant, Limit: limit, Offset: offset})
+	if err != nil {
+		return nil, err
+	}
+	results.Commits.Data = make([]models.CommitMatch, 0, len(commits))
+	for _, commit := range commits {
+		results.Commits.Data = append(results.Commits.Data, models.CommitMatch{
+			Hash:       commit.Hash,
+			Message:    commit.Message,
+			Url:        commit.Url.String,
+			CreatedAt:  commit.CreatedAt.Time,
+			Repository: commit.Repository,
+			Author: models.Author{
+				ID:       commit.AuthorID,
+				Name:     commit.AuthorName,
+				Email:    commit.AuthorEmail,
+				Username: commit.AuthorUsername,
+			},
+		})
+	}
+	results.Commits.TotalCount, err = p.q.CountSearchCommits(ctx, sqlc.CountSearchCommitsParams{Message: pattern, TenantID: tenant})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// SearchCommitMessages ranks the commits whose message matches a web
+// search style query, such as `"fix leak" -test`, by full-text search.
+func (p *pgStore) SearchCommitMessages(ctx context.Context, filter models.CommitSearchFilter, pagination repository.Pagination) (repository.Paginated[models.CommitMatch], error) {
+	var repo pgtype.Text
+	if filter.RepositoryName != nil {
+		repo = pgtype.Text{String: *filter.RepositoryName, Valid: true}
+	}
+	var since, until pgtype.Timestamptz
+	if filter.StartDate != nil {
+		since = pgtype.Timestamptz{Time: *filter.StartDate, Valid: true}
+	}
+	if filter.EndDate != nil {
+		until = pgtype.Timestamptz{Time: *filter.EndDate, Valid: true}
+	}
+
+	rows, err := p.q.SearchCommitMessages(ctx, sqlc.SearchCommitMessagesParams{
+		Query:      filter.Query,
+		Repository: repo,
+		Since:      since,
+		Until:      until,
+		TenantID:   tenantArg(ctx),
+		Limit:      int32(pagination.PerPage),
+		Offset:     int32((pagination.Page - 1) * pagination.PerPage),
+	})
+	if err != nil {
+		return repository.Paginated[models.CommitMatch]{}, err
+	}
+	matches := make([]models.CommitMatch, 0, len(rows))
+	for _, row := range rows {
+		matches = append(matches, models.CommitMatch{
+			Hash:       row.Hash,
+			Message:    row.Message,
+			Url:        row.Url.String,
+			CreatedAt:  row.CreatedAt.Time,
+			Repository: row.Repository,
+			Author: models.Author{
+				ID:       row.AuthorID,
+				Name:     row.AuthorName,
+				Email:    row.AuthorEmail,
+				Username: row.AuthorUsername,
+			},
+			Rank: row.Rank,
+		})
+	}
+
+	total, err := p.q.CountSearchCommitMessages(ctx, sqlc.CountSearchCommitMessagesParams{
+		Query:      filter.Query,
+		Repository: repo,
+		Since:      since,
+		Until:      until,
+		TenantID:   tenantArg(ctx),
+	})
+	if err != nil {
+		return repository.Paginated[models.CommitMatch]{}, fmt.Errorf("failed to count commit matches: %w", err)
+	}
+
+	return repository.Paginated[models.CommitMatch]{
+		Data:       matches,
+		TotalCount: total,
+		Page:       pagination.Page,
+		PerPage:    pagination.PerPage,
+	}, nil
+}
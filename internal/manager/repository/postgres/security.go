@@ -0,0 +1,56 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/noelukwa/indexer/internal/manager/models"
+	"github.com/noelukwa/indexer/internal/manager/repository/postgres/sqlc"
+)
+
+// SaveSecurityAlerts saves the repository's security alerts. An alert
+// saved before takes the state it has now.
+func (p *pgStore) SaveSecurityAlerts(ctx context.Context, repoID int64, alerts []*models.SecurityAlert) error {
+	params := sqlc.SaveSecurityAlertsParams{RepositoryID: repoID}
+	for _, alert := range alerts {
+		params.Numbers = append(params.Numbers, alert.Number)
+		params.States = append(params.States, alert.State)
+		params.Severities = append(params.Severities, alert.Severity)
+		params.Packages = append(params.Packages, alert.Package)
+		params.Ecosystems = append(params.Ecosystems, alert.Ecosystem)
+		params.ManifestPaths = append(params.ManifestPaths, alert.ManifestPath)
+		params.AdvisoryIds = append(params.AdvisoryIds, alert.AdvisoryID)
+		params.Summaries = append(params.Summaries, alert.Summary)
+		params.Urls = append(params.Urls, alert.Url)
+		params.CreatedAts = append(params.CreatedAts, pgtype.Timestamptz{Time: alert.CreatedAt, Valid: true})
+		params.UpdatedAts = append(params.UpdatedAts, pgtype.Timestamptz{Time: alert.UpdatedAt, Valid: true})
+		params.FixedAts = append(params.FixedAts, toTimestamptz(alert.FixedAt))
+		params.DismissedAts = append(params.DismissedAts, toTimestamptz(alert.DismissedAt))
+	}
+	return p.q.SaveSecurityAlerts(ctx, params)
+}
+
+// GetOpenAlertsSummary counts the open security alerts of each indexed
+// repository that has any, in the tenant ctx is scoped to. Open is left
+// for the caller.
+func (p *pgStore) GetOpenAlertsSummary(ctx context.Context) (*models.SecurityAlertSummary, error) {
+	rows, err := p.q.GetOpenSecurityAlerts(ctx, tenantArg(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &models.SecurityAlertSummary{Repositories: make([]models.RepositorySecurityAlerts, 0, len(rows))}
+	for _, row := range rows {
+		summary.Repositories = append(summary.Repositories, models.RepositorySecurityAlerts{
+			Repository: row.FullName,
+			SecurityAlertCounts: models.SecurityAlertCounts{
+				Critical: row.Critical,
+				High:     row.High,
+				Medium:   row.Medium,
+				Low:      row.Low,
+				Total:    row.Total,
+			},
+		})
+	}
+	return summary, nil
+}
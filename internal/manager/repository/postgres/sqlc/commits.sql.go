@@ -8,9 +8,58 @@ package sqlc
 import (
 	"context"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const addRepoCommits = `-- name: AddRepoCommits :exec
+UPDATE repositories
+SET commit_count = commit_count + $1::bigint,
+    last_commit_at = GREATEST(last_commit_at, $2::timestamptz)
+WHERE id = $3
+`
+
+type AddRepoCommitsParams struct {
+	Commits      int64
+	LastCommitAt pgtype.Timestamptz
+	ID           int64
+}
+
+func (q *Queries) AddRepoCommits(ctx context.Context, arg AddRepoCommitsParams) error {
+	_, err := q.db.Exec(ctx, addRepoCommits, arg.Commits, arg.LastCommitAt, arg.ID)
+	return err
+}
+
+const addRepoTenant = `-- name: AddRepoTenant :exec
+INSERT INTO repository_tenants (tenant_id, repository_id)
+SELECT tenant_id, $1::bigint FROM intents
+WHERE id = $2
+ON CONFLICT DO NOTHING
+`
+
+type AddRepoTenantParams struct {
+	RepositoryID int64
+	IntentID     uuid.UUID
+}
+
+// The tenant of the intent whose run fetched the repository sees it from
+// then on.
+func (q *Queries) AddRepoTenant(ctx context.Context, arg AddRepoTenantParams) error {
+	_, err := q.db.Exec(ctx, addRepoTenant, arg.RepositoryID, arg.IntentID)
+	return err
+}
+
+const countAllCommits = `-- name: CountAllCommits :one
+SELECT COUNT(*) FROM commits
+`
+
+func (q *Queries) CountAllCommits(ctx context.Context) (int64, error) {
+	row := q.db.QueryRow(ctx, countAllCommits)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const countCommits = `-- name: CountCommits :one
 SELECT COUNT(*)
 FROM commits c
@@ -41,6 +90,120 @@ func (q *Queries) CountCommits(ctx context.Context, arg CountCommitsParams) (int
 	return count, err
 }
 
+const countLeaderboard = `-- name: CountLeaderboard :one
+SELECT COUNT(*)
+FROM repository_top_committers t
+JOIN repositories r ON t.repository_id = r.id
+WHERE r.full_name = $1
+`
+
+func (q *Queries) CountLeaderboard(ctx context.Context, fullName string) (int64, error) {
+	row := q.db.QueryRow(ctx, countLeaderboard, fullName)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countTopCommitters = `-- name: CountTopCommitters :one
+SELECT COUNT(DISTINCT c.author_id)
+FROM commits c
+JOIN repositories r ON c.repository_id = r.id
+WHERE r.full_name = $1
+    AND ($2::timestamptz IS NULL OR c.created_at >= $2)
+    AND ($3::timestamptz IS NULL OR c.created_at <= $3)
+`
+
+type CountTopCommittersParams struct {
+	FullName string
+	Column2  pgtype.Timestamptz
+	Column3  pgtype.Timestamptz
+}
+
+func (q *Queries) CountTopCommitters(ctx context.Context, arg CountTopCommittersParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countTopCommitters, arg.FullName, arg.Column2, arg.Column3)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const deleteRepo = `-- name: DeleteRepo :one
+UPDATE repositories SET deleted_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING id, watchers, stargazers, full_name, created_at, updated_at, language, forks, topics, license, default_branch, description, homepage, open_issues, archived, network_id, commit_count, last_commit_at, deleted_at
+`
+
+func (q *Queries) DeleteRepo(ctx context.Context, id int64) (Repository, error) {
+	row := q.db.QueryRow(ctx, deleteRepo, id)
+	var i Repository
+	err := row.Scan(
+		&i.ID,
+		&i.Watchers,
+		&i.Stargazers,
+		&i.FullName,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Language,
+		&i.Forks,
+		&i.Topics,
+		&i.License,
+		&i.DefaultBranch,
+		&i.Description,
+		&i.Homepage,
+		&i.OpenIssues,
+		&i.Archived,
+		&i.NetworkID,
+		&i.CommitCount,
+		&i.LastCommitAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const findCommitComments = `-- name: FindCommitComments :many
+SELECT id, author, body, url, created_at FROM commit_comments
+WHERE repository_id = $1 AND commit_hash = $2
+ORDER BY created_at, id
+`
+
+type FindCommitCommentsParams struct {
+	RepositoryID int64
+	CommitHash   string
+}
+
+type FindCommitCommentsRow struct {
+	ID        int64
+	Author    string
+	Body      string
+	Url       pgtype.Text
+	CreatedAt pgtype.Timestamptz
+}
+
+func (q *Queries) FindCommitComments(ctx context.Context, arg FindCommitCommentsParams) ([]FindCommitCommentsRow, error) {
+	rows, err := q.db.Query(ctx, findCommitComments, arg.RepositoryID, arg.CommitHash)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FindCommitCommentsRow
+	for rows.Next() {
+		var i FindCommitCommentsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Author,
+			&i.Body,
+			&i.Url,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const findCommits = `-- name: FindCommits :many
 SELECT 
     c.hash, c.message, c.url, c.created_at,
@@ -130,6 +293,52 @@ func (q *Queries) FindCommits(ctx context.Context, arg FindCommitsParams) ([]Fin
 	return items, nil
 }
 
+const findDeletedRepos = `-- name: FindDeletedRepos :many
+SELECT id, watchers, stargazers, full_name, created_at, updated_at, language, forks, topics, license, default_branch, description, homepage, open_issues, archived, network_id, commit_count, last_commit_at, deleted_at FROM repositories
+WHERE deleted_at < $1
+ORDER BY deleted_at
+`
+
+func (q *Queries) FindDeletedRepos(ctx context.Context, deletedAt pgtype.Timestamptz) ([]Repository, error) {
+	rows, err := q.db.Query(ctx, findDeletedRepos, deletedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Repository
+	for rows.Next() {
+		var i Repository
+		if err := rows.Scan(
+			&i.ID,
+			&i.Watchers,
+			&i.Stargazers,
+			&i.FullName,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Language,
+			&i.Forks,
+			&i.Topics,
+			&i.License,
+			&i.DefaultBranch,
+			&i.Description,
+			&i.Homepage,
+			&i.OpenIssues,
+			&i.Archived,
+			&i.NetworkID,
+			&i.CommitCount,
+			&i.LastCommitAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getAuthor = `-- name: GetAuthor :one
 SELECT id, name, email, username FROM authors
 WHERE id = $1
@@ -147,9 +356,352 @@ func (q *Queries) GetAuthor(ctx context.Context, id int64) (Author, error) {
 	return i, err
 }
 
+const getCommitActivity = `-- name: GetCommitActivity :many
+WITH buckets AS (
+    SELECT date_trunc($1::text, d.day)::date AS bucket, SUM(d.commits)::bigint AS commits
+    FROM commits_daily d
+    JOIN repositories r ON d.repository_id = r.id
+    WHERE r.full_name = $2
+        AND ($3::date IS NULL OR d.day >= $3::date)
+        AND ($4::date IS NULL OR d.day <= $4::date)
+    GROUP BY 1
+), bounds AS (
+    SELECT date_trunc($1::text, COALESCE($3::date, MIN(bucket)))::date AS first_bucket,
+        date_trunc($1::text, COALESCE($4::date, MAX(bucket)))::date AS last_bucket
+    FROM buckets
+)
+SELECT s.bucket::date AS bucket, COALESCE(buckets.commits, 0)::bigint AS commits
+FROM bounds
+CROSS JOIN generate_series(bounds.first_bucket, bounds.last_bucket, ('1 ' || $1::text)::interval) AS s(bucket)
+LEFT JOIN buckets ON buckets.bucket = s.bucket::date
+ORDER BY s.bucket
+`
+
+type GetCommitActivityParams struct {
+	Bucket    string
+	FullName  string
+	StartDate pgtype.Date
+	EndDate   pgtype.Date
+}
+
+type GetCommitActivityRow struct {
+	Bucket  pgtype.Date
+	Commits int64
+}
+
+func (q *Queries) GetCommitActivity(ctx context.Context, arg GetCommitActivityParams) ([]GetCommitActivityRow, error) {
+	rows, err := q.db.Query(ctx, getCommitActivity,
+		arg.Bucket,
+		arg.FullName,
+		arg.StartDate,
+		arg.EndDate,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetCommitActivityRow
+	for rows.Next() {
+		var i GetCommitActivityRow
+		if err := rows.Scan(&i.Bucket, &i.Commits); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getContributions = `-- name: GetContributions :many
+SELECT a.id, a.name, a.email, a.username,
+    date_trunc('month', d.day)::date AS month,
+    SUM(d.commits)::bigint AS commits
+FROM commits_daily d
+JOIN repositories r ON d.repository_id = r.id
+JOIN authors a ON d.author_id = a.id
+WHERE r.full_name = $1
+    AND ($2::date IS NULL OR d.day >= $2)
+    AND ($3::date IS NULL OR d.day <= $3)
+GROUP BY a.id, a.name, a.email, a.username, month
+ORDER BY a.id, month
+`
+
+type GetContributionsParams struct {
+	FullName string
+	Column2  pgtype.Date
+	Column3  pgtype.Date
+}
+
+type GetContributionsRow struct {
+	ID       int64
+	Name     string
+	Email    string
+	Username string
+	Month    pgtype.Date
+	Commits  int64
+}
+
+func (q *Queries) GetContributions(ctx context.Context, arg GetContributionsParams) ([]GetContributionsRow, error) {
+	rows, err := q.db.Query(ctx, getContributions, arg.FullName, arg.Column2, arg.Column3)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetContributionsRow
+	for rows.Next() {
+		var i GetContributionsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Email,
+			&i.Username,
+			&i.Month,
+			&i.Commits,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDailyCommits = `-- name: GetDailyCommits :many
+WITH days AS (
+    SELECT d.day, SUM(d.commits)::bigint AS commits,
+        SUM(d.additions)::bigint AS additions, SUM(d.deletions)::bigint AS deletions
+    FROM commits_daily d
+    JOIN repositories r ON d.repository_id = r.id
+    WHERE r.full_name = $1
+    GROUP BY d.day
+), bounds AS (
+    SELECT COALESCE($2::date, MIN(day)) AS first_day, COALESCE($3::date, MAX(day)) AS last_day
+    FROM days
+)
+SELECT s.day::date AS day,
+    COALESCE(days.commits, 0)::bigint AS commits,
+    COALESCE(days.additions, 0)::bigint AS additions,
+    COALESCE(days.deletions, 0)::bigint AS deletions
+FROM bounds
+CROSS JOIN generate_series(bounds.first_day, bounds.last_day, interval '1 day') AS s(day)
+LEFT JOIN days ON days.day = s.day::date
+ORDER BY s.day
+`
+
+type GetDailyCommitsParams struct {
+	FullName string
+	Column2  pgtype.Date
+	Column3  pgtype.Date
+}
+
+type GetDailyCommitsRow struct {
+	Day       pgtype.Date
+	Commits   int64
+	Additions int64
+	Deletions int64
+}
+
+func (q *Queries) GetDailyCommits(ctx context.Context, arg GetDailyCommitsParams) ([]GetDailyCommitsRow, error) {
+	rows, err := q.db.Query(ctx, getDailyCommits, arg.FullName, arg.Column2, arg.Column3)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetDailyCommitsRow
+	for rows.Next() {
+		var i GetDailyCommitsRow
+		if err := rows.Scan(
+			&i.Day,
+			&i.Commits,
+			&i.Additions,
+			&i.Deletions,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDedupedDailyCommits = `-- name: GetDedupedDailyCommits :many
+WITH days AS (
+    SELECT (c.created_at AT TIME ZONE 'UTC')::date AS day, COUNT(*) AS commits,
+        COALESCE(SUM(c.additions), 0)::bigint AS additions, COALESCE(SUM(c.deletions), 0)::bigint AS deletions
+    FROM commits c
+    JOIN repositories r ON c.repository_id = r.id
+    WHERE r.full_name = $1
+    AND NOT EXISTS (
+        SELECT 1 FROM commits s
+        JOIN repositories u ON s.repository_id = u.id
+        WHERE s.hash = c.hash
+            AND COALESCE(u.network_id, u.id) = COALESCE(r.network_id, r.id)
+            AND (u.created_at, u.id) < (r.created_at, r.id)
+    )
+    GROUP BY 1
+), bounds AS (
+    SELECT COALESCE($2::date, MIN(day)) AS first_day, COALESCE($3::date, MAX(day)) AS last_day
+    FROM days
+)
+SELECT s.day::date AS day,
+    COALESCE(days.commits, 0)::bigint AS commits,
+    COALESCE(days.additions, 0)::bigint AS additions,
+    COALESCE(days.deletions, 0)::bigint AS deletions
+FROM bounds
+CROSS JOIN generate_series(bounds.first_day, bounds.last_day, interval '1 day') AS s(day)
+LEFT JOIN days ON days.day = s.day::date
+ORDER BY s.day
+`
+
+type GetDedupedDailyCommitsParams struct {
+	FullName string
+	Column2  pgtype.Date
+	Column3  pgtype.Date
+}
+
+type GetDedupedDailyCommitsRow struct {
+	Day       pgtype.Date
+	Commits   int64
+	Additions int64
+	Deletions int64
+}
+
+func (q *Queries) GetDedupedDailyCommits(ctx context.Context, arg GetDedupedDailyCommitsParams) ([]GetDedupedDailyCommitsRow, error) {
+	rows, err := q.db.Query(ctx, getDedupedDailyCommits, arg.FullName, arg.Column2, arg.Column3)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetDedupedDailyCommitsRow
+	for rows.Next() {
+		var i GetDedupedDailyCommitsRow
+		if err := rows.Scan(
+			&i.Day,
+			&i.Commits,
+			&i.Additions,
+			&i.Deletions,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDedupedRepoStats = `-- name: GetDedupedRepoStats :one
+SELECT
+    COUNT(*) AS commits,
+    COALESCE(SUM(c.additions), 0)::bigint AS additions,
+    COALESCE(SUM(c.deletions), 0)::bigint AS deletions,
+    COUNT(DISTINCT c.author_id) AS authors,
+    COUNT(DISTINCT (c.created_at AT TIME ZONE 'UTC')::date) AS active_days
+FROM commits c
+JOIN repositories r ON c.repository_id = r.id
+WHERE r.full_name = $1
+    AND ($2::date IS NULL OR (c.created_at AT TIME ZONE 'UTC')::date >= $2)
+    AND ($3::date IS NULL OR (c.created_at AT TIME ZONE 'UTC')::date <= $3)
+    AND NOT EXISTS (
+        SELECT 1 FROM commits s
+        JOIN repositories u ON s.repository_id = u.id
+        WHERE s.hash = c.hash
+            AND COALESCE(u.network_id, u.id) = COALESCE(r.network_id, r.id)
+            AND (u.created_at, u.id) < (r.created_at, r.id)
+    )
+`
+
+type GetDedupedRepoStatsParams struct {
+	FullName string
+	Column2  pgtype.Date
+	Column3  pgtype.Date
+}
+
+type GetDedupedRepoStatsRow struct {
+	Commits    int64
+	Additions  int64
+	Deletions  int64
+	Authors    int64
+	ActiveDays int64
+}
+
+func (q *Queries) GetDedupedRepoStats(ctx context.Context, arg GetDedupedRepoStatsParams) (GetDedupedRepoStatsRow, error) {
+	row := q.db.QueryRow(ctx, getDedupedRepoStats, arg.FullName, arg.Column2, arg.Column3)
+	var i GetDedupedRepoStatsRow
+	err := row.Scan(
+		&i.Commits,
+		&i.Additions,
+		&i.Deletions,
+		&i.Authors,
+		&i.ActiveDays,
+	)
+	return i, err
+}
+
+const getLeaderboard = `-- name: GetLeaderboard :many
+SELECT a.id, a.name, a.email, a.username, t.commit_count
+FROM repository_top_committers t
+JOIN repositories r ON t.repository_id = r.id
+JOIN authors a ON t.author_id = a.id
+WHERE r.full_name = $1
+ORDER BY t.commit_count DESC, a.id
+LIMIT $2 OFFSET $3
+`
+
+type GetLeaderboardParams struct {
+	FullName string
+	Limit    int32
+	Offset   int32
+}
+
+type GetLeaderboardRow struct {
+	ID          int64
+	Name        string
+	Email       string
+	Username    string
+	CommitCount int64
+}
+
+func (q *Queries) GetLeaderboard(ctx context.Context, arg GetLeaderboardParams) ([]GetLeaderboardRow, error) {
+	rows, err := q.db.Query(ctx, getLeaderboard, arg.FullName, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetLeaderboardRow
+	for rows.Next() {
+		var i GetLeaderboardRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Email,
+			&i.Username,
+			&i.CommitCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getRepo = `-- name: GetRepo :one
-SELECT id, watchers, stargazers, full_name, created_at, updated_at, language, forks FROM repositories
-WHERE full_name = $1
+SELECT id, watchers, stargazers, full_name, created_at, updated_at, language, forks, topics, license, default_branch, description, homepage, open_issues, archived, network_id, commit_count, last_commit_at, deleted_at FROM repositories
+WHERE (full_name = $1
+    OR id = (SELECT repository_id FROM repository_aliases WHERE name = $1))
+    AND deleted_at IS NULL
+ORDER BY full_name = $1 DESC
+LIMIT 1
 `
 
 func (q *Queries) GetRepo(ctx context.Context, fullName string) (Repository, error) {
@@ -164,6 +716,58 @@ func (q *Queries) GetRepo(ctx context.Context, fullName string) (Repository, err
 		&i.UpdatedAt,
 		&i.Language,
 		&i.Forks,
+		&i.Topics,
+		&i.License,
+		&i.DefaultBranch,
+		&i.Description,
+		&i.Homepage,
+		&i.OpenIssues,
+		&i.Archived,
+		&i.NetworkID,
+		&i.CommitCount,
+		&i.LastCommitAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const getRepoStats = `-- name: GetRepoStats :one
+SELECT
+    COALESCE(SUM(d.commits), 0)::bigint AS commits,
+    COALESCE(SUM(d.additions), 0)::bigint AS additions,
+    COALESCE(SUM(d.deletions), 0)::bigint AS deletions,
+    COUNT(DISTINCT d.author_id) AS authors,
+    COUNT(DISTINCT d.day) AS active_days
+FROM commits_daily d
+JOIN repositories r ON d.repository_id = r.id
+WHERE r.full_name = $1
+    AND ($2::date IS NULL OR d.day >= $2)
+    AND ($3::date IS NULL OR d.day <= $3)
+`
+
+type GetRepoStatsParams struct {
+	FullName string
+	Column2  pgtype.Date
+	Column3  pgtype.Date
+}
+
+type GetRepoStatsRow struct {
+	Commits    int64
+	Additions  int64
+	Deletions  int64
+	Authors    int64
+	ActiveDays int64
+}
+
+func (q *Queries) GetRepoStats(ctx context.Context, arg GetRepoStatsParams) (GetRepoStatsRow, error) {
+	row := q.db.QueryRow(ctx, getRepoStats, arg.FullName, arg.Column2, arg.Column3)
+	var i GetRepoStatsRow
+	err := row.Scan(
+		&i.Commits,
+		&i.Additions,
+		&i.Deletions,
+		&i.Authors,
+		&i.ActiveDays,
 	)
 	return i, err
 }
@@ -177,7 +781,7 @@ WHERE r.full_name = $1
     AND ($2::timestamptz IS NULL OR c.created_at >= $2)
     AND ($3::timestamptz IS NULL OR c.created_at <= $3)
 GROUP BY a.id, a.name, a.email, a.username
-ORDER BY commit_count DESC
+ORDER BY commit_count DESC, a.id
 LIMIT $4 OFFSET $5
 `
 
@@ -229,6 +833,218 @@ func (q *Queries) GetTopCommitters(ctx context.Context, arg GetTopCommittersPara
 	return items, nil
 }
 
+const lockRepo = `-- name: LockRepo :one
+SELECT commit_count FROM repositories
+WHERE id = $1
+FOR NO KEY UPDATE
+`
+
+// Saving commits and swapping in a reindex lock the repository first, so
+// they take turns.
+func (q *Queries) LockRepo(ctx context.Context, id int64) (int64, error) {
+	row := q.db.QueryRow(ctx, lockRepo, id)
+	var commit_count int64
+	err := row.Scan(&commit_count)
+	return commit_count, err
+}
+
+const notifyCommits = `-- name: NotifyCommits :exec
+SELECT pg_notify('commits', json_build_object(
+    'repository', r.full_name,
+    'commits', $1::int,
+    'at', CURRENT_TIMESTAMP
+)::text)
+FROM repositories r
+WHERE r.id = $2
+`
+
+type NotifyCommitsParams struct {
+	Commits      int32
+	RepositoryID int64
+}
+
+func (q *Queries) NotifyCommits(ctx context.Context, arg NotifyCommitsParams) error {
+	_, err := q.db.Exec(ctx, notifyCommits, arg.Commits, arg.RepositoryID)
+	return err
+}
+
+const purgeRepo = `-- name: PurgeRepo :one
+WITH repo AS (
+    DELETE FROM repositories WHERE id = $1 AND deleted_at IS NOT NULL
+    RETURNING id
+), comments AS (
+    DELETE FROM commit_comments WHERE repository_id IN (SELECT id FROM repo)
+), daily AS (
+    DELETE FROM commits_daily WHERE repository_id IN (SELECT id FROM repo)
+), reviews AS (
+    DELETE FROM pull_request_reviews WHERE repository_id IN (SELECT id FROM repo)
+), runs AS (
+    DELETE FROM workflow_runs WHERE repository_id IN (SELECT id FROM repo)
+), github AS (
+    DELETE FROM repository_github_stats WHERE repository_id IN (SELECT id FROM repo)
+), alerts AS (
+    DELETE FROM security_alerts WHERE repository_id IN (SELECT id FROM repo)
+), purged AS (
+    DELETE FROM commits WHERE repository_id IN (SELECT id FROM repo)
+    RETURNING 1
+)
+SELECT COUNT(*) FROM purged
+`
+
+// Deletes a deleted repository for good, with everything indexed of it,
+// and counts its commits. Its aliases, reindexes and archives cascade.
+func (q *Queries) PurgeRepo(ctx context.Context, id int64) (int64, error) {
+	row := q.db.QueryRow(ctx, purgeRepo, id)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const purgeRepoCommits = `-- name: PurgeRepoCommits :execrows
+WITH repo AS (
+    SELECT id FROM repositories WHERE full_name = $1
+), comments AS (
+    DELETE FROM commit_comments WHERE repository_id IN (SELECT id FROM repo)
+), daily AS (
+    DELETE FROM commits_daily WHERE repository_id IN (SELECT id FROM repo)
+), archives AS (
+    DELETE FROM commit_archives WHERE repository_id IN (SELECT id FROM repo)
+), archived AS (
+    DELETE FROM repository_archives WHERE repository_id IN (SELECT id FROM repo)
+), counts AS (
+    UPDATE repositories SET commit_count = 0, last_commit_at = NULL
+    WHERE id IN (SELECT id FROM repo)
+)
+DELETE FROM commits WHERE repository_id IN (SELECT id FROM repo)
+`
+
+func (q *Queries) PurgeRepoCommits(ctx context.Context, fullName string) (int64, error) {
+	result, err := q.db.Exec(ctx, purgeRepoCommits, fullName)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const refreshTopCommitters = `-- name: RefreshTopCommitters :exec
+REFRESH MATERIALIZED VIEW CONCURRENTLY repository_top_committers
+`
+
+func (q *Queries) RefreshTopCommitters(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, refreshTopCommitters)
+	return err
+}
+
+const renameRepo = `-- name: RenameRepo :one
+WITH previous AS (
+    SELECT id, full_name FROM repositories
+    WHERE id = $1 AND full_name <> $2::text
+    FOR UPDATE
+), renamed AS (
+    UPDATE repositories r SET full_name = $2::text
+    FROM previous
+    WHERE r.id = previous.id
+), aliased AS (
+    INSERT INTO repository_aliases (name, repository_id)
+    SELECT full_name, id FROM previous
+    ON CONFLICT (name) DO UPDATE SET repository_id = EXCLUDED.repository_id
+), unaliased AS (
+    DELETE FROM repository_aliases
+    WHERE name = $2::text
+), moved AS (
+    UPDATE intents SET repository_name = $2::text
+    WHERE repository_name IN (SELECT full_name FROM previous)
+        AND NOT (is_active AND EXISTS (
+            SELECT 1 FROM intents i
+            WHERE i.repository_name = $2::text AND i.is_active
+        ))
+)
+SELECT full_name FROM previous
+`
+
+type RenameRepoParams struct {
+	ID       int64
+	FullName string
+}
+
+func (q *Queries) RenameRepo(ctx context.Context, arg RenameRepoParams) (string, error) {
+	row := q.db.QueryRow(ctx, renameRepo, arg.ID, arg.FullName)
+	var full_name string
+	err := row.Scan(&full_name)
+	return full_name, err
+}
+
+const repoInTenant = `-- name: RepoInTenant :one
+SELECT EXISTS (
+    SELECT 1 FROM repository_tenants
+    WHERE repository_id = $1 AND tenant_id = $2
+)
+`
+
+type RepoInTenantParams struct {
+	RepositoryID int64
+	TenantID     string
+}
+
+func (q *Queries) RepoInTenant(ctx context.Context, arg RepoInTenantParams) (bool, error) {
+	row := q.db.QueryRow(ctx, repoInTenant, arg.RepositoryID, arg.TenantID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const rollupCommits = `-- name: RollupCommits :many
+WITH batch AS (
+    UPDATE commits SET rolled_up = TRUE
+    WHERE (repository_id, hash) IN (
+        SELECT repository_id, hash FROM commits
+        WHERE NOT rolled_up
+        LIMIT $1
+        FOR UPDATE SKIP LOCKED
+    )
+    RETURNING repository_id, author_id, created_at, additions, deletions
+), rolled AS (
+    INSERT INTO commits_daily (repository_id, author_id, day, commits, additions, deletions)
+    SELECT repository_id, author_id, (created_at AT TIME ZONE 'UTC')::date,
+        COUNT(*), COALESCE(SUM(additions), 0), COALESCE(SUM(deletions), 0)
+    FROM batch
+    GROUP BY repository_id, author_id, (created_at AT TIME ZONE 'UTC')::date
+    ON CONFLICT (repository_id, day, author_id) DO UPDATE SET
+        commits = commits_daily.commits + EXCLUDED.commits,
+        additions = commits_daily.additions + EXCLUDED.additions,
+        deletions = commits_daily.deletions + EXCLUDED.deletions
+)
+SELECT r.full_name, COUNT(*)::bigint AS commits
+FROM batch b
+JOIN repositories r ON r.id = b.repository_id
+GROUP BY r.full_name
+`
+
+type RollupCommitsRow struct {
+	FullName string
+	Commits  int64
+}
+
+func (q *Queries) RollupCommits(ctx context.Context, limit int32) ([]RollupCommitsRow, error) {
+	rows, err := q.db.Query(ctx, rollupCommits, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RollupCommitsRow
+	for rows.Next() {
+		var i RollupCommitsRow
+		if err := rows.Scan(&i.FullName, &i.Commits); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const saveAuthor = `-- name: SaveAuthor :one
 INSERT INTO authors (id, name, email, username)
 VALUES ($1, $2, $3, $4)
@@ -263,10 +1079,45 @@ func (q *Queries) SaveAuthor(ctx context.Context, arg SaveAuthorParams) (Author,
 	return i, err
 }
 
-const saveCommit = `-- name: SaveCommit :exec
-INSERT INTO commits (hash, author_id, message, url, created_at, repository_id)
-VALUES ($1, $2, $3, $4, $5, $6)
-ON CONFLICT (hash) DO NOTHING
+const saveAuthors = `-- name: SaveAuthors :exec
+INSERT INTO authors (id, name, email, username)
+SELECT id, name, email, username FROM unnest(
+    $1::bigint[],
+    $2::text[],
+    $3::text[],
+    $4::text[]
+) AS a (id, name, email, username)
+ON CONFLICT (id) DO NOTHING
+`
+
+type SaveAuthorsParams struct {
+	Ids       []int64
+	Names     []string
+	Emails    []string
+	Usernames []string
+}
+
+func (q *Queries) SaveAuthors(ctx context.Context, arg SaveAuthorsParams) error {
+	_, err := q.db.Exec(ctx, saveAuthors,
+		arg.Ids,
+		arg.Names,
+		arg.Emails,
+		arg.Usernames,
+	)
+	return err
+}
+
+const saveCommit = `-- name: SaveCommit :execrows
+INSERT INTO commits (hash, author_id, message, url, created_at, repository_id, additions, deletions, changes, tags)
+SELECT $1, $2, $3, $4, $5::timestamptz, $6::bigint, $7, $8, $9, $10
+FROM repositories r
+WHERE r.id = $6
+    AND r.deleted_at IS NULL
+    AND NOT EXISTS (
+        SELECT 1 FROM repository_archives a
+        WHERE a.repository_id = $6 AND $5 < a.archived_before
+    )
+ON CONFLICT (repository_id, hash) DO NOTHING
 `
 
 type SaveCommitParams struct {
@@ -276,25 +1127,76 @@ type SaveCommitParams struct {
 	Url          pgtype.Text
 	CreatedAt    pgtype.Timestamptz
 	RepositoryID int64
+	Additions    pgtype.Int4
+	Deletions    pgtype.Int4
+	Changes      pgtype.Int4
+	Tags         []string
 }
 
-func (q *Queries) SaveCommit(ctx context.Context, arg SaveCommitParams) error {
-	_, err := q.db.Exec(ctx, saveCommit,
+// Commits older than the repository's archive are in it already.
+func (q *Queries) SaveCommit(ctx context.Context, arg SaveCommitParams) (int64, error) {
+	result, err := q.db.Exec(ctx, saveCommit,
 		arg.Hash,
 		arg.AuthorID,
 		arg.Message,
 		arg.Url,
 		arg.CreatedAt,
 		arg.RepositoryID,
+		arg.Additions,
+		arg.Deletions,
+		arg.Changes,
+		arg.Tags,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const saveCommitComments = `-- name: SaveCommitComments :exec
+INSERT INTO commit_comments (id, repository_id, commit_hash, author, body, url, created_at)
+SELECT c.id, $1::bigint, c.commit_hash, c.author, c.body, NULLIF(c.url, ''), c.created_at
+FROM unnest(
+    $2::bigint[],
+    $3::text[],
+    $4::text[],
+    $5::text[],
+    $6::text[],
+    $7::timestamptz[]
+) AS c (id, commit_hash, author, body, url, created_at)
+ON CONFLICT (repository_id, id) DO UPDATE SET body = EXCLUDED.body
+`
+
+type SaveCommitCommentsParams struct {
+	RepositoryID int64
+	Ids          []int64
+	CommitHashes []string
+	Authors      []string
+	Bodies       []string
+	Urls         []string
+	CreatedAts   []pgtype.Timestamptz
+}
+
+func (q *Queries) SaveCommitComments(ctx context.Context, arg SaveCommitCommentsParams) error {
+	_, err := q.db.Exec(ctx, saveCommitComments,
+		arg.RepositoryID,
+		arg.Ids,
+		arg.CommitHashes,
+		arg.Authors,
+		arg.Bodies,
+		arg.Urls,
+		arg.CreatedAts,
 	)
 	return err
 }
 
 const saveManyCommits = `-- name: SaveManyCommits :many
 INSERT INTO commits (hash, author_id, message, url, created_at, repository_id)
-VALUES ($1, $2, $3, $4, $5, $6)
-ON CONFLICT (hash) DO NOTHING
-RETURNING hash, author_id, message, url, created_at, repository_id
+SELECT $1, $2, $3, $4, $5, $6::bigint
+FROM repositories r
+WHERE r.id = $6 AND r.deleted_at IS NULL
+ON CONFLICT (repository_id, hash) DO NOTHING
+RETURNING hash, author_id, message, url, created_at, repository_id, additions, deletions, changes, rolled_up, tags, message_tsv
 `
 
 type SaveManyCommitsParams struct {
@@ -329,6 +1231,12 @@ func (q *Queries) SaveManyCommits(ctx context.Context, arg SaveManyCommitsParams
 			&i.Url,
 			&i.CreatedAt,
 			&i.RepositoryID,
+			&i.Additions,
+			&i.Deletions,
+			&i.Changes,
+			&i.RolledUp,
+			&i.Tags,
+			&i.MessageTsv,
 		); err != nil {
 			return nil, err
 		}
@@ -341,27 +1249,48 @@ func (q *Queries) SaveManyCommits(ctx context.Context, arg SaveManyCommitsParams
 }
 
 const saveRepo = `-- name: SaveRepo :exec
-INSERT INTO repositories (id, watchers, stargazers, full_name, created_at, updated_at, language, forks)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+INSERT INTO repositories (
+    id, watchers, stargazers, full_name, created_at, updated_at, language, forks, topics, license, default_branch,
+    description, homepage, open_issues, archived, network_id
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
 ON CONFLICT (full_name) DO UPDATE SET
     watchers = EXCLUDED.watchers,
     stargazers = EXCLUDED.stargazers,
     updated_at = EXCLUDED.updated_at,
     language = EXCLUDED.language,
-    forks = EXCLUDED.forks
+    forks = EXCLUDED.forks,
+    topics = EXCLUDED.topics,
+    license = EXCLUDED.license,
+    default_branch = EXCLUDED.default_branch,
+    description = EXCLUDED.description,
+    homepage = EXCLUDED.homepage,
+    open_issues = EXCLUDED.open_issues,
+    archived = EXCLUDED.archived,
+    network_id = EXCLUDED.network_id
+WHERE repositories.deleted_at IS NULL
 `
 
 type SaveRepoParams struct {
-	ID         int64
-	Watchers   int32
-	Stargazers int32
-	FullName   string
-	CreatedAt  pgtype.Timestamptz
-	UpdatedAt  pgtype.Timestamptz
-	Language   pgtype.Text
-	Forks      int32
+	ID            int64
+	Watchers      int32
+	Stargazers    int32
+	FullName      string
+	CreatedAt     pgtype.Timestamptz
+	UpdatedAt     pgtype.Timestamptz
+	Language      pgtype.Text
+	Forks         int32
+	Topics        []string
+	License       pgtype.Text
+	DefaultBranch pgtype.Text
+	Description   pgtype.Text
+	Homepage      pgtype.Text
+	OpenIssues    int32
+	Archived      bool
+	NetworkID     pgtype.Int8
 }
 
+// A deleted repository is left as it was until it is purged.
 func (q *Queries) SaveRepo(ctx context.Context, arg SaveRepoParams) error {
 	_, err := q.db.Exec(ctx, saveRepo,
 		arg.ID,
@@ -372,6 +1301,14 @@ func (q *Queries) SaveRepo(ctx context.Context, arg SaveRepoParams) error {
 		arg.UpdatedAt,
 		arg.Language,
 		arg.Forks,
+		arg.Topics,
+		arg.License,
+		arg.DefaultBranch,
+		arg.Description,
+		arg.Homepage,
+		arg.OpenIssues,
+		arg.Archived,
+		arg.NetworkID,
 	)
 	return err
 }
@@ -12,6 +12,61 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const claimForcedBroadcast = `-- name: ClaimForcedBroadcast :one
+INSERT INTO intent_forced_broadcasts (intent_id, forced_at)
+VALUES ($1, CURRENT_TIMESTAMP)
+ON CONFLICT (intent_id) DO UPDATE SET forced_at = EXCLUDED.forced_at
+WHERE intent_forced_broadcasts.forced_at <= CURRENT_TIMESTAMP - $2::bigint * INTERVAL '1 millisecond'
+RETURNING forced_at
+`
+
+type ClaimForcedBroadcastParams struct {
+	IntentID   uuid.UUID
+	CooldownMs int64
+}
+
+func (q *Queries) ClaimForcedBroadcast(ctx context.Context, arg ClaimForcedBroadcastParams) (pgtype.Timestamptz, error) {
+	row := q.db.QueryRow(ctx, claimForcedBroadcast, arg.IntentID, arg.CooldownMs)
+	var forced_at pgtype.Timestamptz
+	err := row.Scan(&forced_at)
+	return forced_at, err
+}
+
+const claimIntentCommands = `-- name: ClaimIntentCommands :many
+SELECT id, queue, command
+FROM intent_outbox
+WHERE next_attempt_at <= CURRENT_TIMESTAMP
+ORDER BY id
+LIMIT $1
+FOR UPDATE SKIP LOCKED
+`
+
+type ClaimIntentCommandsRow struct {
+	ID      int64
+	Queue   pgtype.Text
+	Command []byte
+}
+
+func (q *Queries) ClaimIntentCommands(ctx context.Context, limit int32) ([]ClaimIntentCommandsRow, error) {
+	rows, err := q.db.Query(ctx, claimIntentCommands, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ClaimIntentCommandsRow
+	for rows.Next() {
+		var i ClaimIntentCommandsRow
+		if err := rows.Scan(&i.ID, &i.Queue, &i.Command); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const countIntents = `-- name: CountIntents :one
 SELECT COUNT(*)
 FROM intents
@@ -19,6 +74,7 @@ WHERE
     (NOT $1 OR status = $2::intent_status)
     AND (NOT $3 OR is_active = $4)
     AND (NOT $5 OR repository_name = $6)
+    AND deleted_at IS NULL
 `
 
 type CountIntentsParams struct {
@@ -44,13 +100,169 @@ func (q *Queries) CountIntents(ctx context.Context, arg CountIntentsParams) (int
 	return count, err
 }
 
+const countIntentsByStatus = `-- name: CountIntentsByStatus :many
+SELECT status, COUNT(*) AS count
+FROM intents
+WHERE deleted_at IS NULL
+GROUP BY status
+`
+
+type CountIntentsByStatusRow struct {
+	Status IntentStatus
+	Count  int64
+}
+
+func (q *Queries) CountIntentsByStatus(ctx context.Context) ([]CountIntentsByStatusRow, error) {
+	rows, err := q.db.Query(ctx, countIntentsByStatus)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountIntentsByStatusRow
+	for rows.Next() {
+		var i CountIntentsByStatusRow
+		if err := rows.Scan(
+			&i.Status,
+			&i.Count,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteIntent = `-- name: DeleteIntent :one
+UPDATE intents
+SET deleted_at = CURRENT_TIMESTAMP, is_active = FALSE, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING id, repository_name, start_date, status, is_active, created_at, updated_at,
+    max_concurrent_pages, requests_per_minute, index_all_branches, path_filters,
+    author_filters, max_commits, end_date, credential_id,
+    sync_started_at, last_synced_at, synced_commits,
+    retry_max_attempts, retry_backoff_base_ms, retry_jitter, deleted_at, parent_id, paused_at, tenant_id,
+    branch, excluded_authors
+`
+
+func (q *Queries) DeleteIntent(ctx context.Context, id uuid.UUID) (Intent, error) {
+	row := q.db.QueryRow(ctx, deleteIntent, id)
+	var i Intent
+	err := row.Scan(
+		&i.ID,
+		&i.RepositoryName,
+		&i.StartDate,
+		&i.Status,
+		&i.IsActive,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.MaxConcurrentPages,
+		&i.RequestsPerMinute,
+		&i.IndexAllBranches,
+		&i.PathFilters,
+		&i.AuthorFilters,
+		&i.MaxCommits,
+		&i.EndDate,
+		&i.CredentialID,
+		&i.SyncStartedAt,
+		&i.LastSyncedAt,
+		&i.SyncedCommits,
+		&i.RetryMaxAttempts,
+		&i.RetryBackoffBaseMs,
+		&i.RetryJitter,
+		&i.DeletedAt,
+		&i.ParentID,
+		&i.PausedAt,
+		&i.TenantID,
+		&i.Branch,
+		&i.ExcludedAuthors,
+	)
+	return i, err
+}
+
+const deleteIntentCommand = `-- name: DeleteIntentCommand :exec
+DELETE FROM intent_outbox WHERE id = $1
+`
+
+func (q *Queries) DeleteIntentCommand(ctx context.Context, id int64) error {
+	_, err := q.db.Exec(ctx, deleteIntentCommand, id)
+	return err
+}
+
+const enqueueIntentCommand = `-- name: EnqueueIntentCommand :exec
+INSERT INTO intent_outbox (intent_id, queue, command)
+VALUES ($1, $2, $3)
+`
+
+type EnqueueIntentCommandParams struct {
+	IntentID uuid.UUID
+	Queue    pgtype.Text
+	Command  []byte
+}
+
+func (q *Queries) EnqueueIntentCommand(ctx context.Context, arg EnqueueIntentCommandParams) error {
+	_, err := q.db.Exec(ctx, enqueueIntentCommand, arg.IntentID, arg.Queue, arg.Command)
+	return err
+}
+
+const failIntentCommand = `-- name: FailIntentCommand :exec
+UPDATE intent_outbox
+SET attempts = attempts + 1,
+    last_error = $2,
+    next_attempt_at = CURRENT_TIMESTAMP + LEAST(INTERVAL '1 second' * POWER(2, attempts), INTERVAL '5 minutes')
+WHERE id = $1
+`
+
+type FailIntentCommandParams struct {
+	ID        int64
+	LastError pgtype.Text
+}
+
+func (q *Queries) FailIntentCommand(ctx context.Context, arg FailIntentCommandParams) error {
+	_, err := q.db.Exec(ctx, failIntentCommand, arg.ID, arg.LastError)
+	return err
+}
+
+const findChildIntentRepos = `-- name: FindChildIntentRepos :many
+SELECT repository_name
+FROM intents
+WHERE parent_id = $1
+`
+
+func (q *Queries) FindChildIntentRepos(ctx context.Context, parentID pgtype.UUID) ([]string, error) {
+	rows, err := q.db.Query(ctx, findChildIntentRepos, parentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var repository_name string
+		if err := rows.Scan(&repository_name); err != nil {
+			return nil, err
+		}
+		items = append(items, repository_name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const findIntent = `-- name: FindIntent :one
 SELECT 
-    id, repository_name, start_date, status, is_active, created_at, updated_at
+    id, repository_name, start_date, status, is_active, created_at, updated_at,
+    max_concurrent_pages, requests_per_minute, index_all_branches, path_filters,
+    author_filters, max_commits, end_date, credential_id,
+    sync_started_at, last_synced_at, synced_commits,
+    retry_max_attempts, retry_backoff_base_ms, retry_jitter, deleted_at, parent_id, paused_at, tenant_id,
+    branch, excluded_authors
 FROM 
     intents
 WHERE 
-    id = $1
+    id = $1 AND deleted_at IS NULL
 `
 
 // FindIntent.sql
@@ -65,19 +277,78 @@ func (q *Queries) FindIntent(ctx context.Context, id uuid.UUID) (Intent, error)
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.MaxConcurrentPages,
+		&i.RequestsPerMinute,
+		&i.IndexAllBranches,
+		&i.PathFilters,
+		&i.AuthorFilters,
+		&i.MaxCommits,
+		&i.EndDate,
+		&i.CredentialID,
+		&i.SyncStartedAt,
+		&i.LastSyncedAt,
+		&i.SyncedCommits,
+		&i.RetryMaxAttempts,
+		&i.RetryBackoffBaseMs,
+		&i.RetryJitter,
+		&i.DeletedAt,
+		&i.ParentID,
+		&i.PausedAt,
+		&i.TenantID,
+		&i.Branch,
+		&i.ExcludedAuthors,
 	)
 	return i, err
 }
 
+const findIntentTransitions = `-- name: FindIntentTransitions :many
+SELECT id, intent_id, from_status, to_status, created_at
+FROM intent_status_history
+WHERE intent_id = $1
+ORDER BY id DESC
+`
+
+func (q *Queries) FindIntentTransitions(ctx context.Context, intentID uuid.UUID) ([]IntentStatusHistory, error) {
+	rows, err := q.db.Query(ctx, findIntentTransitions, intentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []IntentStatusHistory
+	for rows.Next() {
+		var i IntentStatusHistory
+		if err := rows.Scan(
+			&i.ID,
+			&i.IntentID,
+			&i.FromStatus,
+			&i.ToStatus,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const findIntents = `-- name: FindIntents :many
 SELECT 
-    id, repository_name, start_date, status, is_active, created_at, updated_at
+    id, repository_name, start_date, status, is_active, created_at, updated_at,
+    max_concurrent_pages, requests_per_minute, index_all_branches, path_filters,
+    author_filters, max_commits, end_date, credential_id,
+    sync_started_at, last_synced_at, synced_commits,
+    retry_max_attempts, retry_backoff_base_ms, retry_jitter, deleted_at, parent_id, paused_at, tenant_id,
+    branch, excluded_authors
 FROM 
     intents
 WHERE 
     (NOT $3 OR status = $4::intent_status)
     AND (NOT $5 OR is_active = $6)
     AND (NOT $7 OR repository_name = $8)
+    AND deleted_at IS NULL
 ORDER BY created_at DESC
 LIMIT $1 OFFSET $2
 `
@@ -119,6 +390,26 @@ func (q *Queries) FindIntents(ctx context.Context, arg FindIntentsParams) ([]Int
 			&i.IsActive,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.MaxConcurrentPages,
+			&i.RequestsPerMinute,
+			&i.IndexAllBranches,
+			&i.PathFilters,
+			&i.AuthorFilters,
+			&i.MaxCommits,
+			&i.EndDate,
+			&i.CredentialID,
+			&i.SyncStartedAt,
+			&i.LastSyncedAt,
+			&i.SyncedCommits,
+			&i.RetryMaxAttempts,
+			&i.RetryBackoffBaseMs,
+			&i.RetryJitter,
+			&i.DeletedAt,
+			&i.ParentID,
+			&i.PausedAt,
+			&i.TenantID,
+			&i.Branch,
+			&i.ExcludedAuthors,
 		); err != nil {
 			return nil, err
 		}
@@ -130,20 +421,129 @@ func (q *Queries) FindIntents(ctx context.Context, arg FindIntentsParams) ([]Int
 	return items, nil
 }
 
+const findRecentIntentErrors = `-- name: FindRecentIntentErrors :many
+SELECT
+    e.id, e.intent_id, e.created_at, e.message, i.repository_name
+FROM
+    intent_errors e
+JOIN intents i ON i.id = e.intent_id
+ORDER BY e.created_at DESC
+LIMIT $1
+`
+
+type FindRecentIntentErrorsRow struct {
+	ID             uuid.UUID
+	IntentID       uuid.UUID
+	CreatedAt      pgtype.Timestamptz
+	Message        string
+	RepositoryName string
+}
+
+func (q *Queries) FindRecentIntentErrors(ctx context.Context, limit int32) ([]FindRecentIntentErrorsRow, error) {
+	rows, err := q.db.Query(ctx, findRecentIntentErrors, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FindRecentIntentErrorsRow
+	for rows.Next() {
+		var i FindRecentIntentErrorsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.IntentID,
+			&i.CreatedAt,
+			&i.Message,
+			&i.RepositoryName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getIntentProgress = `-- name: GetIntentProgress :one
+SELECT intent_id, commits, pages, last_commit_date, started_at, updated_at FROM intent_progress
+WHERE intent_id = $1
+`
+
+func (q *Queries) GetIntentProgress(ctx context.Context, intentID uuid.UUID) (IntentProgress, error) {
+	row := q.db.QueryRow(ctx, getIntentProgress, intentID)
+	var i IntentProgress
+	err := row.Scan(
+		&i.IntentID,
+		&i.Commits,
+		&i.Pages,
+		&i.LastCommitDate,
+		&i.StartedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const notifyIntentEvent = `-- name: NotifyIntentEvent :exec
+SELECT pg_notify('intent_events', $1::text)
+`
+
+func (q *Queries) NotifyIntentEvent(ctx context.Context, event string) error {
+	_, err := q.db.Exec(ctx, notifyIntentEvent, event)
+	return err
+}
+
+const releaseForcedBroadcast = `-- name: ReleaseForcedBroadcast :exec
+DELETE FROM intent_forced_broadcasts WHERE intent_id = $1 AND forced_at = $2
+`
+
+type ReleaseForcedBroadcastParams struct {
+	IntentID uuid.UUID
+	ForcedAt pgtype.Timestamptz
+}
+
+func (q *Queries) ReleaseForcedBroadcast(ctx context.Context, arg ReleaseForcedBroadcastParams) error {
+	_, err := q.db.Exec(ctx, releaseForcedBroadcast, arg.IntentID, arg.ForcedAt)
+	return err
+}
+
 const saveIntent = `-- name: SaveIntent :one
 INSERT INTO intents (
-    id, repository_name, start_date, status, is_active
+    id, repository_name, start_date, status, is_active, max_concurrent_pages, requests_per_minute, index_all_branches,
+    path_filters, author_filters, max_commits, end_date, credential_id,
+    retry_max_attempts, retry_backoff_base_ms, retry_jitter, parent_id, tenant_id,
+    branch, excluded_authors
 ) VALUES (
-    $1, $2, $3, $4, $5
-) RETURNING id, repository_name, start_date, status, is_active, created_at, updated_at
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20
+) RETURNING id, repository_name, start_date, status, is_active, created_at, updated_at,
+    max_concurrent_pages, requests_per_minute, index_all_branches, path_filters,
+    author_filters, max_commits, end_date, credential_id,
+    sync_started_at, last_synced_at, synced_commits,
+    retry_max_attempts, retry_backoff_base_ms, retry_jitter, deleted_at, parent_id, paused_at, tenant_id,
+    branch, excluded_authors
 `
 
 type SaveIntentParams struct {
-	ID             uuid.UUID
-	RepositoryName string
-	StartDate      pgtype.Timestamptz
-	Status         IntentStatus
-	IsActive       bool
+	ID                 uuid.UUID
+	RepositoryName     string
+	StartDate          pgtype.Timestamptz
+	Status             IntentStatus
+	IsActive           bool
+	MaxConcurrentPages pgtype.Int4
+	RequestsPerMinute  pgtype.Int4
+	IndexAllBranches   bool
+	PathFilters        []string
+	AuthorFilters      []string
+	MaxCommits         pgtype.Int4
+	EndDate            pgtype.Timestamptz
+	CredentialID       pgtype.UUID
+	RetryMaxAttempts   pgtype.Int4
+	RetryBackoffBaseMs pgtype.Int4
+	RetryJitter        pgtype.Float8
+	ParentID           pgtype.UUID
+	TenantID           string
+	Branch             pgtype.Text
+	ExcludedAuthors    []string
 }
 
 // SaveIntent.sql
@@ -154,6 +554,21 @@ func (q *Queries) SaveIntent(ctx context.Context, arg SaveIntentParams) (Intent,
 		arg.StartDate,
 		arg.Status,
 		arg.IsActive,
+		arg.MaxConcurrentPages,
+		arg.RequestsPerMinute,
+		arg.IndexAllBranches,
+		arg.PathFilters,
+		arg.AuthorFilters,
+		arg.MaxCommits,
+		arg.EndDate,
+		arg.CredentialID,
+		arg.RetryMaxAttempts,
+		arg.RetryBackoffBaseMs,
+		arg.RetryJitter,
+		arg.ParentID,
+		arg.TenantID,
+		arg.Branch,
+		arg.ExcludedAuthors,
 	)
 	var i Intent
 	err := row.Scan(
@@ -164,6 +579,26 @@ func (q *Queries) SaveIntent(ctx context.Context, arg SaveIntentParams) (Intent,
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.MaxConcurrentPages,
+		&i.RequestsPerMinute,
+		&i.IndexAllBranches,
+		&i.PathFilters,
+		&i.AuthorFilters,
+		&i.MaxCommits,
+		&i.EndDate,
+		&i.CredentialID,
+		&i.SyncStartedAt,
+		&i.LastSyncedAt,
+		&i.SyncedCommits,
+		&i.RetryMaxAttempts,
+		&i.RetryBackoffBaseMs,
+		&i.RetryJitter,
+		&i.DeletedAt,
+		&i.ParentID,
+		&i.PausedAt,
+		&i.TenantID,
+		&i.Branch,
+		&i.ExcludedAuthors,
 	)
 	return i, err
 }
@@ -194,31 +629,133 @@ func (q *Queries) SaveIntentError(ctx context.Context, arg SaveIntentErrorParams
 	return err
 }
 
+const saveIntentProgress = `-- name: SaveIntentProgress :exec
+INSERT INTO intent_progress (
+    intent_id, commits, pages, last_commit_date, started_at, updated_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+)
+ON CONFLICT (intent_id) DO UPDATE SET
+    commits = EXCLUDED.commits,
+    pages = EXCLUDED.pages,
+    last_commit_date = EXCLUDED.last_commit_date,
+    started_at = EXCLUDED.started_at,
+    updated_at = EXCLUDED.updated_at
+WHERE intent_progress.updated_at <= EXCLUDED.updated_at
+`
+
+type SaveIntentProgressParams struct {
+	IntentID       uuid.UUID
+	Commits        int64
+	Pages          int64
+	LastCommitDate pgtype.Timestamptz
+	StartedAt      pgtype.Timestamptz
+	UpdatedAt      pgtype.Timestamptz
+}
+
+func (q *Queries) SaveIntentProgress(ctx context.Context, arg SaveIntentProgressParams) error {
+	_, err := q.db.Exec(ctx, saveIntentProgress,
+		arg.IntentID,
+		arg.Commits,
+		arg.Pages,
+		arg.LastCommitDate,
+		arg.StartedAt,
+		arg.UpdatedAt,
+	)
+	return err
+}
+
+const saveIntentTransition = `-- name: SaveIntentTransition :exec
+INSERT INTO intent_status_history (intent_id, from_status, to_status, created_at)
+VALUES ($1, $2, $3, $4)
+`
+
+type SaveIntentTransitionParams struct {
+	IntentID   uuid.UUID
+	FromStatus NullIntentStatus
+	ToStatus   IntentStatus
+	CreatedAt  pgtype.Timestamptz
+}
+
+func (q *Queries) SaveIntentTransition(ctx context.Context, arg SaveIntentTransitionParams) error {
+	_, err := q.db.Exec(ctx, saveIntentTransition,
+		arg.IntentID,
+		arg.FromStatus,
+		arg.ToStatus,
+		arg.CreatedAt,
+	)
+	return err
+}
+
+const trimIntentTransitions = `-- name: TrimIntentTransitions :exec
+DELETE FROM intent_status_history
+WHERE intent_id = $1 AND id NOT IN (
+    SELECT id FROM intent_status_history
+    WHERE intent_id = $1
+    ORDER BY id DESC
+    LIMIT $2
+)
+`
+
+type TrimIntentTransitionsParams struct {
+	IntentID uuid.UUID
+	Limit    int32
+}
+
+func (q *Queries) TrimIntentTransitions(ctx context.Context, arg TrimIntentTransitionsParams) error {
+	_, err := q.db.Exec(ctx, trimIntentTransitions, arg.IntentID, arg.Limit)
+	return err
+}
+
 const updateIntent = `-- name: UpdateIntent :one
 UPDATE intents
-SET 
-    status = COALESCE($2, status),
-    is_active = COALESCE($3, is_active),
-    start_date = COALESCE($4, start_date),
+SET
+    status = COALESCE($1, status),
+    is_active = COALESCE($2, is_active),
+    start_date = CASE WHEN $3::boolean THEN NULL ELSE COALESCE($4, start_date) END,
+    end_date = CASE WHEN $5::boolean THEN NULL ELSE COALESCE($6, end_date) END,
+    sync_started_at = COALESCE($7, sync_started_at),
+    last_synced_at = COALESCE($8, last_synced_at),
+    synced_commits = COALESCE($9, synced_commits),
+    paused_at = CASE WHEN $2::boolean THEN NULL ELSE COALESCE($10, paused_at) END,
     updated_at = CURRENT_TIMESTAMP
-WHERE id = $1
-RETURNING id, repository_name, start_date, status, is_active, created_at, updated_at
+WHERE id = $11 AND deleted_at IS NULL
+RETURNING id, repository_name, start_date, status, is_active, created_at, updated_at,
+    max_concurrent_pages, requests_per_minute, index_all_branches, path_filters,
+    author_filters, max_commits, end_date, credential_id,
+    sync_started_at, last_synced_at, synced_commits,
+    retry_max_attempts, retry_backoff_base_ms, retry_jitter, deleted_at, parent_id, paused_at, tenant_id,
+    branch, excluded_authors
 `
 
 type UpdateIntentParams struct {
-	ID        uuid.UUID
-	Status    IntentStatus
-	IsActive  bool
-	StartDate pgtype.Timestamptz
+	Status        NullIntentStatus
+	IsActive      pgtype.Bool
+	FullHistory   bool
+	StartDate     pgtype.Timestamptz
+	OpenEnded     bool
+	EndDate       pgtype.Timestamptz
+	SyncStartedAt pgtype.Timestamptz
+	LastSyncedAt  pgtype.Timestamptz
+	SyncedCommits pgtype.Int8
+	PausedAt      pgtype.Timestamptz
+	ID            uuid.UUID
 }
 
 // UpdateIntent.sql
 func (q *Queries) UpdateIntent(ctx context.Context, arg UpdateIntentParams) (Intent, error) {
 	row := q.db.QueryRow(ctx, updateIntent,
-		arg.ID,
 		arg.Status,
 		arg.IsActive,
+		arg.FullHistory,
 		arg.StartDate,
+		arg.OpenEnded,
+		arg.EndDate,
+		arg.SyncStartedAt,
+		arg.LastSyncedAt,
+		arg.SyncedCommits,
+		arg.PausedAt,
+		arg.ID,
 	)
 	var i Intent
 	err := row.Scan(
@@ -229,6 +766,26 @@ func (q *Queries) UpdateIntent(ctx context.Context, arg UpdateIntentParams) (Int
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.MaxConcurrentPages,
+		&i.RequestsPerMinute,
+		&i.IndexAllBranches,
+		&i.PathFilters,
+		&i.AuthorFilters,
+		&i.MaxCommits,
+		&i.EndDate,
+		&i.CredentialID,
+		&i.SyncStartedAt,
+		&i.LastSyncedAt,
+		&i.SyncedCommits,
+		&i.RetryMaxAttempts,
+		&i.RetryBackoffBaseMs,
+		&i.RetryJitter,
+		&i.DeletedAt,
+		&i.ParentID,
+		&i.PausedAt,
+		&i.TenantID,
+		&i.Branch,
+		&i.ExcludedAuthors,
 	)
 	return i, err
 }
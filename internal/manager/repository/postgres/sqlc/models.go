@@ -15,8 +15,14 @@ import (
 type IntentStatus string
 
 const (
+	IntentStatusCreated          IntentStatus = "created"
 	IntentStatusPendingBroadcast IntentStatus = "pending_broadcast"
-	IntentStatusSuccessBroadcast IntentStatus = "success_broadcast"
+	IntentStatusBroadcast        IntentStatus = "broadcast"
+	IntentStatusFetching         IntentStatus = "fetching"
+	IntentStatusIngesting        IntentStatus = "ingesting"
+	IntentStatusCompleted        IntentStatus = "completed"
+	IntentStatusFailed           IntentStatus = "failed"
+	IntentStatusPaused           IntentStatus = "paused"
 )
 
 func (e *IntentStatus) Scan(src interface{}) error {
@@ -54,6 +60,18 @@ func (ns NullIntentStatus) Value() (driver.Value, error) {
 	return string(ns.IntentStatus), nil
 }
 
+type ApiKey struct {
+	ID         uuid.UUID
+	Name       string
+	KeyHash    string
+	Prefix     string
+	Role       string
+	CreatedAt  pgtype.Timestamptz
+	LastUsedAt pgtype.Timestamptz
+	RevokedAt  pgtype.Timestamptz
+	TenantID   pgtype.Text
+}
+
 type Author struct {
 	ID       int64
 	Name     string
@@ -68,16 +86,102 @@ type Commit struct {
 	Url          pgtype.Text
 	CreatedAt    pgtype.Timestamptz
 	RepositoryID int64
+	Additions    pgtype.Int4
+	Deletions    pgtype.Int4
+	Changes      pgtype.Int4
+	RolledUp     bool
+	Tags         []string
+	MessageTsv   interface{}
+}
+
+type CommitArchive struct {
+	ID            uuid.UUID
+	RepositoryID  int64
+	ObjectKey     string
+	Commits       int32
+	FirstCommitAt pgtype.Timestamptz
+	LastCommitAt  pgtype.Timestamptz
+	SizeBytes     int64
+	CreatedAt     pgtype.Timestamptz
+}
+
+type CommitComment struct {
+	ID           int64
+	RepositoryID int64
+	CommitHash   string
+	Author       string
+	Body         string
+	Url          pgtype.Text
+	CreatedAt    pgtype.Timestamptz
+}
+
+type CommitsDaily struct {
+	RepositoryID int64
+	AuthorID     int64
+	Day          pgtype.Date
+	Commits      int32
+	Additions    int64
+	Deletions    int64
+}
+
+type CommitsShadow struct {
+	ReindexID    uuid.UUID
+	Hash         string
+	AuthorID     int64
+	Message      string
+	Url          pgtype.Text
+	CreatedAt    pgtype.Timestamptz
+	RepositoryID int64
+	Additions    pgtype.Int4
+	Deletions    pgtype.Int4
+	Changes      pgtype.Int4
+	Tags         []string
+}
+
+type Credential struct {
+	ID         uuid.UUID
+	Name       string
+	Ciphertext []byte
+	CreatedAt  pgtype.Timestamptz
+}
+
+type DigestSubscription struct {
+	ID         uuid.UUID
+	Email      string
+	Repository string
+	Label      string
+	LastWeek   pgtype.Date
+	CreatedAt  pgtype.Timestamptz
 }
 
 type Intent struct {
-	ID             uuid.UUID
-	RepositoryName string
-	StartDate      pgtype.Timestamptz
-	Status         IntentStatus
-	IsActive       bool
-	CreatedAt      pgtype.Timestamptz
-	UpdatedAt      pgtype.Timestamptz
+	ID                 uuid.UUID
+	RepositoryName     string
+	StartDate          pgtype.Timestamptz
+	Status             IntentStatus
+	IsActive           bool
+	CreatedAt          pgtype.Timestamptz
+	UpdatedAt          pgtype.Timestamptz
+	MaxConcurrentPages pgtype.Int4
+	RequestsPerMinute  pgtype.Int4
+	IndexAllBranches   bool
+	PathFilters        []string
+	AuthorFilters      []string
+	MaxCommits         pgtype.Int4
+	EndDate            pgtype.Timestamptz
+	CredentialID       pgtype.UUID
+	SyncStartedAt      pgtype.Timestamptz
+	LastSyncedAt       pgtype.Timestamptz
+	SyncedCommits      int64
+	RetryMaxAttempts   pgtype.Int4
+	RetryBackoffBaseMs pgtype.Int4
+	RetryJitter        pgtype.Float8
+	DeletedAt          pgtype.Timestamptz
+	ParentID           pgtype.UUID
+	PausedAt           pgtype.Timestamptz
+	TenantID           string
+	Branch             pgtype.Text
+	ExcludedAuthors    []string
 }
 
 type IntentError struct {
@@ -87,13 +191,140 @@ type IntentError struct {
 	Message   string
 }
 
-type Repository struct {
+type IntentForcedBroadcast struct {
+	IntentID uuid.UUID
+	ForcedAt pgtype.Timestamptz
+}
+
+type IntentOutbox struct {
+	ID            int64
+	IntentID      uuid.UUID
+	Command       []byte
+	Attempts      int32
+	LastError     pgtype.Text
+	CreatedAt     pgtype.Timestamptz
+	Queue         pgtype.Text
+	NextAttemptAt pgtype.Timestamptz
+}
+
+type IntentProgress struct {
+	IntentID       uuid.UUID
+	Commits        int64
+	Pages          int64
+	LastCommitDate pgtype.Timestamptz
+	StartedAt      pgtype.Timestamptz
+	UpdatedAt      pgtype.Timestamptz
+}
+
+type IntentStatusHistory struct {
 	ID         int64
-	Watchers   int32
-	Stargazers int32
-	FullName   string
+	IntentID   uuid.UUID
+	FromStatus NullIntentStatus
+	ToStatus   IntentStatus
 	CreatedAt  pgtype.Timestamptz
-	UpdatedAt  pgtype.Timestamptz
-	Language   pgtype.Text
-	Forks      int32
+}
+
+type PullRequestReview struct {
+	ID            int64
+	RepositoryID  int64
+	PullNumber    int32
+	PullAuthor    string
+	PullCreatedAt pgtype.Timestamptz
+	Reviewer      string
+	State         string
+	SubmittedAt   pgtype.Timestamptz
+}
+
+type Reindex struct {
+	ID              uuid.UUID
+	RepositoryID    int64
+	IntentID        uuid.UUID
+	Status          string
+	ExpectedCommits pgtype.Int8
+	Error           pgtype.Text
+	CreatedAt       pgtype.Timestamptz
+	FinishedAt      pgtype.Timestamptz
+}
+
+type Repository struct {
+	ID            int64
+	Watchers      int32
+	Stargazers    int32
+	FullName      string
+	CreatedAt     pgtype.Timestamptz
+	UpdatedAt     pgtype.Timestamptz
+	Language      pgtype.Text
+	Forks         int32
+	Topics        []string
+	License       pgtype.Text
+	DefaultBranch pgtype.Text
+	Description   pgtype.Text
+	Homepage      pgtype.Text
+	OpenIssues    int32
+	Archived      bool
+	NetworkID     pgtype.Int8
+	CommitCount   int64
+	LastCommitAt  pgtype.Timestamptz
+	DeletedAt     pgtype.Timestamptz
+}
+
+type RepositoryAlias struct {
+	Name         string
+	RepositoryID int64
+}
+
+type RepositoryArchive struct {
+	RepositoryID   int64
+	ArchivedBefore pgtype.Timestamptz
+	LeasedUntil    pgtype.Timestamptz
+}
+
+type RepositoryGithubStat struct {
+	RepositoryID  int64
+	CodeFrequency []byte
+	Participation []byte
+	PunchCard     []byte
+	FetchedAt     pgtype.Timestamptz
+}
+
+type RepositoryTenant struct {
+	TenantID     string
+	RepositoryID int64
+}
+
+type SecurityAlert struct {
+	RepositoryID int64
+	Number       int32
+	State        string
+	Severity     string
+	Package      string
+	Ecosystem    string
+	ManifestPath string
+	AdvisoryID   string
+	Summary      string
+	Url          pgtype.Text
+	CreatedAt    pgtype.Timestamptz
+	UpdatedAt    pgtype.Timestamptz
+	FixedAt      pgtype.Timestamptz
+	DismissedAt  pgtype.Timestamptz
+}
+
+type Session struct {
+	TokenHash string
+	Username  string
+	Role      string
+	CreatedAt pgtype.Timestamptz
+	ExpiresAt pgtype.Timestamptz
+}
+
+type WorkflowRun struct {
+	ID           int64
+	RepositoryID int64
+	Workflow     string
+	HeadSha      string
+	Branch       string
+	Event        string
+	Conclusion   string
+	StartedAt    pgtype.Timestamptz
+	CompletedAt  pgtype.Timestamptz
 }
@@ -0,0 +1,78 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/noelukwa/indexer/internal/manager/models"
+	"github.com/noelukwa/indexer/internal/manager/repository"
+	"github.com/noelukwa/indexer/internal/manager/repository/postgres/sqlc"
+)
+
+// GetLanguageStats totals the repositories and their indexed commits per
+// language, most commits first.
+func (p *pgStore) GetLanguageStats(ctx context.Context) ([]models.LanguageStats, error) {
+	rows, err := p.q.GetLanguageStats(ctx, tenantArg(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]models.LanguageStats, 0, len(rows))
+	for _, row := range rows {
+		stats = append(stats, models.LanguageStats{
+			Language:     row.Language,
+			Repositories: row.Repositories,
+			Commits:      row.Commits,
+		})
+	}
+	return stats, nil
+}
+
+// GetActiveRepos ranks the repositories by their commits in the daily
+// rollup over the filter's days. Repositories without commits in them are
+// left out.
+func (p *pgStore) GetActiveRepos(ctx context.Context, filter models.RepoActivityFilter, pagination repository.Pagination) (repository.Paginated[models.RepoActivity], error) {
+	params := sqlc.GetActiveReposParams{
+		StartDate: pgtype.Date{Time: filter.StartDate, Valid: true},
+		EndDate:   pgtype.Date{Time: filter.EndDate, Valid: true},
+		TenantID:  tenantArg(ctx),
+		Limit:     int32(pagination.PerPage),
+		Offset:    int32((pagination.Page - 1) * pagination.PerPage),
+	}
+	if filter.Language != nil {
+		params.Language = pgtype.Text{String: *filter.Language, Valid: true}
+	}
+
+	total, err := p.q.CountActiveRepos(ctx, sqlc.CountActiveReposParams{
+		StartDate: params.StartDate,
+		EndDate:   params.EndDate,
+		Language:  params.Language,
+		TenantID:  params.TenantID,
+	})
+	if err != nil {
+		return repository.Paginated[models.RepoActivity]{}, err
+	}
+
+	rows, err := p.q.GetActiveRepos(ctx, params)
+	if err != nil {
+		return repository.Paginated[models.RepoActivity]{}, err
+	}
+
+	repos := make([]models.RepoActivity, 0, len(rows))
+	for _, row := range rows {
+		repos = append(repos, models.RepoActivity{
+			Repository: row.FullName,
+			Language:   row.Language,
+			Commits:    row.Commits,
+			Additions:  row.Additions,
+			Deletions:  row.Deletions,
+			Authors:    row.Authors,
+		})
+	}
+	return repository.Paginated[models.RepoActivity]{
+		Data:       repos,
+		TotalCount: total,
+		Page:       pagination.Page,
+		PerPage:    pagination.PerPage,
+	}, nil
+}
@@ -0,0 +1,37 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/noelukwa/indexer/internal/manager/repository"
+)
+
+// tenantArg is the tenant ctx is scoped to as a query argument, NULL when
+// it is not scoped.
+func tenantArg(ctx context.Context) pgtype.Text {
+	tenant, ok := repository.TenantFrom(ctx)
+	return pgtype.Text{String: tenant, Valid: ok}
+}
+
+// inTenant reports whether a record of tenant is seen with ctx.
+func inTenant(ctx context.Context, tenant string) bool {
+	scope, ok := repository.TenantFrom(ctx)
+	return !ok || scope == tenant
+}
+
+// repoInTenant is the condition that the repository whose ID is in column
+// is tracked by tenant.
+func repoInTenant(column, tenant string) squirrel.Sqlizer {
+	return squirrel.Expr("EXISTS (SELECT 1 FROM repository_tenants t WHERE t.repository_id = "+column+" AND t.tenant_id = ?)", tenant)
+}
+
+// newTenant is the tenant a new intent made with ctx belongs to, unless
+// it names its own.
+func newTenant(ctx context.Context) string {
+	if tenant, ok := repository.TenantFrom(ctx); ok {
+		return tenant
+	}
+	return repository.DefaultTenant
+}
@@ -0,0 +1,46 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/noelukwa/indexer/internal/manager/models"
+	"github.com/noelukwa/indexer/internal/manager/repository/postgres/sqlc"
+)
+
+// SaveWorkflowRuns saves the repository's workflow runs. A rerun attempt
+// replaces the outcome saved for its run.
+func (p *pgStore) SaveWorkflowRuns(ctx context.Context, repoID int64, runs []*models.WorkflowRun) error {
+	params := sqlc.SaveWorkflowRunsParams{RepositoryID: repoID}
+	for _, run := range runs {
+		params.Ids = append(params.Ids, run.ID)
+		params.Workflows = append(params.Workflows, run.Workflow)
+		params.HeadShas = append(params.HeadShas, run.HeadSHA)
+		params.Branches = append(params.Branches, run.Branch)
+		params.Events = append(params.Events, run.Event)
+		params.Conclusions = append(params.Conclusions, run.Conclusion)
+		params.StartedAts = append(params.StartedAts, pgtype.Timestamptz{Time: run.StartedAt, Valid: true})
+		params.CompletedAts = append(params.CompletedAts, pgtype.Timestamptz{Time: run.CompletedAt, Valid: true})
+	}
+	return p.q.SaveWorkflowRuns(ctx, params)
+}
+
+// GetCIStats summarizes the workflow runs matching filter. PassRate is
+// left for the caller.
+func (p *pgStore) GetCIStats(ctx context.Context, filter models.CIFilter) (*models.CIStats, error) {
+	row, err := p.q.GetCIStats(ctx, sqlc.GetCIStatsParams{
+		RepositoryID: filter.RepositoryID,
+		Column2:      dateParam(&filter.StartDate),
+		Column3:      dateParam(&filter.EndDate),
+		Column4:      filter.Workflow,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &models.CIStats{
+		Runs:          row.Runs,
+		Passed:        row.Passed,
+		MedianSeconds: row.Median,
+		P90Seconds:    row.P90,
+	}, nil
+}
@@ -2,12 +2,24 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/noelukwa/indexer/internal/manager/models"
 )
 
+var (
+	// ErrNotFound is returned when the record looked up does not exist.
+	ErrNotFound = errors.New("record not found")
+	// ErrConflict is returned when a write conflicts with an existing
+	// record.
+	ErrConflict = errors.New("record already exists")
+	// ErrConstraint is returned when a write breaks another constraint,
+	// such as referring to a record that does not exist.
+	ErrConstraint = errors.New("record violates a constraint")
+)
+
 type Paginated[T any] struct {
 	Data       []T
 	TotalCount int64
@@ -20,16 +32,107 @@ type Pagination struct {
 	PerPage int
 }
 
+// CommitsListener is implemented by stores that announce persisted commits
+// to every replica sharing them. ListenCommits calls notify for each batch
+// until ctx is done or the connection fails.
+type CommitsListener interface {
+	ListenCommits(ctx context.Context, notify func(models.CommitsNotice)) error
+}
+
+// IntentEventsBus is implemented by stores that carry intent events to
+// every replica sharing them. ListenIntentEvents calls notify for each
+// event announced with NotifyIntentEvent, by any replica, until ctx is done
+// or the connection fails.
+type IntentEventsBus interface {
+	NotifyIntentEvent(ctx context.Context, event models.IntentEvent) error
+	ListenIntentEvents(ctx context.Context, notify func(models.IntentEvent)) error
+}
+
 type ManagerStore interface {
 	SaveIntent(ctx context.Context, freshIntent models.Intent) (intent *models.Intent, err error)
+	SaveQueuedIntent(ctx context.Context, freshIntent models.Intent, command []byte) (intent *models.Intent, err error)
 	UpdateIntent(ctx context.Context, update models.IntentUpdate) (intent *models.Intent, err error)
 	SaveIntentError(ctx context.Context, err models.IntentError) error
+	SaveIntentProgress(ctx context.Context, intentID uuid.UUID, progress models.IntentProgress) error
+	GetIntentProgress(ctx context.Context, intentID uuid.UUID) (*models.IntentProgress, error)
 	FindIntents(ctx context.Context, filter models.IntentFilter, pag Pagination) (Paginated[models.Intent], error)
+	FindRepos(ctx context.Context, filter models.RepositoryFilter, pag Pagination) (Paginated[models.Repository], error)
 	FindIntent(ctx context.Context, id uuid.UUID) (*models.Intent, error)
+	DeleteIntent(ctx context.Context, id uuid.UUID) (*models.Intent, error)
+	SaveIntentTransition(ctx context.Context, transition models.IntentTransition, keep int) error
+	FindIntentTransitions(ctx context.Context, intentID uuid.UUID) ([]models.IntentTransition, error)
+	FindChildIntentRepos(ctx context.Context, parentID uuid.UUID) ([]string, error)
+	EnqueueIntentCommand(ctx context.Context, intentID uuid.UUID, queue string, command []byte) error
+	DispatchIntentCommands(ctx context.Context, limit int, publish func(queue string, command []byte) error) (int, error)
+	ClaimForcedBroadcast(ctx context.Context, intentID uuid.UUID, cooldown time.Duration) (forcedAt time.Time, ok bool, err error)
+	ReleaseForcedBroadcast(ctx context.Context, intentID uuid.UUID, forcedAt time.Time) error
 	SaveRepo(ctx context.Context, repo *models.Repository) error
+	AddRepoTenant(ctx context.Context, repoID int64, intentID uuid.UUID) error
 	GetRepo(ctx context.Context, name string) (*models.Repository, error)
+	RenameRepo(ctx context.Context, id int64, name string) (string, error)
+	DeleteRepo(ctx context.Context, id int64) (*models.Repository, error)
+	FindDeletedRepos(ctx context.Context, before time.Time) ([]models.Repository, error)
+	PurgeRepo(ctx context.Context, id int64) (int64, error)
+	Search(ctx context.Context, query string, pagination Pagination) (*models.SearchResults, error)
+	SearchCommitMessages(ctx context.Context, filter models.CommitSearchFilter, pagination Pagination) (Paginated[models.CommitMatch], error)
+	GetAuthorProfile(ctx context.Context, username string) (*models.AuthorProfile, error)
+	GetLanguageStats(ctx context.Context) ([]models.LanguageStats, error)
+	GetActiveRepos(ctx context.Context, filter models.RepoActivityFilter, pagination Pagination) (Paginated[models.RepoActivity], error)
 	FindCommits(ctx context.Context, filter models.CommitsFilter, pag Pagination) (Paginated[models.Commit], error)
+	FindCommitCursor(ctx context.Context, repoID int64, hash string) (*models.CommitCursor, error)
+	FindCommitsAfter(ctx context.Context, repoID int64, after models.CommitCursor, limit int) ([]models.Commit, error)
 	GetTopCommitters(ctx context.Context, repository string, startDate, endDate *time.Time, pagination Pagination) (Paginated[models.AuthorStats], error)
 	SaveManyCommit(ctx context.Context, repoID int64, commit []*models.Commit) error
+	FindCommitComments(ctx context.Context, repoID int64, hash string) ([]models.CommitComment, error)
+	GetChurn(ctx context.Context, filter models.CommitsFilter) (*models.Churn, error)
+	GetDailyCommits(ctx context.Context, filter models.CommitsFilter) ([]models.DailyCommits, error)
+	GetCommitActivity(ctx context.Context, filter models.CommitsFilter, interval models.ActivityInterval) ([]models.ActivityBucket, error)
+	GetContributions(ctx context.Context, filter models.CommitsFilter) ([]models.MonthlyContribution, error)
+	RefreshLeaderboards(ctx context.Context) error
+	RollupCommits(ctx context.Context, limit int) (map[string]int64, error)
+	PurgeRepoCommits(ctx context.Context, repo string) (int64, error)
+	GetRepoStats(ctx context.Context, filter models.CommitsFilter) (*models.RepoStats, error)
+	SaveReviews(ctx context.Context, repoID int64, reviews []*models.PullRequestReview) error
+	GetReviewTurnaround(ctx context.Context, repoID int64, startDate, endDate *time.Time) (*models.ReviewTurnaround, error)
+	GetTopReviewers(ctx context.Context, repoID int64, startDate, endDate *time.Time, pagination Pagination) (Paginated[models.ReviewerStats], error)
+	SaveWorkflowRuns(ctx context.Context, repoID int64, runs []*models.WorkflowRun) error
+	GetCIStats(ctx context.Context, filter models.CIFilter) (*models.CIStats, error)
+	GetQuality(ctx context.Context, repoID int64) (*models.QualityReport, error)
+	GetCoverageGaps(ctx context.Context, repoID int64, startDate, endDate time.Time, minDays int) ([]models.CoverageGap, error)
+	SaveGitHubStats(ctx context.Context, repoID int64, stats *models.GitHubStats) error
+	GetGitHubStats(ctx context.Context, repoID int64) (*models.GitHubStats, error)
+	SaveSecurityAlerts(ctx context.Context, repoID int64, alerts []*models.SecurityAlert) error
+	GetOpenAlertsSummary(ctx context.Context) (*models.SecurityAlertSummary, error)
+	GetLargestCommits(ctx context.Context, filter models.CommitsFilter, limit int) ([]models.DigestCommit, error)
+	SaveDigestSubscription(ctx context.Context, sub models.DigestSubscription) (*models.DigestSubscription, error)
+	FindDigestSubscriptions(ctx context.Context) ([]models.DigestSubscription, error)
+	DeleteDigestSubscription(ctx context.Context, id uuid.UUID) error
+	ClaimDigestSubscriptions(ctx context.Context, week time.Time, limit int) ([]models.DigestSubscription, error)
 	SaveAuthor(ctx context.Context, author *models.Author) error
+	GetIngestionStatus(ctx context.Context, errorLimit int) (*models.IngestionStatus, error)
+	SaveSession(ctx context.Context, tokenHash string, session models.Session) (*models.Session, error)
+	FindSession(ctx context.Context, tokenHash string) (*models.Session, error)
+	DeleteSession(ctx context.Context, tokenHash string) error
+	DeleteExpiredSessions(ctx context.Context) (int64, error)
+	SaveAPIKey(ctx context.Context, keyHash string, key models.APIKey) (*models.APIKey, error)
+	FindAPIKey(ctx context.Context, keyHash string) (*models.APIKey, error)
+	FindAPIKeys(ctx context.Context) ([]models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id uuid.UUID) error
+	TouchAPIKey(ctx context.Context, id uuid.UUID) error
+	FindArchivableRepos(ctx context.Context, before time.Time) ([]models.Repository, error)
+	ClaimRepoArchive(ctx context.Context, repoID int64, before, leasedUntil time.Time) (bool, error)
+	ReleaseRepoArchive(ctx context.Context, repoID int64) error
+	FindArchivableCommits(ctx context.Context, repoID int64, before time.Time, limit int) ([]models.Commit, error)
+	ArchiveCommits(ctx context.Context, archive models.CommitArchive, hashes []string) error
+	FindCommitArchives(ctx context.Context, repoID int64) ([]models.CommitArchive, error)
+	GetArchiveSummary(ctx context.Context, repoID int64) (*models.ArchiveNotice, error)
+	SaveCredential(ctx context.Context, credential models.Credential) (*models.Credential, error)
+	FindCredential(ctx context.Context, id uuid.UUID) (*models.Credential, error)
+	FindCredentials(ctx context.Context) ([]models.Credential, error)
+	CreateReindex(ctx context.Context, intentID uuid.UUID, repoName string) (*models.Reindex, error)
+	GetLatestReindex(ctx context.Context, intentID uuid.UUID) (*models.Reindex, error)
+	SaveShadowCommits(ctx context.Context, id uuid.UUID, commits []*models.Commit) error
+	SetReindexExpected(ctx context.Context, id uuid.UUID, commits int64) error
+	FailReindex(ctx context.Context, id uuid.UUID, reason string) error
+	SwapReindex(ctx context.Context, id uuid.UUID, minRatio float64) (*models.Reindex, error)
 }
@@ -0,0 +1,33 @@
+package repository
+
+import "context"
+
+// DefaultTenant owns the records created outside of any tenant, including
+// every record from before tenants.
+const DefaultTenant = "default"
+
+type tenantKey struct{}
+
+// WithTenant scopes the store's lookups and listings made with the
+// returned context to tenant's records, and its new intents to tenant. An
+// empty tenant lifts the scope of ctx.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// WithoutTenant lifts the tenant scope of ctx, for the checks that must see
+// every tenant's records.
+func WithoutTenant(ctx context.Context) context.Context {
+	if _, ok := TenantFrom(ctx); !ok {
+		return ctx
+	}
+	return WithTenant(ctx, "")
+}
+
+// TenantFrom returns the tenant ctx is scoped to. Without one the store
+// sees the records of every tenant, as the manager's own background work
+// does.
+func TenantFrom(ctx context.Context) (string, bool) {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant, tenant != ""
+}
@@ -0,0 +1,61 @@
+package manager
+
+import (
+	"context"
+	"log"
+
+	"github.com/noelukwa/indexer/internal/manager/models"
+	"github.com/noelukwa/indexer/internal/manager/repository"
+	"github.com/noelukwa/indexer/internal/pkg/retry"
+)
+
+// persist runs save, a write of repoName's data, retrying failures under
+// the retry policy of the repository's active intent. The policy is only
+// looked up once a write has failed. Retries stop after RetryMaxElapsed,
+// as the batches of every repository wait on this one.
+func (svc *Service) persist(ctx context.Context, repoName string, save func() error) error {
+	err := save()
+	if err == nil {
+		return nil
+	}
+
+	policy := svc.retryPolicy(ctx, repoName)
+	waitCtx := ctx
+	if svc.cfg.RetryMaxElapsed > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, svc.cfg.RetryMaxElapsed)
+		defer cancel()
+	}
+
+	// The first attempt has already failed, so Do starts from its error.
+	failed := err
+	return retry.Do(waitCtx, policy, func() error {
+		if failed != nil {
+			err, failed = failed, nil
+			return err
+		}
+		return save()
+	}, func(attempt int, err error) {
+		log.Printf("failed to persist %s (attempt %d/%d): %v", repoName, attempt, policy.MaxAttempts, err)
+	})
+}
+
+// retryPolicy is the manager's default retry policy overridden by the
+// active intent of repoName, if any. The intent cannot lift MaxDelay.
+func (svc *Service) retryPolicy(ctx context.Context, repoName string) retry.Policy {
+	policy := svc.cfg.RetryPolicy()
+
+	active := true
+	intents, err := svc.store.FindIntents(ctx, models.IntentFilter{
+		RepositoryName: &repoName,
+		IsActive:       &active,
+	}, repository.Pagination{Page: 1, PerPage: 1})
+	if err != nil {
+		log.Printf("using the default retry policy for %s: %v", repoName, err)
+		return policy
+	}
+	if len(intents.Data) > 0 {
+		policy = intents.Data[0].Retry.Apply(policy)
+	}
+	return policy
+}
@@ -0,0 +1,60 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/noelukwa/indexer/internal/manager/models"
+	"github.com/noelukwa/indexer/internal/manager/repository"
+)
+
+// saveReviews saves pull request reviews from the monitor, which sends
+// those of one repository at a time.
+func (svc *Service) saveReviews(ctx context.Context, reviews []*models.PullRequestReview) error {
+	name := normalizeRepositoryName(reviews[0].Repository)
+	repo, err := svc.findRepo(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	err = svc.persist(ctx, repo.FullName, func() error {
+		return svc.store.SaveReviews(ctx, repo.ID, reviews)
+	})
+	if err != nil {
+		return err
+	}
+	svc.invalidateRepo(ctx, repo.FullName)
+	return nil
+}
+
+// GetReviewTurnaround measures how long the repository's pull requests
+// opened between startDate and endDate waited for their first review.
+// Either date may be zero to leave it open.
+func (svc *Service) GetReviewTurnaround(ctx context.Context, repo string, startDate, endDate time.Time) (*models.ReviewTurnaround, error) {
+	found, err := svc.findRepo(ctx, normalizeRepositoryName(repo))
+	if err != nil {
+		return nil, err
+	}
+
+	return cachedQuery(ctx, svc, found.FullName, "review_turnaround", dateParams(startDate, endDate, false), func() (*models.ReviewTurnaround, error) {
+		return svc.store.GetReviewTurnaround(ctx, found.ID, &startDate, &endDate)
+	})
+}
+
+// GetTopReviewers ranks the repository's reviewers by their reviews
+// submitted between startDate and endDate, either of which may be zero.
+func (svc *Service) GetTopReviewers(ctx context.Context, repo string, startDate, endDate time.Time, page, perPage int) (repository.Paginated[models.ReviewerStats], error) {
+	found, err := svc.findRepo(ctx, normalizeRepositoryName(repo))
+	if err != nil {
+		return repository.Paginated[models.ReviewerStats]{}, err
+	}
+
+	params := fmt.Sprintf("%s:%d:%d", dateParams(startDate, endDate, false), page, perPage)
+	return cachedQuery(ctx, svc, found.FullName, "top_reviewers", params, func() (repository.Paginated[models.ReviewerStats], error) {
+		return svc.store.GetTopReviewers(ctx, found.ID, &startDate, &endDate, repository.Pagination{
+			Page:    page,
+			PerPage: perPage,
+		})
+	})
+}
@@ -0,0 +1,274 @@
+package manager
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/noelukwa/indexer/internal/manager/models"
+)
+
+// RunRollups maintains the daily rollup until ctx is done. It wakes up when
+// commits are persisted and on the configured interval, which also picks
+// up commits persisted by other replicas, and rolls up until none are left.
+func (svc *Service) RunRollups(ctx context.Context) {
+	interval := svc.cfg.RollupInterval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	batchSize := svc.cfg.RollupBatchSize
+	if batchSize <= 0 {
+		batchSize = 5000
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := svc.rollupPending(ctx, batchSize); err != nil {
+			log.Printf("failed to roll up commits: %v", err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-svc.rollup:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (svc *Service) rollupPending(ctx context.Context, batchSize int) error {
+	// Stats read the rollup, so the results of a repository cached since
+	// its commits arrived are stale once they are rolled up.
+	stale := make(map[string]struct{})
+	defer func() {
+		for repo := range stale {
+			svc.invalidateRepo(ctx, repo)
+		}
+	}()
+
+	for {
+		rolled, err := svc.store.RollupCommits(ctx, batchSize)
+		if err != nil {
+			return err
+		}
+
+		var total int64
+		for repo, commits := range rolled {
+			stale[repo] = struct{}{}
+			total += commits
+		}
+		if total < int64(batchSize) {
+			return nil
+		}
+	}
+}
+
+var ErrInvalidActivityInterval = newError(ErrInvalid, "invalid interval: must be day, week or month")
+
+// maxDailySpan caps how long a daily series may be, as it has a row for
+// every day whether or not it has commits.
+const maxDailySpan = 5 * 366 * 24 * time.Hour
+
+// GetRepoStats summarizes a repository's commits between startDate and
+// endDate, either of which may be zero to leave it open. dedupe leaves out
+// the commits it shares with its upstream in a fork network.
+func (svc *Service) GetRepoStats(ctx context.Context, repo string, startDate, endDate time.Time, dedupe bool) (*models.RepoStats, error) {
+	repo = normalizeRepositoryName(repo)
+
+	found, err := svc.findRepo(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+	repo = found.FullName
+
+	return cachedQuery(ctx, svc, repo, "stats", dateParams(startDate, endDate, dedupe), func() (*models.RepoStats, error) {
+		return svc.store.GetRepoStats(ctx, models.CommitsFilter{
+			RepositoryName: repo,
+			StartDate:      &startDate,
+			EndDate:        &endDate,
+			Dedupe:         dedupe,
+		})
+	})
+}
+
+// GetDailyCommits sums a repository's commits per day between startDate
+// and endDate, either of which may be zero to leave it open, as a dense
+// series with zeros for the days without commits. dedupe leaves out the
+// commits it shares with its upstream in a fork network.
+func (svc *Service) GetDailyCommits(ctx context.Context, repo string, startDate, endDate time.Time, dedupe bool) ([]models.DailyCommits, error) {
+	repo = normalizeRepositoryName(repo)
+	if err := validateDailyRange(startDate, endDate); err != nil {
+		return nil, err
+	}
+
+	found, err := svc.findRepo(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+	repo = found.FullName
+
+	return cachedQuery(ctx, svc, repo, "daily_stats", dateParams(startDate, endDate, dedupe), func() ([]models.DailyCommits, error) {
+		return svc.store.GetDailyCommits(ctx, models.CommitsFilter{
+			RepositoryName: repo,
+			StartDate:      &startDate,
+			EndDate:        &endDate,
+			Dedupe:         dedupe,
+		})
+	})
+}
+
+// GetCommitActivity counts a repository's commits per day, week or month
+// between startDate and endDate, either of which may be zero to leave it
+// open, from the daily rollup. An empty interval counts per day. Like
+// GetDailyCommits, the series is dense, with zeros for the buckets
+// without commits.
+func (svc *Service) GetCommitActivity(ctx context.Context, repo string, interval models.ActivityInterval, startDate, endDate time.Time) ([]models.ActivityBucket, error) {
+	repo = normalizeRepositoryName(repo)
+	if interval == "" {
+		interval = models.ActivityDay
+	}
+	switch interval {
+	case models.ActivityDay, models.ActivityWeek, models.ActivityMonth:
+	default:
+		return nil, ErrInvalidActivityInterval
+	}
+	if err := validateDailyRange(startDate, endDate); err != nil {
+		return nil, err
+	}
+
+	found, err := svc.findRepo(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+	repo = found.FullName
+
+	params := string(interval) + ":" + dateParams(startDate, endDate, false)
+	return cachedQuery(ctx, svc, repo, "activity", params, func() ([]models.ActivityBucket, error) {
+		return svc.store.GetCommitActivity(ctx, models.CommitsFilter{
+			RepositoryName: repo,
+			StartDate:      &startDate,
+			EndDate:        &endDate,
+		}, interval)
+	})
+}
+
+// GetContributions counts the commits of each of a repository's authors
+// per month between startDate and endDate, either of which may be zero to
+// leave it open, from the daily rollup. Months run from the first to the
+// last of the range or, when it is open, of the rolled up commits, with
+// zeros for the months an author has no commits in. Authors with the most
+// commits come first.
+func (svc *Service) GetContributions(ctx context.Context, repo string, startDate, endDate time.Time) (*models.ContributionMatrix, error) {
+	repo = normalizeRepositoryName(repo)
+	if err := validateDailyRange(startDate, endDate); err != nil {
+		return nil, err
+	}
+
+	found, err := svc.findRepo(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+	repo = found.FullName
+
+	return cachedQuery(ctx, svc, repo, "contributions", dateParams(startDate, endDate, false), func() (*models.ContributionMatrix, error) {
+		contributions, err := svc.store.GetContributions(ctx, models.CommitsFilter{
+			RepositoryName: repo,
+			StartDate:      &startDate,
+			EndDate:        &endDate,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return contributionMatrix(contributions, startDate, endDate), nil
+	})
+}
+
+// contributionMatrix pivots per author and month counts into a matrix.
+func contributionMatrix(contributions []models.MonthlyContribution, startDate, endDate time.Time) *models.ContributionMatrix {
+	first, last := monthOf(startDate), monthOf(endDate)
+	for _, c := range contributions {
+		month := monthOf(c.Month)
+		if startDate.IsZero() && (first.IsZero() || month.Before(first)) {
+			first = month
+		}
+		if endDate.IsZero() && month.After(last) {
+			last = month
+		}
+	}
+
+	matrix := &models.ContributionMatrix{Months: []string{}, Authors: []models.AuthorContributions{}}
+	if first.IsZero() || last.IsZero() {
+		return matrix
+	}
+	columns := make(map[time.Time]int)
+	for month := first; !month.After(last); month = month.AddDate(0, 1, 0) {
+		columns[month] = len(matrix.Months)
+		matrix.Months = append(matrix.Months, month.Format("2006-01"))
+	}
+
+	rows := make(map[int64]int)
+	for _, c := range contributions {
+		column, ok := columns[monthOf(c.Month)]
+		if !ok {
+			continue
+		}
+		row, ok := rows[c.Author.ID]
+		if !ok {
+			row = len(matrix.Authors)
+			rows[c.Author.ID] = row
+			matrix.Authors = append(matrix.Authors, models.AuthorContributions{
+				Author:  c.Author,
+				Commits: make([]int64, len(matrix.Months)),
+			})
+		}
+		matrix.Authors[row].Commits[column] += c.Commits
+		matrix.Authors[row].Total += c.Commits
+	}
+
+	slices.SortStableFunc(matrix.Authors, func(a, b models.AuthorContributions) int {
+		if a.Total != b.Total {
+			return cmp.Compare(b.Total, a.Total)
+		}
+		return cmp.Compare(a.Author.ID, b.Author.ID)
+	})
+	return matrix
+}
+
+// monthOf returns the first of t's month in UTC, or the zero time for a
+// zero t.
+func monthOf(t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// validateDailyRange keeps a daily series within maxDailySpan. Without a
+// start date the series begins at the repository's first commit, so only
+// the end date is checked.
+func validateDailyRange(startDate, endDate time.Time) error {
+	now := time.Now()
+	if endDate.After(now.Add(24 * time.Hour)) {
+		return ErrInvalidDateRange
+	}
+	if startDate.IsZero() {
+		return nil
+	}
+	if endDate.IsZero() {
+		endDate = now
+	}
+	if endDate.Before(startDate) || endDate.Sub(startDate) > maxDailySpan {
+		return ErrInvalidDateRange
+	}
+	return nil
+}
+
+// dateParams is the cache key part of a date range query.
+func dateParams(startDate, endDate time.Time, dedupe bool) string {
+	return fmt.Sprintf("%s:%s:%t", startDate.Format(time.DateOnly), endDate.Format(time.DateOnly), dedupe)
+}
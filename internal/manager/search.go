@@ -0,0 +1,68 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/noelukwa/indexer/internal/manager/models"
+	"github.com/noelukwa/indexer/internal/manager/repository"
+)
+
+// minSearchLength keeps single characters from matching most of the index.
+const minSearchLength = 2
+
+var ErrInvalidSearchQuery error = newError(ErrInvalid, fmt.Sprintf("search query must be at least %d characters", minSearchLength))
+
+// Search looks query up across repository names, authors and commit
+// messages, returning the page of each type of match.
+func (svc *Service) Search(ctx context.Context, query string, page, perPage int) (*models.SearchResults, error) {
+	query = strings.TrimSpace(query)
+	if utf8.RuneCountInString(query) < minSearchLength {
+		return nil, ErrInvalidSearchQuery
+	}
+
+	return svc.store.Search(ctx, query, repository.Pagination{
+		Page:    page,
+		PerPage: perPage,
+	})
+}
+
+// SearchCommits ranks the commit messages matching query by full-text
+// search, optionally only in repoName and between startDate and endDate.
+// The end date includes its whole day.
+func (svc *Service) SearchCommits(ctx context.Context, query, repoName string, startDate, endDate time.Time, page, perPage int) (repository.Paginated[models.CommitMatch], error) {
+	query = strings.TrimSpace(query)
+	if utf8.RuneCountInString(query) < minSearchLength {
+		return repository.Paginated[models.CommitMatch]{}, ErrInvalidSearchQuery
+	}
+	if err := validateStartDate(startDate); err != nil {
+		return repository.Paginated[models.CommitMatch]{}, err
+	}
+	if err := validateEndDate(startDate, endDate); err != nil {
+		return repository.Paginated[models.CommitMatch]{}, err
+	}
+
+	filter := models.CommitSearchFilter{Query: query}
+	if repoName = normalizeRepositoryName(repoName); repoName != "" {
+		filter.RepositoryName = &repoName
+	}
+	if !startDate.IsZero() {
+		filter.StartDate = &startDate
+	}
+	if !endDate.IsZero() {
+		endOfDay := endDate.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		filter.EndDate = &endOfDay
+	}
+
+	matches, err := svc.store.SearchCommitMessages(ctx, filter, repository.Pagination{
+		Page:    page,
+		PerPage: perPage,
+	})
+	if err != nil {
+		return repository.Paginated[models.CommitMatch]{}, fmt.Errorf("failed to search commits: %w", err)
+	}
+	return matches, nil
+}
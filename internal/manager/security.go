@@ -0,0 +1,38 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/noelukwa/indexer/internal/manager/models"
+)
+
+// saveSecurityAlerts saves security alerts from the monitor, which sends
+// those of one repository at a time.
+func (svc *Service) saveSecurityAlerts(ctx context.Context, alerts []*models.SecurityAlert) error {
+	repo, err := svc.findRepo(ctx, normalizeRepositoryName(alerts[0].Repository))
+	if err != nil {
+		return err
+	}
+	return svc.persist(ctx, repo.FullName, func() error {
+		return svc.store.SaveSecurityAlerts(ctx, repo.ID, alerts)
+	})
+}
+
+// GetOpenAlertsSummary counts the open security alerts across the indexed
+// repositories by severity. Alerts are only indexed when the monitor
+// fetches them.
+func (svc *Service) GetOpenAlertsSummary(ctx context.Context) (*models.SecurityAlertSummary, error) {
+	summary, err := svc.store.GetOpenAlertsSummary(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get security alerts: %w", err)
+	}
+	for _, repo := range summary.Repositories {
+		summary.Open.Critical += repo.Critical
+		summary.Open.High += repo.High
+		summary.Open.Medium += repo.Medium
+		summary.Open.Low += repo.Low
+		summary.Open.Total += repo.Total
+	}
+	return summary, nil
+}
@@ -3,43 +3,116 @@ package manager
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"sort"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/noelukwa/indexer/internal/events"
 	"github.com/noelukwa/indexer/internal/manager/models"
 	"github.com/noelukwa/indexer/internal/manager/repository"
+	"github.com/noelukwa/indexer/internal/pkg/broker"
 	"github.com/noelukwa/indexer/internal/pkg/config"
-	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/noelukwa/indexer/internal/pkg/objectstore"
 )
 
 var (
-	ErrInvalidRepository  error = fmt.Errorf("invalid repository name: must be in <owner>/<repo> format")
-	ErrInvalidStartDate   error = fmt.Errorf("start date cannot be in the future")
-	ErrExistingIntent     error = fmt.Errorf("repository intent already exists")
-	ErrIntentNotFound     error = fmt.Errorf("repository intent not found")
-	ErrRepositoryNotFound error = fmt.Errorf("repository intent not found")
+	ErrInvalidRepository  error = newError(ErrInvalid, "invalid repository name: must be in <owner>/<repo> format")
+	ErrInvalidStartDate   error = newError(ErrInvalid, "start date cannot be in the future")
+	ErrInvalidEndDate     error = newError(ErrInvalid, "end date cannot be before the start date")
+	ErrExistingIntent     error = newError(ErrConflict, "repository intent already exists")
+	ErrIntentNotFound     error = newError(ErrNotFound, "repository intent not found")
+	ErrRepositoryNotFound error = newError(ErrNotFound, "repository not found")
+	ErrInvalidPathFilter  error = newError(ErrInvalid, "invalid path filter: must be a path prefix such as services/payments/**")
+	ErrInvalidBranch      error = newError(ErrInvalid, "invalid branch: cannot be combined with index_all_branches")
+	ErrInvalidRetryPolicy error = newError(ErrInvalid, "invalid retry policy: max_attempts must be 1 to 10, backoff_base_ms 0 to 600000 and jitter 0 to 1")
+	ErrInvalidDateRange   error = newError(ErrInvalid, "invalid date range: until cannot be before since, later than tomorrow or more than 5 years after since")
+)
+
+const (
+	recentErrorsLimit = 10
+
+	// dispatchBatchSize is how many queued intent commands are published
+	// per outbox transaction.
+	dispatchBatchSize = 100
+	// dispatchInterval is how often the broadcaster checks the outbox
+	// without being woken.
+	dispatchInterval = 5 * time.Second
 )
 
 type Service struct {
-	store       repository.ManagerStore
-	intentsChan chan *events.IntentCommand
-	cfg         *config.ManagerConfig
+	store      repository.ManagerStore
+	rateLimits RateLimitSource
+	locks      LockSource
+	cache      ResultCache
+	watchers   *intentWatchers
+	cfg        *config.ManagerConfig
+
+	// bus carries intent events to the other replicas. It is nil when the
+	// store can't, and events then only reach this replica's watchers.
+	bus repository.IntentEventsBus
+
+	// dispatch wakes the broadcaster when intent commands are queued.
+	dispatch chan struct{}
+
+	// ingested counts commits persisted since the last refresh request.
+	ingested atomic.Int64
+	refresh  chan struct{}
+	// rollup wakes the daily rollup aggregator.
+	rollup chan struct{}
+
+	cacheMetrics cacheMetrics
+	pipeline     pipelineMetrics
+	throttle     *ingestThrottle
+	hooks        []NamedCommitHook
+	mailer       Mailer
+	// repoCommits counts the commits ingested per repository, pushed with
+	// metricsWriter.
+	repoCommits   repoCommitCounters
+	metricsWriter MetricsWriter
+	// archives is where cold commits are archived.
+	archives   objectstore.Store
+	apiLimiter APILimiter
 }
 
-func NewService(store repository.ManagerStore, cfg *config.ManagerConfig) *Service {
+// NewService builds a Service. rateLimits and locks may be nil when the
+// monitor's Redis is not reachable from the manager, and cache nil to run
+// every query against the store.
+func NewService(store repository.ManagerStore, rateLimits RateLimitSource, locks LockSource, cache ResultCache, cfg *config.ManagerConfig) *Service {
+	bus, _ := store.(repository.IntentEventsBus)
 	return &Service{
-		store:       store,
-		intentsChan: make(chan *events.IntentCommand, 1),
-		cfg:         cfg,
+		bus:        bus,
+		store:      store,
+		rateLimits: rateLimits,
+		locks:      locks,
+		cache:      cache,
+		watchers:   newIntentWatchers(),
+		cfg:        cfg,
+		dispatch:   make(chan struct{}, 1),
+		refresh:    make(chan struct{}, 1),
+		rollup:     make(chan struct{}, 1),
+		throttle:   newIngestThrottle(cfg.IngestCommitsPerSecond, cfg.IngestBatchesPerSecond),
 	}
 }
 
-func (svc *Service) CreateIntent(ctx context.Context, repoName string, startDate time.Time) (*models.Intent, error) {
+func (svc *Service) CreateIntent(ctx context.Context, repoName string, startDate, until time.Time, opts models.IntentOptions) (*models.Intent, error) {
+	return svc.createIntent(ctx, repoName, startDate, until, opts, true)
+}
+
+// createIntent saves a new intent and sends it to the monitor, or saves it
+// paused when it isn't active.
+func (svc *Service) createIntent(ctx context.Context, repoName string, startDate, until time.Time, opts models.IntentOptions, active bool) (*models.Intent, error) {
+	return svc.createChildIntent(ctx, nil, repoName, startDate, until, opts, active)
+}
+
+// createChildIntent is createIntent for an intent created by the org
+// intent with parentID, or by no org intent when it is nil.
+func (svc *Service) createChildIntent(ctx context.Context, parentID *uuid.UUID, repoName string, startDate, until time.Time, opts models.IntentOptions, active bool) (*models.Intent, error) {
+	repoName = normalizeRepositoryName(repoName)
 	if err := validateRepositoryName(repoName); err != nil {
 		return nil, err
 	}
@@ -48,71 +121,108 @@ func (svc *Service) CreateIntent(ctx context.Context, repoName string, startDate
 		return nil, err
 	}
 
-	id, err := uuid.NewRandom()
-	if err != nil {
+	if err := validateEndDate(startDate, until); err != nil {
 		return nil, err
 	}
-	intent := &models.Intent{
-		Status:         models.PendingBroadCast,
-		IsActive:       true,
-		ID:             id,
-		RepositoryName: repoName,
-		StartDate:      startDate,
-		Until:          time.Now(),
-	}
-	intent, err = svc.store.SaveIntent(ctx, *intent)
+
+	paths, err := normalizePathFilters(opts.PathFilters)
 	if err != nil {
 		return nil, err
 	}
+	opts.PathFilters = paths
+	opts.AuthorFilters = normalizeAuthorFilters(opts.AuthorFilters)
+	opts.ExcludedAuthors = normalizeAuthorFilters(opts.ExcludedAuthors)
 
-	svc.intentsChan <- events.NewIntentCommand(events.NewIntentKind, &events.IntentPayload{
-		ID:        intent.ID,
-		RepoOwner: strings.Split(repoName, "/")[0],
-		RepoName:  strings.Split(repoName, "/")[1],
-		From:      intent.StartDate,
-	})
-	return intent, nil
-}
-
-func (svc *Service) UpdateIntentStatus(ctx context.Context, id uuid.UUID) (*models.Intent, error) {
-	intent, err := svc.store.FindIntent(ctx, id)
-	if err != nil {
-		return nil, fmt.Errorf("failed to find intent: %w", err)
+	opts.Branch = strings.TrimSpace(opts.Branch)
+	if opts.Branch != "" && opts.IndexAllBranches {
+		return nil, ErrInvalidBranch
 	}
 
-	log.Printf("found intent: %v", intent)
-	if intent == nil {
-		return nil, ErrIntentNotFound
+	if err := validateRetryPolicy(opts.Retry); err != nil {
+		return nil, err
 	}
 
-	newStatus := !intent.IsActive
+	var credential *models.Credential
+	if opts.CredentialID != nil {
+		credential, err = svc.findCredential(ctx, *opts.CredentialID)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	update, err := svc.store.UpdateIntent(ctx, models.IntentUpdate{
-		ID:       id,
-		IsActive: &newStatus,
-	})
+	if active {
+		if err := svc.checkExistingIntent(ctx, repoName); err != nil {
+			return nil, err
+		}
+	}
 
+	id, err := uuid.NewRandom()
 	if err != nil {
-		return nil, fmt.Errorf("failed to update intent: %w", err)
+		return nil, err
 	}
-
-	var eventKind events.IntentKind
-	if intent.IsActive && !newStatus {
-		eventKind = events.CancelIntentKind
-	} else if !intent.IsActive && newStatus {
-		eventKind = events.NewIntentKind
+	intent := &models.Intent{
+		Status:         models.Created,
+		IsActive:       active,
+		ID:             id,
+		RepositoryName: repoName,
+		ParentID:       parentID,
+		IntentOptions:  opts,
+	}
+	if !active {
+		intent.Status = models.Paused
+	}
+	// A zero start date asks for the full history and a zero end date keeps
+	// the intent open.
+	if !startDate.IsZero() {
+		intent.StartDate = &startDate
+	}
+	if !until.IsZero() {
+		intent.Until = &until
+	}
+	if active {
+		// The intent and its command are saved together, so an intent is
+		// never left active without discovery hearing of it.
+		payload := newIntentPayload(intent)
+		if credential != nil {
+			payload.Credential = credential.Ciphertext
+		}
+		var command []byte
+		command, err = encodeIntentCommand(events.NewIntentKind, payload)
+		if err != nil {
+			return nil, err
+		}
+		intent, err = svc.store.SaveQueuedIntent(ctx, *intent, command)
 	} else {
-		eventKind = events.UpdateIntentKind
+		intent, err = svc.store.SaveIntent(ctx, *intent)
 	}
+	if errors.Is(err, repository.ErrConflict) {
+		// Another request created the repository's intent since the check.
+		if err := svc.checkExistingIntent(ctx, repoName); err != nil {
+			return nil, err
+		}
+		return nil, ErrExistingIntent
+	}
+	if err != nil {
+		return nil, storeError(err)
+	}
+	svc.recordTransition(ctx, intent.ID, "", intent.Status)
+	if active {
+		svc.wakeBroadcast()
+	}
+	return intent, nil
+}
 
-	svc.intentsChan <- events.NewIntentCommand(eventKind, &events.IntentPayload{
-		ID:        update.ID,
-		RepoOwner: strings.Split(update.RepositoryName, "/")[0],
-		RepoName:  strings.Split(update.RepositoryName, "/")[1],
-		From:      update.StartDate,
-	})
-
-	return update, nil
+// UpdateIntentStatus pauses the intent when it is active and resumes it
+// when it is paused.
+func (svc *Service) UpdateIntentStatus(ctx context.Context, id uuid.UUID) (*models.Intent, error) {
+	intent, err := svc.findIntent(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if intent.IsActive {
+		return svc.pauseIntent(ctx, intent)
+	}
+	return svc.resumeIntent(ctx, intent)
 }
 
 func (svc *Service) ResetIntentStartDate(ctx context.Context, id uuid.UUID, newDate time.Time) error {
@@ -124,25 +234,51 @@ func (svc *Service) ResetIntentStartDate(ctx context.Context, id uuid.UUID, newD
 		ID:        id,
 		StartDate: &newDate,
 	})
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrIntentNotFound
+	}
 	if err != nil {
-		return err
+		return storeError(err)
 	}
 
-	svc.intentsChan <- events.NewIntentCommand(events.UpdateIntentKind, &events.IntentPayload{
-		ID:        intent.ID,
-		RepoOwner: strings.Split(intent.RepositoryName, "/")[0],
-		RepoName:  strings.Split(intent.RepositoryName, "/")[1],
-		From:      intent.StartDate,
-	})
-
-	return nil
+	payload, err := svc.intentPayload(ctx, intent)
+	if err != nil {
+		return err
+	}
+	return svc.queueIntent(ctx, events.UpdateIntentKind, payload)
 }
 
+// GetIntent returns the intent with how far its latest run has got.
 func (svc *Service) GetIntent(ctx context.Context, id uuid.UUID) (*models.Intent, error) {
-	return svc.store.FindIntent(ctx, id)
+	intent, err := svc.findIntent(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	intent.Progress, err = svc.intentProgress(ctx, intent)
+	if err != nil {
+		return nil, err
+	}
+	return intent, nil
+}
+
+// findIntent is FindIntent with a missing intent reported as
+// ErrIntentNotFound.
+func (svc *Service) findIntent(ctx context.Context, id uuid.UUID) (*models.Intent, error) {
+	intent, err := svc.store.FindIntent(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrIntentNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find intent: %w", err)
+	}
+	return intent, nil
 }
 
 func (svc *Service) GetIntents(ctx context.Context, filter models.IntentFilter, limit, offset int) (repository.Paginated[models.Intent], error) {
+	if filter.RepositoryName != nil {
+		name := normalizeRepositoryName(*filter.RepositoryName)
+		filter.RepositoryName = &name
+	}
 
 	pagination := repository.Pagination{
 		Page:    offset,
@@ -152,13 +288,52 @@ func (svc *Service) GetIntents(ctx context.Context, filter models.IntentFilter,
 	return svc.store.FindIntents(ctx, filter, pagination)
 }
 
-func (svc *Service) GetTopCommitters(ctx context.Context, repoName string, page, perPage int) (repository.Paginated[models.AuthorStats], error) {
+func (svc *Service) GetRepositories(ctx context.Context, filter models.RepositoryFilter, page, perPage int) (repository.Paginated[models.Repository], error) {
 	pagination := repository.Pagination{
 		Page:    page,
 		PerPage: perPage,
 	}
 
-	topCommitters, err := svc.store.GetTopCommitters(ctx, repoName, nil, nil, pagination)
+	return svc.store.FindRepos(ctx, filter, pagination)
+}
+
+// GetTopCommitters ranks the repository's authors by their commits between
+// startDate and endDate, either of which may be zero to leave it open. The
+// end date includes the commits of that day. Without dates the ranking is
+// the all-time leaderboard.
+func (svc *Service) GetTopCommitters(ctx context.Context, repoName string, startDate, endDate time.Time, page, perPage int) (repository.Paginated[models.AuthorStats], error) {
+	if err := validateStartDate(startDate); err != nil {
+		return repository.Paginated[models.AuthorStats]{}, err
+	}
+	if !startDate.IsZero() {
+		if err := validateEndDate(startDate, endDate); err != nil {
+			return repository.Paginated[models.AuthorStats]{}, err
+		}
+	}
+
+	pagination := repository.Pagination{
+		Page:    page,
+		PerPage: perPage,
+	}
+	var start, end *time.Time
+	if !startDate.IsZero() {
+		start = &startDate
+	}
+	if !endDate.IsZero() {
+		endOfDay := endDate.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		end = &endOfDay
+	}
+
+	found, err := svc.findRepo(ctx, normalizeRepositoryName(repoName))
+	if err != nil {
+		return repository.Paginated[models.AuthorStats]{}, err
+	}
+	repoName = found.FullName
+
+	params := fmt.Sprintf("%d:%d:%s", page, perPage, dateParams(startDate, endDate, false))
+	topCommitters, err := cachedQuery(ctx, svc, repoName, "top_committers", params, func() (repository.Paginated[models.AuthorStats], error) {
+		return svc.store.GetTopCommitters(ctx, repoName, start, end, pagination)
+	})
 	if err != nil {
 		return repository.Paginated[models.AuthorStats]{}, fmt.Errorf("failed to get top committers: %w", err)
 	}
@@ -174,6 +349,10 @@ func (svc *Service) BatchSaveCommits(ctx context.Context, commits []*models.Comm
 		return nil
 	}
 
+	for _, commit := range commits {
+		commit.Repository.FullName = normalizeRepositoryName(commit.Repository.FullName)
+	}
+
 	sort.Slice(commits, func(i, j int) bool {
 		return commits[i].Repository.FullName < commits[j].Repository.FullName
 	})
@@ -188,14 +367,19 @@ func (svc *Service) BatchSaveCommits(ctx context.Context, commits []*models.Comm
 				currentRepoCommits = append(currentRepoCommits, commit)
 			}
 			repo, err := svc.store.GetRepo(ctx, commit.Repository.FullName)
-			if err != nil || repo == nil {
+			if err != nil {
 				return fmt.Errorf("failed to find repository %s: %w", currentRepoName, err)
 			}
 
-			err = svc.store.SaveManyCommit(ctx, repo.ID, currentRepoCommits)
+			err = svc.persist(ctx, currentRepoName, func() error {
+				return svc.store.SaveManyCommit(ctx, repo.ID, currentRepoCommits)
+			})
 			if err != nil {
 				return fmt.Errorf("failed to save commits for repository %s: %w", currentRepoName, err)
 			}
+			svc.invalidateRepo(ctx, repo.FullName)
+			svc.noteIngested(len(currentRepoCommits))
+			svc.repoCommits.add(currentRepoName, len(currentRepoCommits))
 			currentRepoName = commit.Repository.FullName
 			currentRepoCommits = []*models.Commit{commit}
 		} else {
@@ -207,20 +391,37 @@ func (svc *Service) BatchSaveCommits(ctx context.Context, commits []*models.Comm
 }
 
 func (svc *Service) FindRepository(ctx context.Context, repoName string) (*models.Repository, error) {
-	return svc.store.GetRepo(ctx, repoName)
+	return svc.findRepo(ctx, normalizeRepositoryName(repoName))
 }
 
-func (svc *Service) GetCommits(ctx context.Context, repo string, startDate, endDate time.Time, page, perPage int) (models.CommitPage, error) {
+// findRepo is GetRepo with a missing repository reported as
+// ErrRepositoryNotFound.
+func (svc *Service) findRepo(ctx context.Context, repoName string) (*models.Repository, error) {
+	repo, err := svc.store.GetRepo(ctx, repoName)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrRepositoryNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find repository: %w", err)
+	}
+	return repo, nil
+}
 
-	_, err := svc.store.GetRepo(ctx, repo)
+// GetCommits lists the repository's commits matching filter, the newest
+// first. Its dates are whole days, so EndDate includes the commits of that
+// day.
+func (svc *Service) GetCommits(ctx context.Context, filter models.CommitsFilter, page, perPage int) (models.CommitPage, error) {
+	// GetRepo resolves the old names of renamed repositories, so query by
+	// the current one.
+	found, err := svc.findRepo(ctx, normalizeRepositoryName(filter.RepositoryName))
 	if err != nil {
 		return models.CommitPage{}, err
 	}
+	filter.RepositoryName = found.FullName
 
-	filter := models.CommitsFilter{
-		RepositoryName: repo,
-		StartDate:      &startDate,
-		EndDate:        &endDate,
+	if filter.EndDate != nil && !filter.EndDate.IsZero() {
+		endOfDay := filter.EndDate.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		filter.EndDate = &endOfDay
 	}
 	pagination := repository.Pagination{
 		Page:    page,
@@ -231,16 +432,65 @@ func (svc *Service) GetCommits(ctx context.Context, repo string, startDate, endD
 	if err != nil {
 		return models.CommitPage{}, err
 	}
+	archived, err := svc.archiveNotice(ctx, found.ID, filter.StartDate)
+	if err != nil {
+		return models.CommitPage{}, err
+	}
 
 	return models.CommitPage{
 		Commits:    repoResp.Data,
 		TotalCount: repoResp.TotalCount,
 		Page:       int32(repoResp.Page),
 		PerPage:    int32(repoResp.PerPage),
+		Archived:   archived,
 	}, nil
 }
 
+// GetCommitComments returns the comments indexed for the repository's
+// commit, the oldest first. Commits are only fetched with their comments
+// when the monitor is configured to.
+func (svc *Service) GetCommitComments(ctx context.Context, repo, hash string) ([]models.CommitComment, error) {
+	found, err := svc.findRepo(ctx, normalizeRepositoryName(repo))
+	if err != nil {
+		return nil, err
+	}
+	return svc.store.FindCommitComments(ctx, found.ID, strings.ToLower(hash))
+}
+
+// GetChurn sums the line changes of a repository's commits between
+// startDate and endDate, both days included, either of which may be zero
+// to leave it open.
+func (svc *Service) GetChurn(ctx context.Context, repo string, startDate, endDate time.Time) (*models.Churn, error) {
+	repo = normalizeRepositoryName(repo)
+
+	found, err := svc.findRepo(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+	repo = found.FullName
+
+	return cachedQuery(ctx, svc, repo, "churn", dateParams(startDate, endDate, false), func() (*models.Churn, error) {
+		return svc.store.GetChurn(ctx, models.CommitsFilter{
+			RepositoryName: repo,
+			StartDate:      &startDate,
+			EndDate:        &endDate,
+		})
+	})
+}
+
+func (svc *Service) GetIngestionStatus(ctx context.Context) (*models.IngestionStatus, error) {
+	status, err := svc.store.GetIngestionStatus(ctx, recentErrorsLimit)
+	if err != nil {
+		return nil, err
+	}
+	if svc.cache != nil {
+		status.QueryCache = svc.cacheMetrics.snapshot()
+	}
+	return status, nil
+}
+
 func (svc *Service) ProcessCommitCommands(ctx context.Context, body []byte) error {
+	receivedAt := time.Now()
 	var command events.CommitsCommand
 	err := json.Unmarshal(body, &command)
 	if err != nil {
@@ -252,20 +502,109 @@ func (svc *Service) ProcessCommitCommands(ctx context.Context, body []byte) erro
 		if command.Payload.Repo == nil {
 			return fmt.Errorf("repo info is missing in the payload")
 		}
-		err = svc.store.SaveRepo(ctx, command.Payload.Repo)
+		repo := command.Payload.Repo
+		repo.FullName = normalizeRepositoryName(repo.FullName)
+		var renamedFrom string
+		err = svc.persist(ctx, repo.FullName, func() error {
+			var err error
+			renamedFrom, err = svc.store.RenameRepo(ctx, repo.ID, repo.FullName)
+			if err != nil {
+				return err
+			}
+			if err := svc.store.SaveRepo(ctx, repo); err != nil {
+				return err
+			}
+			// The run's credential could read the repository, so the
+			// intent's tenant may see it.
+			if command.Payload.IntentID != nil {
+				return svc.store.AddRepoTenant(ctx, repo.ID, *command.Payload.IntentID)
+			}
+			return nil
+		})
 		if err != nil {
 			return fmt.Errorf("failed to save repo: %w", err)
 		}
+		if renamedFrom != "" {
+			if err := svc.publishRename(ctx, renamedFrom, repo.FullName); err != nil {
+				return fmt.Errorf("failed to publish repo rename: %w", err)
+			}
+		}
 
 	case events.NewCommitsKind:
 		if len(command.Payload.Commits) == 0 {
 			return fmt.Errorf("commits are missing in the payload")
 		}
 		log.Printf("new commits payload: %+v\n", command.Payload.Commits)
-		err = svc.BatchSaveCommits(ctx, command.Payload.Commits)
+		waited, err := svc.throttle.wait(ctx, len(command.Payload.Commits))
+		svc.pipeline.throttled(waited)
+		if err != nil {
+			return fmt.Errorf("failed to wait for the ingestion throttle: %w", err)
+		}
+		commits, err := svc.runCommitHooks(ctx, command.Payload.Commits)
+		if err != nil {
+			return err
+		}
+		if len(commits) == 0 {
+			return nil
+		}
+		if command.Payload.ReindexID != nil {
+			err = svc.saveShadowCommits(ctx, *command.Payload.ReindexID, commits)
+		} else {
+			err = svc.BatchSaveCommits(ctx, commits)
+		}
 		if err != nil {
 			return fmt.Errorf("failed to save commits: %w", err)
 		}
+		svc.pipeline.record(commits, receivedAt, time.Now())
+
+	case events.NewReviewsKind:
+		if len(command.Payload.Reviews) == 0 {
+			return fmt.Errorf("reviews are missing in the payload")
+		}
+		if err := svc.saveReviews(ctx, command.Payload.Reviews); err != nil {
+			return fmt.Errorf("failed to save reviews: %w", err)
+		}
+
+	case events.NewWorkflowRunsKind:
+		if len(command.Payload.WorkflowRuns) == 0 {
+			return fmt.Errorf("workflow runs are missing in the payload")
+		}
+		if err := svc.saveWorkflowRuns(ctx, command.Payload.WorkflowRuns); err != nil {
+			return fmt.Errorf("failed to save workflow runs: %w", err)
+		}
+
+	case events.NewGitHubStatsKind:
+		if command.Payload.GitHubStats == nil {
+			return fmt.Errorf("GitHub stats are missing in the payload")
+		}
+		if err := svc.saveGitHubStats(ctx, command.Payload.GitHubStats); err != nil {
+			return fmt.Errorf("failed to save GitHub stats: %w", err)
+		}
+
+	case events.NewSecurityAlertsKind:
+		if len(command.Payload.SecurityAlerts) == 0 {
+			return fmt.Errorf("security alerts are missing in the payload")
+		}
+		if err := svc.saveSecurityAlerts(ctx, command.Payload.SecurityAlerts); err != nil {
+			return fmt.Errorf("failed to save security alerts: %w", err)
+		}
+
+	case events.NewOrgReposKind:
+		if command.Payload.OrgRepos == nil {
+			return fmt.Errorf("org repos are missing in the payload")
+		}
+		if err := svc.expandOrgIntent(ctx, command.Payload.OrgRepos); err != nil {
+			return fmt.Errorf("failed to expand org intent: %w", err)
+		}
+
+	case events.IntentStartedKind, events.IntentProgressKind, events.IntentCompletedKind, events.IntentFailedKind:
+		if command.Payload.Progress == nil {
+			return fmt.Errorf("progress is missing in the payload")
+		}
+		err = svc.recordProgress(ctx, command.Kind, command.Payload.Progress)
+		if err != nil {
+			return fmt.Errorf("failed to record intent progress: %w", err)
+		}
 
 	default:
 		return fmt.Errorf("unknown commit command kind: %s", command.Kind)
@@ -274,49 +613,294 @@ func (svc *Service) ProcessCommitCommands(ctx context.Context, body []byte) erro
 	return nil
 }
 
-func (svc *Service) StartBroadCast(ctx context.Context, ch *amqp.Channel) error {
+// recordProgress applies a lifecycle event from the monitor to its intent.
+// A failed run is also recorded as an intent error. Runs still report on
+// intents paused while they were in flight, but leave them paused.
+func (svc *Service) recordProgress(ctx context.Context, kind events.CommitsEventKind, progress *events.IntentProgress) error {
+	intent, err := svc.findIntent(ctx, progress.IntentID)
+	if err != nil {
+		return err
+	}
+
+	if progress.ReindexID != nil {
+		if err := svc.recordReindexProgress(ctx, intent, kind, progress); err != nil {
+			return fmt.Errorf("failed to record reindex progress: %w", err)
+		}
+	}
+
+	update := models.IntentUpdate{
+		ID:            progress.IntentID,
+		SyncedCommits: &progress.Commits,
+	}
+
+	var status models.IntentStatus
+	switch kind {
+	case events.IntentStartedKind:
+		status = models.Fetching
+		update.SyncStartedAt = &progress.At
+	case events.IntentProgressKind:
+		status = models.Fetching
+		if progress.Commits > 0 {
+			status = models.Ingesting
+		}
+	case events.IntentCompletedKind:
+		status = models.Completed
+		update.LastSyncedAt = &progress.At
+	case events.IntentFailedKind:
+		status = models.Failed
+		err := svc.store.SaveIntentError(ctx, models.IntentError{
+			IntentID:  progress.IntentID,
+			CreatedAt: progress.At,
+			Message:   progress.Error,
+		})
+		if err != nil {
+			return err
+		}
+		svc.emit(ctx, models.IntentEvent{
+			Type:          models.ErrorEvent,
+			IntentID:      intent.ID,
+			Status:        intent.Status,
+			SyncedCommits: progress.Commits,
+			Error:         progress.Error,
+			At:            progress.At,
+		})
+	}
+
+	if !intent.Status.CanTransitionTo(status) {
+		log.Printf("intent %s is %s, not moving it to %s", intent.ID, intent.Status, status)
+		status = intent.Status
+		_, err = svc.store.UpdateIntent(ctx, update)
+	} else {
+		_, err = svc.transitionIntent(ctx, intent, status, update)
+	}
+	if err != nil {
+		return err
+	}
+	if err := svc.saveProgress(ctx, intent, kind, progress); err != nil {
+		return fmt.Errorf("failed to save progress: %w", err)
+	}
+
+	svc.emit(ctx, models.IntentEvent{
+		Type:          models.ProgressEvent,
+		IntentID:      intent.ID,
+		Status:        status,
+		SyncedCommits: progress.Commits,
+		At:            progress.At,
+	})
+
+	if status == models.Completed {
+		if err := svc.pauseIfArchived(ctx, intent.RepositoryName); err != nil {
+			return fmt.Errorf("failed to pause intents of archived repo: %w", err)
+		}
+	}
+	return nil
+}
+
+// pauseIfArchived pauses the intents of repoName once a run of it has
+// completed, if the monitor reported it archived.
+func (svc *Service) pauseIfArchived(ctx context.Context, repoName string) error {
+	repo, err := svc.store.GetRepo(ctx, repoName)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !repo.Archived {
+		return nil
+	}
+	_, err = svc.pauseIntents(ctx, repo.FullName, repo.FullName+" is archived")
+	return err
+}
+
+// publishRename tells the clients watching a renamed repository's intents,
+// which have moved to its new name, about the rename.
+func (svc *Service) publishRename(ctx context.Context, from, to string) error {
+	log.Printf("repository %s was renamed to %s", from, to)
+	if svc.bus == nil && !svc.watchers.watching() {
+		return nil
+	}
+
+	active := true
+	intents, err := svc.store.FindIntents(ctx, models.IntentFilter{
+		RepositoryName: &to,
+		IsActive:       &active,
+	}, repository.Pagination{Page: 1, PerPage: 100})
+	if err != nil {
+		return err
+	}
+
+	for _, intent := range intents.Data {
+		svc.emit(ctx, models.IntentEvent{
+			Type:          models.RenameEvent,
+			IntentID:      intent.ID,
+			Status:        intent.Status,
+			SyncedCommits: intent.SyncedCommits,
+			Repository:    to,
+			RenamedFrom:   from,
+			At:            time.Now(),
+		})
+	}
+	return nil
+}
+
+// pauseIntents deactivates the active intents of repoName, logging why,
+// and returns how many it paused. The monitor reports a repository as
+// archived from the same run that fetches its commits, so that run is the
+// final sync and the intents of archived repositories are only paused once
+// it has completed.
+func (svc *Service) pauseIntents(ctx context.Context, repoName, reason string) (int, error) {
+	isActive := true
+	intents, err := svc.store.FindIntents(ctx, models.IntentFilter{
+		RepositoryName: &repoName,
+		IsActive:       &isActive,
+	}, repository.Pagination{Page: 1, PerPage: 100})
+	if err != nil {
+		return 0, err
+	}
+
+	for i, intent := range intents.Data {
+		inactive := false
+		update, err := svc.transitionIntent(ctx, &intent, models.Paused, models.IntentUpdate{
+			IsActive: &inactive,
+		})
+		if err != nil {
+			return i, err
+		}
+		log.Printf("paused intent %s: %s", intent.ID, reason)
+		if err := svc.queueIntent(ctx, events.CancelIntentKind, newIntentPayload(update)); err != nil {
+			return i, err
+		}
+	}
+	return len(intents.Data), nil
+}
+
+// queueIntent adds an intent command for discovery to the outbox and wakes
+// the broadcaster, so callers never wait on the broker.
+func (svc *Service) queueIntent(ctx context.Context, kind events.IntentKind, payload *events.IntentPayload) error {
+	return svc.queueIntentTo(ctx, "", kind, payload)
+}
+
+// queueIntentTo is queueIntent for the given queue, where an empty queue
+// is discovery's.
+func (svc *Service) queueIntentTo(ctx context.Context, queue string, kind events.IntentKind, payload *events.IntentPayload) error {
+	body, err := encodeIntentCommand(kind, payload)
+	if err != nil {
+		return err
+	}
+	if err := svc.store.EnqueueIntentCommand(ctx, payload.ID, queue, body); err != nil {
+		return fmt.Errorf("failed to queue intent: %w", err)
+	}
+	svc.wakeBroadcast()
+	return nil
+}
+
+func encodeIntentCommand(kind events.IntentKind, payload *events.IntentPayload) ([]byte, error) {
+	body, err := json.Marshal(events.NewIntentCommand(kind, payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal intent: %w", err)
+	}
+	return body, nil
+}
+
+// wakeBroadcast has the broadcaster check the outbox now rather than at
+// its next tick.
+func (svc *Service) wakeBroadcast() {
+	select {
+	case svc.dispatch <- struct{}{}:
+	default:
+	}
+}
+
+// StartBroadCast publishes the queued intent commands as they arrive until
+// ctx is done. It also checks the outbox every dispatchInterval for failed
+// publishes and other replicas' commands.
+func (svc *Service) StartBroadCast(ctx context.Context, b broker.Broker) error {
+	ticker := time.NewTicker(dispatchInterval)
+	defer ticker.Stop()
+
 	for {
+		svc.dispatchIntents(ctx, b)
+
 		select {
-		case v, ok := <-svc.intentsChan:
-			if !ok {
-				return nil
-			}
+		case <-svc.dispatch:
+		case <-ticker.C:
+		case <-ctx.Done():
+			log.Println("context cancelled, stopping broadcast")
+			return ctx.Err()
+		}
+	}
+}
 
-			body, err := json.Marshal(v)
-			if err != nil {
-				log.Printf("failed to marshal intent: %v", err)
-				continue
+// dispatchIntents publishes the outbox until no command is due. Commands
+// that fail to publish are left to their backoff.
+func (svc *Service) dispatchIntents(ctx context.Context, b broker.Broker) {
+	for {
+		claimed, err := svc.store.DispatchIntentCommands(ctx, dispatchBatchSize, func(queue string, body []byte) error {
+			if queue != "" {
+				// Straight to the monitor, which reports the run itself.
+				return b.Publish(ctx, queue, body)
 			}
-
-			err = ch.PublishWithContext(ctx,
-				"",
-				svc.cfg.IntentsQueueName,
-				false,
-				false,
-				amqp.Publishing{
-					ContentType: "application/json",
-					Body:        body,
-				})
-			if err != nil {
+			if err := b.Publish(ctx, svc.cfg.IntentsQueueName, body); err != nil {
 				log.Printf("failed to publish message: %v", err)
-				continue
+				return err
 			}
 
-			newStatus := models.SuccessBroadCast
-			_, err = svc.store.UpdateIntent(ctx, models.IntentUpdate{
-				ID:     v.Intent.ID,
-				Status: &newStatus,
-			})
-			if err != nil {
-				return err
+			var command events.IntentCommand
+			if err := json.Unmarshal(body, &command); err != nil || command.Intent == nil {
+				return nil
 			}
-		case <-ctx.Done():
-			log.Println("context cancelled, stopping broadcast")
-			return ctx.Err()
+			// Cancellations leave the intent paused.
+			if command.Kind == events.CancelIntentKind {
+				return nil
+			}
+			if err := svc.markBroadcast(ctx, command.Intent.ID); err != nil {
+				log.Printf("failed to mark intent %s as broadcast: %v", command.Intent.ID, err)
+			}
+			return nil
+		})
+		if err != nil {
+			log.Printf("failed to dispatch intents: %v", err)
+			return
+		}
+		if claimed < dispatchBatchSize {
+			return
 		}
 	}
 }
 
+func (svc *Service) markBroadcast(ctx context.Context, id uuid.UUID) error {
+	intent, err := svc.findIntent(ctx, id)
+	if err != nil {
+		return err
+	}
+	_, err = svc.transitionIntent(ctx, intent, models.Broadcast, models.IntentUpdate{})
+	return err
+}
+
+func newIntentPayload(intent *models.Intent) *events.IntentPayload {
+	owner, name, _ := strings.Cut(intent.RepositoryName, "/")
+	payload := &events.IntentPayload{
+		ID:            intent.ID,
+		RepoOwner:     owner,
+		RepoName:      name,
+		IntentOptions: intent.IntentOptions,
+	}
+	if intent.StartDate != nil {
+		payload.From = *intent.StartDate
+	}
+	if intent.Until != nil {
+		payload.Until = *intent.Until
+	}
+	return payload
+}
+
+// normalizeRepositoryName lowercases name, since GitHub treats owner and
+// repository names case-insensitively.
+func normalizeRepositoryName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
 func validateRepositoryName(name string) error {
 	parts := strings.Split(name, "/")
 	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
@@ -331,3 +915,54 @@ func validateStartDate(date time.Time) error {
 	}
 	return nil
 }
+
+// validateEndDate accepts an end date on the start date, as the end date
+// includes its whole day.
+func validateEndDate(start, end time.Time) error {
+	if !end.IsZero() && end.Before(start) {
+		return ErrInvalidEndDate
+	}
+	return nil
+}
+
+func validateRetryPolicy(policy *models.RetryPolicy) error {
+	if policy == nil {
+		return nil
+	}
+	if policy.MaxAttempts != nil && (*policy.MaxAttempts < 1 || *policy.MaxAttempts > 10) {
+		return ErrInvalidRetryPolicy
+	}
+	if policy.BackoffBaseMs != nil && (*policy.BackoffBaseMs < 0 || *policy.BackoffBaseMs > 600000) {
+		return ErrInvalidRetryPolicy
+	}
+	if policy.Jitter != nil && (*policy.Jitter < 0 || *policy.Jitter > 1) {
+		return ErrInvalidRetryPolicy
+	}
+	return nil
+}
+
+// normalizePathFilters reduces filters such as "services/payments/**" to the
+// plain prefix GitHub's commit listing accepts as its path parameter.
+func normalizePathFilters(filters []string) ([]string, error) {
+	paths := make([]string, 0, len(filters))
+	for _, filter := range filters {
+		path := strings.Trim(strings.TrimSpace(filter), "/")
+		path = strings.TrimSuffix(path, "/**")
+		path = strings.TrimSuffix(path, "/*")
+		if path == "" || path == "**" || path == "*" || strings.ContainsAny(path, "*?[") {
+			return nil, ErrInvalidPathFilter
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+func normalizeAuthorFilters(filters []string) []string {
+	authors := make([]string, 0, len(filters))
+	for _, filter := range filters {
+		if author := strings.TrimSpace(filter); author != "" {
+			authors = append(authors, author)
+		}
+	}
+	return authors
+}
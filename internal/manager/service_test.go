@@ -1,300 +1,3647 @@
 package manager_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/noelukwa/indexer/internal/events"
 	"github.com/noelukwa/indexer/internal/manager"
 	"github.com/noelukwa/indexer/internal/manager/models"
 	"github.com/noelukwa/indexer/internal/manager/repository"
+	"github.com/noelukwa/indexer/internal/pkg/apilimits"
+	"github.com/noelukwa/indexer/internal/pkg/broker"
 	"github.com/noelukwa/indexer/internal/pkg/config"
+	"github.com/noelukwa/indexer/internal/pkg/objectstore"
+	"github.com/noelukwa/indexer/internal/pkg/remotewrite"
+	"github.com/noelukwa/indexer/internal/pkg/secrets"
 	"github.com/stretchr/testify/mock"
 	"github.com/test-go/testify/assert"
 )
 
 type MockStore struct {
 	mock.Mock
+
+	// outbox holds queued intent commands in memory, so tests don't have
+	// to expect every enqueue.
+	outboxMu sync.Mutex
+	outbox   []outboxCommand
+
+	// forcedAt holds the forced broadcast cooldowns in memory, like the
+	// outbox.
+	forcedMu sync.Mutex
+	forcedAt map[uuid.UUID]time.Time
+}
+
+type outboxCommand struct {
+	queue   string
+	command []byte
+}
+
+func (m *MockStore) EnqueueIntentCommand(ctx context.Context, intentID uuid.UUID, queue string, command []byte) error {
+	m.outboxMu.Lock()
+	defer m.outboxMu.Unlock()
+	m.outbox = append(m.outbox, outboxCommand{queue, command})
+	return nil
+}
+
+// DispatchIntentCommands keeps the commands that fail to publish, with no
+// backoff, and leaves them out of the count so the broadcaster doesn't
+// retry them straight away.
+func (m *MockStore) DispatchIntentCommands(ctx context.Context, limit int, publish func(queue string, command []byte) error) (int, error) {
+	m.outboxMu.Lock()
+	defer m.outboxMu.Unlock()
+	claimed := min(len(m.outbox), limit)
+	var failed []outboxCommand
+	for _, command := range m.outbox[:claimed] {
+		if err := publish(command.queue, command.command); err != nil {
+			failed = append(failed, command)
+		}
+	}
+	m.outbox = append(failed, m.outbox[claimed:]...)
+	return claimed - len(failed), nil
+}
+
+func (m *MockStore) ClaimForcedBroadcast(ctx context.Context, intentID uuid.UUID, cooldown time.Duration) (time.Time, bool, error) {
+	m.forcedMu.Lock()
+	defer m.forcedMu.Unlock()
+	now := time.Now()
+	if at, ok := m.forcedAt[intentID]; ok && now.Sub(at) < cooldown {
+		return time.Time{}, false, nil
+	}
+	if m.forcedAt == nil {
+		m.forcedAt = make(map[uuid.UUID]time.Time)
+	}
+	m.forcedAt[intentID] = now
+	return now, true, nil
+}
+
+func (m *MockStore) ReleaseForcedBroadcast(ctx context.Context, intentID uuid.UUID, forcedAt time.Time) error {
+	m.forcedMu.Lock()
+	defer m.forcedMu.Unlock()
+	if m.forcedAt[intentID].Equal(forcedAt) {
+		delete(m.forcedAt, intentID)
+	}
+	return nil
+}
+
+func (m *MockStore) SaveIntent(ctx context.Context, freshIntent models.Intent) (*models.Intent, error) {
+	args := m.Called(ctx, freshIntent)
+	return args.Get(0).(*models.Intent), args.Error(1)
+}
+
+// SaveQueuedIntent is expected as SaveIntent, and queues command when the
+// save succeeds.
+func (m *MockStore) SaveQueuedIntent(ctx context.Context, freshIntent models.Intent, command []byte) (*models.Intent, error) {
+	intent, err := m.SaveIntent(ctx, freshIntent)
+	if err == nil {
+		m.EnqueueIntentCommand(ctx, freshIntent.ID, "", command)
+	}
+	return intent, err
+}
+
+func (m *MockStore) UpdateIntent(ctx context.Context, update models.IntentUpdate) (*models.Intent, error) {
+	args := m.Called(ctx, update)
+	return args.Get(0).(*models.Intent), args.Error(1)
+}
+
+func (m *MockStore) SaveIntentError(ctx context.Context, err models.IntentError) error {
+	args := m.Called(ctx, err)
+	return args.Error(0)
+}
+
+func (m *MockStore) SaveIntentProgress(ctx context.Context, intentID uuid.UUID, progress models.IntentProgress) error {
+	args := m.Called(ctx, intentID, progress)
+	return args.Error(0)
+}
+
+func (m *MockStore) GetIntentProgress(ctx context.Context, intentID uuid.UUID) (*models.IntentProgress, error) {
+	args := m.Called(ctx, intentID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.IntentProgress), args.Error(1)
+}
+
+func (m *MockStore) FindIntents(ctx context.Context, filter models.IntentFilter, pag repository.Pagination) (repository.Paginated[models.Intent], error) {
+	args := m.Called(ctx, filter, pag)
+	return args.Get(0).(repository.Paginated[models.Intent]), args.Error(1)
+}
+
+func (m *MockStore) FindRepos(ctx context.Context, filter models.RepositoryFilter, pag repository.Pagination) (repository.Paginated[models.Repository], error) {
+	args := m.Called(ctx, filter, pag)
+	return args.Get(0).(repository.Paginated[models.Repository]), args.Error(1)
+}
+
+func (m *MockStore) CreateReindex(ctx context.Context, intentID uuid.UUID, repoName string) (*models.Reindex, error) {
+	args := m.Called(ctx, intentID, repoName)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Reindex), args.Error(1)
+}
+
+func (m *MockStore) GetLatestReindex(ctx context.Context, intentID uuid.UUID) (*models.Reindex, error) {
+	args := m.Called(ctx, intentID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Reindex), args.Error(1)
+}
+
+func (m *MockStore) SaveShadowCommits(ctx context.Context, id uuid.UUID, commits []*models.Commit) error {
+	args := m.Called(ctx, id, commits)
+	return args.Error(0)
+}
+
+func (m *MockStore) SetReindexExpected(ctx context.Context, id uuid.UUID, commits int64) error {
+	args := m.Called(ctx, id, commits)
+	return args.Error(0)
+}
+
+func (m *MockStore) FailReindex(ctx context.Context, id uuid.UUID, reason string) error {
+	args := m.Called(ctx, id, reason)
+	return args.Error(0)
+}
+
+func (m *MockStore) SwapReindex(ctx context.Context, id uuid.UUID, minRatio float64) (*models.Reindex, error) {
+	args := m.Called(ctx, id, minRatio)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Reindex), args.Error(1)
+}
+
+func (m *MockStore) GetChurn(ctx context.Context, filter models.CommitsFilter) (*models.Churn, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Churn), args.Error(1)
+}
+
+func (m *MockStore) GetCommitActivity(ctx context.Context, filter models.CommitsFilter, interval models.ActivityInterval) ([]models.ActivityBucket, error) {
+	args := m.Called(ctx, filter, interval)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.ActivityBucket), args.Error(1)
+}
+
+func (m *MockStore) GetDailyCommits(ctx context.Context, filter models.CommitsFilter) ([]models.DailyCommits, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.DailyCommits), args.Error(1)
+}
+
+func (m *MockStore) GetContributions(ctx context.Context, filter models.CommitsFilter) ([]models.MonthlyContribution, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.MonthlyContribution), args.Error(1)
+}
+
+func (m *MockStore) RollupCommits(ctx context.Context, limit int) (map[string]int64, error) {
+	args := m.Called(ctx, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]int64), args.Error(1)
+}
+
+func (m *MockStore) GetRepoStats(ctx context.Context, filter models.CommitsFilter) (*models.RepoStats, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.RepoStats), args.Error(1)
+}
+
+func (m *MockStore) SaveReviews(ctx context.Context, repoID int64, reviews []*models.PullRequestReview) error {
+	args := m.Called(ctx, repoID, reviews)
+	return args.Error(0)
+}
+
+func (m *MockStore) GetReviewTurnaround(ctx context.Context, repoID int64, startDate, endDate *time.Time) (*models.ReviewTurnaround, error) {
+	args := m.Called(ctx, repoID, startDate, endDate)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ReviewTurnaround), args.Error(1)
+}
+
+func (m *MockStore) GetTopReviewers(ctx context.Context, repoID int64, startDate, endDate *time.Time, pagination repository.Pagination) (repository.Paginated[models.ReviewerStats], error) {
+	args := m.Called(ctx, repoID, startDate, endDate, pagination)
+	return args.Get(0).(repository.Paginated[models.ReviewerStats]), args.Error(1)
+}
+
+func (m *MockStore) SaveWorkflowRuns(ctx context.Context, repoID int64, runs []*models.WorkflowRun) error {
+	args := m.Called(ctx, repoID, runs)
+	return args.Error(0)
+}
+
+func (m *MockStore) GetCIStats(ctx context.Context, filter models.CIFilter) (*models.CIStats, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CIStats), args.Error(1)
+}
+
+func (m *MockStore) GetQuality(ctx context.Context, repoID int64) (*models.QualityReport, error) {
+	args := m.Called(ctx, repoID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.QualityReport), args.Error(1)
+}
+
+func (m *MockStore) GetCoverageGaps(ctx context.Context, repoID int64, startDate, endDate time.Time, minDays int) ([]models.CoverageGap, error) {
+	args := m.Called(ctx, repoID, startDate, endDate, minDays)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.CoverageGap), args.Error(1)
+}
+
+func (m *MockStore) SaveGitHubStats(ctx context.Context, repoID int64, stats *models.GitHubStats) error {
+	args := m.Called(ctx, repoID, stats)
+	return args.Error(0)
+}
+
+func (m *MockStore) GetGitHubStats(ctx context.Context, repoID int64) (*models.GitHubStats, error) {
+	args := m.Called(ctx, repoID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.GitHubStats), args.Error(1)
+}
+
+func (m *MockStore) SaveSecurityAlerts(ctx context.Context, repoID int64, alerts []*models.SecurityAlert) error {
+	args := m.Called(ctx, repoID, alerts)
+	return args.Error(0)
+}
+
+func (m *MockStore) GetOpenAlertsSummary(ctx context.Context) (*models.SecurityAlertSummary, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SecurityAlertSummary), args.Error(1)
+}
+
+func (m *MockStore) RefreshLeaderboards(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *MockStore) DeleteIntent(ctx context.Context, id uuid.UUID) (*models.Intent, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Intent), args.Error(1)
+}
+
+func (m *MockStore) PurgeRepoCommits(ctx context.Context, repo string) (int64, error) {
+	args := m.Called(ctx, repo)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockStore) DeleteRepo(ctx context.Context, id int64) (*models.Repository, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Repository), args.Error(1)
+}
+
+func (m *MockStore) FindDeletedRepos(ctx context.Context, before time.Time) ([]models.Repository, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).([]models.Repository), args.Error(1)
+}
+
+func (m *MockStore) PurgeRepo(ctx context.Context, id int64) (int64, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockStore) FindIntent(ctx context.Context, id uuid.UUID) (*models.Intent, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Intent), args.Error(1)
+}
+
+func (m *MockStore) SaveIntentTransition(ctx context.Context, transition models.IntentTransition, keep int) error {
+	args := m.Called(ctx, transition, keep)
+	return args.Error(0)
+}
+
+func (m *MockStore) FindChildIntentRepos(ctx context.Context, parentID uuid.UUID) ([]string, error) {
+	args := m.Called(ctx, parentID)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockStore) FindIntentTransitions(ctx context.Context, intentID uuid.UUID) ([]models.IntentTransition, error) {
+	args := m.Called(ctx, intentID)
+	return args.Get(0).([]models.IntentTransition), args.Error(1)
+}
+
+func (m *MockStore) SaveRepo(ctx context.Context, repo *models.Repository) error {
+	args := m.Called(ctx, repo)
+	return args.Error(0)
+}
+
+func (m *MockStore) AddRepoTenant(ctx context.Context, repoID int64, intentID uuid.UUID) error {
+	args := m.Called(ctx, repoID, intentID)
+	return args.Error(0)
+}
+
+func (m *MockStore) RenameRepo(ctx context.Context, id int64, name string) (string, error) {
+	args := m.Called(ctx, id, name)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockStore) Search(ctx context.Context, query string, pagination repository.Pagination) (*models.SearchResults, error) {
+	args := m.Called(ctx, query, pagination)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SearchResults), args.Error(1)
+}
+
+func (m *MockStore) SearchCommitMessages(ctx context.Context, filter models.CommitSearchFilter, pagination repository.Pagination) (repository.Paginated[models.CommitMatch], error) {
+	args := m.Called(ctx, filter, pagination)
+	return args.Get(0).(repository.Paginated[models.CommitMatch]), args.Error(1)
+}
+
+func (m *MockStore) GetAuthorProfile(ctx context.Context, username string) (*models.AuthorProfile, error) {
+	args := m.Called(ctx, username)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AuthorProfile), args.Error(1)
+}
+
+func (m *MockStore) GetLanguageStats(ctx context.Context) ([]models.LanguageStats, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.LanguageStats), args.Error(1)
+}
+
+func (m *MockStore) GetActiveRepos(ctx context.Context, filter models.RepoActivityFilter, pagination repository.Pagination) (repository.Paginated[models.RepoActivity], error) {
+	args := m.Called(ctx, filter, pagination)
+	return args.Get(0).(repository.Paginated[models.RepoActivity]), args.Error(1)
+}
+
+func (m *MockStore) GetRepo(ctx context.Context, name string) (*models.Repository, error) {
+	args := m.Called(ctx, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Repository), args.Error(1)
+}
+
+func (m *MockStore) FindCommits(ctx context.Context, filter models.CommitsFilter, pag repository.Pagination) (repository.Paginated[models.Commit], error) {
+	args := m.Called(ctx, filter, pag)
+	return args.Get(0).(repository.Paginated[models.Commit]), args.Error(1)
+}
+
+func (m *MockStore) FindCommitCursor(ctx context.Context, repoID int64, hash string) (*models.CommitCursor, error) {
+	args := m.Called(ctx, repoID, hash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CommitCursor), args.Error(1)
+}
+
+func (m *MockStore) FindCommitsAfter(ctx context.Context, repoID int64, after models.CommitCursor, limit int) ([]models.Commit, error) {
+	args := m.Called(ctx, repoID, after, limit)
+	return args.Get(0).([]models.Commit), args.Error(1)
+}
+
+func (m *MockStore) GetTopCommitters(ctx context.Context, repo string, startDate, endDate *time.Time, pagination repository.Pagination) (repository.Paginated[models.AuthorStats], error) {
+	args := m.Called(ctx, repo, startDate, endDate, pagination)
+	return args.Get(0).(repository.Paginated[models.AuthorStats]), args.Error(1)
+}
+
+func (m *MockStore) SaveManyCommit(ctx context.Context, repoID int64, commits []*models.Commit) error {
+	args := m.Called(ctx, repoID, commits)
+	return args.Error(0)
+}
+
+func (m *MockStore) FindCommitComments(ctx context.Context, repoID int64, hash string) ([]models.CommitComment, error) {
+	args := m.Called(ctx, repoID, hash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.CommitComment), args.Error(1)
+}
+
+func (m *MockStore) GetLargestCommits(ctx context.Context, filter models.CommitsFilter, limit int) ([]models.DigestCommit, error) {
+	args := m.Called(ctx, filter, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.DigestCommit), args.Error(1)
+}
+
+func (m *MockStore) SaveDigestSubscription(ctx context.Context, sub models.DigestSubscription) (*models.DigestSubscription, error) {
+	args := m.Called(ctx, sub)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.DigestSubscription), args.Error(1)
+}
+
+func (m *MockStore) FindDigestSubscriptions(ctx context.Context) ([]models.DigestSubscription, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]models.DigestSubscription), args.Error(1)
+}
+
+func (m *MockStore) DeleteDigestSubscription(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockStore) ClaimDigestSubscriptions(ctx context.Context, week time.Time, limit int) ([]models.DigestSubscription, error) {
+	args := m.Called(ctx, week, limit)
+	return args.Get(0).([]models.DigestSubscription), args.Error(1)
+}
+
+func (m *MockStore) SaveAuthor(ctx context.Context, author *models.Author) error {
+	args := m.Called(ctx, author)
+	return args.Error(0)
+}
+
+func (m *MockStore) GetIngestionStatus(ctx context.Context, errorLimit int) (*models.IngestionStatus, error) {
+	args := m.Called(ctx, errorLimit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.IngestionStatus), args.Error(1)
+}
+
+func (m *MockStore) SaveSession(ctx context.Context, tokenHash string, session models.Session) (*models.Session, error) {
+	args := m.Called(ctx, tokenHash, session)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Session), args.Error(1)
+}
+
+func (m *MockStore) FindSession(ctx context.Context, tokenHash string) (*models.Session, error) {
+	args := m.Called(ctx, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Session), args.Error(1)
+}
+
+func (m *MockStore) DeleteExpiredSessions(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockStore) DeleteSession(ctx context.Context, tokenHash string) error {
+	args := m.Called(ctx, tokenHash)
+	return args.Error(0)
+}
+
+func (m *MockStore) SaveAPIKey(ctx context.Context, keyHash string, key models.APIKey) (*models.APIKey, error) {
+	args := m.Called(ctx, keyHash, key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.APIKey), args.Error(1)
+}
+
+func (m *MockStore) FindAPIKey(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	args := m.Called(ctx, keyHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.APIKey), args.Error(1)
+}
+
+func (m *MockStore) FindAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]models.APIKey), args.Error(1)
+}
+
+func (m *MockStore) RevokeAPIKey(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockStore) TouchAPIKey(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockStore) FindArchivableRepos(ctx context.Context, before time.Time) ([]models.Repository, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).([]models.Repository), args.Error(1)
+}
+
+func (m *MockStore) ClaimRepoArchive(ctx context.Context, repoID int64, before, leasedUntil time.Time) (bool, error) {
+	args := m.Called(ctx, repoID, before, leasedUntil)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockStore) ReleaseRepoArchive(ctx context.Context, repoID int64) error {
+	args := m.Called(ctx, repoID)
+	return args.Error(0)
+}
+
+func (m *MockStore) FindArchivableCommits(ctx context.Context, repoID int64, before time.Time, limit int) ([]models.Commit, error) {
+	args := m.Called(ctx, repoID, before, limit)
+	return args.Get(0).([]models.Commit), args.Error(1)
+}
+
+func (m *MockStore) ArchiveCommits(ctx context.Context, archive models.CommitArchive, hashes []string) error {
+	args := m.Called(ctx, archive, hashes)
+	return args.Error(0)
+}
+
+func (m *MockStore) FindCommitArchives(ctx context.Context, repoID int64) ([]models.CommitArchive, error) {
+	args := m.Called(ctx, repoID)
+	return args.Get(0).([]models.CommitArchive), args.Error(1)
+}
+
+func (m *MockStore) GetArchiveSummary(ctx context.Context, repoID int64) (*models.ArchiveNotice, error) {
+	args := m.Called(ctx, repoID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ArchiveNotice), args.Error(1)
+}
+
+func (m *MockStore) SaveCredential(ctx context.Context, credential models.Credential) (*models.Credential, error) {
+	args := m.Called(ctx, credential)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Credential), args.Error(1)
+}
+
+func (m *MockStore) FindCredential(ctx context.Context, id uuid.UUID) (*models.Credential, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Credential), args.Error(1)
+}
+
+func (m *MockStore) FindCredentials(ctx context.Context) ([]models.Credential, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]models.Credential), args.Error(1)
+}
+
+// testCredentialsKey is a base64 encoded 32 byte key.
+const testCredentialsKey = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+
+// Helper function to create a new service instance
+func newTestService(store repository.ManagerStore) *manager.Service {
+	cfg := &config.ManagerConfig{
+		IntentsQueueName: "test-queue",
+		GitHubOrgRoles: map[string]string{
+			"Acme":    "admin",
+			"friends": "viewer",
+		},
+		SessionTTL:     time.Hour,
+		CredentialsKey: testCredentialsKey,
+	}
+	return manager.NewService(store, nil, nil, nil, cfg)
+}
+
+func TestCreateIntent(t *testing.T) {
+
+	store := new(MockStore)
+	service := newTestService(store)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	repoName := "owner/repo"
+	startDate := time.Now().Add(-time.Hour)
+	intent := &models.Intent{
+		Status:         models.Created,
+		IsActive:       true,
+		ID:             uuid.New(),
+		RepositoryName: repoName,
+		StartDate:      &startDate,
+	}
+
+	store.On("FindIntents", ctx, mock.AnythingOfType("models.IntentFilter"), mock.Anything).Return(repository.Paginated[models.Intent]{}, nil).Once()
+	store.On("SaveIntentTransition", ctx, mock.MatchedBy(func(tr models.IntentTransition) bool {
+		return tr.From == ""
+	}), mock.Anything).Return(nil).Maybe()
+	store.On("SaveIntent", ctx, mock.AnythingOfType("models.Intent")).Return(intent, nil).Once()
+
+	result, err := service.CreateIntent(ctx, repoName, startDate, time.Time{}, models.IntentOptions{})
+	assert.NoError(t, err)
+	assert.NotNil(t, result)
+	assert.Equal(t, repoName, result.RepositoryName)
+}
+
+func TestCreateIntent_NormalizesRepositoryName(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	store.On("FindIntents", ctx, mock.MatchedBy(func(f models.IntentFilter) bool {
+		return *f.RepositoryName == "owner/repo"
+	}), mock.Anything).Return(repository.Paginated[models.Intent]{}, nil).Once()
+	store.On("SaveIntentTransition", ctx, mock.MatchedBy(func(tr models.IntentTransition) bool {
+		return tr.From == ""
+	}), mock.Anything).Return(nil).Maybe()
+	store.On("SaveIntent", ctx, mock.MatchedBy(func(i models.Intent) bool {
+		return i.RepositoryName == "owner/repo"
+	})).Return(&models.Intent{RepositoryName: "owner/repo"}, nil).Once()
+
+	_, err := service.CreateIntent(ctx, " Owner/Repo ", time.Now().Add(-time.Hour), time.Time{}, models.IntentOptions{})
+	assert.NoError(t, err)
+	store.AssertExpectations(t)
+}
+
+func TestCreateIntent_ExistingIntent(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	existingID := uuid.New()
+	store.On("FindIntents", ctx, mock.AnythingOfType("models.IntentFilter"), mock.Anything).
+		Return(repository.Paginated[models.Intent]{Data: []models.Intent{{ID: existingID}}, TotalCount: 1}, nil).Once()
+
+	result, err := service.CreateIntent(ctx, "owner/repo", time.Now().Add(-time.Hour), time.Time{}, models.IntentOptions{})
+	assert.Nil(t, result)
+	assert.True(t, errors.Is(err, manager.ErrExistingIntent))
+	assert.True(t, errors.Is(err, manager.ErrConflict))
+	var existing *manager.ExistingIntentError
+	if assert.True(t, errors.As(err, &existing)) {
+		assert.Equal(t, existingID, existing.IntentID)
+	}
+	store.AssertNotCalled(t, "SaveIntent", mock.Anything, mock.Anything)
+}
+
+func TestCreateIntent_DuplicateOnSave(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	store.On("FindIntents", ctx, mock.AnythingOfType("models.IntentFilter"), mock.Anything).Return(repository.Paginated[models.Intent]{}, nil).Once()
+	store.On("SaveIntentTransition", ctx, mock.MatchedBy(func(tr models.IntentTransition) bool {
+		return tr.From == ""
+	}), mock.Anything).Return(nil).Maybe()
+	store.On("SaveIntent", ctx, mock.AnythingOfType("models.Intent")).Return((*models.Intent)(nil), repository.ErrConflict).Once()
+	// The intent created by the other request is looked up for its ID.
+	existingID := uuid.New()
+	store.On("FindIntents", ctx, mock.AnythingOfType("models.IntentFilter"), mock.Anything).
+		Return(repository.Paginated[models.Intent]{Data: []models.Intent{{ID: existingID}}, TotalCount: 1}, nil).Once()
+
+	result, err := service.CreateIntent(ctx, "owner/repo", time.Now().Add(-time.Hour), time.Time{}, models.IntentOptions{})
+	assert.Nil(t, result)
+	var existing *manager.ExistingIntentError
+	if assert.True(t, errors.As(err, &existing)) {
+		assert.Equal(t, existingID, existing.IntentID)
+	}
+}
+
+func TestUpsertIntent_ExtendsExisting(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	existingID := uuid.New()
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	earlier := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	intent := &models.Intent{ID: existingID, RepositoryName: "owner/repo", StartDate: &start, Until: &until, IsActive: true, Status: models.Completed}
+	extended := *intent
+	extended.StartDate = &earlier
+	extended.Until = nil
+
+	store.On("FindIntents", ctx, mock.AnythingOfType("models.IntentFilter"), mock.Anything).
+		Return(repository.Paginated[models.Intent]{Data: []models.Intent{*intent}, TotalCount: 1}, nil).Once()
+	store.On("FindIntent", ctx, existingID).Return(intent, nil).Once()
+	// An earlier since moves the start back and no until opens the end.
+	store.On("UpdateIntent", ctx, models.IntentUpdate{ID: existingID, StartDate: &earlier, OpenEnded: true}).Return(&extended, nil).Once()
+
+	result, created, err := service.UpsertIntent(ctx, "owner/repo", earlier, time.Time{}, models.IntentOptions{})
+	assert.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, existingID, result.ID)
+	assert.Len(t, store.outbox, 1)
+	assert.Contains(t, string(store.outbox[0].command), `"kind":"update_intent"`)
+	store.AssertExpectations(t)
+}
+
+func TestUpsertIntent_RangeAlreadyCovered(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	existingID := uuid.New()
+	intent := &models.Intent{ID: existingID, RepositoryName: "owner/repo", IsActive: true, Status: models.Completed}
+
+	// An intent of the full history already covers any range.
+	store.On("FindIntents", ctx, mock.AnythingOfType("models.IntentFilter"), mock.Anything).
+		Return(repository.Paginated[models.Intent]{Data: []models.Intent{*intent}, TotalCount: 1}, nil).Once()
+	store.On("FindIntent", ctx, existingID).Return(intent, nil).Once()
+
+	result, created, err := service.UpsertIntent(ctx, "owner/repo", time.Now().AddDate(0, -1, 0), time.Time{}, models.IntentOptions{})
+	assert.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, existingID, result.ID)
+	assert.Empty(t, store.outbox)
+	store.AssertNotCalled(t, "UpdateIntent", mock.Anything, mock.Anything)
+}
+
+func TestCreateIntent_InvalidRepoName(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	repoName := "invalid-repo"
+	startDate := time.Now().Add(-time.Hour)
+
+	result, err := service.CreateIntent(ctx, repoName, startDate, time.Time{}, models.IntentOptions{})
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	assert.Equal(t, manager.ErrInvalidRepository, err)
+}
+
+func TestCreateIntent_PathFilters(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	intent := &models.Intent{ID: uuid.New(), RepositoryName: "owner/repo"}
+	store.On("FindIntents", ctx, mock.AnythingOfType("models.IntentFilter"), mock.Anything).Return(repository.Paginated[models.Intent]{}, nil).Once()
+	store.On("SaveIntentTransition", ctx, mock.MatchedBy(func(tr models.IntentTransition) bool {
+		return tr.From == ""
+	}), mock.Anything).Return(nil).Maybe()
+	store.On("SaveIntent", ctx, mock.MatchedBy(func(i models.Intent) bool {
+		return assert.ObjectsAreEqual([]string{"services/payments", "docs"}, i.PathFilters)
+	})).Return(intent, nil).Once()
+
+	opts := models.IntentOptions{PathFilters: []string{"services/payments/**", " /docs/ "}}
+	_, err := service.CreateIntent(ctx, "owner/repo", time.Now().Add(-time.Hour), time.Time{}, opts)
+	assert.NoError(t, err)
+	store.AssertExpectations(t)
+}
+
+func TestCreateIntent_AuthorFilters(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	intent := &models.Intent{ID: uuid.New(), RepositoryName: "owner/repo"}
+	store.On("FindIntents", ctx, mock.AnythingOfType("models.IntentFilter"), mock.Anything).Return(repository.Paginated[models.Intent]{}, nil).Once()
+	store.On("SaveIntentTransition", ctx, mock.MatchedBy(func(tr models.IntentTransition) bool {
+		return tr.From == ""
+	}), mock.Anything).Return(nil).Maybe()
+	store.On("SaveIntent", ctx, mock.MatchedBy(func(i models.Intent) bool {
+		return assert.ObjectsAreEqual([]string{"octocat", "dev@example.com"}, i.AuthorFilters)
+	})).Return(intent, nil).Once()
+
+	opts := models.IntentOptions{AuthorFilters: []string{" octocat", "", "dev@example.com "}}
+	_, err := service.CreateIntent(ctx, "owner/repo", time.Now().Add(-time.Hour), time.Time{}, opts)
+	assert.NoError(t, err)
+	store.AssertExpectations(t)
+}
+
+func TestCreateIntent_InvalidPathFilter(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	for _, filter := range []string{"", "**", "services/*/api"} {
+		opts := models.IntentOptions{PathFilters: []string{filter}}
+		result, err := service.CreateIntent(ctx, "owner/repo", time.Now().Add(-time.Hour), time.Time{}, opts)
+		assert.Nil(t, result)
+		assert.Equal(t, manager.ErrInvalidPathFilter, err)
+	}
+}
+
+func TestCreateIntent_BranchAndExcludedAuthors(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	intent := &models.Intent{ID: uuid.New(), RepositoryName: "owner/repo"}
+	store.On("FindIntents", ctx, mock.AnythingOfType("models.IntentFilter"), mock.Anything).Return(repository.Paginated[models.Intent]{}, nil).Once()
+	store.On("SaveIntentTransition", ctx, mock.MatchedBy(func(tr models.IntentTransition) bool {
+		return tr.From == ""
+	}), mock.Anything).Return(nil).Maybe()
+	store.On("SaveIntent", ctx, mock.MatchedBy(func(i models.Intent) bool {
+		return i.Branch == "release/v2" && assert.ObjectsAreEqual([]string{"dependabot[bot]"}, i.ExcludedAuthors)
+	})).Return(intent, nil).Once()
+
+	opts := models.IntentOptions{Branch: " release/v2 ", ExcludedAuthors: []string{"dependabot[bot] ", " "}}
+	_, err := service.CreateIntent(ctx, "owner/repo", time.Now().Add(-time.Hour), time.Time{}, opts)
+	assert.NoError(t, err)
+	store.AssertExpectations(t)
+}
+
+func TestCreateIntent_BranchWithAllBranches(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	opts := models.IntentOptions{Branch: "main", IndexAllBranches: true}
+	result, err := service.CreateIntent(ctx, "owner/repo", time.Now().Add(-time.Hour), time.Time{}, opts)
+	assert.Nil(t, result)
+	assert.Equal(t, manager.ErrInvalidBranch, err)
+}
+
+func TestCreateIntent_FullHistory(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	intent := &models.Intent{ID: uuid.New(), RepositoryName: "owner/repo"}
+	store.On("FindIntents", ctx, mock.AnythingOfType("models.IntentFilter"), mock.Anything).Return(repository.Paginated[models.Intent]{}, nil).Once()
+	store.On("SaveIntentTransition", ctx, mock.MatchedBy(func(tr models.IntentTransition) bool {
+		return tr.From == ""
+	}), mock.Anything).Return(nil).Maybe()
+	store.On("SaveIntent", ctx, mock.MatchedBy(func(i models.Intent) bool {
+		return i.StartDate == nil
+	})).Return(intent, nil).Once()
+
+	result, err := service.CreateIntent(ctx, "owner/repo", time.Time{}, time.Time{}, models.IntentOptions{})
+	assert.NoError(t, err)
+	assert.Nil(t, result.StartDate)
+	store.AssertExpectations(t)
+}
+
+func TestCreateIntent_InvalidEndDate(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	startDate := time.Now().Add(-time.Hour)
+	result, err := service.CreateIntent(ctx, "owner/repo", startDate, startDate.Add(-time.Hour), models.IntentOptions{})
+	assert.Nil(t, result)
+	assert.Equal(t, manager.ErrInvalidEndDate, err)
+}
+
+func TestCreateIntent_InvalidStartDate(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	repoName := "owner/repo"
+	startDate := time.Now().Add(time.Hour)
+
+	result, err := service.CreateIntent(ctx, repoName, startDate, time.Time{}, models.IntentOptions{})
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	assert.Equal(t, manager.ErrInvalidStartDate, err)
+}
+
+func TestUpdateIntentStatus(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	intentID := uuid.New()
+	intent := &models.Intent{
+		ID:             intentID,
+		RepositoryName: "owner/repo",
+		Status:         models.Completed,
+		IsActive:       true,
+	}
+	updatedIntent := &models.Intent{
+		ID:             intentID,
+		RepositoryName: "owner/repo",
+		Status:         models.Paused,
+		IsActive:       false,
+	}
+
+	store.On("FindIntent", ctx, intentID).Return(intent, nil).Once()
+	store.On("UpdateIntent", ctx, mock.MatchedBy(func(u models.IntentUpdate) bool {
+		return *u.Status == models.Paused && !*u.IsActive
+	})).Return(updatedIntent, nil).Once()
+	store.On("SaveIntentTransition", ctx, mock.MatchedBy(func(tr models.IntentTransition) bool {
+		return tr.IntentID == intentID && tr.From == models.Completed && tr.To == models.Paused
+	}), mock.Anything).Return(nil).Once()
+
+	result, err := service.UpdateIntentStatus(ctx, intentID)
+	assert.NoError(t, err)
+	assert.NotNil(t, result)
+	assert.False(t, result.IsActive)
+	store.AssertExpectations(t)
+}
+
+func TestUpdateIntentStatus_NotFound(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	intentID := uuid.New()
+
+	store.On("FindIntent", ctx, intentID).Return(nil, repository.ErrNotFound).Once()
+
+	result, err := service.UpdateIntentStatus(ctx, intentID)
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	assert.Equal(t, manager.ErrIntentNotFound, err)
+}
+
+func TestPauseIntent(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	intentID := uuid.New()
+	store.On("FindIntent", ctx, intentID).Return(&models.Intent{ID: intentID, RepositoryName: "owner/repo", Status: models.Ingesting, IsActive: true}, nil).Once()
+	store.On("UpdateIntent", ctx, mock.MatchedBy(func(u models.IntentUpdate) bool {
+		return *u.Status == models.Paused && !*u.IsActive && u.PausedAt != nil
+	})).Return(&models.Intent{ID: intentID, RepositoryName: "owner/repo", Status: models.Paused}, nil).Once()
+	store.On("SaveIntentTransition", ctx, mock.Anything, mock.Anything).Return(nil).Once()
+
+	result, err := service.PauseIntent(ctx, intentID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.Paused, result.Status)
+	assert.Len(t, store.outbox, 1)
+	assert.Contains(t, string(store.outbox[0].command), `"kind":"cancel_intent"`)
+	store.AssertExpectations(t)
+
+	// Pausing it again changes nothing.
+	store.On("FindIntent", ctx, intentID).Return(&models.Intent{ID: intentID, Status: models.Paused}, nil).Once()
+	_, err = service.PauseIntent(ctx, intentID)
+	assert.NoError(t, err)
+	assert.Len(t, store.outbox, 1)
+	store.AssertExpectations(t)
+}
+
+func TestResumeIntent_FromNewestCommit(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	intentID := uuid.New()
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	lastSynced := time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)
+	newest := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	intent := &models.Intent{ID: intentID, RepositoryName: "owner/repo", StartDate: &startDate, LastSyncedAt: &lastSynced, Status: models.Paused}
+	resumed := *intent
+	resumed.Status = models.Created
+	resumed.IsActive = true
+
+	store.On("FindIntent", ctx, intentID).Return(intent, nil).Once()
+	store.On("FindCommits", ctx, models.CommitsFilter{RepositoryName: "owner/repo"}, repository.Pagination{Page: 1, PerPage: 1}).
+		Return(repository.Paginated[models.Commit]{Data: []models.Commit{{CreatedAt: newest}}, TotalCount: 30}, nil).Once()
+	store.On("UpdateIntent", ctx, mock.MatchedBy(func(u models.IntentUpdate) bool {
+		return *u.Status == models.Created && *u.IsActive
+	})).Return(&resumed, nil).Once()
+	store.On("SaveIntentTransition", ctx, mock.Anything, mock.Anything).Return(nil).Once()
+
+	result, err := service.ResumeIntent(ctx, intentID)
+	assert.NoError(t, err)
+	assert.True(t, result.IsActive)
+	// The intent keeps its start date, but indexing picks up from the
+	// newest commit.
+	assert.Equal(t, startDate, *result.StartDate)
+	assert.Len(t, store.outbox, 1)
+	var command events.IntentCommand
+	assert.NoError(t, json.Unmarshal(store.outbox[0].command, &command))
+	assert.Equal(t, events.NewIntentKind, command.Kind)
+	assert.True(t, command.Intent.From.Equal(newest))
+	store.AssertExpectations(t)
+}
+
+func TestResumeIntent_UnfinishedBackfill(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	intentID := uuid.New()
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	intent := &models.Intent{ID: intentID, RepositoryName: "owner/repo", StartDate: &startDate, Status: models.Paused}
+	resumed := *intent
+	resumed.Status = models.Created
+	resumed.IsActive = true
+
+	// Without a completed run the history isn't all indexed, so the
+	// backfill resumes from the start date.
+	store.On("FindIntent", ctx, intentID).Return(intent, nil).Once()
+	store.On("UpdateIntent", ctx, mock.Anything).Return(&resumed, nil).Once()
+	store.On("SaveIntentTransition", ctx, mock.Anything, mock.Anything).Return(nil).Once()
+
+	_, err := service.ResumeIntent(ctx, intentID)
+	assert.NoError(t, err)
+	var command events.IntentCommand
+	assert.NoError(t, json.Unmarshal(store.outbox[0].command, &command))
+	assert.True(t, command.Intent.From.Equal(startDate))
+	store.AssertNotCalled(t, "FindCommits", mock.Anything, mock.Anything, mock.Anything)
+	store.AssertExpectations(t)
+}
+
+func TestResetIntentStartDate(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	intentID := uuid.New()
+	newDate := time.Now().Add(-time.Hour)
+	intent := &models.Intent{
+		ID:             intentID,
+		RepositoryName: "owner/repo",
+		StartDate:      &newDate,
+	}
+
+	store.On("UpdateIntent", ctx, mock.AnythingOfType("models.IntentUpdate")).Return(intent, nil).Once()
+
+	err := service.ResetIntentStartDate(ctx, intentID, newDate)
+	assert.NoError(t, err)
+}
+
+func TestResetIntentStartDate_InvalidDate(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	intentID := uuid.New()
+	newDate := time.Now().Add(time.Hour)
+
+	err := service.ResetIntentStartDate(ctx, intentID, newDate)
+	assert.Error(t, err)
+	assert.Equal(t, manager.ErrInvalidStartDate, err)
+}
+
+func TestResetIntentStartDate_StoreErrors(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	intentID := uuid.New()
+	newDate := time.Now().Add(-time.Hour)
+
+	store.On("UpdateIntent", ctx, mock.AnythingOfType("models.IntentUpdate")).Return((*models.Intent)(nil), repository.ErrNotFound).Once()
+	err := service.ResetIntentStartDate(ctx, intentID, newDate)
+	assert.Equal(t, manager.ErrIntentNotFound, err)
+	assert.True(t, errors.Is(err, manager.ErrNotFound))
+
+	constraint := fmt.Errorf("%w: intents_start_date_check", repository.ErrConstraint)
+	store.On("UpdateIntent", ctx, mock.AnythingOfType("models.IntentUpdate")).Return((*models.Intent)(nil), constraint).Once()
+	err = service.ResetIntentStartDate(ctx, intentID, newDate)
+	assert.True(t, errors.Is(err, manager.ErrInvalid))
+	assert.True(t, errors.Is(err, repository.ErrConstraint))
+	assert.NotContains(t, err.Error(), "intents_start_date_check")
+	store.AssertExpectations(t)
+}
+
+func TestGetIntent(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	intentID := uuid.New()
+	intent := &models.Intent{
+		ID:             intentID,
+		RepositoryName: "owner/repo",
+	}
+
+	store.On("FindIntent", ctx, intentID).Return(intent, nil).Once()
+	store.On("GetIntentProgress", ctx, intentID).Return(nil, repository.ErrNotFound).Once()
+
+	result, err := service.GetIntent(ctx, intentID)
+	assert.NoError(t, err)
+	assert.NotNil(t, result)
+	assert.Equal(t, intentID, result.ID)
+	assert.Nil(t, result.Progress)
+}
+
+func TestGetIntent_Progress(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	intentID := uuid.New()
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	startedAt := time.Date(2024, 1, 11, 0, 0, 0, 0, time.UTC)
+	reached := time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC)
+	store.On("FindIntent", ctx, intentID).Return(&models.Intent{
+		ID:             intentID,
+		RepositoryName: "owner/repo",
+		StartDate:      &since,
+		Status:         models.Ingesting,
+	}, nil).Once()
+	store.On("GetIntentProgress", ctx, intentID).Return(&models.IntentProgress{
+		Commits:        120,
+		Pages:          2,
+		LastCommitDate: &reached,
+		StartedAt:      startedAt,
+	}, nil).Once()
+
+	result, err := service.GetIntent(ctx, intentID)
+	assert.NoError(t, err)
+	if !assert.NotNil(t, result.Progress) {
+		return
+	}
+	// 3 of the 10 days between the start date and the run's start.
+	assert.Equal(t, 30.0, *result.Progress.PercentComplete)
+	assert.Equal(t, int64(2), result.Progress.Pages)
+}
+
+func TestGetIntent_ProgressOfShallowIndex(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	intentID := uuid.New()
+	maxCommits := int32(200)
+	store.On("FindIntent", ctx, intentID).Return(&models.Intent{
+		ID:             intentID,
+		RepositoryName: "owner/repo",
+		Status:         models.Ingesting,
+		IntentOptions:  models.IntentOptions{MaxCommits: &maxCommits},
+	}, nil).Once()
+	store.On("GetIntentProgress", ctx, intentID).Return(&models.IntentProgress{Commits: 50}, nil).Once()
+
+	result, err := service.GetIntent(ctx, intentID)
+	assert.NoError(t, err)
+	assert.Equal(t, 25.0, *result.Progress.PercentComplete)
+}
+
+func TestGetIntent_NotFound(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	intentID := uuid.New()
+	store.On("FindIntent", ctx, intentID).Return(nil, repository.ErrNotFound).Once()
+
+	result, err := service.GetIntent(ctx, intentID)
+	assert.Nil(t, result)
+	assert.Equal(t, manager.ErrIntentNotFound, err)
+}
+
+func TestGetIntents(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	filter := models.IntentFilter{}
+	limit := 10
+	offset := 0
+	intents := []models.Intent{
+		{
+			ID:             uuid.New(),
+			RepositoryName: "owner/repo",
+		},
+	}
+
+	paginatedIntents := repository.Paginated[models.Intent]{
+		Data:       intents,
+		TotalCount: 1,
+		Page:       offset,
+		PerPage:    limit,
+	}
+
+	store.On("FindIntents", ctx, filter, repository.Pagination{Page: offset, PerPage: limit}).Return(paginatedIntents, nil).Once()
+
+	result, err := service.GetIntents(ctx, filter, limit, offset)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(result.Data))
+	assert.Equal(t, intents[0].ID, result.Data[0].ID)
+}
+
+func TestGetTopCommitters(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	repoName := "owner/repo"
+	page := 1
+	perPage := 10
+	committers := []models.AuthorStats{
+		{
+			Author: models.Author{
+				Name:     "Test Author",
+				Email:    "test@example.com",
+				Username: "testuser",
+			},
+			Commits: 5,
+		},
+	}
+
+	paginatedCommitters := repository.Paginated[models.AuthorStats]{
+		Data:       committers,
+		TotalCount: 1,
+		Page:       page,
+		PerPage:    perPage,
+	}
+
+	store.On("GetRepo", ctx, repoName).Return(&models.Repository{ID: 1, FullName: repoName}, nil).Once()
+
+	store.On("GetTopCommitters", ctx, repoName, (*time.Time)(nil), (*time.Time)(nil), repository.Pagination{Page: page, PerPage: perPage}).Return(paginatedCommitters, nil).Once()
+
+	result, err := service.GetTopCommitters(ctx, repoName, time.Time{}, time.Time{}, page, perPage)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(result.Data))
+	assert.Equal(t, committers[0].Author.Name, result.Data[0].Author.Name)
+}
+
+func TestGetTopCommitters_DateRange(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	since := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC)
+	store.On("GetRepo", ctx, "owner/repo").Return(&models.Repository{ID: 1, FullName: "owner/repo"}, nil).Once()
+	store.On("GetTopCommitters", ctx, "owner/repo", &since, mock.MatchedBy(func(end *time.Time) bool {
+		return end.Equal(time.Date(2024, 6, 30, 23, 59, 59, 999999999, time.UTC))
+	}), repository.Pagination{Page: 1, PerPage: 10}).Return(repository.Paginated[models.AuthorStats]{}, nil).Once()
+
+	result, err := service.GetTopCommitters(ctx, "Owner/Repo", since, until, 1, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, result.Data)
+	store.AssertExpectations(t)
+
+	_, err = service.GetTopCommitters(ctx, "owner/repo", since, since.AddDate(0, 0, -1), 1, 10)
+	assert.Equal(t, manager.ErrInvalidEndDate, err)
+	_, err = service.GetTopCommitters(ctx, "owner/repo", time.Now().Add(time.Hour), time.Time{}, 1, 10)
+	assert.Equal(t, manager.ErrInvalidStartDate, err)
+}
+
+func TestGetChurn(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	churn := &models.Churn{Commits: 3, Additions: 40, Deletions: 12, Changes: 52}
+	store.On("GetRepo", ctx, "owner/repo").Return(&models.Repository{ID: 1, FullName: "owner/repo"}, nil).Once()
+	store.On("GetChurn", ctx, mock.MatchedBy(func(f models.CommitsFilter) bool {
+		return f.RepositoryName == "owner/repo"
+	})).Return(churn, nil).Once()
+
+	result, err := service.GetChurn(ctx, "Owner/Repo", time.Time{}, time.Time{})
+	assert.NoError(t, err)
+	assert.Equal(t, churn, result)
+	store.AssertExpectations(t)
+}
+
+func TestGetChurn_RepositoryNotFound(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	store.On("GetRepo", ctx, "owner/missing").Return(nil, repository.ErrNotFound).Once()
+
+	result, err := service.GetChurn(ctx, "owner/missing", time.Time{}, time.Time{})
+	assert.Nil(t, result)
+	assert.Equal(t, manager.ErrRepositoryNotFound, err)
+	store.AssertNotCalled(t, "GetChurn", mock.Anything, mock.Anything)
+}
+
+func TestGetCommitComments(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	comments := []models.CommitComment{{ID: 7, Author: "reviewer", Body: "This broke the build", CreatedAt: time.Now()}}
+	store.On("GetRepo", ctx, "owner/repo").Return(&models.Repository{ID: 1, FullName: "owner/repo"}, nil).Once()
+	store.On("FindCommitComments", ctx, int64(1), "abc123").Return(comments, nil).Once()
+
+	result, err := service.GetCommitComments(ctx, "Owner/Repo", "ABC123")
+	assert.NoError(t, err)
+	assert.Equal(t, comments, result)
+	store.AssertExpectations(t)
+}
+
+func TestCreateSession(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	store.On("SaveSession", ctx, mock.AnythingOfType("string"), mock.MatchedBy(func(s models.Session) bool {
+		return s.Username == "octocat" && s.Role == models.AdminRole
+	})).Return(&models.Session{Username: "octocat", Role: models.AdminRole}, nil).Once()
+
+	session, err := service.CreateSession(ctx, "octocat", []string{"friends", "acme"})
+	assert.NoError(t, err)
+	assert.Equal(t, models.AdminRole, session.Role)
+	assert.NotEmpty(t, session.Token)
+	store.AssertExpectations(t)
+}
+
+func TestCreateSession_NoRole(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	session, err := service.CreateSession(ctx, "octocat", []string{"strangers"})
+	assert.Nil(t, session)
+	assert.Equal(t, manager.ErrNoRole, err)
+}
+
+func TestValidateSession_Expired(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	store.On("FindSession", ctx, mock.AnythingOfType("string")).Return(nil, repository.ErrNotFound).Once()
+
+	session, err := service.ValidateSession(ctx, "stale-token")
+	assert.Nil(t, session)
+	assert.Equal(t, manager.ErrInvalidSession, err)
+}
+
+func TestCreateAPIKey(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	_, err := service.CreateAPIKey(ctx, "ci", models.Role("owner"), "")
+	assert.True(t, errors.Is(err, manager.ErrInvalidKeyName))
+	_, err = service.CreateAPIKey(ctx, "ci", models.ViewerRole, "Team A")
+	assert.True(t, errors.Is(err, manager.ErrInvalidTenant))
+
+	var hash string
+	store.On("SaveAPIKey", ctx, mock.AnythingOfType("string"), mock.MatchedBy(func(key models.APIKey) bool {
+		return key.Name == "ci" && key.Role == models.ViewerRole && key.Tenant == "team-a" &&
+			strings.HasPrefix(key.Prefix, "idx_") && len(key.Prefix) == 12
+	})).Run(func(args mock.Arguments) {
+		hash = args.String(1)
+	}).Return(&models.APIKey{Name: "ci", Role: models.ViewerRole, Tenant: "team-a"}, nil).Once()
+
+	key, err := service.CreateAPIKey(ctx, " ci ", models.ViewerRole, "team-a")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(key.Key, "idx_"))
+	assert.NotContains(t, hash, key.Key)
+	store.AssertExpectations(t)
+}
+
+func TestValidateAPIKey(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := manager.NewService(store, nil, nil, nil, &config.ManagerConfig{AdminAPIKey: "bootstrap"})
+
+	session, err := service.ValidateAPIKey(ctx, "bootstrap")
+	assert.NoError(t, err)
+	assert.Equal(t, models.AdminRole, session.Role)
+
+	_, err = service.ValidateAPIKey(ctx, "")
+	assert.Equal(t, manager.ErrInvalidAPIKey, err)
+
+	id := uuid.New()
+	recent := time.Now()
+	store.On("FindAPIKey", ctx, mock.AnythingOfType("string")).
+		Return(&models.APIKey{ID: id, Name: "ci", Role: models.ViewerRole, Tenant: "team-a"}, nil).Once()
+	store.On("TouchAPIKey", ctx, id).Return(nil).Once()
+	session, err = service.ValidateAPIKey(ctx, "idx_key")
+	assert.NoError(t, err)
+	assert.Equal(t, "api-key:ci", session.Username)
+	assert.Equal(t, models.ViewerRole, session.Role)
+	assert.Equal(t, "team-a", session.Tenant)
+
+	// A key used moments ago isn't touched again.
+	store.On("FindAPIKey", ctx, mock.AnythingOfType("string")).
+		Return(&models.APIKey{ID: id, Name: "ci", Role: models.ViewerRole, LastUsedAt: &recent}, nil).Once()
+	_, err = service.ValidateAPIKey(ctx, "idx_key")
+	assert.NoError(t, err)
+
+	store.On("FindAPIKey", ctx, mock.AnythingOfType("string")).Return(nil, repository.ErrNotFound).Once()
+	_, err = service.ValidateAPIKey(ctx, "idx_revoked")
+	assert.Equal(t, manager.ErrInvalidAPIKey, err)
+	store.AssertExpectations(t)
+}
+
+func TestRevokeAPIKey_NotFound(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	id := uuid.New()
+	store.On("RevokeAPIKey", ctx, id).Return(repository.ErrNotFound).Once()
+
+	err := service.RevokeAPIKey(ctx, id)
+	assert.True(t, errors.Is(err, manager.ErrAPIKeyNotFound))
+	store.AssertExpectations(t)
+}
+
+func TestPurgeSessions(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store := new(MockStore)
+	service := manager.NewService(store, nil, nil, nil, &config.ManagerConfig{
+		SessionPurgeInterval: time.Millisecond,
+	})
+
+	purged := make(chan struct{})
+	store.On("DeleteExpiredSessions", mock.Anything).Return(int64(0), assert.AnError).Once()
+	store.On("DeleteExpiredSessions", mock.Anything).Return(int64(3), nil).Run(func(mock.Arguments) {
+		close(purged)
+	}).Once()
+	store.On("DeleteExpiredSessions", mock.Anything).Return(int64(0), nil)
+
+	go service.PurgeSessions(ctx)
+
+	select {
+	case <-purged:
+	case <-time.After(time.Second):
+		t.Fatal("expired sessions were not purged after a failure")
+	}
+}
+
+func TestProcessCommitCommands_ArchivedRepo(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	intent := models.Intent{ID: uuid.New(), RepositoryName: "owner/repo", Status: models.Ingesting, IsActive: true}
+	completed := intent
+	completed.Status = models.Completed
+	paused := intent
+	paused.IsActive = false
+	paused.Status = models.Paused
+
+	// The repository info only flags the repository as archived.
+	store.On("RenameRepo", ctx, int64(0), "owner/repo").Return("", nil).Once()
+	store.On("SaveRepo", ctx, mock.MatchedBy(func(r *models.Repository) bool {
+		return r.FullName == "owner/repo" && r.Archived
+	})).Return(nil).Once()
+
+	body := []byte(`{"kind":"new_repo_info","paylad":{"repo":{"full_name":"Owner/Repo","archived":true}}}`)
+	assert.NoError(t, service.ProcessCommitCommands(ctx, body))
+	store.AssertExpectations(t)
+	store.AssertNotCalled(t, "FindIntents", mock.Anything, mock.Anything, mock.Anything)
+
+	// Its intents are paused once the final sync has completed.
+	store.On("FindIntent", ctx, intent.ID).Return(&intent, nil).Once()
+	store.On("UpdateIntent", ctx, mock.MatchedBy(func(u models.IntentUpdate) bool {
+		return u.ID == intent.ID && *u.Status == models.Completed
+	})).Return(&completed, nil).Once()
+	store.On("SaveIntentTransition", ctx, mock.MatchedBy(func(tr models.IntentTransition) bool {
+		return tr.From == models.Ingesting && tr.To == models.Completed
+	}), mock.Anything).Return(nil).Once()
+	store.On("GetRepo", ctx, "owner/repo").Return(&models.Repository{FullName: "owner/repo", Archived: true}, nil).Once()
+	store.On("FindIntents", ctx, mock.MatchedBy(func(f models.IntentFilter) bool {
+		return *f.RepositoryName == "owner/repo" && *f.IsActive
+	}), mock.Anything).Return(repository.Paginated[models.Intent]{Data: []models.Intent{completed}, TotalCount: 1}, nil).Once()
+	store.On("UpdateIntent", ctx, mock.MatchedBy(func(u models.IntentUpdate) bool {
+		return u.ID == intent.ID && u.IsActive != nil && !*u.IsActive && *u.Status == models.Paused
+	})).Return(&paused, nil).Once()
+	store.On("SaveIntentTransition", ctx, mock.MatchedBy(func(tr models.IntentTransition) bool {
+		return tr.From == models.Completed && tr.To == models.Paused
+	}), mock.Anything).Return(nil).Once()
+	store.On("SaveIntentProgress", ctx, intent.ID, mock.Anything).Return(nil).Once()
+
+	body = []byte(`{"kind":"intent_completed","paylad":{"progress":{"intent_id":"` + intent.ID.String() + `","commits":7,"at":"2024-06-01T00:00:00Z"}}}`)
+	assert.NoError(t, service.ProcessCommitCommands(ctx, body))
+	store.AssertExpectations(t)
+}
+
+func TestProcessCommitCommands_RenamedRepo(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	intent := models.Intent{ID: uuid.New(), RepositoryName: "owner/repo", Status: models.Ingesting, IsActive: true}
+	store.On("FindIntent", ctx, intent.ID).Return(&intent, nil).Once()
+	events, stop, err := service.WatchIntent(ctx, intent.ID)
+	assert.NoError(t, err)
+	defer stop()
+	<-events
+
+	store.On("RenameRepo", ctx, int64(42), "neworg/repo").Return("owner/repo", nil).Once()
+	store.On("SaveRepo", ctx, mock.MatchedBy(func(r *models.Repository) bool {
+		return r.ID == 42 && r.FullName == "neworg/repo"
+	})).Return(nil).Once()
+	store.On("FindIntents", ctx, mock.MatchedBy(func(f models.IntentFilter) bool {
+		return *f.RepositoryName == "neworg/repo" && *f.IsActive
+	}), mock.Anything).Return(repository.Paginated[models.Intent]{Data: []models.Intent{intent}, TotalCount: 1}, nil).Once()
+
+	body := []byte(`{"kind":"new_repo_info","paylad":{"repo":{"id":42,"full_name":"NewOrg/Repo"}}}`)
+	err = service.ProcessCommitCommands(ctx, body)
+	assert.NoError(t, err)
+
+	ev := <-events
+	assert.Equal(t, models.RenameEvent, ev.Type)
+	assert.Equal(t, "neworg/repo", ev.Repository)
+	assert.Equal(t, "owner/repo", ev.RenamedFrom)
+	store.AssertExpectations(t)
+}
+
+func TestProcessCommitCommands_RepoTenant(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	// The tenant of the intent whose run fetched the repository tracks it.
+	intentID := uuid.New()
+	store.On("RenameRepo", ctx, int64(42), "owner/repo").Return("", nil).Once()
+	store.On("SaveRepo", ctx, mock.Anything).Return(nil).Once()
+	store.On("AddRepoTenant", ctx, int64(42), intentID).Return(nil).Once()
+
+	body := []byte(`{"kind":"new_repo_info","paylad":{"repo":{"id":42,"full_name":"owner/repo"},"intent_id":"` + intentID.String() + `"}}`)
+	assert.NoError(t, service.ProcessCommitCommands(ctx, body))
+	store.AssertExpectations(t)
+
+	// Repository info sent without an intent gives no tenant the repository.
+	store.On("RenameRepo", ctx, int64(42), "owner/repo").Return("", nil).Once()
+	store.On("SaveRepo", ctx, mock.Anything).Return(nil).Once()
+
+	body = []byte(`{"kind":"new_repo_info","paylad":{"repo":{"id":42,"full_name":"owner/repo"}}}`)
+	assert.NoError(t, service.ProcessCommitCommands(ctx, body))
+	store.AssertNumberOfCalls(t, "AddRepoTenant", 1)
+}
+
+func TestCreateCredential(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	var saved models.Credential
+	store.On("SaveCredential", ctx, mock.AnythingOfType("models.Credential")).Run(func(args mock.Arguments) {
+		saved = args.Get(1).(models.Credential)
+	}).Return(&models.Credential{Name: "ci-bot"}, nil).Once()
+
+	result, err := service.CreateCredential(ctx, " ci-bot ", "ghp_secret")
+	assert.NoError(t, err)
+	assert.Equal(t, "ci-bot", result.Name)
+	assert.Equal(t, "ci-bot", saved.Name)
+	assert.NotContains(t, string(saved.Ciphertext), "ghp_secret")
+
+	box, err := secrets.NewBox(testCredentialsKey)
+	assert.NoError(t, err)
+	token, err := box.Open(saved.Ciphertext)
+	assert.NoError(t, err)
+	assert.Equal(t, "ghp_secret", string(token))
+}
+
+func TestCreateIntent_UnknownCredential(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	credentialID := uuid.New()
+	store.On("FindCredential", ctx, credentialID).Return(nil, repository.ErrNotFound).Once()
+
+	result, err := service.CreateIntent(ctx, "owner/repo", time.Now().Add(-time.Hour), time.Time{}, models.IntentOptions{CredentialID: &credentialID})
+	assert.Nil(t, result)
+	assert.Equal(t, manager.ErrCredentialNotFound, err)
+	store.AssertNotCalled(t, "SaveIntent", mock.Anything, mock.Anything)
+}
+
+func TestGetRateLimits_Unavailable(t *testing.T) {
+	service := newTestService(new(MockStore))
+
+	result, err := service.GetRateLimits(context.Background())
+	assert.Nil(t, result)
+	assert.Equal(t, manager.ErrRateLimitsUnavailable, err)
+}
+
+func TestGetLocks_Unavailable(t *testing.T) {
+	service := newTestService(new(MockStore))
+
+	result, err := service.GetLocks(context.Background())
+	assert.Nil(t, result)
+	assert.Equal(t, manager.ErrLocksUnavailable, err)
+}
+
+func TestProcessCommitCommands_IntentStarted(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	intentID := uuid.New()
+	store.On("FindIntent", ctx, intentID).Return(&models.Intent{ID: intentID, Status: models.Broadcast}, nil).Once()
+	store.On("SaveIntentTransition", ctx, mock.MatchedBy(func(tr models.IntentTransition) bool {
+		return tr.From == models.Broadcast && tr.To == models.Fetching
+	}), mock.Anything).Return(nil).Once()
+	store.On("UpdateIntent", ctx, mock.MatchedBy(func(u models.IntentUpdate) bool {
+		return u.ID == intentID && *u.Status == models.Fetching && u.SyncStartedAt != nil && *u.SyncedCommits == 0 && u.LastSyncedAt == nil
+	})).Return(&models.Intent{ID: intentID}, nil).Once()
+	store.On("SaveIntentProgress", ctx, intentID, mock.MatchedBy(func(p models.IntentProgress) bool {
+		// Monitors that don't report the run's start fall back to the event's time.
+		return p.Commits == 0 && p.StartedAt.Equal(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)) && p.UpdatedAt.Equal(p.StartedAt)
+	})).Return(nil).Once()
+
+	body := []byte(`{"kind":"intent_started","paylad":{"progress":{"intent_id":"` + intentID.String() + `","commits":0,"at":"2024-06-01T00:00:00Z"}}}`)
+	err := service.ProcessCommitCommands(ctx, body)
+	assert.NoError(t, err)
+	store.AssertExpectations(t)
+}
+
+func TestProcessCommitCommands_OrgRepos(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	parent := &models.Intent{ID: uuid.New(), RepositoryName: "owner/*", IsActive: true, Status: models.Completed, StartDate: &startDate}
+	store.On("FindIntent", ctx, parent.ID).Return(parent, nil).Once()
+	store.On("FindChildIntentRepos", ctx, parent.ID).Return([]string{"owner/a"}, nil).Once()
+	store.On("FindIntents", ctx, mock.MatchedBy(func(f models.IntentFilter) bool {
+		return *f.RepositoryName == "owner/b"
+	}), mock.Anything).Return(repository.Paginated[models.Intent]{}, nil).Once()
+	// owner/c is already indexed by an intent of its own.
+	store.On("FindIntents", ctx, mock.MatchedBy(func(f models.IntentFilter) bool {
+		return *f.RepositoryName == "owner/c"
+	}), mock.Anything).Return(repository.Paginated[models.Intent]{Data: []models.Intent{{ID: uuid.New(), RepositoryName: "owner/c"}}, TotalCount: 1}, nil).Once()
+	store.On("SaveIntent", ctx, mock.MatchedBy(func(i models.Intent) bool {
+		return i.RepositoryName == "owner/b" && *i.ParentID == parent.ID && i.StartDate.Equal(startDate) && i.IsActive
+	})).Return(&models.Intent{ID: uuid.New(), RepositoryName: "owner/b", Status: models.Created, IsActive: true}, nil).Once()
+	store.On("SaveIntentTransition", ctx, mock.Anything, mock.Anything).Return(nil).Maybe()
+
+	body := []byte(`{"kind":"new_org_repos","paylad":{"org_repos":{"intent_id":"` + parent.ID.String() + `","repos":["owner/a","Owner/B","owner/c","other/d"]}}}`)
+	err := service.ProcessCommitCommands(ctx, body)
+	assert.NoError(t, err)
+	store.AssertExpectations(t)
+}
+
+func TestProcessCommitCommands_PausedOrgIntent(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	parent := &models.Intent{ID: uuid.New(), RepositoryName: "owner/*", Status: models.Paused}
+	store.On("FindIntent", ctx, parent.ID).Return(parent, nil).Once()
+
+	body := []byte(`{"kind":"new_org_repos","paylad":{"org_repos":{"intent_id":"` + parent.ID.String() + `","repos":["owner/a"]}}}`)
+	err := service.ProcessCommitCommands(ctx, body)
+	assert.NoError(t, err)
+	store.AssertExpectations(t)
+	store.AssertNotCalled(t, "SaveIntent", mock.Anything, mock.Anything)
+}
+
+func TestProcessCommitCommands_IntentFailed(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	intentID := uuid.New()
+	store.On("FindIntent", ctx, intentID).Return(&models.Intent{ID: intentID, Status: models.Ingesting}, nil).Once()
+	store.On("SaveIntentTransition", ctx, mock.MatchedBy(func(tr models.IntentTransition) bool {
+		return tr.From == models.Ingesting && tr.To == models.Failed
+	}), mock.Anything).Return(nil).Once()
+	store.On("SaveIntentError", ctx, mock.MatchedBy(func(e models.IntentError) bool {
+		return e.IntentID == intentID && e.Message == "rate limited"
+	})).Return(nil).Once()
+	store.On("UpdateIntent", ctx, mock.MatchedBy(func(u models.IntentUpdate) bool {
+		return u.ID == intentID && *u.Status == models.Failed && *u.SyncedCommits == 42
+	})).Return(&models.Intent{ID: intentID}, nil).Once()
+	store.On("SaveIntentProgress", ctx, intentID, mock.Anything).Return(nil).Once()
+
+	body := []byte(`{"kind":"intent_failed","paylad":{"progress":{"intent_id":"` + intentID.String() + `","commits":42,"at":"2024-06-01T00:00:00Z","error":"rate limited"}}}`)
+	err := service.ProcessCommitCommands(ctx, body)
+	assert.NoError(t, err)
+	store.AssertExpectations(t)
+}
+
+func TestProcessCommitCommands_PausedIntentStaysPaused(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	intentID := uuid.New()
+	store.On("FindIntent", ctx, intentID).Return(&models.Intent{ID: intentID, Status: models.Paused}, nil).Once()
+	store.On("UpdateIntent", ctx, mock.MatchedBy(func(u models.IntentUpdate) bool {
+		return u.ID == intentID && u.Status == nil && u.LastSyncedAt != nil && *u.SyncedCommits == 7
+	})).Return(&models.Intent{ID: intentID, Status: models.Paused}, nil).Once()
+	store.On("SaveIntentProgress", ctx, intentID, mock.Anything).Return(nil).Once()
+
+	body := []byte(`{"kind":"intent_completed","paylad":{"progress":{"intent_id":"` + intentID.String() + `","commits":7,"at":"2024-06-01T00:00:00Z"}}}`)
+	err := service.ProcessCommitCommands(ctx, body)
+	assert.NoError(t, err)
+	store.AssertExpectations(t)
+	store.AssertNotCalled(t, "SaveIntentTransition", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestIntentStatusTransitions(t *testing.T) {
+	assert.True(t, models.Created.CanTransitionTo(models.Broadcast))
+	assert.True(t, models.Completed.CanTransitionTo(models.Fetching))
+	assert.True(t, models.Ingesting.CanTransitionTo(models.Ingesting))
+	assert.True(t, models.Paused.CanTransitionTo(models.Created))
+	assert.False(t, models.Paused.CanTransitionTo(models.Fetching))
+	assert.False(t, models.Broadcast.CanTransitionTo(models.Completed))
+	assert.False(t, models.Created.CanTransitionTo(models.Completed))
+}
+
+func TestWatchIntent(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	intentID := uuid.New()
+	store.On("FindIntent", ctx, intentID).Return(&models.Intent{ID: intentID, Status: models.Fetching}, nil).Twice()
+	store.On("SaveIntentTransition", ctx, mock.Anything, mock.Anything).Return(nil).Once()
+	store.On("UpdateIntent", ctx, mock.Anything).Return(&models.Intent{ID: intentID, Status: models.Ingesting, SyncedCommits: 12}, nil).Once()
+
+	events, stop, err := service.WatchIntent(ctx, intentID)
+	assert.NoError(t, err)
+	defer stop()
+
+	event := <-events
+	assert.Equal(t, models.StatusEvent, event.Type)
+	assert.Equal(t, models.Fetching, event.Status)
+	store.On("SaveIntentProgress", ctx, intentID, mock.Anything).Return(nil).Once()
+
+	body := []byte(`{"kind":"intent_progress","paylad":{"progress":{"intent_id":"` + intentID.String() + `","commits":12,"at":"2024-06-01T00:00:00Z"}}}`)
+	assert.NoError(t, service.ProcessCommitCommands(ctx, body))
+
+	event = <-events
+	assert.Equal(t, models.StatusEvent, event.Type)
+	assert.Equal(t, models.Ingesting, event.Status)
+	event = <-events
+	assert.Equal(t, models.ProgressEvent, event.Type)
+	assert.Equal(t, int64(12), event.SyncedCommits)
+	store.AssertExpectations(t)
+}
+
+func TestWatchIntent_NotFound(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	intentID := uuid.New()
+	store.On("FindIntent", ctx, intentID).Return(nil, repository.ErrNotFound).Once()
+
+	_, _, err := service.WatchIntent(ctx, intentID)
+	assert.Equal(t, manager.ErrIntentNotFound, err)
+}
+
+func TestWatchIntents(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	intentID := uuid.New()
+	store.On("FindIntent", ctx, intentID).Return(&models.Intent{ID: intentID, RepositoryName: "acme/widgets", Status: models.Fetching}, nil).Once()
+	store.On("SaveIntentTransition", ctx, mock.Anything, mock.Anything).Return(nil).Once()
+	store.On("UpdateIntent", ctx, mock.Anything).Return(&models.Intent{ID: intentID, Status: models.Ingesting, SyncedCommits: 12}, nil).Once()
+
+	events, stop := service.WatchIntents(ctx)
+	defer stop()
+	store.On("SaveIntentProgress", ctx, intentID, mock.Anything).Return(nil).Once()
+
+	body := []byte(`{"kind":"intent_progress","paylad":{"progress":{"intent_id":"` + intentID.String() + `","commits":12,"at":"2024-06-01T00:00:00Z"}}}`)
+	assert.NoError(t, service.ProcessCommitCommands(ctx, body))
+
+	// Only the status change reaches the watchers of every intent, not
+	// the progress update.
+	event := <-events
+	assert.Equal(t, models.StatusEvent, event.Type)
+	assert.Equal(t, intentID, event.IntentID)
+	assert.Equal(t, models.Ingesting, event.Status)
+	assert.Equal(t, "acme/widgets", event.Repository)
+	select {
+	case event := <-events:
+		t.Fatalf("unexpected %s event", event.Type)
+	default:
+	}
+	store.AssertExpectations(t)
+}
+
+func TestWatchIntents_Tenant(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	intentID := uuid.New()
+	store.On("FindIntent", ctx, intentID).Return(&models.Intent{ID: intentID, Status: models.Fetching, Tenant: "team-a"}, nil).Once()
+	store.On("SaveIntentTransition", ctx, mock.Anything, mock.Anything).Return(nil).Once()
+	store.On("UpdateIntent", ctx, mock.Anything).Return(&models.Intent{ID: intentID, Status: models.Ingesting, Tenant: "team-a"}, nil).Once()
+	store.On("SaveIntentProgress", ctx, intentID, mock.Anything).Return(nil).Once()
+
+	own, stopOwn := service.WatchIntents(repository.WithTenant(ctx, "team-a"))
+	defer stopOwn()
+	other, stopOther := service.WatchIntents(repository.WithTenant(ctx, "team-b"))
+	defer stopOther()
+
+	body := []byte(`{"kind":"intent_progress","paylad":{"progress":{"intent_id":"` + intentID.String() + `","commits":12,"at":"2024-06-01T00:00:00Z"}}}`)
+	assert.NoError(t, service.ProcessCommitCommands(ctx, body))
+
+	event := <-own
+	assert.Equal(t, intentID, event.IntentID)
+	assert.Equal(t, "team-a", event.Tenant)
+	select {
+	case event := <-other:
+		t.Fatalf("unexpected event of intent %s in another tenant", event.IntentID)
+	default:
+	}
+	store.AssertExpectations(t)
+}
+
+// busStore loops intent events back to its listener, standing in for the
+// NOTIFY of another replica.
+type busStore struct {
+	*MockStore
+	events chan models.IntentEvent
+}
+
+func (b *busStore) NotifyIntentEvent(ctx context.Context, event models.IntentEvent) error {
+	b.events <- event
+	return nil
+}
+
+func (b *busStore) ListenIntentEvents(ctx context.Context, notify func(models.IntentEvent)) error {
+	for {
+		select {
+		case event := <-b.events:
+			notify(event)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func TestWatchIntent_ThroughBus(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store := &busStore{MockStore: new(MockStore), events: make(chan models.IntentEvent, 4)}
+	service := newTestService(store)
+	go service.FollowIntentEvents(ctx)
+
+	intentID := uuid.New()
+	store.On("FindIntent", ctx, intentID).Return(&models.Intent{ID: intentID, Status: models.Fetching}, nil).Twice()
+	store.On("SaveIntentError", ctx, mock.Anything).Return(nil).Once()
+	store.On("SaveIntentTransition", ctx, mock.Anything, mock.Anything).Return(nil).Once()
+	store.On("UpdateIntent", ctx, mock.Anything).Return(&models.Intent{ID: intentID, Status: models.Failed}, nil).Once()
+
+	events, stop, err := service.WatchIntent(ctx, intentID)
+	assert.NoError(t, err)
+	defer stop()
+	<-events
+	store.On("SaveIntentProgress", ctx, intentID, mock.Anything).Return(nil).Once()
+
+	body := []byte(`{"kind":"intent_failed","paylad":{"progress":{"intent_id":"` + intentID.String() + `","error":"boom","at":"2024-06-01T00:00:00Z"}}}`)
+	assert.NoError(t, service.ProcessCommitCommands(ctx, body))
+
+	var types []models.IntentEventType
+	for len(types) < 3 {
+		select {
+		case event := <-events:
+			types = append(types, event.Type)
+		case <-time.After(time.Second):
+			t.Fatalf("only got %v", types)
+		}
+	}
+	assert.Equal(t, []models.IntentEventType{models.ErrorEvent, models.StatusEvent, models.ProgressEvent}, types)
+	store.AssertExpectations(t)
+}
+
+func TestProcessCommitCommands_RetriesWithIntentPolicy(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	attempts, backoff := int32(3), int32(0)
+	intent := models.Intent{ID: uuid.New(), RepositoryName: "owner/repo", IsActive: true}
+	intent.Retry = &models.RetryPolicy{MaxAttempts: &attempts, BackoffBaseMs: &backoff}
+
+	store.On("RenameRepo", ctx, int64(0), "owner/repo").Return("", nil)
+	store.On("SaveRepo", ctx, mock.Anything).Return(assert.AnError).Twice()
+	store.On("SaveRepo", ctx, mock.Anything).Return(nil).Once()
+	store.On("FindIntents", ctx, mock.MatchedBy(func(f models.IntentFilter) bool {
+		return *f.RepositoryName == "owner/repo" && *f.IsActive
+	}), mock.Anything).Return(repository.Paginated[models.Intent]{Data: []models.Intent{intent}, TotalCount: 1}, nil).Once()
+
+	body := []byte(`{"kind":"new_repo_info","paylad":{"repo":{"full_name":"owner/repo"}}}`)
+	err := service.ProcessCommitCommands(ctx, body)
+	assert.NoError(t, err)
+	store.AssertExpectations(t)
+}
+
+func TestProcessCommitCommands_CapsIntentRetryDelay(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := manager.NewService(store, nil, nil, nil, &config.ManagerConfig{
+		RetryMaxDelay:   10 * time.Millisecond,
+		RetryMaxElapsed: time.Second,
+	})
+
+	// Ten minutes between attempts would hold up every other repository.
+	attempts, backoff := int32(3), int32(600000)
+	intent := models.Intent{ID: uuid.New(), RepositoryName: "owner/repo", IsActive: true}
+	intent.Retry = &models.RetryPolicy{MaxAttempts: &attempts, BackoffBaseMs: &backoff}
+
+	store.On("RenameRepo", ctx, int64(0), "owner/repo").Return("", nil)
+	store.On("SaveRepo", ctx, mock.Anything).Return(assert.AnError).Twice()
+	store.On("SaveRepo", ctx, mock.Anything).Return(nil).Once()
+	store.On("FindIntents", ctx, mock.Anything, mock.Anything).Return(repository.Paginated[models.Intent]{Data: []models.Intent{intent}, TotalCount: 1}, nil).Once()
+
+	start := time.Now()
+	body := []byte(`{"kind":"new_repo_info","paylad":{"repo":{"full_name":"owner/repo"}}}`)
+	err := service.ProcessCommitCommands(ctx, body)
+	assert.NoError(t, err)
+	assert.True(t, time.Since(start) < time.Second)
+	store.AssertExpectations(t)
+}
+
+func TestCreateIntent_InvalidRetryPolicy(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	jitter := 1.5
+	intent, err := service.CreateIntent(ctx, "owner/repo", time.Now().Add(-24*time.Hour), time.Time{}, models.IntentOptions{
+		Retry: &models.RetryPolicy{Jitter: &jitter},
+	})
+	assert.Nil(t, intent)
+	assert.Equal(t, manager.ErrInvalidRetryPolicy, err)
+}
+
+func TestPublishCommits(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	intent := models.Intent{ID: uuid.New(), RepositoryName: "owner/repo", Status: models.Ingesting, IsActive: true, SyncedCommits: 40}
+	store.On("FindIntent", ctx, intent.ID).Return(&intent, nil).Once()
+	store.On("FindIntents", ctx, mock.MatchedBy(func(f models.IntentFilter) bool {
+		return *f.RepositoryName == "owner/repo" && *f.IsActive
+	}), mock.Anything).Return(repository.Paginated[models.Intent]{Data: []models.Intent{intent}, TotalCount: 1}, nil).Once()
+
+	events, stop, err := service.WatchIntent(ctx, intent.ID)
+	assert.NoError(t, err)
+	defer stop()
+	<-events
+
+	err = service.PublishCommits(ctx, models.CommitsNotice{Repository: "owner/repo", Commits: 100, At: time.Now()})
+	assert.NoError(t, err)
+
+	event := <-events
+	assert.Equal(t, models.CommitsEvent, event.Type)
+	assert.Equal(t, 100, event.Commits)
+	assert.Equal(t, int64(40), event.SyncedCommits)
+	store.AssertExpectations(t)
+}
+
+func TestPublishCommits_NoWatchers(t *testing.T) {
+	store := new(MockStore)
+	service := newTestService(store)
+
+	err := service.PublishCommits(context.Background(), models.CommitsNotice{Repository: "owner/repo", Commits: 100})
+	assert.NoError(t, err)
+	store.AssertNotCalled(t, "FindIntents", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestGetDailyCommits(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	days := []models.DailyCommits{{Day: "2024-06-01", Commits: 12}, {Day: "2024-06-02", Commits: 3}}
+	store.On("GetRepo", ctx, "owner/repo").Return(&models.Repository{FullName: "owner/repo"}, nil).Once()
+	store.On("GetDailyCommits", ctx, mock.MatchedBy(func(f models.CommitsFilter) bool {
+		return f.RepositoryName == "owner/repo"
+	})).Return(days, nil).Once()
+
+	result, err := service.GetDailyCommits(ctx, "Owner/Repo", time.Time{}, time.Time{}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, days, result)
+	store.AssertExpectations(t)
+}
+
+func TestGetDailyCommits_InvalidRange(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	day := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	for _, r := range []struct{ since, until time.Time }{
+		{day, day.AddDate(0, 0, -1)},
+		{day.AddDate(-6, 0, 0), day},
+		{time.Time{}, time.Now().AddDate(1, 0, 0)},
+		{day.AddDate(-10, 0, 0), time.Time{}},
+	} {
+		_, err := service.GetDailyCommits(ctx, "owner/repo", r.since, r.until, false)
+		assert.Equal(t, manager.ErrInvalidDateRange, err)
+	}
+	store.AssertExpectations(t)
+}
+
+func TestGetCommitActivity(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	weeks := []models.ActivityBucket{{Start: "2024-06-03", Commits: 12}, {Start: "2024-06-10", Commits: 0}}
+	store.On("GetRepo", ctx, "owner/repo").Return(&models.Repository{FullName: "owner/repo"}, nil).Once()
+	store.On("GetCommitActivity", ctx, mock.MatchedBy(func(f models.CommitsFilter) bool {
+		return f.RepositoryName == "owner/repo"
+	}), models.ActivityWeek).Return(weeks, nil).Once()
+
+	result, err := service.GetCommitActivity(ctx, "Owner/Repo", models.ActivityWeek, time.Time{}, time.Time{})
+	assert.NoError(t, err)
+	assert.Equal(t, weeks, result)
+	store.AssertExpectations(t)
+}
+
+func TestGetCommitActivity_Invalid(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	_, err := service.GetCommitActivity(ctx, "owner/repo", "year", time.Time{}, time.Time{})
+	assert.Equal(t, manager.ErrInvalidActivityInterval, err)
+
+	day := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	_, err = service.GetCommitActivity(ctx, "owner/repo", models.ActivityMonth, day, day.AddDate(0, 0, -1))
+	assert.Equal(t, manager.ErrInvalidDateRange, err)
+	store.AssertExpectations(t)
+}
+
+func TestGetContributions(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	alice := models.Author{ID: 1, Name: "Alice"}
+	bob := models.Author{ID: 2, Name: "Bob"}
+	store.On("GetRepo", ctx, "owner/repo").Return(&models.Repository{FullName: "owner/repo"}, nil).Once()
+	store.On("GetContributions", ctx, mock.MatchedBy(func(f models.CommitsFilter) bool {
+		return f.RepositoryName == "owner/repo"
+	})).Return([]models.MonthlyContribution{
+		{Author: alice, Month: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Commits: 2},
+		{Author: bob, Month: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Commits: 1},
+		{Author: bob, Month: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), Commits: 4},
+	}, nil).Once()
+
+	result, err := service.GetContributions(ctx, "Owner/Repo", time.Time{}, time.Date(2024, 4, 15, 0, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Equal(t, &models.ContributionMatrix{
+		Months: []string{"2024-01", "2024-02", "2024-03", "2024-04"},
+		Authors: []models.AuthorContributions{
+			{Author: bob, Commits: []int64{1, 0, 4, 0}, Total: 5},
+			{Author: alice, Commits: []int64{2, 0, 0, 0}, Total: 2},
+		},
+	}, result)
+	store.AssertExpectations(t)
+
+	_, err = service.GetContributions(ctx, "owner/repo", time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, manager.ErrInvalidDateRange, err)
+}
+
+func TestRefreshLeaderboards_AfterLargeIngest(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store := new(MockStore)
+	service := manager.NewService(store, nil, nil, nil, &config.ManagerConfig{
+		LeaderboardRefreshInterval: time.Hour,
+		LeaderboardRefreshCommits:  2,
+	})
+
+	refreshed := make(chan struct{})
+	store.On("RefreshLeaderboards", mock.Anything).Return(nil).Run(func(mock.Arguments) {
+		close(refreshed)
+	}).Once()
+	store.On("GetRepo", ctx, "owner/repo").Return(&models.Repository{ID: 1, FullName: "owner/repo"}, nil)
+	store.On("SaveManyCommit", ctx, int64(1), mock.Anything).Return(nil)
+	go service.RefreshLeaderboards(ctx)
+
+	err := service.BatchSaveCommits(ctx, []*models.Commit{
+		{Hash: "a", Repository: models.Repository{FullName: "owner/repo"}},
+		{Hash: "b", Repository: models.Repository{FullName: "owner/repo"}},
+	})
+	assert.NoError(t, err)
+
+	select {
+	case <-refreshed:
+	case <-time.After(time.Second):
+		t.Fatal("leaderboards were not refreshed")
+	}
+}
+
+func TestRunRollups_DrainsPendingCommits(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store := new(MockStore)
+	service := manager.NewService(store, nil, nil, nil, &config.ManagerConfig{
+		RollupInterval:  time.Hour,
+		RollupBatchSize: 10,
+	})
+
+	drained := make(chan struct{})
+	store.On("RollupCommits", mock.Anything, 10).Return(map[string]int64{"owner/repo": 6, "owner/other": 4}, nil).Twice()
+	store.On("RollupCommits", mock.Anything, 10).Return(map[string]int64{"owner/repo": 3}, nil).Run(func(mock.Arguments) {
+		close(drained)
+	}).Once()
+
+	go service.RunRollups(ctx)
+
+	select {
+	case <-drained:
+	case <-time.After(time.Second):
+		t.Fatal("pending commits were not rolled up")
+	}
+	store.AssertExpectations(t)
+}
+
+func TestGetRepoStats(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	stats := &models.RepoStats{Commits: 120, Additions: 4000, Deletions: 900, Authors: 7, ActiveDays: 30}
+	store.On("GetRepo", ctx, "owner/repo").Return(&models.Repository{FullName: "owner/repo"}, nil).Once()
+	store.On("GetRepoStats", ctx, mock.MatchedBy(func(f models.CommitsFilter) bool {
+		return f.RepositoryName == "owner/repo"
+	})).Return(stats, nil).Once()
+
+	result, err := service.GetRepoStats(ctx, "owner/repo", time.Time{}, time.Time{}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, stats, result)
+	store.AssertExpectations(t)
+}
+
+func TestGetRepoStats_Dedupe(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	stats := &models.RepoStats{Commits: 4, Authors: 1, ActiveDays: 2}
+	store.On("GetRepo", ctx, "owner/fork").Return(&models.Repository{FullName: "owner/fork", NetworkID: 1}, nil).Once()
+	store.On("GetRepoStats", ctx, mock.MatchedBy(func(f models.CommitsFilter) bool {
+		return f.RepositoryName == "owner/fork" && f.Dedupe
+	})).Return(stats, nil).Once()
+
+	result, err := service.GetRepoStats(ctx, "owner/fork", time.Time{}, time.Time{}, true)
+	assert.NoError(t, err)
+	assert.Equal(t, stats, result)
+	store.AssertExpectations(t)
+}
+
+// memoryCache is a ResultCache in a map, with a generation per repository.
+// It keeps the TTLs entries were set for, but never expires them.
+type memoryCache struct {
+	entries map[string][]byte
+	gens    map[string]int
+	ttls    map[string]time.Duration
+}
+
+func newMemoryCache() *memoryCache {
+	return &memoryCache{entries: make(map[string][]byte), gens: make(map[string]int), ttls: make(map[string]time.Duration)}
+}
+
+func (c *memoryCache) Key(ctx context.Context, repo, query string) (string, error) {
+	return fmt.Sprintf("%d:%d:%s:%s", c.gens[""], c.gens[repo], repo, query), nil
+}
+
+func (c *memoryCache) Get(ctx context.Context, key string, dst interface{}) (bool, error) {
+	data, ok := c.entries[key]
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(data, dst)
+}
+
+func (c *memoryCache) Set(ctx context.Context, key string, v interface{}) error {
+	data, err := json.Marshal(v)
+	c.entries[key] = data
+	return err
+}
+
+func (c *memoryCache) SetFor(ctx context.Context, key string, v interface{}, ttl time.Duration) error {
+	c.ttls[key] = ttl
+	return c.Set(ctx, key, v)
+}
+
+func (c *memoryCache) Invalidate(ctx context.Context, repo string) error {
+	c.gens[repo]++
+	return nil
+}
+
+func (c *memoryCache) InvalidateAll(ctx context.Context) error {
+	c.gens[""]++
+	return nil
+}
+
+func TestGetRepoStats_Cached(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := manager.NewService(store, nil, nil, newMemoryCache(), &config.ManagerConfig{})
+
+	repo := &models.Repository{ID: 1, FullName: "owner/repo"}
+	store.On("GetRepo", ctx, "owner/repo").Return(repo, nil)
+	store.On("GetIngestionStatus", ctx, mock.Anything).Return(&models.IngestionStatus{}, nil)
+	store.On("SaveManyCommit", ctx, int64(1), mock.Anything).Return(nil)
+	store.On("GetRepoStats", ctx, mock.Anything).Return(&models.RepoStats{Commits: 1}, nil).Once()
+	store.On("GetRepoStats", ctx, mock.Anything).Return(&models.RepoStats{Commits: 2}, nil).Once()
+
+	for _, want := range []int64{1, 1} {
+		result, err := service.GetRepoStats(ctx, "owner/repo", time.Time{}, time.Time{}, false)
+		assert.NoError(t, err)
+		assert.Equal(t, want, result.Commits)
+	}
+
+	// New commits invalidate the repository's results.
+	err := service.BatchSaveCommits(ctx, []*models.Commit{{Hash: "a", Repository: *repo}})
+	assert.NoError(t, err)
+
+	result, err := service.GetRepoStats(ctx, "owner/repo", time.Time{}, time.Time{}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), result.Commits)
+
+	status, err := service.GetIngestionStatus(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, models.CacheStats{Hits: 1, Misses: 2}, status.QueryCache["stats"])
+	store.AssertExpectations(t)
+}
+
+func TestGetLanguageStats_Cached(t *testing.T) {
+	ctx := context.Background()
+	tenantCtx := repository.WithTenant(ctx, "acme")
+	store := new(MockStore)
+	cache := newMemoryCache()
+	service := manager.NewService(store, nil, nil, cache, &config.ManagerConfig{QueryCacheStatsTTL: time.Minute})
+
+	all := []models.LanguageStats{{Language: "Go", Repositories: 2, Commits: 10}}
+	acme := []models.LanguageStats{{Language: "Go", Repositories: 1, Commits: 4}}
+	store.On("GetLanguageStats", ctx).Return(all, nil).Once()
+	store.On("GetLanguageStats", tenantCtx).Return(acme, nil).Once()
+
+	for range 2 {
+		result, err := service.GetLanguageStats(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, all, result)
+	}
+	// Each tenant's statistics are cached apart.
+	result, err := service.GetLanguageStats(tenantCtx)
+	assert.NoError(t, err)
+	assert.Equal(t, acme, result)
+	store.AssertExpectations(t)
+
+	assert.Len(t, cache.ttls, 2)
+	for _, ttl := range cache.ttls {
+		assert.Equal(t, time.Minute, ttl)
+	}
+}
+
+func TestGetLanguageStats_Uncached(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := manager.NewService(store, nil, nil, newMemoryCache(), &config.ManagerConfig{})
+
+	stats := []models.LanguageStats{{Language: "Go", Repositories: 2, Commits: 10}}
+	store.On("GetLanguageStats", ctx).Return(stats, nil).Twice()
+
+	for range 2 {
+		result, err := service.GetLanguageStats(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, stats, result)
+	}
+	store.AssertExpectations(t)
+}
+
+func TestForceBroadcast_Cooldown(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := manager.NewService(store, nil, nil, nil, &config.ManagerConfig{BroadcastCooldown: time.Hour})
+
+	intent := &models.Intent{ID: uuid.New(), RepositoryName: "owner/repo", Status: models.Completed, IsActive: true}
+	broadcast := *intent
+	broadcast.Status = models.Broadcast
+	store.On("FindIntent", ctx, intent.ID).Return(intent, nil).Twice()
+	// The forced intent is marked broadcast until the monitor reports.
+	store.On("UpdateIntent", ctx, mock.MatchedBy(func(u models.IntentUpdate) bool {
+		return u.ID == intent.ID && *u.Status == models.Broadcast
+	})).Return(&broadcast, nil).Once()
+	store.On("SaveIntentTransition", ctx, mock.MatchedBy(func(tr models.IntentTransition) bool {
+		return tr.From == models.Completed && tr.To == models.Broadcast
+	}), mock.Anything).Return(nil).Once()
+
+	result, err := service.ForceBroadcast(ctx, intent.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.Broadcast, result.Status)
+
+	result, err = service.ForceBroadcast(ctx, intent.ID)
+	assert.Nil(t, result)
+	assert.Equal(t, manager.ErrBroadcastTooSoon, err)
+	store.AssertExpectations(t)
+}
+
+func TestRebroadcastIntent(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	store := new(MockStore)
+	service := manager.NewService(store, nil, nil, nil, &config.ManagerConfig{IntentsQueueName: "intents", BroadcastCooldown: time.Hour})
+	b := broker.NewMemory(broker.MemoryOptions{})
+	defer b.Close()
+
+	intent := &models.Intent{ID: uuid.New(), RepositoryName: "owner/repo", Status: models.Broadcast, IsActive: true}
+	pending := *intent
+	pending.Status = models.PendingBroadcast
+	broadcast := *intent
+	store.On("FindIntent", ctx, intent.ID).Return(intent, nil).Twice()
+	store.On("UpdateIntent", ctx, mock.MatchedBy(func(u models.IntentUpdate) bool {
+		return u.ID == intent.ID && *u.Status == models.PendingBroadcast
+	})).Return(&pending, nil).Once()
+	store.On("SaveIntentTransition", ctx, mock.MatchedBy(func(tr models.IntentTransition) bool {
+		return tr.From == models.Broadcast && tr.To == models.PendingBroadcast
+	}), mock.Anything).Return(nil).Once()
+
+	// Once published, the broadcaster marks it broadcast again.
+	published := make(chan struct{})
+	store.On("FindIntent", mock.Anything, intent.ID).Return(&pending, nil).Once()
+	store.On("UpdateIntent", mock.Anything, mock.MatchedBy(func(u models.IntentUpdate) bool {
+		return u.ID == intent.ID && *u.Status == models.Broadcast
+	})).Return(&broadcast, nil).Once()
+	store.On("SaveIntentTransition", mock.Anything, mock.MatchedBy(func(tr models.IntentTransition) bool {
+		return tr.From == models.PendingBroadcast && tr.To == models.Broadcast
+	}), mock.Anything).Return(nil).Once().Run(func(mock.Arguments) { close(published) })
+
+	msgs, err := b.Consume(ctx, "intents")
+	assert.NoError(t, err)
+
+	result, err := service.RebroadcastIntent(ctx, intent.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.PendingBroadcast, result.Status)
+
+	// The cooldown is shared with ForceBroadcast.
+	result, err = service.ForceBroadcast(ctx, intent.ID)
+	assert.Nil(t, result)
+	assert.Equal(t, manager.ErrBroadcastTooSoon, err)
+
+	go service.StartBroadCast(ctx, b)
+	select {
+	case d := <-msgs:
+		var command events.IntentCommand
+		assert.NoError(t, json.Unmarshal(d.Body, &command))
+		assert.Equal(t, events.NewIntentKind, command.Kind)
+		assert.Equal(t, intent.ID, command.Intent.ID)
+	case <-ctx.Done():
+		t.Fatal("rebroadcast was not published")
+	}
+	select {
+	case <-published:
+	case <-ctx.Done():
+		t.Fatal("rebroadcast intent was not marked broadcast")
+	}
+	store.AssertExpectations(t)
+}
+
+func TestForceBroadcast_PausedIntent(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	intent := &models.Intent{ID: uuid.New(), RepositoryName: "owner/repo", Status: models.Paused}
+	store.On("FindIntent", ctx, intent.ID).Return(intent, nil).Once()
+
+	result, err := service.ForceBroadcast(ctx, intent.ID)
+	assert.Nil(t, result)
+	assert.Equal(t, manager.ErrIntentInactive, err)
+}
+
+func TestStartReindex(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	store := new(MockStore)
+	service := manager.NewService(store, nil, nil, nil, &config.ManagerConfig{MonitorQueueName: "monitor"})
+	b := broker.NewMemory(broker.MemoryOptions{})
+	defer b.Close()
+
+	intent := &models.Intent{ID: uuid.New(), RepositoryName: "owner/repo", Status: models.Completed, IsActive: true}
+	reindex := &models.Reindex{ID: uuid.New(), RepositoryID: 1, IntentID: intent.ID, Status: models.ReindexBuilding}
+	store.On("FindIntent", ctx, intent.ID).Return(intent, nil).Once()
+	store.On("CreateReindex", ctx, intent.ID, "owner/repo").Return(reindex, nil).Once()
+
+	result, err := service.StartReindex(ctx, intent.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, reindex, result)
+
+	// The reindex run goes straight to the monitor.
+	msgs, err := b.Consume(ctx, "monitor")
+	assert.NoError(t, err)
+	go service.StartBroadCast(ctx, b)
+	select {
+	case d := <-msgs:
+		var command events.IntentCommand
+		assert.NoError(t, json.Unmarshal(d.Body, &command))
+		assert.Equal(t, intent.ID, command.Intent.ID)
+		assert.Equal(t, &reindex.ID, command.Intent.ReindexID)
+	case <-ctx.Done():
+		t.Fatal("reindex run was not published")
+	}
+	store.AssertExpectations(t)
+}
+
+func TestStartReindex_NotIndexed(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	intent := &models.Intent{ID: uuid.New(), RepositoryName: "owner/repo", Status: models.Created, IsActive: true}
+	store.On("FindIntent", ctx, intent.ID).Return(intent, nil).Once()
+	store.On("CreateReindex", ctx, intent.ID, "owner/repo").Return(nil, nil).Once()
+
+	result, err := service.StartReindex(ctx, intent.ID)
+	assert.Nil(t, result)
+	assert.Equal(t, manager.ErrNotIndexed, err)
+	store.AssertExpectations(t)
+}
+
+func TestProcessCommitCommands_ReindexCommits(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	reindexID := uuid.New()
+	store.On("SaveShadowCommits", ctx, reindexID, mock.MatchedBy(func(commits []*models.Commit) bool {
+		return len(commits) == 1 && commits[0].Hash == "abc"
+	})).Return(nil).Once()
+	// Still waiting on the run to complete.
+	store.On("SwapReindex", ctx, reindexID, mock.Anything).Return(nil, nil).Once()
+
+	body := []byte(`{"kind":"new_commits","paylad":{"reindex_id":"` + reindexID.String() + `","commits":[{"hash":"abc","repository":{"full_name":"owner/repo"}}]}}`)
+	err := service.ProcessCommitCommands(ctx, body)
+	assert.NoError(t, err)
+	// Shadow commits leave the live commits alone.
+	store.AssertNotCalled(t, "SaveManyCommit", mock.Anything, mock.Anything, mock.Anything)
+	store.AssertExpectations(t)
+}
+
+func TestProcessCommitCommands_ReindexCompleted(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	intentID, reindexID := uuid.New(), uuid.New()
+	expected := int64(42)
+	store.On("FindIntent", ctx, intentID).Return(&models.Intent{ID: intentID, RepositoryName: "owner/repo", Status: models.Ingesting}, nil).Once()
+	store.On("SetReindexExpected", ctx, reindexID, expected).Return(nil).Once()
+	store.On("SwapReindex", ctx, reindexID, mock.Anything).Return(&models.Reindex{
+		ID: reindexID, IntentID: intentID, Status: models.ReindexSwapped, ExpectedCommits: &expected,
+	}, nil).Once()
+	store.On("SaveIntentTransition", ctx, mock.Anything, mock.Anything).Return(nil).Once()
+	store.On("UpdateIntent", ctx, mock.MatchedBy(func(u models.IntentUpdate) bool {
+		return u.ID == intentID && *u.Status == models.Completed
+	})).Return(&models.Intent{ID: intentID}, nil).Once()
+	store.On("GetRepo", ctx, "owner/repo").Return(&models.Repository{FullName: "owner/repo"}, nil).Once()
+	store.On("SaveIntentProgress", ctx, intentID, mock.Anything).Return(nil).Once()
+
+	body := []byte(`{"kind":"intent_completed","paylad":{"progress":{"intent_id":"` + intentID.String() + `","reindex_id":"` + reindexID.String() + `","commits":42,"at":"2024-06-01T00:00:00Z"}}}`)
+	err := service.ProcessCommitCommands(ctx, body)
+	assert.NoError(t, err)
+	store.AssertExpectations(t)
+}
+
+func TestSearch(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	results := &models.SearchResults{
+		Query:        "indexer",
+		Repositories: models.SearchGroup[models.Repository]{Data: []models.Repository{{FullName: "noelukwa/indexer"}}, TotalCount: 1},
+		Page:         1,
+		PerPage:      20,
+	}
+	store.On("Search", ctx, "indexer", repository.Pagination{Page: 1, PerPage: 20}).Return(results, nil).Once()
+
+	result, err := service.Search(ctx, "  indexer ", 1, 20)
+	assert.NoError(t, err)
+	assert.Equal(t, results, result)
+	store.AssertExpectations(t)
+}
+
+func TestSearch_QueryTooShort(t *testing.T) {
+	store := new(MockStore)
+	service := newTestService(store)
+
+	result, err := service.Search(context.Background(), " a ", 1, 20)
+	assert.Nil(t, result)
+	assert.Equal(t, manager.ErrInvalidSearchQuery, err)
+	store.AssertNotCalled(t, "Search")
+}
+
+func TestSearchCommits(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+	matches := repository.Paginated[models.CommitMatch]{
+		Data:       []models.CommitMatch{{Hash: "abc123", Message: "Fix memory leak", Rank: 0.1}},
+		TotalCount: 1,
+		Page:       1,
+		PerPage:    20,
+	}
+	store.On("SearchCommitMessages", ctx, mock.MatchedBy(func(f models.CommitSearchFilter) bool {
+		return f.Query == "memory leak" && *f.RepositoryName == "owner/repo" &&
+			f.StartDate.Equal(since) && f.EndDate.Equal(until.AddDate(0, 0, 1).Add(-time.Nanosecond))
+	}), repository.Pagination{Page: 1, PerPage: 20}).Return(matches, nil).Once()
+
+	result, err := service.SearchCommits(ctx, " memory leak ", "Owner/Repo", since, until, 1, 20)
+	assert.NoError(t, err)
+	assert.Equal(t, matches, result)
+	store.AssertExpectations(t)
+}
+
+func TestSearchCommits_InvalidRequest(t *testing.T) {
+	store := new(MockStore)
+	service := newTestService(store)
+	ctx := context.Background()
+
+	_, err := service.SearchCommits(ctx, "a", "", time.Time{}, time.Time{}, 1, 20)
+	assert.Equal(t, manager.ErrInvalidSearchQuery, err)
+
+	since := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	_, err = service.SearchCommits(ctx, "leak", "", since, since.AddDate(0, 0, -1), 1, 20)
+	assert.Equal(t, manager.ErrInvalidEndDate, err)
+	store.AssertNotCalled(t, "SearchCommitMessages")
+}
+
+func TestGetAuthorProfile(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	profile := &models.AuthorProfile{
+		Author:       models.Author{ID: 1, Username: "octocat"},
+		TotalCommits: 3,
+		Repositories: 1,
+		ByRepository: []models.AuthorRepoActivity{{Repository: "owner/repo", Commits: 3}},
+	}
+	store.On("GetAuthorProfile", ctx, "octocat").Return(profile, nil).Once()
+
+	result, err := service.GetAuthorProfile(ctx, " octocat ")
+	assert.NoError(t, err)
+	assert.Equal(t, profile, result)
+	store.AssertExpectations(t)
+}
+
+func TestGetAuthorProfile_NotFound(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	store.On("GetAuthorProfile", ctx, "ghost").Return(nil, repository.ErrNotFound).Once()
+
+	_, err := service.GetAuthorProfile(ctx, "ghost")
+	assert.Equal(t, manager.ErrAuthorNotFound, err)
+
+	_, err = service.GetAuthorProfile(ctx, " ")
+	assert.Equal(t, manager.ErrAuthorNotFound, err)
+	store.AssertExpectations(t)
+}
+
+func TestGetActiveRepos_DefaultWindow(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	language := "Go"
+	filter := models.RepoActivityFilter{StartDate: today.AddDate(0, 0, -29), EndDate: today, Language: &language}
+	store.On("GetActiveRepos", ctx, filter, repository.Pagination{Page: 1, PerPage: 20}).
+		Return(repository.Paginated[models.RepoActivity]{Page: 1, PerPage: 20}, nil).Once()
+
+	result, err := service.GetActiveRepos(ctx, time.Time{}, time.Time{}, " Go ", 1, 20)
+	assert.NoError(t, err)
+	assert.NotNil(t, result.Data)
+	assert.Empty(t, result.Data)
+	store.AssertExpectations(t)
+}
+
+func TestGetActiveRepos_InvalidRange(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	start := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+	_, err := service.GetActiveRepos(ctx, start, start.AddDate(0, 0, -1), "", 1, 20)
+	assert.Equal(t, manager.ErrInvalidDateRange, err)
+	store.AssertExpectations(t)
+}
+
+func TestStartBroadCast_ForcedGoesToMonitor(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	store := new(MockStore)
+	service := manager.NewService(store, nil, nil, nil, &config.ManagerConfig{MonitorQueueName: "monitor", BroadcastCooldown: time.Hour})
+	b := broker.NewMemory(broker.MemoryOptions{})
+	defer b.Close()
+
+	intent := &models.Intent{ID: uuid.New(), RepositoryName: "owner/repo", Status: models.Completed, IsActive: true}
+	store.On("FindIntent", ctx, intent.ID).Return(intent, nil).Once()
+	store.On("UpdateIntent", ctx, mock.Anything).Return(intent, nil).Once()
+	store.On("SaveIntentTransition", ctx, mock.Anything, mock.Anything).Return(nil).Once()
+
+	msgs, err := b.Consume(ctx, "monitor")
+	assert.NoError(t, err)
+	go service.StartBroadCast(ctx, b)
+
+	_, err = service.ForceBroadcast(ctx, intent.ID)
+	assert.NoError(t, err)
+
+	select {
+	case d := <-msgs:
+		var command events.IntentCommand
+		assert.NoError(t, json.Unmarshal(d.Body, &command))
+		assert.Equal(t, events.NewIntentKind, command.Kind)
+		assert.Equal(t, intent.ID, command.Intent.ID)
+	case <-ctx.Done():
+		t.Fatal("forced broadcast was not published")
+	}
+}
+
+func TestStartBroadCast_PublishFailure(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	store := new(MockStore)
+	service := manager.NewService(store, nil, nil, nil, &config.ManagerConfig{MonitorQueueName: "monitor", BroadcastCooldown: time.Hour})
+	b := broker.NewMemory(broker.MemoryOptions{FailRate: 1})
+	defer b.Close()
+
+	intent := &models.Intent{ID: uuid.New(), RepositoryName: "owner/repo", Status: models.Completed, IsActive: true}
+	store.On("FindIntent", ctx, intent.ID).Return(intent, nil).Once()
+	store.On("UpdateIntent", ctx, mock.Anything).Return(intent, nil).Once()
+	store.On("SaveIntentTransition", ctx, mock.Anything, mock.Anything).Return(nil).Once()
+
+	msgs, err := b.Consume(ctx, "monitor")
+	assert.NoError(t, err)
+	go service.StartBroadCast(ctx, b)
+
+	_, err = service.ForceBroadcast(ctx, intent.ID)
+	assert.NoError(t, err)
+
+	select {
+	case <-msgs:
+		t.Fatal("failed publish was delivered")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestStartBroadCast_PublishesQueuedIntents(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	store := new(MockStore)
+	service := manager.NewService(store, nil, nil, nil, &config.ManagerConfig{IntentsQueueName: "intents"})
+	b := broker.NewMemory(broker.MemoryOptions{})
+	defer b.Close()
+
+	intent := &models.Intent{ID: uuid.New(), RepositoryName: "owner/repo", Status: models.Completed, IsActive: true}
+	updated := *intent
+	updated.StartDate = &time.Time{}
+	store.On("UpdateIntent", ctx, mock.Anything).Return(&updated, nil)
+	// Marking the intent broadcast is best effort once it is published.
+	store.On("FindIntent", mock.Anything, intent.ID).Return(nil, fmt.Errorf("intent not found"))
+
+	// Queuing doesn't wait for the broadcaster, which isn't running yet.
+	assert.NoError(t, service.ResetIntentStartDate(ctx, intent.ID, time.Now().AddDate(0, -1, 0)))
+	assert.NoError(t, service.ResetIntentStartDate(ctx, intent.ID, time.Now().AddDate(0, -2, 0)))
+
+	msgs, err := b.Consume(ctx, "intents")
+	assert.NoError(t, err)
+	go service.StartBroadCast(ctx, b)
+
+	for i := 0; i < 2; i++ {
+		select {
+		case d := <-msgs:
+			var command events.IntentCommand
+			assert.NoError(t, json.Unmarshal(d.Body, &command))
+			assert.Equal(t, events.UpdateIntentKind, command.Kind)
+			assert.Equal(t, intent.ID, command.Intent.ID)
+		case <-ctx.Done():
+			t.Fatal("queued intent was not published")
+		}
+	}
+}
+
+func TestProcessCommitCommands_NewReviews(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	store.On("GetRepo", ctx, "owner/repo").Return(&models.Repository{ID: 1, FullName: "owner/repo"}, nil).Once()
+	store.On("SaveReviews", ctx, int64(1), mock.MatchedBy(func(reviews []*models.PullRequestReview) bool {
+		return len(reviews) == 1 && reviews[0].Reviewer == "alice" && reviews[0].PullRequest == 7
+	})).Return(nil).Once()
+
+	body := []byte(`{"kind":"new_reviews","paylad":{"reviews":[{"id":1,"repository":"Owner/Repo","pull_request":7,"reviewer":"alice","state":"APPROVED"}]}}`)
+	err := service.ProcessCommitCommands(ctx, body)
+	assert.NoError(t, err)
+	store.AssertExpectations(t)
+}
+
+func TestGetTopReviewers(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	reviewers := repository.Paginated[models.ReviewerStats]{
+		Data:       []models.ReviewerStats{{Reviewer: "alice", Reviews: 3, Approvals: 2, PullRequests: 2}},
+		TotalCount: 1,
+		Page:       1,
+		PerPage:    10,
+	}
+	store.On("GetRepo", ctx, "owner/repo").Return(&models.Repository{ID: 1, FullName: "owner/repo"}, nil).Once()
+	store.On("GetTopReviewers", ctx, int64(1), mock.MatchedBy(func(start *time.Time) bool {
+		return start.Equal(since)
+	}), mock.Anything, repository.Pagination{Page: 1, PerPage: 10}).Return(reviewers, nil).Once()
+
+	result, err := service.GetTopReviewers(ctx, "Owner/Repo", since, time.Time{}, 1, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, reviewers, result)
+	store.AssertExpectations(t)
+}
+
+func TestGetReviewTurnaround_RepositoryNotFound(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	store.On("GetRepo", ctx, "owner/missing").Return(nil, repository.ErrNotFound).Once()
+
+	result, err := service.GetReviewTurnaround(ctx, "owner/missing", time.Time{}, time.Time{})
+	assert.Nil(t, result)
+	assert.Equal(t, manager.ErrRepositoryNotFound, err)
+	store.AssertNotCalled(t, "GetReviewTurnaround", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestProcessCommitCommands_NewWorkflowRuns(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	store.On("GetRepo", ctx, "owner/repo").Return(&models.Repository{ID: 1, FullName: "owner/repo"}, nil).Once()
+	store.On("SaveWorkflowRuns", ctx, int64(1), mock.MatchedBy(func(runs []*models.WorkflowRun) bool {
+		return len(runs) == 1 && runs[0].Conclusion == "failure"
+	})).Return(nil).Once()
+
+	body := []byte(`{"kind":"new_workflow_runs","paylad":{"workflow_runs":[{"id":1,"repository":"owner/repo","workflow":"CI","conclusion":"failure"}]}}`)
+	err := service.ProcessCommitCommands(ctx, body)
+	assert.NoError(t, err)
+	store.AssertExpectations(t)
+}
+
+func TestGetCIStats(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	store.On("GetRepo", ctx, "owner/repo").Return(&models.Repository{ID: 1, FullName: "owner/repo"}, nil).Once()
+	store.On("GetCIStats", ctx, models.CIFilter{RepositoryID: 1, Workflow: "CI"}).
+		Return(&models.CIStats{Runs: 4, Passed: 3, MedianSeconds: 90}, nil).Once()
+
+	stats, err := service.GetCIStats(ctx, "owner/repo", time.Time{}, time.Time{}, "CI")
+	assert.NoError(t, err)
+	assert.Equal(t, 0.75, stats.PassRate)
+	assert.Equal(t, float64(90), stats.MedianSeconds)
+	store.AssertExpectations(t)
+}
+
+func TestGetCommits(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	store.On("GetRepo", ctx, "owner/repo").Return(&models.Repository{ID: 1, FullName: "owner/repo"}, nil).Once()
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+	author := "alice"
+	store.On("FindCommits", ctx, mock.MatchedBy(func(filter models.CommitsFilter) bool {
+		// The end date includes the whole of its day.
+		return filter.RepositoryName == "owner/repo" && *filter.AuthorUsername == author &&
+			filter.StartDate.Equal(since) && filter.EndDate.Equal(until.AddDate(0, 0, 1).Add(-time.Nanosecond))
+	}), repository.Pagination{Page: 2, PerPage: 10}).
+		Return(repository.Paginated[models.Commit]{Data: []models.Commit{{Hash: "a"}}, TotalCount: 11, Page: 2, PerPage: 10}, nil).Once()
+	// Commits before February have been archived, so some in the range
+	// are missing.
+	notice := &models.ArchiveNotice{ArchivedBefore: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), Commits: 300}
+	store.On("GetArchiveSummary", ctx, int64(1)).Return(notice, nil).Once()
+
+	page, err := service.GetCommits(ctx, models.CommitsFilter{
+		RepositoryName: "Owner/Repo",
+		StartDate:      &since,
+		EndDate:        &until,
+		AuthorUsername: &author,
+	}, 2, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(11), page.TotalCount)
+	assert.Equal(t, "a", page.Commits[0].Hash)
+	assert.Equal(t, notice, page.Archived)
+	// The caller's end date is left alone.
+	assert.Equal(t, time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), until)
+	store.AssertExpectations(t)
+}
+
+func TestGetCommitDelta(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	repo := &models.Repository{ID: 1, FullName: "owner/repo"}
+	store.On("GetRepo", ctx, "owner/repo").Return(repo, nil).Twice()
+	at := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	store.On("FindCommitCursor", ctx, int64(1), "abc").Return(&models.CommitCursor{CreatedAt: at, Hash: "abc"}, nil).Once()
+	// One more commit than the limit is left for the next delta.
+	store.On("FindCommitsAfter", ctx, int64(1), models.CommitCursor{CreatedAt: at, Hash: "abc"}, 3).
+		Return([]models.Commit{
+			{Hash: "b", CreatedAt: at},
+			{Hash: "c", CreatedAt: at.Add(time.Hour)},
+			{Hash: "d", CreatedAt: at.Add(2 * time.Hour)},
+		}, nil).Once()
+
+	delta, err := service.GetCommitDelta(ctx, "owner/repo", models.CommitDeltaQuery{SinceSHA: "ABC", Limit: 2})
+	assert.NoError(t, err)
+	assert.Len(t, delta.Data, 2)
+	assert.True(t, delta.HasMore)
+
+	// The cursor continues after the last commit returned.
+	store.On("FindCommitsAfter", ctx, int64(1), models.CommitCursor{CreatedAt: at.Add(time.Hour), Hash: "c"}, 3).
+		Return([]models.Commit{{Hash: "d", CreatedAt: at.Add(2 * time.Hour)}}, nil).Once()
+	delta, err = service.GetCommitDelta(ctx, "owner/repo", models.CommitDeltaQuery{Cursor: delta.NextCursor, Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, "d", delta.Data[0].Hash)
+	assert.False(t, delta.HasMore)
+	assert.NotEmpty(t, delta.NextCursor)
+	store.AssertExpectations(t)
+}
+
+func TestGetCommitDelta_InvalidStart(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	_, err := service.GetCommitDelta(ctx, "owner/repo", models.CommitDeltaQuery{Cursor: "x", SinceSHA: "abc"})
+	assert.Equal(t, manager.ErrAmbiguousDelta, err)
+
+	store.On("GetRepo", ctx, "owner/repo").Return(&models.Repository{ID: 1, FullName: "owner/repo"}, nil).Twice()
+	_, err = service.GetCommitDelta(ctx, "owner/repo", models.CommitDeltaQuery{Cursor: "not a cursor"})
+	assert.Equal(t, manager.ErrInvalidCursor, err)
+
+	store.On("FindCommitCursor", ctx, int64(1), "abc").Return(nil, repository.ErrNotFound).Once()
+	_, err = service.GetCommitDelta(ctx, "owner/repo", models.CommitDeltaQuery{SinceSHA: "abc"})
+	assert.Equal(t, manager.ErrDeltaSHANotIndexed, err)
+	store.AssertExpectations(t)
+}
+
+func TestGetQualityReport(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	repo := &models.Repository{ID: 1, FullName: "owner/repo", CreatedAt: time.Date(2020, 3, 4, 10, 0, 0, 0, time.UTC)}
+	store.On("GetRepo", ctx, "owner/repo").Return(repo, nil).Once()
+
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2022, 6, 30, 0, 0, 0, 0, time.UTC)
+	store.On("FindIntents", ctx, models.IntentFilter{RepositoryName: &repo.FullName}, repository.Pagination{Page: 1, PerPage: 100}).
+		Return(repository.Paginated[models.Intent]{Data: []models.Intent{
+			{RepositoryName: "owner/repo", StartDate: &start, Until: &until},
+			{RepositoryName: "owner/repo", Until: &until},
+		}}, nil).Once()
+	store.On("GetQuality", ctx, int64(1)).
+		Return(&models.QualityReport{Commits: 200, BotCommits: 30, UnattributedCommits: 12}, nil).Once()
+	gaps := []models.CoverageGap{{Start: "2020-03-04", End: "2020-12-31", Days: 303}}
+	store.On("GetCoverageGaps", ctx, int64(1), time.Date(2020, 3, 4, 0, 0, 0, 0, time.UTC), until, manager.DefaultMinGapDays).
+		Return(gaps, nil).Once()
+
+	report, err := service.GetQualityReport(ctx, "Owner/Repo", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(15), report.BotPercentage)
+	assert.Equal(t, int64(12), report.UnattributedCommits)
+	assert.Equal(t, &models.Coverage{Since: "2020-03-04", Until: "2022-06-30", MinGapDays: manager.DefaultMinGapDays, Gaps: gaps}, report.Coverage)
+	store.AssertExpectations(t)
+}
+
+func TestGetQualityReport_NoIntents(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	repo := &models.Repository{ID: 1, FullName: "owner/repo"}
+	store.On("GetRepo", ctx, "owner/repo").Return(repo, nil).Once()
+	store.On("FindIntents", ctx, models.IntentFilter{RepositoryName: &repo.FullName}, repository.Pagination{Page: 1, PerPage: 100}).
+		Return(repository.Paginated[models.Intent]{}, nil).Once()
+	store.On("GetQuality", ctx, int64(1)).Return(&models.QualityReport{}, nil).Once()
+
+	report, err := service.GetQualityReport(ctx, "owner/repo", 7)
+	assert.NoError(t, err)
+	assert.Nil(t, report.Coverage)
+	assert.Zero(t, report.BotPercentage)
+	store.AssertExpectations(t)
+}
+
+func TestProcessCommitCommands_NewGitHubStats(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	store.On("GetRepo", ctx, "owner/repo").Return(&models.Repository{ID: 1, FullName: "owner/repo"}, nil).Once()
+	store.On("SaveGitHubStats", ctx, int64(1), mock.MatchedBy(func(stats *models.GitHubStats) bool {
+		return len(stats.PunchCard) == 1 && stats.PunchCard[0].Commits == 4
+	})).Return(nil).Once()
+
+	body := []byte(`{"kind":"new_github_stats","paylad":{"github_stats":{"repository":"owner/repo","punch_card":[{"day":1,"hour":9,"commits":4}]}}}`)
+	err := service.ProcessCommitCommands(ctx, body)
+	assert.NoError(t, err)
+	store.AssertExpectations(t)
+}
+
+func TestGetGitHubStats_NotFetched(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	store.On("GetRepo", ctx, "owner/repo").Return(&models.Repository{ID: 1, FullName: "owner/repo"}, nil).Once()
+	store.On("GetGitHubStats", ctx, int64(1)).Return(nil, repository.ErrNotFound).Once()
+
+	stats, err := service.GetGitHubStats(ctx, "owner/repo")
+	assert.Nil(t, stats)
+	assert.Equal(t, manager.ErrGitHubStatsNotFound, err)
+}
+
+func TestProcessCommitCommands_NewSecurityAlerts(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	store.On("GetRepo", ctx, "owner/repo").Return(&models.Repository{ID: 1, FullName: "owner/repo"}, nil).Once()
+	store.On("SaveSecurityAlerts", ctx, int64(1), mock.MatchedBy(func(alerts []*models.SecurityAlert) bool {
+		return len(alerts) == 1 && alerts[0].Severity == "critical"
+	})).Return(nil).Once()
+
+	body := []byte(`{"kind":"new_security_alerts","paylad":{"security_alerts":[{"number":1,"repository":"owner/repo","state":"open","severity":"critical"}]}}`)
+	err := service.ProcessCommitCommands(ctx, body)
+	assert.NoError(t, err)
+	store.AssertExpectations(t)
+}
+
+func TestGetOpenAlertsSummary(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	store.On("GetOpenAlertsSummary", ctx).Return(&models.SecurityAlertSummary{
+		Repositories: []models.RepositorySecurityAlerts{
+			{Repository: "owner/a", SecurityAlertCounts: models.SecurityAlertCounts{Critical: 1, High: 2, Total: 3}},
+			{Repository: "owner/b", SecurityAlertCounts: models.SecurityAlertCounts{Low: 4, Total: 4}},
+		},
+	}, nil).Once()
+
+	summary, err := service.GetOpenAlertsSummary(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, models.SecurityAlertCounts{Critical: 1, High: 2, Low: 4, Total: 7}, summary.Open)
+	store.AssertExpectations(t)
 }
 
-func (m *MockStore) SaveIntent(ctx context.Context, freshIntent models.Intent) (*models.Intent, error) {
-	args := m.Called(ctx, freshIntent)
-	return args.Get(0).(*models.Intent), args.Error(1)
+func TestPipelineStats(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	reindexID := uuid.New()
+	store.On("SaveShadowCommits", ctx, reindexID, mock.Anything).Return(nil).Once()
+	store.On("SwapReindex", ctx, reindexID, mock.Anything).Return(nil, nil).Once()
+
+	now := time.Now().UTC()
+	fetchedAt := now.Add(-30 * time.Second)
+	commit, err := json.Marshal(&models.Commit{
+		Hash:       "abc",
+		CreatedAt:  now.Add(-2 * time.Hour),
+		FetchedAt:  &fetchedAt,
+		Repository: models.Repository{FullName: "owner/repo"},
+	})
+	assert.NoError(t, err)
+	body := []byte(`{"kind":"new_commits","paylad":{"reindex_id":"` + reindexID.String() + `","commits":[` + string(commit) + `]}}`)
+	assert.NoError(t, service.ProcessCommitCommands(ctx, body))
+
+	stats := service.GetPipelineStats()
+	assert.Len(t, stats.Stages, 3)
+	for _, stage := range stats.Stages {
+		assert.Equal(t, int64(1), stage.Commits, stage.Stage)
+		assert.InDelta(t, 1.0/60, stage.PerSecond, 1e-9, stage.Stage)
+	}
+	assert.Equal(t, "authored_to_fetched", stats.Latency[0].Span)
+	assert.InDelta(t, 2*3600-30, stats.Latency[0].MedianSeconds, 1)
+	assert.Equal(t, "fetched_to_received", stats.Latency[1].Span)
+	assert.InDelta(t, 30, stats.Latency[1].P90Seconds, 1)
+
+	var metrics bytes.Buffer
+	assert.NoError(t, service.WriteMetrics(&metrics))
+	assert.Contains(t, metrics.String(), `indexer_pipeline_commits_total{stage="persisted"} 1`)
+	assert.Contains(t, metrics.String(), `indexer_pipeline_latency_seconds_bucket{span="authored_to_fetched",le="3600"} 0`)
+	assert.Contains(t, metrics.String(), `indexer_pipeline_latency_seconds_bucket{span="authored_to_fetched",le="21600"} 1`)
+}
+
+func TestExportIntents(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
+
+	credentialID := uuid.New()
+	paused := models.Intent{ID: uuid.New(), RepositoryName: "owner/repo", Status: models.Paused}
+	active := models.Intent{ID: uuid.New(), RepositoryName: "owner/repo", Status: models.Completed, IsActive: true,
+		IntentOptions: models.IntentOptions{PathFilters: []string{"docs/**"}, CredentialID: &credentialID}}
+	other := models.Intent{ID: uuid.New(), RepositoryName: "owner/other", Status: models.Paused}
+	store.On("FindIntents", ctx, models.IntentFilter{}, repository.Pagination{Page: 1, PerPage: 500}).
+		Return(repository.Paginated[models.Intent]{Data: []models.Intent{paused, active, other}, TotalCount: 3}, nil).Once()
+
+	export, err := service.ExportIntents(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, models.IntentExportVersion, export.Version)
+	// One intent per repository, its active one first.
+	assert.Len(t, export.Intents, 2)
+	assert.Equal(t, "owner/repo", export.Intents[0].Repository)
+	assert.True(t, export.Intents[0].IsActive)
+	assert.Equal(t, []string{"docs/**"}, export.Intents[0].PathFilters)
+	assert.Nil(t, export.Intents[0].CredentialID)
+	assert.Equal(t, "owner/other", export.Intents[1].Repository)
+	store.AssertExpectations(t)
 }
 
-func (m *MockStore) UpdateIntent(ctx context.Context, update models.IntentUpdate) (*models.Intent, error) {
-	args := m.Called(ctx, update)
-	return args.Get(0).(*models.Intent), args.Error(1)
-}
+func TestImportIntents(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
 
-func (m *MockStore) SaveIntentError(ctx context.Context, err models.IntentError) error {
-	args := m.Called(ctx, err)
-	return args.Error(0)
-}
+	existing := "owner/existing"
+	store.On("FindIntents", ctx, models.IntentFilter{RepositoryName: &existing}, mock.Anything).
+		Return(repository.Paginated[models.Intent]{Data: []models.Intent{{RepositoryName: existing}}, TotalCount: 1}, nil).Once()
+	store.On("FindIntents", ctx, mock.Anything, mock.Anything).Return(repository.Paginated[models.Intent]{}, nil)
+	store.On("SaveIntentTransition", ctx, mock.Anything, mock.Anything).Return(nil).Maybe()
+	store.On("SaveIntent", ctx, mock.MatchedBy(func(i models.Intent) bool {
+		return i.RepositoryName == "owner/active" && i.IsActive && i.Status == models.Created
+	})).Return(&models.Intent{ID: uuid.New(), RepositoryName: "owner/active", IsActive: true, Status: models.Created}, nil).Once()
+	store.On("SaveIntent", ctx, mock.MatchedBy(func(i models.Intent) bool {
+		return i.RepositoryName == "owner/paused" && !i.IsActive && i.Status == models.Paused
+	})).Return(&models.Intent{ID: uuid.New(), RepositoryName: "owner/paused", Status: models.Paused}, nil).Once()
 
-func (m *MockStore) FindIntents(ctx context.Context, filter models.IntentFilter, pag repository.Pagination) (repository.Paginated[models.Intent], error) {
-	args := m.Called(ctx, filter, pag)
-	return args.Get(0).(repository.Paginated[models.Intent]), args.Error(1)
+	result, err := service.ImportIntents(ctx, &models.IntentExport{
+		Version: models.IntentExportVersion,
+		Intents: []models.IntentDefinition{
+			{Repository: "Owner/Active", IsActive: true},
+			{Repository: "owner/paused"},
+			{Repository: existing, IsActive: true},
+			{Repository: "not-a-repo", IsActive: true},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.Created)
+	assert.Equal(t, []models.IntentImportSkip{
+		{Repository: existing, Reason: manager.ErrExistingIntent.Error()},
+		{Repository: "not-a-repo", Reason: manager.ErrInvalidRepository.Error()},
+	}, result.Skipped)
+	store.AssertExpectations(t)
 }
 
-func (m *MockStore) FindIntent(ctx context.Context, id uuid.UUID) (*models.Intent, error) {
-	args := m.Called(ctx, id)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*models.Intent), args.Error(1)
-}
+func TestImportIntents_UnsupportedVersion(t *testing.T) {
+	service := newTestService(new(MockStore))
 
-func (m *MockStore) SaveRepo(ctx context.Context, repo *models.Repository) error {
-	args := m.Called(ctx, repo)
-	return args.Error(0)
+	result, err := service.ImportIntents(context.Background(), &models.IntentExport{Version: 99})
+	assert.Nil(t, result)
+	assert.Equal(t, manager.ErrUnsupportedExport, err)
 }
 
-func (m *MockStore) GetRepo(ctx context.Context, name string) (*models.Repository, error) {
-	args := m.Called(ctx, name)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*models.Repository), args.Error(1)
-}
+func TestInstallRepositories(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
 
-func (m *MockStore) FindCommits(ctx context.Context, filter models.CommitsFilter, pag repository.Pagination) (repository.Paginated[models.Commit], error) {
-	args := m.Called(ctx, filter, pag)
-	return args.Get(0).(repository.Paginated[models.Commit]), args.Error(1)
-}
+	existing := "owner/existing"
+	store.On("FindIntents", ctx, models.IntentFilter{RepositoryName: &existing}, mock.Anything).
+		Return(repository.Paginated[models.Intent]{Data: []models.Intent{{RepositoryName: existing}}, TotalCount: 1}, nil).Once()
+	store.On("FindIntents", ctx, mock.Anything, mock.Anything).Return(repository.Paginated[models.Intent]{}, nil)
+	store.On("SaveIntentTransition", ctx, mock.Anything, mock.Anything).Return(nil).Maybe()
+	store.On("SaveIntent", ctx, mock.MatchedBy(func(i models.Intent) bool {
+		return i.RepositoryName == "owner/new" && i.IsActive && i.StartDate == nil && i.Until == nil
+	})).Return(&models.Intent{ID: uuid.New(), RepositoryName: "owner/new", IsActive: true, Status: models.Created}, nil).Once()
 
-func (m *MockStore) GetTopCommitters(ctx context.Context, repo string, startDate, endDate *time.Time, pagination repository.Pagination) (repository.Paginated[models.AuthorStats], error) {
-	args := m.Called(ctx, repo, startDate, endDate, pagination)
-	return args.Get(0).(repository.Paginated[models.AuthorStats]), args.Error(1)
+	change, err := service.InstallRepositories(ctx, []string{"Owner/New", existing})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"owner/new"}, change.Created)
+	assert.Equal(t, []models.IntentImportSkip{{Repository: existing, Reason: manager.ErrExistingIntent.Error()}}, change.Skipped)
+	assert.Len(t, store.outbox, 1)
+	store.AssertExpectations(t)
 }
 
-func (m *MockStore) SaveManyCommit(ctx context.Context, repoID int64, commits []*models.Commit) error {
-	args := m.Called(ctx, repoID, commits)
-	return args.Error(0)
-}
+func TestUninstallRepositories(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := newTestService(store)
 
-func (m *MockStore) SaveAuthor(ctx context.Context, author *models.Author) error {
-	args := m.Called(ctx, author)
-	return args.Error(0)
-}
+	intent := models.Intent{ID: uuid.New(), RepositoryName: "owner/repo", Status: models.Completed, IsActive: true}
+	paused := intent
+	paused.IsActive = false
+	paused.Status = models.Paused
 
-// Helper function to create a new service instance
-func newTestService(store repository.ManagerStore) *manager.Service {
-	cfg := &config.ManagerConfig{
-		IntentsQueueName: "test-queue",
-	}
-	return manager.NewService(store, cfg)
-}
+	store.On("FindIntents", ctx, mock.MatchedBy(func(f models.IntentFilter) bool {
+		return *f.RepositoryName == "owner/repo" && *f.IsActive
+	}), mock.Anything).Return(repository.Paginated[models.Intent]{Data: []models.Intent{intent}, TotalCount: 1}, nil).Once()
+	store.On("FindIntents", ctx, mock.Anything, mock.Anything).Return(repository.Paginated[models.Intent]{}, nil).Once()
+	store.On("UpdateIntent", ctx, mock.MatchedBy(func(u models.IntentUpdate) bool {
+		return u.ID == intent.ID && !*u.IsActive && *u.Status == models.Paused
+	})).Return(&paused, nil).Once()
+	store.On("SaveIntentTransition", ctx, mock.Anything, mock.Anything).Return(nil).Once()
 
-func TestCreateIntent(t *testing.T) {
+	change, err := service.UninstallRepositories(ctx, []string{"Owner/Repo", "owner/unknown"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"owner/repo"}, change.Paused)
+	assert.Empty(t, change.Created)
+	assert.Len(t, store.outbox, 1)
+	store.AssertExpectations(t)
+}
 
+func TestDeleteIntent(t *testing.T) {
+	ctx := context.Background()
 	store := new(MockStore)
 	service := newTestService(store)
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	repoName := "owner/repo"
-	startDate := time.Now().Add(-time.Hour)
-	intent := &models.Intent{
-		Status:         models.PendingBroadCast,
-		IsActive:       true,
-		ID:             uuid.New(),
-		RepositoryName: repoName,
-		StartDate:      startDate,
-		Until:          time.Now(),
-	}
 
-	store.On("SaveIntent", ctx, mock.AnythingOfType("models.Intent")).Return(intent, nil).Once()
+	intent := models.Intent{ID: uuid.New(), RepositoryName: "owner/repo", Status: models.Completed, IsActive: true}
+	deleted := intent
+	deleted.IsActive = false
+	deletedAt := time.Now()
+	deleted.DeletedAt = &deletedAt
 
-	result, err := service.CreateIntent(ctx, repoName, startDate)
+	store.On("FindIntent", ctx, intent.ID).Return(&intent, nil).Once()
+	store.On("DeleteIntent", ctx, intent.ID).Return(&deleted, nil).Once()
+
+	deletion, err := service.DeleteIntent(ctx, intent.ID, false)
 	assert.NoError(t, err)
-	assert.NotNil(t, result)
-	assert.Equal(t, repoName, result.RepositoryName)
+	assert.Equal(t, &deleted, deletion.Intent)
+	assert.Equal(t, int64(0), deletion.PurgedCommits)
+	assert.Len(t, store.outbox, 1)
+	assert.Contains(t, string(store.outbox[0].command), `"kind":"cancel_intent"`)
+	store.AssertNotCalled(t, "PurgeRepoCommits", mock.Anything, mock.Anything)
+	store.AssertExpectations(t)
 }
 
-func TestCreateIntent_InvalidRepoName(t *testing.T) {
+func TestDeleteIntent_Purge(t *testing.T) {
 	ctx := context.Background()
 	store := new(MockStore)
 	service := newTestService(store)
 
-	repoName := "invalid-repo"
-	startDate := time.Now().Add(-time.Hour)
+	intent := models.Intent{ID: uuid.New(), RepositoryName: "owner/repo", Status: models.Paused}
+	deleted := intent
+	deletedAt := time.Now()
+	deleted.DeletedAt = &deletedAt
 
-	result, err := service.CreateIntent(ctx, repoName, startDate)
-	assert.Error(t, err)
-	assert.Nil(t, result)
-	assert.Equal(t, manager.ErrInvalidRepository, err)
+	store.On("FindIntent", ctx, intent.ID).Return(&intent, nil).Once()
+	store.On("FindIntents", ctx, mock.MatchedBy(func(f models.IntentFilter) bool {
+		return *f.RepositoryName == "owner/repo"
+	}), mock.Anything).Return(repository.Paginated[models.Intent]{Data: []models.Intent{intent}, TotalCount: 1}, nil).Once()
+	store.On("DeleteIntent", ctx, intent.ID).Return(&deleted, nil).Once()
+	store.On("PurgeRepoCommits", ctx, "owner/repo").Return(int64(42), nil).Once()
+
+	// The repository's archive objects go with its commits.
+	archives := objectstore.Dir(t.TempDir())
+	service.UseArchiveStore(archives)
+	key := "commits/owner/repo/20200101-20201231.parquet"
+	assert.NoError(t, archives.Put(ctx, key, []byte("PAR1"), "application/vnd.apache.parquet"))
+	store.On("GetRepo", ctx, "owner/repo").Return(&models.Repository{ID: 1, FullName: "owner/repo"}, nil).Once()
+	store.On("FindCommitArchives", ctx, int64(1)).Return([]models.CommitArchive{{ObjectKey: key}}, nil).Once()
+
+	deletion, err := service.DeleteIntent(ctx, intent.ID, true)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), deletion.PurgedCommits)
+	_, err = archives.Get(ctx, key)
+	assert.Equal(t, objectstore.ErrNotFound, err)
+	store.AssertExpectations(t)
 }
 
-func TestCreateIntent_InvalidStartDate(t *testing.T) {
+func TestDeleteIntent_PurgeShared(t *testing.T) {
 	ctx := context.Background()
 	store := new(MockStore)
 	service := newTestService(store)
 
-	repoName := "owner/repo"
-	startDate := time.Now().Add(time.Hour)
+	intent := models.Intent{ID: uuid.New(), RepositoryName: "owner/repo", Status: models.Paused}
+	other := models.Intent{ID: uuid.New(), RepositoryName: "owner/repo", Status: models.Completed, IsActive: true}
 
-	result, err := service.CreateIntent(ctx, repoName, startDate)
-	assert.Error(t, err)
-	assert.Nil(t, result)
-	assert.Equal(t, manager.ErrInvalidStartDate, err)
+	store.On("FindIntent", ctx, intent.ID).Return(&intent, nil).Once()
+	store.On("FindIntents", ctx, mock.Anything, mock.Anything).Return(repository.Paginated[models.Intent]{Data: []models.Intent{other, intent}, TotalCount: 2}, nil).Once()
+
+	_, err := service.DeleteIntent(ctx, intent.ID, true)
+	assert.Equal(t, manager.ErrRepositoryShared, err)
+	assert.True(t, errors.Is(err, manager.ErrConflict))
+	assert.Empty(t, store.outbox)
+	store.AssertNotCalled(t, "DeleteIntent", mock.Anything, mock.Anything)
+	store.AssertExpectations(t)
 }
 
-func TestUpdateIntentStatus(t *testing.T) {
+func TestDeleteRepository(t *testing.T) {
 	ctx := context.Background()
 	store := new(MockStore)
 	service := newTestService(store)
 
-	intentID := uuid.New()
-	intent := &models.Intent{
-		ID:             intentID,
-		RepositoryName: "owner/repo",
-		IsActive:       true,
-	}
-	updatedIntent := &models.Intent{
-		ID:             intentID,
-		RepositoryName: "owner/repo",
-		IsActive:       false,
-	}
+	repo := models.Repository{ID: 1, FullName: "owner/repo"}
+	deleted := repo
+	deletedAt := time.Now()
+	deleted.DeletedAt = &deletedAt
 
-	store.On("FindIntent", ctx, intentID).Return(intent, nil).Once()
-	store.On("UpdateIntent", ctx, mock.AnythingOfType("models.IntentUpdate")).Return(updatedIntent, nil).Once()
+	store.On("GetRepo", ctx, "owner/repo").Return(&repo, nil).Once()
+	store.On("FindIntents", ctx, mock.MatchedBy(func(f models.IntentFilter) bool {
+		return *f.RepositoryName == "owner/repo"
+	}), mock.Anything).Return(repository.Paginated[models.Intent]{}, nil).Once()
+	store.On("DeleteRepo", ctx, int64(1)).Return(&deleted, nil).Once()
 
-	result, err := service.UpdateIntentStatus(ctx, intentID)
+	got, err := service.DeleteRepository(ctx, "Owner/Repo")
 	assert.NoError(t, err)
-	assert.NotNil(t, result)
-	assert.False(t, result.IsActive)
+	assert.Equal(t, &deleted, got)
+	store.AssertExpectations(t)
 }
 
-func TestUpdateIntentStatus_NotFound(t *testing.T) {
+func TestDeleteRepository_Indexed(t *testing.T) {
 	ctx := context.Background()
 	store := new(MockStore)
 	service := newTestService(store)
 
-	intentID := uuid.New()
+	repo := models.Repository{ID: 1, FullName: "owner/repo"}
+	intent := models.Intent{ID: uuid.New(), RepositoryName: "owner/repo", Status: models.Paused}
 
-	store.On("FindIntent", ctx, intentID).Return(nil, nil).Once()
+	store.On("GetRepo", ctx, "owner/repo").Return(&repo, nil).Once()
+	store.On("FindIntents", ctx, mock.Anything, mock.Anything).Return(repository.Paginated[models.Intent]{Data: []models.Intent{intent}, TotalCount: 1}, nil).Once()
 
-	result, err := service.UpdateIntentStatus(ctx, intentID)
-	assert.Error(t, err)
-	assert.Nil(t, result)
-	assert.Equal(t, manager.ErrIntentNotFound, err)
+	_, err := service.DeleteRepository(ctx, "owner/repo")
+	assert.Equal(t, manager.ErrRepositoryIndexed, err)
+	assert.True(t, errors.Is(err, manager.ErrConflict))
+	store.AssertNotCalled(t, "DeleteRepo", mock.Anything, mock.Anything)
+	store.AssertExpectations(t)
 }
 
-func TestResetIntentStartDate(t *testing.T) {
+func TestDeleteRepository_NotFound(t *testing.T) {
 	ctx := context.Background()
 	store := new(MockStore)
 	service := newTestService(store)
 
-	intentID := uuid.New()
-	newDate := time.Now().Add(-time.Hour)
-	intent := &models.Intent{
-		ID:             intentID,
-		RepositoryName: "owner/repo",
-		StartDate:      newDate,
+	store.On("GetRepo", ctx, "owner/repo").Return(nil, repository.ErrNotFound).Once()
+
+	_, err := service.DeleteRepository(ctx, "owner/repo")
+	assert.Equal(t, manager.ErrRepositoryNotFound, err)
+	store.AssertExpectations(t)
+}
+
+func TestPurgeRepositories(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	store := new(MockStore)
+	service := manager.NewService(store, nil, nil, nil, &config.ManagerConfig{
+		RepoRetention: 30 * 24 * time.Hour,
+	})
+
+	// The repository's archive objects go with it.
+	archives := objectstore.Dir(t.TempDir())
+	service.UseArchiveStore(archives)
+	key := "commits/owner/repo/20200101-20201231.parquet"
+	assert.NoError(t, archives.Put(ctx, key, []byte("PAR1"), "application/vnd.apache.parquet"))
+
+	retained := func(before time.Time) bool {
+		return before.Before(time.Now().AddDate(0, 0, -29)) && before.After(time.Now().AddDate(0, 0, -31))
 	}
+	store.On("FindDeletedRepos", ctx, mock.MatchedBy(retained)).Return([]models.Repository{{ID: 1, FullName: "owner/repo"}}, nil).Once()
+	store.On("FindCommitArchives", ctx, int64(1)).Return([]models.CommitArchive{{ObjectKey: key}}, nil).Once()
+	store.On("PurgeRepo", ctx, int64(1)).Return(int64(42), nil).Once()
 
-	store.On("UpdateIntent", ctx, mock.AnythingOfType("models.IntentUpdate")).Return(intent, nil).Once()
+	service.PurgeRepositories(ctx)
 
-	err := service.ResetIntentStartDate(ctx, intentID, newDate)
-	assert.NoError(t, err)
+	store.AssertExpectations(t)
+	_, err := archives.Get(ctx, key)
+	assert.Equal(t, objectstore.ErrNotFound, err)
 }
 
-func TestResetIntentStartDate_InvalidDate(t *testing.T) {
+func TestIngestThrottle(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	cfg := &config.ManagerConfig{IngestCommitsPerSecond: 10}
+	service := manager.NewService(store, nil, nil, nil, cfg)
+
+	reindexID := uuid.New()
+	store.On("SaveShadowCommits", ctx, reindexID, mock.Anything).Return(nil).Once()
+	store.On("SwapReindex", ctx, reindexID, mock.Anything).Return(nil, nil).Once()
+
+	var commits []string
+	for i := range 15 {
+		commit, err := json.Marshal(&models.Commit{
+			Hash:       fmt.Sprintf("c%d", i),
+			Repository: models.Repository{FullName: "owner/repo"},
+		})
+		assert.NoError(t, err)
+		commits = append(commits, string(commit))
+	}
+	body := []byte(`{"kind":"new_commits","paylad":{"reindex_id":"` + reindexID.String() + `","commits":[` + strings.Join(commits, ",") + `]}}`)
+
+	start := time.Now()
+	assert.NoError(t, service.ProcessCommitCommands(ctx, body))
+	assert.True(t, time.Since(start) >= 400*time.Millisecond)
+	store.AssertExpectations(t)
+
+	stats := service.GetPipelineStats()
+	assert.InDelta(t, 0.5, stats.ThrottledSeconds, 0.2)
+
+	var metrics bytes.Buffer
+	assert.NoError(t, service.WriteMetrics(&metrics))
+	assert.Contains(t, metrics.String(), "indexer_pipeline_throttled_seconds_total")
+}
+
+func TestCommitHooks(t *testing.T) {
 	ctx := context.Background()
 	store := new(MockStore)
 	service := newTestService(store)
 
-	intentID := uuid.New()
-	newDate := time.Now().Add(time.Hour)
+	manager.RegisterCommitHook("test_drop_wip", manager.CommitHookFunc(func(ctx context.Context, commits []*models.Commit) ([]*models.Commit, error) {
+		var kept []*models.Commit
+		for _, commit := range commits {
+			if !strings.HasPrefix(commit.Message, "wip") {
+				kept = append(kept, commit)
+			}
+		}
+		return kept, nil
+	}))
+	hooks, err := manager.CommitHooks([]string{"test_drop_wip", "ticket_ids"})
+	assert.NoError(t, err)
+	service.UseCommitHooks(hooks...)
 
-	err := service.ResetIntentStartDate(ctx, intentID, newDate)
+	_, err = manager.CommitHooks([]string{"missing"})
 	assert.Error(t, err)
-	assert.Equal(t, manager.ErrInvalidStartDate, err)
+
+	reindexID := uuid.New()
+	store.On("SaveShadowCommits", ctx, reindexID, mock.MatchedBy(func(commits []*models.Commit) bool {
+		return len(commits) == 1 && commits[0].Hash == "b" &&
+			assert.ObjectsAreEqual([]string{"PAY-12", "OPS-7"}, commits[0].Tags)
+	})).Return(nil).Once()
+	store.On("SwapReindex", ctx, reindexID, mock.Anything).Return(nil, nil).Once()
+
+	body := []byte(`{"kind":"new_commits","paylad":{"reindex_id":"` + reindexID.String() + `","commits":[` +
+		`{"hash":"a","message":"wip PAY-1","Repository":{"full_name":"owner/repo"}},` +
+		`{"hash":"b","message":"Fix PAY-12 and OPS-7 (again PAY-12)","Repository":{"full_name":"owner/repo"}}]}}`)
+	assert.NoError(t, service.ProcessCommitCommands(ctx, body))
+	store.AssertExpectations(t)
 }
 
-func TestGetIntent(t *testing.T) {
+func TestCreateDigestSubscription(t *testing.T) {
 	ctx := context.Background()
 	store := new(MockStore)
 	service := newTestService(store)
 
-	intentID := uuid.New()
-	intent := &models.Intent{
-		ID:             intentID,
-		RepositoryName: "owner/repo",
-	}
-
-	store.On("FindIntent", ctx, intentID).Return(intent, nil).Once()
+	_, err := service.CreateDigestSubscription(ctx, "dev@example.com", "owner/repo", "go")
+	assert.True(t, errors.Is(err, manager.ErrInvalidSubscription))
+	_, err = service.CreateDigestSubscription(ctx, "dev@example.com", "", " ")
+	assert.True(t, errors.Is(err, manager.ErrInvalidSubscription))
 
-	result, err := service.GetIntent(ctx, intentID)
+	store.On("SaveDigestSubscription", ctx, mock.MatchedBy(func(sub models.DigestSubscription) bool {
+		return sub.Email == "dev@example.com" && sub.Repository == "owner/repo" && sub.Label == ""
+	})).Return(&models.DigestSubscription{Email: "dev@example.com", Repository: "owner/repo"}, nil).Once()
+	sub, err := service.CreateDigestSubscription(ctx, "dev@example.com", "Owner/Repo", "")
 	assert.NoError(t, err)
-	assert.NotNil(t, result)
-	assert.Equal(t, intentID, result.ID)
+	assert.Equal(t, "owner/repo", sub.Repository)
+
+	store.On("SaveDigestSubscription", ctx, mock.MatchedBy(func(sub models.DigestSubscription) bool {
+		return sub.Label == "go"
+	})).Return(nil, repository.ErrConflict).Once()
+	_, err = service.CreateDigestSubscription(ctx, "dev@example.com", "", "Go")
+	assert.True(t, errors.Is(err, manager.ErrExistingSubscription))
+	store.AssertExpectations(t)
 }
 
-func TestGetIntents(t *testing.T) {
+func TestGetWeeklyDigest(t *testing.T) {
 	ctx := context.Background()
 	store := new(MockStore)
 	service := newTestService(store)
 
-	filter := models.IntentFilter{}
-	limit := 10
-	offset := 0
-	intents := []models.Intent{
-		{
-			ID:             uuid.New(),
-			RepositoryName: "owner/repo",
-		},
-	}
+	store.On("GetRepo", ctx, "owner/repo").Return(&models.Repository{ID: 1, FullName: "owner/repo"}, nil).Once()
 
-	paginatedIntents := repository.Paginated[models.Intent]{
-		Data:       intents,
-		TotalCount: 1,
-		Page:       offset,
-		PerPage:    limit,
+	start := time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC)
+	lastDay := time.Date(2024, 6, 16, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 7).Add(-time.Nanosecond)
+	store.On("GetRepoStats", ctx, models.CommitsFilter{RepositoryName: "owner/repo", StartDate: &start, EndDate: &lastDay}).
+		Return(&models.RepoStats{Commits: 12, Authors: 3, Additions: 400, Deletions: 90}, nil).Once()
+	store.On("GetTopCommitters", ctx, "owner/repo", &start, &end, repository.Pagination{Page: 1, PerPage: 5}).
+		Return(repository.Paginated[models.AuthorStats]{Data: []models.AuthorStats{{Author: models.Author{Username: "dev"}, Commits: 8}}}, nil).Once()
+	largest := []models.DigestCommit{{Hash: "abc", Title: "Rewrite parser", Additions: 300, Deletions: 20}}
+	store.On("GetLargestCommits", ctx, models.CommitsFilter{RepositoryName: "owner/repo", StartDate: &start, EndDate: &end}, 5).
+		Return(largest, nil).Once()
+
+	// Any day of the week gives the digest of that week.
+	digest, err := service.GetWeeklyDigest(ctx, "Owner/Repo", time.Date(2024, 6, 13, 15, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Equal(t, "2024-06-10", digest.WeekStart)
+	assert.Equal(t, "2024-06-16", digest.WeekEnd)
+	assert.Equal(t, int64(12), digest.Commits)
+	assert.Len(t, digest.TopCommitters, 1)
+	assert.Equal(t, largest, digest.LargestCommits)
+	store.AssertExpectations(t)
+}
+
+type fakeMailer struct {
+	to      []string
+	subject string
+	body    string
+}
+
+func (m *fakeMailer) Send(ctx context.Context, to []string, subject, body string) error {
+	m.to, m.subject, m.body = to, subject, body
+	return nil
+}
+
+func TestSendDigests(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	store := new(MockStore)
+	service := newTestService(store)
+	mailer := new(fakeMailer)
+	service.UseMailer(mailer)
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	start := today.AddDate(0, 0, -((int(today.Weekday())+6)%7)-7)
+	label := "go"
+	subs := []models.DigestSubscription{{ID: uuid.New(), Email: "dev@example.com", Label: label}}
+	store.On("ClaimDigestSubscriptions", ctx, start, 20).Return(subs, nil).Once()
+	store.On("ClaimDigestSubscriptions", ctx, start, 20).Return([]models.DigestSubscription{}, nil).Once()
+	store.On("FindRepos", ctx, models.RepositoryFilter{Topic: &label}, repository.Pagination{Page: 1, PerPage: 100}).
+		Return(repository.Paginated[models.Repository]{Data: []models.Repository{{FullName: "owner/quiet"}, {FullName: "owner/busy"}}}, nil).Once()
+
+	store.On("GetRepoStats", ctx, mock.MatchedBy(func(filter models.CommitsFilter) bool { return filter.RepositoryName == "owner/quiet" })).
+		Return(&models.RepoStats{}, nil).Once()
+	store.On("GetRepoStats", ctx, mock.MatchedBy(func(filter models.CommitsFilter) bool { return filter.RepositoryName == "owner/busy" })).
+		Return(&models.RepoStats{Commits: 4, Authors: 2, Additions: 50, Deletions: 10}, nil).Once()
+	store.On("GetTopCommitters", ctx, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(repository.Paginated[models.AuthorStats]{Data: []models.AuthorStats{{Author: models.Author{Name: "Dev"}, Commits: 3}}}, nil)
+	store.On("GetLargestCommits", ctx, mock.Anything, 5).
+		Return([]models.DigestCommit{{Hash: "0123456789", Title: "Add cache", Author: models.Author{Username: "dev"}, Additions: 40, Deletions: 2}}, nil)
+
+	service.SendDigests(ctx)
+
+	assert.Equal(t, []string{"dev@example.com"}, mailer.to)
+	assert.Equal(t, "Weekly digest for label go, "+start.Format(time.DateOnly)+" to "+start.AddDate(0, 0, 6).Format(time.DateOnly), mailer.subject)
+	assert.NotContains(t, mailer.body, "owner/quiet")
+	assert.Contains(t, mailer.body, "owner/busy\n4 commits by 2 authors, +50 -10 lines\n")
+	assert.Contains(t, mailer.body, "  Dev: 3\n")
+	assert.Contains(t, mailer.body, "  0123456 +40 -2 Add cache (dev)\n")
+	store.AssertExpectations(t)
+}
+
+func TestArchiveColdCommits(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	store := new(MockStore)
+	service := manager.NewService(store, nil, nil, nil, &config.ManagerConfig{
+		ArchiveAfter:     365 * 24 * time.Hour,
+		ArchiveBatchSize: 2,
+	})
+	archives := objectstore.Dir(t.TempDir())
+	service.UseArchiveStore(archives)
+
+	repo := models.Repository{ID: 1, FullName: "owner/repo"}
+	cutoff := func(before time.Time) bool {
+		return before.Before(time.Now().AddDate(0, 0, -364)) && before.After(time.Now().AddDate(0, 0, -366))
 	}
+	store.On("FindArchivableRepos", ctx, mock.MatchedBy(cutoff)).Return([]models.Repository{repo}, nil).Once()
+	store.On("ClaimRepoArchive", ctx, int64(1), mock.MatchedBy(cutoff), mock.Anything).Return(true, nil).Once()
 
-	store.On("FindIntents", ctx, filter, repository.Pagination{Page: offset, PerPage: limit}).Return(paginatedIntents, nil).Once()
+	day := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	author := models.Author{ID: 7, Name: "Dev", Email: "dev@example.com", Username: "dev"}
+	store.On("FindArchivableCommits", ctx, int64(1), mock.MatchedBy(cutoff), 2).Return([]models.Commit{
+		{Hash: "a", Author: author, Message: "Add cache", CreatedAt: day, Stats: &models.CommitStats{Additions: 10, Changes: 10}},
+		{Hash: "b", Author: author, Message: "Fix cache", CreatedAt: day.AddDate(0, 0, 3), Tags: []string{"CACHE-1"}},
+	}, nil).Once()
+	store.On("FindArchivableCommits", ctx, int64(1), mock.MatchedBy(cutoff), 2).Return([]models.Commit{
+		{Hash: "c", Author: author, Message: "Drop cache", CreatedAt: day.AddDate(0, 1, 0)},
+	}, nil).Once()
 
-	result, err := service.GetIntents(ctx, filter, limit, offset)
+	var keys []string
+	store.On("ArchiveCommits", ctx, mock.AnythingOfType("models.CommitArchive"), []string{"a", "b"}).Return(nil).Once().
+		Run(func(args mock.Arguments) {
+			archive := args.Get(1).(models.CommitArchive)
+			assert.Equal(t, int32(2), archive.Commits)
+			assert.Equal(t, day, archive.FirstCommitAt)
+			assert.True(t, strings.HasPrefix(archive.ObjectKey, "commits/owner/repo/20200501-20200504-"))
+			keys = append(keys, archive.ObjectKey)
+		})
+	store.On("ArchiveCommits", ctx, mock.AnythingOfType("models.CommitArchive"), []string{"c"}).Return(nil).Once().
+		Run(func(args mock.Arguments) {
+			keys = append(keys, args.Get(1).(models.CommitArchive).ObjectKey)
+		})
+	store.On("ReleaseRepoArchive", ctx, int64(1)).Return(nil).Once()
+
+	service.ArchiveColdCommits(ctx)
+
+	store.AssertExpectations(t)
+	if !assert.Len(t, keys, 2) {
+		return
+	}
+	data, err := archives.Get(ctx, keys[0])
 	assert.NoError(t, err)
-	assert.Equal(t, 1, len(result.Data))
-	assert.Equal(t, intents[0].ID, result.Data[0].ID)
+	assert.True(t, bytes.HasPrefix(data, []byte("PAR1")))
+	assert.True(t, bytes.Contains(data, []byte("author_username")))
 }
 
-func TestGetTopCommitters(t *testing.T) {
+func TestArchiveColdCommits_Leased(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	store := new(MockStore)
+	service := manager.NewService(store, nil, nil, nil, &config.ManagerConfig{ArchiveAfter: time.Hour})
+	service.UseArchiveStore(objectstore.Dir(t.TempDir()))
+
+	// Another replica is archiving the repository.
+	store.On("FindArchivableRepos", ctx, mock.Anything).Return([]models.Repository{{ID: 1, FullName: "owner/repo"}}, nil).Once()
+	store.On("ClaimRepoArchive", ctx, int64(1), mock.Anything, mock.Anything).Return(false, nil).Once()
+
+	service.ArchiveColdCommits(ctx)
+
+	store.AssertExpectations(t)
+	store.AssertNotCalled(t, "FindArchivableCommits", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestGetArchiveNotice(t *testing.T) {
 	ctx := context.Background()
 	store := new(MockStore)
 	service := newTestService(store)
 
-	repoName := "owner/repo"
-	page := 1
-	perPage := 10
-	committers := []models.AuthorStats{
-		{
-			Author: models.Author{
-				Name:     "Test Author",
-				Email:    "test@example.com",
-				Username: "testuser",
-			},
-			Commits: 5,
-		},
-	}
+	notice := &models.ArchiveNotice{ArchivedBefore: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Commits: 300}
+	store.On("GetRepo", ctx, "owner/repo").Return(&models.Repository{ID: 1, FullName: "owner/repo"}, nil)
+	store.On("GetArchiveSummary", ctx, int64(1)).Return(notice, nil)
 
-	paginatedCommitters := repository.Paginated[models.AuthorStats]{
-		Data:       committers,
-		TotalCount: 1,
-		Page:       page,
-		PerPage:    perPage,
-	}
+	got, err := service.GetArchiveNotice(ctx, "Owner/Repo", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, notice, got)
 
-	// Mock the GetRepo call
-	store.On("GetRepo", ctx, repoName).Return(&models.Repository{}, nil).Once()
+	// Results since the archive's cutoff miss nothing.
+	since := notice.ArchivedBefore
+	got, err = service.GetArchiveNotice(ctx, "owner/repo", &since)
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+}
 
-	store.On("GetTopCommitters", ctx, repoName, (*time.Time)(nil), (*time.Time)(nil), repository.Pagination{Page: page, PerPage: perPage}).Return(paginatedCommitters, nil).Once()
+func TestAllowRequest(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	service := manager.NewService(store, nil, nil, nil, &config.ManagerConfig{APIRateLimit: 1, APIRateBurst: 2})
+	service.UseAPILimiter(apilimits.NewMemoryStore())
 
-	result, err := service.GetTopCommitters(ctx, repoName, page, perPage)
+	for range 2 {
+		result, err := service.AllowRequest(ctx, "", "10.0.0.1")
+		assert.NoError(t, err)
+		assert.True(t, result.Allowed)
+	}
+	result, err := service.AllowRequest(ctx, "", "10.0.0.1")
 	assert.NoError(t, err)
-	assert.Equal(t, 1, len(result.Data))
-	assert.Equal(t, committers[0].Author.Name, result.Data[0].Author.Name)
+	assert.False(t, result.Allowed)
+	assert.True(t, result.RetryAfter > 0)
+
+	// An API key is limited on its own, wherever it is used from.
+	result, err = service.AllowRequest(ctx, "idx_key", "10.0.0.1")
+	assert.NoError(t, err)
+	assert.True(t, result.Allowed)
+}
+
+type fakeMetricsWriter struct {
+	written chan []remotewrite.Series
+}
+
+func (w *fakeMetricsWriter) Write(ctx context.Context, series []remotewrite.Series) error {
+	w.written <- series
+	return nil
+}
+
+func TestPushCommitMetrics(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store := new(MockStore)
+	service := manager.NewService(store, nil, nil, nil, &config.ManagerConfig{
+		RemoteWriteInterval: time.Millisecond,
+		RemoteWriteLabels:   map[string]string{"cluster": "eu"},
+	})
+	writer := &fakeMetricsWriter{written: make(chan []remotewrite.Series, 1)}
+	service.UseMetricsWriter(writer)
+
+	store.On("GetRepo", ctx, "owner/repo").Return(&models.Repository{ID: 1, FullName: "owner/repo"}, nil)
+	store.On("SaveManyCommit", ctx, int64(1), mock.Anything).Return(nil)
+	for range 2 {
+		err := service.BatchSaveCommits(ctx, []*models.Commit{
+			{Hash: "a", Repository: models.Repository{FullName: "Owner/Repo"}},
+			{Hash: "b", Repository: models.Repository{FullName: "owner/repo"}},
+		})
+		assert.NoError(t, err)
+	}
+
+	go service.PushCommitMetrics(ctx)
+
+	select {
+	case series := <-writer.written:
+		if !assert.Len(t, series, 1) {
+			return
+		}
+		assert.Equal(t, []remotewrite.Label{
+			{Name: "__name__", Value: "indexer_repository_commits_ingested_total"},
+			{Name: "repository", Value: "owner/repo"},
+			{Name: "cluster", Value: "eu"},
+		}, series[0].Labels)
+		assert.Equal(t, float64(4), series[0].Samples[0].Value)
+	case <-time.After(time.Second):
+		t.Fatal("commit metrics were not pushed")
+	}
 }
@@ -0,0 +1,124 @@
+package manager
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/noelukwa/indexer/internal/manager/models"
+	"github.com/noelukwa/indexer/internal/manager/repository"
+)
+
+var (
+	ErrNoRole         error = fmt.Errorf("user is not a member of any organisation with access")
+	ErrInvalidSession error = fmt.Errorf("session is invalid or has expired")
+)
+
+// CreateSession opens an API session for a GitHub user. The role is the
+// highest one granted by the configured org-to-role mapping for any of the
+// user's organisations.
+func (svc *Service) CreateSession(ctx context.Context, username string, orgs []string) (*models.Session, error) {
+	role := svc.resolveRole(orgs)
+	if role == "" {
+		return nil, ErrNoRole
+	}
+
+	token, err := newSessionToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := svc.store.SaveSession(ctx, hashSessionToken(token), models.Session{
+		Username:  username,
+		Role:      role,
+		ExpiresAt: time.Now().Add(svc.cfg.SessionTTL),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save session: %w", err)
+	}
+
+	session.Token = token
+	return session, nil
+}
+
+func (svc *Service) ValidateSession(ctx context.Context, token string) (*models.Session, error) {
+	if token == "" {
+		return nil, ErrInvalidSession
+	}
+
+	session, err := svc.store.FindSession(ctx, hashSessionToken(token))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidSession
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find session: %w", err)
+	}
+
+	return session, nil
+}
+
+func (svc *Service) RevokeSession(ctx context.Context, token string) error {
+	return svc.store.DeleteSession(ctx, hashSessionToken(token))
+}
+
+// PurgeSessions deletes expired sessions on the configured interval until
+// ctx is done. Expired sessions are already refused, so this only keeps
+// the table from growing.
+func (svc *Service) PurgeSessions(ctx context.Context) {
+	interval := svc.cfg.SessionPurgeInterval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		deleted, err := svc.store.DeleteExpiredSessions(ctx)
+		if err != nil {
+			log.Printf("failed to purge expired sessions: %v", err)
+		} else if deleted > 0 {
+			log.Printf("purged %d expired sessions", deleted)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (svc *Service) resolveRole(orgs []string) models.Role {
+	var role models.Role
+	for _, org := range orgs {
+		for configured, granted := range svc.cfg.GitHubOrgRoles {
+			if !strings.EqualFold(configured, org) {
+				continue
+			}
+			r := models.Role(granted)
+			if r.Valid() && (role == "" || r.Allows(role)) {
+				role = r
+			}
+		}
+	}
+	return role
+}
+
+func newSessionToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate session token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func hashSessionToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,62 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/noelukwa/indexer/internal/manager/models"
+	"github.com/noelukwa/indexer/internal/manager/repository"
+)
+
+// activeReposDays is how many days, up to and including the end date, the
+// most active repositories are ranked over without a start date.
+const activeReposDays = 30
+
+// GetLanguageStats totals the indexed repositories and their commits per
+// language, most commits first.
+func (svc *Service) GetLanguageStats(ctx context.Context) ([]models.LanguageStats, error) {
+	return cachedStatsQuery(ctx, svc, "languages", "", func() ([]models.LanguageStats, error) {
+		stats, err := svc.store.GetLanguageStats(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get language stats: %w", err)
+		}
+		return stats, nil
+	})
+}
+
+// GetActiveRepos ranks the repositories by their commits between startDate
+// and endDate, both included, optionally only those in language. The end
+// date is today when zero, and the start date activeReposDays before it.
+// The counts come from the daily rollup, so trail the newest commits until
+// it next runs.
+func (svc *Service) GetActiveRepos(ctx context.Context, startDate, endDate time.Time, language string, page, perPage int) (repository.Paginated[models.RepoActivity], error) {
+	if endDate.IsZero() {
+		endDate = time.Now().UTC().Truncate(24 * time.Hour)
+	}
+	if startDate.IsZero() {
+		startDate = endDate.AddDate(0, 0, 1-activeReposDays)
+	}
+	if err := validateDailyRange(startDate, endDate); err != nil {
+		return repository.Paginated[models.RepoActivity]{}, err
+	}
+
+	filter := models.RepoActivityFilter{StartDate: startDate, EndDate: endDate}
+	if language = strings.TrimSpace(language); language != "" {
+		filter.Language = &language
+	}
+	pagination := repository.Pagination{Page: page, PerPage: perPage}
+
+	params := fmt.Sprintf("%d:%d:%s:%s", page, perPage, strings.ToLower(language), dateParams(startDate, endDate, false))
+	return cachedStatsQuery(ctx, svc, "active_repos", params, func() (repository.Paginated[models.RepoActivity], error) {
+		repos, err := svc.store.GetActiveRepos(ctx, filter, pagination)
+		if err != nil {
+			return repository.Paginated[models.RepoActivity]{}, fmt.Errorf("failed to get active repositories: %w", err)
+		}
+		if repos.Data == nil {
+			repos.Data = []models.RepoActivity{}
+		}
+		return repos, nil
+	})
+}
@@ -0,0 +1,53 @@
+package manager
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// ingestThrottle paces the commit batches saved to the store, so a large
+// backfill can't take all of the database from the API. A nil limiter
+// doesn't limit.
+type ingestThrottle struct {
+	commits *rate.Limiter
+	batches *rate.Limiter
+}
+
+// newIngestThrottle limits ingestion to commitsPerSecond commits and
+// batchesPerSecond batches a second, zero or less for no limit. The
+// commit limiter allows bursts of one second's worth of commits.
+func newIngestThrottle(commitsPerSecond, batchesPerSecond float64) *ingestThrottle {
+	t := &ingestThrottle{}
+	if commitsPerSecond > 0 {
+		t.commits = rate.NewLimiter(rate.Limit(commitsPerSecond), int(math.Max(math.Ceil(commitsPerSecond), 1)))
+	}
+	if batchesPerSecond > 0 {
+		t.batches = rate.NewLimiter(rate.Limit(batchesPerSecond), 1)
+	}
+	return t
+}
+
+// wait blocks until a batch of n commits may be saved, or ctx is done, and
+// returns how long it waited. Batches larger than a burst wait for it a
+// burst at a time.
+func (t *ingestThrottle) wait(ctx context.Context, n int) (time.Duration, error) {
+	start := time.Now()
+	if t.batches != nil {
+		if err := t.batches.Wait(ctx); err != nil {
+			return time.Since(start), err
+		}
+	}
+	if t.commits != nil {
+		for n > 0 {
+			burst := min(n, t.commits.Burst())
+			if err := t.commits.WaitN(ctx, burst); err != nil {
+				return time.Since(start), err
+			}
+			n -= burst
+		}
+	}
+	return time.Since(start), nil
+}
@@ -0,0 +1,106 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/noelukwa/indexer/internal/events"
+	"github.com/noelukwa/indexer/internal/manager/models"
+	"github.com/noelukwa/indexer/internal/manager/repository"
+)
+
+// ExistingIntentError is ErrExistingIntent with the ID of the repository's
+// active intent.
+type ExistingIntentError struct {
+	IntentID uuid.UUID
+}
+
+func (e *ExistingIntentError) Error() string {
+	return ErrExistingIntent.Error()
+}
+
+func (e *ExistingIntentError) Unwrap() error {
+	return ErrExistingIntent
+}
+
+// checkExistingIntent returns an ExistingIntentError when repoName already
+// has an active intent.
+func (svc *Service) checkExistingIntent(ctx context.Context, repoName string) error {
+	active := true
+	existing, err := svc.store.FindIntents(ctx, models.IntentFilter{
+		RepositoryName: &repoName,
+		IsActive:       &active,
+	}, repository.Pagination{Page: 1, PerPage: 1})
+	if err != nil {
+		return fmt.Errorf("failed to check for existing intent: %w", err)
+	}
+	if len(existing.Data) > 0 {
+		return &ExistingIntentError{IntentID: existing.Data[0].ID}
+	}
+	return nil
+}
+
+// UpsertIntent is CreateIntent, except that when the repository already
+// has an active intent it extends that intent's date range to cover
+// startDate to until instead of failing. The existing intent's other
+// options are left as they are. created reports whether a new intent was
+// created.
+func (svc *Service) UpsertIntent(ctx context.Context, repoName string, startDate, until time.Time, opts models.IntentOptions) (intent *models.Intent, created bool, err error) {
+	intent, err = svc.CreateIntent(ctx, repoName, startDate, until, opts)
+	var existing *ExistingIntentError
+	if !errors.As(err, &existing) {
+		return intent, err == nil, err
+	}
+
+	intent, err = svc.extendIntent(ctx, existing.IntentID, startDate, until)
+	return intent, false, err
+}
+
+// extendIntent widens the intent's date range to cover startDate to until,
+// where zero dates are open, and sends discovery the new range. A range
+// the intent already covers leaves it as it is.
+func (svc *Service) extendIntent(ctx context.Context, id uuid.UUID, startDate, until time.Time) (*models.Intent, error) {
+	intent, err := svc.findIntent(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	update := models.IntentUpdate{ID: id}
+	if intent.StartDate != nil {
+		if startDate.IsZero() {
+			update.FullHistory = true
+		} else if startDate.Before(*intent.StartDate) {
+			update.StartDate = &startDate
+		}
+	}
+	if intent.Until != nil {
+		if until.IsZero() {
+			update.OpenEnded = true
+		} else if until.After(*intent.Until) {
+			update.Until = &until
+		}
+	}
+	if !update.FullHistory && !update.OpenEnded && update.StartDate == nil && update.Until == nil {
+		return intent, nil
+	}
+
+	intent, err = svc.store.UpdateIntent(ctx, update)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrIntentNotFound
+	}
+	if err != nil {
+		return nil, storeError(err)
+	}
+
+	payload, err := svc.intentPayload(ctx, intent)
+	if err != nil {
+		return nil, err
+	}
+	if err := svc.queueIntent(ctx, events.UpdateIntentKind, payload); err != nil {
+		return nil, err
+	}
+	return intent, nil
+}
@@ -0,0 +1,201 @@
+package manager
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/noelukwa/indexer/internal/manager/models"
+	"github.com/noelukwa/indexer/internal/manager/repository"
+)
+
+// listenRetryDelay spaces out reconnections of the store's listeners.
+const listenRetryDelay = 5 * time.Second
+
+// watcherBuffer is how many events a slow watcher may fall behind before
+// further events are dropped for it.
+const watcherBuffer = 16
+
+// allIntents is the key of the watchers following every intent's status
+// changes.
+var allIntents = uuid.Nil
+
+// intentWatchers fans intent events out to the clients following them,
+// each only hearing of the intents of its tenant, if it has one.
+type intentWatchers struct {
+	mu   sync.Mutex
+	subs map[uuid.UUID]map[chan models.IntentEvent]string
+}
+
+func newIntentWatchers() *intentWatchers {
+	return &intentWatchers{subs: make(map[uuid.UUID]map[chan models.IntentEvent]string)}
+}
+
+func (w *intentWatchers) subscribe(id uuid.UUID, tenant string) (chan models.IntentEvent, func()) {
+	ch := make(chan models.IntentEvent, watcherBuffer)
+
+	w.mu.Lock()
+	if w.subs[id] == nil {
+		w.subs[id] = make(map[chan models.IntentEvent]string)
+	}
+	w.subs[id][ch] = tenant
+	w.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			w.mu.Lock()
+			defer w.mu.Unlock()
+			delete(w.subs[id], ch)
+			if len(w.subs[id]) == 0 {
+				delete(w.subs, id)
+			}
+		})
+	}
+}
+
+// watching reports whether anyone follows any intent.
+func (w *intentWatchers) watching() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.subs) > 0
+}
+
+// publish never blocks: a watcher whose buffer is full misses the event.
+// Status events also go to the watchers of every intent.
+func (w *intentWatchers) publish(event models.IntentEvent) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	send(w.subs[event.IntentID], event)
+	if event.Type == models.StatusEvent {
+		send(w.subs[allIntents], event)
+	}
+}
+
+func send(subs map[chan models.IntentEvent]string, event models.IntentEvent) {
+	for ch, tenant := range subs {
+		if tenant != "" && tenant != event.Tenant {
+			continue
+		}
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// WatchIntent follows an intent's status changes, progress and errors. The
+// first event is a snapshot of its current status. The returned func must
+// be called once the caller stops reading.
+func (svc *Service) WatchIntent(ctx context.Context, id uuid.UUID) (<-chan models.IntentEvent, func(), error) {
+	intent, err := svc.findIntent(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// findIntent has checked the intent is in ctx's tenant.
+	ch, stop := svc.watchers.subscribe(id, "")
+	ch <- models.IntentEvent{
+		Type:          models.StatusEvent,
+		IntentID:      intent.ID,
+		Status:        intent.Status,
+		SyncedCommits: intent.SyncedCommits,
+		Repository:    intent.RepositoryName,
+		At:            time.Now(),
+	}
+	return ch, stop, nil
+}
+
+// WatchIntents follows the status changes of every intent from now on, or
+// of every intent in the tenant ctx is scoped to. The returned func must
+// be called once the caller stops reading.
+func (svc *Service) WatchIntents(ctx context.Context) (<-chan models.IntentEvent, func()) {
+	tenant, _ := repository.TenantFrom(ctx)
+	return svc.watchers.subscribe(allIntents, tenant)
+}
+
+// emit hands event to the watchers of every replica through the bus, or
+// straight to this replica's watchers when there is no bus or it fails.
+func (svc *Service) emit(ctx context.Context, event models.IntentEvent) {
+	if svc.bus != nil {
+		err := svc.bus.NotifyIntentEvent(ctx, event)
+		if err == nil {
+			return
+		}
+		log.Printf("failed to notify %s event of intent %s: %v", event.Type, event.IntentID, err)
+	}
+	svc.watchers.publish(event)
+}
+
+// FollowIntentEvents feeds the events emitted by every replica to this
+// replica's watchers until ctx is done, reconnecting after failures. It
+// returns at once when the store has no bus.
+func (svc *Service) FollowIntentEvents(ctx context.Context) {
+	if svc.bus == nil {
+		return
+	}
+	follow(ctx, "intent events", func(ctx context.Context) error {
+		return svc.bus.ListenIntentEvents(ctx, svc.watchers.publish)
+	})
+}
+
+// FollowCommits feeds the commits listener's notices to the intent
+// watchers until ctx is done, reconnecting after failures. Every replica
+// follows, so watchers hear of commits persisted by any of them.
+func (svc *Service) FollowCommits(ctx context.Context, listener repository.CommitsListener) {
+	follow(ctx, "commits", func(ctx context.Context) error {
+		return listener.ListenCommits(ctx, func(notice models.CommitsNotice) {
+			if err := svc.PublishCommits(ctx, notice); err != nil {
+				log.Printf("failed to publish commits of %s: %v", notice.Repository, err)
+			}
+		})
+	})
+}
+
+// follow runs listen until ctx is done, restarting it after failures.
+func follow(ctx context.Context, name string, listen func(ctx context.Context) error) {
+	for {
+		err := listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("%s listener stopped, reconnecting: %v", name, err)
+
+		select {
+		case <-time.After(listenRetryDelay):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// PublishCommits tells the watchers of the repository's active intents
+// about a batch of persisted commits.
+func (svc *Service) PublishCommits(ctx context.Context, notice models.CommitsNotice) error {
+	if !svc.watchers.watching() {
+		return nil
+	}
+
+	active := true
+	intents, err := svc.store.FindIntents(ctx, models.IntentFilter{
+		RepositoryName: &notice.Repository,
+		IsActive:       &active,
+	}, repository.Pagination{Page: 1, PerPage: 100})
+	if err != nil {
+		return err
+	}
+
+	for _, intent := range intents.Data {
+		svc.watchers.publish(models.IntentEvent{
+			Type:          models.CommitsEvent,
+			IntentID:      intent.ID,
+			Status:        intent.Status,
+			SyncedCommits: intent.SyncedCommits,
+			Commits:       notice.Commits,
+			At:            notice.At,
+		})
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package manager
+
+import (
+	"context"
+	"time"
+
+	"github.com/noelukwa/indexer/internal/manager/models"
+)
+
+// saveWorkflowRuns saves workflow runs from the monitor, which sends those
+// of one repository at a time.
+func (svc *Service) saveWorkflowRuns(ctx context.Context, runs []*models.WorkflowRun) error {
+	repo, err := svc.findRepo(ctx, normalizeRepositoryName(runs[0].Repository))
+	if err != nil {
+		return err
+	}
+
+	err = svc.persist(ctx, repo.FullName, func() error {
+		return svc.store.SaveWorkflowRuns(ctx, repo.ID, runs)
+	})
+	if err != nil {
+		return err
+	}
+	svc.invalidateRepo(ctx, repo.FullName)
+	return nil
+}
+
+// GetCIStats summarizes the repository's workflow runs started between
+// startDate and endDate, either of which may be zero to leave it open.
+// A workflow name narrows them to that workflow's runs.
+func (svc *Service) GetCIStats(ctx context.Context, repo string, startDate, endDate time.Time, workflow string) (*models.CIStats, error) {
+	found, err := svc.findRepo(ctx, normalizeRepositoryName(repo))
+	if err != nil {
+		return nil, err
+	}
+
+	params := dateParams(startDate, endDate, false) + ":" + workflow
+	return cachedQuery(ctx, svc, found.FullName, "ci_stats", params, func() (*models.CIStats, error) {
+		stats, err := svc.store.GetCIStats(ctx, models.CIFilter{
+			RepositoryID: found.ID,
+			StartDate:    startDate,
+			EndDate:      endDate,
+			Workflow:     workflow,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if stats.Runs > 0 {
+			stats.PassRate = float64(stats.Passed) / float64(stats.Runs)
+		}
+		return stats, nil
+	})
+}
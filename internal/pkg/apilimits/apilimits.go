@@ -0,0 +1,113 @@
+// Package apilimits rate limits the clients of the manager API with a
+// token bucket per client. Store shares the buckets between manager
+// replicas through Redis, MemoryStore keeps them in a single process.
+package apilimits
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const keyPrefix = "apilimit:"
+
+// Limit lets a client make Rate requests a second on average, and up to
+// Burst at once.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Result is the outcome of a request. RetryAfter is how long a client
+// that was refused must wait for its next request to be allowed.
+type Result struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+// Limiter takes a token from the bucket of the client key.
+type Limiter interface {
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// take refills a bucket holding tokens for elapsed and takes a token from
+// it, returning the tokens left.
+func take(tokens float64, elapsed time.Duration, limit Limit) (float64, Result) {
+	tokens = math.Min(float64(limit.Burst), tokens+elapsed.Seconds()*limit.Rate)
+	if tokens >= 1 {
+		tokens--
+		return tokens, Result{Allowed: true, Remaining: int(tokens)}
+	}
+	wait := time.Duration((1 - tokens) / limit.Rate * float64(time.Second))
+	return tokens, Result{RetryAfter: wait}
+}
+
+// takeScript is take in Redis, on the server's clock so replicas agree.
+// Buckets expire once they would be full again.
+var takeScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = redis.call("TIME")
+now = tonumber(now[1]) + tonumber(now[2]) / 1000000
+
+local state = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(state[1]) or burst
+local ts = tonumber(state[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate)
+
+local allowed = 0
+local wait = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	wait = (1 - tokens) / rate
+end
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", tostring(now))
+redis.call("PEXPIRE", KEYS[1], math.ceil(burst / rate * 1000) + 1000)
+return {allowed, tostring(tokens), tostring(wait)}`)
+
+type Store struct {
+	client *redis.Client
+}
+
+func NewStore(client *redis.Client) *Store {
+	return &Store{client: client}
+}
+
+func (s *Store) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	reply, err := takeScript.Run(ctx, s.client, []string{keyPrefix + key}, limit.Rate, limit.Burst).Slice()
+	if err != nil {
+		return Result{}, err
+	}
+	if len(reply) != 3 {
+		return Result{}, fmt.Errorf("unexpected rate limit reply %v", reply)
+	}
+	allowed, _ := reply[0].(int64)
+	tokens, err := replyFloat(reply[1])
+	if err != nil {
+		return Result{}, err
+	}
+	wait, err := replyFloat(reply[2])
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{
+		Allowed:    allowed == 1,
+		Remaining:  int(tokens),
+		RetryAfter: time.Duration(wait * float64(time.Second)),
+	}, nil
+}
+
+func replyFloat(v interface{}) (float64, error) {
+	s, ok := v.(string)
+	if !ok {
+		return 0, fmt.Errorf("unexpected rate limit reply %v", v)
+	}
+	return strconv.ParseFloat(s, 64)
+}
@@ -0,0 +1,74 @@
+package apilimits
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/noelukwa/indexer/internal/pkg/testenv"
+	"github.com/redis/go-redis/v9"
+	"github.com/test-go/testify/assert"
+	"github.com/test-go/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	testenv.Terminate()
+	os.Exit(code)
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	now := time.Date(2024, 6, 10, 9, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+	limit := Limit{Rate: 2, Burst: 3}
+
+	// A new client can burst.
+	for i := 2; i >= 0; i-- {
+		result, err := store.Allow(ctx, "ip:10.0.0.1", limit)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, i, result.Remaining)
+	}
+	result, err := store.Allow(ctx, "ip:10.0.0.1", limit)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 500*time.Millisecond, result.RetryAfter)
+
+	// Other clients have their own buckets.
+	result, err = store.Allow(ctx, "ip:10.0.0.2", limit)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	// Tokens come back at the rate.
+	now = now.Add(500 * time.Millisecond)
+	result, err = store.Allow(ctx, "ip:10.0.0.1", limit)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+}
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	client := redis.NewClient(testenv.Redis(t))
+	defer client.Close()
+	store := NewStore(client)
+	limit := Limit{Rate: 0.5, Burst: 2}
+
+	for i := 1; i >= 0; i-- {
+		result, err := store.Allow(ctx, "key:abc", limit)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, i, result.Remaining)
+	}
+	result, err := store.Allow(ctx, "key:abc", limit)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.True(t, result.RetryAfter > time.Second && result.RetryAfter <= 2*time.Second)
+
+	ttl, err := client.PTTL(ctx, keyPrefix+"key:abc").Result()
+	require.NoError(t, err)
+	assert.True(t, ttl > 0 && ttl <= 5*time.Second)
+}
@@ -0,0 +1,57 @@
+package apilimits
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// maxMemoryBuckets is how many clients a MemoryStore tracks before it
+// drops the buckets that have refilled.
+const maxMemoryBuckets = 10000
+
+// MemoryStore keeps the buckets of a single manager in process, for
+// deployments without Redis. Each replica then limits its own share of a
+// client's requests, so a client spread over n replicas gets n times the
+// limit.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]bucket
+	now     func() time.Time
+}
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]bucket), now: time.Now}
+}
+
+func (s *MemoryStore) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	b, ok := s.buckets[key]
+	if !ok {
+		if len(s.buckets) >= maxMemoryBuckets {
+			s.prune(now, limit)
+		}
+		b = bucket{tokens: float64(limit.Burst), updatedAt: now}
+	}
+	tokens, result := take(b.tokens, now.Sub(b.updatedAt), limit)
+	s.buckets[key] = bucket{tokens: tokens, updatedAt: now}
+	return result, nil
+}
+
+// prune drops the buckets that would be full by now, which are the same
+// as no bucket.
+func (s *MemoryStore) prune(now time.Time, limit Limit) {
+	for key, b := range s.buckets {
+		if b.tokens+now.Sub(b.updatedAt).Seconds()*limit.Rate >= float64(limit.Burst) {
+			delete(s.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package broker
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net/url"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// AMQP is a Broker backed by a RabbitMQ channel.
+type AMQP struct {
+	conn *amqp.Connection
+	ch   *amqp.Channel
+
+	// Compression applies to the messages it publishes. Those it consumes
+	// are decompressed by their content encoding.
+	Compression Compression
+}
+
+// DialAMQP connects to RabbitMQ at url and opens a channel. A non-nil
+// tlsConfig with a client certificate and no credentials in rawURL
+// authenticates with the certificate.
+func DialAMQP(rawURL string, tlsConfig *tls.Config) (*AMQP, error) {
+	var conn *amqp.Connection
+	var err error
+	switch {
+	case tlsConfig == nil:
+		conn, err = amqp.Dial(rawURL)
+	case len(tlsConfig.Certificates) > 0 && !hasCredentials(rawURL):
+		conn, err = amqp.DialTLS_ExternalAuth(rawURL, tlsConfig)
+	default:
+		conn, err = amqp.DialTLS(rawURL, tlsConfig)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to open a channel: %w", err)
+	}
+	return &AMQP{conn: conn, ch: ch}, nil
+}
+
+func hasCredentials(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	return err == nil && u.User != nil
+}
+
+func (b *AMQP) Declare(queues ...string) error {
+	for _, queue := range queues {
+		if _, err := b.ch.QueueDeclare(queue, true, false, false, false, nil); err != nil {
+			return fmt.Errorf("failed to declare queue %s: %w", queue, err)
+		}
+	}
+	return nil
+}
+
+func (b *AMQP) Publish(ctx context.Context, queue string, body []byte) error {
+	body, encoding, err := b.Compression.compress(body)
+	if err != nil {
+		return fmt.Errorf("failed to compress message: %w", err)
+	}
+	return b.ch.PublishWithContext(ctx,
+		"",
+		queue,
+		false,
+		false,
+		amqp.Publishing{
+			ContentType:     "application/json",
+			ContentEncoding: string(encoding),
+			Body:            body,
+		})
+}
+
+func (b *AMQP) Consume(ctx context.Context, queue string) (<-chan Delivery, error) {
+	return b.consume(ctx, queue, true)
+}
+
+func (b *AMQP) ConsumeAcked(ctx context.Context, queue string, prefetch int) (<-chan Delivery, error) {
+	if err := b.ch.Qos(prefetch, 0, false); err != nil {
+		return nil, fmt.Errorf("failed to set prefetch: %w", err)
+	}
+	return b.consume(ctx, queue, false)
+}
+
+func (b *AMQP) consume(ctx context.Context, queue string, autoAck bool) (<-chan Delivery, error) {
+	msgs, err := b.ch.ConsumeWithContext(ctx, queue, "", autoAck, false, false, false, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register a consumer: %w", err)
+	}
+
+	out := make(chan Delivery)
+	go func() {
+		defer close(out)
+		for d := range msgs {
+			body, err := decompress(Encoding(d.ContentEncoding), d.Body)
+			if err != nil {
+				// Redelivering it wouldn't help.
+				log.Printf("Dropping undecodable message on %s: %v", queue, err)
+				if !autoAck {
+					_ = d.Nack(false, false)
+				}
+				continue
+			}
+			delivery := Delivery{Body: body}
+			if !autoAck {
+				delivery.acker = amqpAcker{d}
+			}
+			select {
+			case out <- delivery:
+			case <-ctx.Done():
+				// Hand the message to the other consumers.
+				_ = delivery.Nack(true)
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+type amqpAcker struct {
+	d amqp.Delivery
+}
+
+func (a amqpAcker) ack() error {
+	return a.d.Ack(false)
+}
+
+func (a amqpAcker) nack(requeue bool) error {
+	return a.d.Nack(false, requeue)
+}
+
+func (b *AMQP) Close() error {
+	if err := b.ch.Close(); err != nil {
+		b.conn.Close()
+		return fmt.Errorf("failed to close RabbitMQ channel: %w", err)
+	}
+	return b.conn.Close()
+}
@@ -0,0 +1,125 @@
+// Package broker moves event messages between the services over named
+// queues. RabbitMQ carries them in production; an in-memory transport
+// stands in for it in tests and in dev mode, and can be told to lose,
+// duplicate or delay messages for chaos testing.
+package broker
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MemoryURL selects the in-memory transport in Open.
+const MemoryURL = "memory://"
+
+// Delivery is a message taken off a queue. A delivery from ConsumeAcked
+// must be settled with Ack or Nack; for one from Consume both do nothing.
+type Delivery struct {
+	Body []byte
+
+	acker acker
+}
+
+type acker interface {
+	ack() error
+	nack(requeue bool) error
+}
+
+// Ack marks the message as handled, removing it from the queue.
+func (d Delivery) Ack() error {
+	if d.acker == nil {
+		return nil
+	}
+	return d.acker.ack()
+}
+
+// Nack gives up on the message, putting it back on the queue for the next
+// consumer when requeue is set and discarding it otherwise.
+func (d Delivery) Nack(requeue bool) error {
+	if d.acker == nil {
+		return nil
+	}
+	return d.acker.nack(requeue)
+}
+
+// Broker publishes to and consumes from durable queues. Each message on a
+// queue goes to one of its consumers.
+type Broker interface {
+	// Declare creates the queues if they don't exist yet.
+	Declare(queues ...string) error
+	// Publish sends body to queue.
+	Publish(ctx context.Context, queue string, body []byte) error
+	// Consume delivers the messages of queue until ctx is done or the
+	// broker is closed, then closes the channel. A message is gone once
+	// delivered.
+	Consume(ctx context.Context, queue string) (<-chan Delivery, error)
+	// ConsumeAcked is Consume for deliveries that stay on the queue until
+	// acked, with at most prefetch of them unsettled at a time. Messages
+	// still unsettled when the broker closes go back on the queue.
+	ConsumeAcked(ctx context.Context, queue string, prefetch int) (<-chan Delivery, error)
+	Close() error
+}
+
+// Open connects to the broker at rawURL: RabbitMQ for amqp:// and amqps://
+// URLs, dialed with tlsConfig when it is not nil and publishing with
+// compression, or a new in-memory broker for MemoryURL. The in-memory
+// broker doesn't compress, and takes its MemoryOptions from the query, as
+// in memory://?fail=0.1&drop=0.05&duplicate=0.1&delay=200ms&seed=1.
+func Open(rawURL string, tlsConfig *tls.Config, compression Compression) (Broker, error) {
+	if !strings.HasPrefix(rawURL, MemoryURL) {
+		b, err := DialAMQP(rawURL, tlsConfig)
+		if err != nil {
+			return nil, err
+		}
+		b.Compression = compression
+		return b, nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	opts, err := parseMemoryOptions(u.Query())
+	if err != nil {
+		return nil, fmt.Errorf("invalid broker URL %s: %w", rawURL, err)
+	}
+	return NewMemory(opts), nil
+}
+
+func parseMemoryOptions(q url.Values) (MemoryOptions, error) {
+	var opts MemoryOptions
+	var err error
+	rates := map[string]*float64{
+		"fail":      &opts.FailRate,
+		"drop":      &opts.DropRate,
+		"duplicate": &opts.DuplicateRate,
+	}
+	for key, rate := range rates {
+		if v := q.Get(key); v != "" {
+			if *rate, err = strconv.ParseFloat(v, 64); err != nil || *rate < 0 || *rate > 1 {
+				return opts, fmt.Errorf("%s must be a rate between 0 and 1", key)
+			}
+		}
+	}
+	if v := q.Get("delay"); v != "" {
+		if opts.MaxDelay, err = time.ParseDuration(v); err != nil {
+			return opts, fmt.Errorf("delay: %w", err)
+		}
+	}
+	if v := q.Get("capacity"); v != "" {
+		if opts.Capacity, err = strconv.Atoi(v); err != nil {
+			return opts, fmt.Errorf("capacity: %w", err)
+		}
+	}
+	if v := q.Get("seed"); v != "" {
+		if opts.Seed, err = strconv.ParseUint(v, 10, 64); err != nil {
+			return opts, fmt.Errorf("seed: %w", err)
+		}
+	}
+	return opts, nil
+}
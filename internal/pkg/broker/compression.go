@@ -0,0 +1,87 @@
+package broker
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Encoding is a compression of message bodies. It travels with each
+// message as its content encoding, so consumers decompress whatever
+// their own Compression.
+type Encoding string
+
+const (
+	Identity Encoding = ""
+	Gzip     Encoding = "gzip"
+	Zstd     Encoding = "zstd"
+)
+
+// ParseEncoding parses "gzip", "zstd", or "" or "none" for Identity.
+func ParseEncoding(s string) (Encoding, error) {
+	switch s {
+	case "", "none":
+		return Identity, nil
+	case string(Gzip), string(Zstd):
+		return Encoding(s), nil
+	}
+	return Identity, fmt.Errorf("unknown compression %q: must be gzip, zstd or none", s)
+}
+
+// Compression compresses the published bodies of at least MinSize bytes
+// with Encoding. Smaller ones, such as intent commands, gain little and
+// are sent as they are.
+type Compression struct {
+	Encoding Encoding
+	MinSize  int
+}
+
+var (
+	zstdEncoder, _ = zstd.NewWriter(nil)
+	zstdDecoder, _ = zstd.NewReader(nil)
+)
+
+// compress returns body compressed and its encoding, or body itself and
+// Identity when it is too small to compress.
+func (c Compression) compress(body []byte) ([]byte, Encoding, error) {
+	if c.Encoding == Identity || len(body) < c.MinSize {
+		return body, Identity, nil
+	}
+
+	switch c.Encoding {
+	case Gzip:
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(body); err != nil {
+			return nil, Identity, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, Identity, err
+		}
+		return buf.Bytes(), Gzip, nil
+	case Zstd:
+		return zstdEncoder.EncodeAll(body, nil), Zstd, nil
+	}
+	return nil, Identity, fmt.Errorf("unknown compression %q", c.Encoding)
+}
+
+// decompress returns body decoded from encoding.
+func decompress(encoding Encoding, body []byte) ([]byte, error) {
+	switch encoding {
+	case Identity:
+		return body, nil
+	case Gzip:
+		r, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return io.ReadAll(r)
+	case Zstd:
+		return zstdDecoder.DecodeAll(body, nil)
+	}
+	return nil, fmt.Errorf("unknown content encoding %q", encoding)
+}
@@ -0,0 +1,47 @@
+package broker
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/test-go/testify/assert"
+	"github.com/test-go/testify/require"
+)
+
+func TestCompression(t *testing.T) {
+	body := bytes.Repeat([]byte(`{"hash":"abc","message":"fix the build"},`), 200)
+
+	for _, encoding := range []Encoding{Gzip, Zstd} {
+		compressed, got, err := Compression{Encoding: encoding, MinSize: 1024}.compress(body)
+		require.NoError(t, err)
+		assert.Equal(t, encoding, got)
+		assert.True(t, len(compressed) < len(body)/10, encoding)
+
+		decompressed, err := decompress(got, compressed)
+		require.NoError(t, err)
+		assert.Equal(t, body, decompressed, encoding)
+	}
+}
+
+func TestCompression_SmallBody(t *testing.T) {
+	body := []byte(`{"kind":"new_intent"}`)
+	compressed, encoding, err := Compression{Encoding: Zstd, MinSize: 1024}.compress(body)
+	require.NoError(t, err)
+	assert.Equal(t, Identity, encoding)
+	assert.Equal(t, body, compressed)
+}
+
+func TestParseEncoding(t *testing.T) {
+	for s, want := range map[string]Encoding{"": Identity, "none": Identity, "gzip": Gzip, "zstd": Zstd} {
+		got, err := ParseEncoding(s)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+	_, err := ParseEncoding("brotli")
+	assert.Error(t, err)
+}
+
+func TestDecompress_UnknownEncoding(t *testing.T) {
+	_, err := decompress("br", []byte("x"))
+	assert.Error(t, err)
+}
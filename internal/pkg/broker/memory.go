@@ -0,0 +1,281 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// ErrPublishFailed is returned by a Memory broker's Publish when
+// MemoryOptions.FailRate makes it fail.
+var ErrPublishFailed = errors.New("broker: injected publish failure")
+
+// ErrClosed is returned once the broker is closed.
+var ErrClosed = errors.New("broker: closed")
+
+// MemoryOptions configure a Memory broker. The rates are probabilities
+// between 0 and 1, drawn per message; the zero value delivers every message
+// once, in order.
+type MemoryOptions struct {
+	// Capacity is how many messages a queue holds before Publish blocks,
+	// 1024 when 0.
+	Capacity int
+	// FailRate is the chance Publish returns ErrPublishFailed without
+	// sending the message.
+	FailRate float64
+	// DropRate is the chance Publish reports success but the message is
+	// never delivered.
+	DropRate float64
+	// DuplicateRate is the chance the message is delivered twice.
+	DuplicateRate float64
+	// MaxDelay delays each delivery by up to this long, which also
+	// reorders messages.
+	MaxDelay time.Duration
+	// Seed makes the injected failures repeatable.
+	Seed uint64
+}
+
+// Memory is a Broker passing messages over Go channels within the process.
+// Queues are created on first use, so Declare is optional.
+type Memory struct {
+	opts MemoryOptions
+
+	mu     sync.Mutex
+	rng    *rand.Rand
+	queues map[string]chan Delivery
+	done   chan struct{}
+	closed bool
+}
+
+// NewMemory returns an in-memory broker.
+func NewMemory(opts MemoryOptions) *Memory {
+	if opts.Capacity <= 0 {
+		opts.Capacity = 1024
+	}
+	return &Memory{
+		opts:   opts,
+		rng:    rand.New(rand.NewPCG(opts.Seed, opts.Seed)),
+		queues: make(map[string]chan Delivery),
+		done:   make(chan struct{}),
+	}
+}
+
+func (b *Memory) Declare(queues ...string) error {
+	for _, queue := range queues {
+		if _, err := b.queue(queue); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *Memory) Publish(ctx context.Context, queue string, body []byte) error {
+	q, err := b.queue(queue)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	fail := b.roll(b.opts.FailRate)
+	drop := b.roll(b.opts.DropRate)
+	copies := 1
+	if b.roll(b.opts.DuplicateRate) {
+		copies = 2
+	}
+	var delay time.Duration
+	if b.opts.MaxDelay > 0 {
+		delay = time.Duration(b.rng.Int64N(int64(b.opts.MaxDelay)))
+	}
+	b.mu.Unlock()
+
+	if fail {
+		return ErrPublishFailed
+	}
+	if drop {
+		return nil
+	}
+
+	// Copy the body, as the caller may reuse it.
+	d := Delivery{Body: append([]byte(nil), body...)}
+	for i := 0; i < copies; i++ {
+		if delay > 0 {
+			go b.deliverAfter(q, d, delay)
+			continue
+		}
+		select {
+		case q <- d:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-b.done:
+			return ErrClosed
+		}
+	}
+	return nil
+}
+
+func (b *Memory) deliverAfter(q chan Delivery, d Delivery, delay time.Duration) {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-b.done:
+		return
+	}
+	select {
+	case q <- d:
+	case <-b.done:
+	}
+}
+
+func (b *Memory) Consume(ctx context.Context, queue string) (<-chan Delivery, error) {
+	q, err := b.queue(queue)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan Delivery)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case d := <-q:
+				select {
+				case out <- d:
+				case <-ctx.Done():
+					// Leave the message for the other consumers.
+					b.requeue(q, d)
+					return
+				case <-b.done:
+					return
+				}
+			case <-ctx.Done():
+				return
+			case <-b.done:
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// ConsumeAcked is Consume holding back deliveries while prefetch of them
+// are unsettled.
+func (b *Memory) ConsumeAcked(ctx context.Context, queue string, prefetch int) (<-chan Delivery, error) {
+	q, err := b.queue(queue)
+	if err != nil {
+		return nil, err
+	}
+
+	var slots chan struct{}
+	if prefetch > 0 {
+		slots = make(chan struct{}, prefetch)
+	}
+	out := make(chan Delivery)
+	go func() {
+		defer close(out)
+		for {
+			// A nil slots never blocks the send, so there is no limit.
+			if slots != nil {
+				select {
+				case slots <- struct{}{}:
+				case <-ctx.Done():
+					return
+				case <-b.done:
+					return
+				}
+			}
+
+			select {
+			case d := <-q:
+				d.acker = &memoryAcker{broker: b, queue: q, body: d.Body, slots: slots}
+				select {
+				case out <- d:
+				case <-ctx.Done():
+					b.requeue(q, Delivery{Body: d.Body})
+					return
+				case <-b.done:
+					return
+				}
+			case <-ctx.Done():
+				return
+			case <-b.done:
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// memoryAcker settles a Memory delivery once, freeing its prefetch slot.
+type memoryAcker struct {
+	broker *Memory
+	queue  chan Delivery
+	body   []byte
+	slots  chan struct{}
+	once   sync.Once
+}
+
+func (a *memoryAcker) ack() error {
+	return a.settle(false)
+}
+
+func (a *memoryAcker) nack(requeue bool) error {
+	return a.settle(requeue)
+}
+
+func (a *memoryAcker) settle(requeue bool) error {
+	settled := false
+	a.once.Do(func() {
+		settled = true
+		if a.slots != nil {
+			<-a.slots
+		}
+		if requeue {
+			a.broker.requeue(a.queue, Delivery{Body: a.body})
+		}
+	})
+	if !settled {
+		return errors.New("broker: delivery already settled")
+	}
+	return nil
+}
+
+func (b *Memory) requeue(q chan Delivery, d Delivery) {
+	select {
+	case q <- d:
+	default:
+	}
+}
+
+// Close stops all consumers; pending messages are discarded.
+func (b *Memory) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.closed {
+		b.closed = true
+		close(b.done)
+	}
+	return nil
+}
+
+func (b *Memory) queue(name string) (chan Delivery, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, ErrClosed
+	}
+	q, ok := b.queues[name]
+	if !ok {
+		q = make(chan Delivery, b.opts.Capacity)
+		b.queues[name] = q
+	}
+	return q, nil
+}
+
+// roll reports whether an event of the given probability happens. The
+// caller holds b.mu.
+func (b *Memory) roll(rate float64) bool {
+	return rate > 0 && b.rng.Float64() < rate
+}
@@ -0,0 +1,25 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/noelukwa/indexer/internal/pkg/broker"
+)
+
+// MessageCompression compresses the messages a service publishes: gzip,
+// zstd or none. Bodies under BrokerCompressionMinBytes are sent as they
+// are. Consumers decompress by each message's content encoding, so
+// services can turn it on one at a time.
+type MessageCompression struct {
+	BrokerCompression         string `split_words:"true" default:"none"`
+	BrokerCompressionMinBytes int    `split_words:"true" default:"4096"`
+}
+
+// Compression returns the broker's compression settings.
+func (c MessageCompression) Compression() (broker.Compression, error) {
+	encoding, err := broker.ParseEncoding(c.BrokerCompression)
+	if err != nil {
+		return broker.Compression{}, fmt.Errorf("broker compression: %w", err)
+	}
+	return broker.Compression{Encoding: encoding, MinSize: c.BrokerCompressionMinBytes}, nil
+}
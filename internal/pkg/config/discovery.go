@@ -8,4 +8,7 @@ type DiscoveryConfig struct {
 	RabbitMQConsumeQueue string        `split_words:"true" required:"true"`
 	RabbitMQPublishQueue string        `split_words:"true" required:"true"`
 	BroadcastInterval    time.Duration `split_words:"true" required:"true"`
+	BrokerTLS
+	MessageCompression
+	RedisAuth
 }
@@ -1,9 +1,228 @@
 package config
 
+import (
+	"time"
+
+	"github.com/noelukwa/indexer/internal/pkg/retry"
+)
+
 type ManagerConfig struct {
 	DatabaseURL      string `split_words:"true" required:"true"`
 	RabbitMQURL      string `split_words:"true" required:"true"`
 	IntentsQueueName string `split_words:"true" required:"true"`
 	CommitsQueueName string `split_words:"true" required:"true"`
 	ServerPort       int    `split_words:"true" required:"true"`
+	BrokerTLS
+	MessageCompression
+	RedisAuth
+
+	// GitHub OAuth login. Leaving GitHubClientID empty disables login, so
+	// only API keys are accepted.
+	GitHubClientID     string            `split_words:"true"`
+	GitHubClientSecret string            `split_words:"true"`
+	GitHubRedirectURL  string            `split_words:"true"`
+	GitHubOrgRoles     map[string]string `split_words:"true"`
+	SessionTTL         time.Duration     `split_words:"true" default:"24h"`
+	// Expired sessions are deleted every SessionPurgeInterval.
+	SessionPurgeInterval time.Duration `split_words:"true" default:"1h"`
+
+	// The API requires a session or an API key unless AuthDisabled, which
+	// is meant for local development. AdminAPIKey, when set, is accepted
+	// as an admin key without being stored, to create the first keys with.
+	AuthDisabled bool   `split_words:"true"`
+	AdminAPIKey  string `split_words:"true"`
+
+	// The OpenAPI spec and Swagger UI are served unless DocsDisabled.
+	DocsDisabled bool `split_words:"true"`
+
+	// CredentialsKey is the base64 encoded 32 byte key that seals per-intent
+	// GitHub tokens. The monitor must share it. Leaving it empty disables
+	// stored credentials.
+	CredentialsKey string `split_words:"true"`
+
+	// GitHubWebhookSecret is the webhook secret of the indexer's GitHub App.
+	// Setting it serves the App's webhook, where installing the App on
+	// repositories creates intents for them and removing it pauses them.
+	GitHubWebhookSecret string `split_words:"true"`
+
+	// RedisAddr is the monitor's Redis, where it reports GitHub rate limits.
+	// The manager also caches query results there for QueryCacheTTL, zero
+	// to turn the cache off. Leaving it empty disables both. Statistics
+	// across repositories, which new commits don't invalidate, are cached
+	// for QueryCacheStatsTTL instead, zero to not cache them.
+	RedisAddr          string        `split_words:"true"`
+	QueryCacheTTL      time.Duration `split_words:"true" default:"5m"`
+	QueryCacheStatsTTL time.Duration `split_words:"true" default:"1m"`
+
+	// Retry* bound the retries of failed database writes. An intent's retry
+	// policy overrides the attempts, backoff and jitter for its repository,
+	// but no retry waits longer than RetryMaxDelay and a write gives up
+	// after RetryMaxElapsed, as commits are saved one batch at a time.
+	RetryMaxAttempts int           `split_words:"true" default:"3"`
+	RetryBackoffBase time.Duration `split_words:"true" default:"5s"`
+	RetryJitter      float64       `split_words:"true" default:"0"`
+	RetryMaxDelay    time.Duration `split_words:"true" default:"30s"`
+	RetryMaxElapsed  time.Duration `split_words:"true" default:"2m"`
+
+	// The leaderboard views are refreshed every LeaderboardRefreshInterval,
+	// and sooner once LeaderboardRefreshCommits commits have been ingested.
+	LeaderboardRefreshInterval time.Duration `split_words:"true" default:"10m"`
+	LeaderboardRefreshCommits  int64         `split_words:"true" default:"10000"`
+
+	// The daily rollup takes in new commits as batches arrive, and at least
+	// every RollupInterval, RollupBatchSize commits at a time.
+	RollupInterval  time.Duration `split_words:"true" default:"1m"`
+	RollupBatchSize int           `split_words:"true" default:"5000"`
+
+	// Commit ingestion is throttled to IngestCommitsPerSecond commits and
+	// IngestBatchesPerSecond batches a second, zero for no limit. Batches
+	// are acked once saved, with at most IngestPrefetch unacked, so the
+	// backlog of a throttled backfill waits on the broker.
+	IngestCommitsPerSecond float64 `split_words:"true" default:"0"`
+	IngestBatchesPerSecond float64 `split_words:"true" default:"0"`
+	IngestPrefetch         int     `split_words:"true" default:"10"`
+
+	// CommitHooks names the registered commit hooks to run, in order, on
+	// each batch of commits before it is saved.
+	CommitHooks []string `split_words:"true"`
+
+	// Weekly digests are emailed from DigestFrom through the SMTP server
+	// at SMTPAddr, a host:port, signing in with SMTPUsername and
+	// SMTPPassword when set. Leaving SMTPAddr empty disables them. Digests
+	// due are sent every DigestCheckInterval.
+	SMTPAddr            string        `split_words:"true"`
+	SMTPUsername        string        `split_words:"true"`
+	SMTPPassword        string        `split_words:"true"`
+	DigestFrom          string        `split_words:"true" default:"indexer@localhost"`
+	DigestCheckInterval time.Duration `split_words:"true" default:"1h"`
+
+	// The commits ingested per repository are pushed to the Prometheus
+	// remote-write endpoint at RemoteWriteURL every RemoteWriteInterval,
+	// with basic auth when RemoteWriteUsername is set and RemoteWriteLabels
+	// added to each series. Leaving RemoteWriteURL empty disables it.
+	RemoteWriteURL      string            `split_words:"true"`
+	RemoteWriteUsername string            `split_words:"true"`
+	RemoteWritePassword string            `split_words:"true"`
+	RemoteWriteInterval time.Duration     `split_words:"true" default:"15s"`
+	RemoteWriteLabels   map[string]string `split_words:"true"`
+
+	// Rolled up commits authored more than ArchiveAfter ago are moved out
+	// of the database to Parquet objects at ArchiveURL, s3://bucket/prefix
+	// or file:///path, every ArchiveInterval, ArchiveBatchSize commits to
+	// an object. The Archive S3 settings default to AWS's. Leaving
+	// ArchiveURL empty or ArchiveAfter zero disables it.
+	ArchiveURL               string        `split_words:"true"`
+	ArchiveAfter             time.Duration `split_words:"true"`
+	ArchiveInterval          time.Duration `split_words:"true" default:"24h"`
+	ArchiveBatchSize         int           `split_words:"true" default:"50000"`
+	ArchiveS3Endpoint        string        `split_words:"true"`
+	ArchiveS3Region          string        `split_words:"true" default:"us-east-1"`
+	ArchiveS3AccessKeyID     string        `split_words:"true"`
+	ArchiveS3SecretAccessKey string        `split_words:"true"`
+
+	// Deleted repositories are purged, with their commits, once deleted
+	// for RepoRetention, checked every RepoPurgeInterval.
+	RepoRetention     time.Duration `split_words:"true" default:"720h"`
+	RepoPurgeInterval time.Duration `split_words:"true" default:"1h"`
+
+	// Each API client, by API key or else by IP address, may make
+	// APIRateLimit requests a second, and bursts of up to APIRateBurst.
+	// Limits are shared between replicas through RedisAddr, when set.
+	// Leaving APIRateLimit zero disables them.
+	APIRateLimit float64 `split_words:"true"`
+	APIRateBurst int     `split_words:"true" default:"60"`
+
+	// Listings serve DefaultPerPage items when per_page is left out, and at
+	// most MaxPerPage, capping larger requests.
+	DefaultPerPage int `split_words:"true" default:"20"`
+	MaxPerPage     int `split_words:"true" default:"100"`
+
+	// MonitorQueueName is the queue the monitor consumes intents from.
+	// Forced broadcasts go straight to it. An intent is forced out, or
+	// rebroadcast, at most once every BroadcastCooldown.
+	MonitorQueueName  string        `split_words:"true" default:"discovery.yields"`
+	BroadcastCooldown time.Duration `split_words:"true" default:"1m"`
+
+	// A reindex swaps in only if its shadow holds at least ReindexMinRatio
+	// of the repository's live commits.
+	ReindexMinRatio float64 `split_words:"true" default:"0.9"`
+
+	// The API is served over HTTPS on ServerPort with the certificate in
+	// TLSCertFile and TLSKeyFile, or one obtained from Let's Encrypt for
+	// AutocertDomains and cached in AutocertCacheDir. HTTPRedirectPort,
+	// when set, redirects plain HTTP to HTTPS and answers ACME challenges.
+	TLSCertFile      string   `split_words:"true"`
+	TLSKeyFile       string   `split_words:"true"`
+	AutocertDomains  []string `split_words:"true"`
+	AutocertCacheDir string   `split_words:"true" default:"autocert"`
+	AutocertEmail    string   `split_words:"true"`
+	HTTPRedirectPort int      `split_words:"true"`
+
+	// GRPCPort, when set, also serves the gRPC API for internal services
+	// on that port, over TLS when the API is.
+	GRPCPort int `split_words:"true"`
+
+	// Server timeouts; zero disables one. Intent event streams lift the
+	// write timeout for their connection.
+	ReadHeaderTimeout time.Duration `split_words:"true" default:"10s"`
+	ReadTimeout       time.Duration `split_words:"true" default:"30s"`
+	WriteTimeout      time.Duration `split_words:"true" default:"60s"`
+	IdleTimeout       time.Duration `split_words:"true" default:"2m"`
+}
+
+// RetryPolicy is the default policy for failed database writes.
+func (c *ManagerConfig) RetryPolicy() retry.Policy {
+	return retry.Policy{
+		MaxAttempts: c.RetryMaxAttempts,
+		BackoffBase: c.RetryBackoffBase,
+		Jitter:      c.RetryJitter,
+		MaxDelay:    c.RetryMaxDelay,
+	}
+}
+
+// TLSEnabled reports whether the API is served over HTTPS.
+func (c *ManagerConfig) TLSEnabled() bool {
+	return c.TLSCertFile != "" || c.TLSKeyFile != "" || len(c.AutocertDomains) > 0
+}
+
+// GRPCEnabled reports whether the gRPC API is served.
+func (c *ManagerConfig) GRPCEnabled() bool {
+	return c.GRPCPort != 0
+}
+
+// DigestsEnabled reports whether weekly digests are emailed.
+func (c *ManagerConfig) DigestsEnabled() bool {
+	return c.SMTPAddr != ""
+}
+
+// RemoteWriteEnabled reports whether commit metrics are pushed to a
+// Prometheus remote-write endpoint.
+func (c *ManagerConfig) RemoteWriteEnabled() bool {
+	return c.RemoteWriteURL != ""
+}
+
+// ArchiveEnabled reports whether cold commits are archived to object
+// storage.
+func (c *ManagerConfig) ArchiveEnabled() bool {
+	return c.ArchiveURL != "" && c.ArchiveAfter > 0
+}
+
+// APIRateLimitEnabled reports whether API clients are rate limited.
+func (c *ManagerConfig) APIRateLimitEnabled() bool {
+	return c.APIRateLimit > 0
+}
+
+// AuthEnabled reports whether API requests must be authenticated.
+func (c *ManagerConfig) AuthEnabled() bool {
+	return !c.AuthDisabled
+}
+
+// DocsEnabled reports whether the OpenAPI spec and Swagger UI are served.
+func (c *ManagerConfig) DocsEnabled() bool {
+	return !c.DocsDisabled
+}
+
+// OAuthEnabled reports whether GitHub login has been configured.
+func (c *ManagerConfig) OAuthEnabled() bool {
+	return c.GitHubClientID != ""
 }
@@ -1,9 +1,151 @@
 package config
 
+import (
+	"strings"
+	"time"
+
+	"github.com/noelukwa/indexer/internal/pkg/retry"
+)
+
 type MonitorConfig struct {
 	RabbitMQURL          string `split_words:"true" required:"true"`
 	RabbitMQConsumeQueue string `split_words:"true" required:"true"`
 	RabbitMQPublishQueue string `split_words:"true" required:"true"`
-	GitHubToken          string `split_words:"true" required:"true"`
-	RedisAddr            string `split_words:"true" required:"true"`
+	// GitHubToken is the token the monitor fetches with, or a
+	// comma-separated pool of tokens that it takes turns with. It is only
+	// optional with a GitHub App.
+	GitHubToken string `split_words:"true"`
+	BrokerTLS
+	MessageCompression
+
+	// RedisAddr shares repository locks, backfill checkpoints and rate
+	// limits with other monitors and the manager. Empty runs a single
+	// monitor that keeps its locks and checkpoints in process.
+	RedisAddr string `split_words:"true"`
+	RedisAuth
+
+	// GitHubApp* make the monitor fetch as an installation of a GitHub App
+	// in place of GitHubToken, at the App's rate limits. The private key is
+	// the PEM file GitHub generates for the App, and the installation's
+	// short-lived tokens are renewed as they expire.
+	GitHubAppID             int64  `split_words:"true"`
+	GitHubAppInstallationID int64  `split_words:"true"`
+	GitHubAppPrivateKeyFile string `split_words:"true"`
+
+	// GitHubBaseURL points the monitor at another GitHub API, such as a
+	// GitHub Enterprise server or a githubtest stub. Empty uses github.com.
+	GitHubBaseURL string `split_words:"true"`
+
+	// MaxConcurrentFetches caps in-flight GitHub requests across all
+	// repositories. The Repo* values are per-repository defaults that an
+	// intent may override; a zero RepoRequestsPerMinute means unlimited.
+	MaxConcurrentFetches   int `split_words:"true" default:"10"`
+	RepoMaxConcurrentPages int `split_words:"true" default:"1"`
+	RepoRequestsPerMinute  int `split_words:"true" default:"0"`
+
+	// GitHubRateLimitReserve pauses the fetches made with a token once no
+	// more than this many of its requests are left, until its quota
+	// resets.
+	GitHubRateLimitReserve int `split_words:"true" default:"50"`
+
+	// MaxMessageBytes splits commit batches whose message would be larger
+	// than this, before compression, to stay under the broker's message
+	// size limit. 0 never splits them.
+	MaxMessageBytes int `split_words:"true" default:"16777216"`
+
+	// MaxConcurrentIntents caps the intents a monitor takes off the queue
+	// before finishing one, leaving the rest to other monitors. It is at
+	// least twice MaxConcurrentRepos, so intents wait queued for a worker.
+	MaxConcurrentIntents int `split_words:"true" default:"20"`
+
+	// MaxConcurrentRepos caps the intents a monitor runs at once, each on
+	// its own repository. The intents taken off the queue beyond it wait
+	// their turn, queued per repository.
+	MaxConcurrentRepos int `split_words:"true" default:"10"`
+
+	// GitHubCacheTTL is how long the monitor keeps GitHub's responses in
+	// Redis to make its next requests for them conditional, which GitHub
+	// doesn't count against the quota while they are unchanged. Zero turns
+	// the cache off.
+	GitHubCacheTTL time.Duration `split_words:"true" default:"24h"`
+
+	// CursorOverlap is how far before the newest commit of its last run an
+	// intent's next run lists again, to catch commits pushed since with
+	// older commit dates, such as those of a merged branch.
+	CursorOverlap time.Duration `split_words:"true" default:"168h"`
+
+	// GitHubGraphQL lists commits through the GitHub GraphQL API, which
+	// returns their line counts with a hundred commits per request. Queries
+	// by author, and any GraphQL fails, are listed through REST instead.
+	GitHubGraphQL bool `split_words:"true" default:"false"`
+
+	// FetchCommitStats fetches each commit's details for its line counts,
+	// which costs one extra GitHub request per commit, so is off unless
+	// asked for. GitHubGraphQL gets them without.
+	FetchCommitStats bool `split_words:"true" default:"false"`
+
+	// FetchCommitComments fetches the comments on commits that have any,
+	// one extra GitHub request per page of comments.
+	FetchCommitComments bool `split_words:"true" default:"false"`
+
+	// FetchPullRequestReviews fetches the reviews of the pull requests
+	// updated since an intent's start once its commits are in.
+	FetchPullRequestReviews bool `split_words:"true" default:"false"`
+
+	// FetchWorkflowRuns fetches the completed GitHub Actions runs created
+	// within an intent's dates once its commits are in.
+	FetchWorkflowRuns bool `split_words:"true" default:"false"`
+
+	// FetchGitHubStats fetches the code frequency, participation and punch
+	// card GitHub precomputes for a repository on each sync of it.
+	FetchGitHubStats bool `split_words:"true" default:"false"`
+
+	// FetchSecurityAlerts fetches a repository's Dependabot alerts on each
+	// sync of it. Tokens without access to them skip the alerts.
+	FetchSecurityAlerts bool `split_words:"true" default:"false"`
+
+	// CredentialsKey opens the GitHub tokens intents carry in place of
+	// GitHubToken. It must match the manager's key.
+	CredentialsKey string `split_words:"true"`
+
+	// Retry* bound the retries of failed GitHub requests and publishes. An
+	// intent's retry policy overrides them for its GitHub requests.
+	RetryMaxAttempts int           `split_words:"true" default:"3"`
+	RetryBackoffBase time.Duration `split_words:"true" default:"5s"`
+	RetryJitter      float64       `split_words:"true" default:"0"`
+
+	// LockReapInterval is how often the monitor clears repository locks
+	// left behind by crashed monitors.
+	LockReapInterval time.Duration `split_words:"true" default:"1m"`
+
+	// PushPollInterval, when set, polls the events feed of each repository
+	// the monitor has synced that often for commits pushed since, until no
+	// sync has refreshed the repository for PushPollTTL.
+	PushPollInterval time.Duration `split_words:"true" default:"0"`
+	PushPollTTL      time.Duration `split_words:"true" default:"1h"`
+
+	// DebugAddr, when set, serves the state of the monitor's rate limit
+	// budgets at /debug/ratelimit on this address.
+	DebugAddr string `split_words:"true"`
+}
+
+// GitHubTokens returns the tokens of GitHubToken.
+func (c *MonitorConfig) GitHubTokens() []string {
+	var tokens []string
+	for _, token := range strings.Split(c.GitHubToken, ",") {
+		if token = strings.TrimSpace(token); token != "" {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+// RetryPolicy is the default policy for failed GitHub requests and
+// publishes.
+func (c *MonitorConfig) RetryPolicy() retry.Policy {
+	return retry.Policy{
+		MaxAttempts: c.RetryMaxAttempts,
+		BackoffBase: c.RetryBackoffBase,
+		Jitter:      c.RetryJitter,
+	}
 }
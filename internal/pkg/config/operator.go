@@ -0,0 +1,18 @@
+package config
+
+import "time"
+
+type OperatorConfig struct {
+	ManagerURL   string `split_words:"true" default:"http://localhost:8009"`
+	ManagerToken string `split_words:"true"`
+
+	// KubeAPIURL is the Kubernetes API to watch, such as
+	// http://127.0.0.1:8001 behind kubectl proxy. Empty uses the in-cluster
+	// service account.
+	KubeAPIURL string `split_words:"true"`
+	// Namespace limits the operator to one namespace. Empty watches all.
+	Namespace string `split_words:"true"`
+
+	// ResyncInterval is how often every RepositoryIntent is reconciled.
+	ResyncInterval time.Duration `split_words:"true" default:"30s"`
+}
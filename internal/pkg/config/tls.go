@@ -0,0 +1,101 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// BrokerTLS holds the certificates for an amqps:// broker URL. Without a
+// CA the system roots are trusted. With a client certificate and no
+// credentials in the URL, the broker authenticates the certificate
+// (SASL EXTERNAL).
+type BrokerTLS struct {
+	BrokerCACert     string `split_words:"true"`
+	BrokerClientCert string `split_words:"true"`
+	BrokerClientKey  string `split_words:"true"`
+}
+
+// BrokerTLSConfig returns the TLS config to dial url with, or nil for a
+// plaintext URL.
+func (c BrokerTLS) BrokerTLSConfig(url string) (*tls.Config, error) {
+	if !strings.HasPrefix(url, "amqps://") {
+		if c.BrokerCACert != "" || c.BrokerClientCert != "" || c.BrokerClientKey != "" {
+			return nil, errors.New("broker certificates are set but the broker URL is not amqps://")
+		}
+		return nil, nil
+	}
+	cfg, err := loadTLS(c.BrokerCACert, c.BrokerClientCert, c.BrokerClientKey)
+	if err != nil {
+		return nil, fmt.Errorf("broker TLS: %w", err)
+	}
+	return cfg, nil
+}
+
+// RedisAuth holds Redis ACL credentials and TLS settings. The certificates
+// require RedisTLS.
+type RedisAuth struct {
+	RedisUsername   string `split_words:"true"`
+	RedisPassword   string `split_words:"true"`
+	RedisTLS        bool   `split_words:"true"`
+	RedisCACert     string `split_words:"true"`
+	RedisClientCert string `split_words:"true"`
+	RedisClientKey  string `split_words:"true"`
+}
+
+// RedisOptions returns the options to connect to the Redis server at addr.
+func (c RedisAuth) RedisOptions(addr string) (*redis.Options, error) {
+	opts := &redis.Options{
+		Addr:     addr,
+		Username: c.RedisUsername,
+		Password: c.RedisPassword,
+	}
+	if c.RedisUsername != "" && c.RedisPassword == "" {
+		return nil, errors.New("redis username is set without a password")
+	}
+
+	if !c.RedisTLS {
+		if c.RedisCACert != "" || c.RedisClientCert != "" || c.RedisClientKey != "" {
+			return nil, errors.New("redis certificates are set but redis TLS is disabled")
+		}
+		return opts, nil
+	}
+	cfg, err := loadTLS(c.RedisCACert, c.RedisClientCert, c.RedisClientKey)
+	if err != nil {
+		return nil, fmt.Errorf("redis TLS: %w", err)
+	}
+	opts.TLSConfig = cfg
+	return opts, nil
+}
+
+func loadTLS(caFile, certFile, keyFile string) (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA certificate: %w", err)
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in %s", caFile)
+		}
+	}
+
+	if (certFile == "") != (keyFile == "") {
+		return nil, errors.New("client certificate and key must be set together")
+	}
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
@@ -0,0 +1,182 @@
+// Package githubtest stands in for the GitHub API in tests. A Server
+// replays canned responses, either added in code or recorded from the real
+// API into a cassette file, so pagination, rate limits and error paths can
+// be exercised deterministically and offline.
+package githubtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v63/github"
+)
+
+// Interaction is a request and the response it got. Path includes the
+// query string, so each page of a listing is its own interaction.
+type Interaction struct {
+	Method string          `json:"method"`
+	Path   string          `json:"path"`
+	Status int             `json:"status"`
+	Header http.Header     `json:"header,omitempty"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+// Cassette is the file format of recorded interactions.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Server is an httptest server speaking the GitHub API. Requests are
+// answered by the first unused interaction with the same method and path;
+// once those are used up, the last one repeats, so polled endpoints keep
+// answering. Requests without an interaction get a 404.
+type Server struct {
+	*httptest.Server
+
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+	misses       []string
+
+	// Recording state, see Record.
+	upstream *url.URL
+	token    string
+	cassette string
+}
+
+// NewServer starts a server replaying interactions.
+func NewServer(interactions ...Interaction) *Server {
+	s := &Server{}
+	s.Add(interactions...)
+	s.Server = httptest.NewServer(http.HandlerFunc(s.replay))
+	return s
+}
+
+// Replay starts a server replaying the cassette at path.
+func Replay(path string) (*Server, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cassette Cassette
+	if err := json.Unmarshal(data, &cassette); err != nil {
+		return nil, fmt.Errorf("failed to parse cassette %s: %w", path, err)
+	}
+	return NewServer(cassette.Interactions...), nil
+}
+
+// Add queues more interactions to replay.
+func (s *Server) Add(interactions ...Interaction) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.interactions = append(s.interactions, interactions...)
+	s.used = append(s.used, make([]bool, len(interactions))...)
+}
+
+// Misses lists the requests, as "METHOD path", that had no interaction.
+func (s *Server) Misses() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.misses...)
+}
+
+// Client returns a GitHub client that talks to the server.
+func (s *Server) Client() *github.Client {
+	client := github.NewClient(s.Server.Client())
+	base, _ := url.Parse(s.URL + "/")
+	client.BaseURL = base
+	return client
+}
+
+func (s *Server) replay(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.RequestURI()
+
+	s.mu.Lock()
+	match := -1
+	for i, in := range s.interactions {
+		if in.Method != r.Method || in.Path != path {
+			continue
+		}
+		match = i
+		if !s.used[i] {
+			break
+		}
+	}
+	if match < 0 {
+		s.misses = append(s.misses, r.Method+" "+path)
+		s.mu.Unlock()
+		writeInteraction(w, Error(r.Method, path, http.StatusNotFound, "Not Found"))
+		return
+	}
+	s.used[match] = true
+	in := s.interactions[match]
+	s.mu.Unlock()
+
+	writeInteraction(w, in)
+}
+
+func writeInteraction(w http.ResponseWriter, in Interaction) {
+	for key, values := range in.Header {
+		for _, v := range values {
+			w.Header().Add(key, v)
+		}
+	}
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	}
+	status := in.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+	w.Write(in.Body)
+}
+
+// JSON answers method and path with v encoded as the body.
+func JSON(method, path string, v interface{}) Interaction {
+	body, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("githubtest: failed to encode response for %s %s: %v", method, path, err))
+	}
+	return Interaction{Method: method, Path: path, Status: http.StatusOK, Body: body}
+}
+
+// Page answers one page of a listing with v and a Link header pointing at
+// the next page, or none on the last page when next is 0.
+func Page(method, path string, next int, v interface{}) Interaction {
+	in := JSON(method, path, v)
+	if next > 0 {
+		u, _ := url.Parse(path)
+		q := u.Query()
+		q.Set("page", strconv.Itoa(next))
+		u.RawQuery = q.Encode()
+		in.Header = http.Header{"Link": {fmt.Sprintf(`<%s>; rel="next"`, u.String())}}
+	}
+	return in
+}
+
+// Error answers method and path with a GitHub error response.
+func Error(method, path string, status int, message string) Interaction {
+	body, _ := json.Marshal(map[string]string{"message": message})
+	return Interaction{Method: method, Path: path, Status: status, Body: body}
+}
+
+// RateLimited answers method and path as GitHub does once the core quota
+// is exhausted until reset.
+func RateLimited(method, path string, reset time.Time) Interaction {
+	in := Error(method, path, http.StatusForbidden, "API rate limit exceeded for user ID 1.")
+	in.Header = http.Header{
+		"X-Ratelimit-Limit":     {"5000"},
+		"X-Ratelimit-Remaining": {"0"},
+		"X-Ratelimit-Used":      {"5000"},
+		"X-Ratelimit-Reset":     {strconv.FormatInt(reset.Unix(), 10)},
+	}
+	return in
+}
@@ -0,0 +1,106 @@
+package githubtest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// recordedHeaders are the response headers worth replaying: pagination,
+// rate limits and the content type.
+var recordedHeaders = []string{
+	"Content-Type",
+	"Link",
+	"X-Ratelimit-Limit",
+	"X-Ratelimit-Remaining",
+	"X-Ratelimit-Used",
+	"X-Ratelimit-Reset",
+	"X-Ratelimit-Resource",
+	"Retry-After",
+}
+
+// Record starts a server that forwards requests to the GitHub API at
+// upstream, authenticating with token, and records the responses. Close
+// writes them to the cassette at path for Replay. The token is not
+// recorded.
+func Record(upstream, token, path string) (*Server, error) {
+	u, err := url.Parse(upstream)
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{upstream: u, token: token, cassette: path}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.record))
+	return s, nil
+}
+
+// Close shuts the server down and, when recording, writes the cassette.
+func (s *Server) Close() error {
+	s.Server.Close()
+	if s.cassette == "" {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := json.MarshalIndent(Cassette{Interactions: s.interactions}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.cassette, data, 0o644)
+}
+
+func (s *Server) record(w http.ResponseWriter, r *http.Request) {
+	target := *s.upstream
+	target.Path = strings.TrimSuffix(s.upstream.Path, "/") + r.URL.Path
+	target.RawQuery = r.URL.RawQuery
+	req, err := http.NewRequestWithContext(r.Context(), r.Method, target.String(), r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	req.Header.Set("Accept", r.Header.Get("Accept"))
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	in := Interaction{
+		Method: r.Method,
+		Path:   r.URL.RequestURI(),
+		Status: resp.StatusCode,
+		Header: http.Header{},
+	}
+	for _, key := range recordedHeaders {
+		if values := resp.Header.Values(key); len(values) > 0 {
+			in.Header[key] = values
+		}
+	}
+	// Pagination links point at the upstream, so make them relative to
+	// whichever server replays them.
+	for i, link := range in.Header.Values("Link") {
+		in.Header["Link"][i] = strings.ReplaceAll(link, strings.TrimSuffix(s.upstream.String(), "/"), "")
+	}
+	if json.Valid(body) {
+		in.Body = body
+	}
+	s.Add(in)
+
+	replayed := in
+	replayed.Body = body
+	writeInteraction(w, replayed)
+}
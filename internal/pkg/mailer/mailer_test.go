@@ -0,0 +1,95 @@
+package mailer
+
+import (
+	"context"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/test-go/testify/assert"
+	"github.com/test-go/testify/require"
+)
+
+func TestMessage(t *testing.T) {
+	date := time.Date(2024, 6, 10, 9, 0, 0, 0, time.UTC)
+	msg := string(message("indexer@example.com", []string{"a@example.com", "b@example.com"}, "Weekly digest: café", "one\ntwo\n", date))
+
+	assert.Contains(t, msg, "From: indexer@example.com\r\n")
+	assert.Contains(t, msg, "To: a@example.com, b@example.com\r\n")
+	assert.Contains(t, msg, "Subject: =?utf-8?q?Weekly_digest:_caf=C3=A9?=\r\n")
+	assert.Contains(t, msg, "Date: Mon, 10 Jun 2024 09:00:00 +0000\r\n")
+	assert.True(t, strings.HasSuffix(msg, "\r\n\r\none\r\ntwo\r\n"))
+}
+
+// fakeSMTP accepts one message on a local listener and returns its
+// envelope recipients and data.
+func fakeSMTP(t *testing.T) (addr string, received chan []string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	received = make(chan []string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		tp := textproto.NewConn(conn)
+		tp.PrintfLine("220 localhost ready")
+
+		var got []string
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			switch strings.ToUpper(strings.SplitN(line, " ", 2)[0]) {
+			case "EHLO", "HELO":
+				tp.PrintfLine("250 localhost")
+			case "MAIL":
+				tp.PrintfLine("250 OK")
+			case "RCPT":
+				got = append(got, line)
+				tp.PrintfLine("250 OK")
+			case "DATA":
+				tp.PrintfLine("354 go ahead")
+				data, err := tp.ReadDotLines()
+				if err != nil {
+					return
+				}
+				got = append(got, data...)
+				tp.PrintfLine("250 OK")
+			case "QUIT":
+				tp.PrintfLine("221 bye")
+				received <- got
+				return
+			default:
+				tp.PrintfLine("502 unsupported")
+			}
+		}
+	}()
+	return ln.Addr().String(), received
+}
+
+func TestSend(t *testing.T) {
+	addr, received := fakeSMTP(t)
+	m, err := New(addr, "", "", "indexer@example.com")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, m.Send(ctx, []string{"a@example.com"}, "Digest", "hello"))
+
+	got := <-received
+	assert.Equal(t, "RCPT TO:<a@example.com>", got[0])
+	assert.Contains(t, got, "Subject: Digest")
+	assert.Equal(t, "hello", got[len(got)-1])
+}
+
+func TestNew_InvalidAddr(t *testing.T) {
+	_, err := New("localhost", "", "", "indexer@example.com")
+	assert.Error(t, err)
+}
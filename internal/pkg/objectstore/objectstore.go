@@ -0,0 +1,107 @@
+// Package objectstore keeps objects in an S3 compatible bucket, such as
+// AWS S3, MinIO or Cloudflare R2, or in a local directory.
+package objectstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotFound is returned for an object that doesn't exist.
+var ErrNotFound = errors.New("object not found")
+
+type Store interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// S3Options configures the S3 buckets Open connects to.
+type S3Options struct {
+	// Endpoint is the S3 API's address, https://s3.<region>.amazonaws.com
+	// by default.
+	Endpoint        string
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// Open returns the store at rawURL: s3://bucket/prefix for a bucket, or
+// file:///path for a local directory. Keys are put under the prefix or
+// path.
+func Open(rawURL string, opts S3Options) (Store, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid object store URL: %w", err)
+	}
+	switch u.Scheme {
+	case "s3":
+		if u.Host == "" {
+			return nil, fmt.Errorf("object store URL %q has no bucket", rawURL)
+		}
+		return NewS3(u.Host, strings.Trim(u.Path, "/"), opts), nil
+	case "file":
+		if u.Path == "" {
+			return nil, fmt.Errorf("object store URL %q has no path", rawURL)
+		}
+		return Dir(u.Path), nil
+	default:
+		return nil, fmt.Errorf("unsupported object store URL %q: want s3:// or file://", rawURL)
+	}
+}
+
+// Dir keeps objects as files under a directory, for development and
+// tests.
+type Dir string
+
+func (d Dir) path(key string) (string, error) {
+	path := filepath.Join(string(d), filepath.FromSlash(key))
+	if !strings.HasPrefix(path, filepath.Clean(string(d))+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid object key %q", key)
+	}
+	return path, nil
+}
+
+func (d Dir) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	path, err := d.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	// Written aside and renamed, so a reader never sees half an object.
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (d Dir) Get(ctx context.Context, key string) ([]byte, error) {
+	path, err := d.path(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+func (d Dir) Delete(ctx context.Context, key string) error {
+	path, err := d.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,256 @@
+// Package parquet writes Parquet files of flat tables in a single row
+// group, with one snappy compressed data page per column: enough to
+// archive rows in a format DuckDB, Spark, pandas and Athena read.
+package parquet
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+)
+
+// Kind is the type of a column's values.
+type Kind int
+
+const (
+	String Kind = iota
+	Int32
+	Int64
+	// Timestamp columns hold time.Time values, stored as milliseconds
+	// since the Unix epoch in UTC.
+	Timestamp
+)
+
+// Column is a named column with a value per row: a string, int32, int64
+// or time.Time by its Kind, or nil for a null when it is Optional.
+type Column struct {
+	Name     string
+	Kind     Kind
+	Optional bool
+	Values   []interface{}
+}
+
+var magic = []byte("PAR1")
+
+// Physical types, converted types, encodings and codecs of the format.
+const (
+	typeInt32     = 1
+	typeInt64     = 2
+	typeByteArray = 6
+
+	convertedUTF8            = 0
+	convertedTimestampMillis = 9
+
+	repetitionRequired = 0
+	repetitionOptional = 1
+
+	encodingPlain = 0
+	encodingRLE   = 3
+
+	codecSnappy = 1
+
+	pageData = 0
+)
+
+// Encode is the Parquet file of the columns, which must all have the same
+// number of values.
+func Encode(columns []Column) ([]byte, error) {
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("parquet: no columns")
+	}
+	rows := len(columns[0].Values)
+
+	file := append([]byte(nil), magic...)
+	chunks := make([]columnChunk, 0, len(columns))
+	for _, col := range columns {
+		if len(col.Values) != rows {
+			return nil, fmt.Errorf("parquet: column %s has %d values, want %d", col.Name, len(col.Values), rows)
+		}
+		page, err := encodePage(col)
+		if err != nil {
+			return nil, err
+		}
+		compressed := snappy.Encode(nil, page)
+
+		var header thriftWriter
+		header.i32(1, pageData)
+		header.i32(2, int32(len(page)))
+		header.i32(3, int32(len(compressed)))
+		header.structBegin(5)
+		header.i32(1, int32(rows))
+		header.i32(2, encodingPlain)
+		header.i32(3, encodingRLE)
+		header.i32(4, encodingRLE)
+		header.structEnd()
+		header.stop()
+
+		chunks = append(chunks, columnChunk{
+			column:       col,
+			offset:       int64(len(file)),
+			uncompressed: int64(len(header.b) + len(page)),
+			compressed:   int64(len(header.b) + len(compressed)),
+		})
+		file = append(file, header.b...)
+		file = append(file, compressed...)
+	}
+
+	footer := fileMetaData(chunks, rows)
+	file = append(file, footer...)
+	file = binary.LittleEndian.AppendUint32(file, uint32(len(footer)))
+	return append(file, magic...), nil
+}
+
+type columnChunk struct {
+	column       Column
+	offset       int64
+	uncompressed int64
+	compressed   int64
+}
+
+// encodePage is a v1 data page: the definition levels of an optional
+// column, then its non-null values.
+func encodePage(col Column) ([]byte, error) {
+	var page []byte
+	if col.Optional {
+		levels := definitionLevels(col.Values)
+		page = binary.LittleEndian.AppendUint32(page, uint32(len(levels)))
+		page = append(page, levels...)
+	}
+
+	for row, v := range col.Values {
+		if v == nil {
+			if !col.Optional {
+				return nil, fmt.Errorf("parquet: null in required column %s at row %d", col.Name, row)
+			}
+			continue
+		}
+		var ok bool
+		switch col.Kind {
+		case String:
+			var s string
+			if s, ok = v.(string); ok {
+				page = binary.LittleEndian.AppendUint32(page, uint32(len(s)))
+				page = append(page, s...)
+			}
+		case Int32:
+			var n int32
+			if n, ok = v.(int32); ok {
+				page = binary.LittleEndian.AppendUint32(page, uint32(n))
+			}
+		case Int64:
+			var n int64
+			if n, ok = v.(int64); ok {
+				page = binary.LittleEndian.AppendUint64(page, uint64(n))
+			}
+		case Timestamp:
+			var t time.Time
+			if t, ok = v.(time.Time); ok {
+				page = binary.LittleEndian.AppendUint64(page, uint64(t.UnixMilli()))
+			}
+		}
+		if !ok {
+			return nil, fmt.Errorf("parquet: column %s has a %T at row %d", col.Name, v, row)
+		}
+	}
+	return page, nil
+}
+
+// definitionLevels encodes whether each value is set with the RLE/bit
+// packing hybrid at bit width 1, as RLE runs only.
+func definitionLevels(values []interface{}) []byte {
+	var b []byte
+	for i := 0; i < len(values); {
+		set := values[i] != nil
+		run := 1
+		for i+run < len(values) && (values[i+run] != nil) == set {
+			run++
+		}
+		b = binary.AppendUvarint(b, uint64(run)<<1)
+		if set {
+			b = append(b, 1)
+		} else {
+			b = append(b, 0)
+		}
+		i += run
+	}
+	return b
+}
+
+func fileMetaData(chunks []columnChunk, rows int) []byte {
+	var w thriftWriter
+	w.i32(1, 1)
+
+	w.listBegin(2, thriftStruct, len(chunks)+1)
+	w.elemBegin()
+	w.binary(4, "schema")
+	w.i32(5, int32(len(chunks)))
+	w.elemEnd()
+	for _, chunk := range chunks {
+		physical, converted := physicalType(chunk.column.Kind)
+		repetition := int32(repetitionRequired)
+		if chunk.column.Optional {
+			repetition = repetitionOptional
+		}
+		w.elemBegin()
+		w.i32(1, physical)
+		w.i32(3, repetition)
+		w.binary(4, chunk.column.Name)
+		if converted >= 0 {
+			w.i32(6, converted)
+		}
+		w.elemEnd()
+	}
+
+	w.i64(3, int64(rows))
+
+	var totalSize int64
+	for _, chunk := range chunks {
+		totalSize += chunk.uncompressed
+	}
+	w.listBegin(4, thriftStruct, 1)
+	w.elemBegin()
+	w.listBegin(1, thriftStruct, len(chunks))
+	for _, chunk := range chunks {
+		physical, _ := physicalType(chunk.column.Kind)
+		w.elemBegin()
+		w.i64(2, chunk.offset)
+		w.structBegin(3)
+		w.i32(1, physical)
+		w.listBegin(2, thriftI32, 2)
+		w.listI32(encodingPlain)
+		w.listI32(encodingRLE)
+		w.listBegin(3, thriftBinary, 1)
+		w.listBinary(chunk.column.Name)
+		w.i32(4, codecSnappy)
+		w.i64(5, int64(rows))
+		w.i64(6, chunk.uncompressed)
+		w.i64(7, chunk.compressed)
+		w.i64(9, chunk.offset)
+		w.structEnd()
+		w.elemEnd()
+	}
+	w.i64(2, totalSize)
+	w.i64(3, int64(rows))
+	w.elemEnd()
+
+	w.binary(6, "github.com/noelukwa/indexer")
+	w.stop()
+	return w.b
+}
+
+// physicalType is the physical and converted type of a kind, the latter
+// -1 when there is none.
+func physicalType(kind Kind) (int32, int32) {
+	switch kind {
+	case Int32:
+		return typeInt32, -1
+	case Int64:
+		return typeInt64, -1
+	case Timestamp:
+		return typeInt64, convertedTimestampMillis
+	default:
+		return typeByteArray, convertedUTF8
+	}
+}
@@ -0,0 +1,139 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/test-go/testify/assert"
+	"github.com/test-go/testify/require"
+)
+
+// thriftReader decodes the Thrift compact protocol into maps of field id
+// to value and slices, for checking what Encode writes.
+type thriftReader struct {
+	b []byte
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.b)
+	r.b = r.b[n:]
+	return v
+}
+
+func (r *thriftReader) varint() int64 {
+	v, n := binary.Varint(r.b)
+	r.b = r.b[n:]
+	return v
+}
+
+func (r *thriftReader) value(typ byte) interface{} {
+	switch typ {
+	case thriftI32, thriftI64:
+		return r.varint()
+	case thriftBinary:
+		n := r.uvarint()
+		s := string(r.b[:n])
+		r.b = r.b[n:]
+		return s
+	case thriftList:
+		header := r.b[0]
+		r.b = r.b[1:]
+		size := int(header >> 4)
+		if size == 15 {
+			size = int(r.uvarint())
+		}
+		list := make([]interface{}, size)
+		for i := range list {
+			list[i] = r.value(header & 0x0f)
+		}
+		return list
+	case thriftStruct:
+		return r.structure()
+	}
+	panic("unexpected thrift type")
+}
+
+func (r *thriftReader) structure() map[int16]interface{} {
+	fields := make(map[int16]interface{})
+	var last int16
+	for {
+		header := r.b[0]
+		r.b = r.b[1:]
+		if header == 0 {
+			return fields
+		}
+		id := last + int16(header>>4)
+		if header>>4 == 0 {
+			id = int16(r.varint())
+		}
+		fields[id] = r.value(header & 0x0f)
+		last = id
+	}
+}
+
+func TestEncode(t *testing.T) {
+	at := time.Date(2024, 6, 10, 9, 30, 0, 0, time.UTC)
+	columns := []Column{
+		{Name: "hash", Kind: String, Values: []interface{}{"abc", "def", "ghi"}},
+		{Name: "created_at", Kind: Timestamp, Values: []interface{}{at, at.Add(time.Second), at.Add(time.Minute)}},
+		{Name: "author_id", Kind: Int64, Values: []interface{}{int64(1), int64(-2), int64(3)}},
+		{Name: "additions", Kind: Int32, Optional: true, Values: []interface{}{int32(10), nil, int32(30)}},
+	}
+	file, err := Encode(columns)
+	require.NoError(t, err)
+
+	require.True(t, bytes.HasPrefix(file, magic))
+	require.True(t, bytes.HasSuffix(file, magic))
+	footerLen := binary.LittleEndian.Uint32(file[len(file)-8:])
+	footer := (&thriftReader{b: file[len(file)-8-int(footerLen) : len(file)-8]}).structure()
+
+	assert.Equal(t, int64(3), footer[3])
+	schema := footer[2].([]interface{})
+	require.Len(t, schema, 5)
+	assert.Equal(t, "schema", schema[0].(map[int16]interface{})[4])
+	assert.Equal(t, int64(4), schema[0].(map[int16]interface{})[5])
+	assert.Equal(t, map[int16]interface{}{1: int64(typeByteArray), 3: int64(repetitionRequired), 4: "hash", 6: int64(convertedUTF8)}, schema[1])
+	assert.Equal(t, map[int16]interface{}{1: int64(typeInt64), 3: int64(repetitionRequired), 4: "created_at", 6: int64(convertedTimestampMillis)}, schema[2])
+	assert.Equal(t, map[int16]interface{}{1: int64(typeInt32), 3: int64(repetitionOptional), 4: "additions"}, schema[4])
+
+	rowGroup := footer[4].([]interface{})[0].(map[int16]interface{})
+	assert.Equal(t, int64(3), rowGroup[3])
+	chunks := rowGroup[1].([]interface{})
+	require.Len(t, chunks, 4)
+
+	pages := make([][]byte, len(chunks))
+	for i, chunk := range chunks {
+		meta := chunk.(map[int16]interface{})[3].(map[int16]interface{})
+		assert.Equal(t, []interface{}{columns[i].Name}, meta[3])
+		assert.Equal(t, int64(codecSnappy), meta[4])
+
+		r := &thriftReader{b: file[meta[9].(int64):]}
+		header := r.structure()
+		assert.Equal(t, int64(pageData), header[1])
+		assert.Equal(t, int64(3), header[5].(map[int16]interface{})[1])
+		pages[i], err = snappy.Decode(nil, r.b[:header[3].(int64)])
+		require.NoError(t, err)
+		assert.Len(t, pages[i], int(header[2].(int64)))
+	}
+
+	assert.Equal(t, []byte("\x03\x00\x00\x00abc\x03\x00\x00\x00def\x03\x00\x00\x00ghi"), pages[0])
+	assert.Equal(t, uint64(at.UnixMilli()), binary.LittleEndian.Uint64(pages[1]))
+	assert.Equal(t, uint64(1<<64-2), binary.LittleEndian.Uint64(pages[2][8:]))
+	// One set, one null, one set: three RLE runs, then the two values.
+	assert.Equal(t, []byte{6, 0, 0, 0, 2, 1, 2, 0, 2, 1, 10, 0, 0, 0, 30, 0, 0, 0}, pages[3])
+}
+
+func TestEncode_Invalid(t *testing.T) {
+	_, err := Encode([]Column{{Name: "hash", Kind: String, Values: []interface{}{nil}}})
+	assert.Error(t, err)
+	_, err = Encode([]Column{{Name: "additions", Kind: Int32, Values: []interface{}{int64(1)}}})
+	assert.Error(t, err)
+	_, err = Encode([]Column{
+		{Name: "hash", Kind: String, Values: []interface{}{"a"}},
+		{Name: "message", Kind: String, Values: []interface{}{}},
+	})
+	assert.Error(t, err)
+}
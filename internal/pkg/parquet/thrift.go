@@ -0,0 +1,93 @@
+package parquet
+
+import "encoding/binary"
+
+// Thrift compact protocol types.
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter encodes structs in the Thrift compact protocol, which
+// Parquet uses for its metadata. Fields must be written in increasing id
+// order within each struct.
+type thriftWriter struct {
+	b []byte
+	// last is the id of the last field written in the current struct,
+	// with those of the enclosing structs on stack.
+	last  int16
+	stack []int16
+}
+
+func (w *thriftWriter) field(id int16, typ byte) {
+	if delta := id - w.last; delta > 0 && delta <= 15 {
+		w.b = append(w.b, byte(delta)<<4|typ)
+	} else {
+		w.b = append(w.b, typ)
+		w.b = binary.AppendVarint(w.b, int64(id))
+	}
+	w.last = id
+}
+
+func (w *thriftWriter) i32(id int16, v int32) {
+	w.field(id, thriftI32)
+	w.b = binary.AppendVarint(w.b, int64(v))
+}
+
+func (w *thriftWriter) i64(id int16, v int64) {
+	w.field(id, thriftI64)
+	w.b = binary.AppendVarint(w.b, v)
+}
+
+func (w *thriftWriter) binary(id int16, s string) {
+	w.field(id, thriftBinary)
+	w.listBinary(s)
+}
+
+func (w *thriftWriter) structBegin(id int16) {
+	w.field(id, thriftStruct)
+	w.elemBegin()
+}
+
+func (w *thriftWriter) structEnd() {
+	w.elemEnd()
+}
+
+// elemBegin starts a struct that is a list element.
+func (w *thriftWriter) elemBegin() {
+	w.stack = append(w.stack, w.last)
+	w.last = 0
+}
+
+func (w *thriftWriter) elemEnd() {
+	w.stop()
+	w.last = w.stack[len(w.stack)-1]
+	w.stack = w.stack[:len(w.stack)-1]
+}
+
+func (w *thriftWriter) listBegin(id int16, elem byte, size int) {
+	w.field(id, thriftList)
+	if size < 15 {
+		w.b = append(w.b, byte(size)<<4|elem)
+	} else {
+		w.b = append(w.b, 0xf0|elem)
+		w.b = binary.AppendUvarint(w.b, uint64(size))
+	}
+}
+
+func (w *thriftWriter) listI32(v int32) {
+	w.b = binary.AppendVarint(w.b, int64(v))
+}
+
+func (w *thriftWriter) listBinary(s string) {
+	w.b = binary.AppendUvarint(w.b, uint64(len(s)))
+	w.b = append(w.b, s...)
+}
+
+// stop ends the current struct.
+func (w *thriftWriter) stop() {
+	w.b = append(w.b, 0)
+}
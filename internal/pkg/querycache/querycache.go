@@ -0,0 +1,86 @@
+// Package querycache keeps the results of expensive manager queries in
+// Redis. Entries are keyed by a generation per repository and a global one,
+// so invalidating is a single INCR and the old entries expire on their own.
+package querycache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	keyPrefix = "querycache:"
+	globalGen = keyPrefix + "gen"
+)
+
+type Cache struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// New returns a cache whose entries live for ttl.
+func New(client *redis.Client, ttl time.Duration) *Cache {
+	return &Cache{client: client, ttl: ttl}
+}
+
+// Key returns the key of query on repo at the current generations. Take it
+// before running the query, so a result that raced an invalidation is
+// stored under the old generation and never read.
+func (c *Cache) Key(ctx context.Context, repo, query string) (string, error) {
+	gens, err := c.client.MGet(ctx, globalGen, repoGen(repo)).Result()
+	if err != nil {
+		return "", err
+	}
+	for i, gen := range gens {
+		if gen == nil {
+			gens[i] = "0"
+		}
+	}
+	return fmt.Sprintf("%s%s:%v:%v:%s", keyPrefix, repo, gens[0], gens[1], query), nil
+}
+
+// Get decodes the entry at key into dst and reports whether there was one.
+func (c *Cache) Get(ctx context.Context, key string, dst interface{}) (bool, error) {
+	data, err := c.client.Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(data, dst); err != nil {
+		return false, fmt.Errorf("failed to decode %s: %w", key, err)
+	}
+	return true, nil
+}
+
+func (c *Cache) Set(ctx context.Context, key string, v interface{}) error {
+	return c.SetFor(ctx, key, v, c.ttl)
+}
+
+// SetFor stores v at key for ttl rather than the cache's own.
+func (c *Cache) SetFor(ctx context.Context, key string, v interface{}, ttl time.Duration) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.client.Set(ctx, key, data, ttl).Err()
+}
+
+// Invalidate drops the entries of repo.
+func (c *Cache) Invalidate(ctx context.Context, repo string) error {
+	return c.client.Incr(ctx, repoGen(repo)).Err()
+}
+
+// InvalidateAll drops every entry.
+func (c *Cache) InvalidateAll(ctx context.Context) error {
+	return c.client.Incr(ctx, globalGen).Err()
+}
+
+func repoGen(repo string) string {
+	return globalGen + ":" + repo
+}
@@ -0,0 +1,102 @@
+// Package ratelimits shares GitHub quota samples between the monitor, which
+// reports them, and the manager, which serves them to operators.
+package ratelimits
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	keyPrefix = "ratelimit:"
+	// statusTTL drops tokens the monitor has stopped using.
+	statusTTL = 15 * time.Minute
+)
+
+// Quota is one GitHub rate limit bucket. ProjectedExhaustion is when the
+// remaining requests run out at the current pace, nil when they outlast
+// the reset.
+type Quota struct {
+	Limit               int        `json:"limit"`
+	Remaining           int        `json:"remaining"`
+	Reset               time.Time  `json:"reset"`
+	ProjectedExhaustion *time.Time `json:"projected_exhaustion"`
+}
+
+// Status is the last sample for a token. Token is a label such as
+// "default", "default:2" for a monitor's second pooled token, or
+// "credential:<id>", never the token itself.
+type Status struct {
+	Token     string    `json:"token"`
+	Core      Quota     `json:"core"`
+	Search    Quota     `json:"search"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Project fills in cur.ProjectedExhaustion from the pace since prev, a
+// sample of the same bucket taken at prevAt.
+func Project(prev Quota, prevAt time.Time, cur Quota, now time.Time) Quota {
+	cur.ProjectedExhaustion = nil
+	elapsed := now.Sub(prevAt)
+	used := prev.Remaining - cur.Remaining
+	if !prev.Reset.Equal(cur.Reset) || used <= 0 || elapsed <= 0 {
+		return cur
+	}
+
+	at := now.Add(time.Duration(float64(cur.Remaining) / float64(used) * float64(elapsed)))
+	if at.Before(cur.Reset) {
+		cur.ProjectedExhaustion = &at
+	}
+	return cur
+}
+
+type Store struct {
+	client *redis.Client
+}
+
+func NewStore(client *redis.Client) *Store {
+	return &Store{client: client}
+}
+
+func (s *Store) Save(ctx context.Context, status Status) error {
+	data, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, keyPrefix+status.Token, data, statusTTL).Err()
+}
+
+// List returns the latest status of every token, ordered by label.
+func (s *Store) List(ctx context.Context) ([]Status, error) {
+	keys, err := s.client.Keys(ctx, keyPrefix+"*").Result()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(keys))
+	for _, key := range keys {
+		data, err := s.client.Get(ctx, key).Result()
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var status Status
+		if err := json.Unmarshal([]byte(data), &status); err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", key, err)
+		}
+		statuses = append(statuses, status)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Token < statuses[j].Token
+	})
+	return statuses, nil
+}
@@ -0,0 +1,103 @@
+package remotewrite
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/test-go/testify/assert"
+	"github.com/test-go/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// fields splits a protobuf message into its fields by number, keeping
+// length-delimited and fixed64 values as bytes and varints as numbers.
+func fields(t *testing.T, b []byte) map[protowire.Number][]interface{} {
+	out := make(map[protowire.Number][]interface{})
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		require.True(t, n > 0)
+		b = b[n:]
+		switch typ {
+		case protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			require.True(t, n > 0)
+			out[num] = append(out[num], v)
+			b = b[n:]
+		case protowire.Fixed64Type:
+			v, n := protowire.ConsumeFixed64(b)
+			require.True(t, n > 0)
+			out[num] = append(out[num], math.Float64frombits(v))
+			b = b[n:]
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			require.True(t, n > 0)
+			out[num] = append(out[num], int64(v))
+			b = b[n:]
+		default:
+			t.Fatalf("unexpected wire type %d", typ)
+		}
+	}
+	return out
+}
+
+func TestEncode(t *testing.T) {
+	at := time.UnixMilli(1718010000123)
+	req := fields(t, encode([]Series{{
+		Labels:  []Label{{Name: "repository", Value: "owner/repo"}, {Name: "__name__", Value: "commits_total"}},
+		Samples: []Sample{{Value: 42, Timestamp: at}},
+	}}))
+	require.Len(t, req[1], 1)
+
+	series := fields(t, req[1][0].([]byte))
+	require.Len(t, series[1], 2)
+	first := fields(t, series[1][0].([]byte))
+	assert.Equal(t, "__name__", string(first[1][0].([]byte)))
+	assert.Equal(t, "commits_total", string(first[2][0].([]byte)))
+	second := fields(t, series[1][1].([]byte))
+	assert.Equal(t, "repository", string(second[1][0].([]byte)))
+
+	require.Len(t, series[2], 1)
+	sample := fields(t, series[2][0].([]byte))
+	assert.Equal(t, float64(42), sample[1][0])
+	assert.Equal(t, int64(1718010000123), sample[2][0])
+}
+
+func TestWrite(t *testing.T) {
+	var got []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "snappy", r.Header.Get("Content-Encoding"))
+		assert.Equal(t, "application/x-protobuf", r.Header.Get("Content-Type"))
+		user, pass, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "tenant", user)
+		assert.Equal(t, "secret", pass)
+
+		body, _ := io.ReadAll(r.Body)
+		var err error
+		got, err = snappy.Decode(nil, body)
+		assert.NoError(t, err)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	series := []Series{{Labels: []Label{{Name: "__name__", Value: "up"}}, Samples: []Sample{{Value: 1, Timestamp: time.Now()}}}}
+	require.NoError(t, New(srv.URL, "tenant", "secret").Write(context.Background(), series))
+	assert.Equal(t, encode(series), got)
+}
+
+func TestWrite_Rejected(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "out of order sample", http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	err := New(srv.URL, "", "").Write(context.Background(), nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "out of order sample")
+}
@@ -0,0 +1,88 @@
+package repolocks
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps the locks of a single monitor in process, for
+// deployments without Redis. Its locks are invisible to other monitors and
+// to the manager, and die with the process, so it only suits one monitor.
+type MemoryStore struct {
+	mu    sync.Mutex
+	locks map[string]memoryLock
+}
+
+type memoryLock struct {
+	value     string
+	expiresAt time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{locks: make(map[string]memoryLock)}
+}
+
+// memoryHeld is a lock taken from a MemoryStore.
+type memoryHeld struct {
+	store      *MemoryStore
+	key, value string
+}
+
+// Acquire takes the lock at key for holder, or returns nil if it is held.
+// A lock that was not refreshed for TTL is free again.
+func (s *MemoryStore) Acquire(ctx context.Context, key string, holder Holder) (Lease, error) {
+	data, err := json.Marshal(holder)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if lock, ok := s.locks[key]; ok && time.Now().Before(lock.expiresAt) {
+		return nil, nil
+	}
+	s.locks[key] = memoryLock{value: string(data), expiresAt: time.Now().Add(TTL)}
+	return &memoryHeld{store: s, key: key, value: string(data)}, nil
+}
+
+// Heartbeat does nothing, as a monitor's locks can't outlive it.
+func (s *MemoryStore) Heartbeat(ctx context.Context, owner string) error {
+	return nil
+}
+
+// Reap drops the expired locks. Their holder is the only monitor and
+// still alive, so none of them is stale.
+func (s *MemoryStore) Reap(ctx context.Context) ([]Lock, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for key, lock := range s.locks {
+		if !now.Before(lock.expiresAt) {
+			delete(s.locks, key)
+		}
+	}
+	return nil, nil
+}
+
+func (h *memoryHeld) Refresh(ctx context.Context) (bool, error) {
+	h.store.mu.Lock()
+	defer h.store.mu.Unlock()
+	lock, ok := h.store.locks[h.key]
+	if !ok || lock.value != h.value {
+		return false, nil
+	}
+	lock.expiresAt = time.Now().Add(TTL)
+	h.store.locks[h.key] = lock
+	return true, nil
+}
+
+func (h *memoryHeld) Release(ctx context.Context) error {
+	h.store.mu.Lock()
+	defer h.store.mu.Unlock()
+	if lock, ok := h.store.locks[h.key]; ok && lock.value == h.value {
+		delete(h.store.locks, h.key)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package repolocks_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/noelukwa/indexer/internal/pkg/repolocks"
+	"github.com/test-go/testify/assert"
+	"github.com/test-go/testify/require"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := repolocks.NewMemoryStore()
+	key := repolocks.Key("owner", "repo")
+
+	held, err := store.Acquire(ctx, key, repolocks.Holder{Repository: "owner/repo", AcquiredAt: time.Now()})
+	require.NoError(t, err)
+	require.NotNil(t, held)
+
+	// The lock is taken until it is released.
+	again, err := store.Acquire(ctx, key, repolocks.Holder{Repository: "owner/repo", AcquiredAt: time.Now()})
+	require.NoError(t, err)
+	assert.Nil(t, again)
+
+	ok, err := held.Refresh(ctx)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	require.NoError(t, held.Release(ctx))
+	ok, err = held.Refresh(ctx)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	again, err = store.Acquire(ctx, key, repolocks.Holder{Repository: "owner/repo", AcquiredAt: time.Now()})
+	require.NoError(t, err)
+	assert.NotNil(t, again)
+
+	// Releasing a lock taken since leaves it alone.
+	require.NoError(t, held.Release(ctx))
+	ok, err = again.Refresh(ctx)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
@@ -0,0 +1,276 @@
+// Package repolocks holds the per-repository locks that keep two monitors
+// from fetching the same repository at once. Monitors take and reap them,
+// and the manager lists them to operators.
+package repolocks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	keyPrefix   = "lock:"
+	ownerPrefix = "lockowner:"
+	reapedKey   = "lockstats:reaped"
+
+	// TTL bounds how long a lock outlives a monitor that stopped
+	// refreshing it. Refresh more often than this.
+	TTL = 10 * time.Minute
+	// OwnerTTL is how long a monitor counts as alive after its last
+	// heartbeat. Beat more often than this.
+	OwnerTTL = 30 * time.Second
+)
+
+// Reasons a lock is stale.
+const (
+	// OwnerDead is a lock whose monitor has stopped its heartbeat.
+	OwnerDead = "owner_dead"
+	// NoTTL is a lock that would never expire.
+	NoTTL = "no_ttl"
+	// TTLTooLong is a lock set to outlive TTL.
+	TTLTooLong = "ttl_too_long"
+)
+
+var (
+	releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+	refreshScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+)
+
+// Holder is what a lock records about who took it.
+type Holder struct {
+	Repository string    `json:"repository"`
+	Owner      string    `json:"owner"`
+	IntentID   string    `json:"intent_id,omitempty"`
+	AcquiredAt time.Time `json:"acquired_at"`
+}
+
+// Lock is a held lock as seen by List. Holder fields are empty for a lock
+// taken by a monitor that predates them, and ExpiresAt for one without a
+// TTL. Stale names why the reaper would clear it, empty while it is
+// healthy.
+type Lock struct {
+	Holder
+	ExpiresAt  *time.Time `json:"expires_at"`
+	OwnerAlive bool       `json:"owner_alive"`
+	Stale      string     `json:"stale,omitempty"`
+
+	key, value string
+}
+
+// Report lists the held locks and counts the locks reaped so far by
+// reason.
+type Report struct {
+	Locks  []Lock           `json:"locks"`
+	Reaped map[string]int64 `json:"reaped"`
+}
+
+// Locker takes repository locks for a monitor. Store shares them between
+// monitors through Redis, MemoryStore keeps them in a single monitor.
+type Locker interface {
+	// Acquire takes the lock at key for holder, or returns nil if it is
+	// held.
+	Acquire(ctx context.Context, key string, holder Holder) (Lease, error)
+	// Heartbeat marks owner alive for OwnerTTL.
+	Heartbeat(ctx context.Context, owner string) error
+	// Reap clears the stale locks and returns them.
+	Reap(ctx context.Context) ([]Lock, error)
+}
+
+// Lease is a lock taken by this process.
+type Lease interface {
+	// Refresh extends the lock to the full TTL and reports whether it was
+	// still held.
+	Refresh(ctx context.Context) (bool, error)
+	// Release lets the lock go, unless it has been reaped and taken since.
+	Release(ctx context.Context) error
+}
+
+type Store struct {
+	client *redis.Client
+}
+
+func NewStore(client *redis.Client) *Store {
+	return &Store{client: client}
+}
+
+// Key is the lock of the repository owner/name.
+func Key(owner, name string) string {
+	return keyPrefix + owner + "." + name
+}
+
+// Held is a lock taken by this process.
+type Held struct {
+	store      *Store
+	key, value string
+}
+
+// Acquire takes the lock at key for holder, or returns nil if it is held.
+func (s *Store) Acquire(ctx context.Context, key string, holder Holder) (Lease, error) {
+	data, err := json.Marshal(holder)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := s.client.SetNX(ctx, key, data, TTL).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire lock: %w", err)
+	}
+	if !ok {
+		return nil, nil
+	}
+	return &Held{store: s, key: key, value: string(data)}, nil
+}
+
+// Refresh extends the lock to the full TTL and reports whether it was
+// still held.
+func (h *Held) Refresh(ctx context.Context) (bool, error) {
+	n, err := refreshScript.Run(ctx, h.store.client, []string{h.key}, h.value, TTL.Milliseconds()).Int()
+	return n == 1, err
+}
+
+// Release lets the lock go, unless it has been reaped and taken since.
+func (h *Held) Release(ctx context.Context) error {
+	return releaseScript.Run(ctx, h.store.client, []string{h.key}, h.value).Err()
+}
+
+// Heartbeat marks owner alive for OwnerTTL.
+func (s *Store) Heartbeat(ctx context.Context, owner string) error {
+	return s.client.Set(ctx, ownerPrefix+owner, time.Now().Unix(), OwnerTTL).Err()
+}
+
+// List returns the held locks, ordered by repository.
+func (s *Store) List(ctx context.Context) ([]Lock, error) {
+	var keys []string
+	iter := s.client.Scan(ctx, 0, keyPrefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+
+	locks := make([]Lock, 0, len(keys))
+	for _, key := range keys {
+		lock, err := s.inspect(ctx, key)
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect %s: %w", key, err)
+		}
+		locks = append(locks, lock)
+	}
+
+	sort.Slice(locks, func(i, j int) bool {
+		return locks[i].Repository < locks[j].Repository
+	})
+	return locks, nil
+}
+
+func (s *Store) inspect(ctx context.Context, key string) (Lock, error) {
+	lock := Lock{key: key}
+	value, err := s.client.Get(ctx, key).Result()
+	if err != nil {
+		return lock, err
+	}
+	lock.value = value
+	ttl, err := s.client.PTTL(ctx, key).Result()
+	if err != nil {
+		return lock, err
+	}
+	// PTTL answers -2 for a key that expired since the GET, -1 for one
+	// without a TTL.
+	if ttl == -2 {
+		return lock, redis.Nil
+	}
+	if ttl > 0 {
+		expiresAt := time.Now().Add(ttl)
+		lock.ExpiresAt = &expiresAt
+	}
+
+	// Locks of older monitors hold a placeholder, not a Holder.
+	if json.Unmarshal([]byte(value), &lock.Holder) != nil {
+		lock.Holder = Holder{}
+	}
+	if lock.Repository == "" {
+		lock.Repository = strings.TrimPrefix(key, keyPrefix)
+	}
+	if lock.Owner != "" {
+		n, err := s.client.Exists(ctx, ownerPrefix+lock.Owner).Result()
+		if err != nil {
+			return lock, err
+		}
+		lock.OwnerAlive = n == 1
+	}
+
+	switch {
+	case ttl == -1:
+		lock.Stale = NoTTL
+	case ttl > TTL:
+		lock.Stale = TTLTooLong
+	// Give a new lock's owner a heartbeat's time to show up.
+	case lock.Owner != "" && !lock.OwnerAlive && time.Since(lock.AcquiredAt) > OwnerTTL:
+		lock.Stale = OwnerDead
+	}
+	return lock, nil
+}
+
+// Reap clears the stale locks and returns them. A lock that changed since
+// it was inspected is left alone.
+func (s *Store) Reap(ctx context.Context) ([]Lock, error) {
+	locks, err := s.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var reaped []Lock
+	for _, lock := range locks {
+		if lock.Stale == "" {
+			continue
+		}
+		n, err := releaseScript.Run(ctx, s.client, []string{lock.key}, lock.value).Int()
+		if err != nil {
+			return reaped, fmt.Errorf("failed to reap %s: %w", lock.key, err)
+		}
+		if n == 0 {
+			continue
+		}
+		if err := s.client.HIncrBy(ctx, reapedKey, lock.Stale, 1).Err(); err != nil {
+			return reaped, err
+		}
+		reaped = append(reaped, lock)
+	}
+	return reaped, nil
+}
+
+// Report returns the held locks and the reaped counts.
+func (s *Store) Report(ctx context.Context) (*Report, error) {
+	locks, err := s.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := s.client.HGetAll(ctx, reapedKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Locks: locks, Reaped: make(map[string]int64, len(counts))}
+	for reason, count := range counts {
+		report.Reaped[reason], _ = strconv.ParseInt(count, 10, 64)
+	}
+	return report, nil
+}
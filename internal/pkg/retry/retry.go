@@ -0,0 +1,88 @@
+// Package retry runs operations that may fail transiently, such as GitHub
+// requests and database writes, under a bounded exponential backoff.
+package retry
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+// Policy bounds the attempts at an operation. The n-th retry waits
+// BackoffBase * 2^(n-1), spread by up to Jitter (a fraction between 0 and
+// 1) either way so that failing callers don't retry in lockstep, and
+// capped at MaxDelay when it is set. A MaxAttempts below 1 still makes one
+// attempt.
+type Policy struct {
+	MaxAttempts int
+	BackoffBase time.Duration
+	Jitter      float64
+	MaxDelay    time.Duration
+}
+
+// Delay is how long to wait before the given retry, counting from 1.
+func (p Policy) Delay(retry int) time.Duration {
+	if retry < 1 || p.BackoffBase <= 0 {
+		return 0
+	}
+	delay := p.BackoffBase << min(retry-1, 16)
+	if p.Jitter > 0 {
+		spread := min(p.Jitter, 1) * (2*rand.Float64() - 1)
+		delay += time.Duration(float64(delay) * spread)
+	}
+	if p.MaxDelay > 0 {
+		delay = min(delay, p.MaxDelay)
+	}
+	return delay
+}
+
+// Wait blocks for the delay before the given retry, or until ctx is done.
+func (p Policy) Wait(ctx context.Context, retry int) error {
+	timer := time.NewTimer(p.Delay(retry))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type stopError struct{ err error }
+
+func (e stopError) Error() string { return e.err.Error() }
+func (e stopError) Unwrap() error { return e.err }
+
+// Stop marks err as permanent, so Do returns it without retrying.
+func Stop(err error) error {
+	if err == nil {
+		return nil
+	}
+	return stopError{err}
+}
+
+// Do calls op until it succeeds, returns a Stop error, or the policy's
+// attempts run out, and returns op's last error. onRetry, when not nil, is
+// told about each failure that will be retried.
+func Do(ctx context.Context, p Policy, op func() error, onRetry func(attempt int, err error)) error {
+	for attempt := 1; ; attempt++ {
+		err := op()
+		if err == nil {
+			return nil
+		}
+		var stop stopError
+		if errors.As(err, &stop) {
+			return stop.err
+		}
+		if attempt >= p.MaxAttempts {
+			return err
+		}
+		if onRetry != nil {
+			onRetry(attempt, err)
+		}
+		if p.Wait(ctx, attempt) != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package retry_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/noelukwa/indexer/internal/pkg/retry"
+	"github.com/test-go/testify/assert"
+)
+
+func TestPolicyDelay(t *testing.T) {
+	p := retry.Policy{BackoffBase: 100 * time.Millisecond}
+
+	assert.Equal(t, time.Duration(0), p.Delay(0))
+	assert.Equal(t, 100*time.Millisecond, p.Delay(1))
+	assert.Equal(t, 200*time.Millisecond, p.Delay(2))
+	assert.Equal(t, 800*time.Millisecond, p.Delay(4))
+	// The exponent stops growing, so late retries don't overflow.
+	assert.Equal(t, p.Delay(17), p.Delay(100))
+	assert.True(t, p.Delay(100) > 0)
+}
+
+func TestPolicyDelay_MaxDelay(t *testing.T) {
+	p := retry.Policy{BackoffBase: time.Second, Jitter: 0.5, MaxDelay: 5 * time.Second}
+
+	assert.Equal(t, 5*time.Second, p.Delay(10))
+	for i := 0; i < 100; i++ {
+		assert.True(t, p.Delay(3) <= 5*time.Second)
+	}
+}
+
+func TestPolicyDelay_Jitter(t *testing.T) {
+	p := retry.Policy{BackoffBase: time.Second, Jitter: 0.25}
+
+	for i := 0; i < 100; i++ {
+		delay := p.Delay(2)
+		assert.True(t, delay >= 1500*time.Millisecond && delay <= 2500*time.Millisecond, "delay %s", delay)
+	}
+}
+
+func TestDo(t *testing.T) {
+	ctx := context.Background()
+	p := retry.Policy{MaxAttempts: 3}
+
+	calls, retried := 0, 0
+	err := retry.Do(ctx, p, func() error {
+		calls++
+		if calls < 3 {
+			return errors.New("transient")
+		}
+		return nil
+	}, func(attempt int, err error) { retried++ })
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, 2, retried)
+}
+
+func TestDo_AttemptsRunOut(t *testing.T) {
+	failure := errors.New("transient")
+	calls := 0
+	err := retry.Do(context.Background(), retry.Policy{MaxAttempts: 2}, func() error {
+		calls++
+		return failure
+	}, nil)
+	assert.Equal(t, failure, err)
+	assert.Equal(t, 2, calls)
+}
+
+func TestDo_Stop(t *testing.T) {
+	permanent := errors.New("permanent")
+	calls := 0
+	err := retry.Do(context.Background(), retry.Policy{MaxAttempts: 5}, func() error {
+		calls++
+		return retry.Stop(permanent)
+	}, nil)
+	assert.Equal(t, permanent, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestDo_ContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	calls := 0
+	err := retry.Do(ctx, retry.Policy{MaxAttempts: 5, BackoffBase: time.Hour}, func() error {
+		calls++
+		return errors.New("transient")
+	}, nil)
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+}
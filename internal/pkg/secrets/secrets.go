@@ -0,0 +1,67 @@
+// Package secrets seals values such as GitHub tokens with a key shared by
+// the manager, which stores them, and the monitor, which uses them.
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrNoKey      = errors.New("no secrets key configured")
+	ErrCiphertext = errors.New("malformed ciphertext")
+)
+
+// Box encrypts with AES-256-GCM. Sealed values carry their nonce as a
+// prefix.
+type Box struct {
+	aead cipher.AEAD
+}
+
+// NewBox builds a Box from a base64 encoded 32 byte key.
+func NewBox(key string) (*Box, error) {
+	if key == "" {
+		return nil, ErrNoKey
+	}
+	raw, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode secrets key: %w", err)
+	}
+	if len(raw) != 32 {
+		return nil, fmt.Errorf("secrets key must be 32 bytes, got %d", len(raw))
+	}
+
+	block, err := aes.NewCipher(raw)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Box{aead: aead}, nil
+}
+
+func (b *Box) Seal(plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return b.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func (b *Box) Open(ciphertext []byte) ([]byte, error) {
+	size := b.aead.NonceSize()
+	if len(ciphertext) < size {
+		return nil, ErrCiphertext
+	}
+	plaintext, err := b.aead.Open(nil, ciphertext[:size], ciphertext[size:], nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open secret: %w", err)
+	}
+	return plaintext, nil
+}
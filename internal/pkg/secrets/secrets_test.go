@@ -0,0 +1,78 @@
+package secrets_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/noelukwa/indexer/internal/pkg/secrets"
+	"github.com/test-go/testify/assert"
+	"github.com/test-go/testify/require"
+)
+
+// testKey is a base64 encoded 32 byte key.
+const testKey = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+
+func TestBox_RoundTrip(t *testing.T) {
+	box, err := secrets.NewBox(testKey)
+	require.NoError(t, err)
+
+	for _, plaintext := range []string{"ghp_token", "", "a longer secret with spaces and ünïcode"} {
+		sealed, err := box.Seal([]byte(plaintext))
+		require.NoError(t, err)
+		if plaintext != "" {
+			assert.NotContains(t, string(sealed), plaintext)
+		}
+
+		opened, err := box.Open(sealed)
+		require.NoError(t, err)
+		assert.Equal(t, plaintext, string(opened))
+	}
+}
+
+func TestBox_SealUsesFreshNonces(t *testing.T) {
+	box, err := secrets.NewBox(testKey)
+	require.NoError(t, err)
+
+	first, err := box.Seal([]byte("ghp_token"))
+	require.NoError(t, err)
+	second, err := box.Seal([]byte("ghp_token"))
+	require.NoError(t, err)
+	assert.NotEqual(t, first, second)
+}
+
+func TestBox_OpenRejectsTampering(t *testing.T) {
+	box, err := secrets.NewBox(testKey)
+	require.NoError(t, err)
+
+	sealed, err := box.Seal([]byte("ghp_token"))
+	require.NoError(t, err)
+	sealed[len(sealed)-1] ^= 1
+	_, err = box.Open(sealed)
+	assert.Error(t, err)
+
+	_, err = box.Open([]byte("short"))
+	assert.True(t, errors.Is(err, secrets.ErrCiphertext))
+}
+
+func TestBox_OpenWithOtherKey(t *testing.T) {
+	box, err := secrets.NewBox(testKey)
+	require.NoError(t, err)
+	other, err := secrets.NewBox("ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA=")
+	require.NoError(t, err)
+
+	sealed, err := box.Seal([]byte("ghp_token"))
+	require.NoError(t, err)
+	_, err = other.Open(sealed)
+	assert.Error(t, err)
+}
+
+func TestNewBox_InvalidKeys(t *testing.T) {
+	_, err := secrets.NewBox("")
+	assert.Equal(t, secrets.ErrNoKey, err)
+
+	_, err = secrets.NewBox("not base64!")
+	assert.Error(t, err)
+
+	_, err = secrets.NewBox("c2hvcnQ=")
+	assert.Error(t, err)
+}
@@ -0,0 +1,187 @@
+// Package testenv provides the Postgres and Redis servers that store tests
+// run against. It is opt-in: tests use the servers named in
+// INDEXER_TEST_DATABASE_URL and INDEXER_TEST_REDIS_ADDR, or, with
+// INDEXER_TESTCONTAINERS=1, ephemeral containers started on first use, and
+// are skipped otherwise. Each test gets its own Postgres schema or Redis
+// database, so tests can run in parallel.
+package testenv
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+const (
+	DatabaseURLEnv = "INDEXER_TEST_DATABASE_URL"
+	RedisAddrEnv   = "INDEXER_TEST_REDIS_ADDR"
+	ContainersEnv  = "INDEXER_TESTCONTAINERS"
+
+	postgresImage = "postgres:16-alpine"
+	redisImage    = "redis:7-alpine"
+	startTimeout  = 2 * time.Minute
+)
+
+// server is a Postgres or Redis server shared by the tests of a package,
+// set up on first use.
+type server struct {
+	once      sync.Once
+	addr      string
+	container testcontainers.Container
+	err       error
+}
+
+var (
+	pg      server
+	pgAdmin *pgxpool.Pool
+
+	rdb      server
+	redisDBs chan int
+)
+
+// Postgres returns the URL of a fresh, empty schema for the test, dropped
+// when the test ends. Migrations are left to the store under test.
+func Postgres(t testing.TB) string {
+	t.Helper()
+	pg.once.Do(func() {
+		pg.addr, pg.container, pg.err = start(DatabaseURLEnv, testcontainers.ContainerRequest{
+			Image:        postgresImage,
+			ExposedPorts: []string{"5432/tcp"},
+			Env: map[string]string{
+				"POSTGRES_USER":     "indexer",
+				"POSTGRES_PASSWORD": "indexer",
+				"POSTGRES_DB":       "manager-tests",
+			},
+			// Postgres restarts once after initdb.
+			WaitingFor: wait.ForLog("database system is ready to accept connections").WithOccurrence(2),
+		}, "5432/tcp", "postgres://indexer:indexer@%s/manager-tests?sslmode=disable")
+		if pg.err != nil || pg.addr == "" {
+			return
+		}
+		pgAdmin, pg.err = pgxpool.New(context.Background(), pg.addr)
+		if pg.err != nil {
+			return
+		}
+		// Extensions are per database, so install them where every
+		// schema's search path finds them.
+		_, pg.err = pgAdmin.Exec(context.Background(), "CREATE EXTENSION IF NOT EXISTS pg_trgm SCHEMA public")
+	})
+	ready(t, &pg)
+
+	schema := "test_" + randomHex(8)
+	if _, err := pgAdmin.Exec(context.Background(), "CREATE SCHEMA "+schema); err != nil {
+		t.Fatalf("testenv: failed to create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := pgAdmin.Exec(context.Background(), "DROP SCHEMA "+schema+" CASCADE"); err != nil {
+			t.Errorf("testenv: failed to drop schema %s: %v", schema, err)
+		}
+	})
+
+	u, err := url.Parse(pg.addr)
+	if err != nil {
+		t.Fatalf("testenv: %s must be a postgres:// URL: %v", DatabaseURLEnv, err)
+	}
+	q := u.Query()
+	q.Set("search_path", schema+",public")
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// Redis returns options for an empty Redis database for the test. There
+// are 16 databases, so at most 16 tests hold one at a time; the rest wait.
+func Redis(t testing.TB) *redis.Options {
+	t.Helper()
+	rdb.once.Do(func() {
+		rdb.addr, rdb.container, rdb.err = start(RedisAddrEnv, testcontainers.ContainerRequest{
+			Image:        redisImage,
+			ExposedPorts: []string{"6379/tcp"},
+			WaitingFor:   wait.ForLog("Ready to accept connections"),
+		}, "6379/tcp", "%s")
+		redisDBs = make(chan int, 16)
+		for db := 0; db < cap(redisDBs); db++ {
+			redisDBs <- db
+		}
+	})
+	ready(t, &rdb)
+
+	opts := &redis.Options{Addr: rdb.addr, DB: <-redisDBs}
+	client := redis.NewClient(opts)
+	defer client.Close()
+	if err := client.FlushDB(context.Background()).Err(); err != nil {
+		redisDBs <- opts.DB
+		t.Fatalf("testenv: failed to flush redis database %d: %v", opts.DB, err)
+	}
+	t.Cleanup(func() { redisDBs <- opts.DB })
+	return opts
+}
+
+// Terminate stops the containers started by the package's tests. Call it
+// from TestMain after m.Run.
+func Terminate() {
+	if pgAdmin != nil {
+		pgAdmin.Close()
+	}
+	for _, s := range []*server{&pg, &rdb} {
+		if s.container != nil {
+			s.container.Terminate(context.Background())
+		}
+	}
+}
+
+// start returns the address in env, or starts a container when containers
+// are enabled and formats its host:port into addrFormat.
+func start(env string, req testcontainers.ContainerRequest, port, addrFormat string) (string, testcontainers.Container, error) {
+	if addr := os.Getenv(env); addr != "" {
+		return addr, nil, nil
+	}
+	if os.Getenv(ContainersEnv) != "1" {
+		return "", nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), startTimeout)
+	defer cancel()
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to start %s: %w", req.Image, err)
+	}
+	endpoint, err := container.PortEndpoint(ctx, nat.Port(port), "")
+	if err != nil {
+		container.Terminate(context.Background())
+		return "", nil, fmt.Errorf("failed to find %s port: %w", req.Image, err)
+	}
+	return fmt.Sprintf(addrFormat, endpoint), container, nil
+}
+
+// ready skips the test when no server is configured, and fails it when
+// the server couldn't be set up.
+func ready(t testing.TB, s *server) {
+	t.Helper()
+	if s.err != nil {
+		t.Fatalf("testenv: %v", s.err)
+	}
+	if s.addr == "" {
+		t.Skipf("testenv: set %s=1 or %s and %s to run", ContainersEnv, DatabaseURLEnv, RedisAddrEnv)
+	}
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,171 @@
+// Package client is a Go client for the manager's REST API.
+//
+//	c := client.New("http://localhost:8009", os.Getenv("INDEXER_TOKEN"))
+//	intent, err := c.CreateIntent(ctx, client.CreateIntentRequest{Repository: "owner/repo"})
+//
+// Requests that fail with a rate limit or an unavailable server, and reads
+// that fail on the network, are retried under Client.Retry. Listings can be
+// walked page by page with an Iterator.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/noelukwa/indexer/internal/pkg/retry"
+)
+
+// apiKeyPrefix starts the API keys the manager generates.
+const apiKeyPrefix = "idx_"
+
+// RetryPolicy bounds the attempts at a request, see Client.Retry.
+type RetryPolicy = retry.Policy
+
+// Client calls the manager API. Its fields may be changed before the first
+// request.
+type Client struct {
+	// BaseURL is the manager's address, such as http://localhost:8009.
+	BaseURL string
+	// Token authenticates requests, when set: an API key in the X-API-Key
+	// header, or else a session token as a bearer token.
+	Token      string
+	HTTPClient *http.Client
+	Retry      RetryPolicy
+}
+
+// New returns a client of the manager at baseURL.
+func New(baseURL, token string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		Token:      token,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+		Retry: RetryPolicy{
+			MaxAttempts: 3,
+			BackoffBase: 250 * time.Millisecond,
+			Jitter:      0.2,
+		},
+	}
+}
+
+// Error is an error response of the API.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("manager API: %d %s", e.StatusCode, e.Message)
+}
+
+// IsNotFound reports whether err is a 404 response.
+func IsNotFound(err error) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// retryable reports whether a response status means the request wasn't
+// handled and can be sent again.
+func retryable(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+func (c *Client) get(ctx context.Context, path string, query url.Values, out interface{}) error {
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	return c.do(ctx, http.MethodGet, path, nil, out)
+}
+
+func (c *Client) send(ctx context.Context, method, path string, in, out interface{}) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+	return c.do(ctx, method, path, body, out)
+}
+
+// do sends the request, retrying under c.Retry, and decodes a 2xx response
+// into out unless it is nil.
+func (c *Client) do(ctx context.Context, method, path string, body []byte, out interface{}) error {
+	// Only reads are safe to repeat when a request may have reached the
+	// server before failing.
+	idempotent := method == http.MethodGet || method == http.MethodPut
+
+	var resp *http.Response
+	err := retry.Do(ctx, c.Retry, func() error {
+		req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, bytes.NewReader(body))
+		if err != nil {
+			return retry.Stop(err)
+		}
+		req.Header.Set("Accept", "application/json")
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if strings.HasPrefix(c.Token, apiKeyPrefix) {
+			req.Header.Set("X-API-Key", c.Token)
+		} else if c.Token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.Token)
+		}
+
+		resp, err = c.HTTPClient.Do(req)
+		if err != nil {
+			if !idempotent || ctx.Err() != nil {
+				return retry.Stop(err)
+			}
+			return err
+		}
+		if resp.StatusCode < 300 {
+			return nil
+		}
+
+		apiErr := decodeError(resp)
+		if retryable(resp.StatusCode) {
+			return apiErr
+		}
+		return retry.Stop(apiErr)
+	}, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode %s %s response: %w", method, path, err)
+	}
+	return nil
+}
+
+func decodeError(resp *http.Response) *Error {
+	defer resp.Body.Close()
+	var body struct {
+		Error string `json:"error"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if json.Unmarshal(data, &body) != nil || body.Error == "" {
+		body.Error = http.StatusText(resp.StatusCode)
+	}
+	return &Error{StatusCode: resp.StatusCode, Message: body.Error}
+}
+
+// date formats t as the API's YYYY-MM-DD, or "" when zero.
+func date(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.DateOnly)
+}
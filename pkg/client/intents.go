@@ -0,0 +1,248 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/noelukwa/indexer/internal/manager/models"
+	"github.com/noelukwa/indexer/internal/pkg/ratelimits"
+)
+
+type (
+	Intent           = models.Intent
+	IntentOptions    = models.IntentOptions
+	IntentStatus     = models.IntentStatus
+	IntentTransition = models.IntentTransition
+	Reindex          = models.Reindex
+	IntentRetry      = models.RetryPolicy
+	IngestionStatus  = models.IngestionStatus
+	IntentExport     = models.IntentExport
+	IntentImport     = models.IntentImport
+	Credential       = models.Credential
+	RateLimit        = ratelimits.Status
+)
+
+// CreateIntentRequest describes an intent to index a repository. Zero
+// Since and Until index its full history. Upsert extends the date range of
+// the repository's active intent, when it has one, instead of failing.
+type CreateIntentRequest struct {
+	Repository string
+	Since      time.Time
+	Until      time.Time
+	Upsert     bool
+	IntentOptions
+}
+
+// CreateIntent asks the manager to index a repository.
+func (c *Client) CreateIntent(ctx context.Context, req CreateIntentRequest) (*Intent, error) {
+	body := struct {
+		Repository string `json:"repository"`
+		Since      string `json:"since,omitempty"`
+		Until      string `json:"until,omitempty"`
+		IntentOptions
+	}{req.Repository, date(req.Since), date(req.Until), req.IntentOptions}
+
+	path := "/intents"
+	if req.Upsert {
+		path += "?upsert=true"
+	}
+	var intent Intent
+	if err := c.send(ctx, http.MethodPost, path, body, &intent); err != nil {
+		return nil, err
+	}
+	return &intent, nil
+}
+
+// UpdateIntentRequest pauses or resumes an intent, and moves its start
+// date when Since is set.
+type UpdateIntentRequest struct {
+	IsActive bool
+	Since    time.Time
+}
+
+func (c *Client) UpdateIntent(ctx context.Context, id uuid.UUID, req UpdateIntentRequest) (*Intent, error) {
+	body := struct {
+		IsActive bool   `json:"is_active"`
+		Since    string `json:"since,omitempty"`
+	}{req.IsActive, date(req.Since)}
+
+	var intent Intent
+	if err := c.send(ctx, http.MethodPut, "/intents/"+id.String(), body, &intent); err != nil {
+		return nil, err
+	}
+	return &intent, nil
+}
+
+func (c *Client) GetIntent(ctx context.Context, id uuid.UUID) (*Intent, error) {
+	var intent Intent
+	if err := c.get(ctx, "/intents/"+id.String(), nil, &intent); err != nil {
+		return nil, err
+	}
+	return &intent, nil
+}
+
+// IntentHistory returns the intent's most recent status transitions,
+// newest first.
+func (c *Client) IntentHistory(ctx context.Context, id uuid.UUID) ([]IntentTransition, error) {
+	var history []IntentTransition
+	if err := c.get(ctx, "/intents/"+id.String()+"/history", nil, &history); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// PauseIntent stops indexing the intent.
+func (c *Client) PauseIntent(ctx context.Context, id uuid.UUID) (*Intent, error) {
+	var intent Intent
+	if err := c.do(ctx, http.MethodPost, "/intents/"+id.String()+"/pause", nil, &intent); err != nil {
+		return nil, err
+	}
+	return &intent, nil
+}
+
+// ResumeIntent starts indexing a paused intent again, from the newest
+// commit indexed for its repository once it has completed a run.
+func (c *Client) ResumeIntent(ctx context.Context, id uuid.UUID) (*Intent, error) {
+	var intent Intent
+	if err := c.do(ctx, http.MethodPost, "/intents/"+id.String()+"/resume", nil, &intent); err != nil {
+		return nil, err
+	}
+	return &intent, nil
+}
+
+// BroadcastIntent sends an active intent to the monitor now rather than on
+// discovery's next tick.
+func (c *Client) BroadcastIntent(ctx context.Context, id uuid.UUID) (*Intent, error) {
+	var intent Intent
+	if err := c.do(ctx, http.MethodPost, "/intents/"+id.String()+"/broadcast", nil, &intent); err != nil {
+		return nil, err
+	}
+	return &intent, nil
+}
+
+// ReindexIntent rebuilds the repository of an active intent into a shadow
+// that replaces its live commits once it checks out.
+func (c *Client) ReindexIntent(ctx context.Context, id uuid.UUID) (*Reindex, error) {
+	var reindex Reindex
+	if err := c.do(ctx, http.MethodPost, "/intents/"+id.String()+"/reindex", nil, &reindex); err != nil {
+		return nil, err
+	}
+	return &reindex, nil
+}
+
+// LatestReindex returns the intent's most recent reindex.
+func (c *Client) LatestReindex(ctx context.Context, id uuid.UUID) (*Reindex, error) {
+	var reindex Reindex
+	if err := c.get(ctx, "/intents/"+id.String()+"/reindex", nil, &reindex); err != nil {
+		return nil, err
+	}
+	return &reindex, nil
+}
+
+// IntentsQuery filters a listing of intents. PerPage is 100 when 0.
+type IntentsQuery struct {
+	IsActive   *bool
+	Status     IntentStatus
+	Repository string
+	PerPage    int
+}
+
+// ListIntents returns a page of the intents matching q, counting from 1.
+func (c *Client) ListIntents(ctx context.Context, q IntentsQuery, page int) (*Page[Intent], error) {
+	query := pageQuery(page, q.PerPage)
+	if q.IsActive != nil {
+		query.Set("is_active", strconv.FormatBool(*q.IsActive))
+	}
+	if q.Status != "" {
+		query.Set("status", string(q.Status))
+	}
+	if q.Repository != "" {
+		query.Set("repository_name", q.Repository)
+	}
+
+	var intents Page[Intent]
+	if err := c.get(ctx, "/intents", query, &intents); err != nil {
+		return nil, err
+	}
+	return &intents, nil
+}
+
+// Intents iterates over all intents matching q.
+func (c *Client) Intents(q IntentsQuery) *Iterator[Intent] {
+	return newIterator(func(ctx context.Context, page int) (*Page[Intent], error) {
+		return c.ListIntents(ctx, q, page)
+	})
+}
+
+// ExportIntents dumps the definitions of the intents, one per repository.
+func (c *Client) ExportIntents(ctx context.Context) (*IntentExport, error) {
+	var export IntentExport
+	if err := c.get(ctx, "/intents/export", nil, &export); err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+// ImportIntents recreates the intents of an export, skipping repositories
+// that already have one.
+func (c *Client) ImportIntents(ctx context.Context, export *IntentExport) (*IntentImport, error) {
+	var result IntentImport
+	if err := c.send(ctx, http.MethodPost, "/intents/import", export, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// CreateCredential stores a GitHub token intents can index under.
+func (c *Client) CreateCredential(ctx context.Context, name, token string) (*Credential, error) {
+	body := struct {
+		Name  string `json:"name"`
+		Token string `json:"token"`
+	}{name, token}
+
+	var credential Credential
+	if err := c.send(ctx, http.MethodPost, "/credentials", body, &credential); err != nil {
+		return nil, err
+	}
+	return &credential, nil
+}
+
+func (c *Client) ListCredentials(ctx context.Context) ([]Credential, error) {
+	var credentials []Credential
+	if err := c.get(ctx, "/credentials", nil, &credentials); err != nil {
+		return nil, err
+	}
+	return credentials, nil
+}
+
+// Status summarizes the ingestion pipeline.
+func (c *Client) Status(ctx context.Context) (*IngestionStatus, error) {
+	var status IngestionStatus
+	if err := c.get(ctx, "/admin/status", nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// RateLimits returns the GitHub quotas last seen by the monitors.
+func (c *Client) RateLimits(ctx context.Context) ([]RateLimit, error) {
+	var limits []RateLimit
+	if err := c.get(ctx, "/admin/github/rate-limit", nil, &limits); err != nil {
+		return nil, err
+	}
+	return limits, nil
+}
+
+func pageQuery(page, perPage int) url.Values {
+	if perPage <= 0 {
+		perPage = 100
+	}
+	return url.Values{
+		"page":     {strconv.Itoa(max(page, 1))},
+		"per_page": {strconv.Itoa(perPage)},
+	}
+}
@@ -0,0 +1,68 @@
+package client
+
+import "context"
+
+// Page is one page of a listing.
+type Page[T any] struct {
+	Data       []T   `json:"data"`
+	TotalCount int64 `json:"total_count"`
+	Page       int   `json:"page"`
+	PerPage    int   `json:"per_page"`
+}
+
+// Iterator walks a listing one item at a time, fetching pages as it goes:
+//
+//	it := c.Intents(client.IntentsQuery{})
+//	for it.Next(ctx) {
+//		intent := it.Value()
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type Iterator[T any] struct {
+	fetch func(ctx context.Context, page int) (*Page[T], error)
+
+	page *Page[T]
+	next int
+	i    int
+	seen int64
+	err  error
+}
+
+func newIterator[T any](fetch func(ctx context.Context, page int) (*Page[T], error)) *Iterator[T] {
+	return &Iterator[T]{fetch: fetch, next: 1, i: -1}
+}
+
+// Next advances to the next item, and reports false once the listing is
+// exhausted or a page failed to load.
+func (it *Iterator[T]) Next(ctx context.Context) bool {
+	if it.err != nil {
+		return false
+	}
+	if it.page != nil && it.i+1 < len(it.page.Data) {
+		it.i++
+		it.seen++
+		return true
+	}
+	if it.page != nil && (len(it.page.Data) == 0 || it.seen >= it.page.TotalCount) {
+		return false
+	}
+
+	page, err := it.fetch(ctx, it.next)
+	if err != nil {
+		it.err = err
+		return false
+	}
+	it.page, it.next, it.i = page, it.next+1, -1
+	return it.Next(ctx)
+}
+
+// Value is the current item.
+func (it *Iterator[T]) Value() T {
+	return it.page.Data[it.i]
+}
+
+// Err is the error that stopped the iteration, if any.
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
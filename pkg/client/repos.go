@@ -0,0 +1,322 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/noelukwa/indexer/internal/manager/models"
+)
+
+type (
+	Repository     = models.Repository
+	Author         = models.Author
+	AuthorStats    = models.AuthorStats
+	Churn          = models.Churn
+	RepoStats      = models.RepoStats
+	DailyCommits   = models.DailyCommits
+	ActivityBucket = models.ActivityBucket
+	SearchResults  = models.SearchResults
+	CommitMatch    = models.CommitMatch
+	CommitDelta    = models.CommitDelta
+	AuthorProfile  = models.AuthorProfile
+	LanguageStats  = models.LanguageStats
+	RepoActivity   = models.RepoActivity
+
+	RepositorySort   = models.RepositorySort
+	ActivityInterval = models.ActivityInterval
+)
+
+const (
+	SortByName         = models.SortByName
+	SortByStars        = models.SortByStars
+	SortByCommitCount  = models.SortByCommitCount
+	SortByLastCommitAt = models.SortByLastCommitAt
+
+	ActivityDay   = models.ActivityDay
+	ActivityWeek  = models.ActivityWeek
+	ActivityMonth = models.ActivityMonth
+)
+
+// ReposQuery filters and orders a listing of repositories. Sort is
+// SortByName when empty, and PerPage is 100 when 0.
+type ReposQuery struct {
+	Language   string
+	Sort       RepositorySort
+	Descending bool
+	PerPage    int
+}
+
+// ListRepositories returns a page of the indexed repositories matching q,
+// counting from 1.
+func (c *Client) ListRepositories(ctx context.Context, q ReposQuery, page int) (*Page[Repository], error) {
+	query := pageQuery(page, q.PerPage)
+	if q.Language != "" {
+		query.Set("language", q.Language)
+	}
+	if q.Sort != "" {
+		query.Set("sort", string(q.Sort))
+	}
+	if q.Descending {
+		query.Set("order", "desc")
+	}
+
+	var repos Page[Repository]
+	if err := c.get(ctx, "/repos", query, &repos); err != nil {
+		return nil, err
+	}
+	return &repos, nil
+}
+
+// Repositories iterates over all indexed repositories matching q.
+func (c *Client) Repositories(q ReposQuery) *Iterator[Repository] {
+	return newIterator(func(ctx context.Context, page int) (*Page[Repository], error) {
+		return c.ListRepositories(ctx, q, page)
+	})
+}
+
+// GetRepository returns an indexed repository by its owner/name, or by a
+// name it had before a rename.
+func (c *Client) GetRepository(ctx context.Context, repo string) (*Repository, error) {
+	var repository Repository
+	if err := c.get(ctx, repoPath(repo), nil, &repository); err != nil {
+		return nil, err
+	}
+	return &repository, nil
+}
+
+// DeleteRepository soft-deletes an indexed repository and its commits,
+// which are purged for good after the manager's retention. It is refused
+// while intents index the repository.
+func (c *Client) DeleteRepository(ctx context.Context, repo string) (*Repository, error) {
+	var repository Repository
+	if err := c.do(ctx, http.MethodDelete, repoPath(repo), nil, &repository); err != nil {
+		return nil, err
+	}
+	return &repository, nil
+}
+
+// ListTopCommitters returns a page of the repository's authors with the
+// most commits, counting from 1. PerPage is 100 when 0.
+func (c *Client) ListTopCommitters(ctx context.Context, repo string, page, perPage int) (*Page[AuthorStats], error) {
+	query := pageQuery(page, perPage)
+	query.Set("repo", repo)
+	owner, _, _ := strings.Cut(repo, "/")
+
+	var committers Page[AuthorStats]
+	if err := c.get(ctx, "/repos/"+url.PathEscape(owner)+"/committers", query, &committers); err != nil {
+		return nil, err
+	}
+	return &committers, nil
+}
+
+// TopCommitters iterates over the repository's authors, most commits
+// first.
+func (c *Client) TopCommitters(repo string, perPage int) *Iterator[AuthorStats] {
+	return newIterator(func(ctx context.Context, page int) (*Page[AuthorStats], error) {
+		return c.ListTopCommitters(ctx, repo, page, perPage)
+	})
+}
+
+// StatsQuery bounds repository stats to the days from Since through Until;
+// zero values leave that end open. Dedupe leaves out the commits shared
+// with an older repository in the fork network.
+type StatsQuery struct {
+	Since  time.Time
+	Until  time.Time
+	Dedupe bool
+}
+
+func (q StatsQuery) values() url.Values {
+	query := url.Values{}
+	if s := date(q.Since); s != "" {
+		query.Set("since", s)
+	}
+	if s := date(q.Until); s != "" {
+		query.Set("until", s)
+	}
+	if q.Dedupe {
+		query.Set("dedupe", "true")
+	}
+	return query
+}
+
+// Churn sums the line changes of the repository's commits. Dedupe doesn't
+// apply to churn.
+func (c *Client) Churn(ctx context.Context, repo string, q StatsQuery) (*Churn, error) {
+	var churn Churn
+	if err := c.get(ctx, repoPath(repo)+"/churn", q.values(), &churn); err != nil {
+		return nil, err
+	}
+	return &churn, nil
+}
+
+// RepoStats summarizes the repository's commits.
+func (c *Client) RepoStats(ctx context.Context, repo string, q StatsQuery) (*RepoStats, error) {
+	var stats RepoStats
+	if err := c.get(ctx, repoPath(repo)+"/stats", q.values(), &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// DailyStats returns the repository's commits per UTC day, including days
+// without any.
+func (c *Client) DailyStats(ctx context.Context, repo string, q StatsQuery) ([]DailyCommits, error) {
+	var days []DailyCommits
+	if err := c.get(ctx, repoPath(repo)+"/stats/daily", q.values(), &days); err != nil {
+		return nil, err
+	}
+	return days, nil
+}
+
+// Activity returns the repository's commits per UTC day, week or month,
+// including buckets without any. Dedupe doesn't apply to activity.
+func (c *Client) Activity(ctx context.Context, repo string, interval ActivityInterval, q StatsQuery) ([]ActivityBucket, error) {
+	query := q.values()
+	query.Del("dedupe")
+	if interval != "" {
+		query.Set("interval", string(interval))
+	}
+	var buckets []ActivityBucket
+	if err := c.get(ctx, repoPath(repo)+"/stats/activity", query, &buckets); err != nil {
+		return nil, err
+	}
+	return buckets, nil
+}
+
+// Search finds repositories, authors and commits matching query, returning
+// the given page of each. PerPage is 100 when 0.
+func (c *Client) Search(ctx context.Context, query string, page, perPage int) (*SearchResults, error) {
+	values := pageQuery(page, perPage)
+	values.Set("q", query)
+
+	var results SearchResults
+	if err := c.get(ctx, "/search", values, &results); err != nil {
+		return nil, err
+	}
+	return &results, nil
+}
+
+// GetAuthorProfile returns the author with the GitHub login username and
+// their indexed commits across repositories.
+func (c *Client) GetAuthorProfile(ctx context.Context, username string) (*AuthorProfile, error) {
+	var profile AuthorProfile
+	if err := c.get(ctx, "/authors/"+url.PathEscape(username), nil, &profile); err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// LanguageStats returns the indexed repositories and their commits per
+// language, most commits first.
+func (c *Client) LanguageStats(ctx context.Context) ([]LanguageStats, error) {
+	var stats []LanguageStats
+	if err := c.get(ctx, "/stats/languages", nil, &stats); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// ActiveReposQuery narrows a ranking of the most active repositories to
+// Language and the days from Since through Until. Until is today when zero
+// and Since 29 days before it. PerPage is 100 when 0.
+type ActiveReposQuery struct {
+	Since    time.Time
+	Until    time.Time
+	Language string
+	PerPage  int
+}
+
+// ListActiveRepos returns a page of the repositories with the most commits
+// in q's window, counting from 1.
+func (c *Client) ListActiveRepos(ctx context.Context, q ActiveReposQuery, page int) (*Page[RepoActivity], error) {
+	values := pageQuery(page, q.PerPage)
+	if s := date(q.Since); s != "" {
+		values.Set("since", s)
+	}
+	if s := date(q.Until); s != "" {
+		values.Set("until", s)
+	}
+	if q.Language != "" {
+		values.Set("language", q.Language)
+	}
+
+	var repos Page[RepoActivity]
+	if err := c.get(ctx, "/stats/repos", values, &repos); err != nil {
+		return nil, err
+	}
+	return &repos, nil
+}
+
+// CommitSearchQuery narrows a full-text search of commit messages to Repo
+// and the days from Since through Until; zero values leave them open.
+// PerPage is 100 when 0.
+type CommitSearchQuery struct {
+	Repo    string
+	Since   time.Time
+	Until   time.Time
+	PerPage int
+}
+
+// SearchCommits returns a page of the commits whose message matches query
+// by full-text search, best matches first, counting from 1.
+func (c *Client) SearchCommits(ctx context.Context, query string, q CommitSearchQuery, page int) (*Page[CommitMatch], error) {
+	values := pageQuery(page, q.PerPage)
+	values.Set("q", query)
+	if q.Repo != "" {
+		values.Set("repo", q.Repo)
+	}
+	if s := date(q.Since); s != "" {
+		values.Set("since", s)
+	}
+	if s := date(q.Until); s != "" {
+		values.Set("until", s)
+	}
+
+	var matches Page[CommitMatch]
+	if err := c.get(ctx, "/search/commits", values, &matches); err != nil {
+		return nil, err
+	}
+	return &matches, nil
+}
+
+// CommitDeltaQuery picks where CommitDelta starts: after Cursor, the
+// next_cursor of a previous delta, after the commit SinceSHA, or at Since.
+// At most one may be set. PerPage is 100 when 0.
+type CommitDeltaQuery struct {
+	Cursor   string
+	SinceSHA string
+	Since    time.Time
+	PerPage  int
+}
+
+// CommitDelta returns the repository's next commits, the oldest first.
+// Pass its NextCursor back to keep tailing the repository.
+func (c *Client) CommitDelta(ctx context.Context, repo string, q CommitDeltaQuery) (*CommitDelta, error) {
+	values := pageQuery(1, q.PerPage)
+	values.Del("page")
+	if q.Cursor != "" {
+		values.Set("cursor", q.Cursor)
+	}
+	if q.SinceSHA != "" {
+		values.Set("since_sha", q.SinceSHA)
+	}
+	if !q.Since.IsZero() {
+		values.Set("since", q.Since.UTC().Format(time.RFC3339))
+	}
+
+	var delta CommitDelta
+	if err := c.get(ctx, repoPath(repo)+"/commits/delta", values, &delta); err != nil {
+		return nil, err
+	}
+	return &delta, nil
+}
+
+// repoPath is the API path of an owner/name repository.
+func repoPath(repo string) string {
+	owner, name, _ := strings.Cut(repo, "/")
+	return "/repos/" + url.PathEscape(owner) + "/" + url.PathEscape(name)
+}